@@ -0,0 +1,192 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusreaders
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/statusreaders"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+)
+
+// WaitConditionAnnotation names the condition a custom resource is waited
+// for, as "ConditionType=Status", instead of the Ready condition.
+const WaitConditionAnnotation = "helm.sh/wait-condition"
+
+// readyConditionType is the condition a custom resource is waited for by
+// default.
+const readyConditionType = "Ready"
+
+// builtinGroupKinds are the kinds Kubernetes serves itself, which are left to
+// the other status readers.
+var builtinGroupKinds = func() map[schema.GroupKind]bool {
+	gks := make(map[schema.GroupKind]bool)
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		gks[gvk.GroupKind()] = true
+	}
+	return gks
+}()
+
+// builtinGroups are the API groups Kubernetes serves itself which are not in
+// the client-go scheme, as the ones of CustomResourceDefinitions and
+// APIServices, whose status kstatus computes from their own conditions.
+var builtinGroups = map[string]bool{
+	"apiextensions.k8s.io":   true,
+	"apiregistration.k8s.io": true,
+}
+
+type customResourceStatusReader struct {
+	genericStatusReader engine.StatusReader
+}
+
+// NewCustomResourceStatusReader returns a status reader for the custom
+// resources, which are current once their Ready condition is True, or the
+// condition of their WaitConditionAnnotation has the status it gives. The
+// custom resources without such a condition are current once their
+// observedGeneration reaches their generation, as kstatus computes it.
+func NewCustomResourceStatusReader(mapper meta.RESTMapper) engine.StatusReader {
+	genericStatusReader := statusreaders.NewGenericStatusReader(mapper, customResourceConditions)
+	return &customResourceStatusReader{
+		genericStatusReader: genericStatusReader,
+	}
+}
+
+func (c *customResourceStatusReader) Supports(gk schema.GroupKind) bool {
+	return !builtinGroupKinds[gk] && !builtinGroups[gk.Group]
+}
+
+func (c *customResourceStatusReader) ReadStatus(ctx context.Context, reader engine.ClusterReader, resource object.ObjMetadata) (*event.ResourceStatus, error) {
+	return c.genericStatusReader.ReadStatus(ctx, reader, resource)
+}
+
+func (c *customResourceStatusReader) ReadStatusForObject(ctx context.Context, reader engine.ClusterReader, resource *unstructured.Unstructured) (*event.ResourceStatus, error) {
+	return c.genericStatusReader.ReadStatusForObject(ctx, reader, resource)
+}
+
+// ParseWaitCondition parses the value of the WaitConditionAnnotation. The
+// status defaults to True.
+func ParseWaitCondition(value string) (condType, condStatus string, err error) {
+	condType, condStatus, found := strings.Cut(value, "=")
+	condType = strings.TrimSpace(condType)
+	condStatus = strings.TrimSpace(condStatus)
+	if !found {
+		condStatus = string(corev1.ConditionTrue)
+	}
+	if condType == "" || condStatus == "" {
+		return "", "", fmt.Errorf("invalid %s annotation %q, expected ConditionType=Status", WaitConditionAnnotation, value)
+	}
+	return condType, condStatus, nil
+}
+
+// genericStatus returns the status kstatus computes for u from its deletion,
+// its observedGeneration and its Reconciling and Stalled conditions, leaving
+// out its other conditions, as kstatus would use its Ready condition as well.
+func genericStatus(u *unstructured.Unstructured) (*status.Result, error) {
+	u = u.DeepCopy()
+	conditions, found, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil || !found {
+		return status.Compute(u)
+	}
+	var kept []interface{}
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok {
+			switch m["type"] {
+			case string(status.ConditionReconciling), string(status.ConditionStalled):
+				kept = append(kept, c)
+			}
+		}
+	}
+	if err := unstructured.SetNestedSlice(u.Object, kept, "status", "conditions"); err != nil {
+		return nil, err
+	}
+	return status.Compute(u)
+}
+
+// customResourceConditions computes the status of a custom resource from its
+// deletion, its observedGeneration and its Reconciling and Stalled conditions,
+// as kstatus does, and then waits for its Ready condition, or the one of its
+// WaitConditionAnnotation. A custom resource without a Ready condition or
+// annotation has the status kstatus computes for it, with all its conditions.
+func customResourceConditions(u *unstructured.Unstructured) (*status.Result, error) {
+	var err error
+	condType, condStatus := readyConditionType, string(corev1.ConditionTrue)
+	value, annotated := u.GetAnnotations()[WaitConditionAnnotation]
+	if annotated {
+		if condType, condStatus, err = ParseWaitCondition(value); err != nil {
+			return nil, err
+		}
+	}
+
+	objc, err := status.GetObjectWithConditions(u.UnstructuredContent())
+	if err != nil {
+		return nil, err
+	}
+	if !annotated && !slices.ContainsFunc(objc.Status.Conditions, func(c status.BasicCondition) bool { return c.Type == condType }) {
+		return status.Compute(u)
+	}
+
+	res, err := genericStatus(u)
+	if err != nil || res.Status != status.CurrentStatus {
+		return res, err
+	}
+	for _, c := range objc.Status.Conditions {
+		if c.Type != condType {
+			continue
+		}
+		if strings.EqualFold(string(c.Status), condStatus) {
+			return &status.Result{
+				Status:     status.CurrentStatus,
+				Message:    fmt.Sprintf("Condition %s is %s", condType, c.Status),
+				Conditions: []status.Condition{},
+			}, nil
+		}
+		message := fmt.Sprintf("Waiting for condition %s to be %s, it is %s", condType, condStatus, c.Status)
+		if c.Message != "" {
+			message += ": " + c.Message
+		}
+		return newInProgressResult(c.Reason, message), nil
+	}
+
+	return newInProgressResult("ConditionMissing", fmt.Sprintf("Waiting for condition %s to be %s", condType, condStatus)), nil
+}
+
+func newInProgressResult(reason, message string) *status.Result {
+	return &status.Result{
+		Status:  status.InProgressStatus,
+		Message: message,
+		Conditions: []status.Condition{
+			{
+				Type:    status.ConditionReconciling,
+				Status:  corev1.ConditionTrue,
+				Reason:  reason,
+				Message: message,
+			},
+		},
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusreaders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+)
+
+func customResource(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &u.Object))
+	return u
+}
+
+func TestCustomResourceConditions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		manifest       string
+		expectedStatus status.Status
+	}{
+		{
+			name: "custom resource without status is current",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 1
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "Ready condition True is current",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 2
+status:
+  observedGeneration: 2
+  conditions:
+  - type: Ready
+    status: "True"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "Ready condition False is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 2
+status:
+  observedGeneration: 2
+  conditions:
+  - type: Ready
+    status: "False"
+    reason: Provisioning
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "Ready condition Unknown is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+status:
+  conditions:
+  - type: Ready
+    status: Unknown
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "Ready condition True of an older generation is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 3
+status:
+  observedGeneration: 2
+  conditions:
+  - type: Ready
+    status: "True"
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "observed generation behind without Ready condition is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 3
+status:
+  observedGeneration: 2
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "observed generation reached without Ready condition is current",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  generation: 3
+status:
+  observedGeneration: 3
+  conditions:
+  - type: Synced
+    status: "False"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "annotated condition with the expected status is current",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  annotations:
+    helm.sh/wait-condition: Synced=True
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+  - type: Synced
+    status: "True"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "annotated condition with another status is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  annotations:
+    helm.sh/wait-condition: Synced=True
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+  - type: Synced
+    status: "False"
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "annotated condition expected to be False",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  annotations:
+    helm.sh/wait-condition: Degraded=false
+status:
+  conditions:
+  - type: Degraded
+    status: "False"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "annotated condition defaults to True",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  annotations:
+    helm.sh/wait-condition: Available
+status:
+  conditions:
+  - type: Available
+    status: "True"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "missing annotated condition is in progress",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  annotations:
+    helm.sh/wait-condition: Synced=True
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "established CustomResourceDefinition is current",
+			manifest: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+  generation: 1
+status:
+  conditions:
+  - type: NamesAccepted
+    status: "True"
+  - type: Established
+    status: "True"
+`,
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "CustomResourceDefinition not yet established is in progress",
+			manifest: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+  generation: 1
+status:
+  conditions:
+  - type: NamesAccepted
+    status: "True"
+`,
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "stalled custom resource has failed",
+			manifest: `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+status:
+  conditions:
+  - type: Stalled
+    status: "True"
+  - type: Ready
+    status: "False"
+`,
+			expectedStatus: status.FailedStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := customResourceConditions(customResource(t, tt.manifest))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.Status, res.Message)
+		})
+	}
+}
+
+func TestCustomResourceConditionsInvalidAnnotation(t *testing.T) {
+	t.Parallel()
+	for _, value := range []string{"", "=True", "Synced="} {
+		u := customResource(t, `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+`)
+		u.SetAnnotations(map[string]string{WaitConditionAnnotation: value})
+		_, err := customResourceConditions(u)
+		assert.ErrorContains(t, err, "invalid helm.sh/wait-condition annotation", value)
+	}
+}
+
+func TestCustomResourceStatusReaderSupports(t *testing.T) {
+	t.Parallel()
+	sr := NewCustomResourceStatusReader(testutil.NewFakeRESTMapper())
+	assert.True(t, sr.Supports(schema.GroupKind{Group: "example.com", Kind: "Widget"}))
+	assert.False(t, sr.Supports(schema.GroupKind{Group: "apps", Kind: "Deployment"}))
+	assert.False(t, sr.Supports(schema.GroupKind{Kind: "ConfigMap"}))
+	assert.False(t, sr.Supports(schema.GroupKind{Group: "batch", Kind: "Job"}))
+	assert.False(t, sr.Supports(schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}))
+	assert.False(t, sr.Supports(schema.GroupKind{Group: "apiregistration.k8s.io", Kind: "APIService"}))
+}
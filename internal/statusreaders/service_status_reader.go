@@ -0,0 +1,131 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusreaders
+
+import (
+	"context"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/statusreaders"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+)
+
+// WaitForLoadBalancerAnnotation is the annotation that, when set to "false"
+// on a LoadBalancer Service, skips waiting for its load balancer to be
+// provisioned.
+const WaitForLoadBalancerAnnotation = "helm.sh/wait-for-lb"
+
+type customServiceStatusReader struct {
+	genericStatusReader engine.StatusReader
+}
+
+// NewCustomServiceStatusReader returns a status reader for Services, which
+// kstatus considers current without waiting for their load balancer. A
+// LoadBalancer Service is only current once it has load balancer ingress,
+// unless its WaitForLoadBalancerAnnotation is "false".
+func NewCustomServiceStatusReader(mapper meta.RESTMapper) engine.StatusReader {
+	genericStatusReader := statusreaders.NewGenericStatusReader(mapper, serviceConditions)
+	return &customServiceStatusReader{
+		genericStatusReader: genericStatusReader,
+	}
+}
+
+func (s *customServiceStatusReader) Supports(gk schema.GroupKind) bool {
+	return gk == corev1.SchemeGroupVersion.WithKind("Service").GroupKind()
+}
+
+func (s *customServiceStatusReader) ReadStatus(ctx context.Context, reader engine.ClusterReader, resource object.ObjMetadata) (*event.ResourceStatus, error) {
+	return s.genericStatusReader.ReadStatus(ctx, reader, resource)
+}
+
+func (s *customServiceStatusReader) ReadStatusForObject(ctx context.Context, reader engine.ClusterReader, resource *unstructured.Unstructured) (*event.ResourceStatus, error) {
+	return s.genericStatusReader.ReadStatusForObject(ctx, reader, resource)
+}
+
+func serviceConditions(u *unstructured.Unstructured) (*status.Result, error) {
+	result, err := status.Compute(u)
+	if err != nil || result.Status != status.CurrentStatus {
+		return result, err
+	}
+
+	svc := &corev1.Service{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), svc); err != nil {
+		return nil, err
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.GetAnnotations()[WaitForLoadBalancerAnnotation] == "false" {
+		return result, nil
+	}
+	if LoadBalancerIngressReady(svc) {
+		return result, nil
+	}
+
+	message := "Load balancer ingress not assigned for every IP family"
+	return &status.Result{
+		Status:  status.InProgressStatus,
+		Message: message,
+		Conditions: []status.Condition{
+			{
+				Type:    status.ConditionReconciling,
+				Status:  corev1.ConditionTrue,
+				Reason:  "LoadBalancerPending",
+				Message: message,
+			},
+		},
+	}, nil
+}
+
+// LoadBalancerIngressReady checks that a LoadBalancer Service has been given an
+// ingress point. Services requiring dual-stack need one ingress IP per IP
+// family, unless the load balancer is reached through a hostname, which is
+// expected to resolve to all of them.
+func LoadBalancerIngressReady(s *corev1.Service) bool {
+	families := make(map[corev1.IPFamily]bool)
+	for _, ingress := range s.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			return true
+		}
+		if ip := net.ParseIP(ingress.IP); ip != nil {
+			if ip.To4() != nil {
+				families[corev1.IPv4Protocol] = true
+			} else {
+				families[corev1.IPv6Protocol] = true
+			}
+		}
+	}
+	if len(families) == 0 {
+		return false
+	}
+
+	if s.Spec.IPFamilyPolicy == nil || *s.Spec.IPFamilyPolicy != corev1.IPFamilyPolicyRequireDualStack {
+		return true
+	}
+	for _, family := range s.Spec.IPFamilies {
+		if !families[family] {
+			return false
+		}
+	}
+	return true
+}
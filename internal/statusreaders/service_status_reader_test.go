@@ -0,0 +1,88 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusreaders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+)
+
+func TestServiceConditions(t *testing.T) {
+	requireDualStack := v1.IPFamilyPolicyRequireDualStack
+	newService := func(spec v1.ServiceSpec, annotations map[string]string, ingress ...v1.LoadBalancerIngress) *v1.Service {
+		spec.ClusterIP = "10.0.0.1"
+		return &v1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "svc", Annotations: annotations},
+			Spec:       spec,
+			Status:     v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		service        *v1.Service
+		expectedStatus status.Status
+	}{
+		{
+			name:           "cluster ip service returns current status",
+			service:        newService(v1.ServiceSpec{Type: v1.ServiceTypeClusterIP}, nil),
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name:           "load balancer without ingress returns in progress status",
+			service:        newService(v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer}, nil),
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name:           "load balancer with ingress returns current status",
+			service:        newService(v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer}, nil, v1.LoadBalancerIngress{IP: "203.0.113.10"}),
+			expectedStatus: status.CurrentStatus,
+		},
+		{
+			name: "dual-stack required load balancer missing an ipv6 ingress returns in progress status",
+			service: newService(v1.ServiceSpec{
+				Type:           v1.ServiceTypeLoadBalancer,
+				IPFamilyPolicy: &requireDualStack,
+				IPFamilies:     []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+			}, nil, v1.LoadBalancerIngress{IP: "203.0.113.10"}),
+			expectedStatus: status.InProgressStatus,
+		},
+		{
+			name: "load balancer wait disabled by annotation returns current status",
+			service: newService(v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+				map[string]string{WaitForLoadBalancerAnnotation: "false"}),
+			expectedStatus: status.CurrentStatus,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			us, err := toUnstructured(t, tc.service)
+			assert.NoError(t, err)
+			result, err := serviceConditions(us)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, result.Status)
+		})
+	}
+}
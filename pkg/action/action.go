@@ -84,15 +84,64 @@ type Configuration struct {
 	RegistryClient *registry.Client
 
 	// Capabilities describes the capabilities of the Kubernetes cluster.
+	// Once actions are running, it is only replaced as a whole, by
+	// RefreshCapabilities.
 	Capabilities *chartutil.Capabilities
 
+	// CapabilitiesRefreshFunc, if set, is called with the new capabilities of
+	// the cluster whenever a refresh changes them.
+	CapabilitiesRefreshFunc func(caps *chartutil.Capabilities)
+
 	// CustomTemplateFuncs is defined by users to provide custom template funcs
 	CustomTemplateFuncs template.FuncMap
 
+	// FuncMapOptions adds functions to the templates and disables some of
+	// them, for the programs embedding Helm.
+	FuncMapOptions engine.FuncMapOptions
+
 	// HookOutputFunc called with container name and returns and expects writer that will receive the log output.
 	HookOutputFunc func(namespace, pod, container string) io.Writer
 
+	// ReleaseRecorder, if set, is notified of every install, upgrade,
+	// rollback and uninstall once it succeeded or failed. Dry runs are not
+	// recorded.
+	ReleaseRecorder ReleaseRecorder
+
+	// RequireRecord fails operations whose event could not be recorded by
+	// the ReleaseRecorder. By default, the failure is only logged.
+	RequireRecord bool
+
+	// FieldManager, if set, is the name of the manager of the fields set on
+	// the resources Helm creates, updates or patches. It is passed to the
+	// Kubernetes client created by Init, which otherwise uses its own
+	// default, and recorded in the info of the releases.
+	FieldManager string
+
+	// NamespaceDefaultsConfigMap, if set, is the name of the ConfigMap in the
+	// namespace of a release whose "values.yaml" key holds default values
+	// for the installs and upgrades of the namespace. They are layered
+	// between the values of the chart and the values of the user.
+	NamespaceDefaultsConfigMap string
+
+	// NamespaceDefaultsRequired fails installs and upgrades when the
+	// NamespaceDefaultsConfigMap does not exist. By default, they go on
+	// without namespace defaults.
+	NamespaceDefaultsRequired bool
+
+	// DeferStorageMigration leaves the schema of the SQL storage as it is
+	// when Init creates the SQL driver, for StorageMigrate to list or apply
+	// its pending migrations. By default, the driver migrates it.
+	DeferStorageMigration bool
+
 	mutex sync.Mutex
+
+	// logger is the logger set by InitWithRESTConfig, see log.
+	logger *slog.Logger
+
+	// capabilitiesMutex guards Capabilities against refreshes.
+	capabilitiesMutex sync.RWMutex
+	// refreshMutex serializes refreshes of the capabilities.
+	refreshMutex sync.Mutex
 }
 
 const (
@@ -170,13 +219,58 @@ func splitAndDeannotate(postrendered string) (map[string]string, error) {
 	return reconstructed, nil
 }
 
+// renderOptions configures the rendering of the templates. The zero value
+// renders as usual.
+type renderOptions struct {
+	// deterministic and randomSeed configure a deterministic render, see
+	// engine.Engine.Deterministic.
+	deterministic bool
+	randomSeed    *int64
+	// strict fails the render on values which are not defined, see
+	// engine.Engine.Strict.
+	strict bool
+}
+
+// renderTemplates renders the templates of a chart, or only the targets if
+// they are set, and returns their output by path.
+func (cfg *Configuration) renderTemplates(ch *chart.Chart, values chartutil.Values, targets func(string) bool, interactWithRemote, enableDNS bool, opts renderOptions) (map[string]string, error) {
+	// A `helm template` should not talk to the remote cluster. However, commands with the flag
+	// `--dry-run` with the value of `false`, `none`, or `server` should try to interact with the cluster.
+	// It may break in interesting and exotic ways because other data (e.g. discovery) is mocked.
+	if interactWithRemote && cfg.RESTClientGetter != nil {
+		restConfig, err := cfg.RESTClientGetter.ToRESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		e := engine.New(restConfig)
+		e.EnableDNS = enableDNS
+		e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
+		e.FuncMapOptions = cfg.FuncMapOptions
+		e.Targets = targets
+		e.Deterministic, e.RandomSeed = opts.deterministic, opts.randomSeed
+		e.Strict = opts.strict
+
+		return e.Render(ch, values)
+	}
+
+	var e engine.Engine
+	e.EnableDNS = enableDNS
+	e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
+	e.FuncMapOptions = cfg.FuncMapOptions
+	e.Targets = targets
+	e.Deterministic, e.RandomSeed = opts.deterministic, opts.randomSeed
+	e.Strict = opts.strict
+
+	return e.Render(ch, values)
+}
+
 // renderResources renders the templates in a chart
 //
 // TODO: This function is badly in need of a refactor.
 // TODO: As part of the refactor the duplicate code in cmd/helm/template.go should be removed
 //
 //	This code has to do with writing files to disk.
-func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, interactWithRemote, enableDNS, hideSecret bool) ([]*release.Hook, *bytes.Buffer, string, error) {
+func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, interactWithRemote, enableDNS, hideSecret bool, showOnly []string, opts renderOptions) ([]*release.Hook, *bytes.Buffer, string, error) {
 	hs := []*release.Hook{}
 	b := bytes.NewBuffer(nil)
 
@@ -191,32 +285,16 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 		}
 	}
 
-	var files map[string]string
-	var err2 error
-
-	// A `helm template` should not talk to the remote cluster. However, commands with the flag
-	// `--dry-run` with the value of `false`, `none`, or `server` should try to interact with the cluster.
-	// It may break in interesting and exotic ways because other data (e.g. discovery) is mocked.
-	if interactWithRemote && cfg.RESTClientGetter != nil {
-		restConfig, err := cfg.RESTClientGetter.ToRESTConfig()
-		if err != nil {
-			return hs, b, "", err
-		}
-		e := engine.New(restConfig)
-		e.EnableDNS = enableDNS
-		e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
-
-		files, err2 = e.Render(ch, values)
-	} else {
-		var e engine.Engine
-		e.EnableDNS = enableDNS
-		e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
-
-		files, err2 = e.Render(ch, values)
+	// Only the templates selected with showOnly need to be executed. This does
+	// not hold with a post-renderer, which sees (and may change) everything.
+	var targets func(string) bool
+	if len(showOnly) > 0 && pr == nil {
+		targets = showOnlyTargets(showOnly)
 	}
 
-	if err2 != nil {
-		return hs, b, "", err2
+	files, err := cfg.renderTemplates(ch, values, targets, interactWithRemote, enableDNS, opts)
+	if err != nil {
+		return hs, b, "", err
 	}
 
 	// NOTES.txt gets rendered like all the other files, but because it's not a hook nor a resource,
@@ -282,7 +360,7 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 			}
 			fmt.Fprintf(b, "---\n# Source: %s\n%s\n", name, content)
 		}
-		return hs, b, "", err
+		return hs, b, "", engine.WithErrorHints(err, nil)
 	}
 
 	// Aggregate all valid manifests into one big doc.
@@ -329,6 +407,25 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 	return hs, b, notes, nil
 }
 
+// showOnlyTargets returns a template selector for the given --show-only
+// patterns. Patterns are relative to the chart directory, so the chart name
+// leading every template name is left out when matching. Notes are always
+// selected.
+func showOnlyTargets(patterns []string) func(string) bool {
+	return func(name string) bool {
+		if strings.HasSuffix(name, notesFileSuffix) {
+			return true
+		}
+		_, rel, _ := strings.Cut(name, "/")
+		for _, p := range patterns {
+			if matched, _ := filepath.Match(filepath.ToSlash(p), rel); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // RESTClientGetter gets the rest client
 type RESTClientGetter interface {
 	ToRESTConfig() (*rest.Config, error)
@@ -336,11 +433,31 @@ type RESTClientGetter interface {
 	ToRESTMapper() (meta.RESTMapper, error)
 }
 
-// capabilities builds a Capabilities from discovery information.
+// getCapabilities returns a copy of the cached Capabilities, building them
+// from discovery information the first time.
 func (cfg *Configuration) getCapabilities() (*chartutil.Capabilities, error) {
-	if cfg.Capabilities != nil {
-		return cfg.Capabilities, nil
+	cfg.capabilitiesMutex.RLock()
+	caps := cfg.Capabilities
+	cfg.capabilitiesMutex.RUnlock()
+	if caps != nil {
+		return copyCapabilities(caps), nil
+	}
+
+	caps, err := cfg.discoverCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	cfg.capabilitiesMutex.Lock()
+	if cfg.Capabilities == nil {
+		cfg.Capabilities = caps
 	}
+	caps = cfg.Capabilities
+	cfg.capabilitiesMutex.Unlock()
+	return copyCapabilities(caps), nil
+}
+
+// discoverCapabilities builds a Capabilities from discovery information.
+func (cfg *Configuration) discoverCapabilities() (*chartutil.Capabilities, error) {
 	dc, err := cfg.RESTClientGetter.ToDiscoveryClient()
 	if err != nil {
 		return nil, fmt.Errorf("could not get Kubernetes discovery client: %w", err)
@@ -359,14 +476,14 @@ func (cfg *Configuration) getCapabilities() (*chartutil.Capabilities, error) {
 	apiVersions, err := GetVersionSet(dc)
 	if err != nil {
 		if discovery.IsGroupDiscoveryFailedError(err) {
-			slog.Warn("the kubernetes server has an orphaned API service", slog.Any("error", err))
-			slog.Warn("to fix this, kubectl delete apiservice <service-name>")
+			cfg.log().Warn("the kubernetes server has an orphaned API service", slog.Any("error", err))
+			cfg.log().Warn("to fix this, kubectl delete apiservice <service-name>")
 		} else {
 			return nil, fmt.Errorf("could not get apiVersions from Kubernetes: %w", err)
 		}
 	}
 
-	cfg.Capabilities = &chartutil.Capabilities{
+	return &chartutil.Capabilities{
 		APIVersions: apiVersions,
 		KubeVersion: chartutil.KubeVersion{
 			Version: kubeVersion.GitVersion,
@@ -374,8 +491,7 @@ func (cfg *Configuration) getCapabilities() (*chartutil.Capabilities, error) {
 			Minor:   kubeVersion.Minor,
 		},
 		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
-	}
-	return cfg.Capabilities, nil
+	}, nil
 }
 
 // KubernetesClientSet creates a new kubernetes ClientSet based on the configuration
@@ -459,13 +575,53 @@ func GetVersionSet(client discovery.ServerResourcesInterface) (chartutil.Version
 // recordRelease with an update operation in case reuse has been set.
 func (cfg *Configuration) recordRelease(r *release.Release) {
 	if err := cfg.Releases.Update(r); err != nil {
-		slog.Warn("failed to update release", "name", r.Name, "revision", r.Version, slog.Any("error", err))
+		cfg.log().Warn("failed to update release", "name", r.Name, "revision", r.Version, slog.Any("error", err))
+	}
+}
+
+// MaxFieldManagerLength is the maximum length of the name of a field manager
+// accepted by the Kubernetes API.
+const MaxFieldManagerLength = 128
+
+// ValidateFieldManager checks that name can be used as the name of a field
+// manager.
+func ValidateFieldManager(name string) error {
+	if name == "" {
+		return errors.New("the field manager name cannot be empty")
 	}
+	if len(name) > MaxFieldManagerLength {
+		return fmt.Errorf("the field manager name %q is longer than %d characters", name, MaxFieldManagerLength)
+	}
+	return nil
 }
 
 // Init initializes the action configuration
 func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namespace, helmDriver string) error {
+	return cfg.init(getter, namespace, helmDriver)
+}
+
+// InitWithRESTConfig initializes the action configuration like Init, from a
+// rest.Config the caller already has, as the ones of controller-runtime. The
+// Kubernetes client, the discovery of the capabilities and the storage driver
+// are built from it, without reading any kubeconfig file. The logger, if not
+// nil, is used instead of the default one for the logs of the actions run with
+// the configuration, including those of its initialization.
+func (cfg *Configuration) InitWithRESTConfig(config *rest.Config, namespace, helmDriver string, logger *slog.Logger) error {
+	if config == nil {
+		return errors.New("a rest config is required")
+	}
+	cfg.logger = logger
+	return cfg.init(newRESTConfigGetter(config, namespace), namespace, helmDriver)
+}
+
+func (cfg *Configuration) init(getter genericclioptions.RESTClientGetter, namespace, helmDriver string) error {
+	if cfg.FieldManager != "" {
+		if err := ValidateFieldManager(cfg.FieldManager); err != nil {
+			return err
+		}
+	}
 	kc := kube.New(getter)
+	kc.FieldManager = cfg.FieldManager
 
 	lazyClient := &lazyClient{
 		namespace: namespace,
@@ -496,9 +652,17 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 		d.SetNamespace(namespace)
 		store = storage.Init(d)
 	case "sql":
+		opts, err := sqlOptionsFromEnv()
+		if err != nil {
+			return err
+		}
+		if cfg.DeferStorageMigration {
+			opts = append(opts, driver.WithDeferredMigration())
+		}
 		d, err := driver.NewSQL(
 			os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"),
 			namespace,
+			opts...,
 		)
 		if err != nil {
 			return fmt.Errorf("unable to instantiate SQL driver: %w", err)
@@ -516,6 +680,14 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	return nil
 }
 
+// log returns the logger of the configuration, or the default one.
+func (cfg *Configuration) log() *slog.Logger {
+	if cfg.logger != nil {
+		return cfg.logger
+	}
+	return slog.Default()
+}
+
 // SetHookOutputFunc sets the HookOutputFunc on the Configuration.
 func (cfg *Configuration) SetHookOutputFunc(hookOutputFunc func(_, _, _ string) io.Writer) {
 	cfg.HookOutputFunc = hookOutputFunc
@@ -22,12 +22,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 
 	"helm.sh/helm/v4/internal/logging"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
@@ -357,6 +361,107 @@ func TestConfiguration_Init(t *testing.T) {
 	}
 }
 
+func TestConfiguration_InitFieldManager(t *testing.T) {
+	cfg := &Configuration{}
+	require.NoError(t, cfg.Init(nil, "default", "memory"))
+	assert.Empty(t, cfg.KubeClient.(*kube.Client).FieldManager, "the client default must be kept when no field manager is configured")
+
+	cfg = &Configuration{FieldManager: "argocd-controller"}
+	require.NoError(t, cfg.Init(nil, "default", "memory"))
+	assert.Equal(t, "argocd-controller", cfg.KubeClient.(*kube.Client).FieldManager)
+
+	cfg = &Configuration{FieldManager: strings.Repeat("x", MaxFieldManagerLength+1)}
+	err := cfg.Init(nil, "default", "memory")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is longer than 128 characters")
+}
+
+func TestConfiguration_InitWithRESTConfig(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"31","gitVersion":"v1.31.2"}`)
+		case "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":["v1"]}`)
+		case "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`)
+		case "/api/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"secrets","namespaced":true,"kind":"Secret","verbs":["list"]}]}`)
+		case "/apis/apps/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[{"name":"deployments","namespaced":true,"kind":"Deployment","verbs":["list"]}]}`)
+		case "/api/v1/namespaces/team/secrets":
+			fmt.Fprint(w, `{"kind":"SecretList","apiVersion":"v1","items":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	cfg := &Configuration{}
+	require.NoError(t, cfg.InitWithRESTConfig(&rest.Config{Host: srv.URL}, "team", "secret", logger))
+	assert.IsType(t, &driver.Secrets{}, cfg.Releases.Driver)
+	assert.Empty(t, requests, "the clients are created lazily")
+
+	ns, overridden, err := cfg.KubeClient.(*kube.Client).Factory.ToRawKubeConfigLoader().Namespace()
+	require.NoError(t, err)
+	assert.Equal(t, "team", ns)
+	assert.True(t, overridden)
+
+	caps, err := cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.31.2", caps.KubeVersion.Version)
+	assert.True(t, caps.APIVersions.Has("apps/v1/Deployment"))
+
+	releases, err := cfg.Releases.ListReleases()
+	require.NoError(t, err)
+	assert.Empty(t, releases)
+	assert.Contains(t, requests, "/api/v1/namespaces/team/secrets")
+
+	cfg.Releases = storage.Init(driver.NewMemory())
+	cfg.recordRelease(&release.Release{Name: "missing", Version: 1})
+	assert.Contains(t, logs.String(), "failed to update release")
+
+	// The actions run with the configuration log through it as well.
+	cfg.emitProgress(func(ProgressEvent) { panic("boom") }, ProgressRender, "missing", "")
+	assert.Contains(t, logs.String(), "progress callback panicked")
+}
+
+func TestConfiguration_InitWithRESTConfigDrivers(t *testing.T) {
+	config := &rest.Config{Host: "https://127.0.0.1:1"}
+	for helmDriver, expected := range map[string]interface{}{
+		"":          &driver.Secrets{},
+		"configmap": &driver.ConfigMaps{},
+		"memory":    &driver.Memory{},
+	} {
+		cfg := &Configuration{}
+		require.NoError(t, cfg.InitWithRESTConfig(config, "default", helmDriver, nil))
+		assert.IsType(t, expected, cfg.Releases.Driver, helmDriver)
+	}
+
+	cfg := &Configuration{}
+	assert.ErrorContains(t, cfg.InitWithRESTConfig(config, "default", "sql", nil), "unable to instantiate SQL driver")
+	assert.ErrorContains(t, cfg.InitWithRESTConfig(config, "default", "someDriver", nil), `unknown driver "someDriver"`)
+	assert.Error(t, cfg.InitWithRESTConfig(nil, "default", "memory", nil))
+}
+
+func TestValidateFieldManager(t *testing.T) {
+	assert.NoError(t, ValidateFieldManager("helm"))
+	assert.NoError(t, ValidateFieldManager(strings.Repeat("x", MaxFieldManagerLength)))
+	assert.EqualError(t, ValidateFieldManager(""), "the field manager name cannot be empty")
+	assert.Error(t, ValidateFieldManager(strings.Repeat("x", MaxFieldManagerLength+1)))
+}
+
 func TestGetVersionSet(t *testing.T) {
 	client := fakeclientset.NewClientset()
 
@@ -788,7 +893,7 @@ func TestRenderResources_PostRenderer_Success(t *testing.T) {
 
 	hooks, buf, notes, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		mockPR, false, false, false,
+		mockPR, false, false, false, nil, renderOptions{},
 	)
 
 	assert.NoError(t, err)
@@ -831,7 +936,7 @@ func TestRenderResources_PostRenderer_Error(t *testing.T) {
 
 	_, _, _, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		mockPR, false, false, false,
+		mockPR, false, false, false, nil, renderOptions{},
 	)
 
 	assert.Error(t, err)
@@ -859,7 +964,7 @@ func TestRenderResources_PostRenderer_MergeError(t *testing.T) {
 
 	_, _, _, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		mockPR, false, false, false,
+		mockPR, false, false, false, nil, renderOptions{},
 	)
 
 	assert.Error(t, err)
@@ -881,7 +986,7 @@ func TestRenderResources_PostRenderer_SplitError(t *testing.T) {
 
 	_, _, _, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		mockPR, false, false, false,
+		mockPR, false, false, false, nil, renderOptions{},
 	)
 
 	assert.Error(t, err)
@@ -902,7 +1007,7 @@ func TestRenderResources_PostRenderer_Integration(t *testing.T) {
 
 	hooks, buf, notes, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		mockPR, false, false, false,
+		mockPR, false, false, false, nil, renderOptions{},
 	)
 
 	assert.NoError(t, err)
@@ -938,7 +1043,7 @@ func TestRenderResources_NoPostRenderer(t *testing.T) {
 
 	hooks, buf, notes, err := cfg.renderResources(
 		ch, values, "test-release", "", false, false, false,
-		nil, false, false, false,
+		nil, false, false, false, nil, renderOptions{},
 	)
 
 	assert.NoError(t, err)
@@ -946,3 +1051,21 @@ func TestRenderResources_NoPostRenderer(t *testing.T) {
 	assert.NotNil(t, buf)
 	assert.Equal(t, "", notes)
 }
+
+func TestRenderResources_ShowOnly(t *testing.T) {
+	cfg := actionConfigFixture(t)
+
+	ch := buildChart(withSampleTemplates(), withNotes("some notes"))
+	ch.Templates = append(ch.Templates, &chart.File{Name: "templates/broken", Data: []byte(`{{ fail "not selected" }}`)})
+	values := map[string]interface{}{}
+
+	hooks, buf, notes, err := cfg.renderResources(
+		ch, values, "test-release", "", false, false, false,
+		nil, false, false, false, []string{"templates/with-*"}, renderOptions{},
+	)
+
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+	assert.Equal(t, "---\n# Source: hello/templates/with-partials\nhello: Earth\n", buf.String())
+	assert.Equal(t, "some notes", notes)
+}
@@ -0,0 +1,409 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/kube"
+	"helm.sh/helm/v4/pkg/registry"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// ErrAdoptDiffs is returned when adopting resources which differ from the
+// rendered chart, or which do not exist, without ForceAdoptDiffs.
+var ErrAdoptDiffs = errors.New("resources differ from the rendered chart or do not exist")
+
+// AdoptResourceStatus is the result of the comparison of a rendered resource
+// with the live one.
+type AdoptResourceStatus string
+
+const (
+	// AdoptMatch is a live resource matching the rendered one.
+	AdoptMatch AdoptResourceStatus = "match"
+	// AdoptDiffers is a live resource differing from the rendered one.
+	AdoptDiffers AdoptResourceStatus = "differs"
+	// AdoptMissing is a rendered resource which does not exist.
+	AdoptMissing AdoptResourceStatus = "missing"
+)
+
+// AdoptedResource is a resource of the chart compared by Adopt.
+type AdoptedResource struct {
+	Kind      string              `json:"kind"`
+	Name      string              `json:"name"`
+	Namespace string              `json:"namespace,omitempty"`
+	Status    AdoptResourceStatus `json:"status"`
+	// Differences are the fields of the live resource differing from the
+	// rendered one, such as "spec.replicas: rendered 3, live 2".
+	Differences []string `json:"differences,omitempty"`
+}
+
+// AdoptReport is the result of Adopt.
+type AdoptReport struct {
+	// Release is the release recorded, or which would be recorded by a dry
+	// run.
+	Release   *release.Release  `json:"-"`
+	Resources []AdoptedResource `json:"resources"`
+}
+
+// Adopt is the action for bringing existing resources, not managed by Helm,
+// under a new release without redeploying them.
+//
+// It provides the implementation of 'helm adopt'.
+type Adopt struct {
+	cfg *Configuration
+
+	ChartPathOptions
+
+	// Devel indicates that the operation is done in devel mode.
+	Devel bool
+	// Namespace is the namespace of the release.
+	Namespace string
+	// DryRun compares the resources without changing them nor recording the
+	// release.
+	DryRun bool
+	// ForceAdoptDiffs applies the rendered chart to the resources which
+	// differ from it, and creates the missing ones, instead of failing with
+	// ErrAdoptDiffs.
+	ForceAdoptDiffs bool
+	// IgnoreFields are the dotted paths of the fields, such as "spec.replicas",
+	// not compared in addition to the status and the metadata set by the
+	// cluster.
+	IgnoreFields []string
+	// SkipSchemaValidation determines if JSON schema validation is disabled.
+	SkipSchemaValidation bool
+	// DisableOpenAPIValidation controls whether OpenAPI validation is enforced.
+	DisableOpenAPIValidation bool
+	// Description is the description of the release.
+	Description string
+	Labels      map[string]string
+}
+
+// NewAdopt creates a new Adopt object with the given configuration.
+func NewAdopt(cfg *Configuration) *Adopt {
+	a := &Adopt{
+		cfg: cfg,
+	}
+	a.registryClient = cfg.RegistryClient
+
+	return a
+}
+
+// SetRegistryClient sets the registry client to use when fetching charts.
+func (a *Adopt) SetRegistryClient(client *registry.Client) {
+	a.registryClient = client
+}
+
+// Run renders the chart with vals and compares its resources with the live
+// ones. If they all exist and match, the live resources are labeled and
+// annotated as owned by the release, without changing their spec, and the
+// release is recorded with the rendered manifest. The hooks of the chart are
+// recorded but not run.
+//
+// The report is returned along with ErrAdoptDiffs when resources differ or are
+// missing.
+func (a *Adopt) Run(name string, chrt *chart.Chart, vals map[string]interface{}) (*AdoptReport, error) {
+	if err := a.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	if _, err := a.cfg.Releases.Last(name); err == nil {
+		return nil, fmt.Errorf("release %q already exists: only new releases can adopt resources", name)
+	} else if !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	}
+
+	if err := chartutil.ProcessDependencies(chrt, vals); err != nil {
+		return nil, err
+	}
+	caps, err := a.cfg.getCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      name,
+		Namespace: a.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, vals, options, caps, a.SkipSchemaValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver.ContainsSystemLabels(a.Labels) {
+		return nil, fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
+	}
+
+	hooks, manifestDoc, notes, err := a.cfg.renderResources(chrt, valuesToRender, "", "", false, false, false, nil, true, false, false, nil, renderOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ts := a.cfg.Now()
+	rel := &release.Release{
+		Name:      name,
+		Namespace: a.Namespace,
+		Chart:     chrt,
+		Config:    vals,
+		Info: &release.Info{
+			FirstDeployed: ts,
+			LastDeployed:  ts,
+			Status:        release.StatusPendingInstall,
+			Description:   "Adoption underway",
+			Notes:         notes,
+			FieldManager:  a.cfg.FieldManager,
+			AppliedBy:     currentOperator(),
+		},
+		Version:  1,
+		Manifest: manifestDoc.String(),
+		Hooks:    hooks,
+		Labels:   a.Labels,
+	}
+
+	resources, err := a.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !a.DisableOpenAPIValidation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build kubernetes objects from release manifest: %w", err)
+	}
+
+	report := &AdoptReport{Release: rel, Resources: []AdoptedResource{}}
+	var matching, differing, toApply kube.ResourceList
+	for _, info := range resources {
+		adopted, err := a.compare(info, name)
+		if err != nil {
+			return nil, err
+		}
+		report.Resources = append(report.Resources, adopted)
+		switch adopted.Status {
+		case AdoptMatch:
+			matching.Append(info)
+		case AdoptDiffers:
+			differing.Append(info)
+			toApply.Append(info)
+		case AdoptMissing:
+			toApply.Append(info)
+		}
+	}
+	if len(toApply) > 0 && !a.ForceAdoptDiffs {
+		return report, fmt.Errorf("cannot adopt %d of %d resources: %w", len(toApply), len(resources), ErrAdoptDiffs)
+	}
+
+	if a.DryRun {
+		rel.Info.Description = "Dry run complete"
+		return report, nil
+	}
+
+	if err := a.cfg.Releases.Create(rel); err != nil {
+		return report, err
+	}
+	if err := a.adopt(rel, matching, differing, toApply); err != nil {
+		rel.SetStatus(release.StatusFailed, fmt.Sprintf("Adoption %q failed: %s", name, err))
+		if uerr := a.cfg.Releases.Update(rel); uerr != nil {
+			a.cfg.log().Debug("failed to record the failed adoption", slog.Any("error", uerr))
+		}
+		return report, err
+	}
+
+	rel.SetStatus(release.StatusDeployed, "Adoption complete")
+	if a.Description != "" {
+		rel.Info.Description = a.Description
+	}
+	return report, a.cfg.Releases.Update(rel)
+}
+
+// compare compares the rendered resource info with the live one.
+func (a *Adopt) compare(info *resource.Info, releaseName string) (AdoptedResource, error) {
+	_, kind := info.Mapping.GroupVersionKind.ToAPIVersionAndKind()
+	adopted := AdoptedResource{Kind: kind, Name: info.Name, Namespace: info.Namespace}
+
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	live, err := helper.Get(info.Namespace, info.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			adopted.Status = AdoptMissing
+			return adopted, nil
+		}
+		return adopted, fmt.Errorf("could not get information about the resource %s: %w", resourceString(info), err)
+	}
+
+	annotations, err := accessor.Annotations(live)
+	if err != nil {
+		return adopted, err
+	}
+	if owner, ok := annotations[helmReleaseNameAnnotation]; ok && (owner != releaseName || annotations[helmReleaseNamespaceAnnotation] != a.Namespace) {
+		return adopted, fmt.Errorf("%s already belongs to the release %q in namespace %q", resourceString(info), owner, annotations[helmReleaseNamespaceAnnotation])
+	}
+
+	rendered, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+	if err != nil {
+		return adopted, err
+	}
+	liveContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return adopted, err
+	}
+
+	var diffs []string
+	for _, key := range sortedValueKeys(rendered) {
+		switch key {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			renderedMeta, _ := rendered[key].(map[string]interface{})
+			liveMeta, _ := liveContent[key].(map[string]interface{})
+			for _, field := range []string{"labels", "annotations"} {
+				diffs = adoptDiff(diffs, "metadata."+field, renderedMeta[field], liveMeta[field], a.IgnoreFields)
+			}
+		default:
+			diffs = adoptDiff(diffs, key, rendered[key], liveContent[key], a.IgnoreFields)
+		}
+	}
+	adopted.Status = AdoptMatch
+	if len(diffs) > 0 {
+		adopted.Status = AdoptDiffers
+		adopted.Differences = diffs
+	}
+	return adopted, nil
+}
+
+// adopt takes ownership of the matching resources, and applies the rendered
+// chart to the others.
+func (a *Adopt) adopt(rel *release.Release, matching, differing, toApply kube.ResourceList) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{appManagedByLabel: appManagedByHelm},
+			"annotations": map[string]string{
+				helmReleaseNameAnnotation:      rel.Name,
+				helmReleaseNamespaceAnnotation: rel.Namespace,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, info := range matching {
+		helper := resource.NewHelper(info.Client, info.Mapping)
+		if _, err := helper.Patch(info.Namespace, info.Name, types.MergePatchType, patch, nil); err != nil {
+			return fmt.Errorf("failed to take ownership of %s: %w", resourceString(info), err)
+		}
+	}
+
+	if len(toApply) == 0 {
+		return nil
+	}
+	if err := toApply.Visit(setMetadataVisitor(rel.Name, rel.Namespace, true)); err != nil {
+		return err
+	}
+	if _, err := a.cfg.KubeClient.Update(differing, toApply, false); err != nil {
+		return fmt.Errorf("failed to apply the chart to the resources differing from it: %w", err)
+	}
+	return nil
+}
+
+// adoptDiff appends to diffs the paths at which the live value differs from
+// the rendered one. Only the fields set by the chart are compared, so that
+// the fields defaulted by the cluster are ignored, and the zero values set by
+// the chart match absent live values.
+func adoptDiff(diffs []string, path string, rendered, live interface{}, ignored []string) []string {
+	if rendered == nil || slices.Contains(ignored, path) {
+		return diffs
+	}
+	switch r := rendered.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(r) == 0 && live == nil {
+				return diffs
+			}
+			return append(diffs, fmt.Sprintf("%s: rendered %s, live %s", path, adoptValueString(r), adoptValueString(live)))
+		}
+		for _, key := range sortedValueKeys(r) {
+			diffs = adoptDiff(diffs, valuesPath(path, key), r[key], l[key], ignored)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(r) {
+			if len(r) == 0 && live == nil {
+				return diffs
+			}
+			return append(diffs, fmt.Sprintf("%s: rendered %d item(s), live %d", path, len(r), len(l)))
+		}
+		for i := range r {
+			diffs = adoptDiff(diffs, fmt.Sprintf("%s[%d]", path, i), r[i], l[i], ignored)
+		}
+	default:
+		if live == nil && reflect.ValueOf(r).IsZero() {
+			return diffs
+		}
+		if !adoptScalarEqual(r, live) {
+			return append(diffs, fmt.Sprintf("%s: rendered %s, live %s", path, adoptValueString(r), adoptValueString(live)))
+		}
+	}
+	return diffs
+}
+
+// adoptScalarEqual compares scalar values, numbers by value whatever their
+// type.
+func adoptScalarEqual(a, b interface{}) bool {
+	fa, aNumber := adoptNumber(a)
+	fb, bNumber := adoptNumber(b)
+	if aNumber && bNumber {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func adoptNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func adoptValueString(v interface{}) string {
+	if v == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
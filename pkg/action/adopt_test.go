@@ -0,0 +1,228 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	"k8s.io/utils/ptr"
+
+	"helm.sh/helm/v4/pkg/kube"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// adoptRequests records the requests made to the fake cluster.
+type adoptRequests struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (r *adoptRequests) add(req string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+func adoptDeployment(name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "spaced",
+			Labels:    map[string]string{"app": name},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+		},
+	}
+}
+
+// adoptResource returns the resource rendered as rendered, served by a fake
+// cluster as live, or not found if live is nil.
+func adoptResource(rendered, live *appsv1.Deployment, requests *adoptRequests) *resource.Info {
+	client := fakeClientWith(http.StatusNotFound, appsV1GV, "")
+	if live != nil {
+		client = fakeClientWith(http.StatusOK, appsV1GV, runtime.EncodeOrDie(appsv1Codec, live))
+	}
+	handler := client.Client.Transport
+	client.Client = fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+		requests.add(req.Method + " " + rendered.Name)
+		return handler.RoundTrip(req)
+	})
+	return &resource.Info{
+		Name:      rendered.Name,
+		Namespace: rendered.Namespace,
+		Mapping: &meta.RESTMapping{
+			Resource:         schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Scope:            meta.RESTScopeNamespace,
+		},
+		Object: rendered,
+		Client: client,
+	}
+}
+
+func adoptAction(t *testing.T, resources ...*resource.Info) *Adopt {
+	t.Helper()
+	config := actionConfigFixtureWithDummyResources(t, kube.ResourceList(resources))
+	adopt := NewAdopt(config)
+	adopt.Namespace = "spaced"
+	return adopt
+}
+
+func TestAdopt(t *testing.T) {
+	requests := &adoptRequests{}
+	live := adoptDeployment("web", 2)
+	// Fields defaulted by the cluster, and the status, are ignored.
+	live.Spec.RevisionHistoryLimit = ptr.To(int32(10))
+	live.Labels["team"] = "web"
+	live.Status.ReadyReplicas = 2
+	adopt := adoptAction(t, adoptResource(adoptDeployment("web", 2), live, requests))
+
+	report, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, []AdoptedResource{{Kind: "Deployment", Name: "web", Namespace: "spaced", Status: AdoptMatch}}, report.Resources)
+	assert.Equal(t, []string{"GET web", "PATCH web"}, requests.requests)
+
+	rel, err := adopt.cfg.Releases.Get("web", 1)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	assert.Equal(t, "Adoption complete", rel.Info.Description)
+	assert.Equal(t, report.Release.Manifest, rel.Manifest)
+}
+
+func TestAdoptDiffs(t *testing.T) {
+	t.Run("blocked", func(t *testing.T) {
+		requests := &adoptRequests{}
+		adopt := adoptAction(t,
+			adoptResource(adoptDeployment("web", 2), adoptDeployment("web", 3), requests),
+			adoptResource(adoptDeployment("worker", 1), nil, requests),
+			adoptResource(adoptDeployment("cron", 1), adoptDeployment("cron", 1), requests),
+		)
+
+		report, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+		require.ErrorIs(t, err, ErrAdoptDiffs)
+		assert.EqualError(t, err, "cannot adopt 2 of 3 resources: resources differ from the rendered chart or do not exist")
+		assert.Equal(t, []AdoptedResource{
+			{Kind: "Deployment", Name: "web", Namespace: "spaced", Status: AdoptDiffers, Differences: []string{"spec.replicas: rendered 2, live 3"}},
+			{Kind: "Deployment", Name: "worker", Namespace: "spaced", Status: AdoptMissing},
+			{Kind: "Deployment", Name: "cron", Namespace: "spaced", Status: AdoptMatch},
+		}, report.Resources)
+		assert.Equal(t, []string{"GET web", "GET worker", "GET cron"}, requests.requests, "no resource should be changed")
+
+		_, err = adopt.cfg.Releases.Get("web", 1)
+		assert.Error(t, err, "no release should be recorded")
+	})
+
+	t.Run("ignored fields", func(t *testing.T) {
+		requests := &adoptRequests{}
+		adopt := adoptAction(t, adoptResource(adoptDeployment("web", 2), adoptDeployment("web", 3), requests))
+		adopt.IgnoreFields = []string{"spec.replicas"}
+
+		report, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, AdoptMatch, report.Resources[0].Status)
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		requests := &adoptRequests{}
+		adopt := adoptAction(t,
+			adoptResource(adoptDeployment("web", 2), adoptDeployment("web", 3), requests),
+			adoptResource(adoptDeployment("cron", 1), adoptDeployment("cron", 1), requests),
+		)
+		adopt.ForceAdoptDiffs = true
+
+		report, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, AdoptDiffers, report.Resources[0].Status)
+		assert.Equal(t, []string{"GET web", "GET cron", "PATCH cron"}, requests.requests, "the differing resources should be updated with the chart")
+
+		rel, err := adopt.cfg.Releases.Get("web", 1)
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	})
+}
+
+func TestAdoptDryRun(t *testing.T) {
+	requests := &adoptRequests{}
+	adopt := adoptAction(t, adoptResource(adoptDeployment("web", 2), adoptDeployment("web", 2), requests))
+	adopt.DryRun = true
+
+	report, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, AdoptMatch, report.Resources[0].Status)
+	assert.Equal(t, "Dry run complete", report.Release.Info.Description)
+	assert.Equal(t, []string{"GET web"}, requests.requests)
+
+	_, err = adopt.cfg.Releases.Get("web", 1)
+	assert.Error(t, err, "no release should be recorded")
+}
+
+func TestAdoptConflicts(t *testing.T) {
+	requests := &adoptRequests{}
+	owned := adoptDeployment("web", 2)
+	owned.Annotations = map[string]string{helmReleaseNameAnnotation: "other", helmReleaseNamespaceAnnotation: "spaced"}
+	adopt := adoptAction(t, adoptResource(adoptDeployment("web", 2), owned, requests))
+
+	_, err := adopt.Run("web", buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, `Deployment "web" in namespace "spaced" already belongs to the release "other" in namespace "spaced"`)
+
+	adopt = adoptAction(t, adoptResource(adoptDeployment("web", 2), adoptDeployment("web", 2), requests))
+	require.NoError(t, adopt.cfg.Releases.Create(namedReleaseStub("web", release.StatusDeployed)))
+	_, err = adopt.Run("web", buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, `release "web" already exists`)
+}
+
+func TestAdoptDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		rendered interface{}
+		live     interface{}
+		ignored  []string
+		expected []string
+	}{
+		{name: "equal", rendered: map[string]interface{}{"a": "x"}, live: map[string]interface{}{"a": "x", "b": "defaulted"}},
+		{name: "numbers", rendered: map[string]interface{}{"a": int64(1), "b": 1.5}, live: map[string]interface{}{"a": float64(1), "b": 1.5}},
+		{name: "zero values", rendered: map[string]interface{}{"a": "", "b": false, "c": map[string]interface{}{}, "d": []interface{}{}}, live: map[string]interface{}{}},
+		{name: "changed", rendered: map[string]interface{}{"a": "x"}, live: map[string]interface{}{"a": "y"}, expected: []string{`spec.a: rendered "x", live "y"`}},
+		{name: "unset", rendered: map[string]interface{}{"a": "x"}, live: map[string]interface{}{}, expected: []string{`spec.a: rendered "x", live unset`}},
+		{name: "ignored", rendered: map[string]interface{}{"a": "x"}, live: map[string]interface{}{"a": "y"}, ignored: []string{"spec.a"}},
+		{
+			name:     "lists",
+			rendered: map[string]interface{}{"l": []interface{}{map[string]interface{}{"image": "nginx:1"}}, "m": []interface{}{"a"}},
+			live:     map[string]interface{}{"l": []interface{}{map[string]interface{}{"image": "nginx:2", "imagePullPolicy": "Always"}}, "m": []interface{}{"a", "b"}},
+			expected: []string{`spec.l[0].image: rendered "nginx:1", live "nginx:2"`, "spec.m: rendered 1 item(s), live 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, adoptDiff(nil, "spec", tt.rendered, tt.live, tt.ignored))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// atomicCleanup undoes an operation that failed while atomic was set.
+type atomicCleanup struct {
+	// operation is the name of the failed operation, e.g. "upgrade".
+	operation string
+	// undoing and undone describe the cleanup, e.g. "rolling back" and "rolled back".
+	undoing string
+	undone  string
+	// undo reverts the release to its state before the operation, within
+	// timeout.
+	undo func() error
+	// log is the logger of the configuration of the operation.
+	log *slog.Logger
+	// timeout is the time the cleanup was given, which is reported in the
+	// error when set.
+	timeout time.Duration
+}
+
+// run reverts the release after its operation failed with err and returns the
+// error to report, which wraps err and the cleanup error, if any.
+//
+// The cleanup is not tied to the context of the operation: cancellation is
+// often the very reason the operation failed, and stopping half way through the
+// cleanup would leave the release worse off than either outcome. It is bounded
+// by the timeout given to undo instead.
+func (c atomicCleanup) run(releaseName string, err error) error {
+	c.log.Debug(c.operation+" failed and atomic is set, cleaning up", "release", releaseName, "cleanup", c.undoing, slog.Any("error", err))
+	if cleanupErr := c.undo(); cleanupErr != nil {
+		if c.timeout > 0 {
+			return fmt.Errorf("an error occurred while %s the release, which did not complete within its %s cleanup timeout. original %s error: %w: %w", c.undoing, c.timeout, c.operation, err, cleanupErr)
+		}
+		return fmt.Errorf("an error occurred while %s the release. original %s error: %w: %w", c.undoing, c.operation, err, cleanupErr)
+	}
+	if c.timeout > 0 {
+		return fmt.Errorf("release %s failed, and has been %s within its %s cleanup timeout due to atomic being set: %w", releaseName, c.undone, c.timeout, err)
+	}
+	return fmt.Errorf("release %s failed, and has been %s due to atomic being set: %w", releaseName, c.undone, err)
+}
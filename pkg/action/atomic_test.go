@@ -0,0 +1,77 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicCleanup(t *testing.T) {
+	opErr := errors.New("operation failed")
+
+	t.Run("cleanup succeeds", func(t *testing.T) {
+		c := atomicCleanup{
+			operation: "upgrade",
+			undoing:   "rolling back",
+			undone:    "rolled back",
+			log:       slog.Default(),
+			undo:      func() error { return nil },
+		}
+		err := c.run("nuketown", opErr)
+		assert.EqualError(t, err, "release nuketown failed, and has been rolled back due to atomic being set: operation failed")
+		assert.ErrorIs(t, err, opErr)
+	})
+
+	t.Run("cleanup fails", func(t *testing.T) {
+		cleanupErr := errors.New("cleanup failed")
+		c := atomicCleanup{
+			operation: "install",
+			undoing:   "uninstalling",
+			undone:    "uninstalled",
+			log:       slog.Default(),
+			undo:      func() error { return cleanupErr },
+		}
+		err := c.run("nuketown", opErr)
+		assert.EqualError(t, err, "an error occurred while uninstalling the release. original install error: operation failed: cleanup failed")
+		assert.ErrorIs(t, err, opErr)
+		assert.ErrorIs(t, err, cleanupErr)
+	})
+
+	t.Run("cleanup timeout reported", func(t *testing.T) {
+		c := atomicCleanup{
+			operation: "upgrade",
+			undoing:   "rolling back",
+			undone:    "rolled back",
+			log:       slog.Default(),
+			undo:      func() error { return nil },
+			timeout:   2 * time.Minute,
+		}
+		err := c.run("nuketown", opErr)
+		assert.EqualError(t, err, "release nuketown failed, and has been rolled back within its 2m0s cleanup timeout due to atomic being set: operation failed")
+
+		cleanupErr := errors.New("cleanup failed")
+		c.undo = func() error { return cleanupErr }
+		err = c.run("nuketown", opErr)
+		assert.EqualError(t, err, "an error occurred while rolling back the release, which did not complete within its 2m0s cleanup timeout. original upgrade error: operation failed: cleanup failed")
+		assert.ErrorIs(t, err, cleanupErr)
+	})
+}
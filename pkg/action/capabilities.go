@@ -0,0 +1,232 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// CapabilitiesPollInterval is how often WatchCapabilities discovers the
+// capabilities of the cluster again when it is not allowed to watch CRDs and
+// APIServices.
+var CapabilitiesPollInterval = 5 * time.Minute
+
+// capabilitiesRetryInterval is how long WatchCapabilities waits before
+// watching again after a watch failed.
+var capabilitiesRetryInterval = 5 * time.Second
+
+// newDynamicClient creates the client used to watch CRDs and APIServices.
+// It is a variable so that it can be replaced in tests.
+var newDynamicClient = func(getter RESTClientGetter) (dynamic.Interface, error) {
+	config, err := getter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// apiResources are the resources which add APIs to a cluster.
+var apiResources = []schema.GroupVersionResource{
+	{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+	{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"},
+}
+
+// copyCapabilities returns a copy of caps which shares nothing with it.
+func copyCapabilities(caps *chartutil.Capabilities) *chartutil.Capabilities {
+	c := caps.Copy()
+	c.APIVersions = slices.Clone(caps.APIVersions)
+	return c
+}
+
+// setCapabilities replaces the cached capabilities.
+func (cfg *Configuration) setCapabilities(caps *chartutil.Capabilities) {
+	cfg.capabilitiesMutex.Lock()
+	defer cfg.capabilitiesMutex.Unlock()
+	cfg.Capabilities = caps
+}
+
+// RefreshCapabilities discovers the capabilities of the cluster again and
+// resets the cache of the REST mapper, so that the APIs added or removed since
+// they were cached are taken into account. It returns the new capabilities.
+//
+// The capabilities are replaced at once: an operation already rendering keeps
+// the capabilities it started with, and the next one sees the new ones.
+// CapabilitiesRefreshFunc is called when the capabilities changed.
+func (cfg *Configuration) RefreshCapabilities() (*chartutil.Capabilities, error) {
+	cfg.refreshMutex.Lock()
+	defer cfg.refreshMutex.Unlock()
+
+	caps, err := cfg.discoverCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	restMapper, err := cfg.RESTClientGetter.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	if resettable, ok := restMapper.(meta.ResettableRESTMapper); ok {
+		cfg.log().Debug("clearing REST mapper cache")
+		resettable.Reset()
+	}
+
+	cfg.capabilitiesMutex.Lock()
+	previous := cfg.Capabilities
+	cfg.Capabilities = caps
+	cfg.capabilitiesMutex.Unlock()
+
+	if cfg.CapabilitiesRefreshFunc != nil && !sameCapabilities(previous, caps) {
+		cfg.CapabilitiesRefreshFunc(copyCapabilities(caps))
+	}
+	return copyCapabilities(caps), nil
+}
+
+// sameCapabilities reports whether two capabilities describe the same cluster.
+func sameCapabilities(a, b *chartutil.Capabilities) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.KubeVersion == b.KubeVersion &&
+		slices.Equal(slices.Sorted(slices.Values(a.APIVersions)), slices.Sorted(slices.Values(b.APIVersions)))
+}
+
+// WatchCapabilities keeps the capabilities of the cluster up to date for
+// long-running consumers reusing a Configuration, such as operators: it
+// watches CRDs and APIServices, and refreshes the capabilities whenever they
+// change. When it is not allowed to watch them, it discovers the capabilities
+// again every CapabilitiesPollInterval instead.
+//
+// WatchCapabilities runs until ctx is done.
+func (cfg *Configuration) WatchCapabilities(ctx context.Context) error {
+	client, err := newDynamicClient(cfg.RESTClientGetter)
+	if err != nil {
+		return fmt.Errorf("unable to create the client watching the APIs of the cluster: %w", err)
+	}
+
+	// Changes are coalesced while a refresh is running.
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, gvr := range apiResources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchAPIResource(ctx, cfg.log(), client.Resource(gvr), gvr, notify)
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+			if _, err := cfg.RefreshCapabilities(); err != nil {
+				cfg.log().Warn("unable to refresh the capabilities of the cluster", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// watchAPIResource calls notify whenever a resource of gvr changes, or every
+// CapabilitiesPollInterval when it is not allowed to watch them, until ctx is
+// done.
+func watchAPIResource(ctx context.Context, log *slog.Logger, client dynamic.ResourceInterface, gvr schema.GroupVersionResource, notify func()) {
+	for ctx.Err() == nil {
+		err := watchAPIResourceOnce(ctx, client, notify)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err), apierrors.IsNotFound(err):
+			log.Debug("unable to watch API resources, discovering the capabilities periodically instead", "resource", gvr.String(), slog.Any("error", err))
+			pollCapabilities(ctx, notify)
+			return
+		case err != nil:
+			log.Debug("watching API resources failed, retrying", "resource", gvr.String(), slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(capabilitiesRetryInterval):
+			}
+		}
+	}
+}
+
+// watchAPIResourceOnce lists the resources, then watches them from there
+// until the watch ends. It returns nil when the watch has to be started again.
+func watchAPIResourceOnce(ctx context.Context, client dynamic.ResourceInterface, notify func()) error {
+	list, err := client.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	w, err := client.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				notify()
+			case watch.Error:
+				return apierrors.FromObject(event.Object)
+			}
+		}
+	}
+}
+
+// pollCapabilities calls notify every CapabilitiesPollInterval until ctx is
+// done.
+func pollCapabilities(ctx context.Context, notify func()) {
+	ticker := time.NewTicker(CapabilitiesPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notify()
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// capabilitiesDiscovery is a discovery client counting its invalidations.
+type capabilitiesDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	invalidations atomic.Int32
+}
+
+func (d *capabilitiesDiscovery) Fresh() bool { return true }
+func (d *capabilitiesDiscovery) Invalidate() { d.invalidations.Add(1) }
+
+// capabilitiesRESTMapper is a REST mapper counting its resets.
+type capabilitiesRESTMapper struct {
+	meta.RESTMapper
+	resets atomic.Int32
+}
+
+func (m *capabilitiesRESTMapper) Reset() { m.resets.Add(1) }
+
+type capabilitiesRESTClientGetter struct {
+	discovery *capabilitiesDiscovery
+	mapper    *capabilitiesRESTMapper
+}
+
+func (g *capabilitiesRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+func (g *capabilitiesRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return g.discovery, nil
+}
+
+func (g *capabilitiesRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return g.mapper, nil
+}
+
+// newCapabilitiesRESTClientGetter returns a RESTClientGetter discovering the
+// given API resources.
+func newCapabilitiesRESTClientGetter(resources ...*metav1.APIResourceList) *capabilitiesRESTClientGetter {
+	return &capabilitiesRESTClientGetter{
+		discovery: &capabilitiesDiscovery{
+			FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+		},
+		mapper: &capabilitiesRESTMapper{RESTMapper: meta.NewDefaultRESTMapper(nil)},
+	}
+}
+
+var (
+	coreResources = &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap"}},
+	}
+	customResources = &metav1.APIResourceList{
+		GroupVersion: "crd.com/v1",
+		APIResources: []metav1.APIResource{{Name: "datas", Kind: "Data"}},
+	}
+)
+
+func TestRefreshCapabilities(t *testing.T) {
+	getter := newCapabilitiesRESTClientGetter(coreResources)
+	var refreshed []*chartutil.Capabilities
+	cfg := &Configuration{
+		RESTClientGetter:        getter,
+		CapabilitiesRefreshFunc: func(caps *chartutil.Capabilities) { refreshed = append(refreshed, caps) },
+	}
+
+	caps, err := cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.False(t, caps.APIVersions.Has("crd.com/v1"))
+
+	// A CRD is installed.
+	getter.discovery.Resources = append(getter.discovery.Resources, customResources)
+
+	caps, err = cfg.RefreshCapabilities()
+	require.NoError(t, err)
+	assert.True(t, caps.APIVersions.Has("crd.com/v1"))
+	assert.True(t, caps.APIVersions.Has("crd.com/v1/Data"))
+	assert.EqualValues(t, 1, getter.mapper.resets.Load())
+	assert.EqualValues(t, 2, getter.discovery.invalidations.Load())
+	require.Len(t, refreshed, 1)
+	assert.True(t, refreshed[0].APIVersions.Has("crd.com/v1"))
+
+	caps, err = cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.True(t, caps.APIVersions.Has("crd.com/v1"))
+
+	// Nothing changed, so the refresh function is not called again.
+	_, err = cfg.RefreshCapabilities()
+	require.NoError(t, err)
+	assert.Len(t, refreshed, 1)
+	assert.EqualValues(t, 2, getter.mapper.resets.Load())
+}
+
+func TestRefreshCapabilitiesDiscoveryError(t *testing.T) {
+	getter := newCapabilitiesRESTClientGetter(coreResources)
+	getter.discovery.PrependReactor("get", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	cfg := &Configuration{RESTClientGetter: getter, Capabilities: chartutil.DefaultCapabilities}
+
+	_, err := cfg.RefreshCapabilities()
+	assert.ErrorContains(t, err, "connection refused")
+	// The capabilities cached are kept.
+	assert.Same(t, chartutil.DefaultCapabilities, cfg.Capabilities)
+	assert.Zero(t, getter.mapper.resets.Load())
+}
+
+func TestGetCapabilitiesReturnsCopy(t *testing.T) {
+	cfg := actionConfigFixture(t)
+
+	caps, err := cfg.getCapabilities()
+	require.NoError(t, err)
+	caps.APIVersions = append(caps.APIVersions, "crd.com/v1")
+	caps.APIVersions[0] = "changed/v1"
+	caps.KubeVersion.Version = "v0.0.1"
+
+	caps, err = cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.False(t, caps.APIVersions.Has("crd.com/v1"))
+	assert.False(t, caps.APIVersions.Has("changed/v1"))
+	assert.Equal(t, chartutil.DefaultCapabilities.KubeVersion, caps.KubeVersion)
+	assert.Equal(t, chartutil.DefaultCapabilities.APIVersions, caps.APIVersions)
+}
+
+func newCapabilitiesDynamicClient(t *testing.T) *dynamicfake.FakeDynamicClient {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		apiResources[0]: "CustomResourceDefinitionList",
+		apiResources[1]: "APIServiceList",
+	})
+	previous := newDynamicClient
+	newDynamicClient = func(RESTClientGetter) (dynamic.Interface, error) { return client, nil }
+	t.Cleanup(func() { newDynamicClient = previous })
+	return client
+}
+
+// watchCapabilities runs WatchCapabilities until the test ends, and returns a
+// channel receiving the refreshed capabilities.
+func watchCapabilities(t *testing.T, cfg *Configuration) <-chan *chartutil.Capabilities {
+	t.Helper()
+	refreshed := make(chan *chartutil.Capabilities, 100)
+	cfg.CapabilitiesRefreshFunc = func(caps *chartutil.Capabilities) { refreshed <- caps }
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() { done <- cfg.WatchCapabilities(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return refreshed
+}
+
+func TestWatchCapabilities(t *testing.T) {
+	client := newCapabilitiesDynamicClient(t)
+	cfg := &Configuration{
+		RESTClientGetter: newCapabilitiesRESTClientGetter(coreResources, customResources),
+		Capabilities:     chartutil.DefaultCapabilities,
+	}
+	refreshed := watchCapabilities(t, cfg)
+
+	// CRDs are created until one is seen by the watch.
+	crds := client.Resource(apiResources[0])
+	var created int
+	require.Eventually(t, func() bool {
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1")
+		crd.SetKind("CustomResourceDefinition")
+		crd.SetName(fmt.Sprintf("datas%d.crd.com", created))
+		created++
+		if _, err := crds.Create(t.Context(), crd, metav1.CreateOptions{}); err != nil {
+			t.Error(err)
+		}
+		return len(refreshed) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	caps := <-refreshed
+	assert.True(t, caps.APIVersions.Has("crd.com/v1/Data"))
+	current, err := cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.True(t, current.APIVersions.Has("crd.com/v1/Data"))
+}
+
+func TestWatchCapabilitiesPolls(t *testing.T) {
+	previous := CapabilitiesPollInterval
+	CapabilitiesPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { CapabilitiesPollInterval = previous })
+
+	client := newCapabilitiesDynamicClient(t)
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", fmt.Errorf("not allowed"))
+	})
+	cfg := &Configuration{
+		RESTClientGetter: newCapabilitiesRESTClientGetter(coreResources, customResources),
+		Capabilities:     chartutil.DefaultCapabilities,
+	}
+	refreshed := watchCapabilities(t, cfg)
+
+	select {
+	case caps := <-refreshed:
+		assert.True(t, caps.APIVersions.Has("crd.com/v1/Data"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the capabilities were not refreshed")
+	}
+}
+
+func TestWatchCapabilitiesClientError(t *testing.T) {
+	previous := newDynamicClient
+	newDynamicClient = func(RESTClientGetter) (dynamic.Interface, error) { return nil, fmt.Errorf("no config") }
+	t.Cleanup(func() { newDynamicClient = previous })
+
+	cfg := &Configuration{RESTClientGetter: newCapabilitiesRESTClientGetter(coreResources)}
+	err := cfg.WatchCapabilities(t.Context())
+	assert.ErrorContains(t, err, "no config")
+}
+
+func TestInstallCRDsRefreshesCapabilities(t *testing.T) {
+	cfg := actionConfigFixtureWithDummyResources(t, createDummyResourceList(false))
+	getter := newCapabilitiesRESTClientGetter(coreResources, customResources)
+	cfg.RESTClientGetter = getter
+	instAction := NewInstall(cfg)
+
+	crds := []chart.CRD{{Name: "crds/datas.yaml", File: &chart.File{Name: "crds/datas.yaml", Data: []byte("kind: CustomResourceDefinition")}}}
+	require.NoError(t, instAction.installCRDs(crds))
+
+	assert.EqualValues(t, 1, getter.mapper.resets.Load())
+	caps, err := cfg.getCapabilities()
+	require.NoError(t, err)
+	assert.True(t, caps.APIVersions.Has("crd.com/v1/Data"))
+}
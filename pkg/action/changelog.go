@@ -0,0 +1,324 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
+)
+
+// sensitiveValueKey matches the keys of the values whose content is masked in
+// a changelog.
+var sensitiveValueKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|api[_-]?key|private[_-]?key)`)
+
+// IsSensitiveValueKey reports whether the value of the given key may hold
+// sensitive data, such as a password, and is masked when shown.
+func IsSensitiveValueKey(key string) bool {
+	return sensitiveValueKey.MatchString(key)
+}
+
+// ReleaseChangelog summarizes the changes of a release between two revisions.
+type ReleaseChangelog struct {
+	Release    string        `json:"release"`
+	From       int           `json:"from"`
+	To         int           `json:"to"`
+	Chart      VersionChange `json:"chart"`
+	AppVersion VersionChange `json:"app_version"`
+	// Images lists the containers whose image was added, removed or changed.
+	Images []ImageChangelogEntry `json:"images"`
+	// Values lists the user-supplied values added, removed or changed.
+	Values []ValueChange `json:"values"`
+	// NotesDiff is the unified diff of the notes, empty if they are the same.
+	NotesDiff string `json:"notes_diff,omitempty"`
+	// Revisions are the revisions after From, up to To.
+	Revisions []RevisionSummary `json:"revisions"`
+}
+
+// VersionChange is a version before and after the changes.
+type VersionChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Changed reports whether the version changed.
+func (v VersionChange) Changed() bool {
+	return v.From != v.To
+}
+
+// ImageChangelogEntry is the image of a container before and after the
+// changes. From is empty for an added container, To for a removed one.
+type ImageChangelogEntry struct {
+	// Container identifies the container as "Kind/name/container".
+	Container string `json:"container"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+}
+
+// ValueChange is a value added, removed or changed.
+type ValueChange struct {
+	// Path is the dotted path of the value, such as "image.tag".
+	Path string `json:"path"`
+	// Change is "added", "removed" or "changed".
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+	// Masked is set, and From and To are left out, when the value may be
+	// sensitive, such as a password.
+	Masked bool `json:"masked,omitempty"`
+}
+
+// RevisionSummary describes a revision of a release in a changelog.
+type RevisionSummary struct {
+	Revision    int           `json:"revision"`
+	Updated     helmtime.Time `json:"updated"`
+	Status      string        `json:"status"`
+	Description string        `json:"description"`
+	// Operator is the field manager which applied the revision.
+	Operator string `json:"operator,omitempty"`
+}
+
+// Changelog is the action composing the changes of a release between two of
+// its revisions, from the stored releases only.
+//
+// It provides the implementation of 'helm changelog'.
+type Changelog struct {
+	cfg *Configuration
+
+	// From and To are the revisions compared. A zero To is the latest
+	// revision, and a zero From the last revision deployed before To.
+	From int
+	To   int
+}
+
+// NewChangelog creates a new Changelog object with the given configuration.
+func NewChangelog(cfg *Configuration) *Changelog {
+	return &Changelog{
+		cfg: cfg,
+	}
+}
+
+// Run returns the changes of the named release between the revisions.
+func (c *Changelog) Run(name string) (*ReleaseChangelog, error) {
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	if c.From < 0 || c.To < 0 || (c.To != 0 && c.From >= c.To) {
+		return nil, fmt.Errorf("invalid revisions: --from %d must be before --to %d", c.From, c.To)
+	}
+
+	rels, err := c.cfg.Releases.History(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(rels) == 0 {
+		return nil, fmt.Errorf("release: %q not found", name)
+	}
+	releaseutil.SortByRevision(rels)
+
+	find := func(revision int) (*release.Release, error) {
+		for _, rel := range rels {
+			if rel.Version == revision {
+				return rel, nil
+			}
+		}
+		return nil, fmt.Errorf("revision %d of release %q is not stored", revision, name)
+	}
+
+	to := rels[len(rels)-1]
+	if c.To != 0 {
+		if to, err = find(c.To); err != nil {
+			return nil, err
+		}
+	}
+	var from *release.Release
+	if c.From != 0 {
+		if from, err = find(c.From); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, rel := range rels {
+			if rel.Version < to.Version && rel.Info != nil &&
+				(rel.Info.Status == release.StatusDeployed || rel.Info.Status == release.StatusSuperseded) {
+				from = rel
+			}
+		}
+		if from == nil {
+			return nil, fmt.Errorf("release %q has no revision deployed before revision %d", name, to.Version)
+		}
+	}
+
+	log := &ReleaseChangelog{
+		Release:    name,
+		From:       from.Version,
+		To:         to.Version,
+		Chart:      VersionChange{From: chartVersion(from.Chart), To: chartVersion(to.Chart)},
+		AppVersion: VersionChange{From: appVersion(from.Chart), To: appVersion(to.Chart)},
+		Images:     []ImageChangelogEntry{},
+		Values:     diffValues(from.Config, to.Config),
+		Revisions:  []RevisionSummary{},
+	}
+
+	fromImages, err := releaseutil.ExtractImages(from.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract images from revision %d: %w", from.Version, err)
+	}
+	toImages, err := releaseutil.ExtractImages(to.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract images from revision %d: %w", to.Version, err)
+	}
+	images := releaseutil.DiffImages(fromImages, toImages)
+	for _, ch := range images.Changed {
+		log.Images = append(log.Images, ImageChangelogEntry{Container: ch.New.ID(), From: ch.Old.Image, To: ch.New.Image})
+	}
+	for _, img := range images.Added {
+		log.Images = append(log.Images, ImageChangelogEntry{Container: img.ID(), To: img.Image})
+	}
+	for _, img := range images.Removed {
+		log.Images = append(log.Images, ImageChangelogEntry{Container: img.ID(), From: img.Image})
+	}
+	sort.SliceStable(log.Images, func(i, j int) bool { return log.Images[i].Container < log.Images[j].Container })
+
+	fromNotes, toNotes := releaseNotes(from), releaseNotes(to)
+	if fromNotes != toNotes {
+		log.NotesDiff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(fromNotes),
+			B:        difflib.SplitLines(toNotes),
+			FromFile: fmt.Sprintf("notes (revision %d)", from.Version),
+			ToFile:   fmt.Sprintf("notes (revision %d)", to.Version),
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rel := range rels {
+		if rel.Version <= from.Version || rel.Version > to.Version || rel.Info == nil {
+			continue
+		}
+		log.Revisions = append(log.Revisions, RevisionSummary{
+			Revision:    rel.Version,
+			Updated:     rel.Info.LastDeployed,
+			Status:      rel.Info.Status.String(),
+			Description: rel.Info.Description,
+			Operator:    rel.Info.FieldManager,
+		})
+	}
+	return log, nil
+}
+
+func chartVersion(c *chart.Chart) string {
+	if c == nil || c.Metadata == nil {
+		return ""
+	}
+	return c.Name() + "-" + c.Metadata.Version
+}
+
+func appVersion(c *chart.Chart) string {
+	if c == nil || c.Metadata == nil {
+		return ""
+	}
+	return c.AppVersion()
+}
+
+func releaseNotes(rel *release.Release) string {
+	if rel.Info == nil {
+		return ""
+	}
+	return rel.Info.Notes
+}
+
+// diffValues returns the changes from the values from to the values to,
+// sorted by path. Maps are compared key by key, other values as a whole.
+func diffValues(from, to map[string]interface{}) []ValueChange {
+	changes := []ValueChange{}
+	var walk func(path []string, a, b map[string]interface{})
+	walk = func(path []string, a, b map[string]interface{}) {
+		for k, av := range a {
+			p := append(path[:len(path):len(path)], k)
+			bv, ok := b[k]
+			am, aIsMap := av.(map[string]interface{})
+			bm, bIsMap := bv.(map[string]interface{})
+			switch {
+			case !ok && aIsMap:
+				walk(p, am, map[string]interface{}{})
+			case !ok:
+				changes = append(changes, valueChange(p, "removed", av, nil))
+			case aIsMap && bIsMap:
+				walk(p, am, bm)
+			case !reflect.DeepEqual(av, bv):
+				changes = append(changes, valueChange(p, "changed", av, bv))
+			}
+		}
+		for k, bv := range b {
+			if _, ok := a[k]; ok {
+				continue
+			}
+			p := append(path[:len(path):len(path)], k)
+			if bm, ok := bv.(map[string]interface{}); ok {
+				walk(p, map[string]interface{}{}, bm)
+			} else {
+				changes = append(changes, valueChange(p, "added", nil, bv))
+			}
+		}
+	}
+	walk(nil, from, to)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// valueChange returns the change of the value at path, masked if a key of the
+// path, or of the values themselves, may hold sensitive data.
+func valueChange(path []string, change string, from, to interface{}) ValueChange {
+	masked := hasSensitiveKey(from) || hasSensitiveKey(to)
+	for _, key := range path {
+		masked = masked || sensitiveValueKey.MatchString(key)
+	}
+	if masked {
+		return ValueChange{Path: strings.Join(path, "."), Change: change, Masked: true}
+	}
+	return ValueChange{Path: strings.Join(path, "."), Change: change, From: from, To: to}
+}
+
+func hasSensitiveKey(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if sensitiveValueKey.MatchString(k) || hasSensitiveKey(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasSensitiveKey(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func changelogRelease(version int, status release.Status, chartVersion, image string, config map[string]interface{}) *release.Release {
+	return &release.Release{
+		Name:      "web",
+		Namespace: "spaced",
+		Version:   version,
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "hello", Version: chartVersion, AppVersion: "app-" + chartVersion}},
+		Config:    config,
+		Manifest: fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: %s
+`, image),
+		Info: &release.Info{
+			Status:       status,
+			Description:  fmt.Sprintf("revision %d", version),
+			Notes:        "Visit http://web\nVersion " + chartVersion + "\n",
+			FieldManager: "helm",
+		},
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	is := assert.New(t)
+	config := actionConfigFixture(t)
+	for _, rel := range []*release.Release{
+		changelogRelease(1, release.StatusSuperseded, "0.1.0", "nginx:1.25", map[string]interface{}{
+			"replicas": 1,
+			"db":       map[string]interface{}{"password": "hunter2", "host": "db1"},
+			"debug":    true,
+		}),
+		changelogRelease(2, release.StatusSuperseded, "0.1.0", "nginx:1.25", map[string]interface{}{"replicas": 2}),
+		changelogRelease(3, release.StatusFailed, "0.2.0", "nginx:1.27", map[string]interface{}{"replicas": 5}),
+		changelogRelease(4, release.StatusDeployed, "0.2.0", "nginx:1.27", map[string]interface{}{
+			"replicas": 3,
+			"db":       map[string]interface{}{"password": "correct horse", "host": "db2"},
+			"ingress":  map[string]interface{}{"host": "web.example.com", "tls": map[string]interface{}{"secretName": "web-tls"}},
+		}),
+	} {
+		require.NoError(t, config.Releases.Create(rel))
+	}
+
+	client := NewChangelog(config)
+	client.From = 1
+	log, err := client.Run("web")
+	require.NoError(t, err)
+
+	is.Equal(1, log.From)
+	is.Equal(4, log.To)
+	is.Equal(VersionChange{From: "hello-0.1.0", To: "hello-0.2.0"}, log.Chart)
+	is.Equal(VersionChange{From: "app-0.1.0", To: "app-0.2.0"}, log.AppVersion)
+	is.Equal([]ImageChangelogEntry{{Container: "Deployment/web/app", From: "nginx:1.25", To: "nginx:1.27"}}, log.Images)
+	is.Equal([]ValueChange{
+		{Path: "db.host", Change: "changed", From: "db1", To: "db2"},
+		{Path: "db.password", Change: "changed", Masked: true},
+		{Path: "debug", Change: "removed", From: true},
+		{Path: "ingress.host", Change: "added", To: "web.example.com"},
+		{Path: "ingress.tls.secretName", Change: "added", Masked: true},
+		{Path: "replicas", Change: "changed", From: 1, To: 3},
+	}, log.Values)
+	is.Contains(log.NotesDiff, "-Version 0.1.0\n+Version 0.2.0\n")
+	is.Len(log.Revisions, 3)
+	is.Equal(RevisionSummary{Revision: 3, Status: "failed", Description: "revision 3", Operator: "helm"}, log.Revisions[1])
+}
+
+func TestChangelogDefaultRevisions(t *testing.T) {
+	config := actionConfigFixture(t)
+	for _, rel := range []*release.Release{
+		changelogRelease(1, release.StatusSuperseded, "0.1.0", "nginx:1.25", nil),
+		changelogRelease(2, release.StatusSuperseded, "0.1.0", "nginx:1.25", nil),
+		changelogRelease(3, release.StatusFailed, "0.2.0", "nginx:1.27", nil),
+		changelogRelease(4, release.StatusDeployed, "0.2.0", "nginx:1.27", nil),
+	} {
+		require.NoError(t, config.Releases.Create(rel))
+	}
+
+	// The latest revision is compared with the last one deployed before it,
+	// skipping the failed one.
+	log, err := NewChangelog(config).Run("web")
+	require.NoError(t, err)
+	assert.Equal(t, 2, log.From)
+	assert.Equal(t, 4, log.To)
+	assert.Empty(t, log.Values)
+	assert.Len(t, log.Revisions, 2)
+
+	client := NewChangelog(config)
+	client.To = 3
+	log, err = client.Run("web")
+	require.NoError(t, err)
+	assert.Equal(t, 2, log.From)
+	assert.Equal(t, 3, log.To)
+}
+
+func TestChangelogErrors(t *testing.T) {
+	config := actionConfigFixture(t)
+	require.NoError(t, config.Releases.Create(changelogRelease(1, release.StatusDeployed, "0.1.0", "nginx:1.25", nil)))
+	require.NoError(t, config.Releases.Create(changelogRelease(3, release.StatusDeployed, "0.1.0", "nginx:1.25", nil)))
+
+	for _, tt := range []struct {
+		name     string
+		from, to int
+		release  string
+		wantErr  string
+	}{
+		{name: "no deployed revision before", to: 1, release: "web", wantErr: `release "web" has no revision deployed before revision 1`},
+		{name: "revision not stored", from: 2, to: 3, release: "web", wantErr: `revision 2 of release "web" is not stored`},
+		{name: "reversed revisions", from: 3, to: 1, release: "web", wantErr: "--from 3 must be before --to 1"},
+		{name: "unknown release", release: "unknown", wantErr: "not found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewChangelog(config)
+			client.From, client.To = tt.from, tt.to
+			_, err := client.Run(tt.release)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
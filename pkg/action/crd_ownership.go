@@ -0,0 +1,214 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	"helm.sh/helm/v4/pkg/kube"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+const (
+	// crdOwnerAnnotation records the release, as "namespace/name", which
+	// installed a CRD from the crds/ directory of its chart.
+	crdOwnerAnnotation = "meta.helm.sh/crd-owner"
+	// crdSharedByAnnotation lists, separated by commas, the releases sharing
+	// a CRD. A shared CRD belongs to none of them.
+	crdSharedByAnnotation = "meta.helm.sh/crd-shared-by"
+)
+
+// ErrCRDOwnedByOtherRelease is returned by an install when a CRD of the chart
+// already exists and was installed by another release, unless the install
+// shares the CRDs.
+type ErrCRDOwnedByOtherRelease struct {
+	// CRD is the name of the CustomResourceDefinition.
+	CRD string
+	// Release and Namespace identify the release owning the CRD.
+	Release   string
+	Namespace string
+}
+
+func (e *ErrCRDOwnedByOtherRelease) Error() string {
+	return fmt.Sprintf("CRD %s is owned by release %q in namespace %q: use --share-crds to share it between the releases", e.CRD, e.Release, e.Namespace)
+}
+
+// CRDExclusivelyOwned reports whether the CRD obj was installed by the release
+// name in namespace, and is not shared with other releases. Helm never deletes
+// the CRDs of the crds/ directory, but only the CRDs exclusively owned by a
+// release could safely be removed along with it.
+func CRDExclusivelyOwned(obj runtime.Object, name, namespace string) bool {
+	annos, err := accessor.Annotations(obj)
+	if err != nil {
+		return false
+	}
+	return annos[crdOwnerAnnotation] == crdOwnerKey(name, namespace) && annos[crdSharedByAnnotation] == ""
+}
+
+func crdOwnerKey(name, namespace string) string {
+	return namespace + "/" + name
+}
+
+// claimCRDs records the release as the owner of the CRDs it is about to
+// create, and as sharing them if ShareCRDs is set.
+func (i *Install) claimCRDs(res kube.ResourceList) error {
+	key := crdOwnerKey(i.ReleaseName, i.Namespace)
+	annotations := map[string]string{crdOwnerAnnotation: key}
+	if i.ShareCRDs {
+		annotations[crdSharedByAnnotation] = key
+	}
+	return res.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := mergeAnnotations(info.Object, annotations); err != nil {
+			return fmt.Errorf("CRD %s annotations could not be updated: %w", info.Name, err)
+		}
+		return nil
+	})
+}
+
+// crdOwnerInstalled reports whether the release owning a CRD is still
+// installed. Only the releases of the namespace of the install can be looked
+// up in its storage: the owners from other namespaces are assumed to be
+// installed.
+func (i *Install) crdOwnerInstalled(name, namespace string) (bool, error) {
+	if namespace != i.Namespace {
+		return true, nil
+	}
+	history, err := i.cfg.Releases.History(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get the history of the release %q owning CRDs: %w", name, err)
+	}
+	return slices.ContainsFunc(history, func(r *release.Release) bool {
+		return r.Info != nil && r.Info.Status != release.StatusUninstalled
+	}), nil
+}
+
+// checkCRDsOwnership checks, without changing anything, that none of the CRDs
+// of res which already exist is an ErrCRDOwnedByOtherRelease, so that the CRDs
+// are not created when one of them cannot be used.
+func (i *Install) checkCRDsOwnership(res kube.ResourceList) error {
+	return res.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		_, _, err = i.existingCRDAnnotations(info)
+		return err
+	})
+}
+
+// checkExistingCRDs checks that the CRDs which already exist can be used by the
+// release. CRDs installed by another release are an ErrCRDOwnedByOtherRelease,
+// unless ShareCRDs is set, in which case the release is recorded as sharing
+// them. CRDs owned by a release which has since been uninstalled are claimed
+// again. CRDs without an owner were installed before Helm recorded owners, or
+// by other means, and are used as they are.
+func (i *Install) checkExistingCRDs(res kube.ResourceList) error {
+	return res.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		helper, annotations, err := i.existingCRDAnnotations(info)
+		if err != nil || annotations == nil {
+			return err
+		}
+		if err := patchCRDAnnotations(helper, info, annotations); err != nil {
+			return fmt.Errorf("could not claim the CRD %s: %w", info.Name, err)
+		}
+		i.cfg.log().Debug("CRD ownership updated", "crd", info.Name, "annotations", annotations)
+		return nil
+	})
+}
+
+// existingCRDAnnotations returns the annotations to set on the CRD info, if it
+// exists, for the release to claim or share it as checkExistingCRDs does, or nil
+// if it can be used as it is.
+func (i *Install) existingCRDAnnotations(info *resource.Info) (*resource.Helper, map[string]interface{}, error) {
+	key := crdOwnerKey(i.ReleaseName, i.Namespace)
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	existing, err := helper.Get(info.Namespace, info.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return helper, nil, nil
+		}
+		return nil, nil, fmt.Errorf("could not get information about the CRD %s: %w", info.Name, err)
+	}
+	annos, err := accessor.Annotations(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owner := annos[crdOwnerAnnotation]
+	var sharedBy []string
+	if s := annos[crdSharedByAnnotation]; s != "" {
+		sharedBy = strings.Split(s, ",")
+	}
+	if owner == "" || owner == key || slices.Contains(sharedBy, key) {
+		return helper, nil, nil
+	}
+	namespace, name, _ := strings.Cut(owner, "/")
+	if len(sharedBy) == 0 {
+		installed, err := i.crdOwnerInstalled(name, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !installed {
+			// The owner is no longer installed: the CRD is claimed again.
+			annotations := map[string]interface{}{crdOwnerAnnotation: key}
+			if i.ShareCRDs {
+				annotations[crdSharedByAnnotation] = key
+			}
+			return helper, annotations, nil
+		}
+	}
+	if !i.ShareCRDs {
+		return nil, nil, &ErrCRDOwnedByOtherRelease{CRD: info.Name, Release: name, Namespace: namespace}
+	}
+
+	if len(sharedBy) == 0 {
+		sharedBy = []string{owner}
+	}
+	sharedBy = append(sharedBy, key)
+	return helper, map[string]interface{}{crdSharedByAnnotation: strings.Join(sharedBy, ",")}, nil
+}
+
+// patchCRDAnnotations sets the annotations of the existing CRD info with a
+// merge patch.
+func patchCRDAnnotations(helper *resource.Helper, info *resource.Info, annotations map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = helper.Patch(info.Namespace, info.Name, types.MergePatchType, patch, nil)
+	return err
+}
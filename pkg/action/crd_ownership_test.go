@@ -0,0 +1,222 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest/fake"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// existingCRD returns a resource standing for a CRD which already exists with
+// the annotations, and records the patches sent for it.
+func existingCRD(annotations map[string]string, patches *[]string) *resource.Info {
+	// The objects served do not matter, only their annotations are read.
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com", Annotations: annotations},
+	}
+	body := runtime.EncodeOrDie(appsv1Codec, obj)
+	return &resource.Info{
+		Name: "widgets.example.com",
+		Mapping: &meta.RESTMapping{
+			Resource:         schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployment"},
+			GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Scope:            meta.RESTScopeRoot,
+		},
+		Object: obj.DeepCopy(),
+		Client: &fake.RESTClient{
+			GroupVersion:         appsV1GV,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+				if req.Method == http.MethodPatch {
+					data, _ := io.ReadAll(req.Body)
+					*patches = append(*patches, string(data))
+				}
+				header := http.Header{}
+				header.Set("Content-Type", runtime.ContentTypeJSON)
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: stringBody(body)}, nil
+			}),
+		},
+	}
+}
+
+func TestInstallCRDsOwnership(t *testing.T) {
+	crds := []chart.CRD{{Name: "crds/widgets.yaml", File: &chart.File{Name: "crds/widgets.yaml", Data: []byte("kind: CustomResourceDefinition")}}}
+	otherRelease := map[string]string{crdOwnerAnnotation: "other-ns/other"}
+
+	staleOwner := map[string]string{crdOwnerAnnotation: "spaced/previous"}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		releases    []*release.Release
+		share       bool
+		err         error
+		patches     []string
+	}{
+		{
+			name:        "owned by another release",
+			annotations: otherRelease,
+			err:         &ErrCRDOwnedByOtherRelease{CRD: "widgets.example.com", Release: "other", Namespace: "other-ns"},
+		},
+		{
+			name:        "owned by another installed release of the namespace",
+			annotations: staleOwner,
+			releases:    []*release.Release{namedReleaseStub("previous", release.StatusDeployed)},
+			err:         &ErrCRDOwnedByOtherRelease{CRD: "widgets.example.com", Release: "previous", Namespace: "spaced"},
+		},
+		{
+			name:        "reinstalled after the owner was uninstalled",
+			annotations: staleOwner,
+			patches:     []string{`{"metadata":{"annotations":{"meta.helm.sh/crd-owner":"spaced/crds"}}}`},
+		},
+		{
+			name:        "reinstalled after the owner was uninstalled keeping its history",
+			annotations: staleOwner,
+			releases:    []*release.Release{namedReleaseStub("previous", release.StatusUninstalled)},
+			share:       true,
+			patches:     []string{`{"metadata":{"annotations":{"meta.helm.sh/crd-owner":"spaced/crds","meta.helm.sh/crd-shared-by":"spaced/crds"}}}`},
+		},
+		{
+			name:        "shared with another release",
+			annotations: otherRelease,
+			share:       true,
+			patches:     []string{`{"metadata":{"annotations":{"meta.helm.sh/crd-shared-by":"other-ns/other,spaced/crds"}}}`},
+		},
+		{
+			name:        "already shared",
+			annotations: map[string]string{crdOwnerAnnotation: "other-ns/other", crdSharedByAnnotation: "other-ns/other,spaced/crds"},
+		},
+		{
+			name:        "owned by the release",
+			annotations: map[string]string{crdOwnerAnnotation: "spaced/crds"},
+		},
+		{
+			name: "without owner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patches []string
+			info := existingCRD(tt.annotations, &patches)
+			cfg := actionConfigFixture(t)
+			for _, rel := range tt.releases {
+				rel.Namespace = "spaced"
+				require.NoError(t, cfg.Releases.Create(rel))
+			}
+			cfg.KubeClient = &kubefake.FailingKubeClient{
+				PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard},
+				DummyResources:     kube.ResourceList{info},
+				CreateError:        apierrors.NewAlreadyExists(schema.GroupResource{Resource: "customresourcedefinitions"}, info.Name),
+			}
+			instAction := NewInstall(cfg)
+			instAction.ReleaseName = "crds"
+			instAction.Namespace = "spaced"
+			instAction.ShareCRDs = tt.share
+
+			err := instAction.installCRDs(crds)
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.patches, patches)
+		})
+	}
+}
+
+func TestInstallCRDsOwnedByOtherReleaseCreatesNone(t *testing.T) {
+	crds := []chart.CRD{
+		{Name: "crds/gadgets.yaml", File: &chart.File{Name: "crds/gadgets.yaml", Data: []byte("kind: CustomResourceDefinition")}},
+		{Name: "crds/widgets.yaml", File: &chart.File{Name: "crds/widgets.yaml", Data: []byte("kind: CustomResourceDefinition")}},
+	}
+
+	var patches []string
+	info := existingCRD(map[string]string{crdOwnerAnnotation: "other-ns/other"}, &patches)
+	var created bytes.Buffer
+	cfg := actionConfigFixture(t)
+	cfg.KubeClient = &kubefake.FailingKubeClient{
+		PrintingKubeClient: kubefake.PrintingKubeClient{Out: &created},
+		DummyResources:     kube.ResourceList{info},
+	}
+	instAction := NewInstall(cfg)
+	instAction.ReleaseName = "crds"
+	instAction.Namespace = "spaced"
+
+	err := instAction.installCRDs(crds)
+	assert.Equal(t, &ErrCRDOwnedByOtherRelease{CRD: "widgets.example.com", Release: "other", Namespace: "other-ns"}, err)
+	assert.Empty(t, created.String())
+	assert.Empty(t, patches)
+}
+
+func TestErrCRDOwnedByOtherRelease(t *testing.T) {
+	err := &ErrCRDOwnedByOtherRelease{CRD: "widgets.example.com", Release: "other", Namespace: "other-ns"}
+	assert.EqualError(t, err, `CRD widgets.example.com is owned by release "other" in namespace "other-ns": use --share-crds to share it between the releases`)
+}
+
+func TestInstallCRDsClaimsOwnership(t *testing.T) {
+	crds := []chart.CRD{{Name: "crds/widgets.yaml", File: &chart.File{Name: "crds/widgets.yaml", Data: []byte("kind: CustomResourceDefinition")}}}
+
+	for _, share := range []bool{false, true} {
+		var patches []string
+		info := existingCRD(nil, &patches)
+		cfg := actionConfigFixtureWithDummyResources(t, kube.ResourceList{info})
+		cfg.RESTClientGetter = newCapabilitiesRESTClientGetter(coreResources, customResources)
+		instAction := NewInstall(cfg)
+		instAction.ReleaseName = "crds"
+		instAction.Namespace = "spaced"
+		instAction.ShareCRDs = share
+		require.NoError(t, instAction.installCRDs(crds))
+
+		annos, err := accessor.Annotations(info.Object)
+		require.NoError(t, err)
+		assert.Equal(t, "spaced/crds", annos[crdOwnerAnnotation])
+		assert.Equal(t, !share, CRDExclusivelyOwned(info.Object, "crds", "spaced"))
+		if share {
+			assert.Equal(t, "spaced/crds", annos[crdSharedByAnnotation])
+		}
+	}
+}
+
+func TestCRDExclusivelyOwned(t *testing.T) {
+	crd := func(annotations map[string]string) runtime.Object {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+
+	assert.True(t, CRDExclusivelyOwned(crd(map[string]string{crdOwnerAnnotation: "ns/rel"}), "rel", "ns"))
+	assert.False(t, CRDExclusivelyOwned(crd(map[string]string{crdOwnerAnnotation: "ns/other"}), "rel", "ns"))
+	assert.False(t, CRDExclusivelyOwned(crd(map[string]string{crdOwnerAnnotation: "ns/rel", crdSharedByAnnotation: "ns/rel,ns/other"}), "rel", "ns"))
+	assert.False(t, CRDExclusivelyOwned(crd(nil), "rel", "ns"))
+}
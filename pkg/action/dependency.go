@@ -45,6 +45,12 @@ type Dependency struct {
 	CaFile                string
 	InsecureSkipTLSverify bool
 	PlainHTTP             bool
+	// VerifyOnly checks the charts/ directory against the lock file instead
+	// of building it.
+	VerifyOnly bool
+	// PreferCache takes the dependencies from the repository cache when it
+	// holds them, and only refreshes the repositories for the others.
+	PreferCache bool
 }
 
 // NewDependency creates a new Dependency object with the given configuration.
@@ -0,0 +1,72 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// descriptionContext is the data a description template is rendered with.
+type descriptionContext struct {
+	Release struct {
+		Name      string
+		Namespace string
+	}
+	Chart struct {
+		Name       string
+		Version    string
+		AppVersion string
+	}
+}
+
+// renderDescription returns the description of the release rel: description
+// itself, or, when isTemplate is set, description rendered as a Go template
+// with the name and namespace of the release, the name, version and app
+// version of its chart, and an env function returning environment variables.
+func renderDescription(description string, isTemplate bool, rel *release.Release) (string, error) {
+	if !isTemplate || description == "" {
+		return description, nil
+	}
+
+	tpl, err := template.New("description").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": os.Getenv}).
+		Parse(description)
+	if err != nil {
+		return "", fmt.Errorf("invalid description template: %w", err)
+	}
+
+	var data descriptionContext
+	data.Release.Name = rel.Name
+	data.Release.Namespace = rel.Namespace
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		data.Chart.Name = rel.Chart.Metadata.Name
+		data.Chart.Version = rel.Chart.Metadata.Version
+		data.Chart.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("unable to render the description template: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestRenderDescription(t *testing.T) {
+	t.Setenv("CI_PIPELINE_ID", "4242")
+	rel := &release.Release{
+		Name:      "web",
+		Namespace: "spaced",
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "hello", Version: "0.1.0", AppVersion: "1.2.3"}},
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		isTemplate  bool
+		expect      string
+		wantErr     string
+	}{
+		{
+			name:        "literal with braces",
+			description: `deployed by {{ env "CI_PIPELINE_ID" }}`,
+			expect:      `deployed by {{ env "CI_PIPELINE_ID" }}`,
+		},
+		{
+			name:        "template",
+			description: `deployed by {{ env "CI_PIPELINE_ID" }}: {{ .Release.Namespace }}/{{ .Release.Name }} {{ .Chart.Name }}-{{ .Chart.Version }} ({{ .Chart.AppVersion }})`,
+			isTemplate:  true,
+			expect:      "deployed by 4242: spaced/web hello-0.1.0 (1.2.3)",
+		},
+		{
+			name:        "unset environment variable",
+			description: `{{ env "HELM_UNSET_VARIABLE" }}done`,
+			isTemplate:  true,
+			expect:      "done",
+		},
+		{
+			name:        "empty template",
+			description: "",
+			isTemplate:  true,
+			expect:      "",
+		},
+		{
+			name:        "invalid template",
+			description: "{{ .Release.Name",
+			isTemplate:  true,
+			wantErr:     "invalid description template",
+		},
+		{
+			name:        "unknown field",
+			description: "{{ .Release.Revision }}",
+			isTemplate:  true,
+			wantErr:     "unable to render the description template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderDescription(tt.description, tt.isTemplate, rel)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func TestInstallDescriptionTemplate(t *testing.T) {
+	t.Setenv("CI_PIPELINE_ID", "4242")
+
+	instAction := installAction(t)
+	instAction.Description = `deployed by {{ env "CI_PIPELINE_ID" }} to {{ .Release.Namespace }}`
+	instAction.DescriptionIsTemplate = true
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "deployed by 4242 to spaced", res.Info.Description)
+
+	instAction = installAction(t)
+	instAction.Description = "literal {{ braces }}"
+	res, err = instAction.Run(buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "literal {{ braces }}", res.Info.Description)
+}
+
+func TestInstallDescriptionTemplateError(t *testing.T) {
+	instAction := installAction(t)
+	instAction.Description = "{{ .Release.Unknown }}"
+	instAction.DescriptionIsTemplate = true
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	require.ErrorContains(t, err, "unable to render the description template")
+
+	// The release was not recorded.
+	_, err = instAction.cfg.Releases.Last(instAction.ReleaseName)
+	assert.Error(t, err)
+}
+
+func TestUpgradeDescriptionTemplate(t *testing.T) {
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Info.Status = release.StatusDeployed
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	upAction.Description = "{{ .Chart.Name }} {{ .Chart.Version }} upgraded"
+	upAction.DescriptionIsTemplate = true
+	res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello 0.1.0 upgraded", res.Info.Description)
+
+	upAction.Description = "{{ .Release.Unknown }}"
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	require.ErrorContains(t, err, "unable to render the description template")
+
+	// The failed upgrade did not record a new revision.
+	last, err := upAction.cfg.Releases.Last(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, res.Version, last.Version)
+}
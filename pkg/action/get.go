@@ -17,6 +17,14 @@ limitations under the License.
 package action
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -28,6 +36,13 @@ type Get struct {
 
 	// Initializing Version to 0 will get the latest revision of the release.
 	Version int
+
+	// Kinds, Names and APIVersions restrict the manifest returned by
+	// RunManifest to the resources whose kind, name and apiVersion match,
+	// ignoring case, one of the values of each of them which is set.
+	Kinds       []string
+	Names       []string
+	APIVersions []string
 }
 
 // NewGet creates a new Get object with the given configuration.
@@ -45,3 +60,66 @@ func (g *Get) Run(name string) (*release.Release, error) {
 
 	return g.cfg.releaseContent(name, g.Version)
 }
+
+// RunManifest returns the manifest of the given release, keeping only the
+// resources matching Kinds, Names and APIVersions along with their
+// "# Source:" comments. It returns an error listing the kinds of the release
+// when no resource matches.
+func (g *Get) RunManifest(name string) (string, error) {
+	rel, err := g.Run(name)
+	if err != nil {
+		return "", err
+	}
+	if len(g.Kinds) == 0 && len(g.Names) == 0 && len(g.APIVersions) == 0 {
+		return rel.Manifest, nil
+	}
+
+	manifests := releaseutil.SplitManifests(rel.Manifest)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var b strings.Builder
+	kinds := map[string]struct{}{}
+	for _, k := range keys {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(manifests[k]), &head); err != nil || head.Kind == "" {
+			continue
+		}
+		kinds[head.Kind] = struct{}{}
+
+		var resourceName string
+		if head.Metadata != nil {
+			resourceName = head.Metadata.Name
+		}
+		if matchesAnyFold(g.Kinds, head.Kind) && matchesAnyFold(g.Names, resourceName) && matchesAnyFold(g.APIVersions, head.Version) {
+			fmt.Fprintf(&b, "---\n%s\n", manifests[k])
+		}
+	}
+
+	if b.Len() == 0 {
+		if len(kinds) == 0 {
+			return "", fmt.Errorf("no resource of release %q matches the filter: the release has no resources", name)
+		}
+		available := make([]string, 0, len(kinds))
+		for kind := range kinds {
+			available = append(available, kind)
+		}
+		slices.Sort(available)
+		return "", fmt.Errorf("no resource of release %q matches the filter; the kinds in the release are: %s", name, strings.Join(available, ", "))
+	}
+	return b.String(), nil
+}
+
+// matchesAnyFold reports whether s is equal, ignoring case, to one of the
+// values, or whether there are no values to match.
+func matchesAnyFold(values []string, s string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, s)
+	})
+}
@@ -17,11 +17,14 @@ limitations under the License.
 package action
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
 // GetMetadata is the action for checking a given release's metadata.
@@ -47,6 +50,40 @@ type Metadata struct {
 	Revision     int                 `json:"revision" yaml:"revision"`
 	Status       string              `json:"status" yaml:"status"`
 	DeployedAt   string              `json:"deployedAt" yaml:"deployedAt"`
+	// AppliedBy is the local user who ran the operation which produced the
+	// revision, if it was recorded
+	AppliedBy string `json:"appliedBy,omitempty" yaml:"appliedBy,omitempty"`
+	// Policy constrains the upgrades, rollbacks and uninstalls of the release
+	Policy *release.Policy `json:"policy,omitempty" yaml:"policy,omitempty"`
+	// Pin blocks the upgrades, rollbacks and uninstalls of the release
+	Pin *release.Pin `json:"pin,omitempty" yaml:"pin,omitempty"`
+	// ValuesEdited is set when the values were edited by hand before the
+	// revision was installed or upgraded
+	ValuesEdited bool `json:"valuesEdited,omitempty" yaml:"valuesEdited,omitempty"`
+	// NamespaceDefaults identifies the default values of the namespace which
+	// were layered beneath the values of the revision
+	NamespaceDefaults *release.NamespaceDefaults `json:"namespaceDefaults,omitempty" yaml:"namespaceDefaults,omitempty"`
+	// ValuesMigrations lists the changes made to the reused values by the
+	// values migrations of the chart
+	ValuesMigrations []string `json:"valuesMigrations,omitempty" yaml:"valuesMigrations,omitempty"`
+	// ReleaseAnnotations are the free-form metadata of the release, unlike
+	// Annotations, which are the ones of the chart
+	ReleaseAnnotations map[string]string `json:"releaseAnnotations,omitempty" yaml:"releaseAnnotations,omitempty"`
+}
+
+// RevisionMetadata is the metadata of a single revision of a release.
+type RevisionMetadata struct {
+	Revision    int    `json:"revision" yaml:"revision"`
+	Chart       string `json:"chart" yaml:"chart"`
+	Version     string `json:"version" yaml:"version"`
+	AppVersion  string `json:"appVersion" yaml:"appVersion"`
+	Status      string `json:"status" yaml:"status"`
+	DeployedAt  string `json:"deployedAt" yaml:"deployedAt"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// AppliedBy is the local user who ran the operation which produced the
+	// revision, if it was recorded
+	AppliedBy string            `json:"appliedBy,omitempty" yaml:"appliedBy,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 // NewGetMetadata creates a new GetMetadata object with the given configuration.
@@ -79,9 +116,56 @@ func (g *GetMetadata) Run(name string) (*Metadata, error) {
 		Revision:     rel.Version,
 		Status:       rel.Info.Status.String(),
 		DeployedAt:   rel.Info.LastDeployed.Format(time.RFC3339),
+		AppliedBy:    rel.Info.AppliedBy,
+		Policy:       rel.Policy,
+		Pin:          rel.Pin,
+		ValuesEdited: rel.Info.ValuesEdited,
+
+		NamespaceDefaults: rel.Info.NamespaceDefaults,
+		ValuesMigrations:  rel.Info.ValuesMigrations,
+
+		ReleaseAnnotations: rel.Annotations,
 	}, nil
 }
 
+// RunAllRevisions returns the metadata of every stored revision of the given
+// release, ordered by revision.
+func (g *GetMetadata) RunAllRevisions(name string) ([]RevisionMetadata, error) {
+	if err := g.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+
+	rels, err := g.cfg.Releases.History(name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].Version < rels[j].Version })
+
+	revisions := make([]RevisionMetadata, 0, len(rels))
+	for _, rel := range rels {
+		rev := RevisionMetadata{
+			Revision: rel.Version,
+			Labels:   rel.Labels,
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			rev.Chart = rel.Chart.Metadata.Name
+			rev.Version = rel.Chart.Metadata.Version
+			rev.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		if rel.Info != nil {
+			rev.Status = rel.Info.Status.String()
+			rev.DeployedAt = rel.Info.LastDeployed.Format(time.RFC3339)
+			rev.Description = rel.Info.Description
+			rev.AppliedBy = rel.Info.AppliedBy
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
 // FormattedDepNames formats metadata.dependencies names into a comma-separated list.
 func (m *Metadata) FormattedDepNames() string {
 	depsNames := make([]string, 0, len(m.Dependencies))
@@ -17,11 +17,16 @@ limitations under the License.
 package action
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
 
 	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
 func TestGetMetadata_Labels(t *testing.T) {
@@ -40,3 +45,57 @@ func TestGetMetadata_Labels(t *testing.T) {
 	assert.Equal(t, metadata.Name, rel.Name)
 	assert.Equal(t, metadata.Labels, customLabels)
 }
+
+func TestGetMetadata_AllRevisions(t *testing.T) {
+	drivers := map[string]func() driver.Driver{
+		"memory": func() driver.Driver {
+			return driver.NewMemory()
+		},
+		"configmaps": func() driver.Driver {
+			return driver.NewConfigMaps(fake.NewClientset().CoreV1().ConfigMaps("default"))
+		},
+		"secrets": func() driver.Driver {
+			return driver.NewSecrets(fake.NewClientset().CoreV1().Secrets("default"))
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			cfg := actionConfigFixture(t)
+			cfg.Releases = storage.Init(newDriver())
+
+			// The revisions are stored out of order.
+			for _, v := range []int{2, 3, 1} {
+				rel := namedReleaseStub("audited", release.StatusSuperseded)
+				rel.Version = v
+				rel.Chart.Metadata.Version = fmt.Sprintf("0.%d.0", v)
+				rel.Info.Description = fmt.Sprintf("Revision %d", v)
+				rel.Info.AppliedBy = fmt.Sprintf("operator-%d", v)
+				rel.Labels = map[string]string{"team": "payments"}
+				if v == 3 {
+					rel.Info.Status = release.StatusDeployed
+				}
+				require.NoError(t, cfg.Releases.Create(rel))
+			}
+
+			revisions, err := NewGetMetadata(cfg).RunAllRevisions("audited")
+			require.NoError(t, err)
+			require.Len(t, revisions, 3)
+			for i, rev := range revisions {
+				v := i + 1
+				assert.Equal(t, v, rev.Revision)
+				assert.Equal(t, "hello", rev.Chart)
+				assert.Equal(t, fmt.Sprintf("0.%d.0", v), rev.Version)
+				assert.Equal(t, fmt.Sprintf("Revision %d", v), rev.Description)
+				assert.Equal(t, fmt.Sprintf("operator-%d", v), rev.AppliedBy)
+				assert.Equal(t, "payments", rev.Labels["team"])
+				assert.NotEmpty(t, rev.DeployedAt)
+			}
+			assert.Equal(t, release.StatusSuperseded.String(), revisions[1].Status)
+			assert.Equal(t, release.StatusDeployed.String(), revisions[2].Status)
+
+			_, err = NewGetMetadata(cfg).RunAllRevisions("missing")
+			assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+		})
+	}
+}
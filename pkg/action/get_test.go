@@ -0,0 +1,159 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getManifestFixture = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+# Source: web/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+---
+# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`
+
+func TestGetRunManifest(t *testing.T) {
+	const deployment = `---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`
+	const worker = `---
+# Source: web/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+`
+	const service = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+	const configMap = `---
+# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`
+
+	tests := []struct {
+		name        string
+		kinds       []string
+		names       []string
+		apiVersions []string
+		expected    string
+	}{{
+		name:     "no filter",
+		expected: getManifestFixture,
+	}, {
+		name:     "kind",
+		kinds:    []string{"Deployment"},
+		expected: deployment + worker,
+	}, {
+		name:     "kind ignoring case",
+		kinds:    []string{"deployment"},
+		expected: deployment + worker,
+	}, {
+		name:     "kind and name",
+		kinds:    []string{"Deployment"},
+		names:    []string{"web"},
+		expected: deployment,
+	}, {
+		name:     "several kinds",
+		kinds:    []string{"ConfigMap", "Service"},
+		expected: service + configMap,
+	}, {
+		name:        "apiVersion",
+		apiVersions: []string{"V1"},
+		expected:    service + configMap,
+	}, {
+		name:     "name",
+		names:    []string{"Web"},
+		expected: service + deployment + configMap,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewGet(actionConfigFixture(t))
+			rel := releaseStub()
+			rel.Manifest = getManifestFixture
+			require.NoError(t, client.cfg.Releases.Create(rel))
+
+			client.Kinds = tt.kinds
+			client.Names = tt.names
+			client.APIVersions = tt.apiVersions
+			manifest, err := client.RunManifest(rel.Name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, manifest)
+		})
+	}
+}
+
+func TestGetRunManifestNoMatch(t *testing.T) {
+	client := NewGet(actionConfigFixture(t))
+	rel := releaseStub()
+	rel.Manifest = getManifestFixture
+	require.NoError(t, client.cfg.Releases.Create(rel))
+
+	client.Kinds = []string{"Deployment"}
+	client.Names = []string{"missing"}
+	_, err := client.RunManifest(rel.Name)
+	assert.EqualError(t, err, `no resource of release "angry-panda" matches the filter; the kinds in the release are: ConfigMap, Deployment, Service`)
+
+	rel = releaseStub()
+	rel.Name = "empty"
+	rel.Manifest = ""
+	require.NoError(t, client.cfg.Releases.Create(rel))
+	_, err = client.RunManifest(rel.Name)
+	assert.EqualError(t, err, `no resource of release "empty" matches the filter: the release has no resources`)
+
+	_, err = client.RunManifest("missing")
+	assert.Error(t, err)
+}
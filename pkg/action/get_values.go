@@ -17,7 +17,14 @@ limitations under the License.
 package action
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/engine"
+	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
 // GetValues is the action for checking a given release's values.
@@ -28,6 +35,14 @@ type GetValues struct {
 
 	Version   int
 	AllValues bool
+	// ResolveTemplates evaluates string values containing template actions
+	// (e.g. "{{ .Chart.AppVersion }}") the way the chart's 'tpl' calls would,
+	// so that the effective values are returned instead of the raw strings.
+	// The stored release is never modified.
+	ResolveTemplates bool
+	// Warnings is populated by Run with one entry per value that could not be
+	// resolved when ResolveTemplates is set. Such values are returned raw.
+	Warnings []string
 }
 
 // NewGetValues creates a new GetValues object with the given configuration.
@@ -39,6 +54,8 @@ func NewGetValues(cfg *Configuration) *GetValues {
 
 // Run executes 'helm get values' against the given release.
 func (g *GetValues) Run(name string) (map[string]interface{}, error) {
+	g.Warnings = nil
+
 	if err := g.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -48,13 +65,106 @@ func (g *GetValues) Run(name string) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	vals := rel.Config
 	// If the user wants all values, compute the values and return.
 	if g.AllValues {
-		cfg, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
+		vals, err = chartutil.CoalesceValues(rel.Chart, rel.Config)
 		if err != nil {
 			return nil, err
 		}
-		return cfg, nil
 	}
-	return rel.Config, nil
+
+	if g.ResolveTemplates {
+		return g.resolveTemplates(rel, vals)
+	}
+	return vals, nil
+}
+
+// resolveTemplates returns a copy of vals with every templated string
+// rendered against the release's chart and render context.
+func (g *GetValues) resolveTemplates(rel *release.Release, vals map[string]interface{}) (map[string]interface{}, error) {
+	tpls := make(map[string]string)
+	collectTemplateStrings("", vals, tpls)
+	if len(tpls) == 0 {
+		return vals, nil
+	}
+
+	caps, err := g.cfg.getCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		IsInstall: rel.Version == 1,
+		IsUpgrade: rel.Version > 1,
+	}
+	top, err := chartutil.ToRenderValues(rel.Chart, rel.Config, options, caps)
+	if err != nil {
+		return nil, err
+	}
+
+	var e engine.Engine
+	e.CustomTemplateFuncs = g.cfg.CustomTemplateFuncs
+	e.FuncMapOptions = g.cfg.FuncMapOptions
+	rendered, failed, err := e.RenderStrings(rel.Chart, top, tpls)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve templated values: %w", err)
+	}
+	for _, key := range slices.Sorted(maps.Keys(failed)) {
+		g.Warnings = append(g.Warnings, fmt.Sprintf("%s: %s", key, failed[key]))
+	}
+
+	return replaceTemplateStrings("", vals, rendered).(map[string]interface{}), nil
+}
+
+// collectTemplateStrings records every string in v that contains a template
+// action, keyed by its path (e.g. "image.tag" or "hosts[0]").
+func collectTemplateStrings(path string, v interface{}, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			collectTemplateStrings(joinValuePath(path, k), val, out)
+		}
+	case []interface{}:
+		for i, val := range v {
+			collectTemplateStrings(fmt.Sprintf("%s[%d]", path, i), val, out)
+		}
+	case string:
+		if strings.Contains(v, "{{") {
+			out[path] = v
+		}
+	}
+}
+
+// replaceTemplateStrings returns a deep copy of v in which the strings found
+// at the paths in rendered are substituted by their rendered value.
+func replaceTemplateStrings(path string, v interface{}, rendered map[string]string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			out[k] = replaceTemplateStrings(joinValuePath(path, k), val, rendered)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = replaceTemplateStrings(fmt.Sprintf("%s[%d]", path, i), val, rendered)
+		}
+		return out
+	case string:
+		if s, ok := rendered[path]; ok {
+			return s
+		}
+	}
+	return v
+}
+
+func joinValuePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
 }
@@ -0,0 +1,124 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"sort"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// RevisionValues holds the values of a single revision of a release.
+type RevisionValues struct {
+	Revision int                    `json:"revision"`
+	Config   map[string]interface{} `json:"config"`
+}
+
+// GetValuesHistory is the action for checking how a release's values changed
+// across its revisions.
+//
+// It provides the implementation of 'helm get values --revision-range'.
+type GetValuesHistory struct {
+	cfg *Configuration
+
+	// From and To bound the range of revisions, inclusively. A zero From
+	// starts at the first revision and a zero To ends at the latest one.
+	From      int
+	To        int
+	AllValues bool
+	// Skipped is populated by Run with the revisions of the range that are no
+	// longer stored, e.g. because they were purged by --history-max.
+	Skipped []int
+	// NotFound is populated by Run with the revisions of the range that are
+	// beyond the latest revision of the release.
+	NotFound []int
+}
+
+// NewGetValuesHistory creates a new GetValuesHistory object with the given configuration.
+func NewGetValuesHistory(cfg *Configuration) *GetValuesHistory {
+	return &GetValuesHistory{
+		cfg: cfg,
+	}
+}
+
+// Run returns the values of the revisions of the named release within the
+// range, ordered by revision.
+func (g *GetValuesHistory) Run(name string) ([]RevisionValues, error) {
+	g.Skipped = nil
+	g.NotFound = nil
+
+	if err := g.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	if g.From < 0 || g.To < 0 || (g.To != 0 && g.From > g.To) {
+		return nil, fmt.Errorf("invalid revision range %d:%d", g.From, g.To)
+	}
+
+	rels, err := g.cfg.Releases.History(name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].Version < rels[j].Version })
+
+	latest := 0
+	if len(rels) > 0 {
+		latest = rels[len(rels)-1].Version
+	}
+	from, to := g.From, g.To
+	if from == 0 {
+		from = 1
+	}
+	if to == 0 {
+		to = latest
+	}
+
+	var history []RevisionValues
+	next := from
+	for _, rel := range rels {
+		if rel.Version < from || rel.Version > to {
+			continue
+		}
+		for ; next < rel.Version; next++ {
+			g.Skipped = append(g.Skipped, next)
+		}
+		next = rel.Version + 1
+
+		vals := rel.Config
+		if g.AllValues {
+			if vals, err = chartutil.CoalesceValues(rel.Chart, rel.Config); err != nil {
+				return nil, fmt.Errorf("unable to compute values of revision %d: %w", rel.Version, err)
+			}
+		}
+		history = append(history, RevisionValues{Revision: rel.Version, Config: vals})
+	}
+	for ; next <= to; next++ {
+		if next > latest {
+			g.NotFound = append(g.NotFound, next)
+		} else {
+			g.Skipped = append(g.Skipped, next)
+		}
+	}
+
+	if len(history) == 0 {
+		return nil, fmt.Errorf("release %s has no revisions in range %d:%d", name, from, to)
+	}
+	return history, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+func TestGetValuesHistory(t *testing.T) {
+	drivers := map[string]func() driver.Driver{
+		"configmaps": func() driver.Driver {
+			return driver.NewConfigMaps(fake.NewClientset().CoreV1().ConfigMaps("default"))
+		},
+		"secrets": func() driver.Driver {
+			return driver.NewSecrets(fake.NewClientset().CoreV1().Secrets("default"))
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			cfg := actionConfigFixture(t)
+			cfg.Releases = storage.Init(newDriver())
+
+			// Revision 1 was purged, as --history-max would have done.
+			for v := 2; v <= 5; v++ {
+				rel := namedReleaseStub("drift", release.StatusSuperseded)
+				rel.Version = v
+				rel.Config = map[string]interface{}{"replicas": v}
+				rel.Chart.Values = map[string]interface{}{"image": "nginx"}
+				require.NoError(t, cfg.Releases.Create(rel))
+			}
+
+			client := NewGetValuesHistory(cfg)
+			history, err := client.Run("drift")
+			require.NoError(t, err)
+			assert.Equal(t, []int{1}, client.Skipped)
+			require.Len(t, history, 4)
+			for i, rv := range history {
+				assert.Equal(t, i+2, rv.Revision)
+				assert.EqualValues(t, i+2, rv.Config["replicas"])
+			}
+
+			client.From, client.To = 3, 4
+			history, err = client.Run("drift")
+			require.NoError(t, err)
+			assert.Empty(t, client.Skipped)
+			require.Len(t, history, 2)
+			assert.Equal(t, 3, history[0].Revision)
+			assert.Equal(t, 4, history[1].Revision)
+
+			client.From, client.To = 4, 7
+			history, err = client.Run("drift")
+			require.NoError(t, err)
+			assert.Empty(t, client.Skipped)
+			assert.Equal(t, []int{6, 7}, client.NotFound)
+			assert.Len(t, history, 2)
+
+			client.AllValues = true
+			client.From, client.To = 5, 5
+			history, err = client.Run("drift")
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			assert.EqualValues(t, 5, history[0].Config["replicas"])
+			assert.Equal(t, "nginx", history[0].Config["image"], "chart defaults should be included")
+		})
+	}
+}
+
+func TestGetValuesHistory_InvalidRange(t *testing.T) {
+	cfg := actionConfigFixture(t)
+	require.NoError(t, cfg.Releases.Create(namedReleaseStub("drift", release.StatusDeployed)))
+
+	client := NewGetValuesHistory(cfg)
+	client.From, client.To = 3, 2
+	_, err := client.Run("drift")
+	assert.ErrorContains(t, err, "invalid revision range 3:2")
+
+	client.From, client.To = 5, 6
+	_, err = client.Run("drift")
+	assert.ErrorContains(t, err, "release drift has no revisions in range 5:6")
+}
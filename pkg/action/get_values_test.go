@@ -0,0 +1,60 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetValues_ResolveTemplates(t *testing.T) {
+	rel := releaseStub()
+	rel.Chart.Metadata.AppVersion = "1.2.3"
+	rel.Config = map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "{{ .Chart.AppVersion }}",
+		},
+		"hosts":  []interface{}{"{{ .Release.Name }}.example.com"},
+		"broken": "{{ .Values.image.tag",
+	}
+
+	client := NewGetValues(actionConfigFixture(t))
+	require.NoError(t, client.cfg.Releases.Create(rel))
+
+	client.ResolveTemplates = true
+	vals, err := client.Run(rel.Name)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.2.3",
+		},
+		"hosts":  []interface{}{"angry-panda.example.com"},
+		"broken": "{{ .Values.image.tag",
+	}, vals)
+	require.Len(t, client.Warnings, 1)
+	assert.Contains(t, client.Warnings[0], "broken: ")
+
+	// The stored release must be left untouched.
+	stored, err := client.cfg.Releases.Get(rel.Name, rel.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .Chart.AppVersion }}", stored.Config["image"].(map[string]interface{})["tag"])
+}
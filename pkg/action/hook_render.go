@@ -0,0 +1,192 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/engine"
+	"helm.sh/helm/v4/pkg/kube"
+	"helm.sh/helm/v4/pkg/postrender"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// hookStatusFields are the fields of a hook object's status exposed to
+// late-rendered hooks through .HookResults.
+var hookStatusFields = []string{"phase", "succeeded", "failed", "conditions"}
+
+// hookRun holds the state shared by the hooks executed during one operation,
+// such as the pre- and post-install hooks of an install.
+type hookRun struct {
+	// postRenderer and enableDNS are applied to late-rendered hooks the same
+	// way they were applied when rendering the release.
+	postRenderer postrender.PostRenderer
+	enableDNS    bool
+	// results holds the objects created by the hooks which completed so far.
+	results chartutil.HookResults
+}
+
+func newHookRun(pr postrender.PostRenderer, enableDNS bool) *hookRun {
+	return &hookRun{
+		postRenderer: pr,
+		enableDNS:    enableDNS,
+		results:      chartutil.HookResults{},
+	}
+}
+
+// hookKey returns the key of a hook in .HookResults: the name of the object
+// it creates, or its generateName if it has no name.
+func hookKey(h *release.Hook) string {
+	var head releaseutil.SimpleHead
+	if err := yaml.Unmarshal([]byte(h.Manifest), &head); err != nil || head.Metadata == nil {
+		return h.Name
+	}
+	if head.Metadata.Name != "" {
+		return head.Metadata.Name
+	}
+	return head.Metadata.GenerateName
+}
+
+// record stores the objects created by a hook which completed successfully.
+func (r *hookRun) record(log *slog.Logger, h *release.Hook, resources kube.ResourceList) {
+	for _, info := range resources {
+		// Refresh the object to pick up its status once it completed.
+		if info.Client != nil {
+			if err := info.Get(); err != nil {
+				log.Debug("unable to refresh hook resource", "hook", h.Path, "name", info.Name, slog.Any("error", err))
+			}
+		}
+		if info.Object == nil {
+			continue
+		}
+		accessor, err := meta.Accessor(info.Object)
+		if err != nil {
+			continue
+		}
+		kind := info.Object.GetObjectKind().GroupVersionKind().Kind
+		if kind == "" {
+			kind = h.Kind
+		}
+		status := map[string]interface{}{}
+		if obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object); err == nil {
+			if s, ok := obj["status"].(map[string]interface{}); ok {
+				for _, f := range hookStatusFields {
+					if v, ok := s[f]; ok {
+						status[f] = v
+					}
+				}
+			}
+		}
+		r.results[hookKey(h)] = chartutil.NewHookResult(kind, accessor.GetNamespace(), accessor.GetName(), string(accessor.GetUID()), status)
+	}
+}
+
+// resultsFor returns the .HookResults seen by hook h. Hooks of the release
+// which have not run yet are present, but fail the rendering when used.
+func (r *hookRun) resultsFor(rl *release.Release, h *release.Hook) chartutil.HookResults {
+	results := maps.Clone(r.results)
+	name := hookKey(h)
+	for _, other := range rl.Hooks {
+		key := hookKey(other)
+		if key == "" {
+			continue
+		}
+		if _, ok := results[key]; ok {
+			continue
+		}
+		results[key] = chartutil.PendingHookResult(fmt.Errorf("hook %q is rendered late but depends on hook %q, which has not run before it", name, key))
+	}
+	return results
+}
+
+// renderLateHook renders the template of a hook annotated with
+// helm.sh/hook-render: late, with the results of the hooks which ran before
+// it, and replaces the manifest of the hook with the result.
+func (cfg *Configuration) renderLateHook(rl *release.Release, h *release.Hook, run *hookRun) error {
+	caps, err := cfg.getCapabilities()
+	if err != nil {
+		return err
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      rl.Name,
+		Namespace: rl.Namespace,
+		Revision:  rl.Version,
+		IsInstall: rl.Version == 1,
+		IsUpgrade: rl.Version > 1,
+	}
+	// The values were validated against the schema when the release was
+	// rendered.
+	values, err := chartutil.ToRenderValuesWithSchemaValidation(rl.Chart, rl.Config, options, caps, true)
+	if err != nil {
+		return err
+	}
+	values["HookResults"] = run.resultsFor(rl, h)
+
+	e := engine.Engine{}
+	if cfg.RESTClientGetter != nil {
+		restConfig, err := cfg.RESTClientGetter.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		e = engine.New(restConfig)
+	}
+	e.EnableDNS = run.enableDNS
+	e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
+	e.FuncMapOptions = cfg.FuncMapOptions
+	e.Targets = func(name string) bool { return name == h.Path }
+
+	rendered, err := e.Render(rl.Chart, values)
+	if err != nil {
+		return err
+	}
+	files := map[string]string{h.Path: rendered[h.Path]}
+
+	if run.postRenderer != nil {
+		merged, err := annotateAndMerge(files)
+		if err != nil {
+			return fmt.Errorf("error merging manifests: %w", err)
+		}
+		postRendered, err := run.postRenderer.Run(bytes.NewBufferString(merged))
+		if err != nil {
+			return fmt.Errorf("error while running post render on files: %w", err)
+		}
+		if files, err = splitAndDeannotate(postRendered.String()); err != nil {
+			return fmt.Errorf("error while parsing post rendered output: %w", err)
+		}
+	}
+
+	hooks, _, err := releaseutil.SortManifests(files, nil, releaseutil.InstallOrder)
+	if err != nil {
+		return err
+	}
+	for _, rh := range hooks {
+		if rh.Path == h.Path && rh.Kind == h.Kind && hookKey(rh) == hookKey(h) {
+			h.Manifest = rh.Manifest
+			return nil
+		}
+	}
+	return fmt.Errorf("hook %s is no longer rendered", h.Path)
+}
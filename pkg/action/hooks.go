@@ -21,6 +21,7 @@ import (
 	"log"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"helm.sh/helm/v4/pkg/kube"
@@ -28,12 +29,24 @@ import (
 	"gopkg.in/yaml.v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 	helmtime "helm.sh/helm/v4/pkg/time"
 )
 
 // execHook executes all of the hooks for the given hook event.
 func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration) error {
+	return cfg.execHookWithProgress(rl, hook, waitStrategy, timeout, nil, nil)
+}
+
+// execHookWithProgress executes all of the hooks for the given hook event,
+// calling onHook, if set, right before each hook is executed. Hooks rendered
+// late see the results of the hooks previously executed as part of run, which
+// may be nil when no other hooks are executed alongside these ones.
+func (cfg *Configuration) execHookWithProgress(rl *release.Release, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration, onHook func(*release.Hook), run *hookRun) error {
+	if run == nil {
+		run = newHookRun(nil, false)
+	}
 	executingHooks := []*release.Hook{}
 
 	for _, h := range rl.Hooks {
@@ -44,10 +57,26 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 		}
 	}
 
-	// hooke are pre-ordered by kind, so keep order stable
 	sort.Stable(hookByWeight(executingHooks))
+	if len(executingHooks) > 0 {
+		order := make([]string, 0, len(executingHooks))
+		for _, h := range executingHooks {
+			order = append(order, fmt.Sprintf("%s/%s (weight %d)", h.Kind, h.Name, h.Weight))
+		}
+		cfg.log().Debug("executing hooks", "event", hook, "release", rl.Name, "order", strings.Join(order, ", "))
+	}
 
 	for i, h := range executingHooks {
+		if onHook != nil {
+			onHook(h)
+		}
+
+		if h.RenderLate {
+			if err := cfg.renderLateHook(rl, h, run); err != nil {
+				return fmt.Errorf("unable to render %s hook %s: %w", hook, h.Path, err)
+			}
+		}
+
 		// Set default delete policy to before-hook-creation
 		cfg.hookSetDeletePolicy(h)
 
@@ -111,6 +140,7 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 			return err
 		}
 		h.LastRun.Phase = release.HookPhaseSucceeded
+		run.record(cfg.log(), h, resources)
 	}
 
 	// If all hooks are successful, check the annotation of each hook to determine whether the hook should be deleted
@@ -129,16 +159,53 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 	return nil
 }
 
-// hookByWeight is a sorter for hooks
+// validateSkipHookEvents checks that the hook events to skip are all known
+// events, so that a typo does not leave hooks running.
+func validateSkipHookEvents(skip []release.HookEvent) error {
+	for _, e := range skip {
+		if !slices.Contains(release.HookEvents, e) {
+			known := make([]string, 0, len(release.HookEvents))
+			for _, k := range release.HookEvents {
+				known = append(known, k.String())
+			}
+			return fmt.Errorf("unknown hook event %q to skip: the hook events are %s", e, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// runsHooks reports whether the hooks of event are run, given hooks are all
+// disabled by disableHooks, and the hooks of the events of skip are skipped.
+// Skipped hooks stay recorded on the release, without a last run.
+func (cfg *Configuration) runsHooks(event release.HookEvent, disableHooks bool, skip []release.HookEvent) bool {
+	if disableHooks {
+		return false
+	}
+	if slices.Contains(skip, event) {
+		cfg.log().Debug("skipping hooks", "event", event)
+		return false
+	}
+	return true
+}
+
+// hookByWeight sorts hooks in the order they are executed, which is
+// guaranteed: by weight, then by kind in the order resources are installed,
+// then by name. Hooks of equal weight thus always run in the same order,
+// whatever the order they were rendered in.
 type hookByWeight []*release.Hook
 
 func (x hookByWeight) Len() int      { return len(x) }
 func (x hookByWeight) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
 func (x hookByWeight) Less(i, j int) bool {
-	if x[i].Weight == x[j].Weight {
+	switch {
+	case x[i].Weight != x[j].Weight:
+		return x[i].Weight < x[j].Weight
+	case x[i].Kind != x[j].Kind:
+		return releaseutil.InstallOrder.Less(x[i].Kind, x[j].Kind)
+	case x[i].Name != x[j].Name:
 		return x[i].Name < x[j].Name
 	}
-	return x[i].Weight < x[j].Weight
+	return x[i].Path < x[j].Path
 }
 
 // deleteHookByPolicy deletes a hook if the hook policy instructs it to
@@ -149,26 +216,29 @@ func (cfg *Configuration) deleteHookByPolicy(h *release.Hook, policy release.Hoo
 		return nil
 	}
 	if cfg.hookHasDeletePolicy(h, policy) {
-		resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), false)
-		if err != nil {
-			return fmt.Errorf("unable to build kubernetes object for deleting hook %s: %w", h.Path, err)
-		}
-		_, errs := cfg.KubeClient.Delete(resources)
-		if len(errs) > 0 {
-			return joinErrors(errs, "; ")
-		}
-
-		waiter, err := cfg.KubeClient.GetWaiter(waitStrategy)
-		if err != nil {
-			return err
-		}
-		if err := waiter.WaitForDelete(resources, timeout); err != nil {
-			return err
-		}
+		return cfg.deleteHook(h, waitStrategy, timeout)
 	}
 	return nil
 }
 
+// deleteHook deletes the resources of a hook, and waits for them to be gone.
+func (cfg *Configuration) deleteHook(h *release.Hook, waitStrategy kube.WaitStrategy, timeout time.Duration) error {
+	resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), false)
+	if err != nil {
+		return fmt.Errorf("unable to build kubernetes object for deleting hook %s: %w", h.Path, err)
+	}
+	_, errs := cfg.KubeClient.Delete(resources)
+	if len(errs) > 0 {
+		return joinErrors(errs, "; ")
+	}
+
+	waiter, err := cfg.KubeClient.GetWaiter(waitStrategy)
+	if err != nil {
+		return err
+	}
+	return waiter.WaitForDelete(resources, timeout)
+}
+
 // deleteHooksByPolicy deletes all hooks if the hook policy instructs it to
 func (cfg *Configuration) deleteHooksByPolicy(hooks []*release.Hook, policy release.HookDeletePolicy, waitStrategy kube.WaitStrategy, timeout time.Duration) error {
 	for _, h := range hooks {
@@ -20,12 +20,15 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/rand"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/cli-runtime/pkg/resource"
 
@@ -401,3 +404,157 @@ data:
 		})
 	}
 }
+
+// GeneratingKubeClient builds ConfigMaps and, like the API server, names the
+// ones using generateName and assigns them a UID when creating them.
+type GeneratingKubeClient struct {
+	kubefake.PrintingKubeClient
+}
+
+func (*GeneratingKubeClient) Build(reader io.Reader, _ bool) (kube.ResourceList, error) {
+	configMap := &v1.ConfigMap{}
+	err := yaml.NewYAMLOrJSONDecoder(reader, 1000).Decode(configMap)
+	if err == io.EOF {
+		return kube.ResourceList{}, nil
+	}
+	if err != nil {
+		return kube.ResourceList{}, err
+	}
+	return kube.ResourceList{{
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
+		Object:    configMap,
+	}}, nil
+}
+
+func (c *GeneratingKubeClient) Create(resources kube.ResourceList) (*kube.Result, error) {
+	for _, info := range resources {
+		configMap := info.Object.(*v1.ConfigMap)
+		if configMap.Name == "" {
+			configMap.Name = configMap.GenerateName + "x7k2p"
+			info.Name = configMap.Name
+		}
+		configMap.UID = types.UID("uid-" + configMap.Name)
+	}
+	return c.PrintingKubeClient.Create(resources)
+}
+
+func lateHookChart(preHook, postHook string) *chart.Chart {
+	return buildChartWithTemplates([]*chart.File{
+		{Name: "templates/pre-hook.yaml", Data: []byte(preHook)},
+		{Name: "templates/post-hook.yaml", Data: []byte(postHook)},
+	})
+}
+
+func TestInstallRelease_LateRenderedHook(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.cfg.KubeClient = &GeneratingKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}}
+
+	preHook := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  generateName: migrate-
+  annotations:
+    "helm.sh/hook": pre-install
+`
+	postHook := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: report
+  annotations:
+    "helm.sh/hook": post-install
+    "helm.sh/hook-render": late
+data:
+  migration: {{ (index .HookResults "migrate-").Name | quote }}
+  uid: {{ (index .HookResults "migrate-").UID | quote }}
+`
+	res, err := instAction.Run(lateHookChart(preHook, postHook), map[string]interface{}{})
+	is.NoError(err)
+	is.Equal(release.StatusDeployed, res.Info.Status)
+
+	var report *release.Hook
+	for _, h := range res.Hooks {
+		if h.Name == "report" {
+			report = h
+		}
+	}
+	is.NotNil(report)
+	is.True(report.RenderLate)
+	is.Contains(report.Manifest, `migration: "migrate-x7k2p"`)
+	is.Contains(report.Manifest, `uid: "uid-migrate-x7k2p"`)
+}
+
+func TestInstallRelease_LateRenderedHookDependsOnLaterHook(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.cfg.KubeClient = &GeneratingKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}}
+
+	preHook := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setup
+  annotations:
+    "helm.sh/hook": pre-install
+    "helm.sh/hook-render": late
+data:
+  report: {{ (index .HookResults "report").Name | quote }}
+`
+	postHook := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: report
+  annotations:
+    "helm.sh/hook": post-install
+`
+	_, err := instAction.Run(lateHookChart(preHook, postHook), map[string]interface{}{})
+	is.Error(err)
+	is.Contains(err.Error(), `hook "setup" is rendered late but depends on hook "report", which has not run before it`)
+	is.Contains(err.Error(), "pre-install")
+}
+
+// sameWeightHooksRelease returns a release with three pre-install hooks of
+// equal weight, and a heavier one.
+func sameWeightHooksRelease() *release.Release {
+	rel := releaseStub()
+	rel.Hooks = nil
+	for _, h := range []struct {
+		kind, name string
+		weight     int
+	}{
+		{"Job", "migrate", 0},
+		{"Job", "cleanup", 5},
+		{"ConfigMap", "settings", 0},
+		{"Job", "backup", 0},
+	} {
+		rel.Hooks = append(rel.Hooks, &release.Hook{
+			Name:     h.name,
+			Kind:     h.kind,
+			Path:     fmt.Sprintf("templates/%s.yaml", h.name),
+			Manifest: fmt.Sprintf("kind: %s\nmetadata:\n  name: %s\n", h.kind, h.name),
+			Weight:   h.weight,
+			Events:   []release.HookEvent{release.HookPreInstall},
+		})
+	}
+	return rel
+}
+
+func TestExecHookOrderIsStable(t *testing.T) {
+	config := actionConfigFixture(t)
+	rel := sameWeightHooksRelease()
+	require.NoError(t, config.Releases.Create(rel))
+
+	expected := []string{"ConfigMap/settings", "Job/backup", "Job/migrate", "Job/cleanup"}
+	for i := range 100 {
+		rand.Shuffle(len(rel.Hooks), func(i, j int) {
+			rel.Hooks[i], rel.Hooks[j] = rel.Hooks[j], rel.Hooks[i]
+		})
+
+		var order []string
+		err := config.execHookWithProgress(rel, release.HookPreInstall, kube.StatusWatcherStrategy, time.Minute, func(h *release.Hook) {
+			order = append(order, h.Kind+"/"+h.Name)
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, order, "iteration %d", i)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"net/url"
 	"os"
 	"path"
@@ -36,7 +37,6 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/yaml"
@@ -65,6 +65,19 @@ const notesFileSuffix = "NOTES.txt"
 
 const defaultDirectoryPermission = 0755
 
+// TakeOwnershipScope selects the existing resources adopted by an install
+// which takes ownership of them.
+type TakeOwnershipScope string
+
+const (
+	// TakeOwnershipScopeAll adopts every existing resource.
+	TakeOwnershipScopeAll TakeOwnershipScope = "all"
+	// TakeOwnershipScopeNamespacedOnly adopts existing namespaced resources,
+	// and refuses to adopt cluster-scoped resources which do not already
+	// belong to the release, such as ClusterRoles shared by other workloads.
+	TakeOwnershipScopeNamespacedOnly TakeOwnershipScope = "namespaced-only"
+)
+
 // Install performs an installation operation.
 type Install struct {
 	cfg *Configuration
@@ -98,8 +111,19 @@ type Install struct {
 	HideNotes                bool
 	SkipSchemaValidation     bool
 	DisableOpenAPIValidation bool
-	IncludeCRDs              bool
-	Labels                   map[string]string
+	// PruneUnknownFields removes from the rendered resources the fields the
+	// cluster does not know, according to its OpenAPI schemas, instead of
+	// failing the validation. The fields removed are listed in the release
+	// info. It cannot be combined with DisableOpenAPIValidation.
+	PruneUnknownFields bool
+	IncludeCRDs        bool
+	Labels             map[string]string
+	// Annotations are free-form metadata recorded with the release. Their
+	// keys cannot be empty.
+	Annotations map[string]string
+	// Policy constrains the upgrades, rollbacks and uninstalls of the release.
+	// Its tokens are stored hashed.
+	Policy *release.Policy
 	// KubeVersion allows specifying a custom kubernetes version to use and
 	// APIVersions allows a manual set of supported API Versions to be passed
 	// (for things like templating). These are ignored if ClientOnly is false
@@ -109,18 +133,77 @@ type Install struct {
 	IsUpgrade bool
 	// Enable DNS lookups when rendering templates
 	EnableDNS bool
+	// StrictTemplate fails the render on the values the templates use which
+	// are not defined, instead of rendering them empty, see engine.Engine.Strict.
+	StrictTemplate bool
+	// Deterministic renders the templates deterministically, see
+	// engine.Engine.Deterministic, with RandomSeed. It is used by helm
+	// template to hash the rendered manifests.
+	Deterministic bool
+	// RandomSeed seeds the random values of a deterministic render.
+	RandomSeed *int64
 	// Used by helm template to add the release as part of OutputDir path
 	// OutputDir/<ReleaseName>
 	UseReleaseName bool
+	// OutputLayout is the layout of the files written to OutputDir. The
+	// default is OutputLayoutFlat.
+	OutputLayout OutputLayout
+	// OutputKustomize writes a kustomization.yaml to OutputDir, listing the
+	// files in the order Helm installs them. It implies OutputLayoutChart.
+	OutputKustomize bool
+	// SkipTests leaves the test hooks out of OutputDir in the chart layout.
+	SkipTests bool
 	// TakeOwnership will ignore the check for helm annotations and take ownership of the resources.
 	TakeOwnership bool
-	PostRenderer  postrender.PostRenderer
+	// TakeOwnershipScope limits the resources adopted when taking ownership.
+	// Setting it takes ownership even if TakeOwnership is false. An empty
+	// scope is TakeOwnershipScopeAll.
+	TakeOwnershipScope TakeOwnershipScope
+	PostRenderer       postrender.PostRenderer
+	// ShowOnly, if set, limits rendering to the templates matching these glob
+	// patterns (relative to the chart, e.g. "templates/deployment.yaml") and
+	// whatever they depend on. It is used by 'helm template --show-only'.
+	ShowOnly []string
+	// ProgressFunc, if set, is called synchronously and in order as the
+	// installation goes through its phases. It cannot abort the installation.
+	ProgressFunc func(event ProgressEvent)
+	// AtomicCleanupTimeout is the time given to the uninstall cleaning up
+	// after a failed atomic install. It defaults to Timeout.
+	AtomicCleanupTimeout time.Duration
+	// ServerSideApply selects whether the resources are created with
+	// server-side apply, with the field manager of the configuration, instead
+	// of client-side. It cannot be ServerSideApplyTrue with Force.
+	ServerSideApply ServerSideApplyMode
+	// ForceConflicts takes over the fields managed by other field managers
+	// when the resources are applied server-side.
+	ForceConflicts bool
+	// DescriptionIsTemplate renders Description as a Go template, with the
+	// name and namespace of the release, the name, version and app version of
+	// the chart, and an env function.
+	DescriptionIsTemplate bool
+	// ValuesEdited records in the release info that the values were edited
+	// by hand before the installation.
+	ValuesEdited bool
+	// SkipHookEvents skips the hooks of these events, which are still
+	// recorded on the release.
+	SkipHookEvents []release.HookEvent
+	// ShareCRDs shares the CRDs of the chart which were installed by other
+	// releases, instead of failing with an ErrCRDOwnedByOtherRelease. Shared
+	// CRDs belong to none of the releases.
+	ShareCRDs bool
 	// Lock to control raceconditions when the process receives a SIGTERM
 	Lock sync.Mutex
+
+	// applier applies the resources server-side, unless it is nil.
+	applier kube.InterfaceServerSideApply
+	// description is the description of the release, Description rendered
+	// when DescriptionIsTemplate is set.
+	description string
 }
 
 // ChartPathOptions captures common options used for controlling chart paths
 type ChartPathOptions struct {
+	BearerToken           string // --bearer-token
 	CaFile                string // --ca-file
 	CertFile              string // --cert-file
 	KeyFile               string // --key-file
@@ -133,10 +216,41 @@ type ChartPathOptions struct {
 	Username              string // --username
 	Verify                bool   // --verify
 	Version               string // --version
+	DownloadRetries       int    // --download-retries
 
 	// registryClient provides a registry client but is not added with
 	// options from a flag
 	registryClient *registry.Client
+	// digest is the digest of the OCI reference LocateChart pulled the
+	// chart by, if it was pinned by digest.
+	digest string
+	// mirroredFrom is the OCI reference LocateChart was given, if the chart
+	// was pulled from a mirror of it.
+	mirroredFrom string
+}
+
+// Digest returns the manifest digest the chart found by LocateChart was pulled
+// by, if its OCI reference was pinned by digest, as in
+// "oci://registry/repo/chart@sha256:...". The pulled content is verified
+// against it.
+func (c *ChartPathOptions) Digest() string {
+	return c.digest
+}
+
+// MirroredFrom returns the OCI reference of the chart found by LocateChart, if
+// it was pulled from a registry mirror of that reference rather than from the
+// reference itself.
+func (c *ChartPathOptions) MirroredFrom() string {
+	return c.mirroredFrom
+}
+
+// bearerToken returns the bearer token given in the options or, if there is
+// none, the one of the environment.
+func (c *ChartPathOptions) bearerToken(settings *cli.EnvSettings) string {
+	if c.BearerToken != "" {
+		return c.BearerToken
+	}
+	return settings.RepositoryBearerToken
 }
 
 // NewInstall creates a new Install object with the given configuration.
@@ -160,21 +274,40 @@ func (i *Install) GetRegistryClient() *registry.Client {
 }
 
 func (i *Install) installCRDs(crds []chart.CRD) error {
-	// We do these one file at a time in the order they were read.
-	totalItems := []*resource.Info{}
+	// The CRDs are all built and their ownership checked before any of them is
+	// created, so that none is created when one belongs to another release.
+	built := make([]kube.ResourceList, 0, len(crds))
 	for _, obj := range crds {
-		// Read in the resources
 		res, err := i.cfg.KubeClient.Build(bytes.NewBuffer(obj.File.Data), false)
 		if err != nil {
 			return fmt.Errorf("failed to install CRD %s: %w", obj.Name, err)
 		}
+		if err := i.checkCRDsOwnership(res); err != nil {
+			return err
+		}
+		built = append(built, res)
+	}
+
+	// We do these one file at a time in the order they were read.
+	totalItems := []*resource.Info{}
+	for n, obj := range crds {
+		i.cfg.emitProgress(i.ProgressFunc, ProgressCRDInstall, i.ReleaseName, "CustomResourceDefinition/"+obj.Name)
+
+		res := built[n]
+		if err := i.claimCRDs(res); err != nil {
+			return err
+		}
 
 		// Send them to Kube
 		if _, err := i.cfg.KubeClient.Create(res); err != nil {
-			// If the error is CRD already exists, continue.
+			// If the error is CRD already exists, continue, unless it belongs
+			// to another release.
 			if apierrors.IsAlreadyExists(err) {
+				if err := i.checkExistingCRDs(res); err != nil {
+					return err
+				}
 				crdName := res[0].Name
-				slog.Debug("CRD is already present. Skipping", "crd", crdName)
+				i.cfg.log().Debug("CRD is already present. Skipping", "crd", crdName)
 				continue
 			}
 			return fmt.Errorf("failed to install CRD %s: %w", obj.Name, err)
@@ -191,33 +324,11 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 			return err
 		}
 
-		// If we have already gathered the capabilities, we need to invalidate
-		// the cache so that the new CRDs are recognized. This should only be
-		// the case when an action configuration is reused for multiple actions,
-		// as otherwise it is later loaded by ourselves when getCapabilities
-		// is called later on in the installation process.
-		if i.cfg.Capabilities != nil {
-			discoveryClient, err := i.cfg.RESTClientGetter.ToDiscoveryClient()
-			if err != nil {
-				return err
-			}
-
-			slog.Debug("clearing discovery cache")
-			discoveryClient.Invalidate()
-
-			_, _ = discoveryClient.ServerGroups()
-		}
-
-		// Invalidate the REST mapper, since it will not have the new CRDs
-		// present.
-		restMapper, err := i.cfg.RESTClientGetter.ToRESTMapper()
-		if err != nil {
+		// Refresh the capabilities and the REST mapper, which do not know
+		// about the new CRDs yet.
+		if _, err := i.cfg.RefreshCapabilities(); err != nil {
 			return err
 		}
-		if resettable, ok := restMapper.(meta.ResettableRESTMapper); ok {
-			slog.Debug("clearing REST mapper cache")
-			resettable.Reset()
-		}
 	}
 	return nil
 }
@@ -236,27 +347,70 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 // When the task is cancelled through ctx, the function returns and the install
 // proceeds in the background.
 func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+	startedAt := time.Now()
+	rel, err := i.runWithContext(ctx, chrt, vals)
+	if i.ClientOnly || i.isDryRun() {
+		return rel, err
+	}
+	// The release may still be written by the install running in the
+	// background if ctx is done.
+	i.Lock.Lock()
+	event := newReleaseEvent(OperationInstall, i.ReleaseName, i.Namespace, rel, chrt, err, startedAt)
+	i.Lock.Unlock()
+	if rerr := i.cfg.notifyRecorder(ctx, event); rerr != nil && err == nil {
+		return rel, rerr
+	}
+	return rel, err
+}
+
+func (i *Install) runWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	// Check reachability of cluster unless in client-only mode (e.g. `helm template` without `--validate`)
 	if !i.ClientOnly {
 		if err := i.cfg.KubeClient.IsReachable(); err != nil {
-			slog.Error(fmt.Sprintf("cluster reachability check failed: %v", err))
+			i.cfg.log().Error(fmt.Sprintf("cluster reachability check failed: %v", err))
 			return nil, fmt.Errorf("cluster reachability check failed: %w", err)
 		}
 	}
 
 	// HideSecret must be used with dry run. Otherwise, return an error.
 	if !i.isDryRun() && i.HideSecret {
-		slog.Error("hiding Kubernetes secrets requires a dry-run mode")
+		i.cfg.log().Error("hiding Kubernetes secrets requires a dry-run mode")
 		return nil, errors.New("hiding Kubernetes secrets requires a dry-run mode")
 	}
 
+	if i.PruneUnknownFields && i.DisableOpenAPIValidation {
+		return nil, errPruneWithoutValidation
+	}
+
+	if err := validateSkipHookEvents(i.SkipHookEvents); err != nil {
+		return nil, err
+	}
+
+	if !i.ClientOnly {
+		var err error
+		if i.applier, err = i.cfg.serverSideApplier(i.ServerSideApply, i.Force, i.ForceConflicts); err != nil {
+			return nil, err
+		}
+	}
+
+	switch i.TakeOwnershipScope {
+	case "", TakeOwnershipScopeAll, TakeOwnershipScopeNamespacedOnly:
+	default:
+		return nil, fmt.Errorf("invalid take ownership scope %q. Valid scopes are %s and %s", i.TakeOwnershipScope, TakeOwnershipScopeAll, TakeOwnershipScopeNamespacedOnly)
+	}
+
 	if err := i.availableName(); err != nil {
-		slog.Error("release name check failed", slog.Any("error", err))
+		i.cfg.log().Error("release name check failed", slog.Any("error", err))
 		return nil, fmt.Errorf("release name check failed: %w", err)
 	}
 
-	if err := chartutil.ProcessDependencies(chrt, vals); err != nil {
-		slog.Error("chart dependencies processing failed", slog.Any("error", err))
+	renderVals, namespaceDefaults, err := i.cfg.applyNamespaceDefaults(vals, i.Namespace, i.ClientOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ProcessDependencies(chrt, renderVals); err != nil {
+		i.cfg.log().Error("chart dependencies processing failed", slog.Any("error", err))
 		return nil, fmt.Errorf("chart dependencies processing failed: %w", err)
 	}
 
@@ -270,7 +424,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	if crds := chrt.CRDObjects(); !i.ClientOnly && !i.SkipCRDs && len(crds) > 0 {
 		// On dry run, bail here
 		if i.isDryRun() {
-			slog.Warn("This chart or one of its subcharts contains CRDs. Rendering may fail or contain inaccuracies.")
+			i.cfg.log().Warn("This chart or one of its subcharts contains CRDs. Rendering may fail or contain inaccuracies.")
 		} else if err := i.installCRDs(crds); err != nil {
 			return nil, err
 		}
@@ -279,18 +433,26 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	if i.ClientOnly {
 		// Add mock objects in here so it doesn't use Kube API server
 		// NOTE(bacongobbler): used for `helm template`
-		i.cfg.Capabilities = chartutil.DefaultCapabilities.Copy()
+		caps := copyCapabilities(chartutil.DefaultCapabilities)
 		if i.KubeVersion != nil {
-			i.cfg.Capabilities.KubeVersion = *i.KubeVersion
+			caps.KubeVersion = *i.KubeVersion
+			// Render with the API versions of that Kubernetes version, so that
+			// they agree with the Kubernetes version.
+			if apiVersions, ok := chartutil.KubeAPIVersions(*i.KubeVersion); ok {
+				caps.APIVersions = apiVersions
+			} else {
+				i.cfg.log().Debug("unknown Kubernetes version, using the default API versions", "version", i.KubeVersion.Version)
+			}
 		}
-		i.cfg.Capabilities.APIVersions = append(i.cfg.Capabilities.APIVersions, i.APIVersions...)
+		caps.APIVersions = append(caps.APIVersions, i.APIVersions...)
+		i.cfg.setCapabilities(caps)
 		i.cfg.KubeClient = &kubefake.PrintingKubeClient{Out: io.Discard}
 
 		mem := driver.NewMemory()
 		mem.SetNamespace(i.Namespace)
 		i.cfg.Releases = storage.Init(mem)
 	} else if !i.ClientOnly && len(i.APIVersions) > 0 {
-		slog.Debug("API Version list given outside of client only mode, this list will be ignored")
+		i.cfg.log().Debug("API Version list given outside of client only mode, this list will be ignored")
 	}
 
 	// Make sure if Atomic is set, that wait is set as well. This makes it so
@@ -313,7 +475,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		IsInstall: !isUpgrade,
 		IsUpgrade: isUpgrade,
 	}
-	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, vals, options, caps, i.SkipSchemaValidation)
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, renderVals, options, caps, i.SkipSchemaValidation)
 	if err != nil {
 		return nil, err
 	}
@@ -321,11 +483,28 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	if driver.ContainsSystemLabels(i.Labels) {
 		return nil, fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
+	if err := validateAnnotations(i.Annotations); err != nil {
+		return nil, err
+	}
 
 	rel := i.createRelease(chrt, vals, i.Labels)
+	rel.Info.NamespaceDefaults = namespaceDefaults
+	if i.description, err = renderDescription(i.Description, i.DescriptionIsTemplate, rel); err != nil {
+		return nil, err
+	}
 
+	i.cfg.emitProgress(i.ProgressFunc, ProgressRender, i.ReleaseName, "")
 	var manifestDoc *bytes.Buffer
-	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret)
+	if i.chartOutputLayout() {
+		// The manifests are rendered to the buffer, and written out with the
+		// CRDs and the hooks below.
+		rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, "", i.SubNotes, false, false, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret, i.ShowOnly, renderOptions{deterministic: i.Deterministic, randomSeed: i.RandomSeed, strict: i.StrictTemplate})
+		if err == nil {
+			err = i.writeOutputLayout(chrt, rel.Hooks, manifestDoc.String())
+		}
+	} else {
+		rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret, i.ShowOnly, renderOptions{deterministic: i.Deterministic, randomSeed: i.RandomSeed, strict: i.StrictTemplate})
+	}
 	// Even for errors, attach this if available
 	if manifestDoc != nil {
 		rel.Manifest = manifestDoc.String()
@@ -341,16 +520,25 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	rel.SetStatus(release.StatusPendingInstall, "Initial install underway")
 
 	var toBeAdopted kube.ResourceList
-	resources, err := i.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !i.DisableOpenAPIValidation)
+	pruneUnknownFields := i.PruneUnknownFields && !i.ClientOnly
+	resources, err := i.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !i.DisableOpenAPIValidation && !pruneUnknownFields)
 	if err != nil {
 		return nil, fmt.Errorf("unable to build kubernetes objects from release manifest: %w", err)
 	}
+	if pruneUnknownFields {
+		if rel.Info.PrunedFields, err = i.cfg.pruneUnknownFields(resources); err != nil {
+			return nil, err
+		}
+	}
 
 	// It is safe to use "force" here because these are resources currently rendered by the chart.
 	err = resources.Visit(setMetadataVisitor(rel.Name, rel.Namespace, true))
 	if err != nil {
 		return nil, err
 	}
+	if err := resources.Visit(checkWaitConditionVisitor); err != nil {
+		return nil, err
+	}
 
 	// Install requires an extra validation step of checking that resources
 	// don't already exist before we actually create resources. If we continue
@@ -359,9 +547,12 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	// deleting the release because the manifest will be pointing at that
 	// resource
 	if !i.ClientOnly && !isUpgrade && len(resources) > 0 {
-		if i.TakeOwnership {
+		switch {
+		case i.TakeOwnershipScope == TakeOwnershipScopeNamespacedOnly:
+			toBeAdopted, err = requireNamespacedAdoption(resources, rel.Name, rel.Namespace)
+		case i.takesOwnership():
 			toBeAdopted, err = requireAdoption(resources)
-		} else {
+		default:
 			toBeAdopted, err = existingResourceConflict(resources, rel.Name, rel.Namespace)
 		}
 		if err != nil {
@@ -371,6 +562,11 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 
 	// Bail out here if it is a dry run
 	if i.isDryRun() {
+		if i.DryRunOption == "server" && i.applier != nil && len(resources) > 0 {
+			if _, err := i.applier.CreateServerSideApply(resources, i.applyOptions(true)); err != nil {
+				return nil, fmt.Errorf("server-side dry run failed: %w", err)
+			}
+		}
 		rel.Info.Description = "Dry run complete"
 		return rel, nil
 	}
@@ -414,7 +610,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		// We could try to recover gracefully here, but since nothing has been installed
 		// yet, this is probably safer than trying to continue when we know storage is
 		// not working.
-		return rel, err
+		return rel, fmt.Errorf("release not created: %w", err)
 	}
 
 	rel, err = i.performInstallCtx(ctx, rel, toBeAdopted, resources)
@@ -444,6 +640,16 @@ func (i *Install) performInstallCtx(ctx context.Context, rel *release.Release, t
 	}
 }
 
+// applyOptions returns the options of the server-side apply of the resources.
+func (i *Install) applyOptions(dryRun bool) kube.ServerSideApplyOptions {
+	return kube.ServerSideApplyOptions{ForceConflicts: i.ForceConflicts, DryRun: dryRun}
+}
+
+// takesOwnership reports whether the install adopts existing resources.
+func (i *Install) takesOwnership() bool {
+	return i.TakeOwnership || i.TakeOwnershipScope != ""
+}
+
 // isDryRun returns true if Upgrade is set to run as a DryRun
 func (i *Install) isDryRun() bool {
 	if i.DryRun || i.DryRunOption == "client" || i.DryRunOption == "server" || i.DryRunOption == "true" {
@@ -454,9 +660,13 @@ func (i *Install) isDryRun() bool {
 
 func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.ResourceList, resources kube.ResourceList) (*release.Release, error) {
 	var err error
+	// Hooks rendered late see the results of the pre-install hooks from the
+	// post-install ones.
+	hooks := newHookRun(i.PostRenderer, i.EnableDNS)
+
 	// pre-install hooks
-	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPreInstall, i.WaitStrategy, i.Timeout); err != nil {
+	if i.cfg.runsHooks(release.HookPreInstall, i.DisableHooks, i.SkipHookEvents) {
+		if err := i.cfg.execHookWithProgress(rel, release.HookPreInstall, i.WaitStrategy, i.Timeout, i.cfg.hookProgress(i.ProgressFunc, ProgressPreHook, rel.Name), hooks); err != nil {
 			return rel, fmt.Errorf("failed pre-install: %s", err)
 		}
 	}
@@ -464,10 +674,17 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 	// At this point, we can do the install. Note that before we were detecting whether to
 	// do an update, but it's not clear whether we WANT to do an update if the reuse is set
 	// to true, since that is basically an upgrade operation.
-	if len(toBeAdopted) == 0 && len(resources) > 0 {
+	i.cfg.emitApplyProgress(i.ProgressFunc, rel.Name, resources)
+	switch {
+	case len(resources) == 0:
+	case i.applier != nil && len(toBeAdopted) == 0:
+		_, err = i.applier.CreateServerSideApply(resources, i.applyOptions(false))
+	case i.applier != nil:
+		_, err = i.applier.UpdateServerSideApply(toBeAdopted, resources, i.applyOptions(false))
+	case len(toBeAdopted) == 0:
 		_, err = i.cfg.KubeClient.Create(resources)
-	} else if len(resources) > 0 {
-		if i.TakeOwnership {
+	default:
+		if i.takesOwnership() {
 			_, err = i.cfg.KubeClient.(kube.InterfaceThreeWayMerge).UpdateThreeWayMerge(toBeAdopted, resources, i.Force)
 		} else {
 			_, err = i.cfg.KubeClient.Update(toBeAdopted, resources, i.Force)
@@ -482,6 +699,7 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 		return rel, fmt.Errorf("failed to get waiter: %w", err)
 	}
 
+	i.cfg.emitProgress(i.ProgressFunc, ProgressWait, rel.Name, "")
 	if i.WaitForJobs {
 		err = waiter.WaitWithJobs(resources, i.Timeout)
 	} else {
@@ -491,17 +709,19 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 		return rel, err
 	}
 
-	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPostInstall, i.WaitStrategy, i.Timeout); err != nil {
+	if i.cfg.runsHooks(release.HookPostInstall, i.DisableHooks, i.SkipHookEvents) {
+		if err := i.cfg.execHookWithProgress(rel, release.HookPostInstall, i.WaitStrategy, i.Timeout, i.cfg.hookProgress(i.ProgressFunc, ProgressPostHook, rel.Name), hooks); err != nil {
 			return rel, fmt.Errorf("failed post-install: %s", err)
 		}
 	}
 
-	if len(i.Description) > 0 {
-		rel.SetStatus(release.StatusDeployed, i.Description)
+	i.Lock.Lock()
+	if len(i.description) > 0 {
+		rel.SetStatus(release.StatusDeployed, i.description)
 	} else {
 		rel.SetStatus(release.StatusDeployed, "Install complete")
 	}
+	i.Lock.Unlock()
 
 	// This is a tricky case. The release has been created, but the result
 	// cannot be recorded. The truest thing to tell the user is that the
@@ -510,30 +730,50 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 	//
 	// One possible strategy would be to do a timed retry to see if we can get
 	// this stored in the future.
+	i.cfg.emitProgress(i.ProgressFunc, ProgressPersist, rel.Name, "")
 	if err := i.recordRelease(rel); err != nil {
-		slog.Error("failed to record the release", slog.Any("error", err))
+		i.cfg.log().Error("failed to record the release", slog.Any("error", err))
 	}
 
 	return rel, nil
 }
 
 func (i *Install) failRelease(rel *release.Release, err error) (*release.Release, error) {
+	i.Lock.Lock()
 	rel.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", i.ReleaseName, err.Error()))
+	i.Lock.Unlock()
 	if i.Atomic {
-		slog.Debug("install failed, uninstalling release", "release", i.ReleaseName)
-		uninstall := NewUninstall(i.cfg)
-		uninstall.DisableHooks = i.DisableHooks
-		uninstall.KeepHistory = false
-		uninstall.Timeout = i.Timeout
-		if _, uninstallErr := uninstall.Run(i.ReleaseName); uninstallErr != nil {
-			return rel, fmt.Errorf("an error occurred while uninstalling the release. original install error: %w: %w", err, uninstallErr)
+		cleanup := atomicCleanup{
+			operation: "install",
+			undoing:   "uninstalling",
+			undone:    "uninstalled",
+			log:       i.cfg.log(),
+			undo: func() error {
+				uninstall := NewUninstall(i.cfg)
+				uninstall.DisableHooks = i.DisableHooks
+				uninstall.SkipHookEvents = i.SkipHookEvents
+				uninstall.KeepHistory = false
+				uninstall.Timeout = i.atomicCleanupTimeout()
+				_, err := uninstall.Run(i.ReleaseName)
+				return err
+			},
+			timeout: i.atomicCleanupTimeout(),
 		}
-		return rel, fmt.Errorf("release %s failed, and has been uninstalled due to atomic being set: %w", i.ReleaseName, err)
+		return rel, cleanup.run(i.ReleaseName, err)
 	}
 	i.recordRelease(rel) // Ignore the error, since we have another error to deal with.
 	return rel, err
 }
 
+// atomicCleanupTimeout returns the time given to the cleanup of a failed
+// atomic install.
+func (i *Install) atomicCleanupTimeout() time.Duration {
+	if i.AtomicCleanupTimeout > 0 {
+		return i.AtomicCleanupTimeout
+	}
+	return i.Timeout
+}
+
 // availableName tests whether a name is available
 //
 // Roughly, this will return an error if name is
@@ -578,9 +818,14 @@ func (i *Install) createRelease(chrt *chart.Chart, rawVals map[string]interface{
 			FirstDeployed: ts,
 			LastDeployed:  ts,
 			Status:        release.StatusUnknown,
+			FieldManager:  i.cfg.FieldManager,
+			AppliedBy:     currentOperator(),
+			ValuesEdited:  i.ValuesEdited,
 		},
-		Version: 1,
-		Labels:  labels,
+		Version:     1,
+		Labels:      labels,
+		Annotations: maps.Clone(i.Annotations),
+		Policy:      i.Policy.WithHashedTokens(),
 	}
 }
 
@@ -746,25 +991,6 @@ OUTER:
 	return nil
 }
 
-func portOrDefault(u *url.URL) string {
-	if p := u.Port(); p != "" {
-		return p
-	}
-
-	switch u.Scheme {
-	case "http":
-		return "80"
-	case "https":
-		return "443"
-	default:
-		return ""
-	}
-}
-
-func urlEqual(u1, u2 *url.URL) bool {
-	return u1.Scheme == u2.Scheme && u1.Hostname() == u2.Hostname() && portOrDefault(u1) == portOrDefault(u2)
-}
-
 // LocateChart looks for a chart directory in known places, and returns either the full path or an error.
 //
 // This does not ensure that the chart is well-formed; only that the requested filename exists.
@@ -782,6 +1008,8 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 
 	name = strings.TrimSpace(name)
 	version := strings.TrimSpace(c.Version)
+	c.digest = ""
+	c.mirroredFrom = ""
 
 	if _, err := os.Stat(name); err == nil {
 		abs, err := filepath.Abs(name)
@@ -799,6 +1027,7 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		return name, fmt.Errorf("path %q not found", name)
 	}
 
+	bearerToken := c.bearerToken(settings)
 	dl := downloader.ChartDownloader{
 		Out:     os.Stdout,
 		Keyring: c.Keyring,
@@ -809,6 +1038,7 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 			getter.WithInsecureSkipVerifyTLS(c.InsecureSkipTLSverify),
 			getter.WithPlainHTTP(c.PlainHTTP),
 			getter.WithBasicAuth(c.Username, c.Password),
+			getter.WithRetries(c.DownloadRetries, getter.DefaultRetryBackoff),
 		},
 		RepositoryConfig: settings.RepositoryConfig,
 		RepositoryCache:  settings.RepositoryCache,
@@ -830,6 +1060,7 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 			repo.WithChartVersion(version),
 			repo.WithClientTLS(c.CertFile, c.KeyFile, c.CaFile),
 			repo.WithUsernamePassword(c.Username, c.Password),
+			repo.WithBearerToken(bearerToken),
 			repo.WithInsecureSkipTLSverify(c.InsecureSkipTLSverify),
 			repo.WithPassCredentialsAll(c.PassCredentialsAll),
 		)
@@ -838,7 +1069,7 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		}
 		name = chartURL
 
-		// Only pass the user/pass on when the user has said to or when the
+		// Only pass the credentials on when the user has said to or when the
 		// location of the chart repo and the chart are the same domain.
 		u1, err := url.Parse(c.RepoURL)
 		if err != nil {
@@ -849,16 +1080,21 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 			return "", err
 		}
 
-		// Host on URL (returned from url.Parse) contains the port if present.
-		// This check ensures credentials are not passed between different
-		// services on different ports.
-		if c.PassCredentialsAll || urlEqual(u1, u2) {
-			dl.Options = append(dl.Options, getter.WithBasicAuth(c.Username, c.Password))
+		if getter.ShouldForwardCredentials(u1, u2, c.PassCredentialsAll, nil) {
+			dl.Options = append(dl.Options,
+				getter.WithBasicAuth(c.Username, c.Password),
+				getter.WithBearerToken(bearerToken))
 		} else {
-			dl.Options = append(dl.Options, getter.WithBasicAuth("", ""))
+			dl.Options = append(dl.Options,
+				getter.WithBasicAuth("", ""),
+				getter.WithBearerToken(""))
 		}
 	} else {
-		dl.Options = append(dl.Options, getter.WithBasicAuth(c.Username, c.Password))
+		// The getter only sends the bearer token to the host of the chart,
+		// and the credentials configured for its repository take precedence.
+		dl.Options = append(dl.Options,
+			getter.WithBasicAuth(c.Username, c.Password),
+			getter.WithBearerToken(bearerToken))
 	}
 
 	if err := os.MkdirAll(settings.RepositoryCache, 0755); err != nil {
@@ -869,6 +1105,12 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 	if err != nil {
 		return "", err
 	}
+	if registry.IsOCI(name) {
+		c.digest = registry.ReferenceDigest(name)
+		if _, ok := c.registryClient.Mirror(name); ok {
+			c.mirroredFrom = name
+		}
+	}
 
 	lname, err := filepath.Abs(filename)
 	if err != nil {
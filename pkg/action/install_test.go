@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -36,6 +37,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kuberuntime "k8s.io/apimachinery/pkg/runtime"
@@ -46,10 +48,13 @@ import (
 
 	"helm.sh/helm/v4/internal/test"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cli"
 	"helm.sh/helm/v4/pkg/kube"
 	kubefake "helm.sh/helm/v4/pkg/kube/fake"
 	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
 	"helm.sh/helm/v4/pkg/storage/driver"
 	helmtime "helm.sh/helm/v4/pkg/time"
 )
@@ -108,6 +113,38 @@ func createDummyResourceList(owned bool) kube.ResourceList {
 	return resourceList
 }
 
+// createDummyResourceListWithClusterRole returns the dummy resource list
+// extended with a cluster-scoped ClusterRole, owned by the release or not.
+func createDummyResourceListWithClusterRole(owned, clusterRoleOwned bool) kube.ResourceList {
+	obj := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dummyClusterRole",
+		},
+	}
+	if clusterRoleOwned {
+		obj.Labels = map[string]string{
+			"app.kubernetes.io/managed-by": "Helm",
+		}
+		obj.Annotations = map[string]string{
+			"meta.helm.sh/release-name":      "test-install-release",
+			"meta.helm.sh/release-namespace": "spaced",
+		}
+	}
+	gv := rbacv1.SchemeGroupVersion
+	resourceList := createDummyResourceList(owned)
+	resourceList.Append(&resource.Info{
+		Name: "dummyClusterRole",
+		Mapping: &meta.RESTMapping{
+			Resource:         gv.WithResource("clusterroles"),
+			GroupVersionKind: gv.WithKind("ClusterRole"),
+			Scope:            meta.RESTScopeRoot,
+		},
+		Object: obj,
+		Client: fakeClientWith(http.StatusOK, gv, kuberuntime.EncodeOrDie(scheme.Codecs.LegacyCodec(gv), obj)),
+	})
+	return resourceList
+}
+
 func installActionWithConfig(config *Configuration) *Install {
 	instAction := NewInstall(config)
 	instAction.Namespace = "spaced"
@@ -152,6 +189,7 @@ func TestInstallRelease(t *testing.T) {
 	is.NotEqual(len(rel.Manifest), 0)
 	is.Contains(rel.Manifest, "---\n# Source: hello/templates/hello\nhello: world")
 	is.Equal(rel.Info.Description, "Install complete")
+	is.Empty(rel.Info.FieldManager)
 
 	// Detecting previous bug where context termination after successful release
 	// caused release to fail.
@@ -162,6 +200,17 @@ func TestInstallRelease(t *testing.T) {
 	is.Equal(lastRelease.Info.Status, release.StatusDeployed)
 }
 
+func TestInstallRelease_FieldManager(t *testing.T) {
+	instAction := installAction(t)
+	instAction.cfg.FieldManager = "argocd-controller"
+
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	rel, err := instAction.cfg.Releases.Get(res.Name, res.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "argocd-controller", rel.Info.FieldManager)
+}
+
 func TestInstallReleaseWithTakeOwnership_ResourceNotOwned(t *testing.T) {
 	// This test will test checking ownership of a resource
 	// returned by the fake client. If the resource is not
@@ -217,6 +266,64 @@ func TestInstallReleaseWithTakeOwnership_ResourceOwnedNoFlag(t *testing.T) {
 	is.Contains(err.Error(), "unable to continue with install")
 }
 
+func TestInstallReleaseWithTakeOwnershipScope(t *testing.T) {
+	tests := []struct {
+		name             string
+		takeOwnership    bool
+		scope            TakeOwnershipScope
+		clusterRoleOwned bool
+		expectErr        string
+	}{
+		{
+			name:          "boolean adopts cluster-scoped resources",
+			takeOwnership: true,
+		},
+		{
+			name:  "all adopts cluster-scoped resources",
+			scope: TakeOwnershipScopeAll,
+		},
+		{
+			name:      "namespaced-only refuses cluster-scoped resources",
+			scope:     TakeOwnershipScopeNamespacedOnly,
+			expectErr: `cluster-scoped ClusterRole "dummyClusterRole" exists and only namespaced resources may be adopted`,
+		},
+		{
+			name:          "namespaced-only takes precedence over the boolean",
+			takeOwnership: true,
+			scope:         TakeOwnershipScopeNamespacedOnly,
+			expectErr:     `cluster-scoped ClusterRole "dummyClusterRole" exists and only namespaced resources may be adopted`,
+		},
+		{
+			name:             "namespaced-only keeps cluster-scoped resources owned by the release",
+			scope:            TakeOwnershipScopeNamespacedOnly,
+			clusterRoleOwned: true,
+		},
+		{
+			name:      "invalid scope",
+			scope:     "cluster-only",
+			expectErr: `invalid take ownership scope "cluster-only"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The namespaced Deployment is never owned by the release, and
+			// is adopted in every scope.
+			config := actionConfigFixtureWithDummyResources(t, createDummyResourceListWithClusterRole(false, tt.clusterRoleOwned))
+			instAction := installActionWithConfig(config)
+			instAction.TakeOwnership = tt.takeOwnership
+			instAction.TakeOwnershipScope = tt.scope
+			res, err := instAction.Run(buildChart(), nil)
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Install complete", res.Info.Description)
+		})
+	}
+}
+
 func TestInstallReleaseWithValues(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -262,6 +369,34 @@ func TestInstallReleaseClientOnly(t *testing.T) {
 	is.Equal(instAction.cfg.KubeClient, &kubefake.PrintingKubeClient{Out: io.Discard})
 }
 
+func TestInstallReleaseClientOnlyKubeVersion(t *testing.T) {
+	for _, tt := range []struct {
+		version       string
+		policyV1beta1 bool
+	}{
+		{"v1.24.0", true},
+		{"v1.25.0", false},
+		{"v1.19.0", true}, // unknown, the default API versions
+	} {
+		t.Run(tt.version, func(t *testing.T) {
+			instAction := installAction(t)
+			instAction.ClientOnly = true
+			kv, err := chartutil.ParseKubeVersion(tt.version)
+			require.NoError(t, err)
+			instAction.KubeVersion = kv
+			instAction.APIVersions = chartutil.VersionSet{"example.com/v1"}
+			_, err = instAction.Run(buildChart(), nil)
+			require.NoError(t, err)
+
+			caps := instAction.cfg.Capabilities
+			assert.Equal(t, tt.version, caps.KubeVersion.Version)
+			assert.Equal(t, tt.policyV1beta1, caps.APIVersions.Has("policy/v1beta1"))
+			assert.True(t, caps.APIVersions.Has("policy/v1"))
+			assert.True(t, caps.APIVersions.Has("example.com/v1"), "the API versions given are added")
+		})
+	}
+}
+
 func TestInstallRelease_NoName(t *testing.T) {
 	instAction := installAction(t)
 	instAction.ReleaseName = ""
@@ -273,6 +408,22 @@ func TestInstallRelease_NoName(t *testing.T) {
 	assert.Contains(t, err.Error(), "no name provided")
 }
 
+func TestInstallRelease_StorageCreateFailure(t *testing.T) {
+	is := assert.New(t)
+	errStorage := errors.New("storage unavailable")
+
+	instAction := installAction(t)
+	injection, err := driver.WithErrorInjection(driver.MemoryOpCreate, errStorage, "sh.helm.release.v1.test-install-release.v1")
+	is.NoError(err)
+	instAction.cfg.Releases = storage.Init(driver.NewMemory(injection))
+	_, err = instAction.Run(buildChart(), map[string]interface{}{})
+	is.ErrorIs(err, errStorage)
+	is.Contains(err.Error(), "release not created")
+
+	_, err = instAction.cfg.Releases.Get("test-install-release", 1)
+	is.ErrorIs(err, driver.ErrReleaseNotFound)
+}
+
 func TestInstallRelease_WithNotes(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -475,6 +626,37 @@ func TestInstallRelease_NoHooks(t *testing.T) {
 	is.True(res.Hooks[0].LastRun.CompletedAt.IsZero(), "hooks should not run with no-hooks")
 }
 
+func TestInstallRelease_SkipHookEvents(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.ReleaseName = "skip-hooks"
+	instAction.SkipHookEvents = []release.HookEvent{release.HookPreInstall}
+
+	templates := []*chart.File{
+		{Name: "templates/hello", Data: []byte("hello: world")},
+		{Name: "templates/migrate", Data: []byte("kind: ConfigMap\nmetadata:\n  name: migrate\n  annotations:\n    \"helm.sh/hook\": pre-install\n")},
+		{Name: "templates/register", Data: []byte("kind: ConfigMap\nmetadata:\n  name: register\n  annotations:\n    \"helm.sh/hook\": post-install\n")},
+	}
+	res, err := instAction.Run(buildChartWithTemplates(templates), map[string]interface{}{})
+	is.NoError(err)
+
+	lastRuns := map[string]release.HookExecution{}
+	for _, h := range res.Hooks {
+		lastRuns[h.Name] = h.LastRun
+	}
+	is.Len(lastRuns, 2, "skipped hooks should still be recorded on the release")
+	is.True(lastRuns["migrate"].StartedAt.IsZero(), "pre-install hooks should be skipped")
+	is.Equal(release.HookPhaseSucceeded, lastRuns["register"].Phase)
+}
+
+func TestInstallRelease_SkipUnknownHookEvent(t *testing.T) {
+	instAction := installAction(t)
+	instAction.SkipHookEvents = []release.HookEvent{"pre-instal"}
+
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, `unknown hook event "pre-instal" to skip`)
+}
+
 func TestInstallRelease_FailedHooks(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -590,6 +772,62 @@ func TestInstallRelease_WaitForJobs(t *testing.T) {
 	is.Equal(res.Info.Status, release.StatusFailed)
 }
 
+func TestInstallRelease_HookOnlyWait(t *testing.T) {
+	t.Run("resources are not waited for", func(t *testing.T) {
+		instAction := installAction(t)
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitError = fmt.Errorf("I timed out")
+		instAction.WaitStrategy = kube.HookOnlyStrategy
+		instAction.WaitForJobs = true
+
+		res, err := instAction.Run(buildChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	})
+
+	t.Run("hooks are watched until ready", func(t *testing.T) {
+		instAction := installAction(t)
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = fmt.Errorf("hook timed out")
+		instAction.WaitStrategy = kube.HookOnlyStrategy
+
+		res, err := instAction.Run(buildChart(), map[string]interface{}{})
+		assert.ErrorContains(t, err, "hook timed out")
+		assert.Equal(t, release.StatusFailed, res.Info.Status)
+	})
+
+	t.Run("atomic waits for resources and rolls back", func(t *testing.T) {
+		instAction := installAction(t)
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitError = fmt.Errorf("I timed out")
+		instAction.WaitStrategy = kube.HookOnlyStrategy
+		instAction.Atomic = true
+		instAction.DisableHooks = true
+
+		_, err := instAction.Run(buildChart(), map[string]interface{}{})
+		assert.ErrorContains(t, err, "I timed out")
+		assert.ErrorContains(t, err, "atomic")
+		_, err = instAction.cfg.Releases.Get(instAction.ReleaseName, 1)
+		assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+	})
+
+	t.Run("atomic rolls back on hook failure", func(t *testing.T) {
+		instAction := installAction(t)
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = fmt.Errorf("hook timed out")
+		instAction.WaitStrategy = kube.HookOnlyStrategy
+		instAction.Atomic = true
+		// The pre-delete hook of the cleanup would fail as well.
+		instAction.SkipHookEvents = []release.HookEvent{release.HookPreDelete}
+
+		_, err := instAction.Run(buildChart(), map[string]interface{}{})
+		assert.ErrorContains(t, err, "hook timed out")
+		assert.ErrorContains(t, err, "atomic")
+		_, err = instAction.cfg.Releases.Get(instAction.ReleaseName, 1)
+		assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+	})
+}
+
 func TestInstallRelease_Atomic(t *testing.T) {
 	is := assert.New(t)
 
@@ -633,6 +871,41 @@ func TestInstallRelease_Atomic(t *testing.T) {
 		is.Contains(err.Error(), "an error occurred while uninstalling the release")
 	})
 }
+func TestInstallRelease_AtomicCleanupTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	t.Run("defaults to the timeout", func(t *testing.T) {
+		instAction := installAction(t)
+		instAction.ReleaseName = "slow-release"
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitDuration = 10 * time.Second
+		instAction.Atomic = true
+		instAction.Timeout = 100 * time.Millisecond
+
+		_, err := instAction.Run(buildChart(), map[string]interface{}{})
+		is.Error(err)
+		is.Contains(err.Error(), "timed out waiting for the condition after 100ms")
+		is.Contains(err.Error(), "has been uninstalled within its 100ms cleanup timeout due to atomic being set")
+	})
+
+	t.Run("separate cleanup timeout", func(t *testing.T) {
+		instAction := installAction(t)
+		instAction.ReleaseName = "slow-release"
+		failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitDuration = 10 * time.Second
+		instAction.Atomic = true
+		instAction.Timeout = 100 * time.Millisecond
+		instAction.AtomicCleanupTimeout = 5 * time.Minute
+
+		res, err := instAction.Run(buildChart(), map[string]interface{}{})
+		is.Error(err)
+		is.Contains(err.Error(), "has been uninstalled within its 5m0s cleanup timeout due to atomic being set")
+
+		_, err = instAction.cfg.Releases.Get(res.Name, res.Version)
+		is.Equal(driver.ErrReleaseNotFound, err)
+	})
+}
+
 func TestInstallRelease_Atomic_Interrupted(t *testing.T) {
 
 	is := assert.New(t)
@@ -801,6 +1074,103 @@ func TestInstallOutputDirWithReleaseName(t *testing.T) {
 	is.True(errors.Is(err, fs.ErrNotExist))
 }
 
+func TestInstallReleaseOutputLayout(t *testing.T) {
+	preInstallHook := `kind: Job
+metadata:
+  name: migrate
+  annotations:
+    "helm.sh/hook": pre-install
+    "helm.sh/hook-weight": "-5"
+`
+	layoutChart := func() *chart.Chart {
+		c := buildChart(withSampleTemplates(), withMultipleManifestTemplate())
+		c.Templates = append(c.Templates, &chart.File{Name: "templates/migrate", Data: []byte(preInstallHook)})
+		c.Files = append(c.Files, &chart.File{Name: "crds/widgets.yaml", Data: []byte("kind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n")})
+		return c
+	}
+
+	t.Run("chart layout", func(t *testing.T) {
+		instAction := installAction(t)
+		dir := t.TempDir()
+		instAction.OutputDir = dir
+		instAction.OutputLayout = OutputLayoutChart
+
+		rel, err := instAction.Run(layoutChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		// The manifests are installed as well as written.
+		assert.Contains(t, rel.Manifest, "# Source: hello/templates/rbac")
+
+		test.AssertGoldenFile(t, filepath.Join(dir, "hello/templates/rbac"), "rbac.txt")
+		data, err := os.ReadFile(filepath.Join(dir, "hello/crds/widgets.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "kind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n", string(data))
+		test.AssertGoldenFile(t, filepath.Join(dir, "hooks/pre-install/-5/hello/templates/migrate"), "output/layout-pre-install-hook.txt")
+		for _, f := range []string{"hooks/post-install/0/hello/templates/hooks", "hooks/pre-delete/0/hello/templates/hooks", "hooks/post-upgrade/0/hello/templates/hooks"} {
+			_, err = os.Stat(filepath.Join(dir, f))
+			assert.NoError(t, err, f)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "hello/templates/hooks"))
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		_, err = os.Stat(filepath.Join(dir, kustomizationFileName))
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("kustomization", func(t *testing.T) {
+		instAction := installAction(t)
+		dir := t.TempDir()
+		instAction.OutputDir = dir
+		instAction.OutputKustomize = true
+		instAction.UseReleaseName = true
+
+		_, err := instAction.Run(layoutChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		test.AssertGoldenFile(t, filepath.Join(dir, instAction.ReleaseName, kustomizationFileName), "output/layout-kustomization.yaml")
+	})
+
+	t.Run("skip tests and hide secrets", func(t *testing.T) {
+		instAction := installAction(t)
+		dir := t.TempDir()
+		instAction.OutputDir = dir
+		instAction.OutputLayout = OutputLayoutChart
+		instAction.SkipTests = true
+		instAction.HideSecret = true
+		instAction.DryRunOption = "client"
+		instAction.DryRun = true
+
+		c := layoutChart()
+		c.Templates = append(c.Templates,
+			&chart.File{Name: "templates/secret", Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\ndata:\n  password: c2VjcmV0\n")},
+			&chart.File{Name: "templates/hook-secret", Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: hook-creds\n  annotations:\n    \"helm.sh/hook\": pre-install\ndata:\n  password: c2VjcmV0\n")},
+			&chart.File{Name: "templates/test", Data: []byte("kind: Pod\nmetadata:\n  name: smoke\n  annotations:\n    \"helm.sh/hook\": test\n")},
+		)
+		_, err := instAction.Run(c, map[string]interface{}{})
+		require.NoError(t, err)
+
+		for _, f := range []string{"hello/templates/secret", "hooks/pre-install/0/hello/templates/hook-secret"} {
+			data, err := os.ReadFile(filepath.Join(dir, f))
+			require.NoError(t, err, f)
+			assert.NotContains(t, string(data), "c2VjcmV0", f)
+			assert.Contains(t, string(data), "HIDDEN", f)
+		}
+		_, err = os.Stat(filepath.Join(dir, "hooks/test"))
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("without hooks", func(t *testing.T) {
+		instAction := installAction(t)
+		dir := t.TempDir()
+		instAction.OutputDir = dir
+		instAction.OutputKustomize = true
+		instAction.DisableHooks = true
+
+		_, err := instAction.Run(layoutChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(dir, "hooks"))
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
 func TestNameAndChart(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -935,67 +1305,164 @@ func TestInstallWithSystemLabels(t *testing.T) {
 	is.Equal(fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels()), err)
 }
 
-func TestUrlEqual(t *testing.T) {
+func TestInstallWithAnnotations(t *testing.T) {
 	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.Annotations = map[string]string{
+		"example.com/ticket": "https://tickets.example.com/browse/OPS-1?a=b&c",
+		"example.com/build":  `{"sha": "3b383ba", "tags": ["a", "b"]}`,
+	}
+	res, err := instAction.Run(buildChart(), nil)
+	if err != nil {
+		t.Fatalf("Failed install: %s", err)
+	}
+	is.Equal(instAction.Annotations, res.Annotations)
 
-	tests := []struct {
-		name     string
-		url1     string
-		url2     string
-		expected bool
-	}{
-		{
-			name:     "identical URLs",
-			url1:     "https://example.com:443",
-			url2:     "https://example.com:443",
-			expected: true,
-		},
-		{
-			name:     "same host, scheme, default HTTPS port vs explicit",
-			url1:     "https://example.com",
-			url2:     "https://example.com:443",
-			expected: true,
-		},
-		{
-			name:     "same host, scheme, default HTTP port vs explicit",
-			url1:     "http://example.com",
-			url2:     "http://example.com:80",
-			expected: true,
-		},
-		{
-			name:     "different schemes",
-			url1:     "http://example.com",
-			url2:     "https://example.com",
-			expected: false,
-		},
-		{
-			name:     "different hosts",
-			url1:     "https://example.com",
-			url2:     "https://www.example.com",
-			expected: false,
-		},
-		{
-			name:     "different ports",
-			url1:     "https://example.com:8080",
-			url2:     "https://example.com:9090",
-			expected: false,
-		},
+	stored, err := instAction.cfg.Releases.Get(res.Name, res.Version)
+	is.NoError(err)
+	is.Equal(instAction.Annotations, stored.Annotations)
+
+	instAction = installAction(t)
+	instAction.Annotations = map[string]string{"": "value"}
+	_, err = instAction.Run(buildChart(), nil)
+	is.EqualError(err, "release annotation keys cannot be empty")
+}
+
+func TestInstallRelease_ProgressFunc(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	config := actionConfigFixtureWithDummyResources(t, createDummyResourceList(true))
+	instAction := installActionWithConfig(config)
+
+	var phases []ProgressPhase
+	var resources []string
+	instAction.ProgressFunc = func(event ProgressEvent) {
+		is.Equal(instAction.ReleaseName, event.Release)
+		is.False(event.Time.IsZero())
+		phases = append(phases, event.Phase)
+		resources = append(resources, event.Resource)
+		if event.Phase == ProgressRender {
+			panic("a misbehaving callback must not abort the install")
+		}
 	}
 
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
+	preInstallHook := strings.Replace(manifestWithHook, "post-install,pre-delete,post-upgrade", "pre-install", 1)
+	preInstallHook = strings.Replace(preInstallHook, "test-cm", "pre-cm", 1)
+	ch := buildChart(withSampleTemplates())
+	ch.Templates = append(ch.Templates, &chart.File{Name: "templates/pre-hook", Data: []byte(preInstallHook)})
 
-			u1, err := url.Parse(tc.url1)
-			if err != nil {
-				t.Fatalf("Failed to parse URL1 %s: %v", tc.url1, err)
-			}
-			u2, err := url.Parse(tc.url2)
-			if err != nil {
-				t.Fatalf("Failed to parse URL2 %s: %v", tc.url2, err)
-			}
+	res, err := instAction.Run(ch, map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(release.StatusDeployed, res.Info.Status)
+
+	is.Equal([]ProgressPhase{
+		ProgressRender,
+		ProgressPreHook,
+		ProgressApply,
+		ProgressWait,
+		ProgressPostHook,
+		ProgressPersist,
+	}, phases)
+	is.Equal([]string{"", "ConfigMap/pre-cm", "Deployment/dummyName", "", "ConfigMap/test-cm", ""}, resources)
+}
+
+func TestInstallRelease_StrictTemplate(t *testing.T) {
+	is := assert.New(t)
+	chrt, err := loader.Load("testdata/charts/chart-with-values-typo")
+	require.NoError(t, err)
+
+	instAction := installAction(t)
+	instAction.DryRun = true
+	res, err := instAction.Run(chrt, nil)
+	require.NoError(t, err)
+	is.Contains(res.Manifest, `image: "nginx:1.27"`)
+	is.Contains(res.Manifest, "resources: \n            null")
 
-			is.Equal(tc.expected, urlEqual(u1, u2))
+	instAction = installAction(t)
+	instAction.DryRun = true
+	instAction.StrictTemplate = true
+	_, err = instAction.Run(chrt, nil)
+	require.Error(t, err)
+	is.Contains(err.Error(), "chart-with-values-typo/templates/deployment.yaml:16:")
+	is.Contains(err.Error(), `.Values.resoruces is not defined: the key "resoruces" is missing`)
+
+	// The values which are used with default or hasKey may be missing.
+	instAction = installAction(t)
+	instAction.DryRun = true
+	instAction.StrictTemplate = true
+	res, err = instAction.Run(chrt, map[string]interface{}{"resoruces": map[string]interface{}{}})
+	require.NoError(t, err)
+	is.Contains(res.Manifest, "imagePullPolicy: IfNotPresent")
+}
+
+func TestInstallRelease_InvalidWaitCondition(t *testing.T) {
+	resources := createDummyResourceList(false)
+	resources[0].Object.(*appsv1.Deployment).Annotations = map[string]string{kube.WaitConditionAnno: "=True"}
+	instAction := installActionWithConfig(actionConfigFixtureWithDummyResources(t, resources))
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Deployment "dummyName" in namespace "spaced"`)
+	assert.Contains(t, err.Error(), `invalid helm.sh/wait-condition annotation "=True"`)
+	_, err = instAction.cfg.Releases.Get(instAction.ReleaseName, 1)
+	assert.Error(t, err)
+}
+
+func TestLocateChartURLBearerToken(t *testing.T) {
+	chartData, err := os.ReadFile("testdata/charts/compressedchart-0.1.0.tgz")
+	require.NoError(t, err)
+
+	var otherAuth string
+	var otherHit bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth, otherHit = r.Header.Get("Authorization"), true
+		w.Write(chartData)
+	}))
+	defer other.Close()
+
+	// Both servers listen on the loopback address, but are told apart by
+	// their host names.
+	otherURL, err := url.Parse(other.URL)
+	require.NoError(t, err)
+	otherURL.Host = "localhost:" + otherURL.Port()
+
+	var chartAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chartAuth = r.Header.Get("Authorization")
+		if r.URL.Path == "/moved/compressedchart-0.1.0.tgz" {
+			http.Redirect(w, r, otherURL.String()+"/compressedchart-0.1.0.tgz", http.StatusFound)
+			return
+		}
+		w.Write(chartData)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		flag       string
+		env        string
+		redirected bool
+	}{
+		{name: "token of the flag", path: "/compressedchart-0.1.0.tgz", flag: "token"},
+		{name: "token of the environment", path: "/compressedchart-0.1.0.tgz", env: "token"},
+		{name: "token not forwarded on a cross-host redirect", path: "/moved/compressedchart-0.1.0.tgz", flag: "token", redirected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartAuth, otherAuth, otherHit = "", "", false
+			settings := cli.New()
+			settings.RepositoryConfig = filepath.Join(t.TempDir(), "repositories.yaml")
+			settings.RepositoryCache = t.TempDir()
+			settings.RepositoryBearerToken = tt.env
+			c := ChartPathOptions{BearerToken: tt.flag}
+
+			_, err := c.LocateChart(srv.URL+tt.path, settings)
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer token", chartAuth)
+			assert.Equal(t, tt.redirected, otherHit)
+			assert.Empty(t, otherAuth)
 		})
 	}
 }
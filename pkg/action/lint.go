@@ -17,13 +17,18 @@ limitations under the License.
 package action
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/lint"
+	"helm.sh/helm/v4/pkg/lint/rules"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
 
@@ -31,12 +36,20 @@ import (
 //
 // It provides the implementation of 'helm lint'.
 type Lint struct {
-	Strict               bool
-	Namespace            string
+	Strict    bool
+	Namespace string
+	// WithSubcharts also lints the templates of the enabled subcharts of the
+	// charts, rendered with the values the charts pass down to them.
 	WithSubcharts        bool
 	Quiet                bool
 	SkipSchemaValidation bool
 	KubeVersion          *chartutil.KubeVersion
+	// DeprecationRules are checked in addition to rules.DefaultDeprecationRules
+	// to flag deprecated or removed Kubernetes APIs in rendered manifests.
+	DeprecationRules []rules.DeprecationRule
+	// CompatWarnings flags the uses of template functions and fields which
+	// were deprecated or removed in Helm.
+	CompatWarnings bool
 }
 
 // LintResult is the result of Lint
@@ -46,6 +59,16 @@ type LintResult struct {
 	Errors            []error
 }
 
+// MaxLintCombinations is the maximum number of combinations of values a chart
+// can be linted against in one run.
+var MaxLintCombinations = 64
+
+// LintCombination is a named set of values to lint charts against.
+type LintCombination struct {
+	Name   string
+	Values map[string]interface{}
+}
+
 // NewLint creates a new Lint object with the given configuration.
 func NewLint() *Lint {
 	return &Lint{}
@@ -59,7 +82,7 @@ func (l *Lint) Run(paths []string, vals map[string]interface{}) *LintResult {
 	}
 	result := &LintResult{}
 	for _, path := range paths {
-		linter, err := lintChart(path, vals, l.Namespace, l.KubeVersion, l.SkipSchemaValidation)
+		linter, err := lintChart(path, vals, l.Namespace, l.KubeVersion, l.SkipSchemaValidation, l.DeprecationRules, l.CompatWarnings, l.WithSubcharts)
 		if err != nil {
 			result.Errors = append(result.Errors, err)
 			continue
@@ -76,6 +99,119 @@ func (l *Lint) Run(paths []string, vals map[string]interface{}) *LintResult {
 	return result
 }
 
+// RunCombinations executes 'helm lint' against the given charts once per
+// combination of values, rendering the combinations in parallel.
+//
+// Identical findings are reported once. A finding which only shows with some
+// of the combinations is tagged with their names; one showing with all of them
+// is reported as is.
+func (l *Lint) RunCombinations(paths []string, combinations []LintCombination) (*LintResult, error) {
+	if err := validateLintCombinations(combinations); err != nil {
+		return nil, err
+	}
+
+	lowestTolerance := support.ErrorSev
+	if l.Strict {
+		lowestTolerance = support.WarningSev
+	}
+	result := &LintResult{}
+	for _, path := range paths {
+		linters := make([]support.Linter, len(combinations))
+		errs := make([]error, len(combinations))
+
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for range min(len(combinations), runtime.GOMAXPROCS(0)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					linters[i], errs[i] = lintChart(path, combinations[i].Values, l.Namespace, l.KubeVersion, l.SkipSchemaValidation, l.DeprecationRules, l.CompatWarnings, l.WithSubcharts)
+				}
+			}()
+		}
+		for i := range combinations {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		// The chart could not be read: this does not depend on the values.
+		if i := slices.IndexFunc(errs, func(err error) bool { return err != nil }); i >= 0 {
+			result.Errors = append(result.Errors, errs[i])
+			continue
+		}
+
+		messages := mergeLintFindings(combinations, linters)
+		result.Messages = append(result.Messages, messages...)
+		result.TotalChartsLinted++
+		for _, msg := range messages {
+			if msg.Severity >= lowestTolerance {
+				result.Errors = append(result.Errors, msg.Err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// validateLintCombinations checks that there are combinations to lint charts
+// against, not too many, and that their names tell them apart.
+func validateLintCombinations(combinations []LintCombination) error {
+	if len(combinations) == 0 {
+		return errors.New("no combination of values to lint against")
+	}
+	if len(combinations) > MaxLintCombinations {
+		return fmt.Errorf("too many combinations of values to lint against: %d, the maximum is %d", len(combinations), MaxLintCombinations)
+	}
+	names := make(map[string]struct{}, len(combinations))
+	for i, c := range combinations {
+		if c.Name == "" {
+			return fmt.Errorf("combination of values %d has no name", i+1)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("duplicate combination of values %q", c.Name)
+		}
+		names[c.Name] = struct{}{}
+	}
+	return nil
+}
+
+// mergeLintFindings merges the messages of the linters of each combination,
+// keeping the order in which they were first found.
+func mergeLintFindings(combinations []LintCombination, linters []support.Linter) []support.Message {
+	type finding struct {
+		msg   support.Message
+		names []string
+	}
+	var findings []*finding
+	byKey := map[string]*finding{}
+	for i, linter := range linters {
+		for _, msg := range linter.Messages {
+			key := fmt.Sprintf("%d\x00%s\x00%s", msg.Severity, msg.Path, msg.Err)
+			f, ok := byKey[key]
+			if !ok {
+				f = &finding{msg: msg}
+				byKey[key] = f
+				findings = append(findings, f)
+			}
+			// A rule may report the same finding more than once.
+			if len(f.names) == 0 || f.names[len(f.names)-1] != combinations[i].Name {
+				f.names = append(f.names, combinations[i].Name)
+			}
+		}
+	}
+
+	messages := make([]support.Message, 0, len(findings))
+	for _, f := range findings {
+		msg := f.msg
+		if len(f.names) < len(combinations) {
+			msg.Err = fmt.Errorf("%w (values combinations: %s)", msg.Err, strings.Join(f.names, ", "))
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
 // HasWarningsOrErrors checks is LintResult has any warnings or errors
 func HasWarningsOrErrors(result *LintResult) bool {
 	for _, msg := range result.Messages {
@@ -86,7 +222,7 @@ func HasWarningsOrErrors(result *LintResult) bool {
 	return len(result.Errors) > 0
 }
 
-func lintChart(path string, vals map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool) (support.Linter, error) {
+func lintChart(path string, vals map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool, deprecationRules []rules.DeprecationRule, compatWarnings, withSubcharts bool) (support.Linter, error) {
 	var chartPath string
 	linter := support.Linter{}
 
@@ -131,5 +267,8 @@ func lintChart(path string, vals map[string]interface{}, namespace string, kubeV
 		namespace,
 		lint.WithKubeVersion(kubeVersion),
 		lint.WithSkipSchemaValidation(skipSchemaValidation),
+		lint.WithDeprecationRules(deprecationRules),
+		lint.WithCompatWarnings(compatWarnings),
+		lint.WithSubcharts(withSubcharts),
 	), nil
 }
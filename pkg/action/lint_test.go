@@ -17,7 +17,12 @@ limitations under the License.
 package action
 
 import (
+	"strings"
 	"testing"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/rules"
+	"helm.sh/helm/v4/pkg/lint/support"
 )
 
 var (
@@ -27,6 +32,8 @@ var (
 	chart2MultipleChartLint = "testdata/charts/multiplecharts-lint-chart-2"
 	corruptedTgzChart       = "testdata/charts/corrupted-compressed-chart.tgz"
 	chartWithNoTemplatesDir = "testdata/charts/chart-with-no-templates-dir"
+	chartWithDeprecatedAPIs = "testdata/charts/chart-with-deprecated-apis"
+	chartWithFeatureFlags   = "../cmd/testdata/testcharts/chart-with-feature-flags"
 )
 
 func TestLintChart(t *testing.T) {
@@ -83,7 +90,7 @@ func TestLintChart(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := lintChart(tt.chartPath, map[string]interface{}{}, namespace, nil, tt.skipSchemaValidation)
+			_, err := lintChart(tt.chartPath, map[string]interface{}{}, namespace, nil, tt.skipSchemaValidation, nil, false, false)
 			switch {
 			case err != nil && !tt.err:
 				t.Errorf("%s", err)
@@ -163,3 +170,155 @@ func TestLint_ChartWithWarnings(t *testing.T) {
 		}
 	})
 }
+
+func TestLint_DeprecatedAPIs(t *testing.T) {
+	testCharts := []string{chartWithDeprecatedAPIs}
+
+	lintWithKubeVersion := func(t *testing.T, version string, rules ...rules.DeprecationRule) *LintResult {
+		t.Helper()
+		kubeVersion, err := chartutil.ParseKubeVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testLint := NewLint()
+		testLint.KubeVersion = kubeVersion
+		testLint.DeprecationRules = rules
+		return testLint.Run(testCharts, values)
+	}
+
+	t.Run("deprecated API is a warning", func(t *testing.T) {
+		result := lintWithKubeVersion(t, "1.22.0")
+		if len(result.Errors) > 0 {
+			t.Errorf("expected no error, got %v", result.Errors)
+		}
+		assertLintMessage(t, result, support.WarningSev, "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+; use policy/v1 PodDisruptionBudget")
+	})
+
+	t.Run("removed API is an error", func(t *testing.T) {
+		result := lintWithKubeVersion(t, "1.29.0")
+		if len(result.Errors) != 1 {
+			t.Errorf("expected one error, got %v", result.Errors)
+		}
+		assertLintMessage(t, result, support.ErrorSev, "policy/v1beta1 PodDisruptionBudget is unavailable in v1.25+; use policy/v1 PodDisruptionBudget")
+	})
+
+	t.Run("API not yet deprecated", func(t *testing.T) {
+		result := lintWithKubeVersion(t, "1.20.0")
+		if HasWarningsOrErrors(result) {
+			t.Errorf("expected no warnings or errors, got %v", result.Messages)
+		}
+	})
+
+	t.Run("custom rules", func(t *testing.T) {
+		result := lintWithKubeVersion(t, "1.29.0", rules.DeprecationRule{
+			APIVersion:   "example.com/v1alpha1",
+			Kind:         "Widget",
+			DeprecatedIn: "1.27",
+			Replacement:  "example.com/v1",
+		})
+		assertLintMessage(t, result, support.WarningSev, "example.com/v1alpha1 Widget is deprecated in v1.27+; use example.com/v1 Widget")
+	})
+}
+
+func TestLint_RunCombinations(t *testing.T) {
+	enable := func(feature string) map[string]interface{} {
+		return map[string]interface{}{feature: map[string]interface{}{"enabled": true}}
+	}
+	combinations := []LintCombination{
+		{Name: "defaults"},
+		{Name: "ingress", Values: enable("ingress")},
+		{Name: "ingress-again", Values: enable("ingress")},
+		{Name: "persistence", Values: enable("persistence")},
+	}
+
+	result, err := NewLint().RunCombinations([]string{chartWithFeatureFlags}, combinations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalChartsLinted != 1 {
+		t.Errorf("expected 1 chart linted, got %d", result.TotalChartsLinted)
+	}
+
+	// Findings are reported once, tagged with the combinations showing them
+	// unless all of them do.
+	expected := []string{
+		"[INFO] Chart.yaml: icon is recommended",
+		`[WARNING] templates/ingress.yaml: object name does not conform to Kubernetes naming requirements: "Web"`,
+		"[ERROR] templates/pvc.yaml: unable to parse YAML",
+	}
+	if len(result.Messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), result.Messages)
+	}
+	for i, msg := range result.Messages {
+		if !strings.HasPrefix(msg.Error(), expected[i]) {
+			t.Errorf("expected message %d to start with %q, got %q", i, expected[i], msg.Error())
+		}
+	}
+	if strings.Contains(result.Messages[0].Error(), "values combinations") {
+		t.Errorf("expected a finding of all the combinations not to be tagged, got %q", result.Messages[0].Error())
+	}
+	if !strings.HasSuffix(result.Messages[1].Error(), "(values combinations: ingress, ingress-again)") {
+		t.Errorf("expected the finding to be tagged with its combinations, got %q", result.Messages[1].Error())
+	}
+	if !strings.HasSuffix(result.Messages[2].Error(), "(values combinations: persistence)") {
+		t.Errorf("expected the finding to be tagged with its combination, got %q", result.Messages[2].Error())
+	}
+
+	// Only the error fails the lint, unless it is strict.
+	if len(result.Errors) != 1 {
+		t.Errorf("expected one error, got %v", result.Errors)
+	}
+	strict := NewLint()
+	strict.Strict = true
+	result, err = strict.RunCombinations([]string{chartWithFeatureFlags}, combinations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 2 {
+		t.Errorf("expected two errors, got %v", result.Errors)
+	}
+}
+
+func TestLint_RunCombinationsNotAChart(t *testing.T) {
+	result, err := NewLint().RunCombinations([]string{"testdata/charts/missing"}, []LintCombination{{Name: "a"}, {Name: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || result.TotalChartsLinted != 0 {
+		t.Errorf("expected one error and no chart linted, got %v", result.Errors)
+	}
+}
+
+func TestLint_RunCombinationsInvalid(t *testing.T) {
+	previous := MaxLintCombinations
+	MaxLintCombinations = 2
+	defer func() { MaxLintCombinations = previous }()
+
+	for _, tt := range []struct {
+		name         string
+		combinations []LintCombination
+		expected     string
+	}{
+		{"none", nil, "no combination of values to lint against"},
+		{"too many", []LintCombination{{Name: "a"}, {Name: "b"}, {Name: "c"}}, "too many combinations of values to lint against: 3, the maximum is 2"},
+		{"unnamed", []LintCombination{{Name: "a"}, {}}, "combination of values 2 has no name"},
+		{"duplicate", []LintCombination{{Name: "a"}, {Name: "a"}}, `duplicate combination of values "a"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLint().RunCombinations([]string{chartWithFeatureFlags}, tt.combinations)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func assertLintMessage(t *testing.T, result *LintResult, severity int, text string) {
+	t.Helper()
+	for _, msg := range result.Messages {
+		if msg.Severity == severity && strings.Contains(msg.Err.Error(), text) {
+			return
+		}
+	}
+	t.Errorf("expected message %q with severity %d, got %v", text, severity, result.Messages)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package action
 
 import (
+	"errors"
+	"fmt"
 	"path"
 	"regexp"
 
@@ -24,6 +26,7 @@ import (
 
 	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
 // ListStates represents zero or more status codes that a list item may have set
@@ -80,6 +83,20 @@ func (s ListStates) FromName(str string) ListStates {
 	return ListUnknown
 }
 
+// statuses returns the statuses of the releases the states select.
+func (s ListStates) statuses() []release.Status {
+	var statuses []release.Status
+	for _, status := range []release.Status{
+		release.StatusDeployed, release.StatusUninstalled, release.StatusSuperseded, release.StatusFailed,
+		release.StatusUninstalling, release.StatusPendingInstall, release.StatusPendingUpgrade, release.StatusPendingRollback,
+	} {
+		if s&s.FromName(status.String()) != 0 {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
 // ListAll is a convenience for enabling all list filters
 const ListAll = ListDeployed | ListUninstalled | ListUninstalling | ListPendingInstall | ListPendingRollback | ListPendingUpgrade | ListSuperseded | ListFailed
 
@@ -134,6 +151,14 @@ type List struct {
 	Failed       bool
 	Pending      bool
 	Selector     string
+	// Pinned only lists the pinned releases.
+	Pinned bool
+	// Strict fails the listing when the record of a release cannot be
+	// decoded. By default, such records are skipped and reported in Corrupt.
+	Strict bool
+	// Corrupt is set by Run to the records of releases which could not be
+	// decoded, and were skipped.
+	Corrupt []*driver.CorruptReleaseError
 }
 
 // NewList constructs a new *List
@@ -159,7 +184,11 @@ func (l *List) Run() ([]*release.Release, error) {
 		}
 	}
 
-	results, err := l.cfg.Releases.List(func(rel *release.Release) bool {
+	if pager, ok := l.cfg.Releases.Driver.(driver.Pager); ok && l.pageable() {
+		return l.runPage(pager)
+	}
+
+	results, corrupt, err := l.cfg.Releases.ListWithCorrupt(func(rel *release.Release) bool {
 		// Skip anything that doesn't match the filter.
 		if filter != nil && !filter.MatchString(rel.Name) {
 			return false
@@ -172,6 +201,16 @@ func (l *List) Run() ([]*release.Release, error) {
 		return nil, err
 	}
 
+	l.Corrupt = nil
+	for _, c := range corrupt {
+		if filter == nil || filter.MatchString(c.Name) {
+			l.Corrupt = append(l.Corrupt, c)
+		}
+	}
+	if err := l.checkCorrupt(); err != nil {
+		return nil, err
+	}
+
 	if results == nil {
 		return results, nil
 	}
@@ -194,6 +233,10 @@ func (l *List) Run() ([]*release.Release, error) {
 	}
 	results = l.filterSelector(results, selectorObj)
 
+	if l.Pinned {
+		results = filterPinned(results)
+	}
+
 	// Unfortunately, we have to sort before truncating, which can incur substantial overhead
 	l.sort(results)
 
@@ -216,6 +259,49 @@ func (l *List) Run() ([]*release.Release, error) {
 	return results, err
 }
 
+// pageable reports whether the releases can be selected and paged by a storage
+// driver, which only selects them by status and sorts them by name.
+func (l *List) pageable() bool {
+	return l.Filter == "" && l.Selector == "" && !l.Pinned &&
+		l.Sort == 0 && !l.ByDate && !l.SortReverse &&
+		l.StateMask != 0 && l.StateMask&ListUnknown == 0
+}
+
+// runPage lists the releases selected and paged by the storage driver, rather
+// than reading all of them.
+func (l *List) runPage(pager driver.Pager) ([]*release.Release, error) {
+	results, corrupt, err := pager.ListPage(driver.ListOptions{
+		// Superseded releases are never the latest ones.
+		Latest:   l.StateMask != ListSuperseded,
+		Statuses: l.StateMask.statuses(),
+		Offset:   l.Offset,
+		Limit:    l.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Corrupt = corrupt
+	if err := l.checkCorrupt(); err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return []*release.Release{}, nil
+	}
+	return results, nil
+}
+
+// checkCorrupt fails with Strict when records of releases were skipped.
+func (l *List) checkCorrupt() error {
+	if !l.Strict || len(l.Corrupt) == 0 {
+		return nil
+	}
+	errs := make([]error, len(l.Corrupt))
+	for i, c := range l.Corrupt {
+		errs[i] = c
+	}
+	return fmt.Errorf("unable to decode %d release records: %w", len(l.Corrupt), errors.Join(errs...))
+}
+
 // sort is an in-place sort where order is based on the value of a.Sort
 func (l *List) sort(rels []*release.Release) {
 	if l.SortReverse {
@@ -288,6 +374,17 @@ func (l *List) filterSelector(releases []*release.Release, selector labels.Selec
 	return desiredStateReleases
 }
 
+// filterPinned returns the pinned releases of releases.
+func filterPinned(releases []*release.Release) []*release.Release {
+	pinned := make([]*release.Release, 0)
+	for _, rls := range releases {
+		if rls.Pin != nil {
+			pinned = append(pinned, rls)
+		}
+	}
+	return pinned
+}
+
 // SetStateMask calculates the state mask based on parameters.
 func (l *List) SetStateMask() {
 	if l.All {
@@ -20,9 +20,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	release "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
 func TestListStates(t *testing.T) {
@@ -287,6 +289,78 @@ func makeMeSomeReleases(t *testing.T, store *storage.Storage) {
 	assert.Len(t, all, 3, "sanity test: three items added")
 }
 
+func TestList_Corrupt(t *testing.T) {
+	config, secrets := secretsConfigFixture(t)
+	createRevisions(t, config.Releases, "angry-bird", 1)
+	createRevisions(t, config.Releases, "smug-pigeon", 1)
+	key := corruptRevision(t, secrets, "smug-pigeon", 1)
+
+	lister := NewList(config)
+	list, err := lister.Run()
+	require.NoError(t, err)
+	require.Len(t, list, 1, "the healthy releases are still listed")
+	assert.Equal(t, "angry-bird", list[0].Name)
+	require.Len(t, lister.Corrupt, 1)
+	assert.Equal(t, key, lister.Corrupt[0].Key)
+	assert.Equal(t, "default", lister.Corrupt[0].Namespace)
+
+	lister.Filter = "angry"
+	_, err = lister.Run()
+	require.NoError(t, err)
+	assert.Empty(t, lister.Corrupt, "the corrupt records are filtered by release name")
+
+	lister.Filter = ""
+	lister.Strict = true
+	_, err = lister.Run()
+	assert.ErrorContains(t, err, "unable to decode 1 release records")
+	assert.ErrorContains(t, err, key)
+}
+
+// pagerDriver is a memory driver which records the options of the pages it
+// is asked to list.
+type pagerDriver struct {
+	*driver.Memory
+	opts []driver.ListOptions
+}
+
+func (d *pagerDriver) ListPage(opts driver.ListOptions) ([]*release.Release, []*driver.CorruptReleaseError, error) {
+	d.opts = append(d.opts, opts)
+	return []*release.Release{releaseStub()}, nil, nil
+}
+
+func TestList_Pager(t *testing.T) {
+	config := actionConfigFixture(t)
+	pager := &pagerDriver{Memory: driver.NewMemory()}
+	config.Releases = storage.Init(pager)
+	makeMeSomeReleases(t, config.Releases)
+
+	lister := NewList(config)
+	lister.Limit = 2
+	lister.Offset = 1
+	list, err := lister.Run()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, []driver.ListOptions{{
+		Latest:   true,
+		Statuses: []release.Status{release.StatusDeployed, release.StatusFailed},
+		Offset:   1,
+		Limit:    2,
+	}}, pager.opts)
+
+	lister.StateMask = ListSuperseded
+	_, err = lister.Run()
+	require.NoError(t, err)
+	assert.Equal(t, driver.ListOptions{Statuses: []release.Status{release.StatusSuperseded}, Offset: 1, Limit: 2}, pager.opts[1])
+
+	// Filters and sorts the driver cannot apply list every release.
+	lister.StateMask = ListDeployed
+	lister.ByDate = true
+	list, err = lister.Run()
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Len(t, pager.opts, 2)
+}
+
 func TestFilterLatestReleases(t *testing.T) {
 	t.Run("should filter old versions of the same release", func(t *testing.T) {
 		r1 := releaseStub()
@@ -0,0 +1,122 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"github.com/mitchellh/copystructure"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// namespaceDefaultsKey is the key of the namespace defaults ConfigMap holding
+// the default values.
+const namespaceDefaultsKey = "values.yaml"
+
+// applyNamespaceDefaults layers the default values of namespace, read from the
+// ConfigMap cfg.NamespaceDefaultsConfigMap, beneath the values vals of the user:
+// they override the values of the chart, and vals override them. It returns the
+// values to render the chart with, leaving vals and the chart alone, so that
+// neither the stored values nor the chart record the defaults.
+//
+// It also returns where the defaults were read from, or nil if there were none
+// to read. Renders which do not contact the cluster skip the defaults.
+func (cfg *Configuration) applyNamespaceDefaults(vals map[string]interface{}, namespace string, clientOnly bool) (map[string]interface{}, *release.NamespaceDefaults, error) {
+	if cfg.NamespaceDefaultsConfigMap == "" {
+		return vals, nil, nil
+	}
+	if clientOnly {
+		cfg.log().Warn("the namespace defaults are not applied to client-only renders", "configmap", cfg.NamespaceDefaultsConfigMap)
+		return vals, nil, nil
+	}
+
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return nil, nil, err
+	}
+	defaults, source, err := readNamespaceDefaults(cfg.log(), clientset, namespace, cfg.NamespaceDefaultsConfigMap, cfg.NamespaceDefaultsRequired)
+	if err != nil {
+		return nil, nil, err
+	}
+	if defaults == nil {
+		return vals, nil, nil
+	}
+	layered, err := layerNamespaceDefaults(vals, defaults)
+	if err != nil {
+		return nil, nil, err
+	}
+	return layered, source, nil
+}
+
+// layerNamespaceDefaults returns the values vals of the user merged over
+// defaults. Rendering coalesces the result with the values of the chart, so
+// that defaults override the values of the chart and vals override both. The
+// nulls of vals and defaults are kept, to delete the keys they override when
+// rendering. The result shares no tables with vals or defaults.
+func layerNamespaceDefaults(vals, defaults map[string]interface{}) (map[string]interface{}, error) {
+	layered, err := copystructure.Copy(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("copying the namespace defaults: %w", err)
+	}
+	user := map[string]interface{}{}
+	if vals != nil {
+		copied, err := copystructure.Copy(vals)
+		if err != nil {
+			return nil, fmt.Errorf("copying the values: %w", err)
+		}
+		user = copied.(map[string]interface{})
+	}
+	return chartutil.MergeTables(user, layered.(map[string]interface{})), nil
+}
+
+// readNamespaceDefaults reads the default values from the ConfigMap name in
+// namespace. A missing ConfigMap is an error if required, and otherwise
+// results in no defaults.
+func readNamespaceDefaults(log *slog.Logger, clientset kubernetes.Interface, namespace, name string, required bool) (map[string]interface{}, *release.NamespaceDefaults, error) {
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) && !required {
+		log.Debug("no namespace defaults", "namespace", namespace, "configmap", name)
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the namespace defaults: %w", err)
+	}
+
+	data, ok := cm.Data[namespaceDefaultsKey]
+	if !ok {
+		return nil, nil, fmt.Errorf("namespace defaults ConfigMap %q in namespace %q has no key %q", name, namespace, namespaceDefaultsKey)
+	}
+	values, err := chartutil.ReadValues([]byte(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing the namespace defaults of ConfigMap %q in namespace %q: %w", name, namespace, err)
+	}
+
+	sum := sha256.Sum256([]byte(data))
+	return values, &release.NamespaceDefaults{
+		Source: namespace + "/" + name,
+		Digest: "sha256:" + hex.EncodeToString(sum[:]),
+	}, nil
+}
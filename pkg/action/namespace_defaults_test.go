@@ -0,0 +1,126 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestReadNamespaceDefaults(t *testing.T) {
+	clientset := fake.NewClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "team"},
+			Data:       map[string]string{"values.yaml": "image:\n  registry: mirror.example.com\n"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "nokey", Namespace: "team"},
+			Data:       map[string]string{"other.yaml": "a: b\n"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "team"},
+			Data:       map[string]string{"values.yaml": "- not a map\n"},
+		},
+	)
+
+	defaults, source, err := readNamespaceDefaults(slog.Default(), clientset, "team", "defaults", true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"image": map[string]interface{}{"registry": "mirror.example.com"}}, defaults)
+	assert.Equal(t, "team/defaults", source.Source)
+	assert.Equal(t, "sha256:c91b8913fdd34d4fd31becc79c79f045e247903be4c991cde87364fbcc618a95", source.Digest)
+
+	defaults, source, err = readNamespaceDefaults(slog.Default(), clientset, "other", "defaults", false)
+	require.NoError(t, err)
+	assert.Nil(t, defaults)
+	assert.Nil(t, source)
+
+	_, _, err = readNamespaceDefaults(slog.Default(), clientset, "other", "defaults", true)
+	assert.ErrorContains(t, err, "reading the namespace defaults")
+
+	_, _, err = readNamespaceDefaults(slog.Default(), clientset, "team", "nokey", false)
+	assert.ErrorContains(t, err, `has no key "values.yaml"`)
+
+	_, _, err = readNamespaceDefaults(slog.Default(), clientset, "team", "invalid", false)
+	assert.ErrorContains(t, err, "parsing the namespace defaults")
+}
+
+func TestLayerNamespaceDefaults(t *testing.T) {
+	chartValues := map[string]interface{}{
+		"replicas":  1,
+		"image":     map[string]interface{}{"registry": "docker.io", "tag": "1.0"},
+		"resources": map[string]interface{}{"cpu": "100m"},
+		"debug":     true,
+	}
+	chrt := buildChart(withValues(chartValues))
+	defaults := map[string]interface{}{
+		"replicas": 2,
+		"image":    map[string]interface{}{"registry": "mirror.example.com"},
+		"debug":    nil,
+	}
+	user := map[string]interface{}{"replicas": 3, "resources": map[string]interface{}{"memory": "1Gi"}}
+	layered, err := layerNamespaceDefaults(user, defaults)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 2,
+		"image":    map[string]interface{}{"registry": "mirror.example.com"},
+		"debug":    nil,
+	}, defaults, "the defaults should be left alone")
+	assert.Equal(t, map[string]interface{}{"replicas": 3, "resources": map[string]interface{}{"memory": "1Gi"}}, user, "the values of the user should be left alone")
+	layered["image"].(map[string]interface{})["registry"] = "other.example.com"
+	assert.Equal(t, "mirror.example.com", defaults["image"].(map[string]interface{})["registry"], "the layered values should not share tables with the defaults")
+	layered["image"].(map[string]interface{})["registry"] = "mirror.example.com"
+
+	vals, err := chartutil.ToRenderValues(chrt, layered, chartutil.ReleaseOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicas":  3,
+		"image":     map[string]interface{}{"registry": "mirror.example.com", "tag": "1.0"},
+		"resources": map[string]interface{}{"cpu": "100m", "memory": "1Gi"},
+	}, vals["Values"].(chartutil.Values).AsMap())
+	assert.Equal(t, map[string]interface{}{
+		"replicas":  1,
+		"image":     map[string]interface{}{"registry": "docker.io", "tag": "1.0"},
+		"resources": map[string]interface{}{"cpu": "100m"},
+		"debug":     true,
+	}, chrt.Values, "the values of the chart should be left alone")
+
+	// A chart reused in another namespace renders without the defaults.
+	vals, err = chartutil.ToRenderValues(chrt, nil, chartutil.ReleaseOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, vals["Values"].(chartutil.Values)["replicas"])
+}
+
+func TestInstallClientOnlySkipsNamespaceDefaults(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.ClientOnly = true
+	instAction.cfg.NamespaceDefaultsConfigMap = "defaults"
+	instAction.cfg.NamespaceDefaultsRequired = true
+
+	rel, err := instAction.Run(buildChart(), nil)
+	is.NoError(err)
+	is.Nil(rel.Info.NamespaceDefaults)
+}
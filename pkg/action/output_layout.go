@@ -0,0 +1,202 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// OutputLayout is the layout of the files written to the OutputDir of an
+// install.
+type OutputLayout string
+
+const (
+	// OutputLayoutFlat writes the manifests at the paths of their templates.
+	// The hooks are left to the caller, and the CRDs are only written with
+	// IncludeCRDs. It is the default layout.
+	OutputLayoutFlat OutputLayout = "flat"
+	// OutputLayoutChart writes the CRDs of the crds/ directories as they are,
+	// the manifests at the paths of their templates, and the hooks under
+	// hooks/<event>/<weight>/, at the paths of their templates.
+	OutputLayoutChart OutputLayout = "chart"
+)
+
+// kustomizationFileName is the name of the kustomization written with
+// OutputKustomize.
+const kustomizationFileName = "kustomization.yaml"
+
+// kustomization is the part of a kustomization.yaml written with
+// OutputKustomize. The resources are listed in the order Helm installs them,
+// and the fifo order keeps kustomize from sorting them again.
+type kustomization struct {
+	APIVersion  string `json:"apiVersion"`
+	Kind        string `json:"kind"`
+	SortOptions struct {
+		Order string `json:"order"`
+	} `json:"sortOptions"`
+	Resources []string `json:"resources"`
+}
+
+// chartOutputLayout reports whether the OutputDir is written in the chart
+// layout, which OutputKustomize requires.
+func (i *Install) chartOutputLayout() bool {
+	return i.OutputDir != "" && (i.OutputLayout == OutputLayoutChart || i.OutputKustomize)
+}
+
+// writeOutputLayout writes the rendered chart to the OutputDir in the chart
+// layout. manifests is the stream of rendered manifests, in install order,
+// each one preceded by its source, where HideSecret already hid the Secrets.
+func (i *Install) writeOutputLayout(chrt *chart.Chart, hooks []*release.Hook, manifests string) error {
+	dir := i.OutputDir
+	if i.UseReleaseName {
+		dir = filepath.Join(dir, i.ReleaseName)
+	}
+	var resources []string
+	listed := make(map[string]bool)
+	list := func(name string) {
+		if !listed[name] {
+			listed[name] = true
+			resources = append(resources, name)
+		}
+	}
+
+	for _, crd := range chrt.CRDObjects() {
+		if err := writeVerbatim(dir, crd.Filename, crd.File.Data); err != nil {
+			return err
+		}
+		list(filepath.ToSlash(crd.Filename))
+	}
+
+	var hookFiles map[release.HookEvent][]string
+	if !i.DisableHooks {
+		var err error
+		if hookFiles, err = writeHooks(dir, hooks, i.SkipTests, i.HideSecret); err != nil {
+			return err
+		}
+	}
+	for _, name := range hookFiles[release.HookPreInstall] {
+		list(name)
+	}
+
+	fileWritten := make(map[string]bool)
+	docs := releaseutil.SplitManifests(manifests)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	for _, k := range keys {
+		source, content, _ := strings.Cut(docs[k], "\n")
+		name, ok := strings.CutPrefix(source, "# Source: ")
+		if !ok {
+			return fmt.Errorf("manifest without source: %s", source)
+		}
+		if err := writeToFile(dir, name, content, fileWritten[name]); err != nil {
+			return err
+		}
+		fileWritten[name] = true
+		list(name)
+	}
+
+	for _, name := range hookFiles[release.HookPostInstall] {
+		list(name)
+	}
+
+	if !i.OutputKustomize {
+		return nil
+	}
+	k := kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	}
+	k.SortOptions.Order = "fifo"
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return writeVerbatim(dir, kustomizationFileName, data)
+}
+
+// writeHooks writes the hooks under hooks/<event>/<weight>/ in dir, and
+// returns the files written for each event, in the order the hooks run. A
+// hook running both before and after the install is only returned with the
+// pre-install files, as a kustomization cannot hold a resource twice. The test
+// hooks are left out with skipTests, and the Secrets are hidden with
+// hideSecret.
+func writeHooks(dir string, hooks []*release.Hook, skipTests, hideSecret bool) (map[release.HookEvent][]string, error) {
+	var sorted []*release.Hook
+	for _, h := range hooks {
+		if skipTests && (slices.Contains(h.Events, release.HookTest) || slices.Contains(h.Events, release.HookTestFixture)) {
+			continue
+		}
+		sorted = append(sorted, h)
+	}
+	sort.Stable(hookByWeight(sorted))
+
+	files := make(map[release.HookEvent][]string)
+	fileWritten := make(map[string]bool)
+	listed := make(map[string]bool)
+	for _, h := range sorted {
+		for _, e := range h.Events {
+			hookDir := path.Join("hooks", e.String(), strconv.Itoa(h.Weight))
+			name := path.Join(hookDir, h.Path)
+			manifest := h.Manifest
+			if hideSecret && h.Kind == "Secret" {
+				manifest = "# HIDDEN: The Secret output has been suppressed"
+			}
+			if err := writeToFile(filepath.Join(dir, hookDir), h.Path, manifest, fileWritten[name]); err != nil {
+				return nil, err
+			}
+			fileWritten[name] = true
+			if e == release.HookPostInstall && slices.Contains(h.Events, release.HookPreInstall) {
+				continue
+			}
+			if !listed[name] {
+				listed[name] = true
+				files[e] = append(files[e], name)
+			}
+		}
+	}
+	return files, nil
+}
+
+// writeVerbatim writes data to <dir>/<name> as it is.
+func writeVerbatim(dir, name string, data []byte) error {
+	outfileName := filepath.Join(dir, name)
+	if err := ensureDirectoryForFile(outfileName); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outfileName, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", outfileName)
+	return nil
+}
@@ -20,8 +20,11 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/term"
@@ -44,6 +47,20 @@ type Package struct {
 	AppVersion       string
 	Destination      string
 	DependencyUpdate bool
+	VerifyLock       bool
+	// SkipValidation disables the checks of the structure of the chart run
+	// before it is packaged, such as the parsing of its templates.
+	SkipValidation bool
+	// FileMode and DirMode are the modes of the files and the directories of
+	// the archive, whatever their modes in the chart directory. They default
+	// to chartutil.DefaultArchiveFileMode and chartutil.DefaultArchiveDirMode.
+	FileMode fs.FileMode
+	DirMode  fs.FileMode
+	// Reproducible writes the entries of the archive sorted by path and with
+	// a fixed modification time, so that its digest only depends on the
+	// content of the chart. The signature still records when it was made,
+	// unless SOURCE_DATE_EPOCH sets the time to record.
+	Reproducible bool
 
 	RepositoryConfig      string
 	RepositoryCache       string
@@ -67,7 +84,18 @@ func NewPackage() *Package {
 
 // Run executes 'helm package' against the given chart and returns the path to the packaged chart.
 func (p *Package) Run(path string, _ map[string]interface{}) (string, error) {
-	ch, err := loader.LoadDir(path)
+	files, err := loader.LoadDirFiles(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !p.SkipValidation {
+		if err := validatePackageFiles(files); err != nil {
+			return "", fmt.Errorf("chart %s failed validation:\n%w", path, err)
+		}
+	}
+
+	ch, err := loader.LoadFiles(files)
 	if err != nil {
 		return "", err
 	}
@@ -103,7 +131,7 @@ func (p *Package) Run(path string, _ map[string]interface{}) (string, error) {
 		dest = p.Destination
 	}
 
-	name, err := chartutil.Save(ch, dest)
+	name, err := chartutil.SaveWithOptions(ch, dest, chartutil.SaveOptions{FileMode: p.FileMode, DirMode: p.DirMode, Reproducible: p.Reproducible})
 	if err != nil {
 		return "", fmt.Errorf("failed to save: %w", err)
 	}
@@ -143,6 +171,12 @@ func (p *Package) Clearsign(filename string) error {
 		return err
 	}
 
+	if p.Reproducible {
+		if signer.SignatureTime, err = sourceDateEpoch(); err != nil {
+			return err
+		}
+	}
+
 	sig, err := signer.ClearSign(filename)
 	if err != nil {
 		return err
@@ -151,6 +185,21 @@ func (p *Package) Clearsign(filename string) error {
 	return os.WriteFile(filename+".prov", []byte(sig), 0644)
 }
 
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable, as a number of seconds since the Unix epoch, or the zero time if
+// it is not set.
+func sourceDateEpoch() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 // promptUser implements provenance.PassphraseFetcher
 func promptUser(name string) ([]byte, error) {
 	fmt.Printf("Password for key %q >  ", name)
@@ -17,11 +17,15 @@ limitations under the License.
 package action
 
 import (
+	"crypto/sha256"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"helm.sh/helm/v4/internal/test/ensure"
 )
@@ -152,3 +156,37 @@ func TestValidateVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestPackageReproducible(t *testing.T) {
+	digest := func() [sha256.Size]byte {
+		client := NewPackage()
+		client.Reproducible = true
+		client.Destination = t.TempDir()
+		name, err := client.Run("testdata/charts/chart-with-uncompressed-dependencies", nil)
+		require.NoError(t, err)
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return sha256.Sum256(data)
+	}
+
+	first := digest()
+	// The modification time of the entries would otherwise change.
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, first, digest(), "packaging the same chart twice gives the same archive")
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	got, err := sourceDateEpoch()
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	got, err = sourceDateEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), got)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = sourceDateEpoch()
+	assert.ErrorContains(t, err, `invalid SOURCE_DATE_EPOCH "yesterday"`)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/engine"
+)
+
+// validatePackageFiles checks the structure of the files of a chart before it
+// is packaged: the templates must parse, Chart.yaml must hold valid chart
+// metadata, values.yaml and values.schema.json or values.schema.yaml
+// must parse, and every file of crds/ must be a CustomResourceDefinition. The
+// templates are not rendered, that is left to lint. Every problem found is
+// reported.
+func validatePackageFiles(files []*loader.BufferedFile) error {
+	var errs []error
+	templates := make(map[string]string)
+	hasChartfile := false
+
+	for _, f := range files {
+		switch {
+		case f.Name == "Chart.yaml":
+			hasChartfile = true
+			if err := validatePackageChartfile(f.Data); err != nil {
+				errs = append(errs, err)
+			}
+		case f.Name == "values.yaml":
+			if _, err := loader.LoadValues(bytes.NewReader(f.Data)); err != nil {
+				errs = append(errs, fmt.Errorf("values.yaml: %w", err))
+			}
+		case f.Name == "values.schema.json":
+			var schema interface{}
+			if err := json.Unmarshal(f.Data, &schema); err != nil {
+				errs = append(errs, fmt.Errorf("values.schema.json: %w", err))
+			}
+		case f.Name == loader.SchemaYAMLFile:
+			if _, err := loader.SchemaJSON(f.Data); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", loader.SchemaYAMLFile, err))
+			}
+		case strings.HasPrefix(f.Name, "templates/"):
+			templates[f.Name] = string(f.Data)
+		case strings.HasPrefix(f.Name, "crds/"):
+			errs = append(errs, validatePackageCRDs(f)...)
+		}
+	}
+
+	if !hasChartfile {
+		errs = append([]error{errors.New("Chart.yaml: file is missing")}, errs...)
+	}
+	if err := (engine.Engine{}).Parse(templates); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validatePackageChartfile checks that Chart.yaml parses and holds valid chart
+// metadata of a known API version. As when the chart is loaded, a Chart.yaml
+// without an apiVersion is one of Helm 2, and is taken as v1.
+func validatePackageChartfile(data []byte) error {
+	md := new(chart.Metadata)
+	if err := yaml.Unmarshal(data, md); err != nil {
+		return fmt.Errorf("Chart.yaml: %w", err)
+	}
+	if md.APIVersion == "" {
+		md.APIVersion = chart.APIVersionV1
+	}
+	if md.APIVersion != chart.APIVersionV1 && md.APIVersion != chart.APIVersionV2 {
+		return fmt.Errorf("Chart.yaml: apiVersion %q is not supported, it must be either %q or %q", md.APIVersion, chart.APIVersionV1, chart.APIVersionV2)
+	}
+	if err := md.Validate(); err != nil {
+		return fmt.Errorf("Chart.yaml: %w", err)
+	}
+	return nil
+}
+
+// validatePackageCRDs checks that every document of a file of crds/ is a
+// CustomResourceDefinition.
+func validatePackageCRDs(f *loader.BufferedFile) []error {
+	ext := strings.ToLower(path.Ext(f.Name))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		// Only manifests are installed from crds/, see Chart.CRDObjects.
+		return nil
+	}
+
+	var errs []error
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(f.Data), 4096)
+	for i := 1; ; i++ {
+		var obj map[string]interface{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The rest of the file cannot be decoded.
+			return append(errs, fmt.Errorf("%s: %w", f.Name, err))
+		}
+		if len(obj) == 0 {
+			// An empty document, between separators or made of comments.
+			continue
+		}
+		if kind, _ := obj["kind"].(string); kind != "CustomResourceDefinition" {
+			errs = append(errs, fmt.Errorf("%s: document %d has kind %q, only CustomResourceDefinitions belong in crds/", f.Name, i, kind))
+		}
+	}
+	return errs
+}
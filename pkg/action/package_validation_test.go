@@ -0,0 +1,140 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`
+
+// writeChartDir writes files, keyed by their path in the chart, to a new
+// chart directory and returns its path.
+func writeChartDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "widget")
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, os.WriteFile(name, []byte(data), 0644))
+	}
+	return dir
+}
+
+func validChartFiles() map[string]string {
+	return map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: widget\nversion: 0.1.0\n",
+		"values.yaml":               "replicas: 1\n",
+		"values.schema.json":        `{"type": "object"}`,
+		"templates/_helpers.tpl":    `{{- define "widget.name" -}}{{ .Chart.Name }}{{- end -}}`,
+		"templates/deployment.yaml": "name: {{ include \"widget.name\" . }}\nreplicas: {{ .Values.replicas | required \"replicas\" }}\n",
+		"templates/NOTES.txt":       "{{ lookup \"v1\" \"Pod\" \"\" \"\" }}\n",
+		"crds/widgets.yaml":         "---\n# widgets\n" + validCRD,
+		"crds/README.md":            "Not a manifest.\n",
+	}
+}
+
+func TestPackage_Validation(t *testing.T) {
+	dir := writeChartDir(t, validChartFiles())
+
+	client := NewPackage()
+	client.Destination = t.TempDir()
+	name, err := client.Run(dir, nil)
+	require.NoError(t, err)
+	assert.FileExists(t, name)
+}
+
+func TestPackage_ValidationErrors(t *testing.T) {
+	files := validChartFiles()
+	files["Chart.yaml"] = "apiVersion: v2\nversion: one\n"
+	files["values.yaml"] = "replicas: [1\n"
+	files["values.schema.json"] = `{"type": "object"`
+	files["templates/deployment.yaml"] = "name: {{ .Chart.Name\n"
+	files["templates/service.yaml"] = "name: {{ unknownFunction }}\n"
+	files["crds/widgets.yaml"] = validCRD + "---\napiVersion: v1\nkind: ConfigMap\n"
+	files["crds/gadgets.json"] = `{"kind": `
+	dir := writeChartDir(t, files)
+
+	client := NewPackage()
+	client.Destination = t.TempDir()
+	_, err := client.Run(dir, nil)
+	require.Error(t, err)
+
+	for _, expect := range []string{
+		"chart " + dir + " failed validation:",
+		"Chart.yaml: validation: chart.metadata.name is required",
+		"values.yaml: ",
+		"values.schema.json: ",
+		"parse error at (templates/deployment.yaml:2): unclosed action",
+		`parse error at (templates/service.yaml:1): function "unknownFunction" not defined`,
+		`crds/widgets.yaml: document 2 has kind "ConfigMap", only CustomResourceDefinitions belong in crds/`,
+		"crds/gadgets.json: ",
+	} {
+		assert.Contains(t, err.Error(), expect)
+	}
+	entries, err := os.ReadDir(client.Destination)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is packaged")
+}
+
+func TestPackage_SkipValidation(t *testing.T) {
+	files := validChartFiles()
+	files["templates/deployment.yaml"] = "name: {{ .Chart.Name\n"
+	files["crds/widgets.yaml"] = "apiVersion: v1\nkind: ConfigMap\n"
+	dir := writeChartDir(t, files)
+
+	client := NewPackage()
+	client.Destination = t.TempDir()
+	_, err := client.Run(dir, nil)
+	require.Error(t, err)
+
+	client.SkipValidation = true
+	name, err := client.Run(dir, nil)
+	require.NoError(t, err)
+	assert.FileExists(t, name)
+}
+
+func TestPackage_ValidationMissingChartfile(t *testing.T) {
+	err := validatePackageFiles(nil)
+	require.Error(t, err)
+	assert.Equal(t, "Chart.yaml: file is missing", err.Error())
+}
+
+func TestPackage_ValidationChartfile(t *testing.T) {
+	for chartfile, expect := range map[string]string{
+		"apiVersion: v3\nname: widget\nversion: 0.1.0\n":               `Chart.yaml: apiVersion "v3" is not supported, it must be either "v1" or "v2"`,
+		"apiVersion: v2\nname: widget\nversion: one\n":                 `Chart.yaml: validation: chart.metadata.version "one" is invalid`,
+		"apiVersion: v2\nname: widget\nversion: 0.1.0\ntype: plugin\n": "Chart.yaml: validation: chart.metadata.type must be application or library",
+		"name: widget\nversion: 0.1.0\n":                               "",
+	} {
+		err := validatePackageChartfile([]byte(chartfile))
+		if expect == "" {
+			assert.NoError(t, err, chartfile)
+		} else {
+			assert.EqualError(t, err, expect, chartfile)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"time"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
+)
+
+// ErrReleasePinned is returned by an upgrade, a rollback or an uninstall of a
+// pinned release, unless the pin is overridden.
+type ErrReleasePinned struct {
+	// Release is the name of the pinned release.
+	Release string
+	// Reason, PinnedBy and Since are recorded by the pin.
+	Reason   string
+	PinnedBy string
+	Since    helmtime.Time
+}
+
+func (e *ErrReleasePinned) Error() string {
+	msg := fmt.Sprintf("release %q is pinned", e.Release)
+	if e.PinnedBy != "" {
+		msg += " by " + e.PinnedBy
+	}
+	if !e.Since.IsZero() {
+		msg += " since " + e.Since.Format(time.RFC3339)
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg + ". Use --override-pin to change it anyway, or 'helm unpin' to remove the pin"
+}
+
+// checkPin returns an *ErrReleasePinned if rel is pinned and the pin is not
+// overridden.
+func checkPin(rel *release.Release, override bool) error {
+	if rel == nil || rel.Pin == nil || override {
+		return nil
+	}
+	return &ErrReleasePinned{
+		Release:  rel.Name,
+		Reason:   rel.Pin.Reason,
+		PinnedBy: rel.Pin.PinnedBy,
+		Since:    rel.Pin.Since,
+	}
+}
+
+// Pin is the action for pinning a release, so that it cannot be upgraded,
+// rolled back or uninstalled unless the pin is overridden.
+//
+// It provides the implementation of 'helm pin'.
+type Pin struct {
+	cfg *Configuration
+
+	// Reason is reported when the pin refuses an operation.
+	Reason string
+	// PinnedBy identifies who pinned the release. It defaults to the local
+	// user running Helm.
+	PinnedBy string
+}
+
+// NewPin creates a new Pin object with the given configuration.
+func NewPin(cfg *Configuration) *Pin {
+	return &Pin{
+		cfg: cfg,
+	}
+}
+
+// Run pins the release name. The pin is recorded on its latest revision, and
+// replaces the previous pin of the release, if any.
+func (p *Pin) Run(name string) (*release.Release, error) {
+	rel, err := lastRevisionToPin(p.cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	pinnedBy := p.PinnedBy
+	if pinnedBy == "" {
+		pinnedBy = currentOperator()
+	}
+	rel.Pin = &release.Pin{
+		Reason:   p.Reason,
+		PinnedBy: pinnedBy,
+		Since:    p.cfg.Now(),
+	}
+	if err := p.cfg.Releases.Update(rel); err != nil {
+		return nil, fmt.Errorf("unable to pin release %q: %w", name, err)
+	}
+	return rel, nil
+}
+
+// Unpin is the action for removing the pin of a release.
+//
+// It provides the implementation of 'helm unpin'.
+type Unpin struct {
+	cfg *Configuration
+}
+
+// NewUnpin creates a new Unpin object with the given configuration.
+func NewUnpin(cfg *Configuration) *Unpin {
+	return &Unpin{
+		cfg: cfg,
+	}
+}
+
+// Run removes the pin of the release name.
+func (u *Unpin) Run(name string) (*release.Release, error) {
+	rel, err := lastRevisionToPin(u.cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Pin == nil {
+		return nil, fmt.Errorf("release %q is not pinned", name)
+	}
+	rel.Pin = nil
+	if err := u.cfg.Releases.Update(rel); err != nil {
+		return nil, fmt.Errorf("unable to unpin release %q: %w", name, err)
+	}
+	return rel, nil
+}
+
+// lastRevisionToPin returns the latest revision of the release name, which
+// holds its pin. A pending revision is refused, as the operation in progress
+// would not carry over a pin set meanwhile.
+func lastRevisionToPin(cfg *Configuration, name string) (*release.Release, error) {
+	if err := cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	rel, err := cfg.Releases.Last(name)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Info != nil && rel.Info.Status.IsPending() {
+		return nil, errPending
+	}
+	return rel, nil
+}
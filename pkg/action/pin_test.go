@@ -0,0 +1,201 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// pinRelease pins the release name of cfg.
+func pinRelease(t *testing.T, cfg *Configuration, name string) *release.Pin {
+	t.Helper()
+	pin := NewPin(cfg)
+	pin.Reason = "production database"
+	pin.PinnedBy = "ops"
+	rel, err := pin.Run(name)
+	require.NoError(t, err)
+	return rel.Pin
+}
+
+func requireReleasePinned(t *testing.T, err error) {
+	t.Helper()
+	var pinned *ErrReleasePinned
+	require.True(t, errors.As(err, &pinned), "expected the release to be pinned, got %v", err)
+	assert.Equal(t, "production database", pinned.Reason)
+	assert.Equal(t, "ops", pinned.PinnedBy)
+	assert.Equal(t, "2025-12-15T10:00:00Z", pinned.Since.UTC().Format("2006-01-02T15:04:05Z07:00"))
+}
+
+func TestPin(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+	cfg := actionConfigFixture(t)
+	rel := releaseStub()
+	require.NoError(t, cfg.Releases.Create(rel))
+
+	pin := pinRelease(t, cfg, rel.Name)
+	stored, err := cfg.Releases.Get(rel.Name, 1)
+	require.NoError(t, err)
+	assert.Equal(t, pin, stored.Pin)
+
+	metadata, err := NewGetMetadata(cfg).Run(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, pin, metadata.Pin)
+
+	// The local user pins the release by default.
+	pinned, err := NewPin(cfg).Run(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, currentOperator(), pinned.Pin.PinnedBy)
+
+	_, err = NewUnpin(cfg).Run(rel.Name)
+	require.NoError(t, err)
+	stored, err = cfg.Releases.Get(rel.Name, 1)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Pin)
+
+	_, err = NewUnpin(cfg).Run(rel.Name)
+	assert.ErrorContains(t, err, `release "angry-panda" is not pinned`)
+
+	_, err = NewPin(cfg).Run("missing")
+	assert.Error(t, err)
+}
+
+func TestPinPendingRelease(t *testing.T) {
+	cfg := actionConfigFixture(t)
+	rel := namedReleaseStub("pending", release.StatusPendingUpgrade)
+	require.NoError(t, cfg.Releases.Create(rel))
+
+	_, err := NewPin(cfg).Run(rel.Name)
+	assert.ErrorIs(t, err, errPending)
+}
+
+func TestUpgradePinnedRelease(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+	pin := pinRelease(t, upAction.cfg, rel.Name)
+
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	requireReleasePinned(t, err)
+	assert.ErrorContains(t, err, `release "angry-panda" is pinned by ops since 2025-12-15T10:00:00Z: production database`)
+	_, err = upAction.cfg.Releases.Get(rel.Name, 2)
+	assert.Error(t, err, "no revision should be recorded")
+
+	upAction.OverridePin = true
+	res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, pin, res.Pin, "the pin should be kept")
+
+	upAction = NewUpgrade(upAction.cfg)
+	upAction.Namespace = "spaced"
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	requireReleasePinned(t, err)
+}
+
+func TestRollbackPinnedRelease(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+	config := actionConfigFixture(t)
+	previous := namedReleaseStub("pinned", release.StatusSuperseded)
+	current := namedReleaseStub("pinned", release.StatusDeployed)
+	current.Version = 2
+	require.NoError(t, config.Releases.Create(previous))
+	require.NoError(t, config.Releases.Create(current))
+	pin := pinRelease(t, config, "pinned")
+
+	rb := NewRollback(config)
+	requireReleasePinned(t, rb.Run("pinned"))
+
+	rb = NewRollback(config)
+	rb.OverridePin = true
+	require.NoError(t, rb.Run("pinned"))
+	rel, err := config.Releases.Get("pinned", 3)
+	require.NoError(t, err)
+	assert.Equal(t, pin, rel.Pin, "the pin should not be rolled back")
+}
+
+func TestUpgradePinnedRelease_Atomic(t *testing.T) {
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "pinned"
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+	pinRelease(t, upAction.cfg, rel.Name)
+
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WatchUntilReadyError = errors.New("arming key removed")
+	upAction.Atomic = true
+	upAction.OverridePin = true
+
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has been rolled back due to atomic being set")
+	rolledBack, err := upAction.cfg.Releases.Get(rel.Name, 3)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rolledBack.Info.Status)
+}
+
+func TestRollbackPinnedRelease_Atomic(t *testing.T) {
+	rb := rollbackAtomicFixture(t, "pinned")
+	pinRelease(t, rb.cfg, "pinned")
+	rb.OverridePin = true
+	failer := rb.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WatchUntilReadyError = errors.New("hook timed out")
+
+	err := rb.Run("pinned")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has been rolled forward due to atomic being set")
+	rolledForward, err := rb.cfg.Releases.Get("pinned", 4)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rolledForward.Info.Status)
+}
+
+func TestUninstallPinnedRelease(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	rel := releaseStub()
+	require.NoError(t, unAction.cfg.Releases.Create(rel))
+	pinRelease(t, unAction.cfg, rel.Name)
+
+	_, err := unAction.Run(rel.Name)
+	requireReleasePinned(t, err)
+	stored, err := unAction.cfg.Releases.Get(rel.Name, 1)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, stored.Info.Status)
+
+	unAction.OverridePin = true
+	_, err = unAction.Run(rel.Name)
+	require.NoError(t, err)
+}
+
+func TestListPinned(t *testing.T) {
+	lister := newListFixture(t)
+	makeMeSomeReleases(t, lister.cfg.Releases)
+	pinRelease(t, lister.cfg, "two")
+
+	lister.Pinned = true
+	list, err := lister.Run()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "two", list[0].Name)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// LoadPolicyFile reads a release policy from a YAML file, and validates it.
+func LoadPolicyFile(path string) (*release.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy release.Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse the release policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid release policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// checkPolicy evaluates the policy stored on rel before op changes the
+// release. tokens are the tokens given to approve the operation or override
+// the policy.
+func (cfg *Configuration) checkPolicy(rel *release.Release, op release.PolicyOperation, tokens []string) error {
+	if rel == nil || rel.Policy == nil {
+		return nil
+	}
+	var lastDeployed time.Time
+	if rel.Info != nil {
+		lastDeployed = rel.Info.LastDeployed.Time
+	}
+	if err := rel.Policy.Check(op, cfg.Now().Time, lastDeployed, tokens); err != nil {
+		return fmt.Errorf("%s of release %q refused: %w", op, rel.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
+)
+
+// freezePolicy freezes the releases during December 2025.
+func freezePolicy() *release.Policy {
+	return &release.Policy{
+		FreezeWindows:  []release.FreezeWindow{{Start: "2025-12-01T00:00:00Z", End: "2026-01-01T00:00:00Z", Reason: "end of year"}},
+		OverrideTokens: []string{"hotfix"},
+	}
+}
+
+// setNow sets the time of the actions for the duration of the test.
+func setNow(t *testing.T, now string) {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, now)
+	require.NoError(t, err)
+	previous := Timestamper
+	Timestamper = func() helmtime.Time { return helmtime.Time{Time: ts} }
+	t.Cleanup(func() { Timestamper = previous })
+}
+
+func requirePolicyViolation(t *testing.T, err error, rule string) {
+	t.Helper()
+	var violation *release.PolicyViolation
+	require.True(t, errors.As(err, &violation), "expected a policy violation, got %v", err)
+	assert.Equal(t, rule, violation.Rule)
+}
+
+func TestInstallReleasePolicy(t *testing.T) {
+	instAction := installAction(t)
+	instAction.Policy = freezePolicy()
+
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+
+	rel, err := instAction.cfg.Releases.Get(res.Name, 1)
+	require.NoError(t, err)
+	assert.Equal(t, freezePolicy().WithHashedTokens(), rel.Policy)
+	assert.NotContains(t, rel.Policy.OverrideTokens, "hotfix", "the tokens should be stored hashed")
+	assert.Equal(t, []string{"hotfix"}, instAction.Policy.OverrideTokens, "the policy of the action should be left alone")
+}
+
+func TestUpgradeReleasePolicy(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+
+	t.Run("refused during a freeze window", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := releaseStub()
+		rel.Policy = freezePolicy()
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		requirePolicyViolation(t, err, "freezeWindows[0]")
+		assert.ErrorContains(t, err, `upgrade of release "angry-panda" refused`)
+		assert.ErrorContains(t, err, "end of year")
+
+		_, err = upAction.cfg.Releases.Get(rel.Name, 2)
+		assert.Error(t, err, "no revision should be recorded")
+	})
+
+	t.Run("allowed with an override token", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		upAction.PolicyOverrides = []string{"hotfix"}
+		rel := releaseStub()
+		rel.Policy = freezePolicy()
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+		res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, freezePolicy(), res.Policy, "the policy should be kept")
+	})
+
+	t.Run("atomic rollback with an override token", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		upAction.PolicyOverrides = []string{"hotfix"}
+		upAction.Atomic = true
+		failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = errors.New("arming key removed")
+		rel := releaseStub()
+		rel.Policy = freezePolicy().WithHashedTokens()
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has been rolled back due to atomic being set")
+		rolledBack, err := upAction.cfg.Releases.Get(rel.Name, 3)
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, rolledBack.Info.Status)
+	})
+
+	t.Run("minimum interval", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := releaseStub()
+		rel.Info.LastDeployed = helmtime.Time{Time: time.Date(2025, 12, 15, 9, 30, 0, 0, time.UTC)}
+		rel.Policy = &release.Policy{MinInterval: "1h"}
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		requirePolicyViolation(t, err, "minInterval")
+		assert.ErrorContains(t, err, "the release was deployed 30m0s ago, 1h0m0s must pass between deployments")
+	})
+
+	t.Run("replaces the policy", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		upAction.Policy = &release.Policy{RequireFlag: []string{"approved"}}
+		rel := releaseStub()
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+		res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, upAction.Policy.WithHashedTokens(), res.Policy)
+
+		upAction = NewUpgrade(upAction.cfg)
+		upAction.Namespace = "spaced"
+		_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		requirePolicyViolation(t, err, "requireFlag")
+	})
+}
+
+func TestRollbackReleasePolicy(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+
+	rollbackFixture := func(t *testing.T) *Rollback {
+		t.Helper()
+		config := actionConfigFixture(t)
+		previous := namedReleaseStub("frozen", release.StatusSuperseded)
+		current := namedReleaseStub("frozen", release.StatusDeployed)
+		current.Version = 2
+		current.Policy = freezePolicy()
+		require.NoError(t, config.Releases.Create(previous))
+		require.NoError(t, config.Releases.Create(current))
+		return NewRollback(config)
+	}
+
+	rb := rollbackFixture(t)
+	err := rb.Run("frozen")
+	requirePolicyViolation(t, err, "freezeWindows[0]")
+	assert.ErrorContains(t, err, `rollback of release "frozen" refused`)
+
+	rb = rollbackFixture(t)
+	rb.PolicyOverrides = []string{"hotfix"}
+	require.NoError(t, rb.Run("frozen"))
+	rel, err := rb.cfg.Releases.Get("frozen", 3)
+	require.NoError(t, err)
+	assert.Equal(t, freezePolicy(), rel.Policy, "the policy should not be rolled back")
+}
+
+func TestUninstallReleasePolicy(t *testing.T) {
+	setNow(t, "2025-12-15T10:00:00Z")
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	rel := releaseStub()
+	rel.Policy = freezePolicy()
+	require.NoError(t, unAction.cfg.Releases.Create(rel))
+
+	_, err := unAction.Run(rel.Name)
+	requirePolicyViolation(t, err, "freezeWindows[0]")
+	stored, err := unAction.cfg.Releases.Get(rel.Name, 1)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, stored.Info.Status)
+
+	unAction.PolicyOverrides = []string{"hotfix"}
+	_, err = unAction.Run(rel.Name)
+	require.NoError(t, err)
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	policy, err := LoadPolicyFile(write("policy.yaml", `freezeWindows:
+- cron: "0 18 * * 5"
+  duration: 62h
+  reason: weekend
+minInterval: 1h
+requireFlag: [approved]
+overrideTokens: [hotfix]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, &release.Policy{
+		FreezeWindows:  []release.FreezeWindow{{Cron: "0 18 * * 5", Duration: "62h", Reason: "weekend"}},
+		MinInterval:    "1h",
+		RequireFlag:    []string{"approved"},
+		OverrideTokens: []string{"hotfix"},
+	}, policy)
+
+	_, err = LoadPolicyFile(write("unknown.yaml", "freezeWindow: []\n"))
+	assert.ErrorContains(t, err, "failed to parse the release policy")
+
+	_, err = LoadPolicyFile(write("invalid.yaml", "minInterval: soon\n"))
+	assert.ErrorContains(t, err, "invalid release policy")
+
+	_, err = LoadPolicyFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+
+	"k8s.io/cli-runtime/pkg/resource"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
+)
+
+// ProgressPhase identifies a stage of an install or upgrade operation.
+type ProgressPhase string
+
+// Phases reported through ProgressEvent, in the order in which they occur.
+const (
+	// ProgressCRDInstall is reported for every CRD installed from the chart's crds/ directory.
+	ProgressCRDInstall ProgressPhase = "CRDInstall"
+	// ProgressRender is reported when the chart templates are about to be rendered.
+	ProgressRender ProgressPhase = "Render"
+	// ProgressPreHook is reported for every pre-install or pre-upgrade hook before it runs.
+	ProgressPreHook ProgressPhase = "PreHook"
+	// ProgressApply is reported for every resource about to be created or updated.
+	ProgressApply ProgressPhase = "Apply"
+	// ProgressWait is reported when waiting for the release resources to become ready.
+	ProgressWait ProgressPhase = "Wait"
+	// ProgressPostHook is reported for every post-install or post-upgrade hook before it runs.
+	ProgressPostHook ProgressPhase = "PostHook"
+	// ProgressPersist is reported when the final state of the release is about to be stored.
+	ProgressPersist ProgressPhase = "Persist"
+)
+
+// ProgressEvent describes the progress of an install or upgrade operation.
+type ProgressEvent struct {
+	// Phase is the stage the operation entered.
+	Phase ProgressPhase
+	// Release is the name of the release being operated on.
+	Release string
+	// Resource identifies the resource, hook or CRD involved as "Kind/name",
+	// or is empty when the phase applies to the release as a whole.
+	Resource string
+	// Time is when the event was emitted.
+	Time helmtime.Time
+}
+
+// emitProgress synchronously hands an event to fn, if set. A panicking
+// callback is logged and otherwise ignored so that it cannot abort the
+// operation.
+func (cfg *Configuration) emitProgress(fn func(ProgressEvent), phase ProgressPhase, releaseName, resourceName string) {
+	if fn == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			cfg.log().Warn("progress callback panicked", "phase", phase, "release", releaseName, "panic", r)
+		}
+	}()
+	fn(ProgressEvent{
+		Phase:    phase,
+		Release:  releaseName,
+		Resource: resourceName,
+		Time:     Timestamper(),
+	})
+}
+
+// emitApplyProgress reports a ProgressApply event for each of the resources.
+func (cfg *Configuration) emitApplyProgress(fn func(ProgressEvent), releaseName string, resources []*resource.Info) {
+	if fn == nil {
+		return
+	}
+	for _, r := range resources {
+		var kind string
+		if r.Mapping != nil {
+			kind = r.Mapping.GroupVersionKind.Kind
+		} else if r.Object != nil {
+			kind = r.Object.GetObjectKind().GroupVersionKind().Kind
+		}
+		cfg.emitProgress(fn, ProgressApply, releaseName, fmt.Sprintf("%s/%s", kind, r.Name))
+	}
+}
+
+// hookProgress returns a callback for execHookWithProgress that reports a
+// progress event for each executed hook.
+func (cfg *Configuration) hookProgress(fn func(ProgressEvent), phase ProgressPhase, releaseName string) func(*release.Hook) {
+	if fn == nil {
+		return nil
+	}
+	return func(h *release.Hook) {
+		cfg.emitProgress(fn, phase, releaseName, fmt.Sprintf("%s/%s", h.Kind, h.Name))
+	}
+}
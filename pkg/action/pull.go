@@ -18,6 +18,7 @@ package action
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -73,6 +74,7 @@ func (p *Pull) SetRegistryClient(client *registry.Client) {
 func (p *Pull) Run(chartRef string) (string, error) {
 	var out strings.Builder
 
+	bearerToken := p.bearerToken(p.Settings)
 	c := downloader.ChartDownloader{
 		Out:     &out,
 		Keyring: p.Keyring,
@@ -84,6 +86,7 @@ func (p *Pull) Run(chartRef string) (string, error) {
 			getter.WithTLSClientConfig(p.CertFile, p.KeyFile, p.CaFile),
 			getter.WithInsecureSkipVerifyTLS(p.InsecureSkipTLSverify),
 			getter.WithPlainHTTP(p.PlainHTTP),
+			getter.WithRetries(p.DownloadRetries, getter.DefaultRetryBackoff),
 		},
 		RegistryClient:   p.cfg.RegistryClient,
 		RepositoryConfig: p.Settings.RepositoryConfig,
@@ -122,14 +125,30 @@ func (p *Pull) Run(chartRef string) (string, error) {
 			repo.WithChartVersion(p.Version),
 			repo.WithClientTLS(p.CertFile, p.KeyFile, p.CaFile),
 			repo.WithUsernamePassword(p.Username, p.Password),
+			repo.WithBearerToken(bearerToken),
 			repo.WithInsecureSkipTLSverify(p.InsecureSkipTLSverify),
 			repo.WithPassCredentialsAll(p.PassCredentialsAll),
 		)
 		if err != nil {
 			return out.String(), err
 		}
+
+		// The bearer token is only sent to the chart repository, unless the
+		// user said to pass the credentials to all domains.
+		u1, err := url.Parse(p.RepoURL)
+		if err != nil {
+			return out.String(), err
+		}
+		u2, err := url.Parse(chartURL)
+		if err != nil {
+			return out.String(), err
+		}
+		if !getter.ShouldForwardCredentials(u1, u2, p.PassCredentialsAll, nil) {
+			bearerToken = ""
+		}
 		chartRef = chartURL
 	}
+	c.Options = append(c.Options, getter.WithBearerToken(bearerToken))
 
 	saved, v, err := c.DownloadTo(chartRef, p.Version, dest)
 	if err != nil {
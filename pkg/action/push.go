@@ -30,7 +30,19 @@ import (
 //
 // It provides the implementation of 'helm push'.
 type Push struct {
-	Settings              *cli.EnvSettings
+	Settings *cli.EnvSettings
+	// WithProvenance requires the chart's provenance file to be uploaded
+	// alongside the chart. Without it, the provenance file is uploaded only
+	// when it is found next to the chart archive.
+	WithProvenance bool
+	// Tag is the tag the chart is pushed to, instead of its version, which is
+	// still recorded in the config of the OCI artifact.
+	Tag string
+	// Force pushes the chart even if its tag already exists. Without it, the
+	// push fails with registry.ErrTagAlreadyExists, as registries with
+	// immutable tags would reject it.
+	Force bool
+
 	cfg                   *Configuration
 	certFile              string
 	keyFile               string
@@ -100,6 +112,9 @@ func (p *Push) Run(chartRef string, remote string) (string, error) {
 			pusher.WithTLSClientConfig(p.certFile, p.keyFile, p.caFile),
 			pusher.WithInsecureSkipTLSVerify(p.insecureSkipTLSverify),
 			pusher.WithPlainHTTP(p.plainHTTP),
+			pusher.WithProvenance(p.WithProvenance),
+			pusher.WithTag(p.Tag),
+			pusher.WithForce(p.Force),
 		},
 	}
 
@@ -0,0 +1,66 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// Recover is the action for recovering a release whose last operation was
+// interrupted, leaving its latest revision pending.
+//
+// It provides the implementation of 'helm recover'.
+type Recover struct {
+	cfg *Configuration
+}
+
+// NewRecover creates a new Recover object with the given configuration.
+func NewRecover(cfg *Configuration) *Recover {
+	return &Recover{
+		cfg: cfg,
+	}
+}
+
+// Run marks the pending latest revision of the release name as failed, so
+// that the release can be upgraded or rolled back again. The resources are
+// left as they are.
+func (r *Recover) Run(name string) (*release.Release, error) {
+	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	rel, err := r.cfg.Releases.Last(name)
+	if err != nil {
+		return nil, err
+	}
+	if rel.Info == nil || !rel.Info.Status.IsPending() {
+		return nil, fmt.Errorf("release %q has no interrupted operation to recover from", name)
+	}
+
+	rel.Info.Description = fmt.Sprintf("Recovered from interrupted operation: %s", rel.Info.Status)
+	rel.Info.Status = release.StatusFailed
+	rel.Info.Checkpoint = nil
+	if err := r.cfg.Releases.Update(rel); err != nil {
+		return nil, fmt.Errorf("unable to recover release %q: %w", name, err)
+	}
+	return rel, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestRecover(t *testing.T) {
+	cfg := actionConfigFixture(t)
+	rel := releaseStub()
+	rel.Info.Status = release.StatusPendingUpgrade
+	rel.Info.Checkpoint = &release.UpgradeCheckpoint{Digest: "digest"}
+	require.NoError(t, cfg.Releases.Create(rel))
+
+	recovered, err := NewRecover(cfg).Run(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusFailed, recovered.Info.Status)
+
+	stored, err := cfg.Releases.Get(rel.Name, rel.Version)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusFailed, stored.Info.Status)
+	assert.Equal(t, "Recovered from interrupted operation: pending-upgrade", stored.Info.Description)
+	assert.Nil(t, stored.Info.Checkpoint)
+
+	_, err = NewRecover(cfg).Run(rel.Name)
+	assert.ErrorContains(t, err, `release "angry-panda" has no interrupted operation to recover from`)
+
+	_, err = NewRecover(cfg).Run("missing")
+	assert.Error(t, err)
+}
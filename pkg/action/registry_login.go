@@ -30,6 +30,8 @@ type RegistryLogin struct {
 	caFile    string
 	insecure  bool
 	plainHTTP bool
+	// credHelper is the name of the docker credential helper to log in with.
+	credHelper string
 }
 
 type RegistryLoginOpt func(*RegistryLogin) error
@@ -74,6 +76,15 @@ func WithPlainHTTPLogin(isPlain bool) RegistryLoginOpt {
 	}
 }
 
+// WithCredentialHelper logs in with the credentials of the docker credential
+// helper docker-credential-<name>, rather than a username and password.
+func WithCredentialHelper(name string) RegistryLoginOpt {
+	return func(r *RegistryLogin) error {
+		r.credHelper = name
+		return nil
+	}
+}
+
 // NewRegistryLogin creates a new RegistryLogin object with the given configuration.
 func NewRegistryLogin(cfg *Configuration) *RegistryLogin {
 	return &RegistryLogin{
@@ -89,11 +100,14 @@ func (a *RegistryLogin) Run(_ io.Writer, hostname string, username string, passw
 		}
 	}
 
-	return a.cfg.RegistryClient.Login(
-		hostname,
+	loginOpts := []registry.LoginOption{
 		registry.LoginOptBasicAuth(username, password),
 		registry.LoginOptInsecure(a.insecure),
 		registry.LoginOptTLSClientConfig(a.certFile, a.keyFile, a.caFile),
 		registry.LoginOptPlainText(a.plainHTTP),
-	)
+	}
+	if a.credHelper != "" {
+		loginOpts = append(loginOpts, registry.LoginOptCredentialHelper(a.credHelper))
+	}
+	return a.cfg.RegistryClient.Login(hostname, loginOpts...)
 }
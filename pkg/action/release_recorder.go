@@ -0,0 +1,206 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/user"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// ReleaseOperation is an operation changing the state of a release.
+type ReleaseOperation string
+
+// Operations reported through ReleaseEvent.
+const (
+	OperationInstall   ReleaseOperation = "install"
+	OperationUpgrade   ReleaseOperation = "upgrade"
+	OperationRollback  ReleaseOperation = "rollback"
+	OperationUninstall ReleaseOperation = "uninstall"
+)
+
+// ReleaseEvent describes an operation on a release which reached a terminal
+// state, whether it succeeded or failed.
+type ReleaseEvent struct {
+	Operation ReleaseOperation `json:"operation"`
+	Release   string           `json:"release"`
+	Namespace string           `json:"namespace"`
+	// Revision is the revision of the release the operation produced, or 0
+	// if it failed before producing one.
+	Revision     int    `json:"revision"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chart_version"`
+	AppVersion   string `json:"app_version"`
+	// Digest is the SHA-256 digest of the manifest of the release, prefixed
+	// with "sha256:".
+	Digest string `json:"digest,omitempty"`
+	Status string `json:"status"`
+	// Error is the error the operation failed with.
+	Error string `json:"error,omitempty"`
+	// Operator is the local user who ran the operation.
+	Operator   string    `json:"operator"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// ReleaseRecorder records the operations on releases to an external system,
+// such as a compliance or audit log.
+type ReleaseRecorder interface {
+	Record(ctx context.Context, event ReleaseEvent) error
+}
+
+// newReleaseEvent describes the outcome of an operation on the release name.
+// rel is the release the operation produced, and ch the chart it was given;
+// either may be nil if the operation failed early.
+func newReleaseEvent(op ReleaseOperation, name, namespace string, rel *release.Release, ch *chart.Chart, err error, startedAt time.Time) ReleaseEvent {
+	event := ReleaseEvent{
+		Operation:  op,
+		Release:    name,
+		Namespace:  namespace,
+		Status:     release.StatusFailed.String(),
+		Operator:   currentOperator(),
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: time.Now().UTC(),
+	}
+	if rel != nil {
+		event.Namespace = rel.Namespace
+		event.Revision = rel.Version
+		if rel.Info != nil {
+			event.Status = rel.Info.Status.String()
+		}
+		if rel.Manifest != "" {
+			sum := sha256.Sum256([]byte(rel.Manifest))
+			event.Digest = "sha256:" + hex.EncodeToString(sum[:])
+		}
+		if rel.Chart != nil {
+			ch = rel.Chart
+		}
+	}
+	if ch != nil && ch.Metadata != nil {
+		event.Chart = ch.Metadata.Name
+		event.ChartVersion = ch.Metadata.Version
+		event.AppVersion = ch.Metadata.AppVersion
+	}
+	if err != nil {
+		event.Status = release.StatusFailed.String()
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// currentOperator returns the name of the local user running Helm.
+func currentOperator() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// notifyRecorder passes an event to the release recorder, if any. Failing to
+// record the event is only logged, unless RequireRecord is set.
+func (cfg *Configuration) notifyRecorder(ctx context.Context, event ReleaseEvent) error {
+	if cfg.ReleaseRecorder == nil {
+		return nil
+	}
+	// The event is recorded even when the operation was cancelled.
+	if err := cfg.ReleaseRecorder.Record(context.WithoutCancel(ctx), event); err != nil {
+		if cfg.RequireRecord {
+			return fmt.Errorf("unable to record the %s of release %s: %w", event.Operation, event.Release, err)
+		}
+		cfg.log().Warn("unable to record release operation", "operation", event.Operation, "release", event.Release, slog.Any("error", err))
+	}
+	return nil
+}
+
+// WebhookRecorder is a ReleaseRecorder posting each event as JSON to an HTTP
+// endpoint.
+type WebhookRecorder struct {
+	// URL is the endpoint the events are posted to.
+	URL string
+	// Secret, if set, signs the payload of each event with HMAC-SHA256. The
+	// signature is sent hex encoded in the X-Helm-Signature-256 header,
+	// prefixed with "sha256=".
+	Secret string
+	// Client is the HTTP client used to post the events.
+	Client *http.Client
+}
+
+// NewWebhookRecorder creates a WebhookRecorder posting to url, signing the
+// payloads with secret if it is not empty.
+func NewWebhookRecorder(url, secret string) *WebhookRecorder {
+	return &WebhookRecorder{
+		URL:    url,
+		Secret: secret,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Record posts event to the endpoint, failing unless it answers with a 2xx
+// status.
+func (w *WebhookRecorder) Record(ctx context.Context, event ReleaseEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set("X-Helm-Signature-256", "sha256="+SignPayload(payload, w.Secret))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("release webhook %s answered %s", w.URL, resp.Status)
+	}
+	return nil
+}
+
+// SignPayload returns the hex encoded HMAC-SHA256 of payload with secret, as
+// sent by WebhookRecorder.
+func SignPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// fakeRecorder keeps the events it records, failing with err if set.
+type fakeRecorder struct {
+	events []ReleaseEvent
+	err    error
+}
+
+func (r *fakeRecorder) Record(_ context.Context, event ReleaseEvent) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func withRecorder(cfg *Configuration) *fakeRecorder {
+	r := &fakeRecorder{}
+	cfg.ReleaseRecorder = r
+	return r
+}
+
+func TestReleaseRecorder_Install(t *testing.T) {
+	instAction := installAction(t)
+	recorder := withRecorder(instAction.cfg)
+
+	res, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, OperationInstall, event.Operation)
+	assert.Equal(t, "test-install-release", event.Release)
+	assert.Equal(t, "spaced", event.Namespace)
+	assert.Equal(t, 1, event.Revision)
+	assert.Equal(t, "hello", event.Chart)
+	assert.Equal(t, "0.1.0", event.ChartVersion)
+	assert.Equal(t, release.StatusDeployed.String(), event.Status)
+	assert.Empty(t, event.Error)
+	assert.True(t, strings.HasPrefix(event.Digest, "sha256:"), "unexpected digest %q", event.Digest)
+	assert.False(t, event.FinishedAt.Before(event.StartedAt))
+	assert.Equal(t, res.Version, event.Revision)
+}
+
+func TestReleaseRecorder_InstallFailure(t *testing.T) {
+	instAction := installAction(t)
+	recorder := withRecorder(instAction.cfg)
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.CreateError = errors.New("create failed")
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.Error(t, err)
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, OperationInstall, event.Operation)
+	assert.Equal(t, release.StatusFailed.String(), event.Status)
+	assert.Contains(t, event.Error, "create failed")
+	assert.Equal(t, "hello", event.Chart)
+}
+
+func TestReleaseRecorder_InstallDryRun(t *testing.T) {
+	instAction := installAction(t)
+	recorder := withRecorder(instAction.cfg)
+	instAction.DryRun = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.events, "dry runs are not recorded")
+}
+
+func TestReleaseRecorder_RecordFailure(t *testing.T) {
+	instAction := installAction(t)
+	recorder := withRecorder(instAction.cfg)
+	recorder.err = errors.New("endpoint unavailable")
+
+	res, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err, "recording failures are not fatal by default")
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+
+	instAction = installAction(t)
+	recorder = withRecorder(instAction.cfg)
+	recorder.err = errors.New("endpoint unavailable")
+	instAction.cfg.RequireRecord = true
+
+	res, err = instAction.Run(buildChart(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to record the install of release test-install-release: endpoint unavailable")
+	assert.NotNil(t, res)
+}
+
+func TestReleaseRecorder_Upgrade(t *testing.T) {
+	upAction := upgradeAction(t)
+	recorder := withRecorder(upAction.cfg)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	_, err := upAction.Run(rel.Name, buildChart(), nil)
+	require.NoError(t, err)
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, OperationUpgrade, recorder.events[0].Operation)
+	assert.Equal(t, 2, recorder.events[0].Revision)
+	assert.Equal(t, release.StatusDeployed.String(), recorder.events[0].Status)
+
+	_, err = upAction.Run("missing-release", buildChart(), nil)
+	require.Error(t, err)
+	require.Len(t, recorder.events, 2)
+	assert.Equal(t, "missing-release", recorder.events[1].Release)
+	assert.Equal(t, "spaced", recorder.events[1].Namespace)
+	assert.Equal(t, 0, recorder.events[1].Revision)
+	assert.Equal(t, release.StatusFailed.String(), recorder.events[1].Status)
+}
+
+func TestReleaseRecorder_Rollback(t *testing.T) {
+	rb, _ := rollbackValuesFixture(t)
+	recorder := withRecorder(rb.cfg)
+
+	require.NoError(t, rb.Run("values-rollback"))
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, OperationRollback, recorder.events[0].Operation)
+	assert.Equal(t, 3, recorder.events[0].Revision)
+	assert.Equal(t, release.StatusDeployed.String(), recorder.events[0].Status)
+}
+
+func TestReleaseRecorder_Uninstall(t *testing.T) {
+	unAction := uninstallAction(t)
+	recorder := withRecorder(unAction.cfg)
+	unAction.DisableHooks = true
+	rel := releaseStub()
+	require.NoError(t, unAction.cfg.Releases.Create(rel))
+
+	_, err := unAction.Run(rel.Name)
+	require.NoError(t, err)
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, OperationUninstall, recorder.events[0].Operation)
+	assert.Equal(t, rel.Name, recorder.events[0].Release)
+	assert.Equal(t, release.StatusUninstalled.String(), recorder.events[0].Status)
+
+	unAction.IgnoreNotFound = true
+	_, err = unAction.Run("release-non-exist")
+	require.NoError(t, err)
+	assert.Len(t, recorder.events, 1, "nothing was uninstalled")
+}
+
+func TestWebhookRecorder(t *testing.T) {
+	var body []byte
+	var signature, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Helm-Signature-256")
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	event := ReleaseEvent{
+		Operation: OperationInstall,
+		Release:   "audited",
+		Namespace: "default",
+		Revision:  1,
+		Status:    release.StatusDeployed.String(),
+	}
+	require.NoError(t, NewWebhookRecorder(srv.URL, "s3cr3t").Record(t.Context(), event))
+
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "sha256="+SignPayload(body, "s3cr3t"), signature)
+	var got ReleaseEvent
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, event, got)
+
+	require.NoError(t, NewWebhookRecorder(srv.URL, "").Record(t.Context(), event))
+	assert.Empty(t, signature, "payloads are only signed with a secret")
+}
+
+func TestWebhookRecorder_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookRecorder(srv.URL, "").Record(t.Context(), ReleaseEvent{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
+
+func TestSignPayload(t *testing.T) {
+	// HMAC-SHA256 test case 2 from RFC 4231.
+	assert.Equal(t,
+		"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		SignPayload([]byte("what do ya want for nothing?"), "Jefe"))
+}
@@ -0,0 +1,114 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// ReleaseRepair is the action for finding the records of the revisions of a
+// release which cannot be decoded, and deleting them.
+//
+// It provides the implementation of 'helm release repair'.
+type ReleaseRepair struct {
+	cfg *Configuration
+
+	// DeleteCorrupt deletes the records which cannot be decoded.
+	DeleteCorrupt bool
+	// ConfirmDelete, if set, is called with the report listing the records
+	// about to be deleted. Returning an error aborts the deletion.
+	ConfirmDelete func(report *ReleaseRepairReport) error
+}
+
+// ReleaseRepairReport describes the records of the revisions of a release.
+type ReleaseRepairReport struct {
+	// Readable are the revisions whose records can be decoded, in order.
+	Readable []int
+	// Corrupt are the records which cannot be decoded, ordered by revision.
+	Corrupt []*driver.CorruptReleaseError
+	// Deleted are the keys of the records deleted.
+	Deleted []string
+}
+
+// NewReleaseRepair creates a new ReleaseRepair object with the given
+// configuration.
+func NewReleaseRepair(cfg *Configuration) *ReleaseRepair {
+	return &ReleaseRepair{
+		cfg: cfg,
+	}
+}
+
+// Run executes 'helm release repair' against the given release.
+func (r *ReleaseRepair) Run(name string) (*ReleaseRepairReport, error) {
+	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+
+	r.cfg.log().Debug("checking the records of release", "release", name)
+	readable, corrupt, err := r.cfg.Releases.ListWithCorrupt(func(rel *release.Release) bool {
+		return rel.Name == name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReleaseRepairReport{}
+	for _, rel := range readable {
+		report.Readable = append(report.Readable, rel.Version)
+	}
+	sort.Ints(report.Readable)
+	for _, c := range corrupt {
+		if c.Name == name {
+			report.Corrupt = append(report.Corrupt, c)
+		}
+	}
+	sort.SliceStable(report.Corrupt, func(i, j int) bool {
+		return report.Corrupt[i].Version < report.Corrupt[j].Version
+	})
+	if len(report.Readable) == 0 && len(report.Corrupt) == 0 {
+		return nil, driver.ErrReleaseNotFound
+	}
+
+	if !r.DeleteCorrupt || len(report.Corrupt) == 0 {
+		return report, nil
+	}
+	if r.ConfirmDelete != nil {
+		if err := r.ConfirmDelete(report); err != nil {
+			return report, err
+		}
+	}
+
+	var errs []error
+	for _, c := range report.Corrupt {
+		if err := r.cfg.Releases.DeleteCorrupt(c.Key); err != nil {
+			errs = append(errs, fmt.Errorf("unable to delete release record %q: %w", c.Key, err))
+			continue
+		}
+		report.Deleted = append(report.Deleted, c.Key)
+	}
+	return report, errors.Join(errs...)
+}
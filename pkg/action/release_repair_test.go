@@ -0,0 +1,140 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// secretsConfigFixture returns a configuration storing the releases in
+// Secrets, along with the interface to the Secrets.
+func secretsConfigFixture(t *testing.T) (*Configuration, corev1.SecretInterface) {
+	t.Helper()
+	config := actionConfigFixture(t)
+	secrets := fake.NewClientset().CoreV1().Secrets("default")
+	config.Releases = storage.Init(driver.NewSecrets(secrets))
+	return config, secrets
+}
+
+// createRevisions stores the revisions of the release name, 1 to count.
+func createRevisions(t *testing.T, store *storage.Storage, name string, count int) {
+	t.Helper()
+	for v := 1; v <= count; v++ {
+		rel := releaseStub()
+		rel.Name = name
+		rel.Namespace = "default"
+		rel.Version = v
+		require.NoError(t, store.Create(rel))
+	}
+}
+
+// corruptRevision truncates the record of a revision of the release name.
+func corruptRevision(t *testing.T, secrets corev1.SecretInterface, name string, version int) string {
+	t.Helper()
+	key := fmt.Sprintf("%s.%s.v%d", storage.HelmStorageType, name, version)
+	obj, err := secrets.Get(t.Context(), key, metav1.GetOptions{})
+	require.NoError(t, err)
+	obj.Data["release"] = obj.Data["release"][:12]
+	_, err = secrets.Update(t.Context(), obj, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	return key
+}
+
+func TestReleaseRepair(t *testing.T) {
+	config, secrets := secretsConfigFixture(t)
+	createRevisions(t, config.Releases, "angry-bird", 3)
+	createRevisions(t, config.Releases, "smug-pigeon", 1)
+	key := corruptRevision(t, secrets, "angry-bird", 2)
+	corruptRevision(t, secrets, "smug-pigeon", 1)
+
+	client := NewReleaseRepair(config)
+	report, err := client.Run("angry-bird")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, report.Readable)
+	require.Len(t, report.Corrupt, 1)
+	assert.Equal(t, key, report.Corrupt[0].Key)
+	assert.Equal(t, 2, report.Corrupt[0].Version)
+	assert.Empty(t, report.Deleted, "nothing is deleted without DeleteCorrupt")
+
+	_, err = secrets.Get(t.Context(), key, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestReleaseRepair_DeleteCorrupt(t *testing.T) {
+	config, secrets := secretsConfigFixture(t)
+	createRevisions(t, config.Releases, "angry-bird", 3)
+	key := corruptRevision(t, secrets, "angry-bird", 2)
+
+	client := NewReleaseRepair(config)
+	client.DeleteCorrupt = true
+	var confirmed *ReleaseRepairReport
+	client.ConfirmDelete = func(report *ReleaseRepairReport) error {
+		confirmed = report
+		return nil
+	}
+	report, err := client.Run("angry-bird")
+	require.NoError(t, err)
+	assert.Same(t, report, confirmed)
+	assert.Equal(t, []string{key}, report.Deleted)
+
+	history, err := config.Releases.History("angry-bird")
+	require.NoError(t, err)
+	assert.Len(t, history, 2, "the readable revisions are kept")
+	_, corrupt, err := config.Releases.ListWithCorrupt(func(*release.Release) bool { return true })
+	require.NoError(t, err)
+	assert.Empty(t, corrupt)
+}
+
+func TestReleaseRepair_DeleteNotConfirmed(t *testing.T) {
+	config, secrets := secretsConfigFixture(t)
+	createRevisions(t, config.Releases, "angry-bird", 2)
+	key := corruptRevision(t, secrets, "angry-bird", 2)
+
+	client := NewReleaseRepair(config)
+	client.DeleteCorrupt = true
+	client.ConfirmDelete = func(*ReleaseRepairReport) error {
+		return errors.New("not confirmed")
+	}
+	report, err := client.Run("angry-bird")
+	assert.EqualError(t, err, "not confirmed")
+	assert.Empty(t, report.Deleted)
+
+	_, err = secrets.Get(t.Context(), key, metav1.GetOptions{})
+	assert.NoError(t, err, "the record is kept")
+}
+
+func TestReleaseRepair_NotFound(t *testing.T) {
+	config, _ := secretsConfigFixture(t)
+
+	_, err := NewReleaseRepair(config).Run("angry-bird")
+	assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+
+	_, err = NewReleaseRepair(config).Run("Invalid_Name")
+	assert.ErrorContains(t, err, "release name is invalid")
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"slices"
 	"sort"
 	"time"
@@ -29,6 +32,7 @@ import (
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/kube"
 	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
 )
 
 const (
@@ -46,6 +50,10 @@ type ReleaseTesting struct {
 	Namespace string
 	Filters   map[string][]string
 	HideNotes bool
+	// StreamLogs writes the logs of the test pods to LogOutput while the
+	// tests run, each line prefixed with the name of its pod.
+	StreamLogs bool
+	LogOutput  io.Writer
 }
 
 // NewReleaseTesting creates a new ReleaseTesting object with the given configuration.
@@ -76,7 +84,7 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 	executingHooks := []*release.Hook{}
 	if len(r.Filters[ExcludeNameFilter]) != 0 {
 		for _, h := range rel.Hooks {
-			if slices.Contains(r.Filters[ExcludeNameFilter], h.Name) {
+			if slices.Contains(r.Filters[ExcludeNameFilter], h.Name) && !isTestFixture(h) {
 				skippedHooks = append(skippedHooks, h)
 			} else {
 				executingHooks = append(executingHooks, h)
@@ -87,7 +95,7 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 	if len(r.Filters[IncludeNameFilter]) != 0 {
 		executingHooks = nil
 		for _, h := range rel.Hooks {
-			if slices.Contains(r.Filters[IncludeNameFilter], h.Name) {
+			if slices.Contains(r.Filters[IncludeNameFilter], h.Name) || isTestFixture(h) {
 				executingHooks = append(executingHooks, h)
 			} else {
 				skippedHooks = append(skippedHooks, h)
@@ -96,7 +104,25 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 		rel.Hooks = executingHooks
 	}
 
-	if err := r.cfg.execHook(rel, release.HookTest, kube.StatusWatcherStrategy, r.Timeout); err != nil {
+	var onHook func(*release.Hook)
+	if r.StreamLogs {
+		if client, err := r.cfg.KubernetesClientSet(); err != nil {
+			r.cfg.log().Warn("unable to get kubernetes client to stream pod logs", slog.Any("error", err))
+		} else {
+			logs := newPodLogStreamer(r.cfg.log(), client, r.Namespace, r.LogOutput)
+			defer logs.wait()
+			onHook = logs.follow
+		}
+	}
+
+	fixtures, err := r.createTestFixtures(rel)
+	if err == nil {
+		err = r.cfg.execHookWithProgress(rel, release.HookTest, kube.StatusWatcherStrategy, r.Timeout, onHook, nil)
+	}
+	if cleanupErr := r.deleteTestFixtures(fixtures, err == nil); cleanupErr != nil {
+		err = errors.Join(err, cleanupErr)
+	}
+	if err != nil {
 		rel.Hooks = append(skippedHooks, rel.Hooks...)
 		r.cfg.Releases.Update(rel)
 		return rel, err
@@ -106,6 +132,85 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 	return rel, r.cfg.Releases.Update(rel)
 }
 
+// isTestFixture reports whether h is a test fixture, created for the tests of
+// the release only.
+func isTestFixture(h *release.Hook) bool {
+	return slices.Contains(h.Events, release.HookTestFixture)
+}
+
+// createTestFixtures creates the test fixtures of rel, in the order hooks are
+// executed, and waits for them to be ready. It returns the fixtures created,
+// including the one whose creation failed, if any, which aborts the tests.
+func (r *ReleaseTesting) createTestFixtures(rel *release.Release) ([]*release.Hook, error) {
+	var fixtures []*release.Hook
+	for _, h := range rel.Hooks {
+		if isTestFixture(h) {
+			fixtures = append(fixtures, h)
+		}
+	}
+	sort.Stable(hookByWeight(fixtures))
+
+	for i, h := range fixtures {
+		if err := r.createTestFixture(rel, h); err != nil {
+			h.LastRun.CompletedAt = helmtime.Now()
+			h.LastRun.Phase = release.HookPhaseFailed
+			return fixtures[:i+1], fmt.Errorf("test fixture %s/%s (%s) failed: %w", h.Kind, h.Name, h.Path, err)
+		}
+		h.LastRun.CompletedAt = helmtime.Now()
+		h.LastRun.Phase = release.HookPhaseSucceeded
+	}
+	return fixtures, nil
+}
+
+func (r *ReleaseTesting) createTestFixture(rel *release.Release, h *release.Hook) error {
+	r.cfg.hookSetDeletePolicy(h)
+	if err := r.cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation, kube.StatusWatcherStrategy, r.Timeout); err != nil {
+		return err
+	}
+
+	resources, err := r.cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
+	if err != nil {
+		return fmt.Errorf("unable to build kubernetes object: %w", err)
+	}
+	h.LastRun = release.HookExecution{
+		StartedAt: helmtime.Now(),
+		Phase:     release.HookPhaseRunning,
+	}
+	r.cfg.recordRelease(rel)
+
+	if _, err := r.cfg.KubeClient.Create(resources); err != nil {
+		return err
+	}
+	waiter, err := r.cfg.KubeClient.GetWaiter(kube.StatusWatcherStrategy)
+	if err != nil {
+		return fmt.Errorf("unable to get waiter: %w", err)
+	}
+	return waiter.Wait(resources, r.Timeout)
+}
+
+// deleteTestFixtures deletes the test fixtures once the tests completed, in
+// the reverse order of their creation. Fixtures are deleted whatever the
+// outcome of the tests, unless their delete policy asks for them to be deleted
+// only when the tests succeeded, or only when they failed.
+func (r *ReleaseTesting) deleteTestFixtures(fixtures []*release.Hook, succeeded bool) error {
+	var errs []error
+	for i := len(fixtures) - 1; i >= 0; i-- {
+		h := fixtures[i]
+		policy := release.HookFailed
+		if succeeded {
+			policy = release.HookSucceeded
+		}
+		restricted := r.cfg.hookHasDeletePolicy(h, release.HookSucceeded) || r.cfg.hookHasDeletePolicy(h, release.HookFailed)
+		if restricted && !r.cfg.hookHasDeletePolicy(h, policy) {
+			continue
+		}
+		if err := r.cfg.deleteHook(h, kube.StatusWatcherStrategy, r.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("unable to delete test fixture %s/%s: %w", h.Kind, h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // GetPodLogs will write the logs for all test pods in the given release into
 // the given writer. These can be immediately output to the user or captured for
 // other uses
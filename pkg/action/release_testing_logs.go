@@ -0,0 +1,201 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// streamLogsGrace is how long the log streams of the test pods are still read
+// once the tests are complete, for their last lines to be written.
+var streamLogsGrace = 5 * time.Second
+
+// podLogs opens the stream following the logs of a pod. The logs of the fake
+// clientset being always the same, the tests replace it.
+var podLogs = func(ctx context.Context, pods corev1client.PodInterface, name string) (io.ReadCloser, error) {
+	return pods.GetLogs(name, &v1.PodLogOptions{Follow: true}).Stream(ctx)
+}
+
+// podLogStreamer writes the logs of test pods while they run, each line
+// prefixed with the name of its pod. The errors of the streams are only
+// logged: they never fail the tests, whose completion is known from the hooks.
+type podLogStreamer struct {
+	pods corev1client.PodInterface
+	out  io.Writer
+	log  *slog.Logger
+	// mu serializes the lines written to out by the streams of the pods.
+	mu sync.Mutex
+	wg sync.WaitGroup
+
+	// watchCtx is canceled when the tests are complete, to stop waiting for
+	// the pods which did not start; streamCtx is canceled after
+	// streamLogsGrace, to stop reading the logs.
+	watchCtx, streamCtx       context.Context
+	stopWatching, stopStreams context.CancelFunc
+}
+
+func newPodLogStreamer(log *slog.Logger, client kubernetes.Interface, namespace string, out io.Writer) *podLogStreamer {
+	if out == nil {
+		out = io.Discard
+	}
+	s := &podLogStreamer{pods: client.CoreV1().Pods(namespace), out: out, log: log}
+	s.streamCtx, s.stopStreams = context.WithCancel(context.Background())
+	s.watchCtx, s.stopWatching = context.WithCancel(s.streamCtx)
+	return s
+}
+
+// follow starts streaming the logs of the pod of the hook h as soon as its
+// containers start. It is called right before the hook is created, when a
+// pod left by a previous run may still exist: its logs are not streamed.
+func (s *podLogStreamer) follow(h *release.Hook) {
+	if h.Kind != "Pod" {
+		return
+	}
+	var stale types.UID
+	if pod, err := s.pods.Get(s.watchCtx, h.Name, metav1.GetOptions{}); err == nil {
+		stale = pod.UID
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.stream(h.Name, stale); err != nil {
+			s.log.Warn("unable to stream the logs of a test pod", "pod", h.Name, slog.Any("error", err))
+		}
+	}()
+}
+
+// wait waits for the streams to end, once the tests are complete.
+func (s *podLogStreamer) wait() {
+	s.stopWatching()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(streamLogsGrace):
+		s.stopStreams()
+		<-done
+	}
+	s.stopStreams()
+}
+
+func (s *podLogStreamer) stream(name string, stale types.UID) error {
+	if err := s.waitForStart(name, stale); err != nil {
+		if errors.Is(err, context.Canceled) {
+			// The tests completed before the pod started.
+			return nil
+		}
+		return err
+	}
+
+	logs, err := podLogs(s.streamCtx, s.pods, name)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	r := bufio.NewReader(logs)
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			if line[len(line)-1] != '\n' {
+				line += "\n"
+			}
+			s.mu.Lock()
+			_, _ = fmt.Fprintf(s.out, "[%s] %s", name, line)
+			s.mu.Unlock()
+		}
+		if err == io.EOF || s.streamCtx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// waitForStart waits for a container of the pod, other than the stale one, to
+// start.
+func (s *podLogStreamer) waitForStart(name string, stale types.UID) error {
+	w, err := s.pods.Watch(s.watchCtx, metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	// The pod may have started before the watch.
+	if pod, err := s.pods.Get(s.watchCtx, name, metav1.GetOptions{}); err == nil && pod.UID != stale && podStarted(pod) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-s.watchCtx.Done():
+			// The tests may have completed before the watch reported the
+			// start of the pod.
+			if pod, err := s.pods.Get(s.streamCtx, name, metav1.GetOptions{}); err == nil && pod.UID != stale && podStarted(pod) {
+				return nil
+			}
+			return s.watchCtx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch of pod %s closed", name)
+			}
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok || event.Type == watch.Deleted || pod.Name != name || pod.UID == stale {
+				continue
+			}
+			if podStarted(pod) {
+				return nil
+			}
+		}
+	}
+}
+
+// podStarted reports whether a container of the pod has started, when its logs
+// can be read.
+func podStarted(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return true
+	}
+	for _, c := range pod.Status.ContainerStatuses {
+		if c.State.Running != nil || c.State.Terminated != nil {
+			return true
+		}
+	}
+	return false
+}
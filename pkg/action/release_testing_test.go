@@ -0,0 +1,306 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func testPod(name string, uid types.UID, phase v1.PodPhase) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "spaced", UID: uid},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+	if phase == v1.PodRunning {
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "test", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}
+	}
+	return pod
+}
+
+func TestPodLogStreamer(t *testing.T) {
+	// A pod left by a previous run, whose logs must not be streamed.
+	client := fake.NewClientset(testPod("test-a", "stale", v1.PodSucceeded))
+
+	logs := map[types.UID]string{
+		"stale": "stale\n",
+		"a":     "one\ntwo\nthree",
+		"b":     "alpha\nbeta\n",
+	}
+	defer func(f func(context.Context, corev1client.PodInterface, string) (io.ReadCloser, error)) { podLogs = f }(podLogs)
+	podLogs = func(ctx context.Context, pods corev1client.PodInterface, name string) (io.ReadCloser, error) {
+		if name == "test-broken" {
+			return nil, errors.New("connection reset")
+		}
+		pod, err := pods.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(strings.NewReader(logs[pod.UID])), nil
+	}
+
+	var out bytes.Buffer
+	s := newPodLogStreamer(slog.Default(), client, "spaced", &out)
+	for _, h := range []*release.Hook{
+		{Name: "test-a", Kind: "Pod"},
+		{Name: "test-b", Kind: "Pod"},
+		{Name: "test-broken", Kind: "Pod"},
+		{Name: "test-never-started", Kind: "Pod"},
+		{Name: "test-config", Kind: "ConfigMap"},
+	} {
+		s.follow(h)
+	}
+
+	pods := client.CoreV1().Pods("spaced")
+	ctx := context.Background()
+	_, err := pods.Create(ctx, testPod("test-b", "b", v1.PodPending), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = pods.Update(ctx, testPod("test-b", "b", v1.PodRunning), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, pods.Delete(ctx, "test-a", metav1.DeleteOptions{}))
+	_, err = pods.Create(ctx, testPod("test-a", "a", v1.PodRunning), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = pods.Create(ctx, testPod("test-broken", "broken", v1.PodRunning), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = pods.Create(ctx, testPod("test-never-started", "pending", v1.PodPending), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Wait for the streams of the started pods before completing the tests.
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return strings.Count(out.String(), "\n") == 5
+	}, 10*time.Second, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the streams did not stop once the tests completed")
+	}
+
+	byPod := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		pod, text, ok := strings.Cut(strings.TrimPrefix(line, "["), "] ")
+		require.True(t, ok, "line %q is not prefixed with its pod", line)
+		byPod[pod] = append(byPod[pod], text)
+	}
+	assert.Equal(t, map[string][]string{
+		"test-a": {"one", "two", "three"},
+		"test-b": {"alpha", "beta"},
+	}, byPod)
+}
+
+func TestPodLogStreamerGrace(t *testing.T) {
+	client := fake.NewClientset()
+
+	defer func(d time.Duration) { streamLogsGrace = d }(streamLogsGrace)
+	streamLogsGrace = 10 * time.Millisecond
+	defer func(f func(context.Context, corev1client.PodInterface, string) (io.ReadCloser, error)) { podLogs = f }(podLogs)
+	podLogs = func(ctx context.Context, _ corev1client.PodInterface, _ string) (io.ReadCloser, error) {
+		// A stream which never ends, until canceled.
+		r, w := io.Pipe()
+		go func() {
+			_, _ = io.WriteString(w, "started\n")
+			<-ctx.Done()
+			w.CloseWithError(ctx.Err())
+		}()
+		return r, nil
+	}
+
+	var out bytes.Buffer
+	s := newPodLogStreamer(slog.Default(), client, "spaced", &out)
+	s.follow(&release.Hook{Name: "test-slow", Kind: "Pod"})
+	_, err := client.CoreV1().Pods("spaced").Create(context.Background(), testPod("test-slow", "slow", v1.PodRunning), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return out.Len() > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	s.wait()
+	assert.Equal(t, "[test-slow] started\n", out.String())
+}
+
+func configMapHook(name string, event release.HookEvent, weight int, policies ...release.HookDeletePolicy) *release.Hook {
+	return &release.Hook{
+		Name:           name,
+		Kind:           "ConfigMap",
+		Path:           "templates/" + name + ".yaml",
+		Manifest:       fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n  namespace: test\n", name),
+		Events:         []release.HookEvent{event},
+		Weight:         weight,
+		DeletePolicies: policies,
+	}
+}
+
+func TestReleaseTestingFixtures(t *testing.T) {
+	testCases := []struct {
+		name          string
+		hooks         []*release.Hook
+		failOn        string
+		filters       map[string][]string
+		expectErr     string
+		expectDeleted []string
+		expectPhases  map[string]release.HookPhase
+	}{
+		{
+			name: "fixtures are deleted once the tests succeeded",
+			hooks: []*release.Hook{
+				configMapHook("test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("credentials", release.HookTestFixture, 1),
+				configMapHook("settings", release.HookTestFixture, 0),
+			},
+			expectDeleted: []string{"test", "credentials", "settings"},
+			expectPhases: map[string]release.HookPhase{
+				"test":        release.HookPhaseSucceeded,
+				"credentials": release.HookPhaseSucceeded,
+				"settings":    release.HookPhaseSucceeded,
+			},
+		},
+		{
+			name: "fixtures are deleted once the tests failed",
+			hooks: []*release.Hook{
+				configMapHook("test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("credentials", release.HookTestFixture, 0),
+			},
+			failOn:        "test",
+			expectErr:     "Hook failed!",
+			expectDeleted: []string{"credentials"},
+			expectPhases: map[string]release.HookPhase{
+				"test":        release.HookPhaseFailed,
+				"credentials": release.HookPhaseSucceeded,
+			},
+		},
+		{
+			name: "delete policies of the fixtures are honored",
+			hooks: []*release.Hook{
+				configMapHook("test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("kept", release.HookTestFixture, 0, release.HookSucceeded),
+				configMapHook("deleted", release.HookTestFixture, 1, release.HookFailed),
+			},
+			failOn:        "test",
+			expectErr:     "Hook failed!",
+			expectDeleted: []string{"deleted"},
+		},
+		{
+			name: "a fixture which cannot be created aborts the tests",
+			hooks: []*release.Hook{
+				configMapHook("test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("credentials", release.HookTestFixture, 0),
+				{
+					Name:     "broken",
+					Kind:     "ConfigMap",
+					Path:     "templates/broken.yaml",
+					Manifest: "{not yaml",
+					Events:   []release.HookEvent{release.HookTestFixture},
+					Weight:   1,
+				},
+			},
+			expectErr:     "test fixture ConfigMap/broken (templates/broken.yaml) failed",
+			expectDeleted: []string{"credentials"},
+			expectPhases: map[string]release.HookPhase{
+				"credentials": release.HookPhaseSucceeded,
+				"broken":      release.HookPhaseFailed,
+			},
+		},
+		{
+			name: "fixtures are not filtered out",
+			hooks: []*release.Hook{
+				configMapHook("test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("other-test", release.HookTest, 0, release.HookSucceeded),
+				configMapHook("credentials", release.HookTestFixture, 0),
+			},
+			filters:       map[string][]string{IncludeNameFilter: {"test"}},
+			expectDeleted: []string{"test", "credentials"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := &HookFailingKubeClient{
+				kubefake.PrintingKubeClient{Out: io.Discard}, resource.Info{Name: tc.failOn, Namespace: "test"}, []resource.Info{},
+			}
+			config := actionConfigFixture(t)
+			config.KubeClient = kubeClient
+			rel := &release.Release{
+				Name:      "fixtures",
+				Namespace: "test",
+				Version:   1,
+				Chart:     buildChart(),
+				Info:      &release.Info{Status: release.StatusDeployed},
+				Hooks:     tc.hooks,
+			}
+			require.NoError(t, config.Releases.Create(rel))
+
+			// Hooks without delete policies are deleted before they are
+			// created; only the deletions after the tests are checked.
+			for _, h := range tc.hooks {
+				if len(h.DeletePolicies) == 0 {
+					h.DeletePolicies = []release.HookDeletePolicy{release.HookSucceeded, release.HookFailed}
+				}
+			}
+
+			client := NewReleaseTesting(config)
+			if tc.filters != nil {
+				client.Filters = tc.filters
+			}
+			res, err := client.Run(rel.Name)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var deleted []string
+			for _, info := range kubeClient.deleteRecord {
+				deleted = append(deleted, info.Name)
+			}
+			assert.Equal(t, tc.expectDeleted, deleted)
+
+			for _, h := range res.Hooks {
+				if phase, ok := tc.expectPhases[h.Name]; ok {
+					assert.Equal(t, phase, h.LastRun.Phase, "phase of %s", h.Name)
+				}
+			}
+		})
+	}
+}
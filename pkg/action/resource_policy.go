@@ -19,6 +19,9 @@ package action
 import (
 	"strings"
 
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
 	"helm.sh/helm/v4/pkg/kube"
 	releaseutil "helm.sh/helm/v4/pkg/release/util"
 )
@@ -44,3 +47,54 @@ func filterManifestsToKeep(manifests []releaseutil.Manifest) (keep, remaining []
 	}
 	return keep, remaining
 }
+
+// filterManifestsToRetain splits the manifests between those of the kinds or
+// matching the label selector, which are kept, and the others. A nil selector
+// matches no manifest.
+func filterManifestsToRetain(manifests []releaseutil.Manifest, selector labels.Selector, kinds []string) (keep, remaining []releaseutil.Manifest) {
+	for _, m := range manifests {
+		if isKindKept(m.Head.Kind, kinds) || (selector != nil && selector.Matches(manifestLabels(m))) {
+			keep = append(keep, m)
+			continue
+		}
+		remaining = append(remaining, m)
+	}
+	return keep, remaining
+}
+
+func isKindKept(kind string, kinds []string) bool {
+	for _, k := range kinds {
+		if strings.EqualFold(strings.TrimSpace(k), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestLabels returns the labels of the resource of the manifest, which
+// are not part of its head.
+func manifestLabels(m releaseutil.Manifest) labels.Set {
+	var obj struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(m.Content), &obj); err != nil {
+		return nil
+	}
+	return obj.Metadata.Labels
+}
+
+// manifestNamespace returns the namespace set in the manifest, which is not
+// part of its head.
+func manifestNamespace(m releaseutil.Manifest) string {
+	var obj struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(m.Content), &obj); err != nil {
+		return ""
+	}
+	return obj.Metadata.Namespace
+}
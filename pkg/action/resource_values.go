@@ -0,0 +1,73 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	clivalues "helm.sh/helm/v4/pkg/cli/values"
+)
+
+// ResourceValuesReader returns a clivalues.ResourceReader reading values from the
+// ConfigMaps and Secrets of the cluster. References without a namespace are
+// resolved in namespace, the namespace of the release.
+func (cfg *Configuration) ResourceValuesReader(namespace string) clivalues.ResourceReader {
+	return func(ref clivalues.ResourceRef) ([]byte, error) {
+		clientset, err := cfg.KubernetesClientSet()
+		if err != nil {
+			return nil, err
+		}
+		return readResourceValue(clientset, namespace, ref)
+	}
+}
+
+// readResourceValue returns the value of the key referenced by ref.
+func readResourceValue(clientset kubernetes.Interface, namespace string, ref clivalues.ResourceRef) ([]byte, error) {
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	ctx := context.Background()
+
+	switch ref.Kind {
+	case clivalues.ResourceKindConfigMap:
+		cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := cm.Data[ref.Key]; ok {
+			return []byte(value), nil
+		}
+		if value, ok := cm.BinaryData[ref.Key]; ok {
+			return value, nil
+		}
+	case clivalues.ResourceKindSecret:
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := secret.Data[ref.Key]; ok {
+			return value, nil
+		}
+	default:
+		return nil, fmt.Errorf("values cannot be read from a %s", ref.Kind)
+	}
+	return nil, fmt.Errorf("%s %q in namespace %q has no key %q", ref.Kind, ref.Name, namespace, ref.Key)
+}
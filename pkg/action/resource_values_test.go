@@ -0,0 +1,96 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	clivalues "helm.sh/helm/v4/pkg/cli/values"
+)
+
+func TestReadResourceValue(t *testing.T) {
+	clientset := fake.NewClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "spaced"},
+			Data:       map[string]string{"values.yaml": "replicas: 2\n"},
+			BinaryData: map[string][]byte{"binary.yaml": []byte("replicas: 3\n")},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "shared"},
+			Data:       map[string]string{"values.yaml": "replicas: 4\n"},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "spaced"},
+			Data:       map[string][]byte{"values.yaml": []byte("password: s3cr3t\n")},
+		},
+	)
+
+	tests := []struct {
+		name    string
+		ref     clivalues.ResourceRef
+		expect  string
+		wantErr string
+	}{
+		{
+			name:   "configmap data",
+			ref:    clivalues.ResourceRef{Kind: clivalues.ResourceKindConfigMap, Name: "env", Key: "values.yaml"},
+			expect: "replicas: 2\n",
+		},
+		{
+			name:   "configmap binary data",
+			ref:    clivalues.ResourceRef{Kind: clivalues.ResourceKindConfigMap, Name: "env", Key: "binary.yaml"},
+			expect: "replicas: 3\n",
+		},
+		{
+			name:   "configmap in another namespace",
+			ref:    clivalues.ResourceRef{Kind: clivalues.ResourceKindConfigMap, Namespace: "shared", Name: "env", Key: "values.yaml"},
+			expect: "replicas: 4\n",
+		},
+		{
+			name:   "secret data",
+			ref:    clivalues.ResourceRef{Kind: clivalues.ResourceKindSecret, Name: "credentials", Key: "values.yaml"},
+			expect: "password: s3cr3t\n",
+		},
+		{
+			name:    "missing key",
+			ref:     clivalues.ResourceRef{Kind: clivalues.ResourceKindSecret, Name: "credentials", Key: "missing.yaml"},
+			wantErr: `secret "credentials" in namespace "spaced" has no key "missing.yaml"`,
+		},
+		{
+			name:    "missing resource",
+			ref:     clivalues.ResourceRef{Kind: clivalues.ResourceKindConfigMap, Name: "missing", Key: "values.yaml"},
+			wantErr: `configmaps "missing" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := readResourceValue(clientset, "spaced", tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, string(value))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// restConfigGetter is a genericclioptions.RESTClientGetter returning a
+// rest.Config it was given, instead of loading one from kubeconfig files.
+type restConfigGetter struct {
+	config    *rest.Config
+	namespace string
+
+	initDiscovery sync.Once
+	discovery     discovery.CachedDiscoveryInterface
+	discoveryErr  error
+}
+
+var _ genericclioptions.RESTClientGetter = (*restConfigGetter)(nil)
+
+func newRESTConfigGetter(config *rest.Config, namespace string) *restConfigGetter {
+	return &restConfigGetter{
+		config:    rest.CopyConfig(config),
+		namespace: namespace,
+	}
+}
+
+// ToRESTConfig returns a copy of the rest config, which callers may modify.
+func (g *restConfigGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.config), nil
+}
+
+// ToDiscoveryClient returns a discovery client for the rest config, caching
+// the discovery information in memory.
+func (g *restConfigGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	g.initDiscovery.Do(func() {
+		config := rest.CopyConfig(g.config)
+		// The discovery of the API groups makes many requests at once, raise
+		// the burst as the kubeconfig loader of kubectl does.
+		config.Burst = 300
+		dc, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			g.discoveryErr = err
+			return
+		}
+		g.discovery = memory.NewMemCacheClient(dc)
+	})
+	return g.discovery, g.discoveryErr
+}
+
+// ToRESTMapper returns a REST mapper lazily built from the discovery
+// information.
+func (g *restConfigGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	dc, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(dc)
+	return restmapper.NewShortcutExpander(mapper, dc, nil), nil
+}
+
+// ToRawKubeConfigLoader returns a client config returning the rest config and
+// the namespace, which reads no kubeconfig file.
+func (g *restConfigGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return restClientConfig{getter: g}
+}
+
+// restClientConfig is the clientcmd.ClientConfig of a restConfigGetter.
+type restClientConfig struct {
+	getter *restConfigGetter
+}
+
+var _ clientcmd.ClientConfig = restClientConfig{}
+
+// RawConfig returns an empty kubeconfig, as there is none.
+func (c restClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return *clientcmdapi.NewConfig(), nil
+}
+
+func (c restClientConfig) ClientConfig() (*rest.Config, error) {
+	return c.getter.ToRESTConfig()
+}
+
+// Namespace returns the namespace the configuration was initialized with, or
+// the default namespace.
+func (c restClientConfig) Namespace() (string, bool, error) {
+	if c.getter.namespace == "" {
+		return v1.NamespaceDefault, false, nil
+	}
+	return c.getter.namespace, true, nil
+}
+
+// ConfigAccess returns loading rules without any file to load.
+func (c restClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	return &clientcmd.ClientConfigLoadingRules{}
+}
@@ -18,8 +18,8 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"log/slog"
 	"strings"
 	"time"
 
@@ -44,6 +44,20 @@ type Rollback struct {
 	Force         bool // will (if true) force resource upgrade through uninstall/recreate if needed
 	CleanupOnFail bool
 	MaxHistory    int // MaxHistory limits the maximum number of revisions saved per release
+	// Atomic, if true, will roll forward to the previously deployed revision
+	// if the rollback fails.
+	Atomic bool
+	// ValuesOnly rolls back only the values: the currently deployed chart is
+	// rendered again with the values of the target revision.
+	ValuesOnly bool
+	// PolicyOverrides are the tokens given to approve the rollback, or to
+	// override the policy of the release.
+	PolicyOverrides []string
+	// OverridePin allows the rollback of a pinned release.
+	OverridePin bool
+	// SkipHookEvents skips the hooks of these events, which are still
+	// recorded on the release.
+	SkipHookEvents []release.HookEvent
 }
 
 // NewRollback creates a new Rollback object with the given configuration.
@@ -55,37 +69,65 @@ func NewRollback(cfg *Configuration) *Rollback {
 
 // Run executes 'helm rollback' against the given release.
 func (r *Rollback) Run(name string) error {
-	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+	startedAt := time.Now()
+	rel, err := r.run(name)
+	if r.DryRun {
 		return err
 	}
+	event := newReleaseEvent(OperationRollback, name, "", rel, nil, err, startedAt)
+	if rerr := r.cfg.notifyRecorder(context.Background(), event); rerr != nil && err == nil {
+		return rerr
+	}
+	return err
+}
+
+// run rolls back the release, returning the release created by the rollback
+// once it was prepared.
+func (r *Rollback) run(name string) (*release.Release, error) {
+	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	if err := validateSkipHookEvents(r.SkipHookEvents); err != nil {
+		return nil, err
+	}
+
+	// Make sure if Atomic is set, that wait is set as well. This makes it so
+	// the user doesn't have to specify both
+	if r.WaitStrategy == kube.HookOnlyStrategy && r.Atomic {
+		r.WaitStrategy = kube.StatusWatcherStrategy
+	}
 
 	r.cfg.Releases.MaxHistory = r.MaxHistory
 
-	slog.Debug("preparing rollback", "name", name)
+	r.cfg.log().Debug("preparing rollback", "name", name)
 	currentRelease, targetRelease, err := r.prepareRollback(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !r.DryRun {
-		slog.Debug("creating rolled back release", "name", name)
+		r.cfg.log().Debug("creating rolled back release", "name", name)
 		if err := r.cfg.Releases.Create(targetRelease); err != nil {
-			return err
+			return targetRelease, err
 		}
 	}
 
-	slog.Debug("performing rollback", "name", name)
+	r.cfg.log().Debug("performing rollback", "name", name)
 	if _, err := r.performRollback(currentRelease, targetRelease); err != nil {
-		return err
+		if r.Atomic && !r.DryRun {
+			return targetRelease, r.failRollback(currentRelease, targetRelease, err)
+		}
+		return targetRelease, err
 	}
 
 	if !r.DryRun {
-		slog.Debug("updating status for rolled back release", "name", name)
+		r.cfg.log().Debug("updating status for rolled back release", "name", name)
 		if err := r.cfg.Releases.Update(targetRelease); err != nil {
-			return err
+			return targetRelease, err
 		}
 	}
-	return nil
+	return targetRelease, nil
 }
 
 // prepareRollback finds the previous release and prepares a new release object with
@@ -104,6 +146,13 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 		return nil, nil, err
 	}
 
+	if err := checkPin(currentRelease, r.OverridePin); err != nil {
+		return nil, nil, err
+	}
+	if err := r.cfg.checkPolicy(currentRelease, release.PolicyRollback, r.PolicyOverrides); err != nil {
+		return nil, nil, err
+	}
+
 	previousVersion := r.Version
 	if r.Version == 0 {
 		previousVersion = currentRelease.Version - 1
@@ -127,13 +176,21 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 		return nil, nil, fmt.Errorf("release has no %d version", previousVersion)
 	}
 
-	slog.Debug("rolling back", "name", name, "currentVersion", currentRelease.Version, "targetVersion", previousVersion)
+	r.cfg.log().Debug("rolling back", "name", name, "currentVersion", currentRelease.Version, "targetVersion", previousVersion)
 
 	previousRelease, err := r.cfg.Releases.Get(name, previousVersion)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if r.ValuesOnly {
+		targetRelease, err := r.prepareValuesRollback(currentRelease, previousRelease)
+		if err != nil {
+			return nil, nil, err
+		}
+		return currentRelease, targetRelease, nil
+	}
+
 	// Store a new release object with previous release's configuration
 	targetRelease := &release.Release{
 		Name:      name,
@@ -147,10 +204,16 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 			Notes:         previousRelease.Info.Notes,
 			// Because we lose the reference to previous version elsewhere, we set the
 			// message here, and only override it later if we experience failure.
-			Description: fmt.Sprintf("Rollback to %d", previousVersion),
+			Description:  fmt.Sprintf("Rollback to %d", previousVersion),
+			FieldManager: r.cfg.FieldManager,
+			AppliedBy:    currentOperator(),
 		},
-		Version:  currentRelease.Version + 1,
-		Labels:   previousRelease.Labels,
+		Version:     currentRelease.Version + 1,
+		Labels:      previousRelease.Labels,
+		Annotations: previousRelease.Annotations,
+		// The policy and the pin of the release are not rolled back.
+		Policy:   currentRelease.Policy,
+		Pin:      currentRelease.Pin,
 		Manifest: previousRelease.Manifest,
 		Hooks:    previousRelease.Hooks,
 	}
@@ -158,9 +221,99 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 	return currentRelease, targetRelease, nil
 }
 
+// prepareValuesRollback prepares a new release object that renders the chart of
+// the current release with the values of the previous release.
+func (r *Rollback) prepareValuesRollback(currentRelease, previousRelease *release.Release) (*release.Release, error) {
+	// The storage drivers decode the empty values of a revision installed
+	// without values as nil.
+	config := previousRelease.Config
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	ch := currentRelease.Chart
+	if err := chartutil.ProcessDependencies(ch, config); err != nil {
+		return nil, err
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      currentRelease.Name,
+		Namespace: currentRelease.Namespace,
+		Revision:  currentRelease.Version + 1,
+		IsUpgrade: true,
+	}
+	caps, err := r.cfg.getCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(ch, config, options, caps, false)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, manifestDoc, notesTxt, err := r.cfg.renderResources(ch, valuesToRender, "", "", false, false, false, nil, !r.DryRun, false, false, nil, renderOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &release.Release{
+		Name:      currentRelease.Name,
+		Namespace: currentRelease.Namespace,
+		Chart:     ch,
+		Config:    config,
+		Info: &release.Info{
+			FirstDeployed: currentRelease.Info.FirstDeployed,
+			LastDeployed:  helmtime.Now(),
+			Status:        release.StatusPendingRollback,
+			Notes:         notesTxt,
+			Description:   fmt.Sprintf("Values rollback to revision %d", previousRelease.Version),
+			FieldManager:  r.cfg.FieldManager,
+			AppliedBy:     currentOperator(),
+		},
+		Version:     currentRelease.Version + 1,
+		Labels:      currentRelease.Labels,
+		Annotations: currentRelease.Annotations,
+		Policy:      currentRelease.Policy,
+		Pin:         currentRelease.Pin,
+		Manifest:    manifestDoc.String(),
+		Hooks:       hooks,
+	}, nil
+}
+
+// failRollback marks the rollback as failed and rolls forward to the revision
+// that was deployed before the rollback started.
+func (r *Rollback) failRollback(currentRelease, targetRelease *release.Release, err error) error {
+	if targetRelease.Info.Status != release.StatusFailed {
+		targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Rollback %q failed: %s", targetRelease.Name, err))
+		r.cfg.recordRelease(targetRelease)
+	}
+
+	cleanup := atomicCleanup{
+		operation: "rollback",
+		undoing:   "rolling forward",
+		undone:    "rolled forward",
+		log:       r.cfg.log(),
+		undo: func() error {
+			rollforward := NewRollback(r.cfg)
+			rollforward.Version = currentRelease.Version
+			rollforward.WaitStrategy = r.WaitStrategy
+			rollforward.WaitForJobs = r.WaitForJobs
+			rollforward.DisableHooks = r.DisableHooks
+			rollforward.SkipHookEvents = r.SkipHookEvents
+			rollforward.Force = r.Force
+			rollforward.OverridePin = r.OverridePin
+			rollforward.PolicyOverrides = r.PolicyOverrides
+			rollforward.Timeout = r.Timeout
+			rollforward.MaxHistory = r.MaxHistory
+			return rollforward.Run(targetRelease.Name)
+		},
+	}
+	return cleanup.run(targetRelease.Name, err)
+}
+
 func (r *Rollback) performRollback(currentRelease, targetRelease *release.Release) (*release.Release, error) {
 	if r.DryRun {
-		slog.Debug("dry run", "name", targetRelease.Name)
+		r.cfg.log().Debug("dry run", "name", targetRelease.Name)
 		return targetRelease, nil
 	}
 
@@ -174,12 +327,12 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 	}
 
 	// pre-rollback hooks
-	if !r.DisableHooks {
+	if r.cfg.runsHooks(release.HookPreRollback, r.DisableHooks, r.SkipHookEvents) {
 		if err := r.cfg.execHook(targetRelease, release.HookPreRollback, r.WaitStrategy, r.Timeout); err != nil {
 			return targetRelease, err
 		}
 	} else {
-		slog.Debug("rollback hooks disabled", "name", targetRelease.Name)
+		r.cfg.log().Debug("rollback hooks disabled", "name", targetRelease.Name)
 	}
 
 	// It is safe to use "force" here because these are resources currently rendered by the chart.
@@ -191,21 +344,21 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	if err != nil {
 		msg := fmt.Sprintf("Rollback %q failed: %s", targetRelease.Name, err)
-		slog.Warn(msg)
+		r.cfg.log().Warn(msg)
 		currentRelease.Info.Status = release.StatusSuperseded
 		targetRelease.Info.Status = release.StatusFailed
 		targetRelease.Info.Description = msg
 		r.cfg.recordRelease(currentRelease)
 		r.cfg.recordRelease(targetRelease)
 		if r.CleanupOnFail {
-			slog.Debug("cleanup on fail set, cleaning up resources", "count", len(results.Created))
+			r.cfg.log().Debug("cleanup on fail set, cleaning up resources", "count", len(results.Created))
 			_, errs := r.cfg.KubeClient.Delete(results.Created)
 			if errs != nil {
 				return targetRelease, fmt.Errorf(
 					"an error occurred while cleaning up resources. original rollback error: %w",
 					fmt.Errorf("unable to cleanup resources: %w", joinErrors(errs, ", ")))
 			}
-			slog.Debug("resource cleanup complete")
+			r.cfg.log().Debug("resource cleanup complete")
 		}
 		return targetRelease, err
 	}
@@ -231,7 +384,7 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 	}
 
 	// post-rollback hooks
-	if !r.DisableHooks {
+	if r.cfg.runsHooks(release.HookPostRollback, r.DisableHooks, r.SkipHookEvents) {
 		if err := r.cfg.execHook(targetRelease, release.HookPostRollback, r.WaitStrategy, r.Timeout); err != nil {
 			return targetRelease, err
 		}
@@ -243,7 +396,7 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 	}
 	// Supersede all previous deployments, see issue #2941.
 	for _, rel := range deployed {
-		slog.Debug("superseding previous deployment", "version", rel.Version)
+		r.cfg.log().Debug("superseding previous deployment", "version", rel.Version)
 		rel.Info.Status = release.StatusSuperseded
 		r.cfg.recordRelease(rel)
 	}
@@ -0,0 +1,187 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func rollbackValuesChart(version string) *chart.Chart {
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/configmap", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\ndata:\n  chart: " + version + "\n")},
+	})
+	ch.Metadata.Version = version
+	return ch
+}
+
+func rollbackValuesFixture(t *testing.T) (*Rollback, *chart.Chart) {
+	t.Helper()
+	config := actionConfigFixture(t)
+
+	rel1 := releaseStub()
+	rel1.Name = "values-rollback"
+	rel1.Version = 1
+	rel1.Info.Status = release.StatusSuperseded
+	rel1.Chart = rollbackValuesChart("0.1.0")
+	rel1.Config = map[string]interface{}{"name": "old"}
+	require.NoError(t, config.Releases.Create(rel1))
+
+	current := rollbackValuesChart("0.2.0")
+	rel2 := releaseStub()
+	rel2.Name = "values-rollback"
+	rel2.Version = 2
+	rel2.Chart = current
+	rel2.Config = map[string]interface{}{"name": "new"}
+	require.NoError(t, config.Releases.Create(rel2))
+
+	rb := NewRollback(config)
+	rb.Version = 1
+	rb.ValuesOnly = true
+	return rb, current
+}
+
+func TestRollbackValuesOnly(t *testing.T) {
+	rb, current := rollbackValuesFixture(t)
+
+	require.NoError(t, rb.Run("values-rollback"))
+
+	rel, err := rb.cfg.Releases.Get("values-rollback", 3)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	assert.Equal(t, "Values rollback to revision 1", rel.Info.Description)
+	assert.Empty(t, rel.Info.FieldManager)
+	assert.Equal(t, current.Metadata.Version, rel.Chart.Metadata.Version)
+	assert.Equal(t, map[string]interface{}{"name": "old"}, rel.Config)
+	assert.Contains(t, rel.Manifest, "name: old")
+	assert.Contains(t, rel.Manifest, "chart: 0.2.0")
+
+	previous, err := rb.cfg.Releases.Get("values-rollback", 2)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusSuperseded, previous.Info.Status)
+}
+
+func TestRollbackValuesOnly_NoStoredConfig(t *testing.T) {
+	rb, _ := rollbackValuesFixture(t)
+
+	// A revision installed without values is decoded with no config.
+	rel1, err := rb.cfg.Releases.Get("values-rollback", 1)
+	require.NoError(t, err)
+	rel1.Config = nil
+	require.NoError(t, rb.cfg.Releases.Update(rel1))
+
+	require.NoError(t, rb.Run("values-rollback"))
+
+	rel, err := rb.cfg.Releases.Get("values-rollback", 3)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	assert.Empty(t, rel.Config)
+	assert.NotContains(t, rel.Manifest, "name: new")
+}
+
+func TestRollbackValuesOnly_DryRun(t *testing.T) {
+	rb, _ := rollbackValuesFixture(t)
+	rb.DryRun = true
+
+	require.NoError(t, rb.Run("values-rollback"))
+
+	_, err := rb.cfg.Releases.Get("values-rollback", 3)
+	assert.Error(t, err, "dry run should not record a revision")
+}
+
+func rollbackAtomicFixture(t *testing.T, name string) *Rollback {
+	t.Helper()
+	config := actionConfigFixture(t)
+
+	rel1 := namedReleaseStub(name, release.StatusSuperseded)
+	rel1.Hooks[0].Events = append(rel1.Hooks[0].Events, release.HookPreRollback)
+	require.NoError(t, config.Releases.Create(rel1))
+
+	rel2 := namedReleaseStub(name, release.StatusDeployed)
+	rel2.Version = 2
+	require.NoError(t, config.Releases.Create(rel2))
+
+	rb := NewRollback(config)
+	rb.Version = 1
+	rb.Atomic = true
+	return rb
+}
+
+func TestRollbackRelease_Atomic(t *testing.T) {
+	t.Run("hook failure rolls forward", func(t *testing.T) {
+		rb := rollbackAtomicFixture(t, "hook-fail")
+		failer := rb.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = errors.New("hook timed out")
+
+		err := rb.Run("hook-fail")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "release hook-fail failed, and has been rolled forward due to atomic being set")
+		assert.Contains(t, err.Error(), "hook timed out")
+
+		failed, err := rb.cfg.Releases.Get("hook-fail", 3)
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusFailed, failed.Info.Status)
+
+		rolledForward, err := rb.cfg.Releases.Get("hook-fail", 4)
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, rolledForward.Info.Status)
+		assert.Equal(t, "Rollback to 2", rolledForward.Info.Description)
+	})
+
+	t.Run("apply failure", func(t *testing.T) {
+		rb := rollbackAtomicFixture(t, "apply-fail")
+		failer := rb.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.UpdateError = errors.New("update fail")
+
+		err := rb.Run("apply-fail")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "an error occurred while rolling forward the release. original rollback error: update fail")
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		rb := rollbackAtomicFixture(t, "wait-fail")
+		failer := rb.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitError = errors.New("I timed out")
+
+		err := rb.Run("wait-fail")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "an error occurred while rolling forward the release. original rollback error")
+		assert.Equal(t, 2, strings.Count(err.Error(), "I timed out"), "both errors should be reported")
+	})
+
+	t.Run("not atomic", func(t *testing.T) {
+		rb := rollbackAtomicFixture(t, "no-atomic")
+		rb.Atomic = false
+		failer := rb.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = errors.New("hook timed out")
+
+		err := rb.Run("no-atomic")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "atomic")
+
+		_, err = rb.cfg.Releases.Get("no-atomic", 4)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v4/pkg/kube"
+)
+
+// ServerSideApplyMode selects whether the resources of a release are created
+// and updated with server-side apply.
+type ServerSideApplyMode string
+
+const (
+	// ServerSideApplyAuto applies the resources server-side when the API
+	// server supports it.
+	ServerSideApplyAuto ServerSideApplyMode = "auto"
+	// ServerSideApplyTrue always applies the resources server-side.
+	ServerSideApplyTrue ServerSideApplyMode = "true"
+	// ServerSideApplyFalse creates and patches the resources client-side.
+	ServerSideApplyFalse ServerSideApplyMode = "false"
+)
+
+// serverSideApplier returns the client applying the resources server-side
+// according to mode, or nil if they are created and patched client-side. An
+// empty mode is ServerSideApplyFalse.
+//
+// Resources cannot be replaced with force when they are applied server-side,
+// so ServerSideApplyAuto falls back to the client-side patches with force.
+func (cfg *Configuration) serverSideApplier(mode ServerSideApplyMode, force, forceConflicts bool) (kube.InterfaceServerSideApply, error) {
+	switch mode {
+	case "", ServerSideApplyFalse:
+		if forceConflicts {
+			return nil, errors.New("conflicts can only be forced with server-side apply")
+		}
+		return nil, nil
+	case ServerSideApplyTrue, ServerSideApplyAuto:
+	default:
+		return nil, fmt.Errorf("invalid server-side apply mode %q. Valid modes are %s, %s and %s", mode, ServerSideApplyAuto, ServerSideApplyTrue, ServerSideApplyFalse)
+	}
+
+	applier, ok := cfg.KubeClient.(kube.InterfaceServerSideApply)
+	if mode == ServerSideApplyTrue {
+		switch {
+		case force:
+			return nil, errors.New("resources cannot be replaced with force when they are applied server-side")
+		case !ok:
+			return nil, errors.New("the Kubernetes client is unable to apply resources server-side")
+		}
+		return applier, nil
+	}
+
+	if !ok || force {
+		return nil, nil
+	}
+	supported, err := applier.IsServerSideApplySupported()
+	if err != nil {
+		return nil, fmt.Errorf("unable to check for server-side apply support: %w", err)
+	}
+	if !supported {
+		cfg.log().Debug("server-side apply is not supported by the cluster, patching resources client-side")
+		return nil, nil
+	}
+	return applier, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kuberuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest/fake"
+
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+)
+
+// applyKubeClient records how the resources are created and updated.
+type applyKubeClient struct {
+	*kubefake.FailingKubeClient
+	calls []string
+	opts  []kube.ServerSideApplyOptions
+}
+
+func newApplyKubeClient(supported bool) *applyKubeClient {
+	return &applyKubeClient{FailingKubeClient: &kubefake.FailingKubeClient{
+		PrintingKubeClient:       kubefake.PrintingKubeClient{Out: io.Discard},
+		DummyResources:           missingResourceList(),
+		ServerSideApplySupported: supported,
+	}}
+}
+
+// missingResourceList returns a Deployment which does not exist yet.
+func missingResourceList() kube.ResourceList {
+	resources := createDummyResourceList(false)
+	resources[0].Client = &fake.RESTClient{
+		GroupVersion:         schema.GroupVersion{Group: "apps", Version: "v1"},
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Resp: &http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"Content-Type": []string{kuberuntime.ContentTypeJSON}},
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		},
+	}
+	return resources
+}
+
+// applyInstallAction returns an install creating its resources with client,
+// without hooks, which are always created client-side.
+func applyInstallAction(t *testing.T, client kube.Interface) *Install {
+	t.Helper()
+	instAction := installAction(t)
+	instAction.cfg.KubeClient = client
+	instAction.DisableHooks = true
+	return instAction
+}
+
+func (c *applyKubeClient) Create(resources kube.ResourceList) (*kube.Result, error) {
+	c.calls = append(c.calls, "create")
+	return c.FailingKubeClient.Create(resources)
+}
+
+func (c *applyKubeClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	c.calls = append(c.calls, "update")
+	return c.FailingKubeClient.Update(original, target, force)
+}
+
+func (c *applyKubeClient) CreateServerSideApply(resources kube.ResourceList, opts kube.ServerSideApplyOptions) (*kube.Result, error) {
+	c.calls = append(c.calls, "create-apply")
+	c.opts = append(c.opts, opts)
+	return c.FailingKubeClient.CreateServerSideApply(resources, opts)
+}
+
+func (c *applyKubeClient) UpdateServerSideApply(original, target kube.ResourceList, opts kube.ServerSideApplyOptions) (*kube.Result, error) {
+	c.calls = append(c.calls, "update-apply")
+	c.opts = append(c.opts, opts)
+	return c.FailingKubeClient.UpdateServerSideApply(original, target, opts)
+}
+
+func TestInstallRelease_ServerSideApply(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		mode      ServerSideApplyMode
+		supported bool
+		expected  []string
+	}{
+		{"auto when supported", ServerSideApplyAuto, true, []string{"create-apply"}},
+		{"auto when not supported", ServerSideApplyAuto, false, []string{"create"}},
+		{"true", ServerSideApplyTrue, false, []string{"create-apply"}},
+		{"false", ServerSideApplyFalse, true, []string{"create"}},
+		{"empty", "", true, []string{"create"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newApplyKubeClient(tt.supported)
+			instAction := applyInstallAction(t, client)
+			instAction.ServerSideApply = tt.mode
+
+			_, err := instAction.Run(buildChart(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, client.calls)
+		})
+	}
+}
+
+func TestServerSideApplyDefault(t *testing.T) {
+	cfg := actionConfigFixture(t)
+	assert.Equal(t, ServerSideApplyMode(""), NewInstall(cfg).ServerSideApply, "install applies client-side unless asked")
+	assert.Equal(t, ServerSideApplyMode(""), NewUpgrade(cfg).ServerSideApply, "upgrade patches client-side unless asked")
+}
+
+func TestInstallRelease_ServerSideApplyAdoption(t *testing.T) {
+	client := newApplyKubeClient(true)
+	client.DummyResources = createDummyResourceList(true)
+	instAction := applyInstallAction(t, client)
+	instAction.ServerSideApply = ServerSideApplyAuto
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update-apply"}, client.calls, "the resources of the release which exist are applied over")
+}
+
+func TestInstallRelease_ServerSideApplyForceConflicts(t *testing.T) {
+	client := newApplyKubeClient(true)
+	instAction := applyInstallAction(t, client)
+	instAction.ServerSideApply = ServerSideApplyAuto
+	instAction.ForceConflicts = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []kube.ServerSideApplyOptions{{ForceConflicts: true}}, client.opts)
+}
+
+func TestInstallRelease_ServerSideApplyDryRun(t *testing.T) {
+	for _, tt := range []struct {
+		dryRun   string
+		expected []kube.ServerSideApplyOptions
+	}{
+		{"server", []kube.ServerSideApplyOptions{{DryRun: true}}},
+		{"client", nil},
+	} {
+		t.Run(tt.dryRun, func(t *testing.T) {
+			client := newApplyKubeClient(true)
+			instAction := applyInstallAction(t, client)
+			instAction.ServerSideApply = ServerSideApplyAuto
+			instAction.DryRunOption = tt.dryRun
+
+			res, err := instAction.Run(buildChart(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Dry run complete", res.Info.Description)
+			assert.Equal(t, tt.expected, client.opts)
+			_, err = instAction.cfg.Releases.Get(res.Name, res.Version)
+			assert.Error(t, err, "a dry run is not recorded")
+		})
+	}
+}
+
+func TestInstallRelease_ServerSideApplyErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		client   kube.Interface
+		mode     ServerSideApplyMode
+		force    bool
+		conflict bool
+		expected string
+	}{
+		{
+			name:     "invalid mode",
+			client:   newApplyKubeClient(true),
+			mode:     "sometimes",
+			expected: `invalid server-side apply mode "sometimes". Valid modes are auto, true and false`,
+		},
+		{
+			name:     "replacing with force",
+			client:   newApplyKubeClient(true),
+			mode:     ServerSideApplyTrue,
+			force:    true,
+			expected: "resources cannot be replaced with force when they are applied server-side",
+		},
+		{
+			name:     "forcing conflicts client-side",
+			client:   newApplyKubeClient(true),
+			mode:     ServerSideApplyFalse,
+			conflict: true,
+			expected: "conflicts can only be forced with server-side apply",
+		},
+		{
+			name:     "unsupported client",
+			client:   struct{ kube.Interface }{&kubefake.PrintingKubeClient{Out: io.Discard}},
+			mode:     ServerSideApplyTrue,
+			expected: "the Kubernetes client is unable to apply resources server-side",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			instAction := applyInstallAction(t, tt.client)
+			instAction.ServerSideApply = tt.mode
+			instAction.Force = tt.force
+			instAction.ForceConflicts = tt.conflict
+
+			_, err := instAction.Run(buildChart(), nil)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestInstallRelease_ServerSideApplyAutoWithForce(t *testing.T) {
+	client := newApplyKubeClient(true)
+	instAction := applyInstallAction(t, client)
+	instAction.ServerSideApply = ServerSideApplyAuto
+	instAction.Force = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create"}, client.calls, "resources replaced with force are not applied server-side")
+}
+
+func TestUpgradeRelease_ServerSideApply(t *testing.T) {
+	upAction := upgradeAction(t)
+	client := newApplyKubeClient(true)
+	upAction.cfg.KubeClient = client
+	upAction.DisableHooks = true
+	upAction.ServerSideApply = ServerSideApplyAuto
+	upAction.ForceConflicts = true
+
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	res, err := upAction.Run(rel.Name, buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "deployed", res.Info.Status.String())
+	assert.Equal(t, []string{"update-apply"}, client.calls)
+	assert.Equal(t, []kube.ServerSideApplyOptions{{ForceConflicts: true}}, client.opts)
+}
+
+func TestUpgradeRelease_ServerSideApplyDryRun(t *testing.T) {
+	upAction := upgradeAction(t)
+	client := newApplyKubeClient(true)
+	upAction.cfg.KubeClient = client
+	upAction.DisableHooks = true
+	upAction.ServerSideApply = ServerSideApplyAuto
+	upAction.DryRunOption = "server"
+
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	res, err := upAction.Run(rel.Name, buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Dry run complete", res.Info.Description)
+	assert.Equal(t, []kube.ServerSideApplyOptions{{DryRun: true}}, client.opts)
+}
+
+func TestUpgradeRelease_ServerSideApplyNotSupported(t *testing.T) {
+	upAction := upgradeAction(t)
+	client := newApplyKubeClient(false)
+	upAction.cfg.KubeClient = client
+	upAction.DisableHooks = true
+	upAction.ServerSideApply = ServerSideApplyAuto
+
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	_, err := upAction.Run(rel.Name, buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update"}, client.calls)
+}
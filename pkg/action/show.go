@@ -18,6 +18,7 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 
@@ -60,7 +61,18 @@ type Show struct {
 	Devel            bool
 	OutputFormat     ShowOutputFormat
 	JSONPathTemplate string
-	chart            *chart.Chart // for testing
+	// Values, if set, are the values the conditions and tags of the
+	// dependencies are evaluated with, so that the CRDs of the disabled
+	// subcharts are not shown. Without them, the CRDs of all the subcharts
+	// are shown.
+	Values map[string]interface{}
+	// AllSubcharts shows the values.yaml of the bundled subcharts, recursively,
+	// after the values of the chart, each one under the key its parent passes
+	// its values down under, in a single document. The subcharts disabled by
+	// the conditions and tags of their dependencies, evaluated with Values,
+	// are shown too, but marked as such.
+	AllSubcharts bool
+	chart        *chart.Chart // for testing
 }
 
 // NewShow creates a new Show object with the given configuration.
@@ -80,12 +92,8 @@ func (s *Show) SetRegistryClient(client *registry.Client) {
 
 // Run executes 'helm show' against the given release.
 func (s *Show) Run(chartpath string) (string, error) {
-	if s.chart == nil {
-		chrt, err := loader.Load(chartpath)
-		if err != nil {
-			return "", err
-		}
-		s.chart = chrt
+	if err := s.loadChart(chartpath); err != nil {
+		return "", err
 	}
 	cf, err := yaml.Marshal(s.chart.Metadata)
 	if err != nil {
@@ -97,7 +105,7 @@ func (s *Show) Run(chartpath string) (string, error) {
 		fmt.Fprintf(&out, "%s\n", cf)
 	}
 
-	if (s.OutputFormat == ShowValues || s.OutputFormat == ShowAll) && s.chart.Values != nil {
+	if (s.OutputFormat == ShowValues || s.OutputFormat == ShowAll) && (s.chart.Values != nil || s.AllSubcharts) {
 		if s.OutputFormat == ShowAll {
 			fmt.Fprintln(&out, "---")
 		}
@@ -107,6 +115,10 @@ func (s *Show) Run(chartpath string) (string, error) {
 				return "", fmt.Errorf("error parsing jsonpath %s: %w", s.JSONPathTemplate, err)
 			}
 			printer.Execute(&out, s.chart.Values)
+		} else if s.AllSubcharts {
+			if err := s.writeAllValues(&out); err != nil {
+				return "", err
+			}
 		} else {
 			for _, f := range s.chart.Raw {
 				if f.Name == chartutil.ValuesfileName {
@@ -127,19 +139,70 @@ func (s *Show) Run(chartpath string) (string, error) {
 	}
 
 	if s.OutputFormat == ShowCRDs || s.OutputFormat == ShowAll {
-		crds := s.chart.CRDObjects()
-		if len(crds) > 0 {
-			if s.OutputFormat == ShowAll && !bytes.HasPrefix(crds[0].File.Data, []byte("---")) {
-				fmt.Fprintln(&out, "---")
-			}
-			for _, crd := range crds {
-				fmt.Fprintf(&out, "%s\n", string(crd.File.Data))
+		if s.Values != nil {
+			if err := chartutil.ProcessDependencies(s.chart, s.Values); err != nil {
+				return "", err
 			}
 		}
+		for _, crd := range s.chart.CRDObjects() {
+			writeCRD(&out, crd)
+		}
 	}
 	return out.String(), nil
 }
 
+// writeAllValues writes the values.yaml of the chart, followed by the ones of
+// its subcharts.
+func (s *Show) writeAllValues(out *strings.Builder) error {
+	if err := checkSubchartKeys(s.chart); err != nil {
+		return fmt.Errorf("the values of the subcharts of %s collide: %w", s.chart.Name(), err)
+	}
+	enabled, err := chartutil.EnabledDependencies(s.chart, s.Values)
+	if err != nil {
+		return err
+	}
+	if values := rawValues(s.chart); len(values) > 0 {
+		fmt.Fprintf(out, "%s\n", values)
+	}
+	writeSubchartValues(out, s.chart, "", "", enabled)
+	return nil
+}
+
+// writeCRD writes the content of a CRD file as a YAML document, preceded by a
+// comment giving the file it comes from.
+func writeCRD(out *strings.Builder, crd chart.CRD) {
+	data := crd.File.Data
+	if bytes.HasPrefix(data, []byte("---")) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+	fmt.Fprintf(out, "---\n# Source: %s\n%s\n", crd.Filename, data)
+}
+
+// VerifyLinks checks the links, maintainers' email addresses and license of the
+// definition of the given chart. See chartutil.VerifyLinks.
+func (s *Show) VerifyLinks(ctx context.Context, chartpath string, opts chartutil.VerifyLinksOptions) ([]chartutil.LinkCheck, error) {
+	if err := s.loadChart(chartpath); err != nil {
+		return nil, err
+	}
+	return chartutil.VerifyLinks(ctx, s.chart.Metadata, opts), nil
+}
+
+func (s *Show) loadChart(chartpath string) error {
+	if s.chart != nil {
+		return nil
+	}
+	chrt, err := loader.Load(chartpath)
+	if err != nil {
+		return err
+	}
+	s.chart = chrt
+	return nil
+}
+
 func findReadme(files []*chart.File) (file *chart.File) {
 	for _, file := range files {
 		for _, n := range readmeFileNames {
@@ -0,0 +1,200 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// subchartValues is a subchart, with the key its parent passes its values
+// down under.
+type subchartValues struct {
+	key   string
+	chart *chart.Chart
+}
+
+// bundledSubcharts returns the subcharts of c, in the order of the
+// dependencies of its Chart.yaml, followed by the subcharts it does not list.
+// A dependency is matched to a subchart as ProcessDependencies does, and is
+// keyed by its alias, if any. The dependencies which are not bundled are left
+// out.
+func bundledSubcharts(c *chart.Chart) []subchartValues {
+	var subs []subchartValues
+	listed := make(map[*chart.Chart]bool)
+	for _, dep := range c.Metadata.Dependencies {
+		if dep == nil {
+			continue
+		}
+		for _, sub := range c.Dependencies() {
+			if sub.Name() != dep.Name || !chartutil.IsCompatibleRange(dep.Version, sub.Metadata.Version) {
+				continue
+			}
+			key := dep.Name
+			if dep.Alias != "" {
+				key = dep.Alias
+			}
+			subs = append(subs, subchartValues{key, sub})
+			listed[sub] = true
+			break
+		}
+	}
+
+	var unlisted []subchartValues
+	for _, sub := range c.Dependencies() {
+		if !listed[sub] {
+			unlisted = append(unlisted, subchartValues{sub.Name(), sub})
+		}
+	}
+	sort.SliceStable(unlisted, func(i, j int) bool { return unlisted[i].key < unlisted[j].key })
+	return append(subs, unlisted...)
+}
+
+// checkSubchartKeys returns an error for every subchart of c, recursively,
+// whose key collides with another subchart, with the global values, or with a
+// value of its parent which is not a map and thus cannot hold the values of
+// the subchart.
+func checkSubchartKeys(c *chart.Chart) error {
+	var errs []error
+	owners := make(map[string]string)
+	for _, sub := range bundledSubcharts(c) {
+		errs = append(errs, checkSubchartKeys(sub.chart))
+		name := sub.chart.Name()
+		if sub.key == chartutil.GlobalKey {
+			errs = append(errs, fmt.Errorf("chart %s: the values of subchart %s cannot be passed down under %q, which holds the global values", c.Name(), name, sub.key))
+			continue
+		}
+		if owner, ok := owners[sub.key]; ok {
+			errs = append(errs, fmt.Errorf("chart %s: subcharts %s and %s both take their values under %q", c.Name(), owner, name, sub.key))
+			continue
+		}
+		owners[sub.key] = name
+		if v, ok := c.Values[sub.key]; ok && v != nil {
+			if _, ok := v.(map[string]interface{}); !ok {
+				errs = append(errs, fmt.Errorf("chart %s: the value %q is not a map, but subchart %s takes its values under it", c.Name(), sub.key, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeSubchartValues writes the values.yaml of the subcharts of c, each one
+// under its key and preceded by a comment giving the subchart and its
+// version, followed by the values of its own subcharts. path is the path of c
+// from the top chart, in the keys of enabled, which are the subcharts that
+// the conditions and tags of the dependencies enable. The sections of the
+// subcharts whose key is already set by the values of c are commented out, so
+// that the document holds each key once. The collisions of the keys are
+// checked beforehand by checkSubchartKeys.
+func writeSubchartValues(out *strings.Builder, c *chart.Chart, path, indent string, enabled map[string]bool) {
+	for _, sub := range bundledSubcharts(c) {
+		fmt.Fprintf(out, "\n%s# Subchart %s %s", indent, sub.chart.Name(), sub.chart.Metadata.Version)
+		if sub.key != sub.chart.Name() {
+			fmt.Fprintf(out, ", under alias %s", sub.key)
+		}
+		fmt.Fprintln(out)
+		if !enabled[path+sub.key] {
+			fmt.Fprintf(out, "%s# Disabled by the condition or tags of its dependency.\n", indent)
+		}
+
+		var body strings.Builder
+		bodyIndent := indent + "  "
+		writeIndented(&body, rawValues(sub.chart), bodyIndent)
+		writeSubchartValues(&body, sub.chart, path+sub.key+".", bodyIndent, enabled)
+		var section string
+		if hasValues(body.String()) {
+			section = fmt.Sprintf("%s%s:\n%s", indent, sub.key, body.String())
+		} else {
+			section = fmt.Sprintf("%s%s: {}\n%s", indent, sub.key, body.String())
+		}
+
+		if _, ok := c.Values[sub.key].(map[string]interface{}); ok {
+			fmt.Fprintf(out, "%s# Commented out, as the values of %s already set %s and take precedence.\n", indent, c.Name(), sub.key)
+			section = commentOut(section, indent)
+		}
+		out.WriteString(section)
+	}
+}
+
+// rawValues returns the values.yaml of c as it is written, without the start
+// of the document.
+func rawValues(c *chart.Chart) []byte {
+	for _, f := range c.Raw {
+		if f.Name != chartutil.ValuesfileName {
+			continue
+		}
+		data := f.Data
+		if bytes.HasPrefix(data, []byte("---")) {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				data = data[i+1:]
+			} else {
+				data = nil
+			}
+		}
+		return bytes.TrimRight(data, "\n")
+	}
+	return nil
+}
+
+// writeIndented writes the lines of data indented, leaving the blank lines
+// empty.
+func writeIndented(out *strings.Builder, data []byte, indent string) {
+	if len(data) == 0 {
+		return
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			fmt.Fprintln(out)
+			continue
+		}
+		fmt.Fprintf(out, "%s%s\n", indent, line)
+	}
+}
+
+// commentOut comments out the lines of a section of the document indented by
+// indent, keeping their indentation after the comment mark, so that the
+// section is valid again once the marks are removed.
+func commentOut(section, indent string) string {
+	var out strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSuffix(section, "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintf(&out, "%s#\n", indent)
+			continue
+		}
+		fmt.Fprintf(&out, "%s# %s\n", indent, strings.TrimPrefix(line, indent))
+	}
+	return out.String()
+}
+
+// hasValues reports whether a section of the document sets any value, rather
+// than only holding comments.
+func hasValues(section string) bool {
+	for line := range strings.SplitSeq(section, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package action
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	"sigs.k8s.io/yaml"
+
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 )
 
 func TestShow(t *testing.T) {
@@ -53,9 +59,11 @@ VALUES
 README
 
 ---
+# Source: alpine/crds/foo.yaml
 foo
 
 ---
+# Source: alpine/crds/bar.json
 bar
 
 `
@@ -114,9 +122,11 @@ func TestShowCRDs(t *testing.T) {
 	}
 
 	expect := `---
+# Source: alpine/crds/foo.yaml
 foo
 
 ---
+# Source: alpine/crds/bar.json
 bar
 
 `
@@ -125,6 +135,45 @@ bar
 	}
 }
 
+func TestShowCRDsWithValues(t *testing.T) {
+	parent := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "parent",
+			Dependencies: []*chart.Dependency{
+				{Name: "sub", Condition: "sub.enabled"},
+			},
+		},
+		Files: []*chart.File{
+			{Name: "crds/parent.yaml", Data: []byte("kind: CustomResourceDefinition\n")},
+		},
+		Values: map[string]interface{}{},
+	}
+	parent.AddDependency(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "sub"},
+		Files: []*chart.File{
+			{Name: "crds/sub.yaml", Data: []byte("kind: CustomResourceDefinition\n")},
+		},
+	})
+
+	client := NewShow(ShowCRDs, actionConfigFixture(t))
+	client.chart = parent
+	client.Values = map[string]interface{}{"sub": map[string]interface{}{"enabled": false}}
+	output, err := client.Run("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `---
+# Source: parent/crds/parent.yaml
+kind: CustomResourceDefinition
+
+`
+	if output != expect {
+		t.Errorf("Expected\n%q\nGot\n%q\n", expect, output)
+	}
+}
+
 func TestShowNoReadme(t *testing.T) {
 	config := actionConfigFixture(t)
 	client := NewShow(ShowAll, config)
@@ -145,9 +194,11 @@ func TestShowNoReadme(t *testing.T) {
 	expect := `name: alpine
 
 ---
+# Source: alpine/crds/foo.yaml
 foo
 
 ---
+# Source: alpine/crds/bar.json
 bar
 
 `
@@ -155,3 +206,130 @@ bar
 		t.Errorf("Expected\n%q\nGot\n%q\n", expect, output)
 	}
 }
+
+func TestShowVerifyLinks(t *testing.T) {
+	config := actionConfigFixture(t)
+	client := NewShow(ShowChart, config)
+	client.chart = &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:        "alpine",
+			Home:        "https://helm.sh",
+			Maintainers: []*chart.Maintainer{{Name: "Helm", Email: "not-an-email"}},
+		},
+	}
+
+	checks, err := client.VerifyLinks(context.Background(), "", chartutil.VerifyLinksOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("Expected 2 checks, got %d", len(checks))
+	}
+	if checks[0].Status != chartutil.LinkValid {
+		t.Errorf("Expected the home to be valid, got %s", checks[0].Status)
+	}
+	if checks[1].Status != chartutil.LinkInvalid {
+		t.Errorf("Expected the email to be invalid, got %s", checks[1].Status)
+	}
+}
+
+func TestShowValuesAllSubcharts(t *testing.T) {
+	client := NewShow(ShowValues, actionConfigFixture(t))
+	client.AllSubcharts = true
+	client.chart, _ = loader.Load("../cmd/testdata/testcharts/chart-with-subchart-values")
+	output, err := client.Run("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(output), &values); err != nil {
+		t.Fatalf("expected a single YAML document, got %s: %s", err, output)
+	}
+	api, ok := values["api"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the values of backend under its alias, got %v", values)
+	}
+	if api["replicas"] != float64(1) {
+		t.Errorf("expected the values of backend to be indented under api, got %v", api)
+	}
+	if _, ok := values["metrics"]; !ok {
+		t.Errorf("expected the disabled subchart metrics to be shown, got %v", values)
+	}
+	if !strings.Contains(output, "# Subchart metrics 0.1.0\n# Disabled by the condition or tags of its dependency.\n") {
+		t.Errorf("expected the subchart metrics to be marked as disabled, got %s", output)
+	}
+	if values["web"].(map[string]interface{})["replicas"] != float64(2) {
+		t.Errorf("expected the values of the parent to be kept for web, got %v", values["web"])
+	}
+
+	// The conditions are evaluated with the values given.
+	client.Values = map[string]interface{}{"api": map[string]interface{}{"redis": map[string]interface{}{"enabled": false}}}
+	output, err = client.Run("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "  # Subchart redis 7.0.0\n  # Disabled by the condition or tags of its dependency.\n") {
+		t.Errorf("expected the subchart redis to be marked as disabled, got %s", output)
+	}
+}
+
+func TestShowValuesAllSubchartsCollisions(t *testing.T) {
+	sub := func(name string) *chart.Chart {
+		return &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: "0.1.0"},
+			Raw:      []*chart.File{{Name: "values.yaml", Data: []byte("replicas: 1\n")}},
+			Values:   map[string]interface{}{"replicas": 1},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		deps   []*chart.Dependency
+		values map[string]interface{}
+		expect string
+	}{{
+		name: "alias of another subchart",
+		deps: []*chart.Dependency{
+			{Name: "api", Version: "0.1.0"},
+			{Name: "web", Version: "0.1.0", Alias: "api"},
+		},
+		expect: `chart parent: subcharts api and web both take their values under "api"`,
+	}, {
+		name: "alias of the global values",
+		deps: []*chart.Dependency{
+			{Name: "api", Version: "0.1.0"},
+			{Name: "web", Version: "0.1.0", Alias: "global"},
+		},
+		expect: `chart parent: the values of subchart web cannot be passed down under "global", which holds the global values`,
+	}, {
+		name: "alias of a value of the parent",
+		deps: []*chart.Dependency{
+			{Name: "api", Version: "0.1.0"},
+			{Name: "web", Version: "0.1.0", Alias: "title"},
+		},
+		values: map[string]interface{}{"title": "shop"},
+		expect: `chart parent: the value "title" is not a map, but subchart web takes its values under it`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := &chart.Chart{
+				Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "parent", Version: "0.1.0", Dependencies: tt.deps},
+				Values:   tt.values,
+			}
+			parent.AddDependency(sub("api"), sub("web"))
+
+			client := NewShow(ShowValues, actionConfigFixture(t))
+			client.AllSubcharts = true
+			client.chart = parent
+			_, err := client.Run("")
+			if err == nil {
+				t.Fatal("expected the collision to be reported")
+			}
+			if !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("expected %q in the error, got %q", tt.expect, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package action
 import (
 	"bytes"
 	"errors"
+	"fmt"
 
 	"helm.sh/helm/v4/pkg/kube"
 	release "helm.sh/helm/v4/pkg/release/v1"
@@ -32,6 +33,10 @@ type Status struct {
 
 	Version int
 
+	// ShowResources queries the cluster for the live state of each resource
+	// of the release, reported in the ResourceStatuses of its info.
+	ShowResources bool
+
 	// ShowResourcesTable is used with ShowResources. When true this will cause
 	// the resulting objects to be retrieved as a kind=table.
 	ShowResourcesTable bool
@@ -76,7 +81,42 @@ func (s *Status) Run(name string) (*release.Release, error) {
 
 		rel.Info.Resources = resp
 
+		if s.ShowResources {
+			if err := s.resourceStatuses(rel); err != nil {
+				return nil, err
+			}
+		}
+
 		return rel, nil
 	}
 	return nil, errors.New("unable to get kubeClient with interface InterfaceResources")
 }
+
+// resourceStatuses queries the live state of the resources of rel.
+func (s *Status) resourceStatuses(rel *release.Release) error {
+	kubeClient, ok := s.cfg.KubeClient.(kube.InterfaceResourceStatus)
+	if !ok {
+		return errors.New("unable to get kubeClient with interface InterfaceResourceStatus")
+	}
+	resources, err := s.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return fmt.Errorf("unable to build kubernetes objects from release manifest: %w", err)
+	}
+	statuses, err := kubeClient.ResourceStatuses(resources)
+	if err != nil {
+		return fmt.Errorf("unable to query the status of the resources of release %s: %w", rel.Name, err)
+	}
+
+	rel.Info.ResourceStatuses = make([]release.ResourceStatus, 0, len(statuses))
+	for _, st := range statuses {
+		rel.Info.ResourceStatuses = append(rel.Info.ResourceStatuses, release.ResourceStatus{
+			Kind:            st.Kind,
+			Namespace:       st.Namespace,
+			Name:            st.Name,
+			State:           string(st.State),
+			ReadyReplicas:   st.ReadyReplicas,
+			DesiredReplicas: st.DesiredReplicas,
+		})
+	}
+	return nil
+}
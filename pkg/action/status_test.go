@@ -0,0 +1,74 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func statusAction(t *testing.T) (*Status, *kubefake.FailingKubeClient) {
+	t.Helper()
+	config := actionConfigFixture(t)
+	require.NoError(t, config.Releases.Create(releaseStub()))
+	return NewStatus(config), config.KubeClient.(*kubefake.FailingKubeClient)
+}
+
+func TestStatus(t *testing.T) {
+	client, _ := statusAction(t)
+
+	rel, err := client.Run("angry-panda")
+	require.NoError(t, err)
+	assert.Equal(t, "angry-panda", rel.Name)
+	assert.Empty(t, rel.Info.ResourceStatuses, "the live state is only queried with ShowResources")
+}
+
+func TestStatus_ShowResources(t *testing.T) {
+	client, kubeClient := statusAction(t)
+	client.ShowResources = true
+	two, three := int32(2), int32(3)
+	kubeClient.DummyResourceStatuses = []kube.ResourceStatus{
+		{Kind: "Deployment", Namespace: "spaced", Name: "web", State: kube.ResourceReady, ReadyReplicas: &three, DesiredReplicas: &three},
+		{Kind: "StatefulSet", Namespace: "spaced", Name: "db", State: kube.ResourceNotReady, ReadyReplicas: &two, DesiredReplicas: &three},
+		{Kind: "Service", Namespace: "spaced", Name: "frontend", State: kube.ResourceMissing},
+	}
+
+	rel, err := client.Run("angry-panda")
+	require.NoError(t, err)
+	assert.Equal(t, []release.ResourceStatus{
+		{Kind: "Deployment", Namespace: "spaced", Name: "web", State: "Ready", ReadyReplicas: &three, DesiredReplicas: &three},
+		{Kind: "StatefulSet", Namespace: "spaced", Name: "db", State: "NotReady", ReadyReplicas: &two, DesiredReplicas: &three},
+		{Kind: "Service", Namespace: "spaced", Name: "frontend", State: "Missing"},
+	}, rel.Info.ResourceStatuses)
+}
+
+func TestStatus_ShowResourcesError(t *testing.T) {
+	client, kubeClient := statusAction(t)
+	client.ShowResources = true
+	kubeClient.ResourceStatusesError = errors.New("connection refused")
+
+	_, err := client.Run("angry-panda")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to query the status of the resources of release angry-panda: connection refused")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// StorageMigrate is the action for migrating the schema of the SQL storage.
+// The configuration is initialized with DeferStorageMigration, so that the
+// pending migrations are left to this action.
+//
+// It provides the implementation of 'helm storage migrate'.
+type StorageMigrate struct {
+	cfg *Configuration
+
+	// DryRun only reports the pending migrations, without applying them.
+	DryRun bool
+}
+
+// NewStorageMigrate creates a new StorageMigrate object with the given
+// configuration.
+func NewStorageMigrate(cfg *Configuration) *StorageMigrate {
+	return &StorageMigrate{
+		cfg: cfg,
+	}
+}
+
+// Run returns the IDs of the pending migrations of the schema of the SQL
+// storage, in order, and applies them unless DryRun is set.
+func (m *StorageMigrate) Run() ([]string, error) {
+	d, ok := m.cfg.Releases.Driver.(*driver.SQL)
+	if !ok {
+		return nil, fmt.Errorf("the %s storage driver has no schema to migrate, only the SQL one has", m.cfg.Releases.Name())
+	}
+	if m.DryRun {
+		return d.PendingMigrations()
+	}
+	return d.Migrate()
+}
+
+// sqlOptionsFromEnv returns the options of the SQL driver set in the
+// environment: the limits of its connection pool and the number of retries of
+// its writes.
+func sqlOptionsFromEnv() ([]driver.SQLOption, error) {
+	var opts []driver.SQLOption
+	ints := []struct {
+		name string
+		opt  func(int) driver.SQLOption
+	}{
+		{"HELM_DRIVER_SQL_MAX_OPEN_CONNS", driver.WithMaxOpenConns},
+		{"HELM_DRIVER_SQL_MAX_IDLE_CONNS", driver.WithMaxIdleConns},
+		{"HELM_DRIVER_SQL_WRITE_RETRIES", driver.WithWriteRetries},
+	}
+	for _, env := range ints {
+		v, ok := os.LookupEnv(env.name)
+		if !ok || v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid $%s %q: must be a non-negative integer", env.name, v)
+		}
+		opts = append(opts, env.opt(n))
+	}
+	if v := os.Getenv("HELM_DRIVER_SQL_CONN_MAX_LIFETIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid $HELM_DRIVER_SQL_CONN_MAX_LIFETIME %q: must be a non-negative duration, e.g. 5m", v)
+		}
+		opts = append(opts, driver.WithConnMaxLifetime(d))
+	}
+	return opts, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageMigrateWithoutSQL(t *testing.T) {
+	client := NewStorageMigrate(actionConfigFixture(t))
+	client.DryRun = true
+	_, err := client.Run()
+	assert.EqualError(t, err, "the Memory storage driver has no schema to migrate, only the SQL one has")
+}
+
+func TestSQLOptionsFromEnv(t *testing.T) {
+	opts, err := sqlOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+
+	t.Setenv("HELM_DRIVER_SQL_MAX_OPEN_CONNS", "10")
+	t.Setenv("HELM_DRIVER_SQL_MAX_IDLE_CONNS", "2")
+	t.Setenv("HELM_DRIVER_SQL_WRITE_RETRIES", "0")
+	t.Setenv("HELM_DRIVER_SQL_CONN_MAX_LIFETIME", "5m")
+	opts, err = sqlOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Len(t, opts, 4)
+
+	t.Setenv("HELM_DRIVER_SQL_MAX_OPEN_CONNS", "many")
+	_, err = sqlOptionsFromEnv()
+	assert.EqualError(t, err, `invalid $HELM_DRIVER_SQL_MAX_OPEN_CONNS "many": must be a non-negative integer`)
+
+	t.Setenv("HELM_DRIVER_SQL_MAX_OPEN_CONNS", "10")
+	t.Setenv("HELM_DRIVER_SQL_CONN_MAX_LIFETIME", "-1s")
+	_, err = sqlOptionsFromEnv()
+	assert.EqualError(t, err, `invalid $HELM_DRIVER_SQL_CONN_MAX_LIFETIME "-1s": must be a non-negative duration, e.g. 5m`)
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/kube"
@@ -45,6 +48,20 @@ type Uninstall struct {
 	DeletionPropagation string
 	Timeout             time.Duration
 	Description         string
+	// PolicyOverrides are the tokens given to approve the uninstall, or to
+	// override the policy of the release.
+	PolicyOverrides []string
+	// OverridePin allows the uninstall of a pinned release.
+	OverridePin bool
+	// SkipHookEvents skips the hooks of these events, which are still
+	// recorded on the release.
+	SkipHookEvents []release.HookEvent
+	// KeepSelector is a label selector of the resources of the release which
+	// are not deleted, in addition to those with the keep resource policy.
+	KeepSelector string
+	// KeepKinds are the kinds of the resources of the release which are not
+	// deleted, in addition to those with the keep resource policy.
+	KeepKinds []string
 }
 
 // NewUninstall creates a new Uninstall object with the given configuration.
@@ -56,10 +73,36 @@ func NewUninstall(cfg *Configuration) *Uninstall {
 
 // Run uninstalls the given release.
 func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error) {
+	startedAt := time.Now()
+	res, err := u.run(name)
+	if u.DryRun || (res == nil && err == nil) {
+		// Nothing was uninstalled.
+		return res, err
+	}
+	var rel *release.Release
+	if res != nil {
+		rel = res.Release
+	}
+	event := newReleaseEvent(OperationUninstall, name, "", rel, nil, err, startedAt)
+	if rerr := u.cfg.notifyRecorder(context.Background(), event); rerr != nil && err == nil {
+		return res, rerr
+	}
+	return res, err
+}
+
+func (u *Uninstall) run(name string) (*release.UninstallReleaseResponse, error) {
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
 
+	if err := validateSkipHookEvents(u.SkipHookEvents); err != nil {
+		return nil, err
+	}
+	keepSelector, err := u.keepSelector()
+	if err != nil {
+		return nil, err
+	}
+
 	waiter, err := u.cfg.KubeClient.GetWaiter(u.WaitStrategy)
 	if err != nil {
 		return nil, err
@@ -104,42 +147,47 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 		return nil, fmt.Errorf("the release named %q is already deleted", name)
 	}
 
-	slog.Debug("uninstall: deleting release", "name", name)
+	if err := checkPin(rel, u.OverridePin); err != nil {
+		return nil, err
+	}
+	if err := u.cfg.checkPolicy(rel, release.PolicyUninstall, u.PolicyOverrides); err != nil {
+		return nil, err
+	}
+
+	u.cfg.log().Debug("uninstall: deleting release", "name", name)
 	rel.Info.Status = release.StatusUninstalling
 	rel.Info.Deleted = helmtime.Now()
 	rel.Info.Description = "Deletion in progress (or silently failed)"
 	res := &release.UninstallReleaseResponse{Release: rel}
 
-	if !u.DisableHooks {
+	if u.cfg.runsHooks(release.HookPreDelete, u.DisableHooks, u.SkipHookEvents) {
 		if err := u.cfg.execHook(rel, release.HookPreDelete, u.WaitStrategy, u.Timeout); err != nil {
 			return res, err
 		}
 	} else {
-		slog.Debug("delete hooks disabled", "release", name)
+		u.cfg.log().Debug("delete hooks disabled", "release", name)
 	}
 
 	// From here on out, the release is currently considered to be in StatusUninstalling
 	// state.
 	if err := u.cfg.Releases.Update(rel); err != nil {
-		slog.Debug("uninstall: Failed to store updated release", slog.Any("error", err))
+		u.cfg.log().Debug("uninstall: Failed to store updated release", slog.Any("error", err))
 	}
 
-	deletedResources, kept, errs := u.deleteRelease(rel)
+	deletedResources, kept, keptResources, errs := u.deleteRelease(rel, keepSelector)
 	if errs != nil {
-		slog.Debug("uninstall: Failed to delete release", slog.Any("error", errs))
+		u.cfg.log().Debug("uninstall: Failed to delete release", slog.Any("error", errs))
 		return nil, fmt.Errorf("failed to delete release: %s", name)
 	}
 
-	if kept != "" {
-		kept = "These resources were kept due to the resource policy:\n" + kept
-	}
 	res.Info = kept
+	res.KeptResources = keptResources
 
 	if err := waiter.WaitForDelete(deletedResources, u.Timeout); err != nil {
 		errs = append(errs, err)
 	}
 
-	if !u.DisableHooks {
+	if u.cfg.runsHooks(release.HookPostDelete, u.DisableHooks, u.SkipHookEvents) {
 		if err := u.cfg.execHook(rel, release.HookPostDelete, u.WaitStrategy, u.Timeout); err != nil {
 			errs = append(errs, err)
 		}
@@ -153,7 +201,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	}
 
 	if !u.KeepHistory {
-		slog.Debug("purge requested", "release", name)
+		u.cfg.log().Debug("purge requested", "release", name)
 		err := u.purgeReleases(rels...)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("uninstall: Failed to purge the release: %w", err))
@@ -168,7 +216,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	}
 
 	if err := u.cfg.Releases.Update(rel); err != nil {
-		slog.Debug("uninstall: Failed to store updated release", slog.Any("error", err))
+		u.cfg.log().Debug("uninstall: Failed to store updated release", slog.Any("error", err))
 	}
 
 	if len(errs) > 0 {
@@ -210,8 +258,20 @@ func (e *joinedErrors) Unwrap() []error {
 	return e.errs
 }
 
+// keepSelector parses KeepSelector, returning nil if it is not set.
+func (u *Uninstall) keepSelector() (labels.Selector, error) {
+	if u.KeepSelector == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(u.KeepSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keep selector %q: %w", u.KeepSelector, err)
+	}
+	return selector, nil
+}
+
 // deleteRelease deletes the release and returns list of delete resources and manifests that were kept in the deletion process
-func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, string, []error) {
+func (u *Uninstall) deleteRelease(rel *release.Release, keepSelector labels.Selector) (kube.ResourceList, string, []release.KeptResource, []error) {
 	var errs []error
 
 	manifests := releaseutil.SplitManifests(rel.Manifest)
@@ -221,13 +281,28 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, stri
 		// FIXME: One way to delete at this point would be to try a label-based
 		// deletion. The problem with this is that we could get a false positive
 		// and delete something that was not legitimately part of this release.
-		return nil, rel.Manifest, []error{fmt.Errorf("corrupted release record. You must manually delete the resources: %w", err)}
+		return nil, rel.Manifest, nil, []error{fmt.Errorf("corrupted release record. You must manually delete the resources: %w", err)}
 	}
 
 	filesToKeep, filesToDelete := filterManifestsToKeep(files)
+	filesToRetain, filesToDelete := filterManifestsToRetain(filesToDelete, keepSelector, u.KeepKinds)
 	var kept string
-	for _, f := range filesToKeep {
-		kept += "[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n"
+	var keptResources []release.KeptResource
+	for _, section := range []struct {
+		reason string
+		files  []releaseutil.Manifest
+	}{
+		{"These resources were kept due to the resource policy:\n", filesToKeep},
+		{"These resources were kept due to the keep selector or kinds:\n", filesToRetain},
+	} {
+		if len(section.files) == 0 {
+			continue
+		}
+		kept += section.reason
+		for _, f := range section.files {
+			kept += "[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n"
+			keptResources = append(keptResources, release.KeptResource{Kind: f.Head.Kind, Namespace: u.keptNamespace(rel, f), Name: f.Head.Metadata.Name})
+		}
 	}
 
 	var builder strings.Builder
@@ -237,19 +312,36 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, stri
 
 	resources, err := u.cfg.KubeClient.Build(strings.NewReader(builder.String()), false)
 	if err != nil {
-		return nil, "", []error{fmt.Errorf("unable to build kubernetes objects for delete: %w", err)}
+		return nil, "", nil, []error{fmt.Errorf("unable to build kubernetes objects for delete: %w", err)}
 	}
 	if len(resources) > 0 {
 		if kubeClient, ok := u.cfg.KubeClient.(kube.InterfaceDeletionPropagation); ok {
-			_, errs = kubeClient.DeleteWithPropagationPolicy(resources, parseCascadingFlag(u.DeletionPropagation))
-			return resources, kept, errs
+			_, errs = kubeClient.DeleteWithPropagationPolicy(resources, parseCascadingFlag(u.cfg.log(), u.DeletionPropagation))
+			return resources, kept, keptResources, errs
 		}
 		_, errs = u.cfg.KubeClient.Delete(resources)
 	}
-	return resources, kept, errs
+	return resources, kept, keptResources, errs
+}
+
+// keptNamespace returns the namespace of the kept resource of the manifest m:
+// none if the resource is cluster-scoped, and otherwise the namespace of the
+// manifest or, if it sets none, the namespace of the release.
+func (u *Uninstall) keptNamespace(rel *release.Release, m releaseutil.Manifest) string {
+	// The scope is only known from the REST mapping of the resource. If it
+	// cannot be built, the resource is reported as namespaced.
+	if res, err := u.cfg.KubeClient.Build(strings.NewReader(m.Content), false); err == nil && len(res) == 1 {
+		if mapping := res[0].Mapping; mapping != nil && mapping.Scope != nil && mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			return ""
+		}
+	}
+	if namespace := manifestNamespace(m); namespace != "" {
+		return namespace
+	}
+	return rel.Namespace
 }
 
-func parseCascadingFlag(cascadingFlag string) v1.DeletionPropagation {
+func parseCascadingFlag(log *slog.Logger, cascadingFlag string) v1.DeletionPropagation {
 	switch cascadingFlag {
 	case "orphan":
 		return v1.DeletePropagationOrphan
@@ -258,7 +350,7 @@ func parseCascadingFlag(cascadingFlag string) v1.DeletionPropagation {
 	case "background":
 		return v1.DeletePropagationBackground
 	default:
-		slog.Debug("uninstall: given cascade value, defaulting to delete propagation background", "value", cascadingFlag)
+		log.Debug("uninstall: given cascade value, defaulting to delete propagation background", "value", cascadingFlag)
 		return v1.DeletePropagationBackground
 	}
 }
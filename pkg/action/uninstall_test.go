@@ -18,12 +18,19 @@ package action
 
 import (
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v4/pkg/kube"
 	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -78,6 +85,28 @@ func TestUninstallRelease_deleteRelease(t *testing.T) {
 	is.Contains(res.Info, expected)
 }
 
+func TestUninstallRelease_SkipHookEvents(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.SkipHookEvents = []release.HookEvent{release.HookPreDelete}
+	unAction.KeepHistory = true
+
+	rel := releaseStub()
+	rel.Name = "skip-hooks"
+	rel.Hooks = []*release.Hook{
+		{Name: "drain", Kind: "ConfigMap", Manifest: "kind: ConfigMap\nmetadata:\n  name: drain\n", Events: []release.HookEvent{release.HookPreDelete}},
+		{Name: "deregister", Kind: "ConfigMap", Manifest: "kind: ConfigMap\nmetadata:\n  name: deregister\n", Events: []release.HookEvent{release.HookPostDelete}},
+	}
+	unAction.cfg.Releases.Create(rel)
+	res, err := unAction.Run(rel.Name)
+	is.NoError(err)
+
+	is.Len(res.Release.Hooks, 2)
+	is.True(res.Release.Hooks[0].LastRun.StartedAt.IsZero(), "pre-delete hooks should be skipped")
+	is.Equal(release.HookPhaseSucceeded, res.Release.Hooks[1].LastRun.Phase)
+}
+
 func TestUninstallRelease_Wait(t *testing.T) {
 	is := assert.New(t)
 
@@ -140,3 +169,189 @@ func TestUninstallRelease_Cascade(t *testing.T) {
 	is.Error(err)
 	is.Contains(err.Error(), "failed to delete release: come-fail-away")
 }
+
+// deleteRecordingKubeClient builds a resource named after each manifest it is
+// given, cluster-scoped for a ClusterRole, and records the names of the
+// resources it deletes.
+type deleteRecordingKubeClient struct {
+	*kubefake.FailingKubeClient
+	deleted []string
+}
+
+func (c *deleteRecordingKubeClient) Build(r io.Reader, _ bool) (kube.ResourceList, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var resources kube.ResourceList
+	for _, m := range releaseutil.SplitManifests(string(b)) {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(m), &head); err != nil {
+			return nil, err
+		}
+		scope := meta.RESTScopeNamespace
+		if head.Kind == "ClusterRole" {
+			scope = meta.RESTScopeRoot
+		}
+		resources = append(resources, &resource.Info{Name: head.Metadata.Name, Mapping: &meta.RESTMapping{Scope: scope}})
+	}
+	return resources, nil
+}
+
+func (c *deleteRecordingKubeClient) DeleteWithPropagationPolicy(resources kube.ResourceList, policy metav1.DeletionPropagation) (*kube.Result, []error) {
+	for _, r := range resources {
+		c.deleted = append(c.deleted, r.Name)
+	}
+	return c.FailingKubeClient.DeleteWithPropagationPolicy(resources, policy)
+}
+
+func TestUninstallRelease_KeepSelectorAndKinds(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: policy-kept
+  annotations:
+    helm.sh/resource-policy: keep
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db-config
+  labels:
+    app: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  labels:
+    app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: frontend
+`
+
+	tests := []struct {
+		name     string
+		selector string
+		kinds    []string
+		deleted  []string
+		kept     []release.KeptResource
+	}{
+		{
+			name:    "resource policy only",
+			deleted: []string{"data", "db-config", "web-config", "web"},
+			kept:    []release.KeptResource{{Kind: "Secret", Namespace: "spaced", Name: "policy-kept"}},
+		},
+		{
+			name:     "selector",
+			selector: "app=db",
+			deleted:  []string{"data", "web-config", "web"},
+			kept: []release.KeptResource{
+				{Kind: "Secret", Namespace: "spaced", Name: "policy-kept"},
+				{Kind: "ConfigMap", Namespace: "spaced", Name: "db-config"},
+			},
+		},
+		{
+			name:    "kinds",
+			kinds:   []string{"persistentvolumeclaim", "Service"},
+			deleted: []string{"db-config", "web-config"},
+			kept: []release.KeptResource{
+				{Kind: "Secret", Namespace: "spaced", Name: "policy-kept"},
+				{Kind: "Service", Namespace: "frontend", Name: "web"},
+				{Kind: "PersistentVolumeClaim", Namespace: "spaced", Name: "data"},
+			},
+		},
+		{
+			name:     "selector and kinds",
+			selector: "app in (db,web)",
+			kinds:    []string{"PersistentVolumeClaim"},
+			deleted:  []string{"web"},
+			kept: []release.KeptResource{
+				{Kind: "Secret", Namespace: "spaced", Name: "policy-kept"},
+				{Kind: "ConfigMap", Namespace: "spaced", Name: "db-config"},
+				{Kind: "ConfigMap", Namespace: "spaced", Name: "web-config"},
+				{Kind: "PersistentVolumeClaim", Namespace: "spaced", Name: "data"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			unAction := uninstallAction(t)
+			client := &deleteRecordingKubeClient{FailingKubeClient: unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+			unAction.cfg.KubeClient = client
+			unAction.DisableHooks = true
+			unAction.KeepSelector = tc.selector
+			unAction.KeepKinds = tc.kinds
+
+			rel := releaseStub()
+			rel.Namespace = "spaced"
+			rel.Manifest = manifest
+			require.NoError(t, unAction.cfg.Releases.Create(rel))
+
+			res, err := unAction.Run(rel.Name)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.deleted, client.deleted)
+			assert.ElementsMatch(t, tc.kept, res.KeptResources)
+			assert.Contains(t, res.Info, "These resources were kept due to the resource policy:\n[Secret] policy-kept\n")
+			if len(tc.kept) > 1 {
+				assert.Contains(t, res.Info, "These resources were kept due to the keep selector or kinds:\n")
+			}
+		})
+	}
+}
+
+func TestUninstallRelease_KeepClusterScopedKind(t *testing.T) {
+	unAction := uninstallAction(t)
+	client := &deleteRecordingKubeClient{FailingKubeClient: unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	unAction.cfg.KubeClient = client
+	unAction.DisableHooks = true
+	unAction.KeepKinds = []string{"ClusterRole", "ConfigMap"}
+
+	rel := releaseStub()
+	rel.Namespace = "spaced"
+	rel.Manifest = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+	require.NoError(t, unAction.cfg.Releases.Create(rel))
+
+	res, err := unAction.Run(rel.Name)
+	require.NoError(t, err)
+	assert.Empty(t, client.deleted)
+	assert.ElementsMatch(t, []release.KeptResource{
+		{Kind: "ClusterRole", Name: "reader"},
+		{Kind: "ConfigMap", Namespace: "spaced", Name: "config"},
+	}, res.KeptResources)
+}
+
+func TestUninstallRelease_InvalidKeepSelector(t *testing.T) {
+	unAction := uninstallAction(t)
+	unAction.KeepSelector = "app in db"
+
+	rel := releaseStub()
+	require.NoError(t, unAction.cfg.Releases.Create(rel))
+
+	_, err := unAction.Run(rel.Name)
+	require.ErrorContains(t, err, `invalid keep selector "app in db"`)
+
+	// The release was not uninstalled.
+	r, err := unAction.cfg.Releases.Get(rel.Name, rel.Version)
+	require.NoError(t, err)
+	assert.NotEqual(t, release.StatusUninstalled, r.Info.Status)
+}
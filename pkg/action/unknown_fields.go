@@ -0,0 +1,73 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v4/pkg/kube"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+var errPruneWithoutValidation = errors.New("unknown fields can only be pruned with OpenAPI validation enabled")
+
+// pruneUnknownFields removes from resources the fields the cluster does not
+// know, warns about them, and validates what is left against the OpenAPI
+// schemas of the cluster. It returns the fields removed from each resource.
+func (cfg *Configuration) pruneUnknownFields(resources kube.ResourceList) ([]release.PrunedFields, error) {
+	pruner, ok := cfg.KubeClient.(kube.InterfacePruneUnknownFields)
+	if !ok {
+		return nil, errors.New("the Kubernetes client is unable to prune unknown fields")
+	}
+	report, err := pruner.PruneUnknownFields(resources)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prune unknown fields: %w", err)
+	}
+
+	var pruned []release.PrunedFields
+	for _, r := range report {
+		cfg.log().Warn("removed fields unknown to the cluster", "kind", r.Kind, "namespace", r.Namespace, "name", r.Name, "fields", strings.Join(r.Paths, ", "))
+		pruned = append(pruned, release.PrunedFields{
+			Kind:      r.Kind,
+			Namespace: r.Namespace,
+			Name:      r.Name,
+			Paths:     r.Paths,
+		})
+	}
+
+	var manifest bytes.Buffer
+	for _, info := range resources {
+		if info.Object == nil {
+			continue
+		}
+		data, err := yaml.Marshal(info.Object)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize %s: %w", info.Name, err)
+		}
+		manifest.WriteString("---\n")
+		manifest.Write(data)
+	}
+	if err := validateManifest(cfg.KubeClient, manifest.Bytes(), true); err != nil {
+		return nil, fmt.Errorf("unable to validate the pruned kubernetes objects: %w", err)
+	}
+	return pruned, nil
+}
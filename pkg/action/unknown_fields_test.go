@@ -0,0 +1,146 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// validationKubeClient records whether each call to Build validates the
+// manifest.
+type validationKubeClient struct {
+	*kubefake.FailingKubeClient
+	validations []bool
+}
+
+func (c *validationKubeClient) Build(r io.Reader, validate bool) (kube.ResourceList, error) {
+	c.validations = append(c.validations, validate)
+	return c.FailingKubeClient.Build(r, validate)
+}
+
+func newValidationKubeClient(pruned ...kube.PrunedFields) *validationKubeClient {
+	return &validationKubeClient{FailingKubeClient: &kubefake.FailingKubeClient{
+		PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard},
+		DummyPrunedFields:  pruned,
+	}}
+}
+
+var prunedDeployment = kube.PrunedFields{
+	Kind:      "Deployment",
+	Namespace: "spaced",
+	Name:      "web",
+	Paths:     []string{"spec.template.spec.hostUsers"},
+}
+
+func TestInstallRelease_PruneUnknownFields(t *testing.T) {
+	instAction := installAction(t)
+	client := newValidationKubeClient(prunedDeployment)
+	instAction.cfg.KubeClient = client
+	instAction.PruneUnknownFields = true
+
+	res, err := instAction.Run(buildChart(), nil)
+	require.NoError(t, err)
+
+	expected := []release.PrunedFields{{
+		Kind:      "Deployment",
+		Namespace: "spaced",
+		Name:      "web",
+		Paths:     []string{"spec.template.spec.hostUsers"},
+	}}
+	assert.Equal(t, expected, res.Info.PrunedFields)
+	stored, err := instAction.cfg.Releases.Get(res.Name, res.Version)
+	require.NoError(t, err)
+	assert.Equal(t, expected, stored.Info.PrunedFields)
+
+	// The manifest is built without validation, and the pruned resources
+	// are validated.
+	require.GreaterOrEqual(t, len(client.validations), 2)
+	assert.False(t, client.validations[0])
+	assert.True(t, client.validations[1])
+}
+
+func TestInstallRelease_PruneUnknownFieldsWithoutValidation(t *testing.T) {
+	instAction := installAction(t)
+	instAction.PruneUnknownFields = true
+	instAction.DisableOpenAPIValidation = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	assert.ErrorIs(t, err, errPruneWithoutValidation)
+}
+
+func TestInstallRelease_PruneUnknownFieldsError(t *testing.T) {
+	instAction := installAction(t)
+	instAction.cfg.KubeClient = &kubefake.FailingKubeClient{
+		PrintingKubeClient:      kubefake.PrintingKubeClient{Out: io.Discard},
+		PruneUnknownFieldsError: errors.New("openapi unavailable"),
+	}
+	instAction.PruneUnknownFields = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	assert.ErrorContains(t, err, "unable to prune unknown fields: openapi unavailable")
+}
+
+func TestInstallRelease_PruneUnknownFieldsUnsupported(t *testing.T) {
+	instAction := installAction(t)
+	instAction.cfg.KubeClient = struct{ kube.Interface }{&kubefake.PrintingKubeClient{Out: io.Discard}}
+	instAction.PruneUnknownFields = true
+
+	_, err := instAction.Run(buildChart(), nil)
+	assert.ErrorContains(t, err, "the Kubernetes client is unable to prune unknown fields")
+}
+
+func TestUpgradeRelease_PruneUnknownFields(t *testing.T) {
+	upAction := upgradeAction(t)
+	client := newValidationKubeClient(prunedDeployment)
+	upAction.cfg.KubeClient = client
+	upAction.PruneUnknownFields = true
+
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	res, err := upAction.Run(rel.Name, buildChart(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	require.Len(t, res.Info.PrunedFields, 1)
+	assert.Equal(t, []string{"spec.template.spec.hostUsers"}, res.Info.PrunedFields[0].Paths)
+
+	// The rendered manifest, the current and the target resources are built
+	// without validation, then the pruned resources are validated.
+	require.GreaterOrEqual(t, len(client.validations), 4)
+	assert.Equal(t, []bool{false, false, false, true}, client.validations[:4])
+}
+
+func TestUpgradeRelease_PruneUnknownFieldsWithoutValidation(t *testing.T) {
+	upAction := upgradeAction(t)
+	upAction.PruneUnknownFields = true
+	upAction.DisableOpenAPIValidation = true
+
+	rel := releaseStub()
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	_, err := upAction.Run(rel.Name, buildChart(), nil)
+	assert.ErrorIs(t, err, errPruneWithoutValidation)
+}
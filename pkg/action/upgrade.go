@@ -22,11 +22,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
@@ -62,6 +64,9 @@ type Upgrade struct {
 	Namespace string
 	// SkipCRDs skips installing CRDs when install flag is enabled during upgrade
 	SkipCRDs bool
+	// ShareCRDs shares the CRDs installed by other releases when install flag
+	// is enabled during upgrade.
+	ShareCRDs bool
 	// Timeout is the timeout for this operation
 	Timeout time.Duration
 	// WaitStrategy determines what type of waiting should be done
@@ -70,6 +75,9 @@ type Upgrade struct {
 	WaitForJobs bool
 	// DisableHooks disables hook processing if set to true.
 	DisableHooks bool
+	// SkipHookEvents skips the hooks of these events, which are still
+	// recorded on the release.
+	SkipHookEvents []release.HookEvent
 	// DryRun controls whether the operation is prepared, but not executed.
 	DryRun bool
 	// DryRunOption controls whether the operation is prepared, but not executed with options on whether or not to interact with the remote cluster.
@@ -87,21 +95,61 @@ type Upgrade struct {
 	ReuseValues bool
 	// ResetThenReuseValues will reset the values to the chart's built-ins then merge with user's last supplied values.
 	ResetThenReuseValues bool
+	// SkipValuesMigrations disables the migrations the chart declares in its
+	// values-migrations.yaml file for the reused values of the release.
+	SkipValuesMigrations bool
+	// ExplainChanges renders the chart and values of the current release
+	// again, and lists in the release info the templates whose output the
+	// upgrade changes. It requires a dry run.
+	ExplainChanges bool
+	// ShowOnlyChanged keeps in the manifest of the upgraded release only the
+	// documents which differ from the ones of the current release, flagged
+	// with comments, followed by the documents removed, see
+	// releaseutil.ChangedManifests. The hooks are kept. It requires a dry run.
+	ShowOnlyChanged bool
 	// MaxHistory limits the maximum number of revisions saved per release
 	MaxHistory int
 	// Atomic, if true, will roll back on failure.
 	Atomic bool
+	// AtomicCleanupTimeout is the time given to the rollback after a failed
+	// atomic upgrade. It defaults to Timeout.
+	AtomicCleanupTimeout time.Duration
 	// CleanupOnFail will, if true, cause the upgrade to delete newly-created resources on a failed update.
 	CleanupOnFail bool
 	// SubNotes determines whether sub-notes are rendered in the chart.
 	SubNotes bool
 	// HideNotes determines whether notes are output during upgrade
 	HideNotes bool
+	// PreserveNotesOnFailure keeps, on the release of an upgrade failing
+	// after its resources were applied, the notes of the revision it started
+	// from, which is still serving, prefixed with PreservedNotesMarker. The
+	// notes of the attempted chart are recorded as its pending notes either
+	// way.
+	PreserveNotesOnFailure bool
 	// SkipSchemaValidation determines if JSON schema validation is disabled.
 	SkipSchemaValidation bool
 	// Description is the description of this operation
 	Description string
 	Labels      map[string]string
+	// Annotations are free-form metadata recorded with the release, merged
+	// with the ones of the last release. An annotation set to "null" is
+	// removed. Their keys cannot be empty.
+	Annotations map[string]string
+	// DescriptionIsTemplate renders Description as a Go template, with the
+	// name and namespace of the release, the name, version and app version of
+	// the chart, and an env function.
+	DescriptionIsTemplate bool
+	// ValuesEdited records in the release info that the values were edited
+	// by hand before the upgrade.
+	ValuesEdited bool
+	// Policy replaces the policy of the release, with its tokens stored
+	// hashed. The policy of the release is kept if it is nil.
+	Policy *release.Policy
+	// PolicyOverrides are the tokens given to approve the upgrade, or to
+	// override the policy of the release.
+	PolicyOverrides []string
+	// OverridePin allows the upgrade of a pinned release.
+	OverridePin bool
 	// PostRenderer is an optional post-renderer
 	//
 	// If this is non-nil, then after templates are rendered, they will be sent to the
@@ -109,16 +157,80 @@ type Upgrade struct {
 	PostRenderer postrender.PostRenderer
 	// DisableOpenAPIValidation controls whether OpenAPI validation is enforced.
 	DisableOpenAPIValidation bool
+	// PruneUnknownFields removes from the rendered resources the fields the
+	// cluster does not know, according to its OpenAPI schemas, instead of
+	// failing the validation. The fields removed are listed in the release
+	// info. It cannot be combined with DisableOpenAPIValidation.
+	PruneUnknownFields bool
 	// Get missing dependencies
 	DependencyUpdate bool
 	// Lock to control raceconditions when the process receives a SIGTERM
 	Lock sync.Mutex
 	// Enable DNS lookups when rendering templates
 	EnableDNS bool
+	// StrictTemplate fails the render on the values the templates use which
+	// are not defined, instead of rendering them empty, see engine.Engine.Strict.
+	StrictTemplate bool
 	// TakeOwnership will skip the check for helm annotations and adopt all existing resources.
 	TakeOwnership bool
+	// ConfirmImageChanges, if set, is called once the upgraded release has been
+	// rendered with the container image differences between the deployed and
+	// the upgraded manifests. Returning an error aborts the upgrade before any
+	// change is made to the cluster or to the release history.
+	ConfirmImageChanges func(diff releaseutil.ImageDiff) error
+	// ExpectedManifestHash, if set, aborts the upgrade before any change is
+	// made when the canonical hash of the rendered manifests and hooks differs
+	// from it. It is parsed with releaseutil.ParseManifestHash, and the
+	// templates are rendered deterministically, with RandomSeed.
+	ExpectedManifestHash string
+	// RandomSeed seeds the random values of the templates when they are
+	// rendered deterministically.
+	RandomSeed *int64
+	// ProgressFunc, if set, is called synchronously and in order as the
+	// upgrade goes through its phases. It cannot abort the upgrade.
+	ProgressFunc func(event ProgressEvent)
+	// PruneReport lists the resources the upgrade deletes, because they are
+	// no longer part of the release, in the Pruned field of the release info.
+	PruneReport bool
+	// MaxPruned aborts the upgrade before any change is made if it would
+	// delete more than this many resources, guarding against a release being
+	// wiped by mistake. A negative value means no limit.
+	MaxPruned int
+	// CheckpointInterval is the number of resources applied between two
+	// writes of the progress of the upgrade to its pending release, which
+	// Resume continues from. Zero or less records the progress once all the
+	// resources have been applied.
+	CheckpointInterval int
+	// ServerSideApply selects whether the resources are updated with
+	// server-side apply, with the field manager of the configuration, instead
+	// of client-side patches. It cannot be ServerSideApplyTrue with Force.
+	ServerSideApply ServerSideApplyMode
+	// ForceConflicts takes over the fields managed by other field managers
+	// when the resources are applied server-side.
+	ForceConflicts bool
+	// ForceKinds are the kinds of the resources which are deleted and created
+	// again when they change, rather than patched, as if they had the
+	// "helm.sh/upgrade-strategy: replace" annotation.
+	ForceKinds []string
+
+	// applier applies the resources server-side, unless it is nil.
+	applier kube.InterfaceServerSideApply
+	// description is the description of the release, Description rendered
+	// when DescriptionIsTemplate is set.
+	description string
+	// valuesMigrations are the changes made to the reused values by the
+	// values migrations of the chart.
+	valuesMigrations []string
 }
 
+// PreservedNotesMarker prefixes the notes of a failed release which were kept
+// from the revision the upgrade started from.
+const PreservedNotesMarker = "[notes of the previous revision, which the failed upgrade left in place]\n"
+
+// DefaultCheckpointInterval is the default number of resources applied between
+// two writes of the progress of an upgrade.
+const DefaultCheckpointInterval = 20
+
 type resultMessage struct {
 	r *release.Release
 	e error
@@ -127,7 +239,9 @@ type resultMessage struct {
 // NewUpgrade creates a new Upgrade object with the given configuration.
 func NewUpgrade(cfg *Configuration) *Upgrade {
 	up := &Upgrade{
-		cfg: cfg,
+		cfg:                cfg,
+		MaxPruned:          -1,
+		CheckpointInterval: DefaultCheckpointInterval,
 	}
 	up.registryClient = cfg.RegistryClient
 
@@ -147,6 +261,23 @@ func (u *Upgrade) Run(name string, chart *chart.Chart, vals map[string]interface
 
 // RunWithContext executes the upgrade on the given release with context.
 func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+	startedAt := time.Now()
+	rel, err := u.runWithContext(ctx, name, chart, vals)
+	if u.isDryRun() {
+		return rel, err
+	}
+	// The release may still be written by the upgrade running in the
+	// background if ctx is done.
+	u.Lock.Lock()
+	event := newReleaseEvent(OperationUpgrade, name, u.Namespace, rel, chart, err, startedAt)
+	u.Lock.Unlock()
+	if rerr := u.cfg.notifyRecorder(ctx, event); rerr != nil && err == nil {
+		return rel, rerr
+	}
+	return rel, err
+}
+
+func (u *Upgrade) runWithContext(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -161,15 +292,35 @@ func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.
 		return nil, fmt.Errorf("release name is invalid: %s", name)
 	}
 
-	slog.Debug("preparing upgrade", "name", name)
+	var err error
+	if u.applier, err = u.cfg.serverSideApplier(u.ServerSideApply, u.Force, u.ForceConflicts); err != nil {
+		return nil, err
+	}
+	if err := u.checkForceKinds(); err != nil {
+		return nil, err
+	}
+
+	u.cfg.log().Debug("preparing upgrade", "name", name)
 	currentRelease, upgradedRelease, err := u.prepareUpgrade(name, chart, vals)
 	if err != nil {
 		return nil, err
 	}
 
+	if u.ExpectedManifestHash != "" {
+		if err := u.checkManifestHash(upgradedRelease); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.ConfirmImageChanges != nil {
+		if err := u.confirmImageChanges(currentRelease, upgradedRelease); err != nil {
+			return nil, err
+		}
+	}
+
 	u.cfg.Releases.MaxHistory = u.MaxHistory
 
-	slog.Debug("performing update", "name", name)
+	u.cfg.log().Debug("performing update", "name", name)
 	res, err := u.performUpgrade(ctx, currentRelease, upgradedRelease)
 	if err != nil {
 		return res, err
@@ -177,7 +328,8 @@ func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.
 
 	// Do not update for dry runs
 	if !u.isDryRun() {
-		slog.Debug("updating status for upgraded release", "name", name)
+		u.cfg.log().Debug("updating status for upgraded release", "name", name)
+		u.cfg.emitProgress(u.ProgressFunc, ProgressPersist, name, "")
 		if err := u.cfg.Releases.Update(upgradedRelease); err != nil {
 			return res, err
 		}
@@ -194,6 +346,27 @@ func (u *Upgrade) isDryRun() bool {
 	return false
 }
 
+// applyOptions returns the options of the server-side apply of the resources.
+func (u *Upgrade) applyOptions(dryRun bool) kube.ServerSideApplyOptions {
+	return kube.ServerSideApplyOptions{ForceConflicts: u.ForceConflicts, DryRun: dryRun}
+}
+
+// checkForceKinds checks that the client applying the resources is able to
+// replace the resources of ForceKinds.
+func (u *Upgrade) checkForceKinds() error {
+	if len(u.ForceKinds) == 0 {
+		return nil
+	}
+	var client any = u.cfg.KubeClient
+	if u.applier != nil {
+		client = u.applier
+	}
+	if _, ok := client.(kube.InterfaceUpdateContext); !ok {
+		return errors.New("the Kubernetes client is unable to replace the resources of the forced kinds")
+	}
+	return nil
+}
+
 // prepareUpgrade builds an upgraded release for an upgrade operation.
 func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, *release.Release, error) {
 	if chart == nil {
@@ -205,6 +378,26 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		return nil, nil, errors.New("hiding Kubernetes secrets requires a dry-run mode")
 	}
 
+	if u.PruneUnknownFields && u.DisableOpenAPIValidation {
+		return nil, nil, errPruneWithoutValidation
+	}
+
+	if u.ExpectedManifestHash != "" && u.HideSecret {
+		return nil, nil, errors.New("the manifest hash cannot be checked when hiding Kubernetes secrets")
+	}
+
+	if !u.isDryRun() && u.ExplainChanges {
+		return nil, nil, errors.New("explaining the changes of an upgrade requires a dry-run mode")
+	}
+
+	if !u.isDryRun() && u.ShowOnlyChanged {
+		return nil, nil, errors.New("showing only the changed manifests requires a dry-run mode")
+	}
+
+	if err := validateSkipHookEvents(u.SkipHookEvents); err != nil {
+		return nil, nil, err
+	}
+
 	// finds the last non-deleted release with the given name
 	lastRelease, err := u.cfg.Releases.Last(name)
 	if err != nil {
@@ -220,6 +413,13 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		return nil, nil, errPending
 	}
 
+	if err := checkPin(lastRelease, u.OverridePin); err != nil {
+		return nil, nil, err
+	}
+	if err := u.cfg.checkPolicy(lastRelease, release.PolicyUpgrade, u.PolicyOverrides); err != nil {
+		return nil, nil, err
+	}
+
 	var currentRelease *release.Release
 	if lastRelease.Info.Status == release.StatusDeployed {
 		// no need to retrieve the last deployed release from storage as the last release is deployed
@@ -243,7 +443,12 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		return nil, nil, err
 	}
 
-	if err := chartutil.ProcessDependencies(chart, vals); err != nil {
+	renderVals, namespaceDefaults, err := u.cfg.applyNamespaceDefaults(vals, currentRelease.Namespace, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := chartutil.ProcessDependencies(chart, renderVals); err != nil {
 		return nil, nil, err
 	}
 
@@ -262,7 +467,7 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 	if err != nil {
 		return nil, nil, err
 	}
-	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chart, vals, options, caps, u.SkipSchemaValidation)
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chart, renderVals, options, caps, u.SkipSchemaValidation)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -273,7 +478,8 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		interactWithRemote = true
 	}
 
-	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, interactWithRemote, u.EnableDNS, u.HideSecret)
+	u.cfg.emitProgress(u.ProgressFunc, ProgressRender, name, "")
+	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, interactWithRemote, u.EnableDNS, u.HideSecret, nil, renderOptions{deterministic: u.ExpectedManifestHash != "", randomSeed: u.RandomSeed, strict: u.StrictTemplate})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -281,6 +487,9 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 	if driver.ContainsSystemLabels(u.Labels) {
 		return nil, nil, fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
+	if err := validateAnnotations(u.Annotations); err != nil {
+		return nil, nil, err
+	}
 
 	// Store an upgraded release.
 	upgradedRelease := &release.Release{
@@ -293,43 +502,146 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 			LastDeployed:  Timestamper(),
 			Status:        release.StatusPendingUpgrade,
 			Description:   "Preparing upgrade", // This should be overwritten later.
+			FieldManager:  u.cfg.FieldManager,
+			AppliedBy:     currentOperator(),
+			ValuesEdited:  u.ValuesEdited,
+
+			NamespaceDefaults: namespaceDefaults,
+			ValuesMigrations:  u.valuesMigrations,
 		},
 		Version:  revision,
 		Manifest: manifestDoc.String(),
 		Hooks:    hooks,
 		Labels:   mergeCustomLabels(lastRelease.Labels, u.Labels),
+		Policy:   lastRelease.Policy,
+		Pin:      lastRelease.Pin,
+	}
+	if annotations := mergeCustomLabels(lastRelease.Annotations, u.Annotations); len(annotations) > 0 {
+		upgradedRelease.Annotations = annotations
+	}
+	if u.Policy != nil {
+		upgradedRelease.Policy = u.Policy.WithHashedTokens()
+	}
+	if u.description, err = renderDescription(u.Description, u.DescriptionIsTemplate, upgradedRelease); err != nil {
+		return nil, nil, err
 	}
 
 	if len(notesTxt) > 0 {
 		upgradedRelease.Info.Notes = notesTxt
 	}
-	err = validateManifest(u.cfg.KubeClient, manifestDoc.Bytes(), !u.DisableOpenAPIValidation)
+	if u.ExplainChanges {
+		if upgradedRelease.Info.ChangedTemplates, err = u.explainChanges(currentRelease, chart, valuesToRender, caps, interactWithRemote); err != nil {
+			return nil, nil, err
+		}
+	}
+	// The unknown fields are pruned before the manifest is validated, once
+	// the resources are built.
+	err = validateManifest(u.cfg.KubeClient, manifestDoc.Bytes(), !u.DisableOpenAPIValidation && !u.PruneUnknownFields)
 	return currentRelease, upgradedRelease, err
 }
 
 func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedRelease *release.Release) (*release.Release, error) {
+	current, target, err := u.buildResources(originalRelease, upgradedRelease)
+	if err != nil {
+		return upgradedRelease, err
+	}
+
+	pruned := prunedResources(current, target)
+	if u.MaxPruned >= 0 && len(pruned) > u.MaxPruned {
+		return nil, fmt.Errorf("unable to continue with update: the upgrade would delete %d resources, more than the maximum of %d: %s", len(pruned), u.MaxPruned, strings.Join(pruned, ", "))
+	}
+	if u.PruneReport {
+		upgradedRelease.Info.Pruned = pruned
+	}
+
+	current, err = u.adoptResources(current, target, upgradedRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run if it is a dry run
+	if u.isDryRun() {
+		u.cfg.log().Debug("dry run for release", "name", upgradedRelease.Name)
+		if u.DryRunOption == "server" && u.applier != nil && len(target) > 0 {
+			if _, err := u.applier.UpdateServerSideApply(current, target, u.applyOptions(true)); err != nil {
+				return nil, fmt.Errorf("server-side dry run failed: %w", err)
+			}
+		}
+		if u.ShowOnlyChanged {
+			previous := originalRelease.Manifest
+			if u.HideSecret {
+				// The Secrets are hidden from the rendered manifest, and are
+				// left out of the current one so that they are not shown as
+				// removed either.
+				previous = withoutSecrets(previous)
+			}
+			if upgradedRelease.Manifest, err = releaseutil.ChangedManifests(previous, upgradedRelease.Manifest); err != nil {
+				return nil, err
+			}
+		}
+		if len(u.description) > 0 {
+			upgradedRelease.Info.Description = u.description
+		} else {
+			upgradedRelease.Info.Description = "Dry run complete"
+		}
+		return upgradedRelease, nil
+	}
+
+	digest, err := upgradeDigest(upgradedRelease)
+	if err != nil {
+		return upgradedRelease, err
+	}
+	upgradedRelease.Info.Checkpoint = &release.UpgradeCheckpoint{Digest: digest}
+
+	u.cfg.log().Debug("creating upgraded release", "name", upgradedRelease.Name)
+	if err := u.cfg.Releases.Create(upgradedRelease); err != nil {
+		return nil, err
+	}
+	return u.startUpgrade(ctx, originalRelease, upgradedRelease, current, target)
+}
+
+// buildResources builds the resources of the release being upgraded and of
+// the release it is upgraded to.
+func (u *Upgrade) buildResources(originalRelease, upgradedRelease *release.Release) (kube.ResourceList, kube.ResourceList, error) {
 	current, err := u.cfg.KubeClient.Build(bytes.NewBufferString(originalRelease.Manifest), false)
 	if err != nil {
 		// Checking for removed Kubernetes API error so can provide a more informative error message to the user
 		// Ref: https://github.com/helm/helm/issues/7219
 		if strings.Contains(err.Error(), "unable to recognize \"\": no matches for kind") {
-			return upgradedRelease, fmt.Errorf("current release manifest contains removed kubernetes api(s) for this "+
+			return nil, nil, fmt.Errorf("current release manifest contains removed kubernetes api(s) for this "+
 				"kubernetes version and it is therefore unable to build the kubernetes "+
 				"objects for performing the diff. error from kubernetes: %w", err)
 		}
-		return upgradedRelease, fmt.Errorf("unable to build kubernetes objects from current release manifest: %w", err)
+		return nil, nil, fmt.Errorf("unable to build kubernetes objects from current release manifest: %w", err)
 	}
-	target, err := u.cfg.KubeClient.Build(bytes.NewBufferString(upgradedRelease.Manifest), !u.DisableOpenAPIValidation)
+	// An interrupted upgrade which pruned unknown fields is resumed the
+	// same way.
+	pruneUnknownFields := u.PruneUnknownFields || len(upgradedRelease.Info.PrunedFields) > 0
+	target, err := u.cfg.KubeClient.Build(bytes.NewBufferString(upgradedRelease.Manifest), !u.DisableOpenAPIValidation && !pruneUnknownFields)
 	if err != nil {
-		return upgradedRelease, fmt.Errorf("unable to build kubernetes objects from new release manifest: %w", err)
+		return nil, nil, fmt.Errorf("unable to build kubernetes objects from new release manifest: %w", err)
+	}
+	if pruneUnknownFields {
+		if upgradedRelease.Info.PrunedFields, err = u.cfg.pruneUnknownFields(target); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// It is safe to use force only on target because these are resources currently rendered by the chart.
 	err = target.Visit(setMetadataVisitor(upgradedRelease.Name, upgradedRelease.Namespace, true))
 	if err != nil {
-		return upgradedRelease, err
+		return nil, nil, err
 	}
+	if err := target.Visit(checkWaitConditionVisitor); err != nil {
+		return nil, nil, err
+	}
+	return current, target, nil
+}
 
+// adoptResources checks that the resources of target missing from current
+// can be created, and returns current along with the ones which already exist
+// and belong to the release.
+func (u *Upgrade) adoptResources(current, target kube.ResourceList, upgradedRelease *release.Release) (kube.ResourceList, error) {
 	// Do a basic diff using gvk + name to figure out what new resources are being created so we can validate they don't already exist
 	existingResources := make(map[string]bool)
 	for _, r := range current {
@@ -344,6 +656,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 	}
 
 	var toBeUpdated kube.ResourceList
+	var err error
 	if u.TakeOwnership {
 		toBeUpdated, err = requireAdoption(toBeCreated)
 	} else {
@@ -353,34 +666,26 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 		return nil, fmt.Errorf("unable to continue with update: %w", err)
 	}
 
-	toBeUpdated.Visit(func(r *resource.Info, err error) error {
+	err = toBeUpdated.Visit(func(r *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
 		current.Append(r)
 		return nil
 	})
+	return current, err
+}
 
-	// Run if it is a dry run
-	if u.isDryRun() {
-		slog.Debug("dry run for release", "name", upgradedRelease.Name)
-		if len(u.Description) > 0 {
-			upgradedRelease.Info.Description = u.Description
-		} else {
-			upgradedRelease.Info.Description = "Dry run complete"
-		}
-		return upgradedRelease, nil
-	}
-
-	slog.Debug("creating upgraded release", "name", upgradedRelease.Name)
-	if err := u.cfg.Releases.Create(upgradedRelease); err != nil {
-		return nil, err
-	}
-	rChan := make(chan resultMessage)
-	ctxChan := make(chan resultMessage)
+// startUpgrade applies the upgrade of a release already recorded as pending,
+// until it completes or ctx is done.
+func (u *Upgrade) startUpgrade(ctx context.Context, originalRelease, upgradedRelease *release.Release, current, target kube.ResourceList) (*release.Release, error) {
+	// The channels are buffered so that the result not received, of the
+	// upgrade or of the cancellation, does not block its sender holding u.Lock.
+	rChan := make(chan resultMessage, 1)
+	ctxChan := make(chan resultMessage, 1)
 	doneChan := make(chan interface{})
 	defer close(doneChan)
-	go u.releasingUpgrade(rChan, upgradedRelease, current, target, originalRelease)
+	go u.releasingUpgrade(ctx, rChan, upgradedRelease, current, target, originalRelease)
 	go u.handleContext(ctx, doneChan, ctxChan, upgradedRelease)
 	select {
 	case result := <-rChan:
@@ -393,7 +698,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 // Function used to lock the Mutex, this is important for the case when the atomic flag is set.
 // In that case the upgrade will finish before the rollback is finished so it is necessary to wait for the rollback to finish.
 // The rollback will be trigger by the function failRelease
-func (u *Upgrade) reportToPerformUpgrade(c chan<- resultMessage, rel *release.Release, created kube.ResourceList, err error) {
+func (u *Upgrade) reportToPerformUpgrade(ctx context.Context, c chan<- resultMessage, rel *release.Release, created kube.ResourceList, err error) {
 	u.Lock.Lock()
 	if err != nil {
 		rel, err = u.failRelease(rel, created, err)
@@ -409,54 +714,78 @@ func (u *Upgrade) handleContext(ctx context.Context, done chan interface{}, c ch
 		err := ctx.Err()
 
 		// when the atomic flag is set the ongoing release finish first and doesn't give time for the rollback happens.
-		u.reportToPerformUpgrade(c, upgradedRelease, kube.ResourceList{}, err)
+		u.reportToPerformUpgrade(ctx, c, upgradedRelease, kube.ResourceList{}, err)
 	case <-done:
 		return
 	}
 }
-func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *release.Release, current kube.ResourceList, target kube.ResourceList, originalRelease *release.Release) {
-	// pre-upgrade hooks
+func (u *Upgrade) releasingUpgrade(ctx context.Context, c chan<- resultMessage, upgradedRelease *release.Release, current kube.ResourceList, target kube.ResourceList, originalRelease *release.Release) {
+	// Hooks rendered late see the results of the pre-upgrade hooks from the
+	// post-upgrade ones.
+	hooks := newHookRun(u.PostRenderer, u.EnableDNS)
+	checkpoint := u.checkpoint(upgradedRelease)
 
-	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPreUpgrade, u.WaitStrategy, u.Timeout); err != nil {
-			u.reportToPerformUpgrade(c, upgradedRelease, kube.ResourceList{}, fmt.Errorf("pre-upgrade hooks failed: %s", err))
+	// pre-upgrade hooks
+	switch {
+	case !u.cfg.runsHooks(release.HookPreUpgrade, u.DisableHooks, u.SkipHookEvents):
+		u.cfg.log().Debug("pre-upgrade hooks disabled", "name", upgradedRelease.Name)
+	case checkpoint.PreHooksComplete:
+		u.cfg.log().Debug("pre-upgrade hooks already run", "name", upgradedRelease.Name)
+	default:
+		if err := u.cfg.execHookWithProgress(upgradedRelease, release.HookPreUpgrade, u.WaitStrategy, u.Timeout, u.cfg.hookProgress(u.ProgressFunc, ProgressPreHook, upgradedRelease.Name), hooks); err != nil {
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, kube.ResourceList{}, fmt.Errorf("pre-upgrade hooks failed: %s", err))
 			return
 		}
-	} else {
-		slog.Debug("upgrade hooks disabled", "name", upgradedRelease.Name)
+		if hasHook(upgradedRelease, release.HookPreUpgrade) {
+			u.updateCheckpoint(upgradedRelease, func(checkpoint *release.UpgradeCheckpoint) {
+				checkpoint.PreHooksComplete = true
+			})
+		}
 	}
 
-	results, err := u.cfg.KubeClient.Update(current, target, u.Force)
-	if err != nil {
-		u.cfg.recordRelease(originalRelease)
-		u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
-		return
+	results := &kube.Result{}
+	if checkpoint.ApplyComplete {
+		u.cfg.log().Debug("resources already applied", "name", upgradedRelease.Name)
+	} else {
+		u.cfg.emitApplyProgress(u.ProgressFunc, upgradedRelease.Name, target)
+		var err error
+		results, err = u.applyWithCheckpoints(ctx, upgradedRelease, current, target)
+		if err != nil {
+			u.cfg.recordRelease(originalRelease)
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, err)
+			return
+		}
 	}
 
 	waiter, err := u.cfg.KubeClient.GetWaiter(u.WaitStrategy)
 	if err != nil {
 		u.cfg.recordRelease(originalRelease)
-		u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
+		u.preserveNotes(upgradedRelease, originalRelease)
+		u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, err)
 		return
 	}
+	u.cfg.emitProgress(u.ProgressFunc, ProgressWait, upgradedRelease.Name, "")
 	if u.WaitForJobs {
 		if err := waiter.WaitWithJobs(target, u.Timeout); err != nil {
 			u.cfg.recordRelease(originalRelease)
-			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
+			u.preserveNotes(upgradedRelease, originalRelease)
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, err)
 			return
 		}
 	} else {
 		if err := waiter.Wait(target, u.Timeout); err != nil {
 			u.cfg.recordRelease(originalRelease)
-			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
+			u.preserveNotes(upgradedRelease, originalRelease)
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, err)
 			return
 		}
 	}
 
 	// post-upgrade hooks
-	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPostUpgrade, u.WaitStrategy, u.Timeout); err != nil {
-			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, fmt.Errorf("post-upgrade hooks failed: %s", err))
+	if u.cfg.runsHooks(release.HookPostUpgrade, u.DisableHooks, u.SkipHookEvents) {
+		if err := u.cfg.execHookWithProgress(upgradedRelease, release.HookPostUpgrade, u.WaitStrategy, u.Timeout, u.cfg.hookProgress(u.ProgressFunc, ProgressPostHook, upgradedRelease.Name), hooks); err != nil {
+			u.preserveNotes(upgradedRelease, originalRelease)
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, fmt.Errorf("post-upgrade hooks failed: %s", err))
 			return
 		}
 	}
@@ -464,24 +793,48 @@ func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *rele
 	originalRelease.Info.Status = release.StatusSuperseded
 	u.cfg.recordRelease(originalRelease)
 
+	u.Lock.Lock()
 	upgradedRelease.Info.Status = release.StatusDeployed
-	if len(u.Description) > 0 {
-		upgradedRelease.Info.Description = u.Description
+	if len(u.description) > 0 {
+		upgradedRelease.Info.Description = u.description
 	} else {
 		upgradedRelease.Info.Description = "Upgrade complete"
+		if replaced := replacedResources(results.Replaced); replaced != "" {
+			u.cfg.log().Debug("replaced resources", "name", upgradedRelease.Name, "resources", replaced)
+			upgradedRelease.Info.Description += "; replaced " + replaced
+		}
+	}
+	upgradedRelease.Info.Checkpoint = nil
+	u.Lock.Unlock()
+	u.reportToPerformUpgrade(ctx, c, upgradedRelease, nil, nil)
+}
+
+// preserveNotes records the notes of the upgraded release, which failed after
+// its resources were applied, as pending. When PreserveNotesOnFailure is set,
+// the notes of the original release are kept on the upgraded one.
+func (u *Upgrade) preserveNotes(upgradedRelease, originalRelease *release.Release) {
+	u.Lock.Lock()
+	defer u.Lock.Unlock()
+	upgradedRelease.Info.PendingNotes = upgradedRelease.Info.Notes
+	if !u.PreserveNotesOnFailure {
+		return
+	}
+	upgradedRelease.Info.Notes = ""
+	if originalRelease.Info.Notes != "" {
+		upgradedRelease.Info.Notes = PreservedNotesMarker + originalRelease.Info.Notes
 	}
-	u.reportToPerformUpgrade(c, upgradedRelease, nil, nil)
 }
 
 func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, err error) (*release.Release, error) {
 	msg := fmt.Sprintf("Upgrade %q failed: %s", rel.Name, err)
-	slog.Warn("upgrade failed", "name", rel.Name, slog.Any("error", err))
+	u.cfg.log().Warn("upgrade failed", "name", rel.Name, slog.Any("error", err))
 
 	rel.Info.Status = release.StatusFailed
 	rel.Info.Description = msg
+	rel.Info.Checkpoint = nil
 	u.cfg.recordRelease(rel)
 	if u.CleanupOnFail && len(created) > 0 {
-		slog.Debug("cleanup on fail set", "cleaning_resources", len(created))
+		u.cfg.log().Debug("cleanup on fail set", "cleaning_resources", len(created))
 		_, errs := u.cfg.KubeClient.Delete(created)
 		if errs != nil {
 			return rel, fmt.Errorf(
@@ -493,47 +846,91 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 				),
 			)
 		}
-		slog.Debug("resource cleanup complete")
+		u.cfg.log().Debug("resource cleanup complete")
 	}
 	if u.Atomic {
-		slog.Debug("upgrade failed and atomic is set, rolling back to last successful release")
-
-		// As a protection, get the last successful release before rollback.
-		// If there are no successful releases, bail out
-		hist := NewHistory(u.cfg)
-		fullHistory, herr := hist.Run(rel.Name)
-		if herr != nil {
-			return rel, fmt.Errorf("an error occurred while finding last successful release. original upgrade error: %w: %w", err, herr)
+		cleanup := atomicCleanup{
+			operation: "upgrade",
+			undoing:   "rolling back",
+			undone:    "rolled back",
+			log:       u.cfg.log(),
+			undo: func() error {
+				return u.rollbackToLastSuccessful(rel.Name)
+			},
+			timeout: u.atomicCleanupTimeout(),
 		}
+		return rel, cleanup.run(rel.Name, err)
+	}
 
-		// There isn't a way to tell if a previous release was successful, but
-		// generally failed releases do not get superseded unless the next
-		// release is successful, so this should be relatively safe
-		filteredHistory := releaseutil.FilterFunc(func(r *release.Release) bool {
-			return r.Info.Status == release.StatusSuperseded || r.Info.Status == release.StatusDeployed
-		}).Filter(fullHistory)
-		if len(filteredHistory) == 0 {
-			return rel, fmt.Errorf("unable to find a previously successful release when attempting to rollback. original upgrade error: %w", err)
-		}
+	return rel, err
+}
 
-		releaseutil.Reverse(filteredHistory, releaseutil.SortByRevision)
+// withoutSecrets returns the manifest without its documents which are
+// Kubernetes Secrets, the ones HideSecret hides from the rendered manifest.
+func withoutSecrets(manifest string) string {
+	split := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(split))
+	for k := range split {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
 
-		rollin := NewRollback(u.cfg)
-		rollin.Version = filteredHistory[0].Version
-		if u.WaitStrategy == kube.HookOnlyStrategy {
-			rollin.WaitStrategy = kube.StatusWatcherStrategy
-		}
-		rollin.WaitForJobs = u.WaitForJobs
-		rollin.DisableHooks = u.DisableHooks
-		rollin.Force = u.Force
-		rollin.Timeout = u.Timeout
-		if rollErr := rollin.Run(rel.Name); rollErr != nil {
-			return rel, fmt.Errorf("an error occurred while rolling back the release. original upgrade error: %w: %w", err, rollErr)
+	var b strings.Builder
+	for _, k := range keys {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(split[k]), &head); err == nil && head.Kind == "Secret" && head.Version == "v1" {
+			continue
 		}
-		return rel, fmt.Errorf("release %s failed, and has been rolled back due to atomic being set: %w", rel.Name, err)
+		fmt.Fprintf(&b, "---\n%s\n", split[k])
 	}
+	return b.String()
+}
 
-	return rel, err
+// rollbackToLastSuccessful rolls the release back to its last successfully
+// deployed revision.
+func (u *Upgrade) rollbackToLastSuccessful(name string) error {
+	// As a protection, get the last successful release before rollback.
+	// If there are no successful releases, bail out
+	hist := NewHistory(u.cfg)
+	fullHistory, err := hist.Run(name)
+	if err != nil {
+		return fmt.Errorf("an error occurred while finding last successful release: %w", err)
+	}
+
+	// There isn't a way to tell if a previous release was successful, but
+	// generally failed releases do not get superseded unless the next
+	// release is successful, so this should be relatively safe
+	filteredHistory := releaseutil.FilterFunc(func(r *release.Release) bool {
+		return r.Info.Status == release.StatusSuperseded || r.Info.Status == release.StatusDeployed
+	}).Filter(fullHistory)
+	if len(filteredHistory) == 0 {
+		return errors.New("unable to find a previously successful release")
+	}
+
+	releaseutil.Reverse(filteredHistory, releaseutil.SortByRevision)
+
+	rollin := NewRollback(u.cfg)
+	rollin.Version = filteredHistory[0].Version
+	if u.WaitStrategy == kube.HookOnlyStrategy {
+		rollin.WaitStrategy = kube.StatusWatcherStrategy
+	}
+	rollin.WaitForJobs = u.WaitForJobs
+	rollin.DisableHooks = u.DisableHooks
+	rollin.SkipHookEvents = u.SkipHookEvents
+	rollin.Force = u.Force
+	rollin.OverridePin = u.OverridePin
+	rollin.PolicyOverrides = u.PolicyOverrides
+	rollin.Timeout = u.atomicCleanupTimeout()
+	return rollin.Run(name)
+}
+
+// atomicCleanupTimeout returns the time given to the rollback of a failed
+// atomic upgrade.
+func (u *Upgrade) atomicCleanupTimeout() time.Duration {
+	if u.AtomicCleanupTimeout > 0 {
+		return u.AtomicCleanupTimeout
+	}
+	return u.Timeout
 }
 
 // reuseValues copies values from the current release to a new release if the
@@ -547,21 +944,26 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newVals map[string]interface{}) (map[string]interface{}, error) {
 	if u.ResetValues {
 		// If ResetValues is set, we completely ignore current.Config.
-		slog.Debug("resetting values to the chart's original version")
+		u.cfg.log().Debug("resetting values to the chart's original version")
 		return newVals, nil
 	}
 
 	// If the ReuseValues flag is set, we always copy the old values over the new config's values.
 	if u.ReuseValues {
-		slog.Debug("reusing the old release's values")
+		u.cfg.log().Debug("reusing the old release's values")
+
+		config, err := u.migrateValues(chart, current)
+		if err != nil {
+			return nil, err
+		}
 
 		// We have to regenerate the old coalesced values:
-		oldVals, err := chartutil.CoalesceValues(current.Chart, current.Config)
+		oldVals, err := chartutil.CoalesceValues(current.Chart, config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to rebuild old values: %w", err)
 		}
 
-		newVals = chartutil.CoalesceTables(newVals, current.Config)
+		newVals = chartutil.CoalesceTables(newVals, config)
 
 		chart.Values = oldVals
 
@@ -570,20 +972,100 @@ func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newV
 
 	// If the ResetThenReuseValues flag is set, we use the new chart's values, but we copy the old config's values over the new config's values.
 	if u.ResetThenReuseValues {
-		slog.Debug("merging values from old release to new values")
+		u.cfg.log().Debug("merging values from old release to new values")
+
+		config, err := u.migrateValues(chart, current)
+		if err != nil {
+			return nil, err
+		}
 
-		newVals = chartutil.CoalesceTables(newVals, current.Config)
+		newVals = chartutil.CoalesceTables(newVals, config)
 
 		return newVals, nil
 	}
 
 	if len(newVals) == 0 && len(current.Config) > 0 {
-		slog.Debug("copying values from old release", "name", current.Name, "version", current.Version)
+		u.cfg.log().Debug("copying values from old release", "name", current.Name, "version", current.Version)
 		newVals = current.Config
 	}
 	return newVals, nil
 }
 
+// migrateValues applies the values migrations of chart to the values of the
+// current release, and returns the migrated values. The changes made are
+// recorded in u.valuesMigrations.
+func (u *Upgrade) migrateValues(chart *chart.Chart, current *release.Release) (map[string]interface{}, error) {
+	u.valuesMigrations = nil
+	if u.SkipValuesMigrations {
+		return current.Config, nil
+	}
+	migrations, err := chartutil.LoadValuesMigrations(chart)
+	if err != nil || migrations == nil {
+		return current.Config, err
+	}
+
+	var fromVersion string
+	if current.Chart != nil && current.Chart.Metadata != nil {
+		fromVersion = current.Chart.Metadata.Version
+	}
+	config, applied, err := migrations.Apply(current.Config, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate the values of release %q: %w", current.Name, err)
+	}
+	for _, change := range applied {
+		u.cfg.log().Debug("migrated values", "name", current.Name, "change", change)
+	}
+	u.valuesMigrations = applied
+	return config, nil
+}
+
+// confirmImageChanges hands the container image differences between the two
+// releases to u.ConfirmImageChanges.
+func (u *Upgrade) confirmImageChanges(current, upgraded *release.Release) error {
+	currentImages, err := releaseutil.ExtractImages(current.Manifest)
+	if err != nil {
+		return fmt.Errorf("unable to extract images from current release manifest: %w", err)
+	}
+	upgradedImages, err := releaseutil.ExtractImages(upgraded.Manifest)
+	if err != nil {
+		return fmt.Errorf("unable to extract images from new release manifest: %w", err)
+	}
+	return u.ConfirmImageChanges(releaseutil.DiffImages(currentImages, upgradedImages))
+}
+
+// checkManifestHash compares the canonical hash of the upgraded release with
+// u.ExpectedManifestHash, listing the documents which differ.
+func (u *Upgrade) checkManifestHash(upgraded *release.Release) error {
+	expected, err := releaseutil.ParseManifestHash(u.ExpectedManifestHash)
+	if err != nil {
+		return fmt.Errorf("invalid expected manifest hash: %w", err)
+	}
+	actual, err := releaseutil.HashRelease(upgraded)
+	if err != nil {
+		return fmt.Errorf("unable to hash the rendered manifests: %w", err)
+	}
+	if actual.Sum == expected.Sum {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "unable to continue with upgrade: the rendered manifests have hash %s, expected %s", actual.Sum, expected.Sum)
+	if len(expected.Documents) == 0 {
+		b.WriteString("; pass the output of 'helm template --emit-manifest-hash' to list the documents which differ")
+		return errors.New(b.String())
+	}
+	diff := actual.Diff(expected)
+	for _, d := range []struct {
+		what string
+		ids  []string
+	}{{"added", diff.Added}, {"removed", diff.Removed}, {"changed", diff.Changed}} {
+		if len(d.ids) > 0 {
+			fmt.Fprintf(&b, "\n%s: %s", d.what, strings.Join(d.ids, ", "))
+		}
+	}
+	return errors.New(b.String())
+}
+
 func validateManifest(c kube.Interface, manifest []byte, openAPIValidation bool) error {
 	_, err := c.Build(bytes.NewReader(manifest), openAPIValidation)
 	return err
@@ -594,6 +1076,15 @@ func objectKey(r *resource.Info) string {
 	return fmt.Sprintf("%s/%s/%s/%s", gvk.GroupVersion().String(), gvk.Kind, r.Namespace, r.Name)
 }
 
+// validateAnnotations checks the annotations given to a release, whose keys
+// cannot be empty.
+func validateAnnotations(annotations map[string]string) error {
+	if _, ok := annotations[""]; ok {
+		return errors.New("release annotation keys cannot be empty")
+	}
+	return nil
+}
+
 func mergeCustomLabels(current, desired map[string]string) map[string]string {
 	labels := mergeStrStrMaps(current, desired)
 	for k, v := range labels {
@@ -603,3 +1094,19 @@ func mergeCustomLabels(current, desired map[string]string) map[string]string {
 	}
 	return labels
 }
+
+// prunedResources lists, as "Kind/name", the resources of the current release
+// which the upgrade to target deletes. Resources annotated to be kept are not
+// deleted.
+func prunedResources(current, target kube.ResourceList) []string {
+	var pruned []string
+	for _, info := range current.Difference(target) {
+		if info.Object != nil {
+			if annotations, err := accessor.Annotations(info.Object); err == nil && annotations[kube.ResourcePolicyAnno] == kube.KeepPolicy {
+				continue
+			}
+		}
+		pruned = append(pruned, fmt.Sprintf("%s/%s", info.Mapping.GroupVersionKind.Kind, info.Name))
+	}
+	return pruned
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// explainChanges renders the stored chart of the current release with its
+// values again, and returns the paths of the templates of the upgraded chart,
+// rendered with values, whose output differs, including the templates added
+// or removed. Templates using random or time functions always differ.
+func (u *Upgrade) explainChanges(current *release.Release, chrt *chart.Chart, values chartutil.Values, caps *chartutil.Capabilities, interactWithRemote bool) ([]string, error) {
+	previous := current.Chart
+	if previous == nil || previous.Metadata == nil {
+		return nil, fmt.Errorf("unable to explain the changes of the upgrade: the chart of revision %d of release %q is not stored", current.Version, current.Name)
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      current.Name,
+		Namespace: current.Namespace,
+		Revision:  current.Version,
+		IsInstall: current.Version == 1,
+		IsUpgrade: current.Version > 1,
+	}
+	previousValues, err := chartutil.ToRenderValues(previous, current.Config, options, caps)
+	if err != nil {
+		return nil, fmt.Errorf("unable to explain the changes of the upgrade: %w", err)
+	}
+	before, err := u.cfg.renderTemplates(previous, previousValues, nil, interactWithRemote, u.EnableDNS, renderOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to explain the changes of the upgrade: rendering revision %d failed: %w", current.Version, err)
+	}
+	after, err := u.cfg.renderTemplates(chrt, values, nil, interactWithRemote, u.EnableDNS, renderOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// The subcharts are not stored with the chart of a release, unless the
+	// storage keeps the release in memory. Their templates cannot be compared
+	// then.
+	compared := func(string) bool { return true }
+	if len(previous.Dependencies()) == 0 && len(previous.Metadata.Dependencies) > 0 {
+		u.cfg.log().Warn("the subcharts of the current release are not stored: only the templates of the chart are compared", "release", current.Name, "revision", current.Version)
+		compared = func(path string) bool { return !isSubchartTemplate(path) }
+	}
+
+	var changed []string
+	for path, output := range after {
+		if previousOutput, ok := before[path]; compared(path) && (!ok || previousOutput != output) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; compared(path) && !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// isSubchartTemplate reports whether the path of a rendered template, which
+// starts with the name of the chart, is the path of a template of a subchart.
+func isSubchartTemplate(path string) bool {
+	_, rest, _ := strings.Cut(path, "/")
+	return strings.HasPrefix(rest, "charts/")
+}
@@ -0,0 +1,272 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/kube"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// Resume continues the upgrade of the named release from where it was
+// interrupted, as recorded in the checkpoint of its pending release: the
+// pre-upgrade hooks are not run again if they completed, and the resources
+// already applied are only patched if their live state differs from the one
+// of the release.
+//
+// Resume refuses to continue an upgrade whose pending release no longer
+// matches its checkpoint.
+func (u *Upgrade) Resume(ctx context.Context, name string) (*release.Release, error) {
+	startedAt := time.Now()
+	rel, err := u.resume(ctx, name)
+	event := newReleaseEvent(OperationUpgrade, name, u.Namespace, rel, nil, err, startedAt)
+	if rerr := u.cfg.notifyRecorder(ctx, event); rerr != nil && err == nil {
+		return rel, rerr
+	}
+	return rel, err
+}
+
+func (u *Upgrade) resume(ctx context.Context, name string) (*release.Release, error) {
+	if u.isDryRun() {
+		return nil, errors.New("an interrupted upgrade cannot be resumed in dry-run mode")
+	}
+	if err := u.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	// Make sure if Atomic is set, that wait is set as well. This makes it so
+	// the user doesn't have to specify both
+	if u.WaitStrategy == kube.HookOnlyStrategy && u.Atomic {
+		u.WaitStrategy = kube.StatusWatcherStrategy
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+
+	var err error
+	if u.applier, err = u.cfg.serverSideApplier(u.ServerSideApply, u.Force, u.ForceConflicts); err != nil {
+		return nil, err
+	}
+	if err := u.checkForceKinds(); err != nil {
+		return nil, err
+	}
+
+	pendingRelease, err := u.cfg.Releases.Last(name)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := pendingRelease.Info.Checkpoint
+	if pendingRelease.Info.Status != release.StatusPendingUpgrade || checkpoint == nil {
+		return nil, fmt.Errorf("release %q has no interrupted upgrade to resume", name)
+	}
+	digest, err := upgradeDigest(pendingRelease)
+	if err != nil {
+		return nil, err
+	}
+	if digest != checkpoint.Digest {
+		return nil, fmt.Errorf("revision %d of release %q does not match its checkpoint, refusing to resume it; recover the release with 'helm recover' instead", pendingRelease.Version, name)
+	}
+
+	originalRelease, err := u.cfg.Releases.Deployed(name)
+	if err != nil {
+		originalRelease, err = u.cfg.Releases.Get(name, pendingRelease.Version-1)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the revision release %q is upgraded from: %w", name, err)
+		}
+	}
+
+	current, target, err := u.buildResources(originalRelease, pendingRelease)
+	if err != nil {
+		return nil, err
+	}
+	current, err = u.adoptResources(current, target, pendingRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	u.cfg.Releases.MaxHistory = u.MaxHistory
+
+	u.cfg.log().Debug("resuming upgrade", "name", name, "revision", pendingRelease.Version, "applied", len(checkpoint.Applied))
+	res, err := u.startUpgrade(ctx, originalRelease, pendingRelease, current, target)
+	if err != nil {
+		return res, err
+	}
+
+	u.cfg.log().Debug("updating status for upgraded release", "name", name)
+	u.cfg.emitProgress(u.ProgressFunc, ProgressPersist, name, "")
+	if err := u.cfg.Releases.Update(pendingRelease); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// upgradeDigest identifies the chart, values and manifest a release is
+// upgraded to. The hooks are left out, as their state changes as they run.
+func upgradeDigest(rel *release.Release) (string, error) {
+	var metadata *chart.Metadata
+	if rel.Chart != nil {
+		metadata = rel.Chart.Metadata
+	}
+	data, err := json.Marshal(struct {
+		Metadata *chart.Metadata        `json:"metadata"`
+		Config   map[string]interface{} `json:"config"`
+		Manifest string                 `json:"manifest"`
+	}{metadata, rel.Config, rel.Manifest})
+	if err != nil {
+		return "", fmt.Errorf("unable to compute the digest of release %q: %w", rel.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// checkpoint returns a copy of the checkpoint of rel, or an empty one if it
+// has none.
+func (u *Upgrade) checkpoint(rel *release.Release) release.UpgradeCheckpoint {
+	u.Lock.Lock()
+	defer u.Lock.Unlock()
+	if rel.Info.Checkpoint == nil {
+		return release.UpgradeCheckpoint{}
+	}
+	checkpoint := *rel.Info.Checkpoint
+	checkpoint.Applied = slices.Clone(checkpoint.Applied)
+	return checkpoint
+}
+
+// updateCheckpoint changes the checkpoint of rel with update and writes it to
+// the storage, unless the upgrade is no longer pending. A failed write does
+// not fail the upgrade, which can still complete without it.
+func (u *Upgrade) updateCheckpoint(rel *release.Release, update func(*release.UpgradeCheckpoint)) {
+	u.Lock.Lock()
+	defer u.Lock.Unlock()
+	if rel.Info.Checkpoint == nil || rel.Info.Status != release.StatusPendingUpgrade {
+		return
+	}
+	update(rel.Info.Checkpoint)
+	if err := u.cfg.Releases.Update(rel); err != nil {
+		u.cfg.log().Warn("unable to record the progress of the upgrade", "name", rel.Name, slog.Any("error", err))
+	}
+}
+
+// applyWithCheckpoints applies target in batches of u.CheckpointInterval
+// resources, and records the resources applied in the checkpoint of rel after
+// each of them. The resources of current missing from target are deleted with
+// the last batch.
+//
+// The resources the checkpoint records as applied are patched from their
+// target configuration, so that they are left alone when their live state
+// matches it.
+//
+// The deletion of the resources replaced rather than patched is waited for
+// until ctx is done or the upgrade times out, if the Kubernetes client
+// supports it.
+func (u *Upgrade) applyWithCheckpoints(ctx context.Context, rel *release.Release, current, target kube.ResourceList) (*kube.Result, error) {
+	if u.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.Timeout)
+		defer cancel()
+	}
+
+	applied := make(map[string]bool)
+	for _, key := range u.checkpoint(rel).Applied {
+		applied[key] = true
+	}
+
+	batches := []kube.ResourceList{target}
+	if u.CheckpointInterval > 0 && len(target) > u.CheckpointInterval {
+		batches = nil
+		for batch := range slices.Chunk(target, u.CheckpointInterval) {
+			batches = append(batches, batch)
+		}
+	}
+
+	// The resources of ForceKinds are replaced without being annotated, so
+	// that the live resources keep the upgrade strategy of the chart.
+	replace := resourcesOfKinds(target, u.ForceKinds)
+
+	results := &kube.Result{}
+	for i, batch := range batches {
+		var original kube.ResourceList
+		for _, info := range batch {
+			if applied[objectKey(info)] {
+				original.Append(info)
+			} else if currentInfo := current.Get(info); currentInfo != nil {
+				original.Append(currentInfo)
+			}
+		}
+		last := i == len(batches)-1
+		if last {
+			original = append(original, current.Difference(target)...)
+		}
+
+		var res *kube.Result
+		var err error
+		if u.applier != nil {
+			if applier, ok := u.applier.(kube.InterfaceUpdateContext); ok {
+				res, err = applier.UpdateServerSideApplyWithContext(ctx, original, batch, replace, u.applyOptions(false))
+			} else {
+				res, err = u.applier.UpdateServerSideApply(original, batch, u.applyOptions(false))
+			}
+		} else if kubeClient, ok := u.cfg.KubeClient.(kube.InterfaceUpdateContext); ok {
+			res, err = kubeClient.UpdateWithContext(ctx, original, batch, replace, u.Force)
+		} else {
+			res, err = u.cfg.KubeClient.Update(original, batch, u.Force)
+		}
+		if res != nil {
+			results.Created = append(results.Created, res.Created...)
+			results.Updated = append(results.Updated, res.Updated...)
+			results.Deleted = append(results.Deleted, res.Deleted...)
+			results.Replaced = append(results.Replaced, res.Replaced...)
+		}
+		if err != nil {
+			return results, err
+		}
+
+		keys := make([]string, 0, len(batch))
+		for _, info := range batch {
+			if !applied[objectKey(info)] {
+				keys = append(keys, objectKey(info))
+			}
+		}
+		u.updateCheckpoint(rel, func(checkpoint *release.UpgradeCheckpoint) {
+			checkpoint.Applied = append(checkpoint.Applied, keys...)
+			checkpoint.ApplyComplete = last
+		})
+	}
+	return results, nil
+}
+
+// hasHook reports whether rel has a hook for event.
+func hasHook(rel *release.Release, event release.HookEvent) bool {
+	for _, h := range rel.Hooks {
+		if slices.Contains(h.Events, event) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
+)
+
+// checkpointUpdate is a call to the Update method of checkpointKubeClient.
+type checkpointUpdate struct {
+	original, target []string
+	// applied is the number of resources recorded as applied when the call
+	// was made.
+	applied int
+}
+
+// checkpointKubeClient records the resources each call to Update is given,
+// along with the checkpoint stored at that time.
+type checkpointKubeClient struct {
+	manifestKubeClient
+	releases *storage.Storage
+	name     string
+	updates  []checkpointUpdate
+}
+
+func (c *checkpointKubeClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	update := checkpointUpdate{original: resourceNames(original), target: resourceNames(target)}
+	if last, err := c.releases.Last(c.name); err == nil && last.Info.Checkpoint != nil {
+		update.applied = len(last.Info.Checkpoint.Applied)
+	}
+	c.updates = append(c.updates, update)
+	return c.manifestKubeClient.Update(original, target, force)
+}
+
+func resourceNames(resources kube.ResourceList) []string {
+	var names []string
+	for _, r := range resources {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// checkpointUpgradeAction returns an upgrade of a release with the ConfigMaps
+// "a" to "f" to a chart which renders "a" to "e".
+func checkpointUpgradeAction(t *testing.T) (*Upgrade, *checkpointKubeClient, *chart.Chart) {
+	t.Helper()
+	upAction := upgradeAction(t)
+	client := &checkpointKubeClient{
+		manifestKubeClient: manifestKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}},
+		releases:           upAction.cfg.Releases,
+		name:               "checkpoint-release",
+	}
+	upAction.cfg.KubeClient = client
+	upAction.CheckpointInterval = 2
+
+	rel := releaseStub()
+	rel.Name = "checkpoint-release"
+	rel.Manifest = configMapManifest("a", "b", "c", "d", "e", "f")
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/configmaps.yaml", Data: []byte(configMapManifest("a", "b", "c", "d", "e"))},
+	})
+	return upAction, client, ch
+}
+
+// pendingUpgrade records revision 2 of the release of upAction as an upgrade
+// to ch interrupted at checkpoint.
+func pendingUpgrade(t *testing.T, upAction *Upgrade, ch *chart.Chart, checkpoint release.UpgradeCheckpoint) *release.Release {
+	t.Helper()
+	rel := namedReleaseStub("checkpoint-release", release.StatusPendingUpgrade)
+	rel.Version = 2
+	rel.Chart = ch
+	rel.Manifest = configMapManifest("a", "b", "c", "d", "e")
+	rel.Hooks = []*release.Hook{
+		{Name: "pre", Kind: "ConfigMap", Path: "pre", Manifest: manifestWithHook, Events: []release.HookEvent{release.HookPreUpgrade}},
+		{Name: "post", Kind: "ConfigMap", Path: "post", Manifest: manifestWithHook, Events: []release.HookEvent{release.HookPostUpgrade}},
+	}
+	digest, err := upgradeDigest(rel)
+	require.NoError(t, err)
+	checkpoint.Digest = digest
+	rel.Info.Checkpoint = &checkpoint
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+	return rel
+}
+
+func TestUpgradeRelease_Checkpoints(t *testing.T) {
+	upAction, client, ch := checkpointUpgradeAction(t)
+
+	res, err := upAction.Run("checkpoint-release", ch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	assert.Nil(t, res.Info.Checkpoint)
+
+	assert.Equal(t, []checkpointUpdate{
+		{original: []string{"a", "b"}, target: []string{"a", "b"}, applied: 0},
+		{original: []string{"c", "d"}, target: []string{"c", "d"}, applied: 2},
+		{original: []string{"e", "f"}, target: []string{"e"}, applied: 4},
+	}, client.updates)
+
+	stored, err := upAction.cfg.Releases.Get("checkpoint-release", 2)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Info.Checkpoint)
+}
+
+func TestUpgradeRelease_CheckpointsSingleBatch(t *testing.T) {
+	upAction, client, ch := checkpointUpgradeAction(t)
+	upAction.CheckpointInterval = DefaultCheckpointInterval
+
+	_, err := upAction.Run("checkpoint-release", ch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []checkpointUpdate{
+		{original: []string{"a", "b", "c", "d", "e", "f"}, target: []string{"a", "b", "c", "d", "e"}},
+	}, client.updates)
+}
+
+func TestUpgradeRelease_CheckpointClearedOnFailure(t *testing.T) {
+	upAction, _, ch := checkpointUpgradeAction(t)
+	upAction.cfg.KubeClient = &kubefake.FailingKubeClient{
+		PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard},
+		UpdateError:        errors.New("connection lost"),
+	}
+
+	res, err := upAction.Run("checkpoint-release", ch, nil)
+	require.Error(t, err)
+	assert.Equal(t, release.StatusFailed, res.Info.Status)
+	assert.Nil(t, res.Info.Checkpoint)
+}
+
+func TestUpgradeResume(t *testing.T) {
+	upAction, client, ch := checkpointUpgradeAction(t)
+	pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{
+		PreHooksComplete: true,
+		Applied:          []string{"v1/ConfigMap//a", "v1/ConfigMap//b", "v1/ConfigMap//c"},
+	})
+
+	res, err := upAction.Resume(t.Context(), "checkpoint-release")
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	assert.Equal(t, 2, res.Version)
+	assert.Nil(t, res.Info.Checkpoint)
+
+	// The pre-upgrade hooks are not run again, the post-upgrade ones are.
+	assert.True(t, res.Hooks[0].LastRun.StartedAt.IsZero())
+	assert.False(t, res.Hooks[1].LastRun.StartedAt.IsZero())
+
+	assert.Equal(t, []checkpointUpdate{
+		{original: []string{"a", "b"}, target: []string{"a", "b"}, applied: 3},
+		{original: []string{"c", "d"}, target: []string{"c", "d"}, applied: 3},
+		{original: []string{"e", "f"}, target: []string{"e"}, applied: 4},
+	}, client.updates)
+
+	stored, err := upAction.cfg.Releases.Get("checkpoint-release", 2)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, stored.Info.Status)
+	previous, err := upAction.cfg.Releases.Get("checkpoint-release", 1)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusSuperseded, previous.Info.Status)
+}
+
+func TestUpgradeResume_PatchesAppliedResourcesFromTarget(t *testing.T) {
+	upAction, _, ch := checkpointUpgradeAction(t)
+	var originals []kube.ResourceList
+	client := &resumeKubeClient{
+		manifestKubeClient: manifestKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}},
+		originals:          &originals,
+	}
+	upAction.cfg.KubeClient = client
+	pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{Applied: []string{"v1/ConfigMap//a"}})
+
+	_, err := upAction.Resume(t.Context(), "checkpoint-release")
+	require.NoError(t, err)
+
+	require.NotEmpty(t, originals)
+	// "a" was applied: it is patched from its target configuration, which
+	// carries the release metadata, rather than from the previous release.
+	assert.Equal(t, "Helm", originals[0][0].Object.(*unstructured.Unstructured).GetLabels()[appManagedByLabel])
+	assert.Empty(t, originals[0][1].Object.(*unstructured.Unstructured).GetLabels())
+}
+
+// resumeKubeClient records the original resources given to Update.
+type resumeKubeClient struct {
+	manifestKubeClient
+	originals *[]kube.ResourceList
+}
+
+func (c *resumeKubeClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	*c.originals = append(*c.originals, original)
+	return c.manifestKubeClient.Update(original, target, force)
+}
+
+func TestUpgradeResume_ApplyComplete(t *testing.T) {
+	upAction, client, ch := checkpointUpgradeAction(t)
+	pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{PreHooksComplete: true, ApplyComplete: true})
+
+	res, err := upAction.Resume(t.Context(), "checkpoint-release")
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	assert.Empty(t, client.updates)
+	assert.False(t, res.Hooks[1].LastRun.StartedAt.IsZero())
+}
+
+func TestUpgradeResume_RunsIncompletePreHooks(t *testing.T) {
+	upAction, _, ch := checkpointUpgradeAction(t)
+	pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{})
+
+	res, err := upAction.Resume(t.Context(), "checkpoint-release")
+	require.NoError(t, err)
+	assert.False(t, res.Hooks[0].LastRun.StartedAt.IsZero())
+}
+
+func TestUpgradeResume_DigestMismatch(t *testing.T) {
+	upAction, client, ch := checkpointUpgradeAction(t)
+	rel := pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{})
+	rel.Config = map[string]interface{}{"name": "changed"}
+	require.NoError(t, upAction.cfg.Releases.Update(rel))
+
+	_, err := upAction.Resume(t.Context(), "checkpoint-release")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `revision 2 of release "checkpoint-release" does not match its checkpoint, refusing to resume it`)
+	assert.Contains(t, err.Error(), "helm recover")
+	assert.Empty(t, client.updates)
+
+	stored, err := upAction.cfg.Releases.Get("checkpoint-release", 2)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusPendingUpgrade, stored.Info.Status)
+}
+
+func TestUpgradeResume_NothingToResume(t *testing.T) {
+	upAction, _, ch := checkpointUpgradeAction(t)
+
+	_, err := upAction.Resume(t.Context(), "checkpoint-release")
+	assert.EqualError(t, err, `release "checkpoint-release" has no interrupted upgrade to resume`)
+
+	// A pending upgrade recorded by a version of Helm without checkpoints
+	// cannot be resumed either.
+	rel := pendingUpgrade(t, upAction, ch, release.UpgradeCheckpoint{})
+	rel.Info.Checkpoint = nil
+	require.NoError(t, upAction.cfg.Releases.Update(rel))
+	_, err = upAction.Resume(t.Context(), "checkpoint-release")
+	assert.EqualError(t, err, `release "checkpoint-release" has no interrupted upgrade to resume`)
+}
+
+func TestUpgradeResume_DryRun(t *testing.T) {
+	upAction, _, _ := checkpointUpgradeAction(t)
+	upAction.DryRun = true
+
+	_, err := upAction.Resume(t.Context(), "checkpoint-release")
+	assert.ErrorContains(t, err, "cannot be resumed in dry-run mode")
+}
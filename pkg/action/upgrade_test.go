@@ -19,11 +19,23 @@ package action
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
+
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/kube"
 	"helm.sh/helm/v4/pkg/storage/driver"
 
@@ -31,6 +43,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 	helmtime "helm.sh/helm/v4/pkg/time"
 )
@@ -49,6 +62,7 @@ func TestUpgradeRelease_Success(t *testing.T) {
 	req := require.New(t)
 
 	upAction := upgradeAction(t)
+	upAction.cfg.FieldManager = "argocd-controller"
 	rel := releaseStub()
 	rel.Name = "previous-release"
 	rel.Info.Status = release.StatusDeployed
@@ -62,6 +76,7 @@ func TestUpgradeRelease_Success(t *testing.T) {
 	done()
 	req.NoError(err)
 	is.Equal(res.Info.Status, release.StatusDeployed)
+	is.Equal("argocd-controller", res.Info.FieldManager)
 
 	// Detecting previous bug where context termination after successful release
 	// caused release to fail.
@@ -93,6 +108,58 @@ func TestUpgradeRelease_Wait(t *testing.T) {
 	is.Equal(res.Info.Status, release.StatusFailed)
 }
 
+func TestUpgradeRelease_PreserveNotesOnFailure(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "come-fail-away"
+	rel.Info.Status = release.StatusDeployed
+	rel.Info.Notes = "old notes"
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = fmt.Errorf("I timed out")
+	upAction.cfg.KubeClient = failer
+	upAction.WaitStrategy = kube.StatusWatcherStrategy
+	upAction.PreserveNotesOnFailure = true
+
+	res, err := upAction.Run(rel.Name, buildChart(withNotes("new notes")), map[string]interface{}{})
+	req.Error(err)
+	is.Equal(release.StatusFailed, res.Info.Status)
+	is.Equal(PreservedNotesMarker+"old notes", res.Info.Notes)
+	is.Equal("new notes", res.Info.PendingNotes)
+
+	stored, err := upAction.cfg.Releases.Get(rel.Name, 2)
+	req.NoError(err)
+	is.Equal(PreservedNotesMarker+"old notes", stored.Info.Notes)
+	is.Equal("new notes", stored.Info.PendingNotes)
+}
+
+func TestUpgradeRelease_NotesOnFailure(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "come-fail-away"
+	rel.Info.Status = release.StatusDeployed
+	rel.Info.Notes = "old notes"
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = fmt.Errorf("I timed out")
+	upAction.cfg.KubeClient = failer
+	upAction.WaitStrategy = kube.StatusWatcherStrategy
+
+	res, err := upAction.Run(rel.Name, buildChart(withNotes("new notes")), map[string]interface{}{})
+	req.Error(err)
+	is.Equal(release.StatusFailed, res.Info.Status)
+	is.Equal("new notes", res.Info.Notes)
+	is.Equal("new notes", res.Info.PendingNotes)
+}
+
 func TestUpgradeRelease_WaitForJobs(t *testing.T) {
 	is := assert.New(t)
 	req := require.New(t)
@@ -192,6 +259,86 @@ func TestUpgradeRelease_Atomic(t *testing.T) {
 	})
 }
 
+func TestUpgradeRelease_ValuesMigrations(t *testing.T) {
+	migrations := `migrations:
+  - name: storage
+    fromVersion: "<0.2.0"
+    operations:
+      - op: rename
+        from: persistence.size
+        to: storage.size
+      - op: setDefault
+        path: storage.class
+        value: standard
+`
+	chartVersion := func(version string) *chart.Chart {
+		c := buildChart()
+		c.Metadata.Version = version
+		c.Files = append(c.Files, &chart.File{Name: chartutil.ValuesMigrationsFileName, Data: []byte(migrations)})
+		return c
+	}
+	existingRelease := func(t *testing.T, upAction *Upgrade) *release.Release {
+		t.Helper()
+		rel := releaseStub()
+		rel.Name = "migrated"
+		rel.Config = map[string]interface{}{
+			"persistence": map[string]interface{}{"size": "10Gi"},
+			"replicas":    2,
+		}
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+		return rel
+	}
+
+	t.Run("reused values are migrated", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.ReuseValues = true
+
+		res, err := upAction.Run(rel.Name, chartVersion("0.2.0"), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"storage":  map[string]interface{}{"size": "10Gi", "class": "standard"},
+			"replicas": 2,
+		}, res.Config)
+		assert.Equal(t, []string{
+			"storage: renamed persistence.size to storage.size",
+			"storage: set storage.class to standard",
+		}, res.Info.ValuesMigrations)
+
+		// Upgrading again leaves the migrated values as they are.
+		again := NewUpgrade(upAction.cfg)
+		again.Namespace = upAction.Namespace
+		again.ResetThenReuseValues = true
+		res2, err := again.Run(rel.Name, chartVersion("0.2.0"), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, res.Config, res2.Config)
+		assert.Empty(t, res2.Info.ValuesMigrations)
+	})
+
+	t.Run("values migrations can be skipped", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.ReuseValues = true
+		upAction.SkipValuesMigrations = true
+
+		res, err := upAction.Run(rel.Name, chartVersion("0.2.0"), map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, rel.Config, res.Config)
+		assert.Empty(t, res.Info.ValuesMigrations)
+	})
+
+	t.Run("values are only migrated when they are reused", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.ResetValues = true
+
+		res, err := upAction.Run(rel.Name, chartVersion("0.2.0"), map[string]interface{}{"replicas": 3})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"replicas": 3}, res.Config)
+		assert.Empty(t, res.Info.ValuesMigrations)
+	})
+}
+
 func TestUpgradeRelease_ReuseValues(t *testing.T) {
 	is := assert.New(t)
 
@@ -409,6 +556,47 @@ func TestUpgradeRelease_Interrupted_Wait(t *testing.T) {
 	is.Equal(res.Info.Status, release.StatusFailed)
 }
 
+func TestUpgradeRelease_AtomicCleanupTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	upgradeSlowRelease := func(t *testing.T, cleanupTimeout time.Duration) (*Upgrade, error) {
+		t.Helper()
+		upAction := upgradeAction(t)
+		rel := releaseStub()
+		rel.Name = "slow-release"
+		rel.Info.Status = release.StatusDeployed
+		upAction.cfg.Releases.Create(rel)
+
+		failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WaitDuration = time.Second
+		upAction.Atomic = true
+		upAction.Timeout = 100 * time.Millisecond
+		upAction.AtomicCleanupTimeout = cleanupTimeout
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		return upAction, err
+	}
+
+	t.Run("cleanup runs out of the timeout", func(t *testing.T) {
+		_, err := upgradeSlowRelease(t, 0)
+		is.Error(err)
+		is.Contains(err.Error(), "an error occurred while rolling back the release, which did not complete within its 100ms cleanup timeout")
+		is.Contains(err.Error(), "timed out waiting for the condition after 100ms")
+	})
+
+	t.Run("separate cleanup timeout", func(t *testing.T) {
+		upAction, err := upgradeSlowRelease(t, 5*time.Second)
+		is.Error(err)
+		is.Contains(err.Error(), "release slow-release failed, and has been rolled back within its 5s cleanup timeout due to atomic being set")
+		is.Contains(err.Error(), "timed out waiting for the condition after 100ms")
+
+		last, err := upAction.cfg.Releases.Last("slow-release")
+		is.NoError(err)
+		is.Equal(release.StatusDeployed, last.Info.Status)
+		is.Equal(3, last.Version)
+	})
+}
+
 func TestUpgradeRelease_Interrupted_Atomic(t *testing.T) {
 	is := assert.New(t)
 	req := require.New(t)
@@ -503,6 +691,46 @@ func TestUpgradeRelease_Labels(t *testing.T) {
 	is.Equal(initialRes.Labels, rel.Labels)
 }
 
+func TestUpgradeRelease_Annotations(t *testing.T) {
+	is := assert.New(t)
+	upAction := upgradeAction(t)
+
+	rel := releaseStub()
+	rel.Name = "annotations"
+	rel.Annotations = map[string]string{
+		"example.com/commit": "3b383ba",
+		"example.com/ticket": "OPS-1",
+	}
+	rel.Info.Status = release.StatusDeployed
+	is.NoError(upAction.cfg.Releases.Create(rel))
+
+	upAction.Annotations = map[string]string{
+		"example.com/ticket": "null",
+		"example.com/commit": "8ee6688",
+		"example.com/build":  `{"status": "passed"}`,
+	}
+	res, err := upAction.Run(rel.Name, buildChart(), nil)
+	is.NoError(err)
+	is.Equal(map[string]string{
+		"example.com/commit": "8ee6688",
+		"example.com/build":  `{"status": "passed"}`,
+	}, res.Annotations)
+
+	initialRes, err := upAction.cfg.Releases.Get(res.Name, 1)
+	is.NoError(err)
+	is.Equal(rel.Annotations, initialRes.Annotations)
+
+	// The annotations are carried over to the next revisions.
+	upAction.Annotations = nil
+	res, err = upAction.Run(rel.Name, buildChart(), nil)
+	is.NoError(err)
+	is.Equal("8ee6688", res.Annotations["example.com/commit"])
+
+	upAction.Annotations = map[string]string{"": "value"}
+	_, err = upAction.Run(rel.Name, buildChart(), nil)
+	is.EqualError(err, "release annotation keys cannot be empty")
+}
+
 func TestUpgradeRelease_SystemLabels(t *testing.T) {
 	is := assert.New(t)
 	upAction := upgradeAction(t)
@@ -583,3 +811,491 @@ func TestUpgradeRelease_DryRun(t *testing.T) {
 	done()
 	req.Error(err)
 }
+
+func TestUpgradeRelease_ExplainChanges(t *testing.T) {
+	existingRelease := func(t *testing.T, upAction *Upgrade) *release.Release {
+		t.Helper()
+		rel := releaseStub()
+		rel.Name = "explained"
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+		return rel
+	}
+
+	t.Run("changed, added and removed templates are listed", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.DryRun = true
+		upAction.ExplainChanges = true
+
+		chrt := buildChartWithTemplates([]*chart.File{
+			{Name: "templates/hello", Data: []byte("hello: {{ .Values.name }}")},
+			{Name: "templates/hooks", Data: []byte(manifestWithHook)},
+			{Name: "templates/empty", Data: []byte("")},
+			{Name: "templates/with-partials", Data: []byte(`hello: {{ template "_planet" . }}`)},
+			{Name: "templates/partials/_planet", Data: []byte(`{{define "_planet"}}Earth{{end}}`)},
+			{Name: "templates/revision", Data: []byte("revision: {{ .Release.Revision }}")},
+		})
+		res, err := upAction.Run(rel.Name, chrt, map[string]interface{}{"name": "value"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"hello/templates/goodbye",
+			"hello/templates/hello",
+			"hello/templates/revision",
+		}, res.Info.ChangedTemplates)
+	})
+
+	t.Run("an unchanged chart changes no templates", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.DryRun = true
+		upAction.ExplainChanges = true
+
+		res, err := upAction.Run(rel.Name, buildChart(withSampleTemplates()), rel.Config)
+		require.NoError(t, err)
+		assert.Empty(t, res.Info.ChangedTemplates)
+	})
+
+	t.Run("explaining the changes requires a dry run", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := existingRelease(t, upAction)
+		upAction.ExplainChanges = true
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		assert.EqualError(t, err, "explaining the changes of an upgrade requires a dry-run mode")
+	})
+
+	t.Run("the chart of the current release must be stored", func(t *testing.T) {
+		upAction := upgradeAction(t)
+		rel := releaseStub()
+		rel.Name = "explained"
+		rel.Chart = nil
+		require.NoError(t, upAction.cfg.Releases.Create(rel))
+		upAction.DryRun = true
+		upAction.ExplainChanges = true
+
+		_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		assert.EqualError(t, err, `unable to explain the changes of the upgrade: the chart of revision 1 of release "explained" is not stored`)
+	})
+}
+
+func TestUpgradeRelease_ShowOnlyChanged(t *testing.T) {
+	configMap := func(name, data string) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\ndata:\n  %s\n", name, data)
+	}
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "changed"
+	rel.Manifest = "---\n# Source: hello/templates/unchanged\n" + configMap("unchanged", "a: b\n  c: d") +
+		"---\n# Source: hello/templates/modified\n" + configMap("modified", "a: b") +
+		"---\n# Source: hello/templates/removed\n" + configMap("removed", "a: b")
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+	upAction.DryRun = true
+	upAction.ShowOnlyChanged = true
+
+	chrt := buildChartWithTemplates([]*chart.File{
+		// The same data in another order and format is unchanged.
+		{Name: "templates/unchanged", Data: []byte(configMap("unchanged", "c: 'd'\n  a: b"))},
+		{Name: "templates/modified", Data: []byte(configMap("modified", "a: changed"))},
+		{Name: "templates/added", Data: []byte(configMap("added", "a: b"))},
+	})
+	res, err := upAction.Run(rel.Name, chrt, map[string]interface{}{})
+	require.NoError(t, err)
+
+	assert.NotContains(t, res.Manifest, "name: unchanged")
+	assert.Contains(t, res.Manifest, "---\n# CHANGED\n# Source: hello/templates/modified\n"+configMap("modified", "a: changed"))
+	assert.Contains(t, res.Manifest, "---\n# ADDED\n# Source: hello/templates/added\n"+configMap("added", "a: b"))
+	assert.Contains(t, res.Manifest, "---\n# REMOVED\n# Source: hello/templates/removed\n"+configMap("removed", "a: b"))
+
+	stored, err := upAction.cfg.Releases.Last(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, rel.Version, stored.Version)
+
+	upAction.DryRun = false
+	_, err = upAction.Run(rel.Name, chrt, map[string]interface{}{})
+	assert.EqualError(t, err, "showing only the changed manifests requires a dry-run mode")
+}
+
+func TestUpgradeRelease_ShowOnlyChangedHideSecret(t *testing.T) {
+	secret := func(name, data string) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: Secret\nmetadata:\n  name: %s\nstringData:\n  %s\n", name, data)
+	}
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  a: changed\n"
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "secrets"
+	rel.Manifest = "---\n# Source: hello/templates/modified\n" + secret("modified", "password: old") +
+		"---\n# Source: hello/templates/removed\n" + secret("removed", "password: removed")
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+	upAction.DryRun = true
+	upAction.ShowOnlyChanged = true
+	upAction.HideSecret = true
+
+	chrt := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/modified", Data: []byte(secret("modified", "password: new"))},
+		{Name: "templates/added", Data: []byte(secret("added", "password: added"))},
+		{Name: "templates/config", Data: []byte(configMap)},
+	})
+	res, err := upAction.Run(rel.Name, chrt, map[string]interface{}{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "---\n# ADDED\n# Source: hello/templates/config\n"+configMap, res.Manifest)
+	assert.NotContains(t, res.Manifest, "password")
+}
+
+func TestUpgradeRelease_ConfirmImageChanges(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	deployment := func(image string) string {
+		return fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: %s
+`, image)
+	}
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "image-bump"
+	rel.Manifest = deployment("example.com/web:1.0")
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	var diff releaseutil.ImageDiff
+	upAction.ConfirmImageChanges = func(d releaseutil.ImageDiff) error {
+		diff = d
+		return fmt.Errorf("image changes not confirmed")
+	}
+	ch := buildChartWithTemplates([]*chart.File{{Name: "templates/web.yaml", Data: []byte(deployment("example.com/web:2.0"))}})
+	_, err := upAction.Run(rel.Name, ch, map[string]interface{}{})
+	req.Error(err)
+	is.Contains(err.Error(), "image changes not confirmed")
+	req.Len(diff.Changed, 1)
+	is.Equal("example.com/web:1.0", diff.Changed[0].Old.Image)
+	is.Equal("example.com/web:2.0", diff.Changed[0].New.Image)
+
+	// Nothing may have been recorded for the aborted upgrade.
+	last, err := upAction.cfg.Releases.Last(rel.Name)
+	req.NoError(err)
+	is.Equal(rel.Version, last.Version)
+}
+
+func TestUpgradeRelease_ExpectedManifestHash(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/cm.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  token: {{ randAlphaNum 8 }}\n")},
+		{Name: "templates/secret.yaml", Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: web\n")},
+	})
+	reviewed, err := releaseutil.HashManifest("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  token: reviewed\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n")
+	req.NoError(err)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "hashed"
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	// The random values need a seed.
+	upAction.ExpectedManifestHash = reviewed.String()
+	_, err = upAction.Run(rel.Name, ch, map[string]interface{}{})
+	is.ErrorContains(err, `function "randAlphaNum" returns random values, a random seed is required`)
+
+	seed := int64(7)
+	upAction.RandomSeed = &seed
+	_, err = upAction.Run(rel.Name, ch, map[string]interface{}{})
+	req.Error(err)
+	is.Contains(err.Error(), "unable to continue with upgrade: the rendered manifests have hash sha256:")
+	is.Contains(err.Error(), "expected "+reviewed.Sum)
+	is.Contains(err.Error(), "\nadded: v1 Secret web\nremoved: v1 Service web\nchanged: v1 ConfigMap web")
+
+	// A bare sum does not list the documents.
+	upAction.ExpectedManifestHash = reviewed.Sum
+	_, err = upAction.Run(rel.Name, ch, map[string]interface{}{})
+	is.ErrorContains(err, "pass the output of 'helm template --emit-manifest-hash' to list the documents which differ")
+
+	// Nothing may have been recorded for the aborted upgrades.
+	last, err := upAction.cfg.Releases.Last(rel.Name)
+	req.NoError(err)
+	is.Equal(rel.Version, last.Version)
+
+	// The manifests rendered by helm template with the same seed are the ones
+	// the upgrade applies.
+	instAction := installAction(t)
+	instAction.ReleaseName = rel.Name
+	instAction.DryRun = true
+	instAction.ClientOnly = true
+	instAction.IsUpgrade = true
+	instAction.Deterministic = true
+	instAction.RandomSeed = &seed
+	templated, err := instAction.Run(ch, map[string]interface{}{})
+	req.NoError(err)
+	expected, err := releaseutil.HashRelease(templated)
+	req.NoError(err)
+
+	upAction.ExpectedManifestHash = expected.String()
+	res, err := upAction.Run(rel.Name, ch, map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(release.StatusDeployed, res.Info.Status)
+}
+
+// manifestKubeClient builds a resource for each document of a manifest, without
+// contacting a cluster.
+type manifestKubeClient struct {
+	kubefake.PrintingKubeClient
+}
+
+func (*manifestKubeClient) Build(reader io.Reader, _ bool) (kube.ResourceList, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	docs := releaseutil.SplitManifests(string(data))
+	keys := slices.Collect(maps.Keys(docs))
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var resources kube.ResourceList
+	for _, k := range keys {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(docs[k]), &obj.Object); err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		resources.Append(&resource.Info{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+			Object:    obj,
+			Mapping: &meta.RESTMapping{
+				GroupVersionKind: obj.GroupVersionKind(),
+				Scope:            meta.RESTScopeNamespace,
+			},
+		})
+	}
+	return resources, nil
+}
+
+func configMapManifest(names ...string) string {
+	var docs []string
+	for _, name := range names {
+		docs = append(docs, fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n", name))
+	}
+	return strings.Join(docs, "---\n")
+}
+
+// pruneUpgradeAction returns an upgrade of a release with three ConfigMaps to
+// a chart which only renders the first one.
+func pruneUpgradeAction(t *testing.T) (*Upgrade, *chart.Chart) {
+	t.Helper()
+	upAction := upgradeAction(t)
+	upAction.cfg.KubeClient = &manifestKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}}
+
+	rel := releaseStub()
+	rel.Name = "prune-release"
+	rel.Manifest = configMapManifest("first", "second", "third")
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/first.yaml", Data: []byte(configMapManifest("first"))},
+	})
+	return upAction, ch
+}
+
+func TestUpgradeRelease_PruneReport(t *testing.T) {
+	upAction, ch := pruneUpgradeAction(t)
+	upAction.PruneReport = true
+
+	res, err := upAction.Run("prune-release", ch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+	assert.Equal(t, []string{"ConfigMap/second", "ConfigMap/third"}, res.Info.Pruned)
+
+	stored, err := upAction.cfg.Releases.Get("prune-release", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/second", "ConfigMap/third"}, stored.Info.Pruned)
+}
+
+func TestUpgradeRelease_PruneReportDisabled(t *testing.T) {
+	upAction, ch := pruneUpgradeAction(t)
+
+	res, err := upAction.Run("prune-release", ch, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Info.Pruned)
+}
+
+func TestUpgradeRelease_PruneReportKeepsAnnotatedResources(t *testing.T) {
+	upAction, ch := pruneUpgradeAction(t)
+	upAction.PruneReport = true
+	upAction.MaxPruned = 1
+
+	rel, err := upAction.cfg.Releases.Get("prune-release", 1)
+	require.NoError(t, err)
+	rel.Manifest = configMapManifest("first", "second") + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: third\n  annotations:\n    helm.sh/resource-policy: keep\n"
+	require.NoError(t, upAction.cfg.Releases.Update(rel))
+
+	res, err := upAction.Run("prune-release", ch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/second"}, res.Info.Pruned)
+}
+
+func TestUpgradeRelease_MaxPruned(t *testing.T) {
+	upAction, ch := pruneUpgradeAction(t)
+	upAction.MaxPruned = 1
+
+	_, err := upAction.Run("prune-release", ch, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the upgrade would delete 2 resources, more than the maximum of 1: ConfigMap/second, ConfigMap/third")
+
+	_, err = upAction.cfg.Releases.Get("prune-release", 2)
+	assert.Error(t, err, "no revision should be recorded")
+	last, err := upAction.cfg.Releases.Last("prune-release")
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, last.Info.Status)
+
+	upAction.MaxPruned = 2
+	_, err = upAction.Run("prune-release", ch, nil)
+	require.NoError(t, err)
+}
+
+func TestUpgradeRelease_StrictTemplate(t *testing.T) {
+	chrt, err := loader.Load("testdata/charts/chart-with-values-typo")
+	require.NoError(t, err)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "strict"
+	rel.Info.Status = release.StatusDeployed
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	upAction.StrictTemplate = true
+	_, err = upAction.Run(rel.Name, chrt, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `.Values.resoruces is not defined`)
+
+	upAction.StrictTemplate = false
+	_, err = upAction.Run(rel.Name, chrt, nil)
+	require.NoError(t, err)
+}
+
+// immutableJobKubeClient fails, as the API server does, to update a Job whose
+// template changed, unless it has the replace upgrade strategy or is to be
+// replaced.
+type immutableJobKubeClient struct {
+	manifestKubeClient
+	jobs []*unstructured.Unstructured
+}
+
+func (c *immutableJobKubeClient) Update(original, target kube.ResourceList, _ bool) (*kube.Result, error) {
+	return c.update(original, target, nil)
+}
+
+func (c *immutableJobKubeClient) UpdateWithContext(_ context.Context, original, target, replace kube.ResourceList, _ bool) (*kube.Result, error) {
+	return c.update(original, target, replace)
+}
+
+func (c *immutableJobKubeClient) UpdateServerSideApplyWithContext(_ context.Context, original, target, replace kube.ResourceList, _ kube.ServerSideApplyOptions) (*kube.Result, error) {
+	return c.update(original, target, replace)
+}
+
+func (c *immutableJobKubeClient) update(original, target, replace kube.ResourceList) (*kube.Result, error) {
+	res := &kube.Result{}
+	for _, info := range target {
+		current := original.Get(info)
+		if current == nil {
+			res.Created.Append(info)
+			continue
+		}
+		res.Updated.Append(info)
+		if info.Mapping.GroupVersionKind.Kind == "Job" {
+			c.jobs = append(c.jobs, info.Object.(*unstructured.Unstructured))
+		}
+		changed := !reflect.DeepEqual(current.Object.(*unstructured.Unstructured).Object["spec"], info.Object.(*unstructured.Unstructured).Object["spec"])
+		if info.Mapping.GroupVersionKind.Kind != "Job" || !changed {
+			continue
+		}
+		if info.Object.(*unstructured.Unstructured).GetAnnotations()[kube.UpgradeStrategyAnno] != kube.ReplaceStrategy && !replace.Contains(info) {
+			return res, fmt.Errorf(`Job.batch %q is invalid: spec.template: Invalid value: field is immutable`, info.Name)
+		}
+		res.Replaced.Append(info)
+	}
+	return res, nil
+}
+
+func jobManifest(image string, annotations string) string {
+	return fmt.Sprintf("apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n%sspec:\n  template:\n    spec:\n      containers:\n      - name: migrate\n        image: %s\n", annotations, image)
+}
+
+func TestUpgradeRelease_ReplaceStrategy(t *testing.T) {
+	const replaceAnnotation = "  annotations:\n    helm.sh/upgrade-strategy: replace\n"
+
+	tests := []struct {
+		name        string
+		annotations string
+		forceKinds  []string
+		unsupported bool
+		err         string
+	}{
+		{
+			name: "immutable change fails",
+			err:  "field is immutable",
+		},
+		{
+			name:        "annotated resource is replaced",
+			annotations: replaceAnnotation,
+		},
+		{
+			name:       "resource of a forced kind is replaced",
+			forceKinds: []string{"job"},
+		},
+		{
+			name:        "forced kinds need a client able to replace them",
+			forceKinds:  []string{"job"},
+			unsupported: true,
+			err:         "unable to replace the resources of the forced kinds",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			upAction := upgradeAction(t)
+			client := &immutableJobKubeClient{manifestKubeClient: manifestKubeClient{kubefake.PrintingKubeClient{Out: io.Discard}}}
+			upAction.cfg.KubeClient = client
+			if tc.unsupported {
+				upAction.cfg.KubeClient = &client.manifestKubeClient
+			}
+			upAction.ForceKinds = tc.forceKinds
+
+			rel := releaseStub()
+			rel.Name = "replace-release"
+			rel.Manifest = jobManifest("migrate:v1", tc.annotations) + "---\n" + configMapManifest("config")
+			require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+			ch := buildChartWithTemplates([]*chart.File{
+				{Name: "templates/job.yaml", Data: []byte(jobManifest("migrate:v2", tc.annotations))},
+				{Name: "templates/config.yaml", Data: []byte(configMapManifest("config"))},
+			})
+			res, err := upAction.Run(rel.Name, ch, nil)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, release.StatusDeployed, res.Info.Status)
+			assert.Equal(t, "Upgrade complete; replaced Job/migrate", res.Info.Description)
+
+			// The upgrade strategy of the forced kinds is not written to the
+			// resources.
+			require.Len(t, client.jobs, 1)
+			_, annotated := client.jobs[0].GetAnnotations()[kube.UpgradeStrategyAnno]
+			assert.Equal(t, tc.annotations != "", annotated)
+		})
+	}
+}
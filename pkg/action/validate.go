@@ -19,6 +19,8 @@ package action
 import (
 	"fmt"
 	"maps"
+	"slices"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -62,6 +64,55 @@ func requireAdoption(resources kube.ResourceList) (kube.ResourceList, error) {
 	return requireUpdate, err
 }
 
+// requireNamespacedAdoption returns the subset of resources that already exist
+// in the cluster, like requireAdoption, but only adopts namespaced resources.
+// Existing cluster-scoped resources must already belong to the release.
+func requireNamespacedAdoption(resources kube.ResourceList, releaseName, releaseNamespace string) (kube.ResourceList, error) {
+	var namespaced, clusterScoped kube.ResourceList
+	for _, info := range resources {
+		if isClusterScoped(info) {
+			clusterScoped.Append(info)
+		} else {
+			namespaced.Append(info)
+		}
+	}
+
+	requireUpdate, err := requireAdoption(namespaced)
+	if err != nil {
+		return nil, err
+	}
+
+	err = clusterScoped.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+
+		helper := resource.NewHelper(info.Client, info.Mapping)
+		existing, err := helper.Get(info.Namespace, info.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("could not get information about the resource %s: %w", resourceString(info), err)
+		}
+
+		if err := checkOwnership(existing, releaseName, releaseNamespace); err != nil {
+			_, k := info.Mapping.GroupVersionKind.ToAPIVersionAndKind()
+			return fmt.Errorf("cluster-scoped %s %q exists and only namespaced resources may be adopted: %s", k, info.Name, err)
+		}
+
+		requireUpdate.Append(info)
+		return nil
+	})
+
+	return requireUpdate, err
+}
+
+// isClusterScoped reports whether the REST mapping of a resource is not namespaced.
+func isClusterScoped(info *resource.Info) bool {
+	return info.Mapping != nil && info.Mapping.Scope != nil && info.Mapping.Scope.Name() == meta.RESTScopeNameRoot
+}
+
 func existingResourceConflict(resources kube.ResourceList, releaseName, releaseNamespace string) (kube.ResourceList, error) {
 	var requireUpdate kube.ResourceList
 
@@ -168,6 +219,50 @@ func setMetadataVisitor(releaseName, releaseNamespace string, force bool) resour
 	}
 }
 
+// resourcesOfKinds returns the resources of the kinds, which are replaced
+// rather than patched when they change. The kinds are matched regardless of
+// case.
+func resourcesOfKinds(resources kube.ResourceList, kinds []string) kube.ResourceList {
+	var matched kube.ResourceList
+	for _, info := range resources {
+		kind := info.Mapping.GroupVersionKind.Kind
+		if slices.ContainsFunc(kinds, func(k string) bool { return strings.EqualFold(strings.TrimSpace(k), kind) }) {
+			matched.Append(info)
+		}
+	}
+	return matched
+}
+
+// checkWaitConditionVisitor checks the wait condition annotation of the
+// resources, so that a malformed one fails the operation before anything is
+// applied, rather than as a timeout once the resources are waited for.
+func checkWaitConditionVisitor(info *resource.Info, err error) error {
+	if err != nil || info.Object == nil {
+		return err
+	}
+	annotations, err := accessor.Annotations(info.Object)
+	if err != nil {
+		return err
+	}
+	value, ok := annotations[kube.WaitConditionAnno]
+	if !ok {
+		return nil
+	}
+	if err := kube.ValidateWaitCondition(value); err != nil {
+		return fmt.Errorf("%s: %w", resourceString(info), err)
+	}
+	return nil
+}
+
+// replacedResources lists the replaced resources as Kind/name.
+func replacedResources(resources kube.ResourceList) string {
+	names := make([]string, 0, len(resources))
+	for _, info := range resources {
+		names = append(names, info.Mapping.GroupVersionKind.Kind+"/"+info.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
 func resourceString(info *resource.Info) string {
 	_, k := info.Mapping.GroupVersionKind.ToAPIVersionAndKind()
 	return fmt.Sprintf(
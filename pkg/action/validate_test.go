@@ -25,6 +25,7 @@ import (
 	"helm.sh/helm/v4/pkg/kube"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -134,6 +135,36 @@ func TestRequireAdoption(t *testing.T) {
 	assert.Equal(t, found[0], existing)
 }
 
+func TestRequireNamespacedAdoption(t *testing.T) {
+	var (
+		missing    = newMissingDeployment("missing", "ns-a")
+		existing   = newDeploymentWithOwner("existing", "ns-a", nil, nil)
+		clusterRes = newDeploymentWithOwner("cluster", "", nil, nil)
+	)
+	clusterRes.Mapping.Scope = meta.RESTScopeRoot
+
+	// Verify that namespaced resources are adopted regardless of ownership
+	found, err := requireNamespacedAdoption(kube.ResourceList{missing, existing}, "rel-a", "ns-a")
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, found[0], existing)
+
+	// Verify that an existing cluster-scoped resource is not adopted
+	_, err = requireNamespacedAdoption(kube.ResourceList{missing, existing, clusterRes}, "rel-a", "ns-a")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `cluster-scoped Deployment "cluster" exists`)
+
+	// Verify that an existing cluster-scoped resource owned by the release is kept
+	owned := newDeploymentWithOwner("cluster", "", map[string]string{appManagedByLabel: appManagedByHelm}, map[string]string{
+		helmReleaseNameAnnotation:      "rel-a",
+		helmReleaseNamespaceAnnotation: "ns-a",
+	})
+	owned.Mapping.Scope = meta.RESTScopeRoot
+	found, err = requireNamespacedAdoption(kube.ResourceList{missing, existing, owned}, "rel-a", "ns-a")
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+}
+
 func TestExistingResourceConflict(t *testing.T) {
 	var (
 		releaseName      = "rel-name"
@@ -238,3 +269,20 @@ func TestSetMetadataVisitor(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), `Deployment "baz" in namespace "" cannot be owned`)
 }
+
+func TestCheckWaitConditionVisitor(t *testing.T) {
+	withCondition := func(name, value string) *resource.Info {
+		info := newDeploymentResource(name, "ns-a")
+		info.Object.(*appsv1.Deployment).Annotations = map[string]string{kube.WaitConditionAnno: value}
+		return info
+	}
+
+	resources := kube.ResourceList{newDeploymentResource("foo", "ns-a"), withCondition("bar", "Available=False")}
+	assert.NoError(t, resources.Visit(checkWaitConditionVisitor))
+
+	resources.Append(withCondition("baz", "=True"))
+	err := resources.Visit(checkWaitConditionVisitor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Deployment "baz" in namespace ""`)
+	assert.Contains(t, err.Error(), kube.WaitConditionAnno)
+}
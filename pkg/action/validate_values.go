@@ -0,0 +1,298 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	clivalues "helm.sh/helm/v4/pkg/cli/values"
+	"helm.sh/helm/v4/pkg/getter"
+)
+
+// RequiredValuesAnnotation is the chart annotation listing, separated by
+// commas, the paths of the values which must be set for the chart to be
+// installed, e.g. "image.repository,ingress.host".
+const RequiredValuesAnnotation = "helm.sh/required-values"
+
+// Checks of ValidateValues.
+const (
+	ValuesCheckMerge      = "merge"
+	ValuesCheckSchema     = "schema"
+	ValuesCheckUnknownKey = "unknown-key"
+	ValuesCheckRequired   = "required"
+)
+
+// ValuesFindingSeverity is the severity of a ValuesFinding.
+type ValuesFindingSeverity string
+
+const (
+	// ValuesError is a finding which would make the values rejected.
+	ValuesError ValuesFindingSeverity = "error"
+	// ValuesWarning is a finding which would not make the values rejected.
+	ValuesWarning ValuesFindingSeverity = "warning"
+)
+
+// ValuesFinding is a problem found by ValidateValues.
+type ValuesFinding struct {
+	Severity ValuesFindingSeverity `json:"severity"`
+	// Check is the check which found the problem, such as ValuesCheckSchema.
+	Check string `json:"check"`
+	// Path is the dotted path of the value, or of the chart for the schema
+	// check, the problem was found at.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValuesCheckOptions controls the checks of ValidateValues.
+type ValuesCheckOptions struct {
+	// Getters read the values files given by URL.
+	Getters getter.Providers
+	// SkipSchemaValidation skips the validation against the values schemas of
+	// the chart and its subcharts.
+	SkipSchemaValidation bool
+	// UnknownKeys reports the values which are neither set by default nor
+	// declared by the schema of the chart they are given to.
+	UnknownKeys bool
+}
+
+// ValidateValues checks the values given by opts for the chart without
+// rendering its templates or contacting a cluster: they must parse and merge
+// with the defaults of the chart, satisfy the schemas of the chart and its
+// enabled subcharts, set the values listed by the RequiredValuesAnnotation of
+// the charts and, with UnknownKeys, only set values the charts know.
+//
+// The values are checked as given, without the values of an existing release
+// reused by an upgrade. Values read from cluster resources cannot be checked.
+//
+// The chart is modified as it would be by an install: the subcharts disabled
+// by the values are removed.
+func ValidateValues(chrt *chart.Chart, opts clivalues.Options, checks ValuesCheckOptions) ([]ValuesFinding, error) {
+	vals, err := opts.MergeValues(checks.Getters)
+	if err != nil {
+		return []ValuesFinding{{Severity: ValuesError, Check: ValuesCheckMerge, Message: err.Error()}}, nil
+	}
+
+	dependencies := dependencyNames(chrt)
+	if err := chartutil.ProcessDependencies(chrt, vals); err != nil {
+		return nil, err
+	}
+
+	merged, err := chartutil.CoalesceValues(chrt, vals)
+	if err != nil {
+		return []ValuesFinding{{Severity: ValuesError, Check: ValuesCheckMerge, Message: err.Error()}}, nil
+	}
+
+	var findings []ValuesFinding
+	if !checks.SkipSchemaValidation {
+		findings = schemaFindings(findings, chrt, merged, "")
+	}
+	if checks.UnknownKeys {
+		defaults, err := chartutil.CoalesceValues(chrt, map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		findings = unknownKeyFindings(findings, chrt, dependencies, vals, defaults, schemaProperties(chrt.Schema), "")
+	}
+	findings = requiredValueFindings(findings, chrt, merged, "")
+	return findings, nil
+}
+
+// schemaFindings appends the violations of the values schema of chrt and its
+// subcharts by vals.
+func schemaFindings(findings []ValuesFinding, chrt *chart.Chart, vals map[string]interface{}, prefix string) []ValuesFinding {
+	path := valuesPath(prefix, chrt.Name())
+	if prefix == "" {
+		path = chrt.Name()
+	}
+	if chrt.Schema != nil {
+		if err := chartutil.ValidateAgainstSingleSchema(vals, chrt.Schema); err != nil {
+			findings = append(findings, ValuesFinding{
+				Severity: ValuesError,
+				Check:    ValuesCheckSchema,
+				Path:     path,
+				Message:  strings.TrimSpace(err.Error()),
+			})
+		}
+	}
+	for _, sub := range chrt.Dependencies() {
+		subVals, _ := vals[sub.Name()].(map[string]interface{})
+		findings = schemaFindings(findings, sub, subVals, path)
+	}
+	return findings
+}
+
+// unknownKeyFindings appends the keys of user which are not known by the
+// chart: the keys which are neither in defaults nor declared by the schema
+// properties. chrt is set when user are the values of a chart, whose
+// dependencies are the names of all its subcharts, enabled or not.
+//
+// Maps set to an empty map by default are free-form, so their keys are not
+// checked.
+func unknownKeyFindings(findings []ValuesFinding, chrt *chart.Chart, dependencies []string, user, defaults, props map[string]interface{}, prefix string) []ValuesFinding {
+	for _, key := range sortedValueKeys(user) {
+		path := valuesPath(prefix, key)
+		if chrt != nil {
+			if key == chartutil.GlobalKey {
+				continue
+			}
+			if sub := enabledDependency(chrt, key); sub != nil {
+				subUser, _ := user[key].(map[string]interface{})
+				subDefaults, _ := defaults[key].(map[string]interface{})
+				findings = unknownKeyFindings(findings, sub, dependencyNames(sub), subUser, subDefaults, schemaProperties(sub.Schema), path)
+				continue
+			}
+			if slices.Contains(dependencies, key) {
+				findings = append(findings, ValuesFinding{
+					Severity: ValuesWarning,
+					Check:    ValuesCheckUnknownKey,
+					Path:     path,
+					Message:  fmt.Sprintf("the subchart %s is disabled, so its values are ignored", key),
+				})
+				continue
+			}
+		}
+
+		def, inDefaults := defaults[key]
+		prop, inSchema := props[key].(map[string]interface{})
+		if !inDefaults && !inSchema {
+			findings = append(findings, ValuesFinding{
+				Severity: ValuesError,
+				Check:    ValuesCheckUnknownKey,
+				Path:     path,
+				Message:  "the chart does not set this value by default nor declare it in its schema",
+			})
+			continue
+		}
+
+		nested, ok := user[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nestedDefaults, _ := def.(map[string]interface{})
+		nestedProps, _ := prop["properties"].(map[string]interface{})
+		if len(nestedDefaults) == 0 && len(nestedProps) == 0 {
+			continue
+		}
+		findings = unknownKeyFindings(findings, nil, nil, nested, nestedDefaults, nestedProps, path)
+	}
+	return findings
+}
+
+// requiredValueFindings appends the values listed by the
+// RequiredValuesAnnotation of chrt and its subcharts which are not set.
+func requiredValueFindings(findings []ValuesFinding, chrt *chart.Chart, vals map[string]interface{}, prefix string) []ValuesFinding {
+	if chrt.Metadata != nil {
+		for required := range strings.SplitSeq(chrt.Metadata.Annotations[RequiredValuesAnnotation], ",") {
+			required = strings.TrimSpace(required)
+			if required == "" {
+				continue
+			}
+			if v, ok := lookupValue(vals, required); !ok || v == nil || v == "" {
+				findings = append(findings, ValuesFinding{
+					Severity: ValuesError,
+					Check:    ValuesCheckRequired,
+					Path:     valuesPath(prefix, required),
+					Message:  fmt.Sprintf("the chart %s requires this value to be set", chrt.Name()),
+				})
+			}
+		}
+	}
+	for _, sub := range chrt.Dependencies() {
+		subVals, _ := vals[sub.Name()].(map[string]interface{})
+		findings = requiredValueFindings(findings, sub, subVals, valuesPath(prefix, sub.Name()))
+	}
+	return findings
+}
+
+// lookupValue returns the value at the dotted path in vals.
+func lookupValue(vals map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = vals
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// dependencyNames returns the names of the subcharts of chrt, including the
+// disabled ones.
+func dependencyNames(chrt *chart.Chart) []string {
+	var names []string
+	if chrt.Metadata != nil {
+		for _, dep := range chrt.Metadata.Dependencies {
+			if dep.Alias != "" {
+				names = append(names, dep.Alias)
+			} else {
+				names = append(names, dep.Name)
+			}
+		}
+	}
+	for _, sub := range chrt.Dependencies() {
+		if !slices.Contains(names, sub.Name()) {
+			names = append(names, sub.Name())
+		}
+	}
+	return names
+}
+
+func enabledDependency(chrt *chart.Chart, name string) *chart.Chart {
+	for _, sub := range chrt.Dependencies() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// schemaProperties returns the top-level properties of a values schema.
+func schemaProperties(schema []byte) map[string]interface{} {
+	if len(schema) == 0 {
+		return nil
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	return props
+}
+
+func sortedValueKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func valuesPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
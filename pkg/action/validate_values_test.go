@@ -0,0 +1,158 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	clivalues "helm.sh/helm/v4/pkg/cli/values"
+)
+
+// valuesCheckChart returns a chart with a schema, a required value and two
+// subcharts, of which database is enabled by database.enabled.
+func valuesCheckChart() *chart.Chart {
+	cache := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "cache", Version: "0.1.0"},
+		Values:   map[string]interface{}{"size": 1},
+		Schema:   []byte(`{"type": "object", "properties": {"size": {"type": "integer", "minimum": 1}}}`),
+	}
+	database := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:  chart.APIVersionV2,
+			Name:        "database",
+			Version:     "0.1.0",
+			Annotations: map[string]string{RequiredValuesAnnotation: "password"},
+		},
+		Values: map[string]interface{}{"password": ""},
+	}
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:  chart.APIVersionV2,
+			Name:        "app",
+			Version:     "0.1.0",
+			Annotations: map[string]string{RequiredValuesAnnotation: "image.repository, ingress.host"},
+			Dependencies: []*chart.Dependency{
+				{Name: "cache", Version: "0.1.0"},
+				{Name: "database", Version: "0.1.0", Condition: "database.enabled"},
+			},
+		},
+		Values: map[string]interface{}{
+			"image":       map[string]interface{}{"repository": "nginx", "tag": "latest"},
+			"ingress":     map[string]interface{}{"host": "example.com"},
+			"annotations": map[string]interface{}{},
+			"database":    map[string]interface{}{"enabled": false},
+		},
+		Schema: []byte(`{"type": "object", "properties": {"replicas": {"type": "integer"}, "image": {"type": "object", "properties": {"pullPolicy": {"type": "string"}}}}}`),
+	}
+	ch.AddDependency(cache, database)
+	return ch
+}
+
+func validateValues(t *testing.T, checks ValuesCheckOptions, yaml string) []ValuesFinding {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(yaml), 0644))
+	findings, err := ValidateValues(valuesCheckChart(), clivalues.Options{ValueFiles: []string{file}}, checks)
+	require.NoError(t, err)
+	return findings
+}
+
+func TestValidateValues(t *testing.T) {
+	findings := validateValues(t, ValuesCheckOptions{}, `
+replicas: 3
+image:
+  tag: "1.27"
+cache:
+  size: 4
+`)
+	assert.Empty(t, findings)
+}
+
+func TestValidateValuesMerge(t *testing.T) {
+	findings := validateValues(t, ValuesCheckOptions{}, "image: [unclosed")
+	require.Len(t, findings, 1)
+	assert.Equal(t, ValuesError, findings[0].Severity)
+	assert.Equal(t, ValuesCheckMerge, findings[0].Check)
+	assert.Contains(t, findings[0].Message, "failed to parse")
+}
+
+func TestValidateValuesSchema(t *testing.T) {
+	yaml := `
+replicas: three
+cache:
+  size: 0
+`
+	findings := validateValues(t, ValuesCheckOptions{}, yaml)
+	require.Len(t, findings, 2)
+	assert.Equal(t, ValuesCheckSchema, findings[0].Check)
+	assert.Equal(t, "app", findings[0].Path)
+	assert.Contains(t, findings[0].Message, "replicas")
+	assert.Equal(t, ValuesCheckSchema, findings[1].Check)
+	assert.Equal(t, "app.cache", findings[1].Path, "the schemas of the subcharts are validated")
+	assert.Contains(t, findings[1].Message, "size")
+
+	findings = validateValues(t, ValuesCheckOptions{SkipSchemaValidation: true}, yaml)
+	assert.Empty(t, findings)
+}
+
+func TestValidateValuesRequired(t *testing.T) {
+	findings := validateValues(t, ValuesCheckOptions{}, `
+image:
+  repository: ""
+ingress: null
+database:
+  enabled: true
+`)
+	assert.Equal(t, []ValuesFinding{
+		{Severity: ValuesError, Check: ValuesCheckRequired, Path: "image.repository", Message: "the chart app requires this value to be set"},
+		{Severity: ValuesError, Check: ValuesCheckRequired, Path: "ingress.host", Message: "the chart app requires this value to be set"},
+		{Severity: ValuesError, Check: ValuesCheckRequired, Path: "database.password", Message: "the chart database requires this value to be set"},
+	}, findings)
+}
+
+func TestValidateValuesUnknownKeys(t *testing.T) {
+	yaml := `
+replicaz: 3
+global:
+  anything: true
+image:
+  tagg: "1.27"
+  pullPolicy: Always
+annotations:
+  free: form
+cache:
+  size: 2
+  sise: 2
+database:
+  password: secret
+`
+	assert.Empty(t, validateValues(t, ValuesCheckOptions{}, yaml), "unknown keys are only reported when enabled")
+
+	findings := validateValues(t, ValuesCheckOptions{UnknownKeys: true}, yaml)
+	assert.Equal(t, []ValuesFinding{
+		{Severity: ValuesError, Check: ValuesCheckUnknownKey, Path: "cache.sise", Message: "the chart does not set this value by default nor declare it in its schema"},
+		{Severity: ValuesWarning, Check: ValuesCheckUnknownKey, Path: "database", Message: "the subchart database is disabled, so its values are ignored"},
+		{Severity: ValuesError, Check: ValuesCheckUnknownKey, Path: "image.tagg", Message: "the chart does not set this value by default nor declare it in its schema"},
+		{Severity: ValuesError, Check: ValuesCheckUnknownKey, Path: "replicaz", Message: "the chart does not set this value by default nor declare it in its schema"},
+	}, findings)
+}
@@ -18,6 +18,7 @@ package action
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"helm.sh/helm/v4/pkg/downloader"
@@ -28,7 +29,10 @@ import (
 // It provides the implementation of 'helm verify'.
 type Verify struct {
 	Keyring string
-	Out     string
+	// Out is the output of the verification, as printed by 'helm verify'.
+	//
+	// Deprecated: use the VerifyResult returned by Run.
+	Out string
 }
 
 // NewVerify creates a new Verify object with the given configuration.
@@ -36,24 +40,48 @@ func NewVerify() *Verify {
 	return &Verify{}
 }
 
-// Run executes 'helm verify'.
-func (v *Verify) Run(chartfile string) error {
-	var out strings.Builder
+// VerifyResult describes the signature of a chart verified by 'helm verify'.
+type VerifyResult struct {
+	// Chart is the path of the chart archive verified.
+	Chart string `json:"chart" yaml:"chart"`
+	// ProvenanceFile is the path of the provenance file of the chart.
+	ProvenanceFile string `json:"provenanceFile" yaml:"provenanceFile"`
+	// Fingerprint is the fingerprint of the primary key of the signer, in
+	// uppercase hexadecimal.
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+	// SignedBy are the user identities of the signer, such as
+	// "Name (Comment) <email>", sorted.
+	SignedBy []string `json:"signedBy" yaml:"signedBy"`
+	// FileHash is the hash of the chart archive, prefixed with its algorithm.
+	FileHash string `json:"fileHash" yaml:"fileHash"`
+}
+
+// Run executes 'helm verify'. It returns an error when the chart is not
+// signed by a key of the keyring, or was changed after it was signed.
+func (v *Verify) Run(chartfile string) (*VerifyResult, error) {
 	p, err := downloader.VerifyChart(chartfile, v.Keyring)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	result := &VerifyResult{
+		Chart:          chartfile,
+		ProvenanceFile: chartfile + ".prov",
+		Fingerprint:    fmt.Sprintf("%X", p.SignedBy.PrimaryKey.Fingerprint),
+		FileHash:       p.FileHash,
+	}
 	for name := range p.SignedBy.Identities {
-		fmt.Fprintf(&out, "Signed by: %v\n", name)
+		result.SignedBy = append(result.SignedBy, name)
 	}
-	fmt.Fprintf(&out, "Using Key With Fingerprint: %X\n", p.SignedBy.PrimaryKey.Fingerprint)
-	fmt.Fprintf(&out, "Chart Hash Verified: %s\n", p.FileHash)
+	slices.Sort(result.SignedBy)
 
-	// TODO(mattfarina): The output is set as a property rather than returned
-	// to maintain the Go API. In Helm v4 this function should return the out
-	// and the property on the struct can be removed.
+	var out strings.Builder
+	for _, name := range result.SignedBy {
+		fmt.Fprintf(&out, "Signed by: %v\n", name)
+	}
+	fmt.Fprintf(&out, "Using Key With Fingerprint: %s\n", result.Fingerprint)
+	fmt.Fprintf(&out, "Chart Hash Verified: %s\n", result.FileHash)
 	v.Out = out.String()
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,53 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRun(t *testing.T) {
+	client := NewVerify()
+	client.Keyring = "../cmd/testdata/helm-test-key.pub"
+
+	result, err := client.Run("../cmd/testdata/testcharts/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, &VerifyResult{
+		Chart:          "../cmd/testdata/testcharts/signtest-0.1.0.tgz",
+		ProvenanceFile: "../cmd/testdata/testcharts/signtest-0.1.0.tgz.prov",
+		Fingerprint:    "5E615389B53CA37F0EE60BD3843BBF981FC18762",
+		SignedBy:       []string{"Helm Testing (This key should only be used for testing. DO NOT TRUST.) <helm-testing@helm.sh>"},
+		FileHash:       "sha256:e5ef611620fb97704d8751c16bab17fedb68883bfb0edc76f78a70e9173f9b55",
+	}, result)
+	assert.Equal(t, "Signed by: Helm Testing (This key should only be used for testing. DO NOT TRUST.) <helm-testing@helm.sh>\nUsing Key With Fingerprint: 5E615389B53CA37F0EE60BD3843BBF981FC18762\nChart Hash Verified: sha256:e5ef611620fb97704d8751c16bab17fedb68883bfb0edc76f78a70e9173f9b55\n", client.Out)
+}
+
+func TestVerifyRunErrors(t *testing.T) {
+	client := NewVerify()
+	client.Keyring = "../cmd/testdata/helm-test-key.pub"
+
+	result, err := client.Run("testdata/charts/compressedchart-0.1.0.tgz")
+	assert.ErrorContains(t, err, "could not load provenance file testdata/charts/compressedchart-0.1.0.tgz.prov")
+	assert.Nil(t, result)
+
+	client.Keyring = "testdata/missing.pub"
+	_, err = client.Run("../cmd/testdata/testcharts/signtest-0.1.0.tgz")
+	assert.ErrorContains(t, err, "failed to load keyring")
+}
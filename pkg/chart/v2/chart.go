@@ -16,9 +16,16 @@ limitations under the License.
 package v2
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 )
 
 // APIVersionV1 is the API version number for version 1.
@@ -169,6 +176,30 @@ func (ch *Chart) CRDObjects() []CRD {
 	return crds
 }
 
+// UnstructuredCRDs parses the files returned by CRDObjects, which may each hold
+// several YAML documents, into unstructured objects. Empty documents are
+// skipped.
+func (ch *Chart) UnstructuredCRDs() ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, crd := range ch.CRDObjects() {
+		decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(crd.File.Data), 4096)
+		for {
+			var obj map[string]interface{}
+			if err := decoder.Decode(&obj); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("parsing %s: %w", crd.Filename, err)
+			}
+			if len(obj) == 0 {
+				continue
+			}
+			objs = append(objs, &unstructured.Unstructured{Object: obj})
+		}
+	}
+	return objs, nil
+}
+
 func hasManifestExtension(fname string) bool {
 	ext := filepath.Ext(fname)
 	return strings.EqualFold(ext, ".yaml") || strings.EqualFold(ext, ".yml") || strings.EqualFold(ext, ".json")
@@ -160,6 +160,39 @@ func TestChartFullPath(t *testing.T) {
 	is.Equal("foo", chrt2.ChartFullPath())
 }
 
+func TestUnstructuredCRDs(t *testing.T) {
+	chrt := &Chart{
+		Metadata: &Metadata{Name: "parent"},
+		Files: []*File{
+			{
+				Name: "crds/backups.yaml",
+				Data: []byte("---\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: backups.example.com\n---\n"),
+			},
+		},
+	}
+	chrt.AddDependency(&Chart{
+		Metadata: &Metadata{Name: "sub"},
+		Files: []*File{
+			{
+				Name: "crds/monitors.json",
+				Data: []byte(`{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition", "metadata": {"name": "monitors.example.com"}}`),
+			},
+		},
+	})
+
+	is := assert.New(t)
+	crds, err := chrt.UnstructuredCRDs()
+	is.NoError(err)
+	is.Len(crds, 2)
+	is.Equal("backups.example.com", crds[0].GetName())
+	is.Equal("CustomResourceDefinition", crds[0].GetKind())
+	is.Equal("monitors.example.com", crds[1].GetName())
+
+	chrt.Files = append(chrt.Files, &File{Name: "crds/broken.yaml", Data: []byte("kind: [")})
+	_, err = chrt.UnstructuredCRDs()
+	is.ErrorContains(err, "parsing parent/crds/broken.yaml")
+}
+
 func TestCRDObjects(t *testing.T) {
 	chrt := Chart{
 		Files: []*File{
@@ -33,7 +33,8 @@ import (
 )
 
 // MaxDecompressedChartSize is the maximum size of a chart archive that will be
-// decompressed. This is the decompressed size of all the files.
+// decompressed. This is the decompressed size of all the files, including the
+// ones of the subchart archives it contains.
 // The default value is 100 MiB.
 var MaxDecompressedChartSize int64 = 100 * 1024 * 1024 // Default 100 MiB
 
@@ -41,8 +42,47 @@ var MaxDecompressedChartSize int64 = 100 * 1024 * 1024 // Default 100 MiB
 // The size of the file is the decompressed version of it when it is stored in an archive.
 var MaxDecompressedFileSize int64 = 5 * 1024 * 1024 // Default 5 MiB
 
+// ErrChartTooLarge is wrapped by the error returned when a chart archive
+// decompresses to more than MaxDecompressedChartSize.
+var ErrChartTooLarge = errors.New("decompressed chart is larger than the maximum size")
+
 var drivePathPattern = regexp.MustCompile(`^[a-zA-Z]:/`)
 
+// UnsafeArchiveEntryError is returned when a chart archive contains an entry
+// which no chart needs and which would be unsafe to extract: a file or a
+// directory with the setuid or setgid bit, or a device or a FIFO.
+type UnsafeArchiveEntryError struct {
+	// Name is the name of the entry in the archive.
+	Name string
+	// Reason tells why the entry is unsafe.
+	Reason string
+}
+
+func (e *UnsafeArchiveEntryError) Error() string {
+	return fmt.Sprintf("chart archive contains an unsafe entry %q: %s", e.Name, e.Reason)
+}
+
+// The setuid and setgid bits of the mode of a tar entry.
+const (
+	tarModeSetuid = 0o4000
+	tarModeSetgid = 0o2000
+)
+
+// checkArchiveEntry returns an UnsafeArchiveEntryError if the entry of hd is
+// unsafe.
+func checkArchiveEntry(hd *tar.Header) error {
+	switch hd.Typeflag {
+	case tar.TypeChar, tar.TypeBlock:
+		return &UnsafeArchiveEntryError{Name: hd.Name, Reason: "it is a device"}
+	case tar.TypeFifo:
+		return &UnsafeArchiveEntryError{Name: hd.Name, Reason: "it is a FIFO"}
+	}
+	if hd.Mode&(tarModeSetuid|tarModeSetgid) != 0 {
+		return &UnsafeArchiveEntryError{Name: hd.Name, Reason: "it has the setuid or setgid bit"}
+	}
+	return nil
+}
+
 // FileLoader loads a chart from a file
 type FileLoader string
 
@@ -115,10 +155,30 @@ func isGZipApplication(data []byte) bool {
 	return bytes.HasPrefix(data, sig)
 }
 
+// sizeBudget is the decompressed size left to a chart archive and to the
+// subchart archives nested in it.
+type sizeBudget struct {
+	remaining int64
+}
+
+func newSizeBudget() *sizeBudget {
+	return &sizeBudget{remaining: MaxDecompressedChartSize}
+}
+
+// tooLarge returns the error reporting that decompressing the file name
+// exceeded MaxDecompressedChartSize.
+func (b *sizeBudget) tooLarge(name string) error {
+	return fmt.Errorf("%w %d: exceeded while decompressing %q", ErrChartTooLarge, MaxDecompressedChartSize, name)
+}
+
 // LoadArchiveFiles reads in files out of an archive into memory. This function
 // performs important path security checks and should always be used before
 // expanding a tarball
 func LoadArchiveFiles(in io.Reader) ([]*BufferedFile, error) {
+	return loadArchiveFiles(in, newSizeBudget())
+}
+
+func loadArchiveFiles(in io.Reader, budget *sizeBudget) ([]*BufferedFile, error) {
 	unzipped, err := gzip.NewReader(in)
 	if err != nil {
 		return nil, err
@@ -127,7 +187,6 @@ func LoadArchiveFiles(in io.Reader) ([]*BufferedFile, error) {
 
 	files := []*BufferedFile{}
 	tr := tar.NewReader(unzipped)
-	remainingSize := MaxDecompressedChartSize
 	for {
 		b := bytes.NewBuffer(nil)
 		hd, err := tr.Next()
@@ -138,6 +197,10 @@ func LoadArchiveFiles(in io.Reader) ([]*BufferedFile, error) {
 			return nil, err
 		}
 
+		if err := checkArchiveEntry(hd); err != nil {
+			return nil, err
+		}
+
 		if hd.FileInfo().IsDir() {
 			// Use this instead of hd.Typeflag because we don't have to do any
 			// inference chasing.
@@ -187,28 +250,28 @@ func LoadArchiveFiles(in io.Reader) ([]*BufferedFile, error) {
 			return nil, errors.New("chart yaml not in base directory")
 		}
 
-		if hd.Size > remainingSize {
-			return nil, fmt.Errorf("decompressed chart is larger than the maximum size %d", MaxDecompressedChartSize)
+		if hd.Size > budget.remaining {
+			return nil, budget.tooLarge(hd.Name)
 		}
 
 		if hd.Size > MaxDecompressedFileSize {
 			return nil, fmt.Errorf("decompressed chart file %q is larger than the maximum file size %d", hd.Name, MaxDecompressedFileSize)
 		}
 
-		limitedReader := io.LimitReader(tr, remainingSize)
+		limitedReader := io.LimitReader(tr, budget.remaining)
 
 		bytesWritten, err := io.Copy(b, limitedReader)
 		if err != nil {
 			return nil, err
 		}
 
-		remainingSize -= bytesWritten
+		budget.remaining -= bytesWritten
 		// When the bytesWritten are less than the file size it means the limit reader ended
 		// copying early. Here we report that error. This is important if the last file extracted
 		// is the one that goes over the limit. It assumes the Size stored in the tar header
 		// is correct, something many applications do.
-		if bytesWritten < hd.Size || remainingSize <= 0 {
-			return nil, fmt.Errorf("decompressed chart is larger than the maximum size %d", MaxDecompressedChartSize)
+		if bytesWritten < hd.Size || budget.remaining <= 0 {
+			return nil, budget.tooLarge(hd.Name)
 		}
 
 		data := bytes.TrimPrefix(b.Bytes(), utf8bom)
@@ -224,11 +287,19 @@ func LoadArchiveFiles(in io.Reader) ([]*BufferedFile, error) {
 }
 
 // LoadArchive loads from a reader containing a compressed tar archive.
+//
+// The archive, along with the subchart archives it contains, may not
+// decompress to more than MaxDecompressedChartSize: an error wrapping
+// ErrChartTooLarge is returned otherwise.
 func LoadArchive(in io.Reader) (*chart.Chart, error) {
-	files, err := LoadArchiveFiles(in)
+	return loadArchive(in, newSizeBudget())
+}
+
+func loadArchive(in io.Reader, budget *sizeBudget) (*chart.Chart, error) {
+	files, err := loadArchiveFiles(in, budget)
 	if err != nil {
 		return nil, err
 	}
 
-	return LoadFiles(files)
+	return loadFiles(files, budget)
 }
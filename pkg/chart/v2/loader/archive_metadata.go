@@ -0,0 +1,108 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// LoadArchiveMetadata reads the metadata of the chart in a compressed tar
+// archive without loading the rest of the chart: the archive is streamed, and
+// only Chart.yaml and the deprecated requirements.yaml are kept in memory.
+//
+// The metadata is the same, and is validated in the same way, as the one of the
+// chart loaded by LoadArchive.
+func LoadArchiveMetadata(in io.Reader) (*chart.Metadata, error) {
+	unzipped, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	defer unzipped.Close()
+
+	var chartYAML, requirementsYAML []byte
+	tr := tar.NewReader(unzipped)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hd.FileInfo().IsDir() || hd.Typeflag == tar.TypeXGlobalHeader || hd.Typeflag == tar.TypeXHeader {
+			continue
+		}
+
+		// Archive could contain \ if generated on Windows
+		delimiter := "/"
+		if strings.ContainsRune(hd.Name, '\\') {
+			delimiter = "\\"
+		}
+		parts := strings.Split(hd.Name, delimiter)
+		if parts[0] == "Chart.yaml" {
+			return nil, errors.New("chart yaml not in base directory")
+		}
+		name := strings.Join(parts[1:], "/")
+		if name != "Chart.yaml" && name != "requirements.yaml" {
+			continue
+		}
+
+		if hd.Size > MaxDecompressedFileSize {
+			return nil, fmt.Errorf("decompressed chart file %q is larger than the maximum file size %d", hd.Name, MaxDecompressedFileSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, MaxDecompressedFileSize))
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, utf8bom)
+		if name == "Chart.yaml" {
+			chartYAML = data
+		} else {
+			requirementsYAML = data
+		}
+	}
+
+	if chartYAML == nil {
+		return nil, errors.New("Chart.yaml file is missing") //nolint:staticcheck
+	}
+	md := new(chart.Metadata)
+	if err := yaml.Unmarshal(chartYAML, md); err != nil {
+		return nil, fmt.Errorf("cannot load Chart.yaml: %w", err)
+	}
+	if md.APIVersion == "" {
+		md.APIVersion = chart.APIVersionV1
+	}
+	if requirementsYAML != nil {
+		if err := yaml.Unmarshal(requirementsYAML, md); err != nil {
+			return nil, fmt.Errorf("cannot load requirements.yaml: %w", err)
+		}
+	}
+	if err := md.Validate(); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
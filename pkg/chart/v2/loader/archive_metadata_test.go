@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadArchiveMetadata(t *testing.T) {
+	for _, name := range []string{
+		"testdata/frobnitz-1.2.3.tgz",
+		"testdata/frobnitz.v1.tgz",
+		"testdata/frobnitz_backslash-1.2.3.tgz",
+		"testdata/frobnitz_with_bom.tgz",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := Load(name)
+			if err != nil {
+				t.Fatalf("Failed to load testdata: %s", err)
+			}
+			f, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			md, err := LoadArchiveMetadata(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(md, c.Metadata) {
+				t.Errorf("expected metadata %+v, got %+v", c.Metadata, md)
+			}
+		})
+	}
+}
+
+func TestLoadArchiveMetadata_BadCases(t *testing.T) {
+	writeTar := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		zipper := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zipper)
+		for name, body := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(body))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tw.Close()
+		zipper.Close()
+		return &buf
+	}
+
+	for _, tt := range []struct {
+		name        string
+		files       map[string]string
+		expectError string
+	}{
+		{"missing Chart.yaml", map[string]string{"foo/values.yaml": "a: b"}, "Chart.yaml file is missing"},
+		{"Chart.yaml at the root", map[string]string{"Chart.yaml": "name: foo"}, "chart yaml not in base directory"},
+		{"invalid Chart.yaml", map[string]string{"foo/Chart.yaml": "name: [foo"}, "cannot load Chart.yaml"},
+		{"invalid metadata", map[string]string{"foo/Chart.yaml": "version: 1.0.0"}, "validation: chart.metadata.name is required"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadArchiveMetadata(writeTar(tt.files))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error to contain %q, got %q", tt.expectError, err)
+			}
+		})
+	}
+
+	if _, err := LoadArchiveMetadata(bytes.NewBufferString("not a gzip stream")); err == nil {
+		t.Error("expected an error for an archive which is not gzipped")
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -90,3 +92,150 @@ func TestLoadArchiveFiles(t *testing.T) {
 		})
 	}
 }
+
+// makeArchive returns a compressed tar archive of the files, in order.
+func makeArchive(t *testing.T, files ...*BufferedFile) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gzw)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.Name, Mode: 0644, Size: int64(len(f.Data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func setMaxDecompressedChartSize(t *testing.T, size int64) {
+	t.Helper()
+	previous := MaxDecompressedChartSize
+	MaxDecompressedChartSize = size
+	t.Cleanup(func() { MaxDecompressedChartSize = previous })
+}
+
+func TestLoadArchiveMaxDecompressedChartSize(t *testing.T) {
+	setMaxDecompressedChartSize(t, 1024)
+
+	chartYaml := &BufferedFile{Name: "frobnitz/Chart.yaml", Data: []byte("apiVersion: v2\nname: frobnitz\nversion: 1.0.0\n")}
+	// Highly compressible, so that the archive itself stays small.
+	bomb := &BufferedFile{Name: "frobnitz/templates/bomb.yaml", Data: []byte(strings.Repeat("a", 2048))}
+
+	if _, err := LoadArchive(bytes.NewReader(makeArchive(t, chartYaml))); err != nil {
+		t.Fatalf("unexpected error loading a chart within the limit: %v", err)
+	}
+
+	archive := makeArchive(t, chartYaml, bomb)
+	if len(archive) >= 1024 {
+		t.Fatalf("expected the archive to be smaller than the limit, got %d bytes", len(archive))
+	}
+	_, err := LoadArchive(bytes.NewReader(archive))
+	if !errors.Is(err, ErrChartTooLarge) {
+		t.Fatalf("expected ErrChartTooLarge, got %v", err)
+	}
+	expected := `decompressed chart is larger than the maximum size 1024: exceeded while decompressing "frobnitz/templates/bomb.yaml"`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestLoadArchiveMaxDecompressedChartSizeIncludesSubcharts(t *testing.T) {
+	setMaxDecompressedChartSize(t, 4096)
+
+	// Each archive is within the limit on its own, but not with the
+	// subchart archives it contains.
+	subchart := makeArchive(t,
+		&BufferedFile{Name: "sub/Chart.yaml", Data: []byte("apiVersion: v2\nname: sub\nversion: 1.0.0\n")},
+		&BufferedFile{Name: "sub/templates/big.yaml", Data: []byte(strings.Repeat("b", 3000))},
+	)
+	parent := makeArchive(t,
+		&BufferedFile{Name: "parent/Chart.yaml", Data: []byte("apiVersion: v2\nname: parent\nversion: 1.0.0\n")},
+		&BufferedFile{Name: "parent/templates/big.yaml", Data: []byte(strings.Repeat("p", 2000))},
+		&BufferedFile{Name: "parent/charts/sub-1.0.0.tgz", Data: subchart},
+	)
+
+	_, err := LoadArchive(bytes.NewReader(parent))
+	if !errors.Is(err, ErrChartTooLarge) {
+		t.Fatalf("expected ErrChartTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "subchart sub-1.0.0.tgz in parent") || !strings.Contains(err.Error(), `"sub/templates/big.yaml"`) {
+		t.Errorf("expected the error to name the subchart file, got %q", err.Error())
+	}
+
+	// Each chart loads on its own.
+	if _, err := LoadArchive(bytes.NewReader(subchart)); err != nil {
+		t.Fatalf("unexpected error loading the subchart: %v", err)
+	}
+}
+
+func TestLoadArchiveRejectsUnsafeEntries(t *testing.T) {
+	chartYaml := []byte("apiVersion: v2\nname: hostile\nversion: 1.0.0\n")
+	tcs := []struct {
+		name   string
+		header *tar.Header
+		reason string
+	}{
+		{
+			name:   "setuid file",
+			header: &tar.Header{Typeflag: tar.TypeReg, Name: "hostile/templates/cm.yaml", Mode: 04755},
+			reason: "it has the setuid or setgid bit",
+		},
+		{
+			name:   "setgid directory",
+			header: &tar.Header{Typeflag: tar.TypeDir, Name: "hostile/templates/", Mode: 02755},
+			reason: "it has the setuid or setgid bit",
+		},
+		{
+			name:   "character device",
+			header: &tar.Header{Typeflag: tar.TypeChar, Name: "hostile/null", Mode: 0644, Devmajor: 1, Devminor: 3},
+			reason: "it is a device",
+		},
+		{
+			name:   "block device",
+			header: &tar.Header{Typeflag: tar.TypeBlock, Name: "hostile/sda", Mode: 0644, Devmajor: 8},
+			reason: "it is a device",
+		},
+		{
+			name:   "fifo",
+			header: &tar.Header{Typeflag: tar.TypeFifo, Name: "hostile/pipe", Mode: 0644},
+			reason: "it is a FIFO",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			gzw := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gzw)
+			if err := tw.WriteHeader(&tar.Header{Name: "hostile/Chart.yaml", Mode: 0644, Size: int64(len(chartYaml))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(chartYaml); err != nil {
+				t.Fatal(err)
+			}
+			if err := tw.WriteHeader(tc.header); err != nil {
+				t.Fatal(err)
+			}
+			_ = tw.Close()
+			_ = gzw.Close()
+
+			_, err := LoadArchive(buf)
+			var unsafeErr *UnsafeArchiveEntryError
+			if !errors.As(err, &unsafeErr) {
+				t.Fatalf("expected an UnsafeArchiveEntryError, got %v", err)
+			}
+			if unsafeErr.Name != tc.header.Name || unsafeErr.Reason != tc.reason {
+				t.Errorf("expected entry %q to be unsafe because %s, got %+v", tc.header.Name, tc.reason, unsafeErr)
+			}
+		})
+	}
+}
@@ -42,20 +42,29 @@ func (l DirLoader) Load() (*chart.Chart, error) {
 //
 // This loads charts only from directories.
 func LoadDir(dir string) (*chart.Chart, error) {
+	files, err := LoadDirFiles(dir)
+	if err != nil {
+		// Just used for errors.
+		return &chart.Chart{}, err
+	}
+
+	return LoadFiles(files)
+}
+
+// LoadDirFiles reads in the files of a chart directory into memory, skipping
+// the files matched by its .helmignore.
+func LoadDirFiles(dir string) ([]*BufferedFile, error) {
 	topdir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	// Just used for errors.
-	c := &chart.Chart{}
-
 	rules := ignore.Empty()
 	ifile := filepath.Join(topdir, ignore.HelmIgnore)
 	if _, err := os.Stat(ifile); err == nil {
 		r, err := ignore.ParseFile(ifile)
 		if err != nil {
-			return c, err
+			return nil, err
 		}
 		rules = r
 	}
@@ -114,8 +123,8 @@ func LoadDir(dir string) (*chart.Chart, error) {
 		return nil
 	}
 	if err = sympath.Walk(topdir, walk); err != nil {
-		return c, err
+		return nil, err
 	}
 
-	return LoadFiles(files)
+	return files, nil
 }
@@ -74,8 +74,15 @@ type BufferedFile struct {
 
 // LoadFiles loads from in-memory files.
 func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
+	return loadFiles(files, newSizeBudget())
+}
+
+// loadFiles loads from in-memory files, decompressing the subchart archives
+// within the size left by budget.
+func loadFiles(files []*BufferedFile, budget *sizeBudget) (*chart.Chart, error) {
 	c := new(chart.Chart)
 	subcharts := make(map[string][]*BufferedFile)
+	var schemaYAML []byte
 
 	// do not rely on assumed ordering of files in the chart and crash
 	// if Chart.yaml was not coming early enough to initialize metadata
@@ -114,6 +121,11 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 			c.Values = values
 		case f.Name == "values.schema.json":
 			c.Schema = f.Data
+		case f.Name == SchemaYAMLFile:
+			// The original file is kept with the files of the chart, so that
+			// it is packaged instead of the JSON it is converted to.
+			c.Files = append(c.Files, &chart.File{Name: f.Name, Data: f.Data})
+			schemaYAML = f.Data
 
 		// Deprecated: requirements.yaml is deprecated use Chart.yaml.
 		// We will handle it for you because we are nice people
@@ -162,6 +174,12 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 		}
 	}
 
+	if schemaYAML != nil {
+		if err := loadSchemaYAML(c, schemaYAML); err != nil {
+			return c, err
+		}
+	}
+
 	if c.Metadata == nil {
 		return c, errors.New("Chart.yaml file is missing") //nolint:staticcheck
 	}
@@ -182,7 +200,7 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 				return c, fmt.Errorf("error unpacking subchart tar in %s: expected %s, got %s", c.Name(), n, file.Name)
 			}
 			// Untar the chart and add to c.Dependencies
-			sc, err = LoadArchive(bytes.NewBuffer(file.Data))
+			sc, err = loadArchive(bytes.NewBuffer(file.Data), budget)
 		default:
 			// We have to trim the prefix off of every file, and ignore any file
 			// that is in charts/, but isn't actually a chart.
@@ -195,7 +213,7 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 				f.Name = parts[1]
 				buff = append(buff, f)
 			}
-			sc, err = LoadFiles(buff)
+			sc, err = loadFiles(buff, budget)
 		}
 
 		if err != nil {
@@ -48,6 +48,25 @@ func TestLoadDir(t *testing.T) {
 	verifyDependenciesLock(t, c)
 }
 
+func TestLoadDirFiles(t *testing.T) {
+	files, err := LoadDirFiles("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	names := make(map[string]bool, len(files))
+	for _, f := range files {
+		names[f.Name] = true
+	}
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/template.tpl", "charts/alpine/Chart.yaml", "charts/mariner-4.3.2.tgz"} {
+		if !names[name] {
+			t.Errorf("Expected file %s to be loaded", name)
+		}
+	}
+	if names["ignore/me.txt"] {
+		t.Errorf("Expected the files matched by .helmignore to be skipped")
+	}
+}
+
 func TestLoadDirWithDevNull(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("test only works on unix systems with /dev/null present")
@@ -307,6 +326,59 @@ icon: https://example.com/64x64.png
 	}
 }
 
+func TestLoadFilesSchemaYAML(t *testing.T) {
+	chartfile := &BufferedFile{Name: "Chart.yaml", Data: []byte("apiVersion: v2\nname: schema\nversion: 0.1.0\n")}
+	schemaYAML := &BufferedFile{Name: SchemaYAMLFile, Data: []byte("type: object\nproperties:\n  name:\n    type: string\n")}
+	schemaJSON := `{"properties": {"name": {"type": "string"}}, "type": "object"}`
+
+	t.Run("yaml", func(t *testing.T) {
+		c, err := LoadFiles([]*BufferedFile{chartfile, schemaYAML})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(c.Schema) != `{"properties":{"name":{"type":"string"}},"type":"object"}` {
+			t.Errorf("unexpected schema converted from YAML: %s", c.Schema)
+		}
+		if len(c.Files) != 1 || c.Files[0].Name != SchemaYAMLFile {
+			t.Errorf("expected the YAML schema to be kept with the files, got %v", c.Files)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		c, err := LoadFiles([]*BufferedFile{chartfile, {Name: "values.schema.json", Data: []byte(schemaJSON)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(c.Schema) != schemaJSON {
+			t.Errorf("unexpected schema: %s", c.Schema)
+		}
+	})
+
+	t.Run("both with the same schema", func(t *testing.T) {
+		c, err := LoadFiles([]*BufferedFile{schemaYAML, chartfile, {Name: "values.schema.json", Data: []byte(schemaJSON)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(c.Schema) != schemaJSON {
+			t.Errorf("expected the JSON schema to be kept, got %s", c.Schema)
+		}
+	})
+
+	t.Run("both with different schemas", func(t *testing.T) {
+		_, err := LoadFiles([]*BufferedFile{chartfile, schemaYAML, {Name: "values.schema.json", Data: []byte(`{"type": "object"}`)}})
+		if err == nil || !strings.Contains(err.Error(), "values.schema.yaml and values.schema.json define different schemas") {
+			t.Errorf("expected the schemas to conflict, got %v", err)
+		}
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := LoadFiles([]*BufferedFile{chartfile, {Name: SchemaYAMLFile, Data: []byte("type: [object")}})
+		if err == nil || !strings.Contains(err.Error(), "cannot load values.schema.yaml") {
+			t.Errorf("expected the YAML schema to fail loading, got %v", err)
+		}
+	})
+}
+
 // Test the order of file loading. The Chart.yaml file needs to come first for
 // later comparison checks. See https://github.com/helm/helm/pull/8948
 func TestLoadFilesOrder(t *testing.T) {
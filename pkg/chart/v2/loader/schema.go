@@ -0,0 +1,62 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// SchemaYAMLFile is the name of the values schema of a chart written in YAML
+// rather than in JSON, in values.schema.json.
+const SchemaYAMLFile = "values.schema.yaml"
+
+// SchemaJSON converts a values schema written in YAML to JSON.
+func SchemaJSON(data []byte) ([]byte, error) {
+	return yaml.YAMLToJSON(data)
+}
+
+// loadSchemaYAML sets the schema of c from its values.schema.yaml. A
+// values.schema.json next to it must define the same schema, and is kept as
+// the schema of c.
+func loadSchemaYAML(c *chart.Chart, data []byte) error {
+	schema, err := SchemaJSON(data)
+	if err != nil {
+		return fmt.Errorf("cannot load %s: %w", SchemaYAMLFile, err)
+	}
+	if c.Schema == nil {
+		c.Schema = schema
+		return nil
+	}
+
+	var fromYAML, fromJSON interface{}
+	if err := json.Unmarshal(schema, &fromYAML); err != nil {
+		return fmt.Errorf("cannot load %s: %w", SchemaYAMLFile, err)
+	}
+	if err := json.Unmarshal(c.Schema, &fromJSON); err != nil {
+		return fmt.Errorf("cannot load values.schema.json: %w", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		return fmt.Errorf("%s and values.schema.json define different schemas: keep only one of them", SchemaYAMLFile)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"slices"
+	"strconv"
+)
+
+// kubeAPIVersionChanges lists, for each minor version of Kubernetes 1.x, the
+// API versions served by default by the API server which were added or
+// removed in that version. The first entry is the full set of its version.
+//
+// Only the versions enabled by default are listed: alpha versions, and beta
+// versions introduced since Kubernetes 1.24, must be enabled explicitly.
+var kubeAPIVersionChanges = []struct {
+	minor   int
+	added   []string
+	removed []string
+}{
+	{
+		minor: 22,
+		added: []string{
+			"v1",
+			"admissionregistration.k8s.io/v1",
+			"apiextensions.k8s.io/v1",
+			"apiregistration.k8s.io/v1",
+			"apps/v1",
+			"authentication.k8s.io/v1",
+			"authorization.k8s.io/v1",
+			"autoscaling/v1",
+			"autoscaling/v2beta1",
+			"autoscaling/v2beta2",
+			"batch/v1",
+			"batch/v1beta1",
+			"certificates.k8s.io/v1",
+			"coordination.k8s.io/v1",
+			"discovery.k8s.io/v1",
+			"discovery.k8s.io/v1beta1",
+			"events.k8s.io/v1",
+			"events.k8s.io/v1beta1",
+			"flowcontrol.apiserver.k8s.io/v1beta1",
+			"networking.k8s.io/v1",
+			"node.k8s.io/v1",
+			"node.k8s.io/v1beta1",
+			"policy/v1",
+			"policy/v1beta1",
+			"rbac.authorization.k8s.io/v1",
+			"scheduling.k8s.io/v1",
+			"storage.k8s.io/v1",
+			"storage.k8s.io/v1beta1",
+		},
+	},
+	{minor: 23, added: []string{"autoscaling/v2", "flowcontrol.apiserver.k8s.io/v1beta2"}},
+	{minor: 24},
+	{
+		minor: 25,
+		removed: []string{
+			"autoscaling/v2beta1",
+			"batch/v1beta1",
+			"discovery.k8s.io/v1beta1",
+			"events.k8s.io/v1beta1",
+			"node.k8s.io/v1beta1",
+			"policy/v1beta1",
+		},
+	},
+	{
+		minor:   26,
+		added:   []string{"flowcontrol.apiserver.k8s.io/v1beta3"},
+		removed: []string{"autoscaling/v2beta2", "flowcontrol.apiserver.k8s.io/v1beta1"},
+	},
+	{minor: 27, removed: []string{"storage.k8s.io/v1beta1"}},
+	{minor: 28},
+	{
+		minor:   29,
+		added:   []string{"flowcontrol.apiserver.k8s.io/v1"},
+		removed: []string{"flowcontrol.apiserver.k8s.io/v1beta2"},
+	},
+	{minor: 30},
+	{minor: 31},
+	{minor: 32, removed: []string{"flowcontrol.apiserver.k8s.io/v1beta3"}},
+	{minor: 33},
+}
+
+// KubeAPIVersions returns the API versions served by default by the API
+// server of the given Kubernetes version, and false if the version is not
+// known to Helm.
+func KubeAPIVersions(kv KubeVersion) (VersionSet, bool) {
+	minor, err := strconv.Atoi(kv.Minor)
+	first, last := kubeAPIVersionChanges[0].minor, kubeAPIVersionChanges[len(kubeAPIVersionChanges)-1].minor
+	if kv.Major != "1" || err != nil || minor < first || minor > last {
+		return nil, false
+	}
+
+	var vs VersionSet
+	for _, changes := range kubeAPIVersionChanges[:minor-first+1] {
+		vs = slices.DeleteFunc(vs, func(v string) bool { return slices.Contains(changes.removed, v) })
+		vs = append(vs, changes.added...)
+	}
+	return vs, true
+}
@@ -0,0 +1,77 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import "testing"
+
+func TestKubeAPIVersions(t *testing.T) {
+	tests := []struct {
+		version string
+		has     []string
+		hasNot  []string
+	}{
+		{"v1.22.0", []string{"v1", "policy/v1", "policy/v1beta1", "autoscaling/v2beta1"}, []string{"autoscaling/v2", "networking.k8s.io/v1beta1"}},
+		{"v1.24.17", []string{"policy/v1beta1", "batch/v1beta1", "autoscaling/v2"}, nil},
+		{"v1.25.0", []string{"policy/v1", "batch/v1", "autoscaling/v2beta2"}, []string{"policy/v1beta1", "batch/v1beta1", "autoscaling/v2beta1"}},
+		{"v1.26.3", []string{"flowcontrol.apiserver.k8s.io/v1beta3", "storage.k8s.io/v1beta1"}, []string{"autoscaling/v2beta2", "flowcontrol.apiserver.k8s.io/v1beta1"}},
+		{"v1.27.0", nil, []string{"storage.k8s.io/v1beta1"}},
+		{"v1.29.0", []string{"flowcontrol.apiserver.k8s.io/v1", "flowcontrol.apiserver.k8s.io/v1beta3"}, []string{"flowcontrol.apiserver.k8s.io/v1beta2"}},
+		{"v1.33.1", []string{"v1", "apps/v1", "policy/v1", "apiextensions.k8s.io/v1", "flowcontrol.apiserver.k8s.io/v1"}, []string{"policy/v1beta1", "flowcontrol.apiserver.k8s.io/v1beta3"}},
+	}
+	for _, tt := range tests {
+		kv, err := ParseKubeVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, ok := KubeAPIVersions(*kv)
+		if !ok {
+			t.Errorf("Expected the API versions of %s to be known", tt.version)
+			continue
+		}
+		for _, v := range tt.has {
+			if !vs.Has(v) {
+				t.Errorf("Expected %s to serve %s", tt.version, v)
+			}
+		}
+		for _, v := range tt.hasNot {
+			if vs.Has(v) {
+				t.Errorf("Expected %s not to serve %s", tt.version, v)
+			}
+		}
+	}
+}
+
+func TestKubeAPIVersionsUnknown(t *testing.T) {
+	for _, version := range []string{"v1.21.0", "v1.99.0", "v2.0.0"} {
+		kv, err := ParseKubeVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vs, ok := KubeAPIVersions(*kv); ok || vs != nil {
+			t.Errorf("Expected the API versions of %s to be unknown, got %v", version, vs)
+		}
+	}
+}
+
+func TestKubeAPIVersionsIndependent(t *testing.T) {
+	kv, _ := ParseKubeVersion("v1.30.0")
+	vs, _ := KubeAPIVersions(*kv)
+	vs[0] = "example.com/v1"
+	if vs, _ := KubeAPIVersions(*kv); vs.Has("example.com/v1") {
+		t.Error("Expected the API versions returned to be a copy")
+	}
+}
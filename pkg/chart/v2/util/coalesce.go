@@ -73,12 +73,11 @@ func MergeValues(chrt *chart.Chart, vals map[string]interface{}) (Values, error)
 }
 
 func copyValues(vals map[string]interface{}) (Values, error) {
-	v, err := copystructure.Copy(vals)
+	valsCopy, err := deepCopyTable(vals)
 	if err != nil {
 		return vals, err
 	}
 
-	valsCopy := v.(map[string]interface{})
 	// if we have an empty map, make sure it is initialized
 	if valsCopy == nil {
 		valsCopy = make(map[string]interface{})
@@ -87,6 +86,50 @@ func copyValues(vals map[string]interface{}) (Values, error) {
 	return valsCopy, nil
 }
 
+// deepCopyTable returns a deep copy of a table of values.
+func deepCopyTable(table map[string]interface{}) (map[string]interface{}, error) {
+	if table == nil {
+		return nil, nil
+	}
+	tableCopy := make(map[string]interface{}, len(table))
+	for key, val := range table {
+		valCopy, err := deepCopyValue(val)
+		if err != nil {
+			return nil, err
+		}
+		tableCopy[key] = valCopy
+	}
+	return tableCopy, nil
+}
+
+// deepCopyValue returns a deep copy of a value. The tables, lists and scalars
+// values are made of when they are read from YAML or JSON are copied directly,
+// as copying them with reflection is the bulk of the time spent coalescing the
+// values of charts with many dependencies. Anything else is copied with
+// copystructure.
+func deepCopyValue(val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case map[string]interface{}:
+		return deepCopyTable(val)
+	case []interface{}:
+		if val == nil {
+			return val, nil
+		}
+		listCopy := make([]interface{}, len(val))
+		for i, item := range val {
+			itemCopy, err := deepCopyValue(item)
+			if err != nil {
+				return nil, err
+			}
+			listCopy[i] = itemCopy
+		}
+		return listCopy, nil
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return val, nil
+	}
+	return copystructure.Copy(val)
+}
+
 type printFn func(format string, v ...interface{})
 
 // coalesce coalesces the dest values and the chart values, giving priority to the dest values.
@@ -196,9 +239,7 @@ func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, pr
 	// Using c.Values directly when coalescing a table can cause problems where
 	// the original c.Values is altered. Creating a deep copy stops the problem.
 	// This section is fault-tolerant as there is no ability to return an error.
-	valuesCopy, err := copystructure.Copy(c.Values)
-	var vc map[string]interface{}
-	var ok bool
+	vc, err := deepCopyTable(c.Values)
 	if err != nil {
 		// If there is an error something is wrong with copying c.Values it
 		// means there is a problem in the deep copying package or something
@@ -206,15 +247,6 @@ func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, pr
 		// an error.
 		printf("warning: unable to copy values, err: %s", err)
 		vc = c.Values
-	} else {
-		vc, ok = valuesCopy.(map[string]interface{})
-		if !ok {
-			// c.Values has a map[string]interface{} structure. If the copy of
-			// it cannot be treated as map[string]interface{} there is something
-			// strangely wrong. Log it and use c.Values
-			printf("warning: unable to convert values copy to values type")
-			vc = c.Values
-		}
 	}
 
 	for key, val := range vc {
@@ -721,3 +721,135 @@ func TestConcatPrefix(t *testing.T) {
 	assert.Equal(t, "b", concatPrefix("", "b"))
 	assert.Equal(t, "a.b", concatPrefix("a", "b"))
 }
+
+func TestDeepCopyValue(t *testing.T) {
+	type custom struct{ Name string }
+	vals := map[string]interface{}{
+		"table":     map[string]interface{}{"list": []interface{}{"a", map[string]interface{}{"b": 1}}},
+		"nilTable":  map[string]interface{}(nil),
+		"nilList":   []interface{}(nil),
+		"null":      nil,
+		"number":    int64(1),
+		"custom":    &custom{Name: "c"},
+		"stringMap": map[string]string{"d": "e"},
+	}
+
+	valsCopy, err := deepCopyTable(vals)
+	assert.NoError(t, err)
+	assert.Equal(t, vals, valsCopy)
+
+	// Typed nils are kept as they are.
+	assert.Equal(t, map[string]interface{}(nil), valsCopy["nilTable"])
+	assert.Equal(t, []interface{}(nil), valsCopy["nilList"])
+
+	// The copy shares nothing with the values.
+	valsCopy["table"].(map[string]interface{})["list"].([]interface{})[1].(map[string]interface{})["b"] = 2
+	valsCopy["custom"].(*custom).Name = "changed"
+	valsCopy["stringMap"].(map[string]string)["d"] = "changed"
+	assert.Equal(t, 1, vals["table"].(map[string]interface{})["list"].([]interface{})[1].(map[string]interface{})["b"])
+	assert.Equal(t, "c", vals["custom"].(*custom).Name)
+	assert.Equal(t, "e", vals["stringMap"].(map[string]string)["d"])
+
+	nilCopy, err := deepCopyTable(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, nilCopy)
+}
+
+// benchmarkChartValues returns the kind of values a chart of an umbrella chart
+// has: a few tables, lists and a large configuration table.
+func benchmarkChartValues(name string) map[string]interface{} {
+	config := make(map[string]interface{}, 50)
+	for i := range 50 {
+		config[fmt.Sprintf("key%d", i)] = map[string]interface{}{
+			"enabled": i%2 == 0,
+			"value":   fmt.Sprintf("%s-%d", name, i),
+			"weight":  float64(i),
+		}
+	}
+	env := make([]interface{}, 0, 10)
+	for i := range 10 {
+		env = append(env, map[string]interface{}{"name": fmt.Sprintf("VAR_%d", i), "value": name})
+	}
+	return map[string]interface{}{
+		"enabled":      true,
+		"replicaCount": float64(1),
+		"image":        map[string]interface{}{"repository": "example.com/" + name, "tag": "1.0.0", "pullPolicy": "IfNotPresent"},
+		"resources": map[string]interface{}{
+			"limits":   map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+			"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+		},
+		"env":      env,
+		"config":   config,
+		"disabled": nil,
+		"exports":  map[string]interface{}{"data": map[string]interface{}{"name": name}},
+	}
+}
+
+// benchmarkChartTree returns a chart whose dependencies are width charts, each
+// with width dependencies, down to depth levels.
+func benchmarkChartTree(name string, depth, width int) *chart.Chart {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: "1.0.0"},
+		Values:   benchmarkChartValues(name),
+	}
+	if depth == 0 {
+		return c
+	}
+	for i := range width {
+		sub := benchmarkChartTree(fmt.Sprintf("%s-%d", name, i), depth-1, width)
+		c.AddDependency(sub)
+		c.Metadata.Dependencies = append(c.Metadata.Dependencies, &chart.Dependency{
+			Name:         sub.Name(),
+			Version:      "1.0.0",
+			Condition:    sub.Name() + ".enabled",
+			ImportValues: []interface{}{"data"},
+		})
+	}
+	return c
+}
+
+// The deep tree is an umbrella chart with 62 subcharts, 5 levels deep.
+const benchmarkTreeDepth, benchmarkTreeWidth = 5, 2
+
+func benchmarkUserValues() map[string]interface{} {
+	return map[string]interface{}{
+		"global": map[string]interface{}{
+			"imageRegistry": "registry.example.com",
+			"labels":        map[string]interface{}{"team": "platform"},
+		},
+		"umbrella-0": map[string]interface{}{
+			"replicaCount": float64(3),
+			"umbrella-0-1": map[string]interface{}{"enabled": false},
+		},
+		"umbrella-1": map[string]interface{}{
+			"config": map[string]interface{}{"key1": nil},
+		},
+	}
+}
+
+func BenchmarkCoalesceValues(b *testing.B) {
+	c := benchmarkChartTree("umbrella", benchmarkTreeDepth, benchmarkTreeWidth)
+	vals := benchmarkUserValues()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := CoalesceValues(c, vals); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessDependencies(b *testing.B) {
+	vals := benchmarkUserValues()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		// ProcessDependencies alters the chart, which must be built again.
+		b.StopTimer()
+		c := benchmarkChartTree("umbrella", benchmarkTreeDepth, benchmarkTreeWidth)
+		b.StartTimer()
+		if err := ProcessDependencies(c, vals); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
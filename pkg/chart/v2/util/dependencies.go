@@ -16,11 +16,10 @@ limitations under the License.
 package util
 
 import (
+	"log"
 	"log/slog"
 	"strings"
 
-	"github.com/mitchellh/copystructure"
-
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 )
 
@@ -32,6 +31,42 @@ func ProcessDependencies(c *chart.Chart, v Values) error {
 	return processDependencyImportValues(c, true)
 }
 
+// EnabledDependencies returns the subcharts of c which the conditions and tags
+// of the dependencies enable with the values v, as ProcessDependencies would
+// keep them. Each subchart is given by its path from c, such as
+// "subchart1.subcharta", the aliased subcharts being named after their alias.
+// c itself is left unchanged.
+func EnabledDependencies(c *chart.Chart, v Values) (map[string]bool, error) {
+	cpy := copyChartTree(c)
+	if err := processDependencyEnabled(cpy, v, ""); err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool)
+	var walk func(c *chart.Chart, path string)
+	walk = func(c *chart.Chart, path string) {
+		for _, d := range c.Dependencies() {
+			enabled[path+d.Name()] = true
+			walk(d, path+d.Name()+".")
+		}
+	}
+	walk(cpy, "")
+	return enabled, nil
+}
+
+// copyChartTree copies c and its subcharts, with their metadata, so that the
+// dependencies of the copy can be processed without altering c.
+func copyChartTree(c *chart.Chart) *chart.Chart {
+	out := *c
+	out.Metadata = copyMetadata(c.Metadata)
+	out.Values = deepCopyMap(c.Values)
+	deps := make([]*chart.Chart, 0, len(c.Dependencies()))
+	for _, d := range c.Dependencies() {
+		deps = append(deps, copyChartTree(d))
+	}
+	out.SetDependencies(deps...)
+	return &out
+}
+
 // processDependencyConditions disables charts based on condition path value in values
 func processDependencyConditions(reqs []*chart.Dependency, cvals Values, cpath string) {
 	if reqs == nil {
@@ -139,6 +174,13 @@ func copyMetadata(metadata *chart.Metadata) *chart.Metadata {
 
 // processDependencyEnabled removes disabled charts from dependencies
 func processDependencyEnabled(c *chart.Chart, v map[string]interface{}, path string) error {
+	return processDependencyEnabledValues(c, v, path, false)
+}
+
+// processDependencyEnabledValues is processDependencyEnabled, coalescing the
+// values of the chart into v in place rather than into a copy of v when v is
+// owned by the call.
+func processDependencyEnabledValues(c *chart.Chart, v map[string]interface{}, path string, owned bool) error {
 	if c.Metadata.Dependencies == nil {
 		return nil
 	}
@@ -176,7 +218,13 @@ Loop:
 	for _, lr := range c.Metadata.Dependencies {
 		lr.Enabled = true
 	}
-	cvals, err := CoalesceValues(c, v)
+	var cvals Values
+	var err error
+	if owned {
+		cvals, err = coalesce(log.Printf, c, v, "", false)
+	} else {
+		cvals, err = CoalesceValues(c, v)
+	}
 	if err != nil {
 		return err
 	}
@@ -208,10 +256,12 @@ Loop:
 		}
 	}
 
-	// recursively call self to process sub dependencies
-	for _, t := range cd {
+	// recursively call self to process sub dependencies. Every dependency
+	// alters its own copy of the values, except the last one, which is given
+	// the values as they are no longer needed here.
+	for i, t := range cd {
 		subpath := path + t.Metadata.Name + "."
-		if err := processDependencyEnabled(t, cvals, subpath); err != nil {
+		if err := processDependencyEnabledValues(t, cvals, subpath, i == len(cd)-1); err != nil {
 			return err
 		}
 	}
@@ -328,30 +378,35 @@ func processImportValues(c *chart.Chart, merge bool) error {
 }
 
 func deepCopyMap(vals map[string]interface{}) map[string]interface{} {
-	valsCopy, err := copystructure.Copy(vals)
+	valsCopy, err := deepCopyTable(vals)
 	if err != nil {
 		return vals
 	}
-	return valsCopy.(map[string]interface{})
+	return valsCopy
 }
 
+// trimNilValues returns a copy of vals without the nil values of its tables.
 func trimNilValues(vals map[string]interface{}) map[string]interface{} {
-	valsCopy, err := copystructure.Copy(vals)
+	valsCopy, err := deepCopyTable(vals)
 	if err != nil {
 		return vals
 	}
-	valsCopyMap := valsCopy.(map[string]interface{})
-	for key, val := range valsCopyMap {
+	// The copy belongs to us, and is trimmed in place rather than copied
+	// again for every nested table.
+	trimNils(valsCopy)
+	return valsCopy
+}
+
+func trimNils(vals map[string]interface{}) {
+	for key, val := range vals {
 		if val == nil {
 			// Iterate over the values and remove nil keys
-			delete(valsCopyMap, key)
+			delete(vals, key)
 		} else if istable(val) {
 			// Recursively call into ourselves to remove keys from inner tables
-			valsCopyMap[key] = trimNilValues(val.(map[string]interface{}))
+			trimNils(val.(map[string]interface{}))
 		}
 	}
-
-	return valsCopyMap
 }
 
 // processDependencyImportValues imports specified chart values from child to parent.
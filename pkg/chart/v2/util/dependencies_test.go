@@ -17,6 +17,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"testing"
@@ -133,6 +134,35 @@ func TestDependencyEnabled(t *testing.T) {
 	}
 }
 
+func TestEnabledDependencies(t *testing.T) {
+	type M = map[string]interface{}
+	c := loadChart(t, "testdata/subpop")
+
+	enabled, err := EnabledDependencies(c, M{"subchart1": M{"enabled": false}, "subchart2alias": M{"enabled": true, "subchartb": M{"enabled": true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expect := []string{"subchart2alias", "subchart2alias.subchartb"}
+	if !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected the enabled subcharts %v, got %v", expect, names)
+	}
+
+	// The chart is left as it was loaded.
+	if got := len(c.Dependencies()); got != 2 {
+		t.Errorf("expected the chart to keep its 2 subcharts, got %d", got)
+	}
+	for _, dep := range c.Metadata.Dependencies {
+		if dep.Alias != "" && dep.Name == dep.Alias {
+			t.Errorf("expected the dependency %s to keep its name", dep.Alias)
+		}
+	}
+}
+
 // extractChartNames recursively searches chart dependencies returning all charts found
 func extractChartNames(c *chart.Chart) []string {
 	var out []string
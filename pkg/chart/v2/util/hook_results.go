@@ -0,0 +1,66 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+// HookResults is the .HookResults template context. It maps the name of each
+// hook (or its generateName, for hooks which use one) to the object the hook
+// created.
+//
+// It is only populated for hooks annotated with helm.sh/hook-render: late,
+// which are rendered right before they run. Anywhere else it is empty, and
+// looking up a hook returns a zero HookResult.
+type HookResults map[string]HookResult
+
+// HookResult describes the object created by a hook, as seen by templates.
+type HookResult struct {
+	kind      string
+	namespace string
+	name      string
+	uid       string
+	status    map[string]interface{}
+	// err is set for hooks which have not run yet, and returned when the
+	// result is used.
+	err error
+}
+
+// NewHookResult returns the result of a hook which created the given object.
+func NewHookResult(kind, namespace, name, uid string, status map[string]interface{}) HookResult {
+	return HookResult{kind: kind, namespace: namespace, name: name, uid: uid, status: status}
+}
+
+// PendingHookResult returns the result of a hook which has not run yet. Using
+// it from a template fails with err.
+func PendingHookResult(err error) HookResult {
+	return HookResult{err: err}
+}
+
+// Kind returns the kind of the object.
+func (r HookResult) Kind() (string, error) { return r.kind, r.err }
+
+// Namespace returns the namespace of the object.
+func (r HookResult) Namespace() (string, error) { return r.namespace, r.err }
+
+// Name returns the name of the object, as assigned by the cluster when the
+// hook uses generateName.
+func (r HookResult) Name() (string, error) { return r.name, r.err }
+
+// UID returns the UID of the object.
+func (r HookResult) UID() (string, error) { return r.uid, r.err }
+
+// Status returns selected fields of the status of the object, once the hook
+// completed, such as "phase", "succeeded" or "conditions".
+func (r HookResult) Status() (map[string]interface{}, error) { return r.status, r.err }
@@ -63,31 +63,48 @@ func ValidateAgainstSingleSchema(values Values, schemaJSON []byte) (reterr error
 		}
 	}()
 
-	// This unmarshal function leverages UseNumber() for number precision. The parser
-	// used for values does this as well.
-	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJSON))
+	validator, err := compileSchema(schemaJSON)
 	if err != nil {
 		return err
 	}
-	slog.Debug("unmarshalled JSON schema", "schema", schemaJSON)
 
-	compiler := jsonschema.NewCompiler()
-	err = compiler.AddResource("file:///values.schema.json", schema)
+	err = validator.Validate(values.AsMap())
 	if err != nil {
-		return err
+		return JSONSchemaValidationError{err}
 	}
 
-	validator, err := compiler.Compile("file:///values.schema.json")
+	return nil
+}
+
+// CompileSchema checks that schemaJSON is a valid JSON Schema, of the draft it
+// declares with $schema or of the latest one.
+func CompileSchema(schemaJSON []byte) (reterr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			reterr = fmt.Errorf("unable to compile schema: %s", r)
+		}
+	}()
+
+	_, err := compileSchema(schemaJSON)
+	return err
+}
+
+func compileSchema(schemaJSON []byte) (*jsonschema.Schema, error) {
+	// This unmarshal function leverages UseNumber() for number precision. The parser
+	// used for values does this as well.
+	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJSON))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	slog.Debug("unmarshalled JSON schema", "schema", schemaJSON)
 
-	err = validator.Validate(values.AsMap())
+	compiler := jsonschema.NewCompiler()
+	err = compiler.AddResource("file:///values.schema.json", schema)
 	if err != nil {
-		return JSONSchemaValidationError{err}
+		return nil, err
 	}
 
-	return nil
+	return compiler.Compile("file:///values.schema.json")
 }
 
 // Note, JSONSchemaValidationError is used to wrap the error from the underlying
@@ -18,22 +18,77 @@ package util
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"sigs.k8s.io/yaml"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
 )
 
 var headerBytes = []byte("+aHR0cHM6Ly95b3V0dS5iZS96OVV6MWljandyTQo=")
 
+const (
+	// DefaultArchiveFileMode is the mode of the files of a chart archive.
+	DefaultArchiveFileMode fs.FileMode = 0644
+	// DefaultArchiveDirMode is the mode of the directories of a chart archive.
+	DefaultArchiveDirMode fs.FileMode = 0755
+)
+
+// SaveOptions sets how the entries of a chart archive are written. Whatever
+// the modes and owners of the files the chart was loaded from, all the files
+// of the archive get FileMode, all the directories get DirMode, and no entry
+// records an owner, so that the archive does not depend on the platform or
+// the umask it was packaged with.
+type SaveOptions struct {
+	// FileMode is the mode of the files. It defaults to DefaultArchiveFileMode.
+	FileMode fs.FileMode
+	// DirMode is the mode of the directories. It defaults to
+	// DefaultArchiveDirMode.
+	DirMode fs.FileMode
+	// ModTime is the modification time of the entries. It defaults to the
+	// time the archive is written, or to the Unix epoch if Reproducible is set.
+	ModTime time.Time
+	// Reproducible writes the entries sorted by path, so that the archive
+	// only depends on the content of the chart, not on the order of its
+	// files. Along with a fixed ModTime, packaging the same chart twice
+	// produces the same bytes.
+	Reproducible bool
+}
+
+func (o SaveOptions) withDefaults() (SaveOptions, error) {
+	if o.FileMode == 0 {
+		o.FileMode = DefaultArchiveFileMode
+	}
+	if o.DirMode == 0 {
+		o.DirMode = DefaultArchiveDirMode
+	}
+	if o.FileMode&^fs.ModePerm != 0 {
+		return o, fmt.Errorf("invalid file mode %#o: only permission bits may be set", uint32(o.FileMode))
+	}
+	if o.DirMode&^fs.ModePerm != 0 {
+		return o, fmt.Errorf("invalid directory mode %#o: only permission bits may be set", uint32(o.DirMode))
+	}
+	if o.ModTime.IsZero() {
+		o.ModTime = time.Now()
+		if o.Reproducible {
+			o.ModTime = time.Unix(0, 0)
+		}
+	}
+	return o, nil
+}
+
 // SaveDir saves a chart as files in a directory.
 //
 // This takes the chart name, and creates a new subdirectory inside of the given dest
@@ -68,7 +123,7 @@ func SaveDir(c *chart.Chart, dest string) error {
 	}
 
 	// Save values.schema.json if it exists
-	if c.Schema != nil {
+	if c.Schema != nil && !schemaFromYAMLFile(c) {
 		filename := filepath.Join(outdir, SchemafileName)
 		if err := writeFile(filename, c.Schema); err != nil {
 			return err
@@ -105,9 +160,18 @@ func SaveDir(c *chart.Chart, dest string) error {
 //
 // This returns the absolute path to the chart archive file.
 func Save(c *chart.Chart, outDir string) (string, error) {
+	return SaveWithOptions(c, outDir, SaveOptions{})
+}
+
+// SaveWithOptions is Save, writing the entries of the archive as set by opts.
+func SaveWithOptions(c *chart.Chart, outDir string, opts SaveOptions) (string, error) {
 	if err := c.Validate(); err != nil {
 		return "", fmt.Errorf("chart validation: %w", err)
 	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
 
 	filename := fmt.Sprintf("%s-%s.tgz", c.Name(), c.Metadata.Version)
 	filename = filepath.Join(outDir, filename)
@@ -129,13 +193,14 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 		return "", err
 	}
 
-	// Wrap in gzip writer
+	// Wrap in gzip writer. Its header records no modification time, which
+	// would change the bytes of the archive each time it is written.
 	zipper := gzip.NewWriter(f)
 	zipper.Extra = headerBytes
 	zipper.Comment = "Helm"
 
 	// Wrap in tar writer
-	twriter := tar.NewWriter(zipper)
+	twriter := &tarWriter{Writer: tar.NewWriter(zipper), opts: opts, dirs: map[string]bool{}}
 	rollback := false
 	defer func() {
 		twriter.Close()
@@ -150,10 +215,14 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 		rollback = true
 		return filename, err
 	}
+	if err := twriter.flush(); err != nil {
+		rollback = true
+		return filename, err
+	}
 	return filename, nil
 }
 
-func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
+func writeTarContents(out *tarWriter, c *chart.Chart, prefix string) error {
 	err := validateName(c.Name())
 	if err != nil {
 		return err
@@ -174,7 +243,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	if err != nil {
 		return err
 	}
-	if err := writeToTar(out, filepath.Join(base, ChartfileName), cdata); err != nil {
+	if err := out.writeFile(filepath.Join(base, ChartfileName), cdata); err != nil {
 		return err
 	}
 
@@ -186,7 +255,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 			if err != nil {
 				return err
 			}
-			if err := writeToTar(out, filepath.Join(base, "Chart.lock"), ldata); err != nil {
+			if err := out.writeFile(filepath.Join(base, "Chart.lock"), ldata); err != nil {
 				return err
 			}
 		}
@@ -195,18 +264,18 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save values.yaml
 	for _, f := range c.Raw {
 		if f.Name == ValuesfileName {
-			if err := writeToTar(out, filepath.Join(base, ValuesfileName), f.Data); err != nil {
+			if err := out.writeFile(filepath.Join(base, ValuesfileName), f.Data); err != nil {
 				return err
 			}
 		}
 	}
 
 	// Save values.schema.json if it exists
-	if c.Schema != nil {
+	if c.Schema != nil && !schemaFromYAMLFile(c) {
 		if !json.Valid(c.Schema) {
 			return errors.New("invalid JSON in " + SchemafileName)
 		}
-		if err := writeToTar(out, filepath.Join(base, SchemafileName), c.Schema); err != nil {
+		if err := out.writeFile(filepath.Join(base, SchemafileName), c.Schema); err != nil {
 			return err
 		}
 	}
@@ -214,7 +283,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save templates
 	for _, f := range c.Templates {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := out.writeFile(n, f.Data); err != nil {
 			return err
 		}
 	}
@@ -222,7 +291,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save files
 	for _, f := range c.Files {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := out.writeFile(n, f.Data); err != nil {
 			return err
 		}
 	}
@@ -236,14 +305,57 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	return nil
 }
 
-// writeToTar writes a single file to a tar archive.
-func writeToTar(out *tar.Writer, name string, body []byte) error {
-	// TODO: Do we need to create dummy parent directory names if none exist?
+// tarWriter writes the files of a chart archive, along with their parent
+// directories, as set by the SaveOptions.
+type tarWriter struct {
+	*tar.Writer
+	opts SaveOptions
+	// dirs holds the directories already written.
+	dirs map[string]bool
+	// pending holds the files of a reproducible archive, which are written
+	// sorted by flush.
+	pending []pendingFile
+}
+
+type pendingFile struct {
+	name string
+	body []byte
+}
+
+// writeFile writes a single file to a tar archive.
+func (out *tarWriter) writeFile(name string, body []byte) error {
+	name = filepath.ToSlash(name)
+	if out.opts.Reproducible {
+		out.pending = append(out.pending, pendingFile{name: name, body: body})
+		return nil
+	}
+	return out.writeEntry(name, body)
+}
+
+// flush writes the pending files, sorted by path.
+func (out *tarWriter) flush() error {
+	slices.SortFunc(out.pending, func(a, b pendingFile) int {
+		return strings.Compare(a.name, b.name)
+	})
+	for _, f := range out.pending {
+		if err := out.writeEntry(f.name, f.body); err != nil {
+			return err
+		}
+	}
+	out.pending = nil
+	return nil
+}
+
+// writeEntry writes the file and its parent directories.
+func (out *tarWriter) writeEntry(name string, body []byte) error {
+	if err := out.writeDir(path.Dir(name)); err != nil {
+		return err
+	}
 	h := &tar.Header{
-		Name:    filepath.ToSlash(name),
-		Mode:    0644,
+		Name:    name,
+		Mode:    int64(out.opts.FileMode),
 		Size:    int64(len(body)),
-		ModTime: time.Now(),
+		ModTime: out.opts.ModTime,
 	}
 	if err := out.WriteHeader(h); err != nil {
 		return err
@@ -252,6 +364,24 @@ func writeToTar(out *tar.Writer, name string, body []byte) error {
 	return err
 }
 
+// writeDir writes the directory dir, and its parents, unless they were
+// already written.
+func (out *tarWriter) writeDir(dir string) error {
+	if dir == "." || dir == "/" || out.dirs[dir] {
+		return nil
+	}
+	if err := out.writeDir(path.Dir(dir)); err != nil {
+		return err
+	}
+	out.dirs[dir] = true
+	return out.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dir + "/",
+		Mode:     int64(out.opts.DirMode),
+		ModTime:  out.opts.ModTime,
+	})
+}
+
 // If the name has directory name has characters which would change the location
 // they need to be removed.
 func validateName(name string) error {
@@ -263,3 +393,15 @@ func validateName(name string) error {
 
 	return nil
 }
+
+// schemaFromYAMLFile reports whether the schema of c is the one converted from
+// its values.schema.yaml, which is saved with its files instead of it.
+func schemaFromYAMLFile(c *chart.Chart) bool {
+	for _, f := range c.Files {
+		if f.Name == loader.SchemaYAMLFile {
+			schema, err := loader.SchemaJSON(f.Data)
+			return err == nil && bytes.Equal(schema, c.Schema)
+		}
+	}
+	return false
+}
@@ -20,11 +20,14 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -262,3 +265,209 @@ func TestSaveDir(t *testing.T) {
 		t.Fatalf("Did not get expected error for chart named %q", c.Name())
 	}
 }
+
+func TestSaveNormalizesEntries(t *testing.T) {
+	// The same chart, as checked out on platforms or with umasks giving its
+	// files different modes.
+	src := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "ahab",
+			Version:    "1.2.3",
+		},
+		Templates: []*chart.File{
+			{Name: path.Join(TemplatesDir, "nested", "thing.yaml"), Data: []byte("abc: {{ .Values.abc }}")},
+		},
+		Files: []*chart.File{
+			{Name: "scheherazade/shahryar.txt", Data: []byte("1,001 Nights")},
+		},
+	}
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var digests []string
+	for _, mode := range []os.FileMode{0777, 0600} {
+		dir := t.TempDir()
+		if err := SaveDir(src, dir); err != nil {
+			t.Fatal(err)
+		}
+		err := filepath.Walk(filepath.Join(dir, "ahab"), func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			return os.Chmod(p, mode)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := loader.LoadDir(filepath.Join(dir, "ahab"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		where, err := SaveWithOptions(c, t.TempDir(), SaveOptions{ModTime: modTime})
+		if err != nil {
+			t.Fatalf("Failed to save: %s", err)
+		}
+		data, err := os.ReadFile(where)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, fmt.Sprintf("%x", sha256.Sum256(data)))
+
+		headers, err := retrieveAllHeadersFromTar(where)
+		if err != nil {
+			t.Fatalf("Failed to parse tar: %v", err)
+		}
+		for _, h := range headers {
+			expected := int64(DefaultArchiveFileMode)
+			if h.Typeflag == tar.TypeDir {
+				expected = int64(DefaultArchiveDirMode)
+			}
+			if h.Mode != expected {
+				t.Errorf("Expected %s to have mode %#o, got %#o", h.Name, expected, h.Mode)
+			}
+			if h.Uid != 0 || h.Gid != 0 || h.Uname != "" || h.Gname != "" {
+				t.Errorf("Expected %s not to record an owner, got %d:%d (%s:%s)", h.Name, h.Uid, h.Gid, h.Uname, h.Gname)
+			}
+			if !h.ModTime.Equal(modTime) {
+				t.Errorf("Expected %s to be modified at %s, got %s", h.Name, modTime, h.ModTime)
+			}
+		}
+	}
+	if digests[0] != digests[1] {
+		t.Errorf("Expected the archives to be identical, got digests %s and %s", digests[0], digests[1])
+	}
+}
+
+func TestSaveReproducible(t *testing.T) {
+	files := func(names ...string) []*chart.File {
+		var fs []*chart.File
+		for _, n := range names {
+			fs = append(fs, &chart.File{Name: n, Data: []byte(n)})
+		}
+		return fs
+	}
+	// The same chart, its files loaded in different orders.
+	charts := []*chart.Chart{
+		{
+			Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ahab", Version: "1.2.3"},
+			Templates: files("templates/b.yaml", "templates/a.yaml"),
+			Files:     files("README.md", "files/z.txt", "files/a.txt"),
+		},
+		{
+			Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ahab", Version: "1.2.3"},
+			Templates: files("templates/a.yaml", "templates/b.yaml"),
+			Files:     files("files/a.txt", "README.md", "files/z.txt"),
+		},
+	}
+
+	var digests []string
+	for _, c := range charts {
+		where, err := SaveWithOptions(c, t.TempDir(), SaveOptions{Reproducible: true})
+		if err != nil {
+			t.Fatalf("Failed to save: %s", err)
+		}
+		data, err := os.ReadFile(where)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, fmt.Sprintf("%x", sha256.Sum256(data)))
+
+		headers, err := retrieveAllHeadersFromTar(where)
+		if err != nil {
+			t.Fatalf("Failed to parse tar: %v", err)
+		}
+		var names []string
+		for _, h := range headers {
+			if h.Typeflag != tar.TypeDir {
+				names = append(names, h.Name)
+			}
+			if !h.ModTime.Equal(time.Unix(0, 0)) {
+				t.Errorf("Expected %s to be modified at the Unix epoch, got %s", h.Name, h.ModTime)
+			}
+		}
+		if !slices.IsSorted(names) {
+			t.Errorf("Expected the entries to be sorted by path, got %v", names)
+		}
+	}
+	if digests[0] != digests[1] {
+		t.Errorf("Expected the archives to be identical, got digests %s and %s", digests[0], digests[1])
+	}
+}
+
+func TestSaveSchemaYAML(t *testing.T) {
+	c, err := loader.LoadFiles([]*loader.BufferedFile{
+		{Name: "Chart.yaml", Data: []byte("apiVersion: v2\nname: ahab\nversion: 1.2.3\n")},
+		{Name: loader.SchemaYAMLFile, Data: []byte("type: object\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	where, err := Save(c, t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	headers, err := retrieveAllHeadersFromTar(where)
+	if err != nil {
+		t.Fatalf("Failed to parse tar: %v", err)
+	}
+	var names []string
+	for _, h := range headers {
+		names = append(names, h.Name)
+	}
+	if !slices.Contains(names, "ahab/values.schema.yaml") || slices.Contains(names, "ahab/values.schema.json") {
+		t.Errorf("Expected only the YAML schema to be saved, got %v", names)
+	}
+
+	// The schema changed since the chart was loaded is saved in JSON.
+	c.Schema = []byte(`{"type":"array"}`)
+	dir := t.TempDir()
+	if err := SaveDir(c, dir); err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ahab", SchemafileName)); err != nil {
+		t.Errorf("Expected the changed schema to be saved in JSON: %s", err)
+	}
+}
+
+func TestSaveWithOptionsModes(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "ahab",
+			Version:    "1.2.3",
+		},
+		Files: []*chart.File{
+			{Name: "scheherazade/shahryar.txt", Data: []byte("1,001 Nights")},
+		},
+	}
+
+	where, err := SaveWithOptions(c, t.TempDir(), SaveOptions{FileMode: 0640, DirMode: 0750})
+	if err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	headers, err := retrieveAllHeadersFromTar(where)
+	if err != nil {
+		t.Fatalf("Failed to parse tar: %v", err)
+	}
+	var names []string
+	for _, h := range headers {
+		names = append(names, h.Name)
+		expected := int64(0640)
+		if h.Typeflag == tar.TypeDir {
+			expected = 0750
+		}
+		if h.Mode != expected {
+			t.Errorf("Expected %s to have mode %#o, got %#o", h.Name, expected, h.Mode)
+		}
+	}
+	if got, expected := strings.Join(names, ","), "ahab/,ahab/Chart.yaml,ahab/scheherazade/,ahab/scheherazade/shahryar.txt"; got != expected {
+		t.Errorf("Expected entries %s, got %s", expected, got)
+	}
+
+	for _, opts := range []SaveOptions{{FileMode: 04644}, {DirMode: os.ModeDir | 0755}} {
+		if _, err := SaveWithOptions(c, t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "only permission bits may be set") {
+			t.Errorf("Expected %+v to be rejected, got %v", opts, err)
+		}
+	}
+}
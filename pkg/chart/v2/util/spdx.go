@@ -0,0 +1,188 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// spdxLicenses are the identifiers of the SPDX License List known to Helm,
+// which are the licenses most commonly found in charts and the software they
+// deploy.
+var spdxLicenses = newSPDXSet(
+	"0BSD", "AFL-3.0", "AGPL-1.0-only", "AGPL-1.0-or-later", "AGPL-3.0-only",
+	"AGPL-3.0-or-later", "Apache-1.0", "Apache-1.1", "Apache-2.0", "APSL-2.0",
+	"Artistic-1.0", "Artistic-2.0", "BitTorrent-1.1", "BlueOak-1.0.0", "BSD-1-Clause",
+	"BSD-2-Clause", "BSD-2-Clause-Patent", "BSD-3-Clause", "BSD-3-Clause-Clear",
+	"BSD-4-Clause", "BSL-1.0", "BUSL-1.1", "CAL-1.0", "CC-BY-3.0", "CC-BY-4.0",
+	"CC-BY-NC-4.0", "CC-BY-NC-SA-4.0", "CC-BY-ND-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0",
+	"CC0-1.0", "CDDL-1.0", "CDDL-1.1", "CECILL-2.1", "CPAL-1.0", "CPL-1.0",
+	"ECL-2.0", "EFL-2.0", "Elastic-2.0", "EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2",
+	"FTL", "GFDL-1.3-only", "GFDL-1.3-or-later", "GPL-2.0-only", "GPL-2.0-or-later",
+	"GPL-3.0-only", "GPL-3.0-or-later", "HPND", "ICU", "IJG", "IPL-1.0", "ISC",
+	"LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0-only", "LGPL-3.0-or-later", "Libpng", "LPL-1.02", "LPPL-1.3c",
+	"MIT", "MIT-0", "MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception",
+	"MS-PL", "MS-RL", "MulanPSL-2.0", "NCSA", "ODbL-1.0", "OFL-1.1", "OpenSSL",
+	"OSL-3.0", "PHP-3.01", "PostgreSQL", "PSF-2.0", "Python-2.0", "Ruby", "SSPL-1.0",
+	"UPL-1.0", "Unicode-3.0", "Unicode-DFS-2016", "Unlicense", "Vim", "W3C",
+	"WTFPL", "X11", "Zlib", "ZPL-2.0", "ZPL-2.1",
+)
+
+// spdxExceptions are the identifiers of the SPDX License Exceptions known to
+// Helm.
+var spdxExceptions = newSPDXSet(
+	"Autoconf-exception-3.0", "Bison-exception-2.2", "Classpath-exception-2.0",
+	"GCC-exception-3.1", "LLVM-exception", "OpenJDK-assembly-exception-1.0",
+	"Universal-FOSS-exception-1.0",
+)
+
+// newSPDXSet returns a set of identifiers, which SPDX matches case-insensitively.
+func newSPDXSet(ids ...string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[strings.ToLower(id)] = true
+	}
+	return set
+}
+
+var (
+	spdxIDPattern  = regexp.MustCompile(`^[A-Za-z0-9.-]+\+?$`)
+	spdxRefPattern = regexp.MustCompile(`^(DocumentRef-[A-Za-z0-9.-]+:)?LicenseRef-[A-Za-z0-9.-]+$`)
+)
+
+// errUnknownSPDXLicense is returned for a well-formed expression using a
+// license or an exception Helm does not know.
+var errUnknownSPDXLicense = errors.New("unknown SPDX identifier")
+
+// validateSPDXExpression checks that expr is a well-formed SPDX license
+// expression, such as "Apache-2.0 OR (MIT AND BSD-3-Clause)", whose licenses
+// and exceptions are known.
+func validateSPDXExpression(expr string) error {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	if len(tokens) == 0 {
+		return errors.New("empty license expression")
+	}
+	p := &spdxParser{tokens: tokens}
+	if err := p.expression(); err != nil {
+		return err
+	}
+	if p.pos < len(p.tokens) {
+		return fmt.Errorf("unexpected %q in license expression", p.tokens[p.pos])
+	}
+	if len(p.unknown) > 0 {
+		return fmt.Errorf("%w: %s", errUnknownSPDXLicense, strings.Join(p.unknown, ", "))
+	}
+	return nil
+}
+
+// spdxParser parses the grammar of SPDX license expressions:
+//
+//	expression = term { "OR" term }
+//	term       = factor { "AND" factor }
+//	factor     = "(" expression ")" | license [ "WITH" exception ]
+type spdxParser struct {
+	tokens  []string
+	pos     int
+	unknown []string
+}
+
+func (p *spdxParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token
+}
+
+func (p *spdxParser) peek(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *spdxParser) expression() error {
+	if err := p.term(); err != nil {
+		return err
+	}
+	for p.peek("OR") {
+		p.pos++
+		if err := p.term(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *spdxParser) term() error {
+	if err := p.factor(); err != nil {
+		return err
+	}
+	for p.peek("AND") {
+		p.pos++
+		if err := p.factor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *spdxParser) factor() error {
+	token := p.next()
+	switch {
+	case token == "":
+		return errors.New("incomplete license expression")
+	case token == "(":
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if p.next() != ")" {
+			return errors.New("missing closing parenthesis in license expression")
+		}
+		return nil
+	case token == ")" || isSPDXOperator(token):
+		return fmt.Errorf("unexpected %q in license expression", token)
+	case spdxRefPattern.MatchString(token):
+	case spdxIDPattern.MatchString(token):
+		if !spdxLicenses[strings.ToLower(strings.TrimSuffix(token, "+"))] {
+			p.unknown = append(p.unknown, token)
+		}
+	default:
+		return fmt.Errorf("invalid license identifier %q", token)
+	}
+
+	if !p.peek("WITH") {
+		return nil
+	}
+	p.pos++
+	exception := p.next()
+	switch {
+	case exception == "" || exception == "(" || exception == ")" || isSPDXOperator(exception):
+		return errors.New("missing exception after WITH in license expression")
+	case !spdxIDPattern.MatchString(exception):
+		return fmt.Errorf("invalid exception identifier %q", exception)
+	case !spdxExceptions[strings.ToLower(exception)]:
+		p.unknown = append(p.unknown, exception)
+	}
+	return nil
+}
+
+func isSPDXOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSPDXExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		err     string
+		unknown bool
+	}{
+		{expr: "Apache-2.0"},
+		{expr: "apache-2.0"},
+		{expr: "GPL-2.0-or-later"},
+		{expr: "MPL-1.1+"},
+		{expr: "MIT OR Apache-2.0"},
+		{expr: "Apache-2.0 OR (MIT AND BSD-3-Clause)"},
+		{expr: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{expr: "(MIT)"},
+		{expr: "LicenseRef-Proprietary"},
+		{expr: "DocumentRef-spdx:LicenseRef-Custom AND MIT"},
+		{expr: "Foo-1.0", err: "unknown SPDX identifier: Foo-1.0", unknown: true},
+		{expr: "MIT OR Bar AND Baz", err: "unknown SPDX identifier: Bar, Baz", unknown: true},
+		{expr: "GPL-2.0-only WITH Foo-exception", err: "unknown SPDX identifier: Foo-exception", unknown: true},
+		{expr: "", err: "empty license expression"},
+		{expr: "MIT OR", err: "incomplete license expression"},
+		{expr: "AND MIT", err: `unexpected "AND" in license expression`},
+		{expr: "MIT Apache-2.0", err: `unexpected "Apache-2.0" in license expression`},
+		{expr: "(MIT OR Apache-2.0", err: "missing closing parenthesis in license expression"},
+		{expr: "MIT)", err: `unexpected ")" in license expression`},
+		{expr: "MIT WITH", err: "missing exception after WITH in license expression"},
+		{expr: "MIT/X11", err: `invalid license identifier "MIT/X11"`},
+	}
+	for _, tt := range tests {
+		err := validateSPDXExpression(tt.expr)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %s", tt.expr, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%q: expected error %q", tt.expr, tt.err)
+			continue
+		}
+		if err.Error() != tt.err {
+			t.Errorf("%q: expected error %q, got %q", tt.expr, tt.err, err)
+		}
+		if errors.Is(err, errUnknownSPDXLicense) != tt.unknown {
+			t.Errorf("%q: expected unknown identifier to be %t", tt.expr, tt.unknown)
+		}
+	}
+}
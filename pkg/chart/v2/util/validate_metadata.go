@@ -0,0 +1,254 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/asaskevich/govalidator"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// Severity is the severity of a Finding.
+type Severity int
+
+const (
+	// SeverityInfo is a suggestion, e.g. a recommended field which is not set.
+	SeverityInfo Severity = iota
+	// SeverityWarning is a likely mistake, which does not prevent the chart
+	// from being used.
+	SeverityWarning
+	// SeverityError makes the chart invalid.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Finding is an issue found in chart metadata.
+type Finding struct {
+	// Path is the path of the field in Chart.yaml, e.g. "maintainers[0].email".
+	Path     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Path, f.Message)
+}
+
+type validateOptions struct {
+	iconRequired bool
+}
+
+// ValidateOption configures ValidateMetadata.
+type ValidateOption func(*validateOptions)
+
+// WithIconRequired reports a chart without an icon as an error rather than a
+// suggestion.
+func WithIconRequired(required bool) ValidateOption {
+	return func(o *validateOptions) {
+		o.iconRequired = required
+	}
+}
+
+// ValidateMetadata checks chart metadata, as read from Chart.yaml, and returns
+// all the issues found, in the order of the fields of Chart.yaml. Unlike
+// Metadata.Validate, it does not stop at the first issue and leaves md
+// untouched.
+func ValidateMetadata(md *chart.Metadata, opts ...ValidateOption) []Finding {
+	o := validateOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := metadataValidator{}
+	if md == nil {
+		v.errorf("", "chart metadata is required")
+		return v.findings
+	}
+
+	v.name(md)
+	v.apiVersion(md)
+	v.version(md)
+	v.maintainers(md)
+	v.sources(md)
+	v.icon(md, o.iconRequired)
+	v.chartType(md)
+	v.dependencies(md)
+	v.kubeVersion(md)
+	v.deprecated(md)
+	return v.findings
+}
+
+// metadataValidator collects the findings of ValidateMetadata.
+type metadataValidator struct {
+	findings []Finding
+}
+
+func (v *metadataValidator) add(sev Severity, path, format string, args ...interface{}) {
+	v.findings = append(v.findings, Finding{Path: path, Severity: sev, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *metadataValidator) errorf(path, format string, args ...interface{}) {
+	v.add(SeverityError, path, format, args...)
+}
+
+func (v *metadataValidator) name(md *chart.Metadata) {
+	if md.Name == "" {
+		v.errorf("name", "name is required")
+	} else if filepath.Base(md.Name) != md.Name {
+		v.errorf("name", "chart name %q is invalid", md.Name)
+	}
+}
+
+func (v *metadataValidator) apiVersion(md *chart.Metadata) {
+	if md.APIVersion == "" {
+		v.errorf("apiVersion", "apiVersion is required. The value must be either \"v1\" or \"v2\"")
+	} else if md.APIVersion != chart.APIVersionV1 && md.APIVersion != chart.APIVersionV2 {
+		v.errorf("apiVersion", "apiVersion '%s' is not valid. The value must be either \"v1\" or \"v2\"", md.APIVersion)
+	}
+}
+
+func (v *metadataValidator) version(md *chart.Metadata) {
+	if md.Version == "" {
+		v.errorf("version", "version is required")
+		return
+	}
+
+	version, err := semver.NewVersion(md.Version)
+	if err != nil {
+		v.errorf("version", "version '%s' is not a valid SemVer", md.Version)
+		return
+	}
+	c, err := semver.NewConstraint(">0.0.0-0")
+	if err != nil {
+		v.errorf("version", "%s", err)
+		return
+	}
+	if valid, msgs := c.Validate(version); !valid && len(msgs) > 0 {
+		v.errorf("version", "version %v", msgs[0])
+	}
+}
+
+func (v *metadataValidator) maintainers(md *chart.Metadata) {
+	for i, m := range md.Maintainers {
+		path := fmt.Sprintf("maintainers[%d]", i)
+		switch {
+		case m == nil:
+			v.errorf(path, "maintainers must not contain empty or null nodes")
+		case m.Name == "":
+			v.errorf(path+".name", "each maintainer requires a name")
+		case m.Email != "" && !govalidator.IsEmail(m.Email):
+			v.errorf(path+".email", "invalid email '%s' for maintainer '%s'", m.Email, m.Name)
+		case m.URL != "" && !govalidator.IsURL(m.URL):
+			v.errorf(path+".url", "invalid url '%s' for maintainer '%s'", m.URL, m.Name)
+		}
+	}
+}
+
+func (v *metadataValidator) sources(md *chart.Metadata) {
+	for i, source := range md.Sources {
+		if source == "" || !govalidator.IsRequestURL(source) {
+			v.errorf(fmt.Sprintf("sources[%d]", i), "invalid source URL '%s'", source)
+		}
+	}
+}
+
+func (v *metadataValidator) icon(md *chart.Metadata, required bool) {
+	switch {
+	case md.Icon == "" && required:
+		v.errorf("icon", "icon is required")
+	case md.Icon == "":
+		v.add(SeverityInfo, "icon", "icon is recommended")
+	case !govalidator.IsRequestURL(md.Icon):
+		v.errorf("icon", "invalid icon URL '%s'", md.Icon)
+	}
+}
+
+func (v *metadataValidator) chartType(md *chart.Metadata) {
+	if md.Type == "" {
+		return
+	}
+	if md.APIVersion != chart.APIVersionV2 {
+		v.errorf("type", "chart type is not valid in apiVersion '%s'. It is valid in apiVersion '%s'", md.APIVersion, chart.APIVersionV2)
+	} else if !isValidChartType(md.Type) {
+		v.errorf("type", "chart type '%s' is not valid. The value must be either \"application\" or \"library\"", md.Type)
+	}
+}
+
+func (v *metadataValidator) dependencies(md *chart.Metadata) {
+	if len(md.Dependencies) > 0 && md.APIVersion != chart.APIVersionV2 {
+		v.errorf("dependencies", "dependencies are not valid in the Chart file with apiVersion '%s'. They are valid in apiVersion '%s'", md.APIVersion, chart.APIVersionV2)
+	}
+
+	seen := make(map[string]int)
+	for i, dep := range md.Dependencies {
+		if dep == nil {
+			continue
+		}
+		key, field := dep.Name, "name"
+		if dep.Alias != "" {
+			key, field = dep.Alias, "alias"
+		}
+		if first, ok := seen[key]; ok {
+			v.errorf(fmt.Sprintf("dependencies[%d].%s", i, field), "more than one dependency with name or alias %q, first used by dependencies[%d]", key, first)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+func (v *metadataValidator) kubeVersion(md *chart.Metadata) {
+	if md.KubeVersion == "" {
+		return
+	}
+	if _, err := semver.NewConstraint(md.KubeVersion); err != nil {
+		v.errorf("kubeVersion", "kubeVersion '%s' is not a valid SemVer constraint: %s", md.KubeVersion, err)
+	}
+}
+
+func (v *metadataValidator) deprecated(md *chart.Metadata) {
+	if !md.Deprecated {
+		return
+	}
+	// Searches leave pre-releases out by default, so the deprecation of a
+	// chart released as one goes unnoticed.
+	if version, err := semver.NewVersion(md.Version); err == nil && version.Prerelease() != "" {
+		v.add(SeverityWarning, "deprecated", "chart is deprecated in the pre-release version '%s', which searches do not show by default; release the deprecation in a stable version", md.Version)
+	}
+}
+
+func isValidChartType(in string) bool {
+	switch in {
+	case "", "application", "library":
+		return true
+	}
+	return false
+}
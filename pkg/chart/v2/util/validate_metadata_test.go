@@ -0,0 +1,258 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// validMetadata returns metadata without findings, changed by edit.
+func validMetadata(edit func(md *chart.Metadata)) *chart.Metadata {
+	md := &chart.Metadata{
+		APIVersion: chart.APIVersionV2,
+		Name:       "mychart",
+		Version:    "1.2.3",
+		Icon:       "https://example.com/icon.png",
+	}
+	if edit != nil {
+		edit(md)
+	}
+	return md
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(md *chart.Metadata)
+		opts     []ValidateOption
+		expected []Finding
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:     "name required",
+			edit:     func(md *chart.Metadata) { md.Name = "" },
+			expected: []Finding{{"name", SeverityError, "name is required"}},
+		},
+		{
+			name:     "name with a path",
+			edit:     func(md *chart.Metadata) { md.Name = "../mychart" },
+			expected: []Finding{{"name", SeverityError, `chart name "../mychart" is invalid`}},
+		},
+		{
+			name:     "apiVersion required",
+			edit:     func(md *chart.Metadata) { md.APIVersion = "" },
+			expected: []Finding{{"apiVersion", SeverityError, `apiVersion is required. The value must be either "v1" or "v2"`}},
+		},
+		{
+			name:     "unknown apiVersion",
+			edit:     func(md *chart.Metadata) { md.APIVersion = "v3" },
+			expected: []Finding{{"apiVersion", SeverityError, `apiVersion 'v3' is not valid. The value must be either "v1" or "v2"`}},
+		},
+		{
+			name:     "version required",
+			edit:     func(md *chart.Metadata) { md.Version = "" },
+			expected: []Finding{{"version", SeverityError, "version is required"}},
+		},
+		{
+			name:     "version not SemVer",
+			edit:     func(md *chart.Metadata) { md.Version = "1.2.3.4" },
+			expected: []Finding{{"version", SeverityError, "version '1.2.3.4' is not a valid SemVer"}},
+		},
+		{
+			name:     "version not a number",
+			edit:     func(md *chart.Metadata) { md.Version = "waps" },
+			expected: []Finding{{"version", SeverityError, "version 'waps' is not a valid SemVer"}},
+		},
+		{
+			name:     "version negative",
+			edit:     func(md *chart.Metadata) { md.Version = "-3" },
+			expected: []Finding{{"version", SeverityError, "version '-3' is not a valid SemVer"}},
+		},
+		{
+			name:     "version with build metadata",
+			edit:     func(md *chart.Metadata) { md.Version = "0.0.1+build" },
+			expected: nil,
+		},
+		{
+			name:     "version pre-release",
+			edit:     func(md *chart.Metadata) { md.Version = "0.0.1-beta" },
+			expected: nil,
+		},
+		{
+			name:     "version patch",
+			edit:     func(md *chart.Metadata) { md.Version = "0.0.1" },
+			expected: nil,
+		},
+		{
+			name: "maintainers",
+			edit: func(md *chart.Metadata) {
+				md.Maintainers = []*chart.Maintainer{
+					{Name: "John Snow", Email: "john@winterfell.com"},
+					{Email: "test@test.com"},
+					{Name: "John Snow", Email: "wrongFormatEmail.com"},
+					{Name: "John Snow", URL: "not a url"},
+					nil,
+					{},
+					{Name: "John Snow"},
+				}
+			},
+			expected: []Finding{
+				{"maintainers[1].name", SeverityError, "each maintainer requires a name"},
+				{"maintainers[2].email", SeverityError, "invalid email 'wrongFormatEmail.com' for maintainer 'John Snow'"},
+				{"maintainers[3].url", SeverityError, "invalid url 'not a url' for maintainer 'John Snow'"},
+				{"maintainers[4]", SeverityError, "maintainers must not contain empty or null nodes"},
+				{"maintainers[5].name", SeverityError, "each maintainer requires a name"},
+			},
+		},
+		{
+			name: "sources",
+			edit: func(md *chart.Metadata) {
+				md.Sources = []string{"https://riverrun.io/blackfish", "", "riverrun.io", "http://riverrun.io", "RiverRun", "https://riverrun.io", "john@winterfell"}
+			},
+			expected: []Finding{
+				{"sources[1]", SeverityError, "invalid source URL ''"},
+				{"sources[2]", SeverityError, "invalid source URL 'riverrun.io'"},
+				{"sources[4]", SeverityError, "invalid source URL 'RiverRun'"},
+				{"sources[6]", SeverityError, "invalid source URL 'john@winterfell'"},
+			},
+		},
+		{
+			name:     "icon recommended",
+			edit:     func(md *chart.Metadata) { md.Icon = "" },
+			expected: []Finding{{"icon", SeverityInfo, "icon is recommended"}},
+		},
+		{
+			name:     "icon required",
+			edit:     func(md *chart.Metadata) { md.Icon = "" },
+			opts:     []ValidateOption{WithIconRequired(true)},
+			expected: []Finding{{"icon", SeverityError, "icon is required"}},
+		},
+		{
+			name:     "icon URL",
+			edit:     func(md *chart.Metadata) { md.Icon = "john@winterfell" },
+			expected: []Finding{{"icon", SeverityError, "invalid icon URL 'john@winterfell'"}},
+		},
+		{
+			name:     "icon URL without scheme",
+			edit:     func(md *chart.Metadata) { md.Icon = "riverrun.io" },
+			expected: []Finding{{"icon", SeverityError, "invalid icon URL 'riverrun.io'"}},
+		},
+		{
+			name:     "icon URL not a URL",
+			edit:     func(md *chart.Metadata) { md.Icon = "RiverRun" },
+			expected: []Finding{{"icon", SeverityError, "invalid icon URL 'RiverRun'"}},
+		},
+		{
+			name:     "icon URL over HTTP",
+			edit:     func(md *chart.Metadata) { md.Icon = "http://riverrun.io" },
+			expected: nil,
+		},
+		{
+			name:     "icon URL with a path",
+			edit:     func(md *chart.Metadata) { md.Icon = "https://riverrun.io/blackfish.png" },
+			expected: nil,
+		},
+		{
+			name: "type in apiVersion v1",
+			edit: func(md *chart.Metadata) {
+				md.APIVersion = chart.APIVersionV1
+				md.Type = "application"
+			},
+			expected: []Finding{{"type", SeverityError, "chart type is not valid in apiVersion 'v1'. It is valid in apiVersion 'v2'"}},
+		},
+		{
+			name:     "unknown type",
+			edit:     func(md *chart.Metadata) { md.Type = "plugin" },
+			expected: []Finding{{"type", SeverityError, `chart type 'plugin' is not valid. The value must be either "application" or "library"`}},
+		},
+		{
+			name: "dependencies in apiVersion v1",
+			edit: func(md *chart.Metadata) {
+				md.APIVersion = chart.APIVersionV1
+				md.Dependencies = []*chart.Dependency{{Name: "mariadb"}}
+			},
+			expected: []Finding{{"dependencies", SeverityError, "dependencies are not valid in the Chart file with apiVersion 'v1'. They are valid in apiVersion 'v2'"}},
+		},
+		{
+			name: "duplicate dependency aliases",
+			edit: func(md *chart.Metadata) {
+				md.Dependencies = []*chart.Dependency{
+					{Name: "mariadb"},
+					{Name: "postgresql", Alias: "db"},
+					{Name: "mysql", Alias: "db"},
+					{Name: "mariadb", Alias: "cache"},
+					{Name: "redis", Alias: "mariadb"},
+				}
+			},
+			expected: []Finding{
+				{"dependencies[2].alias", SeverityError, `more than one dependency with name or alias "db", first used by dependencies[1]`},
+				{"dependencies[4].alias", SeverityError, `more than one dependency with name or alias "mariadb", first used by dependencies[0]`},
+			},
+		},
+		{
+			name:     "valid kubeVersion",
+			edit:     func(md *chart.Metadata) { md.KubeVersion = ">=1.27.0-0 <1.32.0" },
+			expected: nil,
+		},
+		{
+			name: "invalid kubeVersion",
+			edit: func(md *chart.Metadata) { md.KubeVersion = ">=one" },
+			expected: []Finding{{"kubeVersion", SeverityError,
+				"kubeVersion '>=one' is not a valid SemVer constraint: improper constraint: >=one"}},
+		},
+		{
+			name: "deprecated",
+			edit: func(md *chart.Metadata) { md.Deprecated = true },
+		},
+		{
+			name: "deprecated in a pre-release",
+			edit: func(md *chart.Metadata) {
+				md.Deprecated = true
+				md.Version = "1.2.3-rc.1"
+			},
+			expected: []Finding{{"deprecated", SeverityWarning,
+				"chart is deprecated in the pre-release version '1.2.3-rc.1', which searches do not show by default; release the deprecation in a stable version"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ValidateMetadata(validMetadata(tt.edit), tt.opts...))
+		})
+	}
+}
+
+func TestValidateMetadataNil(t *testing.T) {
+	assert.Equal(t, []Finding{{"", SeverityError, "chart metadata is required"}}, ValidateMetadata(nil))
+}
+
+func TestValidateMetadataReportsAll(t *testing.T) {
+	findings := ValidateMetadata(&chart.Metadata{Version: "waps"})
+
+	var paths []string
+	for _, f := range findings {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"name", "apiVersion", "version", "icon"}, paths)
+	assert.Equal(t, "[error] version: version 'waps' is not a valid SemVer", findings[2].String())
+}
@@ -0,0 +1,249 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// maxInferredDepth is the nesting depth from which maps found in values.yaml
+// are summarized instead of being expanded into their keys.
+const maxInferredDepth = 4
+
+// ValueField describes a value that can be set on a chart.
+type ValueField struct {
+	// Path is the dotted path of the value, as used with --set.
+	Path string `json:"path"`
+	// Type is the JSON schema type of the value, e.g. "string" or "integer".
+	// Free-form maps have the type "map".
+	Type string `json:"type"`
+	// Default is the value set by the chart, if any.
+	Default interface{} `json:"default,omitempty"`
+	// Enum lists the allowed values, if restricted.
+	Enum []interface{} `json:"enum,omitempty"`
+	// Description documents the value.
+	Description string `json:"description,omitempty"`
+	// Summarized is set on free-form maps whose keys are not listed.
+	Summarized bool `json:"summarized,omitempty"`
+}
+
+// ValueFields lists the values that can be set on the chart.
+//
+// The fields are read from the chart's values schema. Charts without a schema
+// have their fields inferred from the default values instead. Descriptions
+// missing from the schema are taken from the comments preceding the keys in
+// values.yaml.
+func ValueFields(ch *chart.Chart) ([]ValueField, error) {
+	comments := valueComments(ch)
+
+	var fields []ValueField
+	if len(ch.Schema) > 0 {
+		var schema map[string]interface{}
+		if err := json.Unmarshal(ch.Schema, &schema); err != nil {
+			return nil, fmt.Errorf("unable to parse values schema of chart %s: %w", ch.Name(), err)
+		}
+		fields = schemaFields(fields, schema, "", ch.Values, comments)
+	} else {
+		fields = inferFields(fields, ch.Values, "", 0, comments)
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields, nil
+}
+
+// schemaFields appends the fields described by the properties of schema.
+func schemaFields(fields []ValueField, schema map[string]interface{}, prefix string, defaults map[string]interface{}, comments map[string]string) []ValueField {
+	props, _ := schema["properties"].(map[string]interface{})
+	for _, key := range sortedKeys(props) {
+		prop, ok := props[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fieldPath(prefix, key)
+		def, hasDefault := prop["default"]
+		if !hasDefault {
+			def = defaults[key]
+		}
+
+		typ := schemaType(prop)
+		if typ == "object" {
+			if _, ok := prop["properties"].(map[string]interface{}); ok {
+				nested, _ := def.(map[string]interface{})
+				fields = schemaFields(fields, prop, path, nested, comments)
+				continue
+			}
+			typ = "map"
+		}
+
+		description, _ := prop["description"].(string)
+		if description == "" {
+			description = comments[path]
+		}
+		enum, _ := prop["enum"].([]interface{})
+		fields = append(fields, ValueField{
+			Path:        path,
+			Type:        typ,
+			Default:     def,
+			Enum:        enum,
+			Description: description,
+			Summarized:  typ == "map",
+		})
+	}
+	return fields
+}
+
+// schemaType returns the type of a schema property. Union types are joined
+// with "|", leaving out "null".
+func schemaType(prop map[string]interface{}) string {
+	switch t := prop["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		var types []string
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+		if len(types) == 1 {
+			return types[0]
+		}
+		if len(types) > 1 {
+			return strings.Join(types, "|")
+		}
+	}
+	if _, ok := prop["properties"]; ok {
+		return "object"
+	}
+	return "any"
+}
+
+// inferFields appends the fields found in the values.
+func inferFields(fields []ValueField, values map[string]interface{}, prefix string, depth int, comments map[string]string) []ValueField {
+	for _, key := range sortedKeys(values) {
+		path := fieldPath(prefix, key)
+		if m, ok := values[key].(map[string]interface{}); ok && len(m) > 0 && depth+1 < maxInferredDepth {
+			fields = inferFields(fields, m, path, depth+1, comments)
+			continue
+		}
+		field := ValueField{
+			Path:        path,
+			Type:        valueType(values[key]),
+			Default:     values[key],
+			Description: comments[path],
+		}
+		if field.Type == "map" {
+			field.Summarized = true
+			field.Default = nil
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// valueType returns the JSON schema type matching a value read from values.yaml.
+func valueType(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "any"
+}
+
+// valueComments maps the paths of the keys in the chart's values.yaml to the
+// comments preceding them.
+func valueComments(ch *chart.Chart) map[string]string {
+	comments := make(map[string]string)
+	for _, f := range ch.Raw {
+		if f.Name != ValuesfileName {
+			continue
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(f.Data, &doc); err != nil || len(doc.Content) == 0 {
+			return comments
+		}
+		collectComments(doc.Content[0], "", comments)
+	}
+	return comments
+}
+
+func collectComments(node *yaml.Node, prefix string, comments map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := fieldPath(prefix, key.Value)
+		if c := cleanComment(key.HeadComment); c != "" {
+			comments[path] = c
+		}
+		collectComments(value, path, comments)
+	}
+}
+
+// cleanComment turns a YAML comment block into a single line of text. Only
+// the paragraph directly preceding the key is kept.
+func cleanComment(comment string) string {
+	paragraphs := strings.Split(comment, "\n\n")
+	var lines []string
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func fieldPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
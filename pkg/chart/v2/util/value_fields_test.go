@@ -0,0 +1,115 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+const valueFieldsYAML = `# -- number of replicas
+replicaCount: 1
+image:
+  # container image repository
+  repository: nginx
+  # This is a comment that belongs to nothing.
+
+  # -- container image tag
+  tag: "1.25.3"
+podAnnotations: {}
+deep:
+  a:
+    b:
+      c:
+        d: 1
+hosts:
+  - example.com
+`
+
+func valueFieldsChart(t *testing.T, schema string) *chart.Chart {
+	t.Helper()
+	vals, err := ReadValues([]byte(valueFieldsYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "fields"},
+		Values:   vals,
+		Schema:   []byte(schema),
+		Raw:      []*chart.File{{Name: ValuesfileName, Data: []byte(valueFieldsYAML)}},
+	}
+}
+
+func TestValueFieldsInferred(t *testing.T) {
+	fields, err := ValueFields(valueFieldsChart(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []ValueField{
+		{Path: "deep.a.b.c", Type: "map", Summarized: true},
+		{Path: "hosts", Type: "array", Default: []interface{}{"example.com"}},
+		{Path: "image.repository", Type: "string", Default: "nginx", Description: "container image repository"},
+		{Path: "image.tag", Type: "string", Default: "1.25.3", Description: "container image tag"},
+		{Path: "podAnnotations", Type: "map", Summarized: true},
+		{Path: "replicaCount", Type: "integer", Default: float64(1), Description: "number of replicas"},
+	}
+	if !reflect.DeepEqual(expect, fields) {
+		t.Errorf("expected %#v, got %#v", expect, fields)
+	}
+}
+
+func TestValueFieldsFromSchema(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 0},
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": "string", "description": "image tag from schema"},
+        "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent"], "default": "IfNotPresent"}
+      }
+    },
+    "podAnnotations": {"type": "object", "additionalProperties": {"type": "string"}},
+    "nodePort": {"type": ["integer", "null"]}
+  }
+}`
+	fields, err := ValueFields(valueFieldsChart(t, schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []ValueField{
+		{Path: "image.pullPolicy", Type: "string", Default: "IfNotPresent", Enum: []interface{}{"Always", "IfNotPresent"}},
+		{Path: "image.tag", Type: "string", Default: "1.25.3", Description: "image tag from schema"},
+		{Path: "nodePort", Type: "integer"},
+		{Path: "podAnnotations", Type: "map", Default: map[string]interface{}{}, Summarized: true},
+		{Path: "replicaCount", Type: "integer", Default: float64(1), Description: "number of replicas"},
+	}
+	if !reflect.DeepEqual(expect, fields) {
+		t.Errorf("expected %#v, got %#v", expect, fields)
+	}
+}
+
+func TestValueFieldsInvalidSchema(t *testing.T) {
+	if _, err := ValueFields(valueFieldsChart(t, "{")); err == nil {
+		t.Error("expected an invalid schema to fail")
+	}
+}
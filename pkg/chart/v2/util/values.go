@@ -156,6 +156,7 @@ func ToRenderValuesWithSchemaValidation(chrt *chart.Chart, chrtVals map[string]i
 			"Revision":  options.Revision,
 			"Service":   "Helm",
 		},
+		"HookResults": HookResults{},
 	}
 
 	vals, err := CoalesceValues(chrt, chrtVals)
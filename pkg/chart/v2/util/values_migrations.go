@@ -0,0 +1,337 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// ValuesMigrationsFileName is the name of the file of a chart declaring how
+// the values given to its previous versions are migrated on upgrade.
+const ValuesMigrationsFileName = "values-migrations.yaml"
+
+// ValuesMigrationOp is an operation of a values migration.
+type ValuesMigrationOp string
+
+const (
+	// ValuesMigrationRename moves the value at From to To.
+	ValuesMigrationRename ValuesMigrationOp = "rename"
+	// ValuesMigrationMove moves the table at From under To, merging it with
+	// the table already there, if any.
+	ValuesMigrationMove ValuesMigrationOp = "move"
+	// ValuesMigrationSetDefault sets the value at Path to Value, unless it is
+	// already set.
+	ValuesMigrationSetDefault ValuesMigrationOp = "setDefault"
+	// ValuesMigrationDelete deletes the value at Path.
+	ValuesMigrationDelete ValuesMigrationOp = "delete"
+)
+
+// ValuesMigrations are the migrations of a chart, as declared in its
+// values-migrations.yaml file.
+//
+// Every migration applies, in order, to the values of the releases of the
+// previous versions of the chart matching its FromVersion constraint. The
+// operations leave values which were already migrated unchanged, so that
+// migrations can be applied again.
+type ValuesMigrations struct {
+	Migrations []ValuesMigration `json:"migrations"`
+}
+
+// ValuesMigration is a list of operations migrating values.
+type ValuesMigration struct {
+	// Name identifies the migration in reports.
+	Name string `json:"name,omitempty"`
+	// FromVersion is the SemVer constraint the version of the chart the values
+	// were given to must satisfy. The migration applies to all the versions
+	// if empty.
+	FromVersion string `json:"fromVersion,omitempty"`
+	// Operations are applied in order.
+	Operations []ValuesMigrationOperation `json:"operations"`
+}
+
+// ValuesMigrationOperation is an operation on values. Paths are keys separated
+// by dots, e.g. "persistence.size".
+type ValuesMigrationOperation struct {
+	Op ValuesMigrationOp `json:"op"`
+	// From and To are the paths of rename and move.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Path is the path of setDefault and delete.
+	Path string `json:"path,omitempty"`
+	// Value is the value of setDefault.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// LoadValuesMigrations returns the values migrations of chrt, or nil if it has
+// none.
+func LoadValuesMigrations(chrt *chart.Chart) (*ValuesMigrations, error) {
+	for _, f := range chrt.Files {
+		if f.Name == ValuesMigrationsFileName {
+			return ParseValuesMigrations(f.Data)
+		}
+	}
+	return nil, nil
+}
+
+// ParseValuesMigrations parses and validates a values-migrations.yaml file.
+func ParseValuesMigrations(data []byte) (*ValuesMigrations, error) {
+	m := &ValuesMigrations{}
+	if err := yaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", ValuesMigrationsFileName, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks the constraints and the operations of the migrations, and
+// returns all the issues found.
+func (m *ValuesMigrations) Validate() error {
+	var errs []error
+	for i, migration := range m.Migrations {
+		prefix := fmt.Sprintf("migrations[%d]", i)
+		if migration.FromVersion != "" {
+			if _, err := semver.NewConstraint(migration.FromVersion); err != nil {
+				errs = append(errs, fmt.Errorf("%s.fromVersion: %q is not a valid SemVer constraint: %w", prefix, migration.FromVersion, err))
+			}
+		}
+		if len(migration.Operations) == 0 {
+			errs = append(errs, fmt.Errorf("%s.operations: at least one operation is required", prefix))
+		}
+		for j, op := range migration.Operations {
+			if err := op.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s.operations[%d]: %w", prefix, j, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (o ValuesMigrationOperation) validate() error {
+	switch o.Op {
+	case ValuesMigrationRename, ValuesMigrationMove:
+		if o.From == "" || o.To == "" {
+			return fmt.Errorf("%s requires from and to", o.Op)
+		}
+		if o.Path != "" || o.Value != nil {
+			return fmt.Errorf("%s only takes from and to", o.Op)
+		}
+		if o.From == o.To || strings.HasPrefix(o.To, o.From+".") {
+			return fmt.Errorf("cannot %s %s into itself", o.Op, o.From)
+		}
+		return errors.Join(validateValuesPath(o.From), validateValuesPath(o.To))
+	case ValuesMigrationSetDefault:
+		if o.Path == "" || o.Value == nil {
+			return fmt.Errorf("%s requires path and value", o.Op)
+		}
+		if o.From != "" || o.To != "" {
+			return fmt.Errorf("%s only takes path and value", o.Op)
+		}
+		return validateValuesPath(o.Path)
+	case ValuesMigrationDelete:
+		if o.Path == "" {
+			return fmt.Errorf("%s requires path", o.Op)
+		}
+		if o.From != "" || o.To != "" || o.Value != nil {
+			return fmt.Errorf("%s only takes path", o.Op)
+		}
+		return validateValuesPath(o.Path)
+	case "":
+		return errors.New("op is required")
+	}
+	return fmt.Errorf("unknown op %q. The op must be one of %q, %q, %q or %q", o.Op, ValuesMigrationRename, ValuesMigrationMove, ValuesMigrationSetDefault, ValuesMigrationDelete)
+}
+
+func validateValuesPath(path string) error {
+	for _, key := range parsePath(path) {
+		if key == "" {
+			return fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return nil
+}
+
+// Apply applies the migrations whose constraint fromVersion, the version of
+// the chart vals were given to, satisfies. It returns the migrated copy of
+// vals, leaving vals untouched, and a description of every change made.
+func (m *ValuesMigrations) Apply(vals map[string]interface{}, fromVersion string) (map[string]interface{}, []string, error) {
+	migrated, err := copyValues(vals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var version *semver.Version
+	var applied []string
+	for i, migration := range m.Migrations {
+		name := migration.Name
+		if name == "" {
+			name = fmt.Sprintf("migrations[%d]", i)
+		}
+		if migration.FromVersion != "" {
+			if version == nil {
+				if version, err = semver.NewVersion(fromVersion); err != nil {
+					return nil, nil, fmt.Errorf("invalid chart version %q: %w", fromVersion, err)
+				}
+			}
+			constraint, err := semver.NewConstraint(migration.FromVersion)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if !constraint.Check(version) {
+				continue
+			}
+		}
+		for _, op := range migration.Operations {
+			change, err := op.apply(migrated)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if change != "" {
+				applied = append(applied, name+": "+change)
+			}
+		}
+	}
+	return migrated, applied, nil
+}
+
+// apply applies the operation to vals, and returns a description of the
+// change made, or an empty string if it changed nothing.
+func (o ValuesMigrationOperation) apply(vals map[string]interface{}) (string, error) {
+	switch o.Op {
+	case ValuesMigrationRename, ValuesMigrationMove:
+		value, ok := lookupValue(vals, o.From)
+		if !ok {
+			return "", nil
+		}
+		current, exists := lookupValue(vals, o.To)
+		src, srcIsTable := value.(map[string]interface{})
+		dst, dstIsTable := current.(map[string]interface{})
+		switch {
+		case !exists:
+			if err := setValue(vals, o.To, value); err != nil {
+				return "", err
+			}
+		case o.Op == ValuesMigrationMove && srcIsTable && dstIsTable:
+			mergeMissing(dst, src)
+		default:
+			// The value at To was set explicitly, and wins.
+			deleteValue(vals, o.From)
+			return fmt.Sprintf("dropped %s, as %s is already set", o.From, o.To), nil
+		}
+		deleteValue(vals, o.From)
+		if o.Op == ValuesMigrationMove {
+			return fmt.Sprintf("moved %s to %s", o.From, o.To), nil
+		}
+		return fmt.Sprintf("renamed %s to %s", o.From, o.To), nil
+	case ValuesMigrationSetDefault:
+		if _, ok := lookupValue(vals, o.Path); ok {
+			return "", nil
+		}
+		if err := setValue(vals, o.Path, o.Value); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("set %s to %v", o.Path, o.Value), nil
+	case ValuesMigrationDelete:
+		if !deleteValue(vals, o.Path) {
+			return "", nil
+		}
+		return fmt.Sprintf("deleted %s", o.Path), nil
+	}
+	return "", fmt.Errorf("unknown op %q", o.Op)
+}
+
+func lookupValue(vals map[string]interface{}, path string) (interface{}, bool) {
+	keys := parsePath(path)
+	table := vals
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := table[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		table = next
+	}
+	value, ok := table[keys[len(keys)-1]]
+	return value, ok
+}
+
+// setValue sets the value at path, creating the missing tables.
+func setValue(vals map[string]interface{}, path string, value interface{}) error {
+	keys := parsePath(path)
+	table := vals
+	for i, key := range keys[:len(keys)-1] {
+		switch next := table[key].(type) {
+		case map[string]interface{}:
+			table = next
+		case nil:
+			created := map[string]interface{}{}
+			table[key] = created
+			table = created
+		default:
+			return fmt.Errorf("cannot set %s: %s is not a table", path, joinPath(keys[:i+1]...))
+		}
+	}
+	table[keys[len(keys)-1]] = value
+	return nil
+}
+
+// deleteValue deletes the value at path, and the tables it leaves empty. It
+// reports whether there was a value to delete.
+func deleteValue(vals map[string]interface{}, path string) bool {
+	return deleteKeys(vals, parsePath(path))
+}
+
+func deleteKeys(table map[string]interface{}, keys []string) bool {
+	if len(keys) == 1 {
+		if _, ok := table[keys[0]]; !ok {
+			return false
+		}
+		delete(table, keys[0])
+		return true
+	}
+	next, ok := table[keys[0]].(map[string]interface{})
+	if !ok || !deleteKeys(next, keys[1:]) {
+		return false
+	}
+	if len(next) == 0 {
+		delete(table, keys[0])
+	}
+	return true
+}
+
+// mergeMissing copies into dst the values of src it does not already have.
+func mergeMissing(dst, src map[string]interface{}) {
+	for key, value := range src {
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		if dstTable, ok := existing.(map[string]interface{}); ok {
+			if srcTable, ok := value.(map[string]interface{}); ok {
+				mergeMissing(dstTable, srcTable)
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+const testValuesMigrations = `
+migrations:
+- name: v2
+  fromVersion: "<2.0.0"
+  operations:
+  - op: rename
+    from: image.tag
+    to: image.version
+  - op: move
+    from: persistence
+    to: storage.persistence
+  - op: setDefault
+    path: storage.class
+    value: standard
+  - op: delete
+    path: legacy
+- name: v3
+  fromVersion: "<3.0.0"
+  operations:
+  - op: rename
+    from: replicas
+    to: replicaCount
+`
+
+func TestParseValuesMigrations(t *testing.T) {
+	m, err := ParseValuesMigrations([]byte(testValuesMigrations))
+	require.NoError(t, err)
+	require.Len(t, m.Migrations, 2)
+	assert.Equal(t, "<2.0.0", m.Migrations[0].FromVersion)
+	assert.Len(t, m.Migrations[0].Operations, 4)
+	assert.Equal(t, "standard", m.Migrations[0].Operations[2].Value)
+
+	tests := []struct {
+		name string
+		data string
+		errs []string
+	}{
+		{
+			name: "unknown field",
+			data: "migrations:\n- operations:\n  - op: delete\n    path: a\n    force: true\n",
+			errs: []string{`unknown field "force"`},
+		},
+		{
+			name: "unknown op",
+			data: "migrations:\n- operations:\n  - op: copy\n    from: a\n    to: b\n",
+			errs: []string{`migrations[0].operations[0]: unknown op "copy"`},
+		},
+		{
+			name: "missing fields",
+			data: "migrations:\n- operations:\n  - op: rename\n    from: a\n  - op: setDefault\n    path: b\n  - {}\n",
+			errs: []string{
+				"migrations[0].operations[0]: rename requires from and to",
+				"migrations[0].operations[1]: setDefault requires path and value",
+				"migrations[0].operations[2]: op is required",
+			},
+		},
+		{
+			name: "misplaced fields",
+			data: "migrations:\n- operations:\n  - op: delete\n    path: a\n    to: b\n",
+			errs: []string{"migrations[0].operations[0]: delete only takes path"},
+		},
+		{
+			name: "invalid path",
+			data: "migrations:\n- operations:\n  - op: delete\n    path: a..b\n",
+			errs: []string{`migrations[0].operations[0]: invalid path "a..b"`},
+		},
+		{
+			name: "rename into itself",
+			data: "migrations:\n- operations:\n  - op: move\n    from: a\n    to: a.b\n",
+			errs: []string{"migrations[0].operations[0]: cannot move a into itself"},
+		},
+		{
+			name: "invalid constraint and no operations",
+			data: "migrations:\n- fromVersion: not-a-version\n",
+			errs: []string{
+				`migrations[0].fromVersion: "not-a-version" is not a valid SemVer constraint`,
+				"migrations[0].operations: at least one operation is required",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseValuesMigrations([]byte(tt.data))
+			require.Error(t, err)
+			for _, msg := range tt.errs {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestLoadValuesMigrations(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "migrating", Version: "2.0.0"}}
+	m, err := LoadValuesMigrations(c)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	c.Files = []*chart.File{{Name: ValuesMigrationsFileName, Data: []byte(testValuesMigrations)}}
+	m, err = LoadValuesMigrations(c)
+	require.NoError(t, err)
+	assert.Len(t, m.Migrations, 2)
+}
+
+func TestValuesMigrationsApply(t *testing.T) {
+	m, err := ParseValuesMigrations([]byte(testValuesMigrations))
+	require.NoError(t, err)
+
+	vals := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "1.0"},
+		"persistence": map[string]interface{}{
+			"enabled": true,
+			"size":    "1Gi",
+		},
+		"storage": map[string]interface{}{
+			"persistence": map[string]interface{}{"size": "10Gi"},
+		},
+		"legacy":   true,
+		"replicas": 3,
+	}
+
+	migrated, applied, err := m.Apply(vals, "1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{"version": "1.0"},
+		"storage": map[string]interface{}{
+			"class": "standard",
+			"persistence": map[string]interface{}{
+				"enabled": true,
+				"size":    "10Gi",
+			},
+		},
+		"replicaCount": 3,
+	}, migrated)
+	assert.Equal(t, []string{
+		"v2: renamed image.tag to image.version",
+		"v2: moved persistence to storage.persistence",
+		"v2: set storage.class to standard",
+		"v2: deleted legacy",
+		"v3: renamed replicas to replicaCount",
+	}, applied)
+
+	// The values given are left untouched.
+	assert.Equal(t, "1.0", vals["image"].(map[string]interface{})["tag"])
+	assert.Contains(t, vals, "legacy")
+
+	// Migrations applied again change nothing.
+	again, applied, err := m.Apply(migrated, "1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, migrated, again)
+	assert.Empty(t, applied)
+}
+
+func TestValuesMigrationsApplyFromVersion(t *testing.T) {
+	m, err := ParseValuesMigrations([]byte(testValuesMigrations))
+	require.NoError(t, err)
+
+	vals := map[string]interface{}{
+		"legacy":   true,
+		"replicas": 3,
+	}
+	migrated, applied, err := m.Apply(vals, "2.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"legacy": true, "replicaCount": 3}, migrated)
+	assert.Equal(t, []string{"v3: renamed replicas to replicaCount"}, applied)
+
+	migrated, applied, err = m.Apply(vals, "3.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, vals, migrated)
+	assert.Empty(t, applied)
+
+	_, _, err = m.Apply(vals, "latest")
+	assert.ErrorContains(t, err, `invalid chart version "latest"`)
+}
+
+func TestValuesMigrationsApplyCollision(t *testing.T) {
+	m, err := ParseValuesMigrations([]byte(`
+migrations:
+- operations:
+  - op: rename
+    from: replicas
+    to: replicaCount
+  - op: setDefault
+    path: image.tag
+    value: latest
+`))
+	require.NoError(t, err)
+
+	migrated, applied, err := m.Apply(map[string]interface{}{
+		"replicas":     3,
+		"replicaCount": 5,
+		"image":        "nginx",
+	}, "1.0.0")
+	assert.ErrorContains(t, err, "cannot set image.tag: image is not a table")
+	assert.Nil(t, migrated)
+	assert.Nil(t, applied)
+
+	migrated, applied, err = m.Apply(map[string]interface{}{
+		"replicas":     3,
+		"replicaCount": 5,
+	}, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": 5,
+		"image":        map[string]interface{}{"tag": "latest"},
+	}, migrated)
+	assert.Equal(t, []string{
+		"migrations[0]: dropped replicas, as replicaCount is already set",
+		"migrations[0]: set image.tag to latest",
+	}, applied)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sync"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// LicenseAnnotation is the chart annotation holding the SPDX license
+// expression of the chart.
+const LicenseAnnotation = "artifacthub.io/license"
+
+// LinkStatus is the result of the verification of a field of a chart's
+// metadata.
+type LinkStatus string
+
+const (
+	// LinkValid is a well-formed field which was not probed.
+	LinkValid LinkStatus = "valid"
+	// LinkReachable is a well-formed URL which answered a probe.
+	LinkReachable LinkStatus = "reachable"
+	// LinkInvalid is a malformed field.
+	LinkInvalid LinkStatus = "invalid"
+	// LinkUnreachable is a well-formed URL which did not answer a probe
+	// successfully.
+	LinkUnreachable LinkStatus = "unreachable"
+	// LinkUnknown is a well-formed license expression using identifiers Helm
+	// does not know.
+	LinkUnknown LinkStatus = "unknown"
+)
+
+// Failed reports whether the field failed the verification.
+func (s LinkStatus) Failed() bool {
+	return s == LinkInvalid || s == LinkUnreachable
+}
+
+// LinkCheck is the verification of a field of a chart's metadata.
+type LinkCheck struct {
+	// Field is the path of the field, such as "sources[0]" or
+	// "maintainers[1].email".
+	Field   string     `json:"field"`
+	Value   string     `json:"value"`
+	Status  LinkStatus `json:"status"`
+	Message string     `json:"message,omitempty"`
+}
+
+// Default options of VerifyLinks.
+const (
+	DefaultProbeTimeout     = 5 * time.Second
+	DefaultProbeParallelism = 4
+)
+
+// VerifyLinksOptions controls the verification of a chart's metadata.
+type VerifyLinksOptions struct {
+	// Probe sends a HEAD request to each URL to check that it is reachable.
+	// Nothing is sent over the network unless it is set.
+	Probe bool
+	// Timeout is the time given to each probe, DefaultProbeTimeout if zero.
+	Timeout time.Duration
+	// Parallelism is the maximum number of probes sent at once,
+	// DefaultProbeParallelism if zero or less.
+	Parallelism int
+	// Client sends the probes. It defaults to a client using the proxy of
+	// the environment.
+	Client *http.Client
+}
+
+// VerifyLinks checks the URLs of the home, sources, icon and maintainers of a
+// chart, the email addresses of its maintainers, and the SPDX license
+// expression of its LicenseAnnotation. The fields which are not set are not
+// reported.
+func VerifyLinks(ctx context.Context, md *chart.Metadata, opts VerifyLinksOptions) []LinkCheck {
+	var checks []LinkCheck
+	var urls []int
+	addURL := func(field, value string) {
+		if value == "" {
+			return
+		}
+		check := LinkCheck{Field: field, Value: value, Status: LinkValid}
+		if err := validateLinkURL(value); err != nil {
+			check.Status, check.Message = LinkInvalid, err.Error()
+		} else {
+			urls = append(urls, len(checks))
+		}
+		checks = append(checks, check)
+	}
+
+	addURL("home", md.Home)
+	for i, source := range md.Sources {
+		addURL(fmt.Sprintf("sources[%d]", i), source)
+	}
+	addURL("icon", md.Icon)
+	for i, m := range md.Maintainers {
+		if m == nil {
+			continue
+		}
+		if m.Email != "" {
+			check := LinkCheck{Field: fmt.Sprintf("maintainers[%d].email", i), Value: m.Email, Status: LinkValid}
+			if err := validateEmail(m.Email); err != nil {
+				check.Status, check.Message = LinkInvalid, err.Error()
+			}
+			checks = append(checks, check)
+		}
+		addURL(fmt.Sprintf("maintainers[%d].url", i), m.URL)
+	}
+	if license, ok := md.Annotations[LicenseAnnotation]; ok {
+		check := LinkCheck{Field: "annotations." + LicenseAnnotation, Value: license, Status: LinkValid}
+		if err := validateSPDXExpression(license); errors.Is(err, errUnknownSPDXLicense) {
+			check.Status, check.Message = LinkUnknown, err.Error()
+		} else if err != nil {
+			check.Status, check.Message = LinkInvalid, err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	if opts.Probe {
+		probeLinks(ctx, checks, urls, opts)
+	}
+	return checks
+}
+
+// validateLinkURL checks that link is an absolute HTTP or HTTPS URL.
+func validateLinkURL(link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return errors.Unwrap(err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("not an http or https URL")
+	}
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
+
+// validateEmail checks that email is a bare RFC 5322 address.
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return err
+	}
+	if addr.Name != "" || addr.Address != email {
+		return errors.New("not a bare email address")
+	}
+	return nil
+}
+
+// probeLinks probes the URLs of the checks at the indexes urls, each URL once,
+// and updates their status.
+func probeLinks(ctx context.Context, checks []LinkCheck, urls []int, opts VerifyLinksOptions) {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultProbeParallelism
+	}
+
+	var links []string
+	seen := make(map[string]int)
+	for _, i := range urls {
+		if _, ok := seen[checks[i].Value]; !ok {
+			seen[checks[i].Value] = len(links)
+			links = append(links, checks[i].Value)
+		}
+	}
+
+	errs := make([]error, len(links))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelism)
+	for j, link := range links {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[j] = probeLink(ctx, client, link, timeout)
+		}()
+	}
+	wg.Wait()
+
+	for _, i := range urls {
+		if err := errs[seen[checks[i].Value]]; err != nil {
+			checks[i].Status, checks[i].Message = LinkUnreachable, err.Error()
+		} else {
+			checks[i].Status = LinkReachable
+		}
+	}
+}
+
+// probeLink sends a HEAD request to link, falling back to a GET request for
+// servers which do not allow HEAD, and fails unless the final response is
+// successful.
+func probeLink(ctx context.Context, client *http.Client, link string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status, err := probeLinkWith(ctx, client, http.MethodHead, link)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = probeLinkWith(ctx, client, http.MethodGet, link)
+	}
+	if err != nil {
+		return err
+	}
+	if status >= 400 {
+		return fmt.Errorf("HTTP %d %s", status, http.StatusText(status))
+	}
+	return nil
+}
+
+func probeLinkWith(ctx context.Context, client *http.Client, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
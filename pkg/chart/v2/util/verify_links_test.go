@@ -0,0 +1,134 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+func TestVerifyLinks(t *testing.T) {
+	md := &chart.Metadata{
+		Home:    "https://helm.sh",
+		Sources: []string{"https://github.com/helm/helm", "github.com/helm/charts", ""},
+		Icon:    "ftp://helm.sh/icon.png",
+		Maintainers: []*chart.Maintainer{
+			{Name: "Helm", Email: "helm@example.com", URL: "https://helm.sh/maintainers"},
+			{Name: "Nobody", Email: "Nobody <nobody@example.com>"},
+			{Name: "Nowhere", Email: "nowhere"},
+			nil,
+		},
+		Annotations: map[string]string{LicenseAnnotation: "Apache-2.0 OR Foo"},
+	}
+
+	expect := []LinkCheck{
+		{Field: "home", Value: "https://helm.sh", Status: LinkValid},
+		{Field: "sources[0]", Value: "https://github.com/helm/helm", Status: LinkValid},
+		{Field: "sources[1]", Value: "github.com/helm/charts", Status: LinkInvalid, Message: "not an http or https URL"},
+		{Field: "icon", Value: "ftp://helm.sh/icon.png", Status: LinkInvalid, Message: "not an http or https URL"},
+		{Field: "maintainers[0].email", Value: "helm@example.com", Status: LinkValid},
+		{Field: "maintainers[0].url", Value: "https://helm.sh/maintainers", Status: LinkValid},
+		{Field: "maintainers[1].email", Value: "Nobody <nobody@example.com>", Status: LinkInvalid, Message: "not a bare email address"},
+		{Field: "maintainers[2].email", Value: "nowhere", Status: LinkInvalid, Message: "mail: missing '@' or angle-addr"},
+		{Field: "annotations." + LicenseAnnotation, Value: "Apache-2.0 OR Foo", Status: LinkUnknown, Message: "unknown SPDX identifier: Foo"},
+	}
+
+	checks := VerifyLinks(context.Background(), md, VerifyLinksOptions{})
+	if !reflect.DeepEqual(checks, expect) {
+		t.Errorf("Expected\n%+v\nGot\n%+v", expect, checks)
+	}
+}
+
+func TestVerifyLinksProbe(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path] = append(requests[r.URL.Path], r.Method)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/ok":
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	md := &chart.Metadata{
+		Home:        srv.URL + "/ok",
+		Sources:     []string{srv.URL + "/missing", srv.URL + "/no-head", srv.URL + "/ok", "not a url"},
+		Maintainers: []*chart.Maintainer{{Name: "Helm", URL: srv.URL + "/ok"}},
+	}
+
+	checks := VerifyLinks(context.Background(), md, VerifyLinksOptions{Probe: true, Client: srv.Client(), Parallelism: 2})
+	statuses := map[string]LinkStatus{}
+	for _, check := range checks {
+		statuses[check.Field] = check.Status
+	}
+	expect := map[string]LinkStatus{
+		"home":               LinkReachable,
+		"sources[0]":         LinkUnreachable,
+		"sources[1]":         LinkReachable,
+		"sources[2]":         LinkReachable,
+		"sources[3]":         LinkInvalid,
+		"maintainers[0].url": LinkReachable,
+	}
+	if !reflect.DeepEqual(statuses, expect) {
+		t.Errorf("Expected %v, got %v", expect, statuses)
+	}
+	if checks[1].Message != "HTTP 404 Not Found" {
+		t.Errorf("Expected the message of an unreachable URL to be the status, got %q", checks[1].Message)
+	}
+
+	expectRequests := map[string][]string{
+		"/ok":      {http.MethodHead},
+		"/missing": {http.MethodHead},
+		"/no-head": {http.MethodHead, http.MethodGet},
+	}
+	if !reflect.DeepEqual(requests, expectRequests) {
+		t.Errorf("Expected each URL to be probed once with %v, got %v", expectRequests, requests)
+	}
+}
+
+func TestVerifyLinksProbeTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	md := &chart.Metadata{Home: srv.URL}
+	checks := VerifyLinks(context.Background(), md, VerifyLinksOptions{Probe: true, Client: srv.Client(), Timeout: 50 * time.Millisecond})
+	if checks[0].Status != LinkUnreachable {
+		t.Errorf("Expected a URL which does not answer in time to be unreachable, got %s", checks[0].Status)
+	}
+}
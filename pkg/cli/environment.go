@@ -77,6 +77,9 @@ type EnvSettings struct {
 	Debug bool
 	// RegistryConfig is the path to the registry config file.
 	RegistryConfig string
+	// RegistryMirrorsConfig is the path to the file of the registry mirrors
+	// charts are pulled from.
+	RegistryMirrorsConfig string
 	// RepositoryConfig is the path to the repositories file.
 	RepositoryConfig string
 	// RepositoryCache is the path to the repository cache directory.
@@ -91,6 +94,23 @@ type EnvSettings struct {
 	QPS float32
 	// NoColor disables colorized output
 	NoColor bool
+	// DownloadRetries is the number of times a failed chart download is retried.
+	DownloadRetries int
+	// CacheMaxSize, if set, is the size the repository cache is brought back
+	// under after downloading charts or indexes, e.g. "1Gi".
+	CacheMaxSize string
+	// ReleaseWebhookURL, if set, is the endpoint every install, upgrade,
+	// rollback and uninstall is recorded to.
+	ReleaseWebhookURL string
+	// ReleaseWebhookSecret, if set, is the key the payloads posted to
+	// ReleaseWebhookURL are signed with.
+	ReleaseWebhookSecret string
+	// RepositoryBearerToken, if set, is the bearer token sent to the host of
+	// the chart downloaded when none is given with a flag.
+	RepositoryBearerToken string
+	// FieldManager, if set, is the name of the manager of the fields Helm
+	// sets on the resources it creates or updates.
+	FieldManager string
 }
 
 func New() *EnvSettings {
@@ -107,11 +127,18 @@ func New() *EnvSettings {
 		KubeInsecureSkipTLSVerify: envBoolOr("HELM_KUBEINSECURE_SKIP_TLS_VERIFY", false),
 		PluginsDirectory:          envOr("HELM_PLUGINS", helmpath.DataPath("plugins")),
 		RegistryConfig:            envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry/config.json")),
+		RegistryMirrorsConfig:     envOr("HELM_REGISTRY_MIRRORS_CONFIG", helmpath.ConfigPath("registry/mirrors.yaml")),
 		RepositoryConfig:          envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
 		RepositoryCache:           envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
 		BurstLimit:                envIntOr("HELM_BURST_LIMIT", defaultBurstLimit),
 		QPS:                       envFloat32Or("HELM_QPS", defaultQPS),
 		NoColor:                   envBoolOr("NO_COLOR", false),
+		DownloadRetries:           envIntOr("HELM_DOWNLOAD_RETRIES", 0),
+		CacheMaxSize:              os.Getenv("HELM_CACHE_MAX_SIZE"),
+		ReleaseWebhookURL:         os.Getenv("HELM_RELEASE_WEBHOOK_URL"),
+		ReleaseWebhookSecret:      os.Getenv("HELM_RELEASE_WEBHOOK_SECRET"),
+		RepositoryBearerToken:     os.Getenv("HELM_REPOSITORY_BEARER_TOKEN"),
+		FieldManager:              os.Getenv("HELM_FIELD_MANAGER"),
 	}
 	env.Debug, _ = strconv.ParseBool(os.Getenv("HELM_DEBUG"))
 
@@ -164,6 +191,7 @@ func (s *EnvSettings) AddFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&s.BurstLimit, "burst-limit", s.BurstLimit, "client-side default throttling limit")
 	fs.Float32Var(&s.QPS, "qps", s.QPS, "queries per second used when communicating with the Kubernetes API, not including bursting")
 	fs.BoolVar(&s.NoColor, "no-color", s.NoColor, "disable colorized output")
+	fs.StringVar(&s.FieldManager, "field-manager", s.FieldManager, "name of the manager of the fields Helm sets on the resources it creates or updates, the name of the binary if not set")
 }
 
 func envOr(name, def string) string {
@@ -219,19 +247,24 @@ func envCSV(name string) (ls []string) {
 
 func (s *EnvSettings) EnvVars() map[string]string {
 	envvars := map[string]string{
-		"HELM_BIN":               os.Args[0],
-		"HELM_CACHE_HOME":        helmpath.CachePath(""),
-		"HELM_CONFIG_HOME":       helmpath.ConfigPath(""),
-		"HELM_DATA_HOME":         helmpath.DataPath(""),
-		"HELM_DEBUG":             fmt.Sprint(s.Debug),
-		"HELM_PLUGINS":           s.PluginsDirectory,
-		"HELM_REGISTRY_CONFIG":   s.RegistryConfig,
-		"HELM_REPOSITORY_CACHE":  s.RepositoryCache,
-		"HELM_REPOSITORY_CONFIG": s.RepositoryConfig,
-		"HELM_NAMESPACE":         s.Namespace(),
-		"HELM_MAX_HISTORY":       strconv.Itoa(s.MaxHistory),
-		"HELM_BURST_LIMIT":       strconv.Itoa(s.BurstLimit),
-		"HELM_QPS":               strconv.FormatFloat(float64(s.QPS), 'f', 2, 32),
+		"HELM_BIN":                     os.Args[0],
+		"HELM_CACHE_HOME":              helmpath.CachePath(""),
+		"HELM_CACHE_MAX_SIZE":          s.CacheMaxSize,
+		"HELM_CONFIG_HOME":             helmpath.ConfigPath(""),
+		"HELM_DATA_HOME":               helmpath.DataPath(""),
+		"HELM_DEBUG":                   fmt.Sprint(s.Debug),
+		"HELM_PLUGINS":                 s.PluginsDirectory,
+		"HELM_REGISTRY_CONFIG":         s.RegistryConfig,
+		"HELM_REGISTRY_MIRRORS_CONFIG": s.RegistryMirrorsConfig,
+		"HELM_REPOSITORY_CACHE":        s.RepositoryCache,
+		"HELM_REPOSITORY_CONFIG":       s.RepositoryConfig,
+		"HELM_NAMESPACE":               s.Namespace(),
+		"HELM_MAX_HISTORY":             strconv.Itoa(s.MaxHistory),
+		"HELM_BURST_LIMIT":             strconv.Itoa(s.BurstLimit),
+		"HELM_QPS":                     strconv.FormatFloat(float64(s.QPS), 'f', 2, 32),
+		"HELM_DOWNLOAD_RETRIES":        strconv.Itoa(s.DownloadRetries),
+		"HELM_RELEASE_WEBHOOK_URL":     s.ReleaseWebhookURL,
+		"HELM_FIELD_MANAGER":           s.FieldManager,
 
 		// broken, these are populated from helm flags and not kubeconfig.
 		"HELM_KUBECONTEXT":                  s.KubeContext,
@@ -59,20 +59,23 @@ func TestEnvSettings(t *testing.T) {
 		kubeTLSServer string
 		burstLimit    int
 		qps           float32
+		fieldManager  string
 	}{
 		{
-			name:       "defaults",
-			ns:         "default",
-			maxhistory: defaultMaxHistory,
-			burstLimit: defaultBurstLimit,
-			qps:        defaultQPS,
+			name:         "defaults",
+			ns:           "default",
+			maxhistory:   defaultMaxHistory,
+			burstLimit:   defaultBurstLimit,
+			qps:          defaultQPS,
+			fieldManager: "",
 		},
 		{
 			name:          "with flags set",
-			args:          "--debug --namespace=myns --kube-as-user=poro --kube-as-group=admins --kube-as-group=teatime --kube-as-group=snackeaters --kube-ca-file=/tmp/ca.crt --burst-limit 100  --qps 50.12 --kube-insecure-skip-tls-verify=true --kube-tls-server-name=example.org",
+			args:          "--debug --namespace=myns --kube-as-user=poro --kube-as-group=admins --kube-as-group=teatime --kube-as-group=snackeaters --kube-ca-file=/tmp/ca.crt --burst-limit 100  --qps 50.12 --kube-insecure-skip-tls-verify=true --kube-tls-server-name=example.org --field-manager=argo",
 			ns:            "myns",
 			debug:         true,
 			maxhistory:    defaultMaxHistory,
+			fieldManager:  "argo",
 			burstLimit:    100,
 			qps:           50.12,
 			kubeAsUser:    "poro",
@@ -83,7 +86,8 @@ func TestEnvSettings(t *testing.T) {
 		},
 		{
 			name:          "with envvars set",
-			envvars:       map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt", "HELM_BURST_LIMIT": "150", "HELM_KUBEINSECURE_SKIP_TLS_VERIFY": "true", "HELM_KUBETLS_SERVER_NAME": "example.org", "HELM_QPS": "60.34"},
+			envvars:       map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt", "HELM_BURST_LIMIT": "150", "HELM_KUBEINSECURE_SKIP_TLS_VERIFY": "true", "HELM_KUBETLS_SERVER_NAME": "example.org", "HELM_QPS": "60.34", "HELM_FIELD_MANAGER": "flux"},
+			fieldManager:  "flux",
 			ns:            "yourns",
 			maxhistory:    5,
 			burstLimit:    150,
@@ -97,8 +101,9 @@ func TestEnvSettings(t *testing.T) {
 		},
 		{
 			name:          "with flags and envvars set",
-			args:          "--debug --namespace=myns --kube-as-user=poro --kube-as-group=admins --kube-as-group=teatime --kube-as-group=snackeaters --kube-ca-file=/my/ca.crt --burst-limit 175 --qps 70 --kube-insecure-skip-tls-verify=true --kube-tls-server-name=example.org",
-			envvars:       map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt", "HELM_BURST_LIMIT": "200", "HELM_KUBEINSECURE_SKIP_TLS_VERIFY": "true", "HELM_KUBETLS_SERVER_NAME": "example.org", "HELM_QPS": "40"},
+			args:          "--debug --namespace=myns --kube-as-user=poro --kube-as-group=admins --kube-as-group=teatime --kube-as-group=snackeaters --kube-ca-file=/my/ca.crt --burst-limit 175 --qps 70 --kube-insecure-skip-tls-verify=true --kube-tls-server-name=example.org --field-manager=argo",
+			envvars:       map[string]string{"HELM_DEBUG": "1", "HELM_NAMESPACE": "yourns", "HELM_KUBEASUSER": "pikachu", "HELM_KUBEASGROUPS": ",,,operators,snackeaters,partyanimals", "HELM_MAX_HISTORY": "5", "HELM_KUBECAFILE": "/tmp/ca.crt", "HELM_BURST_LIMIT": "200", "HELM_KUBEINSECURE_SKIP_TLS_VERIFY": "true", "HELM_KUBETLS_SERVER_NAME": "example.org", "HELM_QPS": "40", "HELM_FIELD_MANAGER": "flux"},
+			fieldManager:  "argo",
 			ns:            "myns",
 			debug:         true,
 			maxhistory:    5,
@@ -111,12 +116,13 @@ func TestEnvSettings(t *testing.T) {
 			kubeInsecure:  true,
 		},
 		{
-			name:       "invalid kubeconfig",
-			ns:         "testns",
-			args:       "--namespace=testns --kubeconfig=/path/to/fake/file",
-			maxhistory: defaultMaxHistory,
-			burstLimit: defaultBurstLimit,
-			qps:        defaultQPS,
+			name:         "invalid kubeconfig",
+			ns:           "testns",
+			args:         "--namespace=testns --kubeconfig=/path/to/fake/file",
+			maxhistory:   defaultMaxHistory,
+			burstLimit:   defaultBurstLimit,
+			qps:          defaultQPS,
+			fieldManager: "",
 		},
 	}
 
@@ -164,6 +170,9 @@ func TestEnvSettings(t *testing.T) {
 			if tt.kubeTLSServer != settings.KubeTLSServerName {
 				t.Errorf("expected kubeTLSServer %q, got %q", tt.kubeTLSServer, settings.KubeTLSServerName)
 			}
+			if tt.fieldManager != settings.FieldManager {
+				t.Errorf("expected fieldManager %q, got %q", tt.fieldManager, settings.FieldManager)
+			}
 		})
 	}
 }
@@ -0,0 +1,408 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/strvals"
+)
+
+// CoerceSetValues converts the scalars of vals set with --set and --set-string
+// to the types declared for them by the values.schema.json of the chart and of
+// its subcharts: "3" becomes 3 for an integer, 3 becomes "3" for a string. vals
+// are the values merged by MergeValues.
+//
+// A value is left as is when no schema declares its type, or when the schemas
+// allow several types, as with an anyOf of an integer and a string. An error is
+// returned when a value cannot be converted, such as "abc" for an integer.
+// Nothing is done when NoSchemaCoercion is set.
+func (opts *Options) CoerceSetValues(vals map[string]interface{}, chrt *chart.Chart) error {
+	if opts.NoSchemaCoercion || (len(opts.Values) == 0 && len(opts.StringValues) == 0) {
+		return nil
+	}
+
+	// Parse the values set on the command line again, on their own, to know
+	// which of vals they set.
+	set := map[string]interface{}{}
+	for _, value := range opts.Values {
+		if err := strvals.ParseInto(value, set); err != nil {
+			return fmt.Errorf("failed parsing --set data: %w", err)
+		}
+	}
+	for _, value := range opts.StringValues {
+		if err := strvals.ParseIntoString(value, set); err != nil {
+			return fmt.Errorf("failed parsing --set-string data: %w", err)
+		}
+	}
+
+	schemas, err := chartSchemas(chrt)
+	if err != nil {
+		return err
+	}
+	return coerceMap(vals, set, nil, schemas)
+}
+
+// chartSchema is the schema of a chart, with the path of the values of the
+// chart within the values of the top-level chart.
+type chartSchema struct {
+	chart  string
+	prefix []string
+	root   map[string]interface{}
+}
+
+// chartSchemas returns the schemas of the chart and of its subcharts.
+func chartSchemas(chrt *chart.Chart) ([]chartSchema, error) {
+	var schemas []chartSchema
+	var walk func(c *chart.Chart, prefix []string) error
+	walk = func(c *chart.Chart, prefix []string) error {
+		if len(c.Schema) > 0 {
+			var root map[string]interface{}
+			if err := json.Unmarshal(c.Schema, &root); err != nil {
+				return fmt.Errorf("cannot parse the values schema of chart %s: %w", c.Name(), err)
+			}
+			schemas = append(schemas, chartSchema{chart: c.Name(), prefix: prefix, root: root})
+		}
+		for _, sub := range c.Dependencies() {
+			for _, name := range subchartKeys(c, sub) {
+				if err := walk(sub, append(prefix[:len(prefix):len(prefix)], name)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(chrt, nil); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// subchartKeys returns the keys of the values of the subchart sub within the
+// values of c: the aliases of the dependencies of c using sub, or its name. A
+// chart used several times under different aliases has one key for each, as
+// with chartutil.ProcessDependencies.
+func subchartKeys(c, sub *chart.Chart) []string {
+	var keys []string
+	for _, dep := range c.Metadata.Dependencies {
+		if dep == nil || dep.Name != sub.Name() {
+			continue
+		}
+		if dep.Version != "" && sub.Metadata.Version != "" && !chartutil.IsCompatibleRange(dep.Version, sub.Metadata.Version) {
+			continue
+		}
+		key := dep.Name
+		if dep.Alias != "" {
+			key = dep.Alias
+		}
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		// The subchart is not listed as a dependency, or its dependencies
+		// were already processed, and it is named as its values.
+		keys = append(keys, sub.Name())
+	}
+	return keys
+}
+
+func coerceMap(vals, set map[string]interface{}, path []string, schemas []chartSchema) error {
+	for k, s := range set {
+		v, ok := vals[k]
+		if !ok {
+			continue
+		}
+		coerced, err := coerceValue(v, s, append(path[:len(path):len(path)], k), schemas)
+		if err != nil {
+			return err
+		}
+		vals[k] = coerced
+	}
+	return nil
+}
+
+func coerceValue(v, set interface{}, path []string, schemas []chartSchema) (interface{}, error) {
+	switch s := set.(type) {
+	case map[string]interface{}:
+		if m, ok := v.(map[string]interface{}); ok {
+			return v, coerceMap(m, s, path, schemas)
+		}
+		return v, nil
+	case []interface{}:
+		list, ok := v.([]interface{})
+		if !ok {
+			return v, nil
+		}
+		for i, item := range s {
+			if item == nil || i >= len(list) {
+				continue
+			}
+			coerced, err := coerceValue(list[i], item, append(path[:len(path):len(path)], strconv.Itoa(i)), schemas)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	case nil:
+		// null removes the value.
+		return v, nil
+	}
+
+	typ, chartName := declaredType(path, schemas)
+	if typ == "" {
+		return v, nil
+	}
+	coerced, ok := coerceScalar(v, typ)
+	if !ok {
+		return nil, fmt.Errorf("cannot use %v for %s: the values schema of chart %s declares %s %s", formatScalar(v), formatPath(path), chartName, article(typ), typ)
+	}
+	return coerced, nil
+}
+
+// declaredType returns the single scalar type declared for the value at path,
+// and the chart declaring it. It is empty when no type, or several ones, are
+// declared.
+func declaredType(path []string, schemas []chartSchema) (string, string) {
+	var typ, chartName string
+	for _, cs := range schemas {
+		if len(path) <= len(cs.prefix) || !hasPrefix(path, cs.prefix) {
+			continue
+		}
+		for _, t := range schemaTypes(cs.root, cs.root, path[len(cs.prefix):], 0) {
+			switch {
+			case typ == "":
+				typ, chartName = t, cs.chart
+			case t != typ:
+				return "", ""
+			}
+		}
+	}
+	switch typ {
+	case "integer", "number", "boolean", "string":
+		return typ, chartName
+	}
+	return "", ""
+}
+
+// maxSchemaDepth bounds the $ref resolved while looking up a type, in case of
+// a cycle.
+const maxSchemaDepth = 32
+
+// schemaTypes returns the types declared by schema for the value at path, each
+// alternative of an anyOf or oneOf adding its own.
+func schemaTypes(root, schema map[string]interface{}, path []string, depth int) []string {
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return schemaTypes(root, resolveRef(root, ref), path, depth+1)
+	}
+
+	var types []string
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, _ := schema[key].([]interface{})
+		for _, sub := range subs {
+			sub, _ := sub.(map[string]interface{})
+			types = append(types, schemaTypes(root, sub, path, depth+1)...)
+		}
+	}
+
+	if len(path) == 0 {
+		switch t := schema["type"].(type) {
+		case string:
+			types = append(types, t)
+		case []interface{}:
+			for _, t := range t {
+				if t, ok := t.(string); ok && t != "null" {
+					types = append(types, t)
+				}
+			}
+		}
+		return types
+	}
+
+	for _, child := range childSchemas(schema, path[0]) {
+		types = append(types, schemaTypes(root, child, path[1:], depth+1)...)
+	}
+	return types
+}
+
+// childSchemas returns the schemas of the property, or item, key of the value
+// described by schema.
+func childSchemas(schema map[string]interface{}, key string) []map[string]interface{} {
+	var children []map[string]interface{}
+	add := func(s interface{}) {
+		if s, ok := s.(map[string]interface{}); ok {
+			children = append(children, s)
+		}
+	}
+
+	if i, err := strconv.Atoi(key); err == nil {
+		if prefixItems, ok := schema["prefixItems"].([]interface{}); ok && i < len(prefixItems) {
+			add(prefixItems[i])
+			return children
+		}
+		add(schema["items"])
+		if len(children) > 0 {
+			return children
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if p, ok := properties[key]; ok {
+			add(p)
+			return children
+		}
+	}
+	if patterns, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		for pattern, p := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(key) {
+				add(p)
+			}
+		}
+		if len(children) > 0 {
+			return children
+		}
+	}
+	add(schema["additionalProperties"])
+	return children
+}
+
+// resolveRef resolves a reference local to the schema, such as
+// "#/$defs/port".
+func resolveRef(root map[string]interface{}, ref string) map[string]interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	var node interface{} = root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[token]
+	}
+	m, _ := node.(map[string]interface{})
+	return m
+}
+
+// coerceScalar converts v to typ. It returns false if v cannot be converted.
+func coerceScalar(v interface{}, typ string) (interface{}, bool) {
+	switch typ {
+	case "integer":
+		switch v := v.(type) {
+		case int64, int:
+			return v, true
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), true
+			}
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i, true
+			}
+		}
+	case "number":
+		switch v := v.(type) {
+		case int64, int, float64:
+			return v, true
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i, true
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f, true
+			}
+		}
+	case "boolean":
+		switch v := v.(type) {
+		case bool:
+			return v, true
+		case string:
+			if v == "true" || v == "false" {
+				return v == "true", true
+			}
+		}
+	case "string":
+		switch v := v.(type) {
+		case string:
+			return v, true
+		case int64:
+			return strconv.FormatInt(v, 10), true
+		case int:
+			return strconv.Itoa(v), true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	}
+	return nil, false
+}
+
+func formatScalar(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatPath formats path as with --set, such as "ports[0].name".
+func formatPath(path []string) string {
+	var sb strings.Builder
+	for i, key := range path {
+		if _, err := strconv.Atoi(key); err == nil && i > 0 {
+			sb.WriteString("[" + key + "]")
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(key)
+	}
+	return sb.String()
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "an"
+	}
+	return "a"
+}
+
+func hasPrefix(path, prefix []string) bool {
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
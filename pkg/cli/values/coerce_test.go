@@ -0,0 +1,246 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+const coerceTestSchema = `{
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer"},
+    "name": {"type": "string"},
+    "ratio": {"type": "number"},
+    "enabled": {"type": "boolean"},
+    "resources": {
+      "type": "object",
+      "properties": {
+        "limits": {
+          "type": "object",
+          "properties": {
+            "cpu": {"type": "string"},
+            "gpus": {"type": ["integer", "null"]}
+          }
+        }
+      }
+    },
+    "ports": {
+      "type": "array",
+      "items": {"$ref": "#/$defs/port"}
+    },
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "env": {"type": "object", "additionalProperties": {"type": "string"}},
+    "size": {"anyOf": [{"type": "integer"}, {"type": "string"}]},
+    "count": {"oneOf": [{"type": "integer", "minimum": 10}, {"type": "integer", "maximum": 0}]},
+    "mixed": {"type": ["integer", "string"]}
+  },
+  "$defs": {
+    "port": {
+      "type": "object",
+      "properties": {
+        "number": {"type": "integer"},
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+func coerceTestChart() *chart.Chart {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "sub"},
+		Schema:   []byte(`{"properties": {"port": {"type": "integer"}}}`),
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "parent"},
+		Schema:   []byte(coerceTestSchema),
+	}
+	c.AddDependency(sub)
+	return c
+}
+
+func TestCoerceSetValues(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         Options
+		expected     map[string]interface{}
+		expectsError string
+	}{
+		{
+			name:     "scalars",
+			opts:     Options{StringValues: []string{"replicas=3", "ratio=0.5", "enabled=true"}, Values: []string{"name=42"}},
+			expected: map[string]interface{}{"replicas": int64(3), "ratio": 0.5, "enabled": true, "name": "42"},
+		},
+		{
+			name:     "nested paths",
+			opts:     Options{Values: []string{"resources.limits.cpu=2", "resources.limits.gpus=1"}},
+			expected: map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "2", "gpus": int64(1)}}},
+		},
+		{
+			name: "arrays of typed items",
+			opts: Options{Values: []string{"ports[0].number=80", "tags={1,true}"}, StringValues: []string{"ports[0].name=http"}},
+			expected: map[string]interface{}{
+				"ports": []interface{}{map[string]interface{}{"number": int64(80), "name": "http"}},
+				"tags":  []interface{}{"1", "true"},
+			},
+		},
+		{
+			name:     "additional properties",
+			opts:     Options{Values: []string{"env.DEBUG=true"}},
+			expected: map[string]interface{}{"env": map[string]interface{}{"DEBUG": "true"}},
+		},
+		{
+			name:     "subchart",
+			opts:     Options{StringValues: []string{"sub.port=8080"}},
+			expected: map[string]interface{}{"sub": map[string]interface{}{"port": int64(8080)}},
+		},
+		{
+			name:     "ambiguous unions are skipped",
+			opts:     Options{StringValues: []string{"size=10", "mixed=10"}},
+			expected: map[string]interface{}{"size": "10", "mixed": "10"},
+		},
+		{
+			name:     "unions of a single type",
+			opts:     Options{StringValues: []string{"count=20"}},
+			expected: map[string]interface{}{"count": int64(20)},
+		},
+		{
+			name:     "undeclared values are kept",
+			opts:     Options{StringValues: []string{"other=3"}, Values: []string{"more.deep=4"}},
+			expected: map[string]interface{}{"other": "3", "more": map[string]interface{}{"deep": int64(4)}},
+		},
+		{
+			name:     "null",
+			opts:     Options{Values: []string{"replicas=null"}},
+			expected: map[string]interface{}{"replicas": nil},
+		},
+		{
+			name:     "no schema coercion",
+			opts:     Options{StringValues: []string{"replicas=3"}, NoSchemaCoercion: true},
+			expected: map[string]interface{}{"replicas": "3"},
+		},
+		{
+			name:         "invalid integer",
+			opts:         Options{Values: []string{"replicas=abc"}},
+			expectsError: `cannot use "abc" for replicas: the values schema of chart parent declares an integer`,
+		},
+		{
+			name:         "invalid nested item",
+			opts:         Options{Values: []string{"ports[0].number=http"}},
+			expectsError: `cannot use "http" for ports[0].number: the values schema of chart parent declares an integer`,
+		},
+		{
+			name:         "invalid boolean",
+			opts:         Options{Values: []string{"enabled=3"}},
+			expectsError: `cannot use 3 for enabled: the values schema of chart parent declares a boolean`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vals, err := tt.opts.MergeValues(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.opts.CoerceSetValues(vals, coerceTestChart())
+			if tt.expectsError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectsError) {
+					t.Fatalf("expected error %q, got %v", tt.expectsError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(vals, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, vals)
+			}
+		})
+	}
+}
+
+func TestCoerceSetValuesOnlySetValues(t *testing.T) {
+	// The values of the files are not converted, even at the paths of the
+	// values set on the command line.
+	vals := map[string]interface{}{
+		"replicas": "3",
+		"ports":    []interface{}{map[string]interface{}{"number": "80"}, map[string]interface{}{"number": "443"}},
+	}
+	opts := Options{Values: []string{"ports[1].number=8443"}}
+	vals["ports"].([]interface{})[1].(map[string]interface{})["number"] = "8443"
+
+	if err := opts.CoerceSetValues(vals, coerceTestChart()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"replicas": "3",
+		"ports":    []interface{}{map[string]interface{}{"number": "80"}, map[string]interface{}{"number": int64(8443)}},
+	}
+	if !reflect.DeepEqual(vals, expected) {
+		t.Errorf("expected %#v, got %#v", expected, vals)
+	}
+}
+
+func TestCoerceSetValuesWithoutSchema(t *testing.T) {
+	opts := Options{StringValues: []string{"replicas=3"}}
+	vals, err := opts.MergeValues(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.CoerceSetValues(vals, &chart.Chart{Metadata: &chart.Metadata{Name: "plain"}}); err != nil {
+		t.Fatal(err)
+	}
+	if vals["replicas"] != "3" {
+		t.Errorf("expected the value to be kept, got %#v", vals["replicas"])
+	}
+}
+
+func TestCoerceSetValuesAliasedSubchart(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "sub", Version: "1.2.3"},
+		Schema:   []byte(`{"properties": {"port": {"type": "integer"}}}`),
+	}
+	c := &chart.Chart{Metadata: &chart.Metadata{
+		Name: "parent",
+		Dependencies: []*chart.Dependency{
+			{Name: "sub", Version: "^1.0.0", Alias: "db"},
+			{Name: "sub", Version: "^1.0.0", Alias: "cache"},
+		},
+	}}
+	c.AddDependency(sub)
+
+	opts := Options{StringValues: []string{"db.port=5432", "cache.port=6379", "sub.port=80"}}
+	vals, err := opts.MergeValues(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.CoerceSetValues(vals, c); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"db":    map[string]interface{}{"port": int64(5432)},
+		"cache": map[string]interface{}{"port": int64(6379)},
+		"sub":   map[string]interface{}{"port": "80"},
+	}
+	if !reflect.DeepEqual(vals, expected) {
+		t.Errorf("expected %#v, got %#v", expected, vals)
+	}
+}
@@ -19,6 +19,7 @@ package values
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -32,21 +33,78 @@ import (
 
 // Options captures the different ways to specify values
 type Options struct {
-	ValueFiles    []string // -f/--values
-	StringValues  []string // --set-string
-	Values        []string // --set
-	FileValues    []string // --set-file
-	JSONValues    []string // --set-json
-	LiteralValues []string // --set-literal
+	ValueFiles          []string // -f/--values
+	ValuesFromResources []string // --values-from
+	StringValues        []string // --set-string
+	Values              []string // --set
+	FileValues          []string // --set-file
+	JSONValues          []string // --set-json
+	LiteralValues       []string // --set-literal
+
+	// NoSchemaCoercion disables the conversion of the values set with --set
+	// and --set-string to the types declared by the values schema of the
+	// chart, done by CoerceSetValues.
+	NoSchemaCoercion bool // --no-schema-coercion
+
+	// EditValues, when set, opens the merged values in an editor before they
+	// are used: "masked" hides the values which may be sensitive, "full"
+	// shows them as they are.
+	EditValues string // --edit-values
+
+	// ResourceReader reads the values referenced by ValuesFromResources. It
+	// is only set when the cluster can be reached.
+	ResourceReader ResourceReader
+
+	// valuesFromAfter holds, for each entry of ValuesFromResources added with
+	// AddValuesFromResource, the number of values files given before it.
+	valuesFromAfter []int
 }
 
-// MergeValues merges values from files specified via -f/--values and directly
-// via --set-json, --set, --set-string, or --set-file, marshaling them to YAML
+// AddValuesFromResource adds a reference to the values held by a ConfigMap or
+// a Secret, to be merged after the values files given so far and before the
+// ones given next.
+func (opts *Options) AddValuesFromResource(ref string) {
+	opts.valuesFromAfter = append(opts.valuesFromAfter, len(opts.ValueFiles))
+	opts.ValuesFromResources = append(opts.ValuesFromResources, ref)
+}
+
+// MergeValues merges values from files specified via -f/--values, resources
+// specified via --values-from and directly via --set-json, --set,
+// --set-string, or --set-file, marshaling them to YAML
 func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, error) {
 	base := map[string]interface{}{}
 
+	if len(opts.ValuesFromResources) > 0 && opts.ResourceReader == nil {
+		return nil, errors.New("values from cluster resources (--values-from) cannot be read without access to a Kubernetes cluster")
+	}
+
+	// User specified values from resources via --values-from, merged in
+	// command line order with the values files. References not added with
+	// AddValuesFromResource are merged after all the values files.
+	next := 0
+	mergeResources := func(files int) error {
+		for ; next < len(opts.ValuesFromResources); next++ {
+			after := len(opts.ValueFiles)
+			if next < len(opts.valuesFromAfter) {
+				after = opts.valuesFromAfter[next]
+			}
+			if after > files {
+				return nil
+			}
+			currentMap, err := opts.readResource(opts.ValuesFromResources[next])
+			if err != nil {
+				return err
+			}
+			base = loader.MergeMaps(base, currentMap)
+		}
+		return nil
+	}
+
 	// User specified a values files via -f/--values
-	for _, filePath := range opts.ValueFiles {
+	for i, filePath := range opts.ValueFiles {
+		if err := mergeResources(i); err != nil {
+			return nil, err
+		}
 		raw, err := readFile(filePath, p)
 		if err != nil {
 			return nil, err
@@ -58,6 +116,9 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 		// Merge with the previous map
 		base = loader.MergeMaps(base, currentMap)
 	}
+	if err := mergeResources(len(opts.ValueFiles)); err != nil {
+		return nil, err
+	}
 
 	// User specified a value via --set-json
 	for _, value := range opts.JSONValues {
@@ -115,6 +176,23 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 	return base, nil
 }
 
+// readResource reads and parses the values held by the resource referenced by ref.
+func (opts *Options) readResource(ref string) (map[string]interface{}, error) {
+	r, err := ParseResourceRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := opts.ResourceReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values from %s: %w", ref, err)
+	}
+	currentMap, err := loader.LoadValues(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ref, err)
+	}
+	return currentMap, nil
+}
+
 // readFile load a file from stdin, the local directory, or a remote file with a url.
 func readFile(filePath string, p getter.Providers) ([]byte, error) {
 	if strings.TrimSpace(filePath) == "-" {
@@ -350,6 +350,26 @@ func TestMergeValues(t *testing.T) {
 				"foo": "true",
 			},
 		},
+		{
+			name: "set literal value overrides set value",
+			opts: Options{
+				Values:        []string{"foo=bar,baz=qux"},
+				LiteralValues: []string{"foo=bar,baz=qux"},
+			},
+			expected: map[string]interface{}{
+				"foo": "bar,baz=qux",
+				"baz": "qux",
+			},
+		},
+		{
+			name: "last set literal value wins",
+			opts: Options{
+				LiteralValues: []string{"foo=first", "foo=second"},
+			},
+			expected: map[string]interface{}{
+				"foo": "second",
+			},
+		},
 		{
 			name: "multiple options",
 			opts: Options{
@@ -387,3 +407,116 @@ func TestMergeValues(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeValuesFromResources(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.yaml")
+	second := filepath.Join(dir, "second.yaml")
+	if err := os.WriteFile(first, []byte("a: first\nb: first\nc: first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("c: second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resources := map[string]string{
+		"configmap/before#values.yaml":       "a: before\nb: before\nc: before\nd: before\n",
+		"secret/between#values.yaml":         "b: between\nc: between\n",
+		"configmap/shared/after#values.yaml": "d: after\n",
+	}
+	var read []string
+	reader := func(ref ResourceRef) ([]byte, error) {
+		read = append(read, ref.String())
+		data, ok := resources[ref.String()]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return []byte(data), nil
+	}
+
+	// -f is given between the --values-from references:
+	// --values-from configmap/before#values.yaml -f first.yaml
+	// --values-from secret/between#values.yaml -f second.yaml
+	// --values-from configmap/shared/after#values.yaml
+	opts := &Options{ResourceReader: reader}
+	opts.AddValuesFromResource("configmap/before#values.yaml")
+	opts.ValueFiles = append(opts.ValueFiles, first)
+	opts.AddValuesFromResource("secret/between#values.yaml")
+	opts.ValueFiles = append(opts.ValueFiles, second)
+	opts.AddValuesFromResource("configmap/shared/after#values.yaml")
+	opts.Values = []string{"e=set"}
+
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"a": "first",
+		"b": "between",
+		"c": "second",
+		"d": "after",
+		"e": "set",
+	}
+	if !reflect.DeepEqual(vals, expected) {
+		t.Errorf("expected %v, got %v", expected, vals)
+	}
+	if strings.Join(read, " ") != "configmap/before#values.yaml secret/between#values.yaml configmap/shared/after#values.yaml" {
+		t.Errorf("unexpected resources read: %v", read)
+	}
+
+	// References set directly are merged after the values files
+	opts = &Options{
+		ValueFiles:          []string{first},
+		ValuesFromResources: []string{"secret/between#values.yaml"},
+		ResourceReader:      reader,
+	}
+	vals, err = opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals["b"] != "between" || vals["a"] != "first" {
+		t.Errorf("expected values from the secret to override the values file, got %v", vals)
+	}
+}
+
+func TestMergeValuesFromResourcesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		reader  ResourceReader
+		wantErr string
+	}{
+		{
+			name:    "no cluster access",
+			ref:     "configmap/env#values.yaml",
+			wantErr: "cannot be read without access to a Kubernetes cluster",
+		},
+		{
+			name:    "invalid reference",
+			ref:     "configmap/env",
+			reader:  func(ResourceRef) ([]byte, error) { return nil, nil },
+			wantErr: "invalid resource reference",
+		},
+		{
+			name:    "read failure",
+			ref:     "configmap/env#values.yaml",
+			reader:  func(ResourceRef) ([]byte, error) { return nil, errors.New("forbidden") },
+			wantErr: "failed to read values from configmap/env#values.yaml: forbidden",
+		},
+		{
+			name:    "invalid YAML",
+			ref:     "configmap/env#values.yaml",
+			reader:  func(ResourceRef) ([]byte, error) { return []byte("a: [b"), nil },
+			wantErr: "failed to parse configmap/env#values.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &Options{ValuesFromResources: []string{tt.ref}, ResourceReader: tt.reader}
+			_, err := opts.MergeValues(getter.Providers{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
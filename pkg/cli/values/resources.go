@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of the resources values can be read from.
+const (
+	ResourceKindConfigMap = "configmap"
+	ResourceKindSecret    = "secret"
+)
+
+// ResourceRef references a key of a ConfigMap or a Secret holding values.
+type ResourceRef struct {
+	// Kind is either ResourceKindConfigMap or ResourceKindSecret.
+	Kind string
+	// Namespace is the namespace of the resource. When empty, the resource
+	// is read from the namespace of the release.
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// String returns the reference in the form accepted by ParseResourceRef.
+func (r ResourceRef) String() string {
+	if r.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s#%s", r.Kind, r.Namespace, r.Name, r.Key)
+	}
+	return fmt.Sprintf("%s/%s#%s", r.Kind, r.Name, r.Key)
+}
+
+// ParseResourceRef parses a reference of the form KIND/NAME#KEY or
+// KIND/NAMESPACE/NAME#KEY, where KIND is configmap (or cm) or secret.
+func ParseResourceRef(ref string) (ResourceRef, error) {
+	invalid := fmt.Errorf("invalid resource reference %q: expected configmap/NAME#KEY, secret/NAME#KEY or KIND/NAMESPACE/NAME#KEY", ref)
+
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || key == "" {
+		return ResourceRef{}, invalid
+	}
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" {
+			return ResourceRef{}, invalid
+		}
+	}
+
+	var r ResourceRef
+	switch len(parts) {
+	case 2:
+		r = ResourceRef{Kind: parts[0], Name: parts[1], Key: key}
+	case 3:
+		r = ResourceRef{Kind: parts[0], Namespace: parts[1], Name: parts[2], Key: key}
+	default:
+		return ResourceRef{}, invalid
+	}
+
+	switch strings.ToLower(r.Kind) {
+	case ResourceKindConfigMap, "cm":
+		r.Kind = ResourceKindConfigMap
+	case ResourceKindSecret:
+		r.Kind = ResourceKindSecret
+	default:
+		return ResourceRef{}, fmt.Errorf("invalid resource reference %q: values can only be read from a configmap or a secret", ref)
+	}
+	return r, nil
+}
+
+// ResourceReader reads the value of the key referenced by ref.
+type ResourceReader func(ref ResourceRef) ([]byte, error)
@@ -0,0 +1,81 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResourceRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		expect  ResourceRef
+		wantErr string
+	}{
+		{
+			ref:    "configmap/env#values.yaml",
+			expect: ResourceRef{Kind: ResourceKindConfigMap, Name: "env", Key: "values.yaml"},
+		},
+		{
+			ref:    "cm/env#values.yaml",
+			expect: ResourceRef{Kind: ResourceKindConfigMap, Name: "env", Key: "values.yaml"},
+		},
+		{
+			ref:    "ConfigMap/env#values.yaml",
+			expect: ResourceRef{Kind: ResourceKindConfigMap, Name: "env", Key: "values.yaml"},
+		},
+		{
+			ref:    "secret/shared/credentials#values.yaml",
+			expect: ResourceRef{Kind: ResourceKindSecret, Namespace: "shared", Name: "credentials", Key: "values.yaml"},
+		},
+		{ref: "configmap/env", wantErr: "expected configmap/NAME#KEY"},
+		{ref: "configmap/env#", wantErr: "expected configmap/NAME#KEY"},
+		{ref: "configmap#values.yaml", wantErr: "expected configmap/NAME#KEY"},
+		{ref: "configmap//env#values.yaml", wantErr: "expected configmap/NAME#KEY"},
+		{ref: "configmap/a/b/c#values.yaml", wantErr: "expected configmap/NAME#KEY"},
+		{ref: "deployment/env#values.yaml", wantErr: "values can only be read from a configmap or a secret"},
+	}
+	for _, tt := range tests {
+		r, err := ParseResourceRef(tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.ref, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.ref, err)
+			continue
+		}
+		if r != tt.expect {
+			t.Errorf("%s: expected %+v, got %+v", tt.ref, tt.expect, r)
+		}
+	}
+}
+
+func TestResourceRefString(t *testing.T) {
+	for _, ref := range []string{"configmap/env#values.yaml", "secret/shared/credentials#values.yaml"} {
+		r, err := ParseResourceRef(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.String() != ref {
+			t.Errorf("expected %s, got %s", ref, r.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cli/values"
+	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/getter"
+)
+
+const adoptDesc = `
+Bring existing resources, deployed without Helm, under a new release without
+redeploying them.
+
+The chart is rendered with the given values, and each rendered resource is
+compared with the live one. Only the fields set by the chart are compared: the
+status of the resources, and the fields defaulted by the cluster, are ignored,
+as are the fields given with --ignore-field, such as 'spec.replicas'.
+
+If all the resources exist and match, they are labeled and annotated as owned
+by the release, without any other change, and the release is recorded with
+the rendered manifest. The hooks of the chart are not run.
+
+Resources which differ from the chart, or which do not exist, are reported and
+block the adoption, unless --force-adopt-diffs is set: the chart is then
+applied to them. Use --dry-run to preview the adoption.
+
+    $ helm adopt web ./chart -f values.yaml --dry-run
+`
+
+func newAdoptCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewAdopt(cfg)
+	valueOpts := &values.Options{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "adopt NAME CHART",
+		Short: "bring existing resources under a new release",
+		Long:  adoptDesc,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return compListCharts(toComplete, true)
+			}
+			if len(args) > 1 {
+				return noMoreArgsComp()
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
+				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
+			if err != nil {
+				return fmt.Errorf("missing registry client: %w", err)
+			}
+			client.SetRegistryClient(registryClient)
+
+			if client.Version == "" && client.Devel {
+				client.Version = ">0.0.0-0"
+			}
+			cp, err := client.LocateChart(args[1], settings)
+			if err != nil {
+				return err
+			}
+			ch, err := loader.Load(cp)
+			if err != nil {
+				return err
+			}
+			if err := checkIfInstallable(ch); err != nil {
+				return err
+			}
+			if req := ch.Metadata.Dependencies; req != nil {
+				if err := action.CheckDependencies(ch, req); err != nil {
+					return fmt.Errorf("an error occurred while checking for chart dependencies. You may need to run `helm dependency build` to fetch missing dependencies: %w", err)
+				}
+			}
+
+			client.Namespace = settings.Namespace()
+			valueOpts.ResourceReader = cfg.ResourceValuesReader(client.Namespace)
+			vals, err := valueOpts.MergeValues(getter.All(settings))
+			if err != nil {
+				return err
+			}
+			if err := valueOpts.CoerceSetValues(vals, ch); err != nil {
+				return err
+			}
+
+			report, runErr := client.Run(args[0], ch, vals)
+			if report != nil {
+				if err := outfmt.Write(out, &adoptWriter{report: report, dryRun: client.DryRun, done: runErr == nil}); err != nil {
+					return err
+				}
+			}
+			if errors.Is(runErr, action.ErrAdoptDiffs) {
+				return fmt.Errorf("%w. Use --force-adopt-diffs to apply the chart to them", runErr)
+			}
+			return runErr
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.DryRun, "dry-run", false, "compare the resources with the chart without changing them nor recording the release")
+	f.BoolVar(&client.ForceAdoptDiffs, "force-adopt-diffs", false, "apply the chart to the resources differing from it, and create the missing ones, instead of failing")
+	f.StringArrayVar(&client.IgnoreFields, "ignore-field", nil, "dotted path of a field not compared, such as 'spec.replicas'. Can be specified multiple times")
+	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
+	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
+	f.BoolVar(&client.DisableOpenAPIValidation, "disable-openapi-validation", false, "if set, the rendered templates are not validated against the Kubernetes OpenAPI Schema")
+	f.StringVar(&client.Description, "description", "", "add a custom description")
+	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be divided by comma.")
+	addChartPathOptionsFlags(f, &client.ChartPathOptions)
+	addValueOptionsFlags(f, valueOpts)
+	addSchemaCoercionFlag(f, valueOpts)
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+// adoptWriter prints the resources compared by an adoption.
+type adoptWriter struct {
+	report *action.AdoptReport
+	dryRun bool
+	// done is set when the resources were, or can be with dryRun, adopted.
+	done bool
+}
+
+func (w *adoptWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("RESOURCE", "STATUS", "DIFFERENCES")
+	for _, r := range w.report.Resources {
+		resource := r.Kind + "/" + r.Name
+		if r.Namespace != "" {
+			resource = r.Namespace + "/" + resource
+		}
+		table.AddRow(resource, r.Status, strings.Join(r.Differences, "; "))
+	}
+	if err := output.EncodeTable(out, table); err != nil {
+		return err
+	}
+
+	switch {
+	case !w.done:
+	case w.dryRun:
+		_, _ = fmt.Fprintf(out, "Release %q can adopt the resources.\n", w.report.Release.Name)
+	default:
+		_, _ = fmt.Fprintf(out, "Release %q has adopted the resources. Happy Helming!\n", w.report.Release.Name)
+	}
+	return nil
+}
+
+func (w *adoptWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.report)
+}
+
+func (w *adoptWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.report)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestAdoptCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "adopt resources",
+		cmd:    "adopt aeneas testdata/testcharts/empty",
+		golden: "output/adopt.txt",
+	}, {
+		name:   "preview an adoption",
+		cmd:    "adopt aeneas testdata/testcharts/empty --dry-run",
+		golden: "output/adopt-dry-run.txt",
+	}, {
+		name:   "adopt resources to json",
+		cmd:    "adopt aeneas testdata/testcharts/empty --dry-run -o json",
+		golden: "output/adopt.json",
+	}, {
+		name:      "adopt resources into an existing release",
+		cmd:       "adopt aeneas testdata/testcharts/empty",
+		golden:    "output/adopt-existing-release.txt",
+		rels:      []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas"})},
+		wantError: true,
+	}, {
+		name:      "adopt requires a chart",
+		cmd:       "adopt aeneas",
+		golden:    "output/adopt-no-args.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestAdoptCompletion(t *testing.T) {
+	checkFileCompletion(t, "adopt", false)
+	checkFileCompletion(t, "adopt myrelease", true)
+	checkFileCompletion(t, "adopt myrelease mychart", false)
+}
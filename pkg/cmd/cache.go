@@ -0,0 +1,98 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/repo"
+)
+
+var cacheHelp = `
+This command consists of multiple subcommands to inspect and clean the cache
+of chart repository indexes and chart archives.
+
+The cache is split in categories: "indexes" are the indexes of chart
+repositories, "charts" are the chart archives kept when charts are installed
+or upgraded from a repository, and "oci" are the indexes built from the tags
+of OCI registries added with 'helm repo add'.
+`
+
+func newCacheCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache info|clean [ARGS]",
+		Short: "inspect and clean the repository cache",
+		Long:  cacheHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newCacheInfoCmd(out))
+	cmd.AddCommand(newCacheCleanCmd(out))
+
+	return cmd
+}
+
+// parseCacheSize parses a cache size such as "1Gi" or "500M".
+func parseCacheSize(s string) (int64, error) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache size %q: %w", s, err)
+	}
+	if q.Sign() < 0 {
+		return 0, fmt.Errorf("invalid cache size %q: must not be negative", s)
+	}
+	return q.Value(), nil
+}
+
+// formatCacheSize formats a cache size the way parseCacheSize accepts it.
+func formatCacheSize(size int64) string {
+	return resource.NewQuantity(size, resource.BinarySI).String()
+}
+
+// loadCacheRepositories loads the repositories file, which tells the cached
+// indexes of OCI registries apart. A missing file has no repositories.
+func loadCacheRepositories() *repo.File {
+	f, _ := repo.LoadFile(settings.RepositoryConfig)
+	return f
+}
+
+// pruneCacheBySettings brings the repository cache back under
+// $HELM_CACHE_MAX_SIZE, if set. It is run after commands which download to
+// the cache, and never fails them.
+func pruneCacheBySettings() {
+	if settings.CacheMaxSize == "" {
+		return
+	}
+	maxSize, err := parseCacheSize(settings.CacheMaxSize)
+	if err != nil {
+		slog.Warn("ignoring $HELM_CACHE_MAX_SIZE", slog.Any("error", err))
+		return
+	}
+	evicted, err := repo.PruneCache(settings.RepositoryCache, loadCacheRepositories(), repo.CachePruneOptions{MaxSize: maxSize})
+	if err != nil {
+		slog.Warn("unable to prune the repository cache", slog.Any("error", err))
+	}
+	if len(evicted) > 0 {
+		slog.Debug("pruned the repository cache", "entries", len(evicted), "maxSize", settings.CacheMaxSize)
+	}
+}
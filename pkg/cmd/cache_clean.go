@@ -0,0 +1,124 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/repo"
+)
+
+const cacheCleanDesc = `
+Evict entries of the repository cache, least recently used first.
+
+With --max-size, entries are evicted until the cache fits in the given size,
+e.g. 1Gi or 500M. With --older-than, entries not used for the given duration,
+e.g. 720h, are evicted. Without either flag, the whole cache is emptied.
+
+With --category, only the entries of the given categories are evicted:
+indexes, charts or oci. The size of the other entries still counts towards
+--max-size.
+
+Files locked by another Helm process are never removed. Evicted repository
+indexes are downloaded again by 'helm repo update'.
+`
+
+type cacheCleanOptions struct {
+	maxSize    string
+	olderThan  time.Duration
+	dryRun     bool
+	categories []string
+}
+
+func newCacheCleanCmd(out io.Writer) *cobra.Command {
+	o := &cacheCleanOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "clean",
+		Short:             "evict entries of the repository cache",
+		Long:              cacheCleanDesc,
+		Args:              require.NoArgs,
+		ValidArgsFunction: noMoreArgsCompFunc,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return o.run(out, settings.RepositoryCache)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.maxSize, "max-size", "", "evict the least recently used entries until the cache fits in this size, e.g. 1Gi")
+	f.DurationVar(&o.olderThan, "older-than", 0, "evict the entries not used for this long, e.g. 720h")
+	f.BoolVar(&o.dryRun, "dry-run", false, "list the entries which would be evicted without removing them")
+	f.StringSliceVar(&o.categories, "category", nil, "only evict the entries of these categories: indexes, charts or oci")
+
+	err := cmd.RegisterFlagCompletionFunc("category", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		var categories []string
+		for _, c := range repo.CacheCategories {
+			categories = append(categories, string(c))
+		}
+		return categories, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cmd
+}
+
+func (o *cacheCleanOptions) run(out io.Writer, dir string) error {
+	if o.olderThan < 0 {
+		return errors.New("--older-than must not be negative")
+	}
+	opts := repo.CachePruneOptions{OlderThan: o.olderThan, DryRun: o.dryRun}
+	if o.maxSize != "" {
+		maxSize, err := parseCacheSize(o.maxSize)
+		if err != nil {
+			return err
+		}
+		opts.MaxSize = maxSize
+	}
+	opts.All = opts.MaxSize == 0 && opts.OlderThan == 0
+	for _, name := range o.categories {
+		category := repo.CacheCategory(name)
+		if !slices.Contains(repo.CacheCategories, category) {
+			return fmt.Errorf("invalid cache category %q: must be one of indexes, charts or oci", name)
+		}
+		opts.Categories = append(opts.Categories, category)
+	}
+
+	evicted, err := repo.PruneCache(dir, loadCacheRepositories(), opts)
+	var size int64
+	for _, e := range evicted {
+		if o.dryRun {
+			fmt.Fprintf(out, "would evict %s\n", e.Path)
+		}
+		size += e.Size
+	}
+	verb := "Evicted"
+	if o.dryRun {
+		verb = "Would evict"
+	}
+	fmt.Fprintf(out, "%s %d entries (%s) from the repository cache\n", verb, len(evicted), formatCacheSize(size))
+	return err
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/repo"
+)
+
+const cacheInfoDesc = `
+Show the number of entries and the size of each category of the repository
+cache: repository indexes, chart archives along with their provenance files,
+and the indexes of OCI registries.
+`
+
+func newCacheInfoCmd(out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	cmd := &cobra.Command{
+		Use:               "info",
+		Short:             "show the size of the repository cache",
+		Long:              cacheInfoDesc,
+		Args:              require.NoArgs,
+		ValidArgsFunction: noMoreArgsCompFunc,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			entries, err := repo.ScanCache(settings.RepositoryCache, loadCacheRepositories())
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &cacheInfoWriter{
+				Path:  settings.RepositoryCache,
+				Usage: repo.SummarizeCache(entries),
+			})
+		},
+	}
+
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type cacheInfoWriter struct {
+	Path  string            `json:"path"`
+	Usage []repo.CacheUsage `json:"usage"`
+}
+
+func (w *cacheInfoWriter) WriteTable(out io.Writer) error {
+	fmt.Fprintf(out, "PATH: %s\n", w.Path)
+	table := uitable.New()
+	table.AddRow("CATEGORY", "ENTRIES", "SIZE")
+	var entries int
+	var size int64
+	for _, u := range w.Usage {
+		table.AddRow(u.Category, u.Entries, formatCacheSize(u.Size))
+		entries += u.Entries
+		size += u.Size
+	}
+	table.AddRow("total", entries, formatCacheSize(size))
+	return output.EncodeTable(out, table)
+}
+
+func (w *cacheInfoWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w)
+}
+
+func (w *cacheInfoWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/repo"
+	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// newTestRepoCache returns a repository cache holding an index, last used an
+// hour ago, a 2KiB chart archive, last used 10 days ago, and the 512B index of
+// the OCI registry of testdata/cache-repositories.yaml, last used 2 days ago.
+func newTestRepoCache(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	for name, file := range map[string]struct {
+		size int
+		age  time.Duration
+	}{
+		"stable-index.yaml": {1024, time.Hour},
+		"nginx-1.0.0.tgz":   {2048, 240 * time.Hour},
+		"oci-index.yaml":    {512, 48 * time.Hour},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, make([]byte, file.size), 0644))
+		used := now.Add(-file.age)
+		require.NoError(t, os.Chtimes(path, used, used))
+	}
+	return dir
+}
+
+func TestCacheInfo(t *testing.T) {
+	dir := newTestRepoCache(t)
+	store := storage.Init(driver.NewMemory())
+
+	_, out, err := executeActionCommandC(store, "cache info --repository-config testdata/cache-repositories.yaml --repository-cache "+dir)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("PATH: %s\n", dir)+
+		"CATEGORY\tENTRIES\tSIZE\n"+
+		"indexes \t1      \t1Ki \n"+
+		"charts  \t1      \t2Ki \n"+
+		"oci     \t1      \t512 \n"+
+		"total   \t3      \t3584\n", out)
+
+	_, out, err = executeActionCommandC(store, "cache info -o json --repository-config testdata/cache-repositories.yaml --repository-cache "+dir)
+	require.NoError(t, err)
+	var info cacheInfoWriter
+	require.NoError(t, json.Unmarshal([]byte(out), &info))
+	assert.Equal(t, dir, info.Path)
+	assert.Equal(t, []repo.CacheUsage{
+		{Category: repo.CacheCategoryIndexes, Entries: 1, Size: 1024},
+		{Category: repo.CacheCategoryCharts, Entries: 1, Size: 2048},
+		{Category: repo.CacheCategoryOCI, Entries: 1, Size: 512},
+	}, info.Usage)
+}
+
+func TestCacheClean(t *testing.T) {
+	tests := []struct {
+		name      string
+		flags     string
+		wantError string
+		output    string
+		remaining []string
+	}{
+		{
+			name:      "max size",
+			flags:     "--max-size 1Ki",
+			output:    "Evicted 2 entries (2560) from the repository cache\n",
+			remaining: []string{"stable-index.yaml"},
+		},
+		{
+			name:      "older than",
+			flags:     "--older-than 720h",
+			output:    "Evicted 0 entries (0) from the repository cache\n",
+			remaining: []string{"nginx-1.0.0.tgz", "oci-index.yaml", "stable-index.yaml"},
+		},
+		{
+			name:   "everything",
+			output: "Evicted 3 entries (3584) from the repository cache\n",
+		},
+		{
+			name:      "category",
+			flags:     "--category charts,oci",
+			output:    "Evicted 2 entries (2560) from the repository cache\n",
+			remaining: []string{"stable-index.yaml"},
+		},
+		{
+			name:      "max size of a category",
+			flags:     "--max-size 2Ki --category indexes",
+			output:    "Evicted 1 entries (1Ki) from the repository cache\n",
+			remaining: []string{"nginx-1.0.0.tgz", "oci-index.yaml"},
+		},
+		{
+			name:      "invalid max size",
+			flags:     "--max-size lots",
+			wantError: `invalid cache size "lots"`,
+			remaining: []string{"nginx-1.0.0.tgz", "oci-index.yaml", "stable-index.yaml"},
+		},
+		{
+			name:      "invalid category",
+			flags:     "--category layers",
+			wantError: `invalid cache category "layers"`,
+			remaining: []string{"nginx-1.0.0.tgz", "oci-index.yaml", "stable-index.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepoCache(t)
+			store := storage.Init(driver.NewMemory())
+
+			_, out, err := executeActionCommandC(store, "cache clean "+tt.flags+" --repository-config testdata/cache-repositories.yaml --repository-cache "+dir)
+			if tt.wantError != "" {
+				require.ErrorContains(t, err, tt.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.output, out)
+			}
+
+			var remaining []string
+			entries, err := repo.ScanCache(dir, nil)
+			require.NoError(t, err)
+			for _, e := range entries {
+				remaining = append(remaining, filepath.Base(e.Path))
+			}
+			assert.ElementsMatch(t, tt.remaining, remaining)
+		})
+	}
+}
+
+func TestCacheCleanDryRun(t *testing.T) {
+	dir := newTestRepoCache(t)
+	store := storage.Init(driver.NewMemory())
+
+	_, out, err := executeActionCommandC(store, "cache clean --dry-run --older-than 72h --repository-config testdata/cache-repositories.yaml --repository-cache "+dir)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("would evict %s\n", filepath.Join(dir, "nginx-1.0.0.tgz"))+
+		"Would evict 1 entries (2Ki) from the repository cache\n", out)
+	assert.FileExists(t, filepath.Join(dir, "nginx-1.0.0.tgz"))
+}
+
+func TestPruneCacheBySettings(t *testing.T) {
+	dir := newTestRepoCache(t)
+	oldCache, oldMaxSize := settings.RepositoryCache, settings.CacheMaxSize
+	defer func() { settings.RepositoryCache, settings.CacheMaxSize = oldCache, oldMaxSize }()
+	settings.RepositoryCache = dir
+
+	settings.CacheMaxSize = ""
+	pruneCacheBySettings()
+	assert.FileExists(t, filepath.Join(dir, "nginx-1.0.0.tgz"))
+
+	settings.CacheMaxSize = "not-a-size"
+	pruneCacheBySettings()
+	assert.FileExists(t, filepath.Join(dir, "nginx-1.0.0.tgz"))
+
+	settings.CacheMaxSize = "2Ki"
+	pruneCacheBySettings()
+	assert.NoFileExists(t, filepath.Join(dir, "nginx-1.0.0.tgz"))
+	assert.FileExists(t, filepath.Join(dir, "stable-index.yaml"))
+}
@@ -0,0 +1,193 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const changelogHelp = `
+Changelog summarizes the changes of a release between two revisions, as
+markdown or JSON: the chart and app versions, the container images, the
+user-supplied values, the notes, and the description of each revision in
+between.
+
+By default, the latest revision is compared with the last revision deployed
+before it. The values which may be sensitive, such as passwords and tokens,
+are masked. Only the stored releases are read.
+
+    $ helm changelog angry-bird --from 4 --to 7 --file CHANGELOG.md
+`
+
+// changelogFormats are the formats of 'helm changelog'.
+var changelogFormats = []string{"md", "json"}
+
+func newChangelogCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewChangelog(cfg)
+	format := "md"
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "changelog RELEASE_NAME",
+		Short: "summarize the changes of a release between two revisions",
+		Long:  changelogHelp,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			var write func(io.Writer, *action.ReleaseChangelog) error
+			switch format {
+			case "md":
+				write = writeChangelogMarkdown
+			case "json":
+				write = func(w io.Writer, log *action.ReleaseChangelog) error {
+					return output.EncodeJSON(w, log)
+				}
+			default:
+				return fmt.Errorf("invalid format %q, must be one of: %s", format, strings.Join(changelogFormats, ", "))
+			}
+
+			log, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				return write(out, log)
+			}
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			if err := write(f, log); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&client.From, "from", 0, "revision to compare from. Defaults to the last revision deployed before --to")
+	f.IntVar(&client.To, "to", 0, "revision to compare to. Defaults to the latest revision")
+	f.StringVarP(&format, "output", "o", format, fmt.Sprintf("prints the output in the specified format. Allowed values: %s", strings.Join(changelogFormats, ", ")))
+	f.StringVar(&file, "file", "", "write the changelog to this file instead of the standard output")
+	if err := cmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return changelogFormats, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// writeChangelogMarkdown writes a changelog as a markdown document.
+func writeChangelogMarkdown(out io.Writer, log *action.ReleaseChangelog) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s: revision %d to %d\n\n", log.Release, log.From, log.To)
+
+	sb.WriteString("## Chart\n\n")
+	fmt.Fprintf(&sb, "- Chart: %s\n", formatVersionChange(log.Chart))
+	fmt.Fprintf(&sb, "- App version: %s\n\n", formatVersionChange(log.AppVersion))
+
+	sb.WriteString("## Images\n\n")
+	if len(log.Images) == 0 {
+		sb.WriteString("No changes.\n")
+	}
+	for _, img := range log.Images {
+		switch {
+		case img.From == "":
+			fmt.Fprintf(&sb, "- `%s`: added `%s`\n", img.Container, img.To)
+		case img.To == "":
+			fmt.Fprintf(&sb, "- `%s`: removed `%s`\n", img.Container, img.From)
+		default:
+			fmt.Fprintf(&sb, "- `%s`: `%s` → `%s`\n", img.Container, img.From, img.To)
+		}
+	}
+
+	sb.WriteString("\n## Values\n\n")
+	if len(log.Values) == 0 {
+		sb.WriteString("No changes.\n")
+	}
+	for _, v := range log.Values {
+		switch {
+		case v.Masked:
+			fmt.Fprintf(&sb, "- `%s`: %s (masked)\n", v.Path, v.Change)
+		case v.Change == "added":
+			fmt.Fprintf(&sb, "- `%s`: added `%s`\n", v.Path, formatChangelogValue(v.To))
+		case v.Change == "removed":
+			fmt.Fprintf(&sb, "- `%s`: removed `%s`\n", v.Path, formatChangelogValue(v.From))
+		default:
+			fmt.Fprintf(&sb, "- `%s`: `%s` → `%s`\n", v.Path, formatChangelogValue(v.From), formatChangelogValue(v.To))
+		}
+	}
+
+	sb.WriteString("\n## Notes\n\n")
+	if log.NotesDiff == "" {
+		sb.WriteString("No changes.\n")
+	} else {
+		fmt.Fprintf(&sb, "```diff\n%s```\n", log.NotesDiff)
+	}
+
+	sb.WriteString("\n## Revisions\n\n")
+	sb.WriteString("| Revision | Updated | Status | Operator | Description |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, r := range log.Revisions {
+		var updated string
+		if !r.Updated.IsZero() {
+			updated = r.Updated.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s |\n", r.Revision, updated, r.Status, r.Operator, strings.ReplaceAll(r.Description, "|", "\\|"))
+	}
+
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+func formatVersionChange(v action.VersionChange) string {
+	if !v.Changed() {
+		return fmt.Sprintf("`%s` (unchanged)", v.To)
+	}
+	return fmt.Sprintf("`%s` → `%s`", v.From, v.To)
+}
+
+// formatChangelogValue formats a value on a single line, as JSON.
+func formatChangelogValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
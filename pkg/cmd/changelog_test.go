@@ -0,0 +1,126 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	helmtime "helm.sh/helm/v4/pkg/time"
+)
+
+func changelogReleases() []*release.Release {
+	mk := func(version int, status release.Status, chartVersion, image string, config map[string]interface{}) *release.Release {
+		return &release.Release{
+			Name:      "angry-bird",
+			Namespace: "default",
+			Version:   version,
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "foo", Version: chartVersion, AppVersion: "1." + chartVersion}},
+			Config:    config,
+			Manifest: fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: angry-bird
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: %s
+`, image),
+			Info: &release.Info{
+				LastDeployed: helmtime.Unix(242085845, 0).Add(time.Duration(version) * time.Hour),
+				Status:       status,
+				Description:  fmt.Sprintf("Upgrade %d complete", version),
+				Notes:        "Visit http://angry-bird\nRunning chart " + chartVersion + "\n",
+				FieldManager: "helm",
+			},
+		}
+	}
+	return []*release.Release{
+		mk(1, release.StatusSuperseded, "0.1.0", "bird:1.0", map[string]interface{}{"replicas": 1, "apiToken": "s3cr3t"}),
+		mk(2, release.StatusSuperseded, "0.1.0", "bird:1.0", map[string]interface{}{"replicas": 2, "apiToken": "s3cr3t"}),
+		mk(3, release.StatusFailed, "0.2.0", "bird:2.0", map[string]interface{}{"replicas": 2, "apiToken": "n3w"}),
+		mk(4, release.StatusDeployed, "0.2.0", "bird:2.0", map[string]interface{}{"replicas": 3, "apiToken": "n3w", "image": map[string]interface{}{"pullPolicy": "Always"}}),
+	}
+}
+
+func TestChangelogCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "changelog from the last deployed revision",
+		cmd:    "changelog angry-bird",
+		rels:   changelogReleases(),
+		golden: "output/changelog.md",
+	}, {
+		name:   "changelog between two revisions",
+		cmd:    "changelog angry-bird --from 1 --to 3",
+		rels:   changelogReleases(),
+		golden: "output/changelog-range.md",
+	}, {
+		name:   "changelog in json",
+		cmd:    "changelog angry-bird --from 1 -o json",
+		rels:   changelogReleases(),
+		golden: "output/changelog.json",
+	}, {
+		name:      "changelog with an invalid format",
+		cmd:       "changelog angry-bird -o table",
+		rels:      changelogReleases(),
+		golden:    "output/changelog-invalid-format.txt",
+		wantError: true,
+	}, {
+		name:      "changelog of a revision not stored",
+		cmd:       "changelog angry-bird --from 1 --to 7",
+		rels:      changelogReleases(),
+		golden:    "output/changelog-missing-revision.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestChangelogCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "changelog", false)
+}
+
+func TestChangelogCmdFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	store := storageFixture()
+	for _, rel := range changelogReleases() {
+		if err := store.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, out, err := executeActionCommandC(store, "changelog angry-bird --file "+file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("expected no output, got %q", out)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "# angry-bird: revision 2 to 4\n") {
+		t.Errorf("unexpected changelog:\n%s", b)
+	}
+}
@@ -39,6 +39,17 @@ does.
 
 If no lock file is found, 'helm dependency build' will mirror the behavior
 of 'helm dependency update'.
+
+With '--verify-only', nothing is downloaded. Instead, the lock file is checked
+to be in sync with Chart.yaml, and every locked dependency is checked to be
+present in the charts/ directory at its locked version. All discrepancies are
+reported, and the command fails if there are any. This makes no network
+requests, and is suited to verify a vendored charts/ directory in CI.
+
+With '--prefer-cache', the locked dependencies are copied from the local
+repository cache when it holds them at their locked version and digest. The
+repositories are only refreshed, and the charts only downloaded, for the
+dependencies missing from the cache, so a warm cache needs no network.
 `
 
 func newDependencyBuildCmd(out io.Writer) *cobra.Command {
@@ -50,10 +61,19 @@ func newDependencyBuildCmd(out io.Writer) *cobra.Command {
 		Long:  dependencyBuildDesc,
 		Args:  require.MaximumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
+			defer pruneCacheBySettings()
+
 			chartpath := "."
 			if len(args) > 0 {
 				chartpath = filepath.Clean(args[0])
 			}
+			if client.VerifyOnly {
+				if err := downloader.VerifyLock(chartpath); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "The dependencies of %s match its lock file\n", chartpath)
+				return nil
+			}
 			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
 				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
 			if err != nil {
@@ -65,11 +85,13 @@ func newDependencyBuildCmd(out io.Writer) *cobra.Command {
 				ChartPath:        chartpath,
 				Keyring:          client.Keyring,
 				SkipUpdate:       client.SkipRefresh,
+				PreferCache:      client.PreferCache,
 				Getters:          getter.All(settings),
 				RegistryClient:   registryClient,
 				RepositoryConfig: settings.RepositoryConfig,
 				RepositoryCache:  settings.RepositoryCache,
 				Debug:            settings.Debug,
+				DownloadRetries:  settings.DownloadRetries,
 			}
 			if client.Verify {
 				man.Verify = downloader.VerifyIfPossible
@@ -84,6 +106,7 @@ func newDependencyBuildCmd(out io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	addDependencySubcommandFlags(f, client)
+	f.BoolVar(&client.VerifyOnly, "verify-only", false, "check that charts/ matches the lock file without downloading anything")
 
 	return cmd
 }
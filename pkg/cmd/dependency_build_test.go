@@ -83,9 +83,21 @@ func TestDependencyBuildCmd(t *testing.T) {
 	if _, err := os.Stat(lockfile); err != nil {
 		t.Fatal(err)
 	}
+	verifyCmd := fmt.Sprintf("dependency build '%s' --verify-only", filepath.Join(rootDir, chartname))
+	if _, out, err := executeActionCommand(verifyCmd); err != nil {
+		t.Logf("Output: %s", out)
+		t.Fatal(err)
+	}
 	if err := os.RemoveAll(expect); err != nil {
 		t.Fatal(err)
 	}
+	_, _, err = executeActionCommand(verifyCmd)
+	if err == nil || !strings.Contains(err.Error(), `dependency "reqtest" is locked at version 0.1.0 but is missing from charts/`) {
+		t.Fatalf("expected --verify-only to report the missing dependency, got %v", err)
+	}
+	if _, err := os.Stat(expect); err == nil {
+		t.Fatal("--verify-only downloaded the missing dependency")
+	}
 
 	_, out, err = executeActionCommand(cmd)
 	if err != nil {
@@ -150,8 +162,34 @@ func TestDependencyBuildCmd(t *testing.T) {
 	}
 }
 
+// copyIssue7233Chart copies the issue-7233 chart, together with the alpine
+// chart it depends on through a file:// repository, into a temporary
+// directory so dependency builds never write into testdata. When build is
+// set the dependencies are built as well. It returns the chart path.
+func copyIssue7233Chart(t *testing.T, build bool) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range []string{"issue-7233", "alpine"} {
+		src := os.DirFS(filepath.Join("testdata/testcharts", name))
+		if err := os.CopyFS(filepath.Join(dir, name), src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chartName := filepath.Join(dir, "issue-7233")
+
+	if build {
+		cmd := fmt.Sprintf("dependency build '%s'", chartName)
+		if _, out, err := executeActionCommand(cmd); err != nil {
+			t.Logf("Output: %s", out)
+			t.Fatal(err)
+		}
+	}
+	return chartName
+}
+
 func TestDependencyBuildCmdWithHelmV2Hash(t *testing.T) {
-	chartName := "testdata/testcharts/issue-7233"
+	chartName := copyIssue7233Chart(t, false)
 
 	cmd := fmt.Sprintf("dependency build '%s'", chartName)
 	_, out, err := executeActionCommand(cmd)
@@ -162,3 +200,17 @@ func TestDependencyBuildCmdWithHelmV2Hash(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDependencyBuildCmdVerifyOnlyWithHelmV2Hash(t *testing.T) {
+	chartName := copyIssue7233Chart(t, true)
+
+	cmd := fmt.Sprintf("dependency build '%s' --verify-only", chartName)
+	_, out, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Logf("Output: %s", out)
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "match its lock file") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
@@ -54,6 +54,8 @@ func newDependencyUpdateCmd(_ *action.Configuration, out io.Writer) *cobra.Comma
 		Long:    dependencyUpDesc,
 		Args:    require.MaximumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
+			defer pruneCacheBySettings()
+
 			chartpath := "."
 			if len(args) > 0 {
 				chartpath = filepath.Clean(args[0])
@@ -69,11 +71,13 @@ func newDependencyUpdateCmd(_ *action.Configuration, out io.Writer) *cobra.Comma
 				ChartPath:        chartpath,
 				Keyring:          client.Keyring,
 				SkipUpdate:       client.SkipRefresh,
+				PreferCache:      client.PreferCache,
 				Getters:          getter.All(settings),
 				RegistryClient:   registryClient,
 				RepositoryConfig: settings.RepositoryConfig,
 				RepositoryCache:  settings.RepositoryCache,
 				Debug:            settings.Debug,
+				DownloadRetries:  settings.DownloadRetries,
 			}
 			if client.Verify {
 				man.Verify = downloader.VerifyAlways
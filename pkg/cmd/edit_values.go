@@ -0,0 +1,250 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/moby/term"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v4/pkg/action"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// The modes of --edit-values.
+const (
+	editValuesMasked = "masked"
+	editValuesFull   = "full"
+)
+
+// maskedValue replaces the values which may be sensitive in the values file
+// opened by --edit-values. A value left masked keeps its original content.
+const maskedValue = "<masked>"
+
+const editValuesHeader = `# Please edit the values below, which will be used for the release. Lines
+# beginning with a '#' are ignored, and an empty file aborts the operation. If
+# the values cannot be used, this file is reopened with the errors.
+`
+
+var (
+	// stdinIsTerminal reports whether the values can be edited interactively.
+	stdinIsTerminal = func() bool {
+		return term.IsTerminal(os.Stdin.Fd())
+	}
+
+	// runEditor opens the file at path with the editor command and waits for
+	// the editor to exit.
+	runEditor = func(editor []string, path string) error {
+		cmd := exec.Command(editor[0], append(editor[1:], path)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+)
+
+// editorCommand returns the editor set by $HELM_EDITOR, $KUBE_EDITOR or
+// $EDITOR, in this order, along with its arguments.
+func editorCommand() []string {
+	for _, env := range []string{"HELM_EDITOR", "KUBE_EDITOR", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(env)); len(editor) > 0 {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editValues opens vals in an editor and returns the values it was saved
+// with, and whether they changed. The file is reopened, with the errors, for
+// as long as the edited values cannot be parsed or, when validate is set, do
+// not meet the values schema of chrt.
+//
+// In the editValuesMasked mode, the values which may be sensitive are shown
+// masked, and keep their original content unless they are changed.
+func editValues(mode string, vals map[string]interface{}, chrt *chart.Chart, validate bool) (map[string]interface{}, bool, error) {
+	if mode != editValuesMasked && mode != editValuesFull {
+		return nil, false, fmt.Errorf("invalid --edit-values %q: must be %q or %q", mode, editValuesMasked, editValuesFull)
+	}
+	if !stdinIsTerminal() {
+		return nil, false, errors.New("--edit-values requires an interactive terminal")
+	}
+
+	shown := vals
+	if mode == editValuesMasked {
+		shown = maskSensitiveValues(vals)
+	}
+	original, err := yaml.Marshal(shown)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// CreateTemp creates the file readable by its owner only.
+	f, err := os.CreateTemp("", "helm-values-*.yaml")
+	if err != nil {
+		return nil, false, err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if err := f.Close(); err != nil {
+		return nil, false, err
+	}
+
+	content := append([]byte(editValuesHeader), original...)
+	for retry := false; ; retry = true {
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return nil, false, err
+		}
+		if err := runEditor(editorCommand(), path); err != nil {
+			return nil, false, fmt.Errorf("failed to edit the values: %w", err)
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+
+		body := stripLeadingComments(edited)
+		switch {
+		case len(bytes.TrimSpace(body)) == 0:
+			return nil, false, errors.New("edit cancelled: the values file is empty")
+		case retry && bytes.Equal(edited, content):
+			return nil, false, errors.New("edit cancelled: the values were not fixed")
+		case bytes.Equal(body, original):
+			return vals, false, nil
+		}
+
+		result, err := parseEditedValues(body, vals, chrt, validate)
+		if err == nil {
+			return result, true, nil
+		}
+		content = append(annotateValuesError(err), body...)
+	}
+}
+
+// parseEditedValues parses the edited values, restoring the masked ones from
+// vals, and validates them against the values schema of chrt.
+func parseEditedValues(data []byte, vals map[string]interface{}, chrt *chart.Chart, validate bool) (map[string]interface{}, error) {
+	edited, err := loader.LoadValues(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	unmaskValues(edited, vals)
+
+	if validate {
+		coalesced, err := chartutil.CoalesceValues(chrt, edited)
+		if err != nil {
+			return nil, err
+		}
+		if err := chartutil.ValidateAgainstSchema(chrt, coalesced); err != nil {
+			return nil, fmt.Errorf("values don't meet the specifications of the schema(s) in the following chart(s):\n%w", err)
+		}
+	}
+	return edited, nil
+}
+
+// annotateValuesError returns the comment heading the values file reopened
+// after err.
+func annotateValuesError(err error) []byte {
+	var sb strings.Builder
+	sb.WriteString(editValuesHeader)
+	sb.WriteString("#\n# The values could not be used:\n")
+	for _, line := range strings.Split(strings.TrimSpace(err.Error()), "\n") {
+		fmt.Fprintf(&sb, "#   %s\n", line)
+	}
+	sb.WriteString("#\n")
+	return []byte(sb.String())
+}
+
+// stripLeadingComments removes the comment lines heading data.
+func stripLeadingComments(data []byte) []byte {
+	for len(data) > 0 && data[0] == '#' {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil
+		}
+		data = data[i+1:]
+	}
+	return data
+}
+
+// maskSensitiveValues returns a copy of vals in which the values of the keys
+// which may hold sensitive data are replaced with maskedValue.
+func maskSensitiveValues(vals map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		if action.IsSensitiveValueKey(k) {
+			masked[k] = maskedValue
+			continue
+		}
+		masked[k] = maskValue(v)
+	}
+	return masked
+}
+
+func maskValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return maskSensitiveValues(v)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskValue(item)
+		}
+		return masked
+	}
+	return v
+}
+
+// unmaskValues puts back in edited the values of vals which were left masked.
+func unmaskValues(edited, vals map[string]interface{}) {
+	for k, v := range edited {
+		edited[k] = unmaskValue(v, vals[k])
+	}
+}
+
+func unmaskValue(edited, original interface{}) interface{} {
+	switch v := edited.(type) {
+	case string:
+		if v == maskedValue && original != nil {
+			return original
+		}
+	case map[string]interface{}:
+		if m, ok := original.(map[string]interface{}); ok {
+			unmaskValues(v, m)
+		}
+	case []interface{}:
+		if l, ok := original.([]interface{}); ok {
+			for i := range v {
+				if i < len(l) {
+					v[i] = unmaskValue(v[i], l[i])
+				}
+			}
+		}
+	}
+	return edited
+}
@@ -0,0 +1,192 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+)
+
+// fakeEditor replaces the editor with edits, applied in turn to the content
+// of the file each time it is opened. It returns the contents the file was
+// opened with.
+func fakeEditor(t *testing.T, edits ...func(content string) string) *[]string {
+	t.Helper()
+	var opened []string
+	origEditor, origTerminal := runEditor, stdinIsTerminal
+	t.Cleanup(func() { runEditor, stdinIsTerminal = origEditor, origTerminal })
+	stdinIsTerminal = func() bool { return true }
+	runEditor = func(_ []string, path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if len(opened) >= len(edits) {
+			t.Fatalf("editor opened %d times, expected %d", len(opened)+1, len(edits))
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			t.Errorf("expected the values file to be private, got %v", perm)
+		}
+		edit := edits[len(opened)]
+		opened = append(opened, string(data))
+		return os.WriteFile(path, []byte(edit(string(data))), 0600)
+	}
+	return &opened
+}
+
+func replace(from, to string) func(string) string {
+	return func(content string) string {
+		return strings.Replace(content, from, to, 1)
+	}
+}
+
+func TestEditValuesMasked(t *testing.T) {
+	opened := fakeEditor(t, replace("replicas: 1", "replicas: 3"))
+	vals := map[string]interface{}{
+		"replicas": 1,
+		"db": map[string]interface{}{
+			"host":     "db.local",
+			"password": "hunter2",
+		},
+	}
+
+	edited, changed, err := editValues(editValuesMasked, vals, nil, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, (*opened)[0], "hunter2")
+	assert.Contains(t, (*opened)[0], "password: <masked>")
+	assert.Equal(t, float64(3), edited["replicas"])
+	assert.Equal(t, "hunter2", edited["db"].(map[string]interface{})["password"])
+}
+
+func TestEditValuesFull(t *testing.T) {
+	opened := fakeEditor(t, replace("hunter2", "correct-horse"))
+	vals := map[string]interface{}{"password": "hunter2"}
+
+	edited, changed, err := editValues(editValuesFull, vals, nil, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, (*opened)[0], "password: hunter2")
+	assert.Equal(t, "correct-horse", edited["password"])
+}
+
+func TestEditValuesUnchanged(t *testing.T) {
+	fakeEditor(t, func(content string) string { return content })
+	vals := map[string]interface{}{"replicas": 1}
+
+	edited, changed, err := editValues(editValuesMasked, vals, nil, false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, vals, edited)
+}
+
+func TestEditValuesReopensOnErrors(t *testing.T) {
+	chrt, err := loader.Load("testdata/testcharts/chart-with-schema")
+	require.NoError(t, err)
+
+	opened := fakeEditor(t,
+		replace("age: 25", "age: [25"),
+		replace("age: [25", "age: -1"),
+		replace("age: -1", "age: 30"),
+	)
+	vals := map[string]interface{}{"age": 25}
+
+	edited, changed, err := editValues(editValuesMasked, vals, chrt, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, float64(30), edited["age"])
+	require.Len(t, *opened, 3)
+	assert.Contains(t, (*opened)[1], "# The values could not be used:")
+	assert.Contains(t, (*opened)[1], "age: [25")
+	assert.Contains(t, (*opened)[2], "minimum")
+}
+
+func TestEditValuesCancelled(t *testing.T) {
+	tests := []struct {
+		name  string
+		edits []func(string) string
+		err   string
+	}{{
+		name:  "empty file",
+		edits: []func(string) string{func(string) string { return "# nothing\n" }},
+		err:   "the values file is empty",
+	}, {
+		name: "error not fixed",
+		edits: []func(string) string{
+			replace("replicas: 1", "replicas: [1"),
+			func(content string) string { return content },
+		},
+		err: "the values were not fixed",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeEditor(t, tt.edits...)
+			_, _, err := editValues(editValuesMasked, map[string]interface{}{"replicas": 1}, nil, false)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestEditValuesRequiresTerminal(t *testing.T) {
+	fakeEditor(t)
+	stdinIsTerminal = func() bool { return false }
+
+	_, _, err := editValues(editValuesMasked, map[string]interface{}{}, nil, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "interactive terminal")
+}
+
+func TestEditValuesInvalidMode(t *testing.T) {
+	_, _, err := editValues("partial", map[string]interface{}{}, nil, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --edit-values")
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("HELM_EDITOR", "")
+	t.Setenv("KUBE_EDITOR", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, []string{"code", "--wait"}, editorCommand())
+
+	t.Setenv("HELM_EDITOR", "emacs")
+	assert.Equal(t, []string{"emacs"}, editorCommand())
+}
+
+func TestInstallEditValues(t *testing.T) {
+	fakeEditor(t, replace("age: 25", "age: 30"))
+	store := storageFixture()
+
+	_, _, err := executeActionCommandC(store, "install edited testdata/testcharts/chart-with-schema --set age=25 --edit-values")
+	require.NoError(t, err)
+
+	rel, err := store.Get("edited", 1)
+	require.NoError(t, err)
+	assert.True(t, rel.Info.ValuesEdited)
+	assert.Equal(t, float64(30), rel.Config["age"])
+}
@@ -19,10 +19,13 @@ package cmd
 import (
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -35,6 +38,8 @@ import (
 	"helm.sh/helm/v4/pkg/helmpath"
 	"helm.sh/helm/v4/pkg/kube"
 	"helm.sh/helm/v4/pkg/postrender"
+	"helm.sh/helm/v4/pkg/registry"
+	release "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/repo"
 )
 
@@ -50,14 +55,51 @@ func addValueOptionsFlags(f *pflag.FlagSet, v *values.Options) {
 	f.StringArrayVar(&v.StringValues, "set-string", []string{}, "set STRING values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
 	f.StringArrayVar(&v.FileValues, "set-file", []string{}, "set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
 	f.StringArrayVar(&v.JSONValues, "set-json", []string{}, "set JSON values on the command line (can specify multiple or separate values with commas: key1=jsonval1,key2=jsonval2 or using json format: {\"key1\": jsonval1, \"key2\": \"jsonval2\"})")
-	f.StringArrayVar(&v.LiteralValues, "set-literal", []string{}, "set a literal STRING value on the command line")
+	f.StringArrayVar(&v.LiteralValues, "set-literal", []string{}, "set a literal STRING value on the command line. Everything after the first '=' is used verbatim: no comma splitting and no type conversion (key1.key2[0]=value)")
+	f.Var((*valuesFromValue)(v), "values-from", "specify values in the YAML held by a key of a ConfigMap or a Secret of the cluster, as configmap/NAME#KEY or secret/NAME#KEY, optionally prefixing NAME with NAMESPACE/ (can specify multiple, merged in order with the --values files)")
+}
+
+// addSchemaCoercionFlag adds the flag disabling the conversion of the values
+// set on the command line to the types of the values schema of the chart.
+func addSchemaCoercionFlag(f *pflag.FlagSet, v *values.Options) {
+	f.BoolVar(&v.NoSchemaCoercion, "no-schema-coercion", false, "do not convert the values given with --set and --set-string to the types declared by the values schema of the chart")
+}
+
+// addEditValuesFlag adds the flag opening the merged values in an editor
+// before they are used.
+func addEditValuesFlag(f *pflag.FlagSet, v *values.Options) {
+	f.StringVar(&v.EditValues, "edit-values", "", "open the merged values in $HELM_EDITOR, $KUBE_EDITOR or $EDITOR and use the edited values. Values which may be sensitive are masked unless set to 'full'")
+	f.Lookup("edit-values").NoOptDefVal = editValuesMasked
+}
+
+// valuesFromValue is a flag adding references to the values held by
+// ConfigMaps and Secrets, in the order they are given along the values files.
+type valuesFromValue values.Options
+
+func (v *valuesFromValue) String() string {
+	if v == nil {
+		return "[]"
+	}
+	return "[" + strings.Join(v.ValuesFromResources, ",") + "]"
+}
+
+func (v *valuesFromValue) Set(s string) error {
+	if _, err := values.ParseResourceRef(s); err != nil {
+		return err
+	}
+	(*values.Options)(v).AddValuesFromResource(s)
+	return nil
+}
+
+func (v *valuesFromValue) Type() string {
+	return "stringArray"
 }
 
 func AddWaitFlag(cmd *cobra.Command, wait *kube.WaitStrategy) {
 	cmd.Flags().Var(
 		newWaitValue(kube.HookOnlyStrategy, wait),
 		"wait",
-		"if specified, will wait until all resources are in the expected state before marking the operation as successful. It will wait for as long as --timeout. Valid inputs are 'watcher' and 'legacy'",
+		"if specified, will wait until all resources are in the expected state before marking the operation as successful. It will wait for as long as --timeout. Valid inputs are 'watcher', 'legacy' and 'hookOnly'. 'hookOnly', the default, only waits for the hooks, and is replaced by 'watcher' with --atomic",
 	)
 	// Sets the strategy to use the watcher strategy if `--wait` is used without an argument
 	cmd.Flags().Lookup("wait").NoOptDefVal = string(kube.StatusWatcherStrategy)
@@ -79,7 +121,7 @@ func (ws *waitValue) String() string {
 
 func (ws *waitValue) Set(s string) error {
 	switch s {
-	case string(kube.StatusWatcherStrategy), string(kube.LegacyStrategy):
+	case string(kube.StatusWatcherStrategy), string(kube.LegacyStrategy), string(kube.HookOnlyStrategy):
 		*ws = waitValue(s)
 		return nil
 	case "true":
@@ -91,7 +133,7 @@ func (ws *waitValue) Set(s string) error {
 		*ws = waitValue(kube.HookOnlyStrategy)
 		return nil
 	default:
-		return fmt.Errorf("invalid wait input %q. Valid inputs are %s, and %s", s, kube.StatusWatcherStrategy, kube.LegacyStrategy)
+		return fmt.Errorf("invalid wait input %q. Valid inputs are %s, %s, and %s", s, kube.StatusWatcherStrategy, kube.LegacyStrategy, kube.HookOnlyStrategy)
 	}
 }
 
@@ -99,6 +141,208 @@ func (ws *waitValue) Type() string {
 	return "WaitStrategy"
 }
 
+// fileModeValue is a file mode given in octal, such as 0644. Only the
+// permission bits may be set.
+type fileModeValue fs.FileMode
+
+func newFileModeValue(defaultValue fs.FileMode, mode *fs.FileMode) *fileModeValue {
+	*mode = defaultValue
+	return (*fileModeValue)(mode)
+}
+
+func (m *fileModeValue) String() string {
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%#o", uint32(*m))
+}
+
+func (m *fileModeValue) Set(s string) error {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || fs.FileMode(mode)&^fs.ModePerm != 0 {
+		return fmt.Errorf("invalid file mode %q: it must be an octal mode with only permission bits, such as 0644", s)
+	}
+	*m = fileModeValue(mode)
+	return nil
+}
+
+func (m *fileModeValue) Type() string {
+	return "mode"
+}
+
+// addServerSideApplyFlags adds the flags selecting whether the resources of a
+// release are applied server-side.
+func addServerSideApplyFlags(f *pflag.FlagSet, mode *action.ServerSideApplyMode, forceConflicts *bool) {
+	f.Var(newServerSideApplyValue(action.ServerSideApplyFalse, mode), "server-side", "apply the resources server-side with the field manager of --field-manager, instead of creating and patching them client-side. Valid inputs are 'true', 'false' and 'auto', which applies them server-side if the cluster supports it")
+	f.Lookup("server-side").NoOptDefVal = string(action.ServerSideApplyTrue)
+	f.BoolVar(forceConflicts, "force-conflicts", false, "if set, take over the fields of the resources managed by other field managers when applying them server-side, instead of failing on conflicts")
+}
+
+type serverSideApplyValue action.ServerSideApplyMode
+
+func newServerSideApplyValue(defaultValue action.ServerSideApplyMode, mode *action.ServerSideApplyMode) *serverSideApplyValue {
+	*mode = defaultValue
+	return (*serverSideApplyValue)(mode)
+}
+
+func (v *serverSideApplyValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return string(*v)
+}
+
+func (v *serverSideApplyValue) Set(s string) error {
+	switch action.ServerSideApplyMode(s) {
+	case action.ServerSideApplyAuto, action.ServerSideApplyTrue, action.ServerSideApplyFalse:
+		*v = serverSideApplyValue(s)
+		return nil
+	default:
+		return fmt.Errorf("invalid server-side input %q. Valid inputs are %s, %s and %s", s, action.ServerSideApplyAuto, action.ServerSideApplyTrue, action.ServerSideApplyFalse)
+	}
+}
+
+func (v *serverSideApplyValue) Type() string {
+	return "string"
+}
+
+// namespacedOnlyValue is a boolean flag which limits taking ownership of
+// existing resources to namespaced ones.
+type namespacedOnlyValue action.TakeOwnershipScope
+
+func (v *namespacedOnlyValue) String() string {
+	if v == nil {
+		return "false"
+	}
+	return strconv.FormatBool(action.TakeOwnershipScope(*v) == action.TakeOwnershipScopeNamespacedOnly)
+}
+
+func (v *namespacedOnlyValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if b {
+		*v = namespacedOnlyValue(action.TakeOwnershipScopeNamespacedOnly)
+	} else {
+		*v = ""
+	}
+	return nil
+}
+
+func (v *namespacedOnlyValue) Type() string {
+	return "bool"
+}
+
+func (v *namespacedOnlyValue) IsBoolFlag() bool {
+	return true
+}
+
+// addSkipHooksFlag adds the flag skipping the hooks of some events.
+func addSkipHooksFlag(f *pflag.FlagSet, events *[]release.HookEvent) {
+	f.Var((*hookEventsValue)(events), "skip-hooks", "skip the hooks of these events, separated by commas, e.g. pre-install,post-delete. The skipped hooks are still recorded on the release")
+}
+
+// hookEventsValue is a comma-separated list of hook events.
+type hookEventsValue []release.HookEvent
+
+func (v *hookEventsValue) String() string {
+	events := make([]string, 0, len(*v))
+	for _, e := range *v {
+		events = append(events, e.String())
+	}
+	return "[" + strings.Join(events, ",") + "]"
+}
+
+func (v *hookEventsValue) Set(s string) error {
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			*v = append(*v, release.HookEvent(e))
+		}
+	}
+	return nil
+}
+
+func (v *hookEventsValue) Type() string {
+	return "strings"
+}
+
+// addAnnotationFlag adds the flag setting the annotations of a release.
+func addAnnotationFlag(f *pflag.FlagSet, annotations *map[string]string, usage string) {
+	f.Var((*annotationValue)(annotations), "annotation", usage)
+}
+
+// annotationValue is a key=value annotation, given once per flag. Unlike the
+// map flags of pflag, the values are not split at commas.
+type annotationValue map[string]string
+
+func (v *annotationValue) String() string {
+	annotations := make([]string, 0, len(*v))
+	for k, val := range *v {
+		annotations = append(annotations, k+"="+val)
+	}
+	sort.Strings(annotations)
+	return "[" + strings.Join(annotations, ",") + "]"
+}
+
+func (v *annotationValue) Set(s string) error {
+	key, val, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("%q is not a key=value annotation", s)
+	}
+	if key == "" {
+		return fmt.Errorf("%q has an empty annotation key", s)
+	}
+	if *v == nil {
+		*v = make(map[string]string)
+	}
+	(*v)[key] = val
+	return nil
+}
+
+func (v *annotationValue) Type() string {
+	return "key=value"
+}
+
+// addRequireRecordFlag adds the flag making the failure to record a release
+// operation with $HELM_RELEASE_WEBHOOK_URL fatal.
+func addRequireRecordFlag(f *pflag.FlagSet, cfg *action.Configuration) {
+	f.BoolVar(&cfg.RequireRecord, "require-record", false, "fail if the operation cannot be recorded to $HELM_RELEASE_WEBHOOK_URL. By default, the failure is only logged")
+}
+
+// addNamespaceDefaultsFlags adds the flags layering the default values of the
+// namespace of the release beneath the values of the user.
+func addNamespaceDefaultsFlags(f *pflag.FlagSet, cfg *action.Configuration) {
+	f.StringVar(&cfg.NamespaceDefaultsConfigMap, "namespace-defaults", os.Getenv("HELM_NAMESPACE_DEFAULTS"), "name of the ConfigMap of the release namespace whose \"values.yaml\" key holds default values, which override the values of the chart and are overridden by the values given. Defaults to $HELM_NAMESPACE_DEFAULTS")
+	f.BoolVar(&cfg.NamespaceDefaultsRequired, "namespace-defaults-required", false, "fail if the ConfigMap of --namespace-defaults does not exist. By default, the release goes on without namespace defaults")
+}
+
+// addPolicyOverrideFlag adds the flag giving the tokens which approve an
+// operation, or override the policy of the release.
+func addPolicyOverrideFlag(f *pflag.FlagSet, tokens *[]string) {
+	f.StringArrayVar(tokens, "override-policy", nil, "token approving the operation under the policy of the release, or overriding its rules when it is one of its override tokens. Can be specified multiple times")
+}
+
+// addOverridePinFlag adds the flag allowing an operation on a pinned release.
+func addOverridePinFlag(f *pflag.FlagSet, override *bool) {
+	f.BoolVar(override, "override-pin", false, "allow the operation even if the release is pinned. The pin is kept")
+}
+
+// addCredHelperFlag adds the flag forcing the docker credential helper giving
+// the registry credentials.
+func addCredHelperFlag(f *pflag.FlagSet, name *string) {
+	f.StringVar(name, "cred-helper", "", "get the registry credentials from the docker credential helper docker-credential-NAME, rather than the ones configured in the docker config")
+}
+
+// credentialHelperOpts returns the registry client options forcing the docker
+// credential helper name, if set.
+func credentialHelperOpts(name string) []registry.ClientOption {
+	if name == "" {
+		return nil
+	}
+	return []registry.ClientOption{registry.ClientOptCredentialHelper(name)}
+}
+
 func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.StringVar(&c.Version, "version", "", "specify a version constraint for the chart version to use. This constraint can be a specific tag (e.g. 1.1.1) or it may reference a valid range (e.g. ^2.0.0). If this is not specified, the latest version is used")
 	f.BoolVar(&c.Verify, "verify", false, "verify the package before using it")
@@ -106,12 +350,14 @@ func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.StringVar(&c.RepoURL, "repo", "", "chart repository url where to locate the requested chart")
 	f.StringVar(&c.Username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&c.Password, "password", "", "chart repository password where to locate the requested chart")
+	f.StringVar(&c.BearerToken, "bearer-token", "", "bearer token sent to the host of the chart, or of the chart repository given with --repo, unless credentials are configured for its repository. Defaults to $HELM_REPOSITORY_BEARER_TOKEN")
 	f.StringVar(&c.CertFile, "cert-file", "", "identify HTTPS client using this SSL certificate file")
 	f.StringVar(&c.KeyFile, "key-file", "", "identify HTTPS client using this SSL key file")
 	f.BoolVar(&c.InsecureSkipTLSverify, "insecure-skip-tls-verify", false, "skip tls certificate checks for the chart download")
 	f.BoolVar(&c.PlainHTTP, "plain-http", false, "use insecure HTTP connections for the chart download")
 	f.StringVar(&c.CaFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
 	f.BoolVar(&c.PassCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
+	f.IntVar(&c.DownloadRetries, "download-retries", settings.DownloadRetries, "number of times a failed chart download is retried")
 }
 
 // bindOutputFlag will add the output flag to the given command and bind the
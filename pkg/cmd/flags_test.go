@@ -18,12 +18,19 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
 
 	"helm.sh/helm/v4/pkg/action"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/cli/values"
+	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/kube"
 	release "helm.sh/helm/v4/pkg/release/v1"
 	helmtime "helm.sh/helm/v4/pkg/time"
 )
@@ -118,3 +125,76 @@ func TestPostRendererFlagSetOnce(t *testing.T) {
 	err = str.Set("cat")
 	require.Error(t, err)
 }
+
+func TestWaitFlag(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want kube.WaitStrategy
+	}{
+		{args: nil, want: kube.HookOnlyStrategy},
+		{args: []string{"--wait"}, want: kube.StatusWatcherStrategy},
+		{args: []string{"--wait=legacy"}, want: kube.LegacyStrategy},
+		{args: []string{"--wait=hookOnly"}, want: kube.HookOnlyStrategy},
+		{args: []string{"--wait=false"}, want: kube.HookOnlyStrategy},
+	} {
+		var ws kube.WaitStrategy
+		cmd := &cobra.Command{}
+		AddWaitFlag(cmd, &ws)
+		require.NoError(t, cmd.ParseFlags(tt.args))
+		require.Equal(t, tt.want, ws, "%v", tt.args)
+	}
+
+	var ws kube.WaitStrategy
+	cmd := &cobra.Command{}
+	AddWaitFlag(cmd, &ws)
+	require.ErrorContains(t, cmd.ParseFlags([]string{"--wait=forever"}), `invalid wait input "forever". Valid inputs are watcher, legacy, and hookOnly`)
+}
+
+func TestValuesFromFlagOrder(t *testing.T) {
+	valueOpts := &values.Options{}
+	f := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addValueOptionsFlags(f, valueOpts)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "values.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("a: file\nb: file\n"), 0o644))
+
+	require.NoError(t, f.Parse([]string{
+		"--values-from", "configmap/before#values.yaml",
+		"-f", file,
+		"--values-from", "secret/ns/after#values.yaml",
+	}))
+	require.Equal(t, []string{"configmap/before#values.yaml", "secret/ns/after#values.yaml"}, valueOpts.ValuesFromResources)
+
+	valueOpts.ResourceReader = func(ref values.ResourceRef) ([]byte, error) {
+		if ref.Kind == values.ResourceKindConfigMap {
+			return []byte("a: before\n"), nil
+		}
+		return []byte("b: after\n"), nil
+	}
+	vals, err := valueOpts.MergeValues(getter.Providers{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": "file", "b": "after"}, vals)
+
+	require.ErrorContains(t, f.Parse([]string{"--values-from", "deployment/app#values.yaml"}), "values can only be read from a configmap or a secret")
+}
+
+func TestAnnotationFlag(t *testing.T) {
+	var annotations map[string]string
+	f := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addAnnotationFlag(f, &annotations, "")
+
+	require.NoError(t, f.Parse([]string{
+		"--annotation", "example.com/commit=3b383ba",
+		"--annotation", `example.com/build={"tags": ["a", "b"], "url": "https://ci.example.com/?a=b"}`,
+		"--annotation", "example.com/empty=",
+	}))
+	require.Equal(t, map[string]string{
+		"example.com/commit": "3b383ba",
+		"example.com/build":  `{"tags": ["a", "b"], "url": "https://ci.example.com/?a=b"}`,
+		"example.com/empty":  "",
+	}, annotations)
+
+	require.ErrorContains(t, f.Parse([]string{"--annotation", "commit"}), `"commit" is not a key=value annotation`)
+	require.ErrorContains(t, f.Parse([]string{"--annotation", "=3b383ba"}), `"=3b383ba" has an empty annotation key`)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -33,9 +34,17 @@ This command fetches the generated manifest for a given release.
 A manifest is a YAML-encoded representation of the Kubernetes resources that
 were generated from this release's chart(s). If a chart is dependent on other
 charts, those resources will also be included in the manifest.
+
+Use '--filter KEY=VALUE' to only show the resources whose kind, name or
+apiVersion match, ignoring case. Filters on the same key are alternatives,
+filters on different keys must all match, e.g.
+
+    $ helm get manifest web --filter kind=Deployment,name=web
+    $ helm get manifest web --filter kind=Service --filter kind=Ingress
 `
 
 func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var filters []string
 	client := action.NewGet(cfg)
 
 	cmd := &cobra.Command{
@@ -50,16 +59,24 @@ func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 			return compListReleases(toComplete, args, cfg)
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			res, err := client.Run(args[0])
+			if err := parseManifestFilters(client, filters); err != nil {
+				return err
+			}
+			manifest, err := client.RunManifest(args[0])
 			if err != nil {
 				return err
 			}
-			fmt.Fprintln(out, res.Manifest)
+			if len(filters) > 0 {
+				fmt.Fprint(out, manifest)
+				return nil
+			}
+			fmt.Fprintln(out, manifest)
 			return nil
 		},
 	}
 
 	cmd.Flags().IntVar(&client.Version, "revision", 0, "get the named release with revision")
+	cmd.Flags().StringSliceVar(&filters, "filter", nil, "only show the resources matching KEY=VALUE, where KEY is kind, name or apiVersion (can specify multiple or separate values with commas: kind=Deployment,name=web)")
 	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
 			return compListRevisions(toComplete, cfg, args[0])
@@ -73,3 +90,25 @@ func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 
 	return cmd
 }
+
+// parseManifestFilters sets the manifest filters of client from KEY=VALUE
+// filters.
+func parseManifestFilters(client *action.Get, filters []string) error {
+	for _, f := range filters {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || value == "" {
+			return fmt.Errorf("invalid filter %q: expected KEY=VALUE", f)
+		}
+		switch strings.ToLower(key) {
+		case "kind":
+			client.Kinds = append(client.Kinds, value)
+		case "name":
+			client.Names = append(client.Names, value)
+		case "apiversion":
+			client.APIVersions = append(client.APIVersions, value)
+		default:
+			return fmt.Errorf("invalid filter %q: KEY must be kind, name or apiVersion", f)
+		}
+	}
+	return nil
+}
@@ -23,6 +23,27 @@ import (
 )
 
 func TestGetManifest(t *testing.T) {
+	multiKind := release.Mock(&release.MockReleaseOptions{Name: "multi"})
+	multiKind.Manifest = `---
+# Source: multi/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: multi/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+# Source: multi/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+`
+
 	tests := []cmdTestCase{{
 		name:   "get manifest with release",
 		cmd:    "get manifest juno",
@@ -33,6 +54,28 @@ func TestGetManifest(t *testing.T) {
 		cmd:       "get manifest",
 		golden:    "output/get-manifest-no-args.txt",
 		wantError: true,
+	}, {
+		name:   "get manifest filtered by kind and name",
+		cmd:    "get manifest multi --filter kind=deployment,name=web",
+		golden: "output/get-manifest-filter.txt",
+		rels:   []*release.Release{multiKind},
+	}, {
+		name:   "get manifest filtered by repeated kinds",
+		cmd:    "get manifest multi --filter kind=Service --filter kind=Deployment",
+		golden: "output/get-manifest-filter-kinds.txt",
+		rels:   []*release.Release{multiKind},
+	}, {
+		name:      "get manifest filter without match",
+		cmd:       "get manifest multi --filter kind=Ingress",
+		golden:    "output/get-manifest-filter-no-match.txt",
+		rels:      []*release.Release{multiKind},
+		wantError: true,
+	}, {
+		name:      "get manifest with invalid filter",
+		cmd:       "get manifest multi --filter namespace=default",
+		golden:    "output/get-manifest-filter-invalid.txt",
+		rels:      []*release.Release{multiKind},
+		wantError: true,
 	}}
 	runTestCmd(t, tests)
 }
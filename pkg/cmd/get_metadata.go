@@ -17,10 +17,14 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 	k8sLabels "k8s.io/apimachinery/pkg/labels"
 
@@ -29,17 +33,31 @@ import (
 	"helm.sh/helm/v4/pkg/cmd/require"
 )
 
+const getMetadataHelp = `
+This command fetches the metadata of a given release.
+
+With '--all-revisions', the metadata of every stored revision of the release is
+shown instead, ordered by revision: its chart and app versions, status, deploy
+time, description, labels, and the user who applied it.
+`
+
 type metadataWriter struct {
 	metadata *action.Metadata
 }
 
+type revisionsMetadataWriter struct {
+	revisions []action.RevisionMetadata
+}
+
 func newGetMetadataCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var outfmt output.Format
+	var allRevisions bool
 	client := action.NewGetMetadata(cfg)
 
 	cmd := &cobra.Command{
 		Use:   "metadata RELEASE_NAME",
 		Short: "This command fetches metadata for a given release",
+		Long:  getMetadataHelp,
 		Args:  require.ExactArgs(1),
 		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) != 0 {
@@ -48,6 +66,16 @@ func newGetMetadataCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 			return compListReleases(toComplete, args, cfg)
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
+			if allRevisions {
+				if client.Version != 0 {
+					return fmt.Errorf("--all-revisions cannot be used with --revision")
+				}
+				revisions, err := client.RunAllRevisions(args[0])
+				if err != nil {
+					return err
+				}
+				return outfmt.Write(out, &revisionsMetadataWriter{revisions})
+			}
 			releaseMetadata, err := client.Run(args[0])
 			if err != nil {
 				return err
@@ -58,6 +86,7 @@ func newGetMetadataCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 
 	f := cmd.Flags()
 	f.IntVar(&client.Version, "revision", 0, "specify release revision")
+	f.BoolVar(&allRevisions, "all-revisions", false, "show the metadata of every stored revision of the release")
 	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
 			return compListRevisions(toComplete, cfg, args[0])
@@ -81,11 +110,40 @@ func (w metadataWriter) WriteTable(out io.Writer) error {
 	_, _ = fmt.Fprintf(out, "APP_VERSION: %v\n", w.metadata.AppVersion)
 	_, _ = fmt.Fprintf(out, "ANNOTATIONS: %v\n", k8sLabels.Set(w.metadata.Annotations).String())
 	_, _ = fmt.Fprintf(out, "LABELS: %v\n", k8sLabels.Set(w.metadata.Labels).String())
+	if len(w.metadata.ReleaseAnnotations) > 0 {
+		_, _ = fmt.Fprintf(out, "RELEASE_ANNOTATIONS: %v\n", k8sLabels.Set(w.metadata.ReleaseAnnotations).String())
+	}
 	_, _ = fmt.Fprintf(out, "DEPENDENCIES: %v\n", w.metadata.FormattedDepNames())
 	_, _ = fmt.Fprintf(out, "NAMESPACE: %v\n", w.metadata.Namespace)
 	_, _ = fmt.Fprintf(out, "REVISION: %v\n", w.metadata.Revision)
 	_, _ = fmt.Fprintf(out, "STATUS: %v\n", w.metadata.Status)
 	_, _ = fmt.Fprintf(out, "DEPLOYED_AT: %v\n", w.metadata.DeployedAt)
+	if w.metadata.AppliedBy != "" {
+		_, _ = fmt.Fprintf(out, "APPLIED_BY: %v\n", w.metadata.AppliedBy)
+	}
+	if w.metadata.Policy != nil {
+		policy, err := json.Marshal(w.metadata.Policy)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "POLICY: %s\n", policy)
+	}
+	if pin := w.metadata.Pin; pin != nil {
+		_, _ = fmt.Fprintf(out, "PINNED_BY: %v\n", pin.PinnedBy)
+		_, _ = fmt.Fprintf(out, "PINNED_SINCE: %v\n", pin.Since.Format(time.RFC3339))
+		if pin.Reason != "" {
+			_, _ = fmt.Fprintf(out, "PIN_REASON: %v\n", pin.Reason)
+		}
+	}
+	if w.metadata.ValuesEdited {
+		_, _ = fmt.Fprintln(out, "VALUES_EDITED: true")
+	}
+	if nd := w.metadata.NamespaceDefaults; nd != nil {
+		_, _ = fmt.Fprintf(out, "NAMESPACE_DEFAULTS: %s (%s)\n", nd.Source, nd.Digest)
+	}
+	if len(w.metadata.ValuesMigrations) > 0 {
+		_, _ = fmt.Fprintf(out, "VALUES_MIGRATIONS: %s\n", strings.Join(w.metadata.ValuesMigrations, "; "))
+	}
 
 	return nil
 }
@@ -97,3 +155,20 @@ func (w metadataWriter) WriteJSON(out io.Writer) error {
 func (w metadataWriter) WriteYAML(out io.Writer) error {
 	return output.EncodeYAML(out, w.metadata)
 }
+
+func (w revisionsMetadataWriter) WriteTable(out io.Writer) error {
+	tbl := uitable.New()
+	tbl.AddRow("REVISION", "DEPLOYED_AT", "STATUS", "CHART", "VERSION", "APP_VERSION", "APPLIED_BY", "LABELS", "DESCRIPTION")
+	for _, rev := range w.revisions {
+		tbl.AddRow(rev.Revision, rev.DeployedAt, rev.Status, rev.Chart, rev.Version, rev.AppVersion, rev.AppliedBy, k8sLabels.Set(rev.Labels).String(), rev.Description)
+	}
+	return output.EncodeTable(out, tbl)
+}
+
+func (w revisionsMetadataWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.revisions)
+}
+
+func (w revisionsMetadataWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.revisions)
+}
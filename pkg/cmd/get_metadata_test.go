@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"testing"
 
 	release "helm.sh/helm/v4/pkg/release/v1"
@@ -39,6 +40,57 @@ func TestGetMetadataCmd(t *testing.T) {
 		cmd:    "get metadata thomas-guide --output json",
 		golden: "output/get-metadata.json",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide", Labels: map[string]string{"key1": "value1"}})},
+	}, {
+		name:   "get metadata with a policy",
+		cmd:    "get metadata thomas-guide",
+		golden: "output/get-metadata-policy.txt",
+		rels:   []*release.Release{policyMock()},
+	}, {
+		name:   "get metadata with a policy to yaml",
+		cmd:    "get metadata thomas-guide --output yaml",
+		golden: "output/get-metadata-policy.yaml",
+		rels:   []*release.Release{policyMock()},
+	}, {
+		name:   "get metadata of a pinned release",
+		cmd:    "get metadata thomas-guide",
+		golden: "output/get-metadata-pin.txt",
+		rels:   []*release.Release{pinMock("thomas-guide")},
+	}, {
+		name:   "get metadata of a pinned release to yaml",
+		cmd:    "get metadata thomas-guide --output yaml",
+		golden: "output/get-metadata-pin.yaml",
+		rels:   []*release.Release{pinMock("thomas-guide")},
+	}, {
+		name:   "get metadata of all the revisions",
+		cmd:    "get metadata thomas-guide --all-revisions",
+		golden: "output/get-metadata-all-revisions.txt",
+		rels:   revisionsMock("thomas-guide"),
+	}, {
+		name:   "get metadata of all the revisions to json",
+		cmd:    "get metadata thomas-guide --all-revisions --output json",
+		golden: "output/get-metadata-all-revisions.json",
+		rels:   revisionsMock("thomas-guide"),
+	}, {
+		name:   "get metadata of all the revisions to yaml",
+		cmd:    "get metadata thomas-guide --all-revisions --output yaml",
+		golden: "output/get-metadata-all-revisions.yaml",
+		rels:   revisionsMock("thomas-guide"),
+	}, {
+		name:      "get metadata of all the revisions with a revision",
+		cmd:       "get metadata thomas-guide --all-revisions --revision 2",
+		golden:    "output/get-metadata-all-revisions-revision.txt",
+		rels:      revisionsMock("thomas-guide"),
+		wantError: true,
+	}, {
+		name:   "get metadata with release annotations",
+		cmd:    "get metadata thomas-guide",
+		golden: "output/get-metadata-release-annotations.txt",
+		rels:   []*release.Release{annotationsMock()},
+	}, {
+		name:   "get metadata with release annotations to json",
+		cmd:    "get metadata thomas-guide --output json",
+		golden: "output/get-metadata-release-annotations.json",
+		rels:   []*release.Release{annotationsMock()},
 	}, {
 		name:   "get metadata to yaml",
 		cmd:    "get metadata thomas-guide --output yaml",
@@ -48,6 +100,39 @@ func TestGetMetadataCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+// revisionsMock returns three revisions of the release name, applied by
+// different users.
+func revisionsMock(name string) []*release.Release {
+	var rels []*release.Release
+	for v, status := range []release.Status{release.StatusSuperseded, release.StatusSuperseded, release.StatusDeployed} {
+		rel := release.Mock(&release.MockReleaseOptions{Name: name, Version: v + 1, Status: status, Labels: map[string]string{"team": "payments"}})
+		rel.Info.Description = fmt.Sprintf("Upgrade %d", v+1)
+		rel.Info.AppliedBy = fmt.Sprintf("operator-%d", v+1)
+		rels = append(rels, rel)
+	}
+	rels[0].Info.Description = "Install complete"
+	return rels
+}
+
+func annotationsMock() *release.Release {
+	rel := release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})
+	rel.Annotations = map[string]string{
+		"example.com/commit": "3b383ba",
+		"example.com/build":  `{"url": "https://ci.example.com/1", "status": "passed"}`,
+	}
+	return rel
+}
+
+func policyMock() *release.Release {
+	rel := release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})
+	rel.Policy = (&release.Policy{
+		FreezeWindows: []release.FreezeWindow{{Cron: "0 18 * * 5", Duration: "62h", Reason: "weekend"}},
+		MinInterval:   "1h",
+		RequireFlag:   []string{"approved"},
+	}).WithHashedTokens()
+	return rel
+}
+
 func TestGetMetadataCompletion(t *testing.T) {
 	checkReleaseCompletion(t, "get metadata", false)
 }
@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cmd/require"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+var getReleaseHelp = `
+This command prints a named release in a stable, versioned export format,
+meant to be consumed by other tools.
+
+The document is wrapped in an envelope whose 'apiVersion' names the format,
+e.g. 'helm.sh/release-export/v1'. The fields of a given format version never
+change; use '--schema-version' to select the version to export.
+`
+
+// exportSchemaVersions maps the values accepted by --schema-version to the
+// export API versions they select.
+var exportSchemaVersions = map[string]string{
+	"v1": release.ExportAPIVersionV1,
+}
+
+type releaseExportWriter struct {
+	export *release.ExportV1
+}
+
+func newGetReleaseCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var schemaVersion string
+	client := action.NewGet(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "release RELEASE_NAME",
+		Short: "download a named release in a versioned export format",
+		Long:  getReleaseHelp,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if _, ok := exportSchemaVersions[schemaVersion]; !ok {
+				return fmt.Errorf("unsupported schema version %q, the supported versions are: v1", schemaVersion)
+			}
+			rel, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &releaseExportWriter{release.NewExportV1(rel)})
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&client.Version, "revision", 0, "get the named release with revision")
+	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return compListRevisions(toComplete, cfg, args[0])
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f.StringVar(&schemaVersion, "schema-version", "v1", "version of the export format")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+// WriteTable prints the export as YAML, which is easier to read than JSON.
+func (w releaseExportWriter) WriteTable(out io.Writer) error {
+	return output.EncodeYAML(out, w.export)
+}
+
+func (w releaseExportWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.export)
+}
+
+func (w releaseExportWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.export)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestGetReleaseCmd(t *testing.T) {
+	rels := []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide", Labels: map[string]string{"key1": "value1"}})}
+	tests := []cmdTestCase{{
+		name:   "get release to json",
+		cmd:    "get release thomas-guide --schema-version v1 --output json",
+		golden: "output/get-release-export.json",
+		rels:   rels,
+	}, {
+		name:   "get release",
+		cmd:    "get release thomas-guide",
+		golden: "output/get-release-export.txt",
+		rels:   rels,
+	}, {
+		name:      "get release with an unsupported schema version",
+		cmd:       "get release thomas-guide --schema-version v2",
+		golden:    "output/get-release-export-schema-version.txt",
+		rels:      rels,
+		wantError: true,
+	}, {
+		name:      "get release requires release name arg",
+		cmd:       "get release",
+		golden:    "output/get-release-export-args.txt",
+		rels:      rels,
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestGetReleaseCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "get release", false)
+}
+
+func TestGetReleaseRevisionCompletion(t *testing.T) {
+	revisionFlagCompletionTest(t, "get release")
+}
+
+func TestGetReleaseOutputCompletion(t *testing.T) {
+	outputFlagCompletionTest(t, "get release")
+}
+
+func TestGetReleaseFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "get release", false)
+	checkFileCompletion(t, "get release myrelease", false)
+}
@@ -30,6 +30,15 @@ import (
 
 var getValuesHelp = `
 This command downloads a values file for a given release.
+
+Use '--resolve-templates' to show the effective value of strings which the
+chart runs through 'tpl' (e.g. "{{ .Chart.AppVersion }}"). Values that fail to
+render are shown unchanged and reported as warnings.
+
+Use '--revision-range FROM:TO' to show the values of every revision in the
+range, e.g. '2:5', '3:' or ':4'. Add '--diff' to show how the values changed
+between consecutive revisions instead. Revisions that are no longer stored,
+for instance because of '--history-max', are skipped with a note.
 `
 
 type valuesWriter struct {
@@ -39,6 +48,8 @@ type valuesWriter struct {
 
 func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var outfmt output.Format
+	var revisionRange string
+	var showDiff bool
 	client := action.NewGetValues(cfg)
 
 	cmd := &cobra.Command{
@@ -52,11 +63,20 @@ func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			return compListReleases(toComplete, args, cfg)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if revisionRange != "" {
+				return runGetValuesHistory(cmd, cfg, args[0], revisionRange, showDiff, client.AllValues, outfmt, out)
+			}
+			if showDiff {
+				return fmt.Errorf("--diff requires --revision-range")
+			}
 			vals, err := client.Run(args[0])
 			if err != nil {
 				return err
 			}
+			for _, w := range client.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: unable to resolve templated value %s\n", w)
+			}
 			return outfmt.Write(out, &valuesWriter{vals, client.AllValues})
 		},
 	}
@@ -75,11 +95,40 @@ func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	}
 
 	f.BoolVarP(&client.AllValues, "all", "a", false, "dump all (computed) values")
+	f.BoolVar(&client.ResolveTemplates, "resolve-templates", false, "render templated string values (as the chart's 'tpl' calls would) and show the effective values")
+	f.StringVar(&revisionRange, "revision-range", "", "get the values of every revision in the range FROM:TO")
+	f.BoolVar(&showDiff, "diff", false, "with --revision-range, show the changes between consecutive revisions")
+	cmd.MarkFlagsMutuallyExclusive("revision", "revision-range")
+	cmd.MarkFlagsMutuallyExclusive("resolve-templates", "revision-range")
 	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
 }
 
+func runGetValuesHistory(cmd *cobra.Command, cfg *action.Configuration, name, revisionRange string, showDiff, allValues bool, outfmt output.Format, out io.Writer) error {
+	if showDiff && outfmt != output.Table {
+		return fmt.Errorf("--diff cannot be used with --output %s", outfmt)
+	}
+	client := action.NewGetValuesHistory(cfg)
+	var err error
+	if client.From, client.To, err = parseRevisionRange(revisionRange); err != nil {
+		return err
+	}
+	client.AllValues = allValues
+
+	history, err := client.Run(name)
+	if err != nil {
+		return err
+	}
+	for _, rev := range client.Skipped {
+		fmt.Fprintf(cmd.ErrOrStderr(), "NOTE: revision %d is no longer stored and was skipped\n", rev)
+	}
+	for _, rev := range client.NotFound {
+		fmt.Fprintf(cmd.ErrOrStderr(), "NOTE: revision %d was not found\n", rev)
+	}
+	return outfmt.Write(out, &valuesHistoryWriter{history: history, diff: showDiff})
+}
+
 func (v valuesWriter) WriteTable(out io.Writer) error {
 	if v.allValues {
 		fmt.Fprintln(out, "COMPUTED VALUES:")
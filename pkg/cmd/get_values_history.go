@@ -0,0 +1,127 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cli/output"
+)
+
+// valuesHistoryWriter prints the values of several revisions of a release,
+// either in full or as diffs between consecutive revisions.
+type valuesHistoryWriter struct {
+	history []action.RevisionValues
+	diff    bool
+}
+
+// parseRevisionRange parses a revision range of the form "FROM:TO", where
+// either bound may be left out, or a single revision.
+func parseRevisionRange(s string) (int, int, error) {
+	fromStr, toStr, isRange := strings.Cut(s, ":")
+	if !isRange {
+		toStr = fromStr
+	}
+	bound := func(v string) (int, error) {
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid revision range %q: revisions must be positive integers", s)
+		}
+		return n, nil
+	}
+	from, err := bound(fromStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := bound(toStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to != 0 && from > to {
+		return 0, 0, fmt.Errorf("invalid revision range %q: %d is after %d", s, from, to)
+	}
+	return from, to, nil
+}
+
+func (w valuesHistoryWriter) WriteTable(out io.Writer) error {
+	if w.diff {
+		return w.writeDiff(out)
+	}
+	for _, rv := range w.history {
+		fmt.Fprintf(out, "---\n# REVISION: %d\n", rv.Revision)
+		if err := output.EncodeYAML(out, rv.Config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w valuesHistoryWriter) writeDiff(out io.Writer) error {
+	for i := 1; i < len(w.history); i++ {
+		prev, cur := w.history[i-1], w.history[i]
+		a, err := valuesYAML(prev.Config)
+		if err != nil {
+			return err
+		}
+		b, err := valuesYAML(cur.Config)
+		if err != nil {
+			return err
+		}
+		if a == b {
+			fmt.Fprintf(out, "REVISION %d: no changes since revision %d\n", cur.Revision, prev.Revision)
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(a),
+			B:        difflib.SplitLines(b),
+			FromFile: fmt.Sprintf("values (revision %d)", prev.Revision),
+			ToFile:   fmt.Sprintf("values (revision %d)", cur.Revision),
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, diff)
+	}
+	return nil
+}
+
+func (w valuesHistoryWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.history)
+}
+
+func (w valuesHistoryWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.history)
+}
+
+func valuesYAML(vals map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := output.EncodeYAML(&buf, vals); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -53,6 +53,66 @@ func TestGetValuesCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestGetValuesResolveTemplatesCmd(t *testing.T) {
+	rel := release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})
+	rel.Config = map[string]interface{}{
+		"fullname": "{{ .Release.Name }}-{{ .Chart.Name }}",
+		"broken":   "{{ .Values.fullname",
+	}
+
+	tests := []cmdTestCase{{
+		name:   "get values with templated values resolved",
+		cmd:    "get values thomas-guide --resolve-templates",
+		golden: "output/get-values-resolve-templates.txt",
+		rels:   []*release.Release{rel},
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestGetValuesRevisionRangeCmd(t *testing.T) {
+	// Revision 1 is missing, as if it had been purged by --history-max.
+	var rels []*release.Release
+	for i, config := range []map[string]interface{}{
+		{"name": "value", "replicas": 1},
+		{"name": "value", "replicas": 1},
+		{"name": "other", "replicas": 3},
+	} {
+		rel := release.Mock(&release.MockReleaseOptions{Name: "thomas-guide", Version: i + 2})
+		rel.Config = config
+		rels = append(rels, rel)
+	}
+
+	tests := []cmdTestCase{{
+		name:   "get values of a revision range",
+		cmd:    "get values thomas-guide --revision-range 1:4",
+		golden: "output/get-values-revision-range.txt",
+		rels:   rels,
+	}, {
+		name:   "get values diff across a revision range",
+		cmd:    "get values thomas-guide --revision-range 2: --diff",
+		golden: "output/get-values-revision-range-diff.txt",
+		rels:   rels,
+	}, {
+		name:   "get values of a revision range to json",
+		cmd:    "get values thomas-guide --revision-range 3:4 --output json",
+		golden: "output/get-values-revision-range.json",
+		rels:   rels,
+	}, {
+		name:      "get values diff requires a revision range",
+		cmd:       "get values thomas-guide --diff",
+		golden:    "output/get-values-diff-no-range.txt",
+		rels:      rels,
+		wantError: true,
+	}, {
+		name:      "get values with an invalid revision range",
+		cmd:       "get values thomas-guide --revision-range 4:2",
+		golden:    "output/get-values-revision-range-invalid.txt",
+		rels:      rels,
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestGetValuesCompletion(t *testing.T) {
 	checkReleaseCompletion(t, "get values", false)
 }
@@ -0,0 +1,103 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cli/output"
+)
+
+// valueFieldsWriter prints the values that can be set on a chart.
+type valueFieldsWriter struct {
+	fields []chartutil.ValueField
+}
+
+// runHelpValues prints a reference of the values that can be set on the chart
+// given as the last argument.
+func runHelpValues(args []string, client *action.Install, outfmt output.Format, out io.Writer) error {
+	if client.Version == "" && client.Devel {
+		client.Version = ">0.0.0-0"
+	}
+	cp, err := client.LocateChart(args[len(args)-1], settings)
+	if err != nil {
+		return err
+	}
+	ch, err := loader.Load(cp)
+	if err != nil {
+		return err
+	}
+	fields, err := chartutil.ValueFields(ch)
+	if err != nil {
+		return err
+	}
+	return outfmt.Write(out, &valueFieldsWriter{fields: fields})
+}
+
+func (w valueFieldsWriter) WriteTable(out io.Writer) error {
+	for _, f := range w.fields {
+		var b strings.Builder
+		if f.Summarized {
+			fmt.Fprintf(&b, "--set %s.<key>=<value> (map)", f.Path)
+		} else {
+			fmt.Fprintf(&b, "--set %s=<%s>", f.Path, f.Type)
+			var details []string
+			if f.Default != nil {
+				details = append(details, "default "+formatFieldValue(f.Default))
+			}
+			if len(f.Enum) > 0 {
+				choices := make([]string, len(f.Enum))
+				for i, v := range f.Enum {
+					choices[i] = formatFieldValue(v)
+				}
+				details = append(details, "one of "+strings.Join(choices, ", "))
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(details, "; "))
+			}
+		}
+		if f.Description != "" {
+			fmt.Fprintf(&b, " — %s", f.Description)
+		}
+		_, _ = fmt.Fprintln(out, b.String())
+	}
+	return nil
+}
+
+func (w valueFieldsWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.fields)
+}
+
+func (w valueFieldsWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.fields)
+}
+
+// formatFieldValue formats a default or enum value the way it would be
+// written in JSON, which quotes strings.
+func formatFieldValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
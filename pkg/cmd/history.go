@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -48,6 +49,10 @@ The historical release set is printed as a formatted table, e.g:
     2           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Upgraded successfully
     3           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Rolled back to 2
     4           Mon Oct 3 10:15:13 2016     deployed        alpine-0.1.0      1.0             Upgraded successfully
+
+With '--output json' or '--output yaml', the revisions are printed as a list
+which also includes the labels of each revision, with update times in RFC 3339
+format.
 `
 
 func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -83,13 +88,34 @@ func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	return cmd
 }
 
+// releaseInfo is a revision of a release, as printed by 'helm history'. Its
+// JSON and YAML representation is a stable schema meant to be scraped.
 type releaseInfo struct {
-	Revision    int           `json:"revision"`
-	Updated     helmtime.Time `json:"updated"`
-	Status      string        `json:"status"`
-	Chart       string        `json:"chart"`
-	AppVersion  string        `json:"app_version"`
-	Description string        `json:"description"`
+	Revision    int               `json:"revision"`
+	Updated     helmtime.Time     `json:"-"`
+	Status      string            `json:"status"`
+	Chart       string            `json:"chart"`
+	AppVersion  string            `json:"app_version"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// MarshalJSON encodes the update time in RFC 3339 format, or as an empty
+// string if unknown.
+func (r releaseInfo) MarshalJSON() ([]byte, error) {
+	var updated string
+	if !r.Updated.IsZero() {
+		updated = r.Updated.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(struct {
+		Revision    int               `json:"revision"`
+		Updated     string            `json:"updated"`
+		Status      string            `json:"status"`
+		Chart       string            `json:"chart"`
+		AppVersion  string            `json:"app_version"`
+		Description string            `json:"description"`
+		Labels      map[string]string `json:"labels"`
+	}{r.Revision, updated, r.Status, r.Chart, r.AppVersion, r.Description, r.Labels})
 }
 
 type releaseHistory []releaseInfo
@@ -148,6 +174,10 @@ func getReleaseHistory(rls []*release.Release) (history releaseHistory) {
 			Chart:       c,
 			AppVersion:  a,
 			Description: d,
+			Labels:      r.Labels,
+		}
+		if rInfo.Labels == nil {
+			rInfo.Labels = map[string]string{}
 		}
 		if !r.Info.LastDeployed.IsZero() {
 			rInfo.Updated = r.Info.LastDeployed
@@ -66,6 +66,42 @@ func TestHistoryCmd(t *testing.T) {
 			mk("angry-bird", 3, release.StatusSuperseded),
 		},
 		golden: "output/history.json",
+	}, {
+		name: "get history with labels in json output format",
+		cmd:  "history angry-bird --output json",
+		rels: []*release.Release{
+			release.Mock(&release.MockReleaseOptions{
+				Name:    "angry-bird",
+				Version: 1,
+				Status:  release.StatusDeployed,
+				Labels:  map[string]string{"team": "birds"},
+			}),
+		},
+		golden: "output/history-labels.json",
+	}, {
+		name: "get history with labels in yaml output format",
+		cmd:  "history angry-bird --output yaml",
+		rels: []*release.Release{
+			release.Mock(&release.MockReleaseOptions{
+				Name:    "angry-bird",
+				Version: 1,
+				Status:  release.StatusDeployed,
+				Labels:  map[string]string{"team": "birds"},
+			}),
+		},
+		golden: "output/history-labels.yaml",
+	}, {
+		name: "get history with labels in table output format",
+		cmd:  "history angry-bird",
+		rels: []*release.Release{
+			release.Mock(&release.MockReleaseOptions{
+				Name:    "angry-bird",
+				Version: 1,
+				Status:  release.StatusDeployed,
+				Labels:  map[string]string{"team": "birds"},
+			}),
+		},
+		golden: "output/history-labels.txt",
 	}}
 	runTestCmd(t, tests)
 }
@@ -40,6 +40,7 @@ import (
 	"helm.sh/helm/v4/pkg/cmd/require"
 	"helm.sh/helm/v4/pkg/downloader"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/registry"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -78,6 +79,18 @@ or
 
     $ helm install --set-json '{"master":{"sidecars":[{"name":"sidecar","image":"myImage","imagePullPolicy":"Always","ports":[{"name":"portname","containerPort":1234}]}]}}' myredis ./redis
 
+or use '--set-literal' when the value contains commas, braces or other special
+characters. Everything after the first '=' is taken verbatim as a string:
+
+    $ helm install --set-literal db.dsn='host=db,port=5432,sslmode=require' myredis ./redis
+
+To list the values a chart accepts, with their types, defaults and descriptions,
+use '--help-values'. They are read from the chart's values.schema.json or
+values.schema.yaml, or inferred from its values.yaml when the chart has no
+schema:
+
+    $ helm install --help-values ./redis
+
 You can specify the '--values'/'-f' flag multiple times. The priority will be given to the
 last (right-most) file specified. For example, if both myvalues.yaml and override.yaml
 contained a key called 'Test', the value set in override.yaml would take precedence:
@@ -98,9 +111,21 @@ And in the following example, 'foo' is set to '{"key1":"value1","key2":"bar"}':
 
     $ helm install --set-json='foo={"key1":"value1","key2":"value2"}' --set-json='foo.key2="bar"' myredis ./redis
 
+Setting a value to null with '--set' removes it. In the lists of the values
+files, '--set list[1]=null' removes the second element and shifts the ones
+after it, and '--set list[1].key=null' removes the key from the element:
+
+    $ helm install -f myvalues.yaml --set 'servers[1]=null' myredis ./redis
+
 To check the generated manifests of a release without installing the chart,
 the --debug and --dry-run flags can be combined.
 
+With --output-dir, the rendered chart is also written to files, in the layout
+of the chart: the CRDs of the chart as they are, the templates, and the hooks
+under hooks/<event>/<weight>/. With --output-kustomize, a kustomization.yaml
+lists the files in the order Helm installs them, so that 'kubectl apply -k'
+applies them in that order.
+
 The --dry-run flag will output all generated chart manifests, including Secrets
 which can contain sensitive values. To hide Kubernetes Secrets use the
 --hide-secret flag. Please carefully consider how and when these flags are used.
@@ -135,6 +160,9 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewInstall(cfg)
 	valueOpts := &values.Options{}
 	var outfmt output.Format
+	var helpValues bool
+	var vc valuesCheck
+	var policyFile string
 
 	cmd := &cobra.Command{
 		Use:   "install [NAME] [CHART]",
@@ -152,12 +180,34 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetRegistryClient(registryClient)
 
+			if helpValues {
+				return runHelpValues(args, client, outfmt, out)
+			}
+			if err := validateValuesCheckFlags(vc); err != nil {
+				return err
+			}
+			if vc.only {
+				return runValuesCheck(args[len(args)-1], &client.ChartPathOptions, client.Devel, client.SkipSchemaValidation, vc, valueOpts, outfmt, out)
+			}
+			if policyFile != "" {
+				if client.Policy, err = action.LoadPolicyFile(policyFile); err != nil {
+					return err
+				}
+			}
+
 			// This is for the case where "" is specifically passed in as a
 			// value. When there is no value passed in NoOptDefVal will be used
 			// and it is set to client. See addInstallFlags.
 			if client.DryRunOption == "" {
 				client.DryRunOption = "none"
 			}
+			if client.OutputKustomize && client.OutputDir == "" {
+				return errors.New("--output-kustomize requires --output-dir")
+			}
+			// The flat layout writes the manifests instead of installing
+			// them, which only suits helm template.
+			client.OutputLayout = action.OutputLayoutChart
+			valueOpts.ResourceReader = cfg.ResourceValuesReader(settings.Namespace())
 			rel, err := runInstall(args, client, valueOpts, out)
 			if err != nil {
 				return fmt.Errorf("INSTALLATION FAILED: %w", err)
@@ -178,6 +228,14 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	// it is added separately
 	f := cmd.Flags()
 	f.BoolVar(&client.HideSecret, "hide-secret", false, "hide Kubernetes Secrets when also using the --dry-run flag")
+	f.StringVar(&client.OutputDir, "output-dir", "", "also write the rendered chart to files in output-dir: the CRDs, the templates, and the hooks under hooks/<event>/<weight>/")
+	f.BoolVar(&client.OutputKustomize, "output-kustomize", false, "write a kustomization.yaml listing the files of output-dir in install order, so that \"kubectl apply -k\" applies them in that order")
+	f.BoolVar(&helpValues, "help-values", false, "list the values that can be set on the chart, with their types, defaults and descriptions, instead of installing it")
+	addValuesCheckFlags(f, &vc)
+	addEditValuesFlag(f, valueOpts)
+	f.StringVar(&policyFile, "policy-file", "", "store on the release the policy of this YAML file, which constrains its upgrades, rollbacks and uninstalls with freeze windows, a minimum interval between upgrades and required approval tokens")
+	addRequireRecordFlag(f, cfg)
+	addNamespaceDefaultsFlags(f, cfg)
 	bindOutputFlag(cmd, &outfmt)
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 
@@ -195,24 +253,35 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	f.Lookup("dry-run").NoOptDefVal = "client"
 	f.BoolVar(&client.Force, "force", false, "force resource updates through a replacement strategy")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during install")
+	addSkipHooksFlag(f, &client.SkipHookEvents)
 	f.BoolVar(&client.Replace, "replace", false, "reuse the given name, only if that name is a deleted release which remains in the history. This is unsafe in production")
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVarP(&client.GenerateName, "generate-name", "g", false, "generate the name (and omit the NAME parameter)")
 	f.StringVar(&client.NameTemplate, "name-template", "", "specify template used to name the release")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
+	f.BoolVar(&client.DescriptionIsTemplate, "description-is-template", false, "render the description as a Go template, with .Release.Name, .Release.Namespace, .Chart.Name, .Chart.Version, .Chart.AppVersion and the env function")
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
 	f.BoolVar(&client.DisableOpenAPIValidation, "disable-openapi-validation", false, "if set, the installation process will not validate rendered templates against the Kubernetes OpenAPI Schema")
+	f.BoolVar(&client.PruneUnknownFields, "prune-unknown-fields", false, "if set, remove from the rendered templates the fields the Kubernetes OpenAPI Schema of the cluster does not know, and report them, instead of failing the validation")
 	f.BoolVar(&client.Atomic, "atomic", false, "if set, the installation process deletes the installation on failure. The --wait flag will be set automatically to \"watcher\" if --atomic is used")
+	f.DurationVar(&client.AtomicCleanupTimeout, "atomic-timeout", 0, "time given to the uninstall cleaning up after a failed --atomic installation. Defaults to --timeout")
 	f.BoolVar(&client.SkipCRDs, "skip-crds", false, "if set, no CRDs will be installed. By default, CRDs are installed if not already present")
+	f.BoolVar(&client.ShareCRDs, "share-crds", false, "if set, CRDs installed by other releases are shared with them instead of failing. Shared CRDs belong to none of the releases")
 	f.BoolVar(&client.SubNotes, "render-subchart-notes", false, "if set, render subchart notes along with the parent")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
 	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be divided by comma.")
+	addAnnotationFlag(f, &client.Annotations, "free-form metadata recorded with the release, as key=value. The value may contain any character. Can be given multiple times")
 	f.BoolVar(&client.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
+	f.BoolVar(&client.StrictTemplate, "strict-template", false, "fail rendering when a template uses a value which is not defined, instead of rendering it empty. Values given to default, required, empty, coalesce and hasKey may still be missing")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in install output. Does not affect presence in chart metadata")
 	f.BoolVar(&client.TakeOwnership, "take-ownership", false, "if set, install will ignore the check for helm annotations and take ownership of the existing resources")
+	f.Var((*namespacedOnlyValue)(&client.TakeOwnershipScope), "force-adopt-namespaced-only", "if set, install will take ownership of the existing namespaced resources, but fail on existing cluster-scoped resources not owned by the release")
+	f.Lookup("force-adopt-namespaced-only").NoOptDefVal = "true"
+	addServerSideApplyFlags(f, &client.ServerSideApply, &client.ForceConflicts)
 	addValueOptionsFlags(f, valueOpts)
+	addSchemaCoercionFlag(f, valueOpts)
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 	AddWaitFlag(cmd, &client.WaitStrategy)
 
@@ -287,6 +356,7 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 					RepositoryCache:  settings.RepositoryCache,
 					Debug:            settings.Debug,
 					RegistryClient:   client.GetRegistryClient(),
+					DownloadRetries:  client.DownloadRetries,
 				}
 				if err := man.Update(); err != nil {
 					return nil, err
@@ -301,6 +371,21 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 		}
 	}
 
+	annotateChartSource(chartRequested, &client.ChartPathOptions)
+
+	if err := valueOpts.CoerceSetValues(vals, chartRequested); err != nil {
+		return nil, err
+	}
+
+	if valueOpts.EditValues != "" {
+		var edited bool
+		vals, edited, err = editValues(valueOpts.EditValues, vals, chartRequested, !client.SkipSchemaValidation)
+		if err != nil {
+			return nil, err
+		}
+		client.ValuesEdited = edited
+	}
+
 	client.Namespace = settings.Namespace()
 
 	// Validate DryRunOption member is one of the allowed values
@@ -326,6 +411,25 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	return client.RunWithContext(ctx, chartRequested, vals)
 }
 
+// annotateChartSource records on a chart the manifest digest it was pulled by,
+// if its OCI reference was pinned by digest, and the reference it was requested
+// by, if it was pulled from a mirror, so that the release records them.
+func annotateChartSource(ch *chart.Chart, client *action.ChartPathOptions) {
+	annotations := map[string]string{
+		registry.ChartDigestAnnotation:       client.Digest(),
+		registry.ChartMirroredFromAnnotation: client.MirroredFrom(),
+	}
+	for k, v := range annotations {
+		if v == "" {
+			continue
+		}
+		if ch.Metadata.Annotations == nil {
+			ch.Metadata.Annotations = make(map[string]string)
+		}
+		ch.Metadata.Annotations[k] = v
+	}
+}
+
 // checkIfInstallable validates if a chart can be installed
 //
 // Application chart type is only installable
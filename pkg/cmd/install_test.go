@@ -17,12 +17,20 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"helm.sh/helm/v4/pkg/registry"
 	"helm.sh/helm/v4/pkg/repo/repotest"
 )
 
@@ -71,6 +79,30 @@ func TestInstall(t *testing.T) {
 			cmd:    "install virgil testdata/testcharts/alpine -f testdata/testcharts/alpine/extra_values.yaml",
 			golden: "output/install-with-values-file.txt",
 		},
+		// Install, pruning the fields unknown to the cluster
+		{
+			name:   "install pruning unknown fields",
+			cmd:    "install aeneas testdata/testcharts/alpine --prune-unknown-fields --set test.Name=hello",
+			golden: "output/install-prune-unknown-fields.txt",
+		},
+		{
+			name:      "install pruning unknown fields without validation",
+			cmd:       "install aeneas testdata/testcharts/alpine --prune-unknown-fields --disable-openapi-validation",
+			golden:    "output/install-prune-unknown-fields-without-validation.txt",
+			wantError: true,
+		},
+		{
+			name:      "install with an invalid server-side apply mode",
+			cmd:       "install aeneas testdata/testcharts/alpine --server-side=sometimes",
+			golden:    "output/install-server-side-invalid.txt",
+			wantError: true,
+		},
+		{
+			name:      "install forcing conflicts client-side",
+			cmd:       "install aeneas testdata/testcharts/alpine --server-side=false --force-conflicts",
+			golden:    "output/install-force-conflicts-client-side.txt",
+			wantError: true,
+		},
 		// Install, no hooks
 		{
 			name:   "install without hooks",
@@ -102,6 +134,16 @@ func TestInstall(t *testing.T) {
 			cmd:    "install aeneas-take-ownership testdata/testcharts/empty --take-ownership",
 			golden: "output/install-and-take-ownership.txt",
 		},
+		{
+			name:   "install and take ownership of namespaced resources only",
+			cmd:    "install aeneas-take-ownership testdata/testcharts/empty --force-adopt-namespaced-only",
+			golden: "output/install-and-take-ownership.txt",
+		},
+		{
+			name:      "install with an invalid --force-adopt-namespaced-only value",
+			cmd:       "install aeneas-take-ownership testdata/testcharts/empty --force-adopt-namespaced-only=maybe",
+			wantError: true,
+		},
 		// Install, with timeout
 		{
 			name:   "install with a timeout",
@@ -205,6 +247,24 @@ func TestInstall(t *testing.T) {
 			wantError: true,
 			golden:    "output/schema-negative-cli.txt",
 		},
+		// Install, values from cli converted to the types of the schema
+		{
+			name:   "install with schema file, values from cli coerced",
+			cmd:    "install schema testdata/testcharts/chart-with-schema --set-string age=30 --set firstname=123",
+			golden: "output/schema.txt",
+		},
+		{
+			name:      "install with schema file, values from cli not coerced",
+			cmd:       "install schema testdata/testcharts/chart-with-schema --set-string age=30 --no-schema-coercion",
+			wantError: true,
+			golden:    "output/schema-no-coercion.txt",
+		},
+		{
+			name:      "install with schema file, values from cli which cannot be coerced",
+			cmd:       "install schema testdata/testcharts/chart-with-schema --set age=abc",
+			wantError: true,
+			golden:    "output/schema-coercion-error.txt",
+		},
 		// Install with subchart, values from yaml, schematized with errors
 		{
 			name:      "install with schema file and schematized subchart, with errors",
@@ -323,3 +383,213 @@ func TestInstallFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "install myname", true)
 	checkFileCompletion(t, "install myname mychart", false)
 }
+
+func TestInstallHelpValues(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "help values from schema",
+		cmd:    "install --help-values testdata/testcharts/chart-with-schema",
+		golden: "output/install-help-values-schema.txt",
+	}, {
+		name:   "help values inferred from values.yaml",
+		cmd:    "install --help-values testdata/testcharts/subchart",
+		golden: "output/install-help-values-inferred.txt",
+	}, {
+		name:   "help values as json",
+		cmd:    "install --help-values testdata/testcharts/chart-with-schema -o json",
+		golden: "output/install-help-values-schema.json",
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestInstallValuesCheckOnly(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "check valid values",
+		cmd:    "install schema testdata/testcharts/chart-with-schema --values-check-only",
+		golden: "output/install-values-check.txt",
+	}, {
+		name:      "check values violating the schemas",
+		cmd:       "install schema testdata/testcharts/chart-with-schema-and-subchart --values-check-only --set lastname=doe --set subchart-with-schema.age=-25",
+		golden:    "output/install-values-check-schema.txt",
+		wantError: true,
+	}, {
+		name:      "check unknown values",
+		cmd:       "install schema testdata/testcharts/chart-with-schema --values-check-only --values-check-unknown-keys --set agee=5 --set employmentInfo.title=Developer -o json",
+		golden:    "output/install-values-check-unknown-keys.json",
+		wantError: true,
+	}, {
+		name:      "check values which do not parse",
+		cmd:       "install schema testdata/testcharts/chart-with-schema --values-check-only -f testdata/testcharts/chart-with-schema/missing-values.yaml",
+		golden:    "output/install-values-check-missing-file.txt",
+		wantError: true,
+	}, {
+		name:      "check unknown values without checking values",
+		cmd:       "install schema testdata/testcharts/chart-with-schema --values-check-unknown-keys",
+		golden:    "output/install-values-check-unknown-keys-only.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestInstallByDigest(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testcharts/*.tgz*"),
+	)
+	defer srv.Stop()
+
+	ociSrv, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSrv.Run(t)
+
+	store := storageFixture()
+	ref := fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart@%s", ociSrv.RegistryURL, ociSrv.ManifestDigest)
+	cmd := fmt.Sprintf("install digest %s --repository-cache %s --registry-config %s --plain-http",
+		ref, srv.Root(), filepath.Join(srv.Root(), "config.json"))
+	if _, _, err := executeActionCommandC(store, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	rel, err := store.Last("digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := rel.Chart.Metadata.Annotations[registry.ChartDigestAnnotation]; d != ociSrv.ManifestDigest {
+		t.Errorf("expected the release chart to record the digest %s, got %q", ociSrv.ManifestDigest, d)
+	}
+
+	if _, _, err := executeActionCommandC(store, cmd+" --version 0.1.0"); err == nil {
+		t.Error("expected an error installing a chart pinned by digest with a version")
+	}
+}
+
+func TestInstallFromMirror(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testcharts/*.tgz*"),
+	)
+	defer srv.Stop()
+
+	origin, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin.Run(t)
+	mirror, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror.Run(t)
+
+	// Copy the chart of the origin to the mirror, and push a tampered one with
+	// another creation time next to it.
+	originRef := fmt.Sprintf("%s/u/ocitestuser/oci-dependent-chart:0.1.0", origin.RegistryURL)
+	pulled, err := origin.Client.Pull(originRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(pulled.Manifest.Data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	created := manifest.Annotations[ocispec.AnnotationCreated]
+	copied, err := mirror.Client.Push(pulled.Chart.Data,
+		fmt.Sprintf("%s/copy/oci-dependent-chart:0.1.0", mirror.RegistryURL), registry.PushOptCreationTime(created))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Manifest.Digest != origin.ManifestDigest {
+		t.Fatalf("expected the copy to have the digest %s, got %s", origin.ManifestDigest, copied.Manifest.Digest)
+	}
+	if _, err := mirror.Client.Push(pulled.Chart.Data,
+		fmt.Sprintf("%s/tampered/oci-dependent-chart:0.1.0", mirror.RegistryURL), registry.PushOptCreationTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	if err := origin.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// tampering serves the tampered chart for any manifest it is asked for,
+	// without the digest header which would give it away.
+	mirrorURL, err := url.Parse("http://" + mirror.RegistryURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(mirrorURL)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		resp.Header.Del("Docker-Content-Digest")
+		return nil
+	}
+	tampering := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/manifests/") {
+			r.URL.Path = "/v2/tampered/oci-dependent-chart/manifests/0.1.0"
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer tampering.Close()
+
+	mirrorsFile := filepath.Join(t.TempDir(), "mirrors.yaml")
+	defer func(path string) { settings.RegistryMirrorsConfig = path }(settings.RegistryMirrorsConfig)
+	settings.RegistryMirrorsConfig = mirrorsFile
+
+	ref := fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart@%s", origin.RegistryURL, origin.ManifestDigest)
+	cmd := fmt.Sprintf("install %%s %s --repository-cache %s --registry-config %s --plain-http --username %s --password %s",
+		ref, srv.Root(), filepath.Join(srv.Root(), "config.json"), mirror.TestUsername, mirror.TestPassword)
+	store := storageFixture()
+
+	mirrors := fmt.Sprintf("mirrors:\n  %s/u/ocitestuser: %s/copy\n", origin.RegistryURL, mirror.RegistryURL)
+	if err := os.WriteFile(mirrorsFile, []byte(mirrors), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := executeActionCommandC(store, fmt.Sprintf(cmd, "mirrored")); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := store.Last("mirrored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rel.Chart.Metadata.Annotations[registry.ChartMirroredFromAnnotation]; r != ref {
+		t.Errorf("expected the release chart to record the reference %s, got %q", ref, r)
+	}
+	if d := rel.Chart.Metadata.Annotations[registry.ChartDigestAnnotation]; d != origin.ManifestDigest {
+		t.Errorf("expected the release chart to record the digest %s, got %q", origin.ManifestDigest, d)
+	}
+
+	mirrors = fmt.Sprintf("mirrors:\n  %s: %s/copy\n", origin.RegistryURL, strings.TrimPrefix(tampering.URL, "http://"))
+	if err := os.WriteFile(mirrorsFile, []byte(mirrors), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = executeActionCommandC(store, fmt.Sprintf(cmd, "tampered"))
+	if err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("expected a digest mismatch installing from a tampering mirror, got %v", err)
+	}
+}
+
+func TestInstallOutputDir(t *testing.T) {
+	store := storageFixture()
+	dir := t.TempDir()
+
+	if _, _, err := executeActionCommandC(store, "install aeneas testdata/testcharts/alpine --output-dir "+dir+" --output-kustomize"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chart is written in the layout of the chart, and installed as well.
+	for _, f := range []string{"alpine/templates/alpine-pod.yaml", "kustomization.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("expected %s to be written: %s", f, err)
+		}
+	}
+	rel, err := store.Last("aeneas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rel.Manifest, "# Source: alpine/templates/alpine-pod.yaml") {
+		t.Errorf("expected the release to hold the manifest, got %q", rel.Manifest)
+	}
+
+	_, _, err = executeActionCommandC(store, "install aeneas2 testdata/testcharts/alpine --output-kustomize")
+	if err == nil || !strings.Contains(err.Error(), "--output-kustomize requires --output-dir") {
+		t.Errorf("expected --output-kustomize without --output-dir to fail, got %v", err)
+	}
+}
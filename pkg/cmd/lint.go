@@ -22,9 +22,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v4/pkg/action"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
@@ -40,12 +42,44 @@ the chart is well-formed.
 If the linter encounters things that will cause the chart to fail installation,
 it will emit [ERROR] messages. If it encounters issues that break with convention
 or recommendation, it will emit [WARNING] messages.
+
+Use '--values-matrix' to lint the chart once per combination of values listed
+in a file, for instance to check the features a chart can enable:
+
+    combinations:
+    - name: defaults
+    - name: ingress
+      values:
+      - ci/ingress-values.yaml
+      set:
+      - ingress.enabled=true
+
+The values files of a combination are relative to the matrix file, and are
+merged after the ones given with '--values'. A finding only showing with some
+combinations is tagged with their names.
+
+With '--with-subcharts', the charts in the charts/ directory are linted as
+well. The templates of the enabled subcharts are also linted as part of their
+parent, with the values it passes down to them, globals included, as an
+install would render them. Their findings are reported at
+'charts/<name>/templates/...', where name is the alias of the subchart, if any.
 `
 
+// valuesMatrix is the file listing the combinations of values given to
+// 'helm lint --values-matrix'.
+type valuesMatrix struct {
+	Combinations []struct {
+		Name   string   `json:"name"`
+		Values []string `json:"values,omitempty"`
+		Set    []string `json:"set,omitempty"`
+	} `json:"combinations"`
+}
+
 func newLintCmd(out io.Writer) *cobra.Command {
 	client := action.NewLint()
 	valueOpts := &values.Options{}
 	var kubeVersion string
+	var valuesMatrixFile string
 
 	cmd := &cobra.Command{
 		Use:   "lint PATH",
@@ -81,9 +115,20 @@ func newLintCmd(out io.Writer) *cobra.Command {
 			}
 
 			client.Namespace = settings.Namespace()
-			vals, err := valueOpts.MergeValues(getter.All(settings))
-			if err != nil {
-				return err
+			var vals map[string]interface{}
+			var combinations []action.LintCombination
+			if valuesMatrixFile != "" {
+				var err error
+				combinations, err = loadValuesMatrix(valuesMatrixFile, valueOpts, getter.All(settings))
+				if err != nil {
+					return err
+				}
+			} else {
+				var err error
+				vals, err = valueOpts.MergeValues(getter.All(settings))
+				if err != nil {
+					return err
+				}
 			}
 
 			var message strings.Builder
@@ -91,7 +136,15 @@ func newLintCmd(out io.Writer) *cobra.Command {
 			errorsOrWarnings := 0
 
 			for _, path := range paths {
-				result := client.Run([]string{path}, vals)
+				var result *action.LintResult
+				if combinations != nil {
+					var err error
+					if result, err = client.RunCombinations([]string{path}, combinations); err != nil {
+						return err
+					}
+				} else {
+					result = client.Run([]string{path}, vals)
+				}
 
 				// If there is no errors/warnings and quiet flag is set
 				// go to the next chart
@@ -146,11 +199,50 @@ func newLintCmd(out io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	f.BoolVar(&client.Strict, "strict", false, "fail on lint warnings")
-	f.BoolVar(&client.WithSubcharts, "with-subcharts", false, "lint dependent charts")
+	f.BoolVar(&client.WithSubcharts, "with-subcharts", false, "lint dependent charts, and the templates of the enabled subcharts with the values their parent passes down to them")
 	f.BoolVar(&client.Quiet, "quiet", false, "print only warnings and errors")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
+	f.BoolVar(&client.CompatWarnings, "compat-warnings", false, "report the uses of template functions and fields deprecated or removed in Helm")
 	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for capabilities and deprecation checks")
+	f.StringVar(&valuesMatrixFile, "values-matrix", "", "lint against each combination of values listed in a YAML file")
 	addValueOptionsFlags(f, valueOpts)
 
 	return cmd
 }
+
+// loadValuesMatrix reads the combinations of values of a values matrix file,
+// each merged with the values given on the command line.
+func loadValuesMatrix(path string, valueOpts *values.Options, p getter.Providers) ([]action.LintCombination, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read values matrix: %w", err)
+	}
+	var matrix valuesMatrix
+	if err := yaml.UnmarshalStrict(data, &matrix); err != nil {
+		return nil, fmt.Errorf("unable to parse values matrix %s: %w", path, err)
+	}
+	if len(matrix.Combinations) > action.MaxLintCombinations {
+		return nil, fmt.Errorf("values matrix %s has %d combinations, the maximum is %d", path, len(matrix.Combinations), action.MaxLintCombinations)
+	}
+
+	dir := filepath.Dir(path)
+	combinations := make([]action.LintCombination, 0, len(matrix.Combinations))
+	for _, c := range matrix.Combinations {
+		opts := *valueOpts
+		opts.ValueFiles = slices.Clone(valueOpts.ValueFiles)
+		for _, f := range c.Values {
+			if !filepath.IsAbs(f) && !strings.Contains(f, "://") {
+				f = filepath.Join(dir, f)
+			}
+			opts.ValueFiles = append(opts.ValueFiles, f)
+		}
+		opts.Values = append(slices.Clone(valueOpts.Values), c.Set...)
+
+		vals, err := opts.MergeValues(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to merge the values of combination %q: %w", c.Name, err)
+		}
+		combinations = append(combinations, action.LintCombination{Name: c.Name, Values: vals})
+	}
+	return combinations, nil
+}
@@ -37,6 +37,36 @@ func TestLintCmdWithSubchartsFlag(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestLintCmdWithValuesMatrix(t *testing.T) {
+	testChart := "testdata/testcharts/chart-with-feature-flags"
+	tests := []cmdTestCase{{
+		name:   "lint chart against a values matrix with warnings",
+		cmd:    fmt.Sprintf("lint --values-matrix testdata/values-matrix.yaml %s", testChart),
+		golden: "output/lint-values-matrix.txt",
+	}, {
+		name:      "lint chart against a values matrix with warnings using --strict",
+		cmd:       fmt.Sprintf("lint --strict --values-matrix testdata/values-matrix.yaml %s", testChart),
+		golden:    "output/lint-values-matrix-strict.txt",
+		wantError: true,
+	}, {
+		name:      "lint chart against a values matrix with errors",
+		cmd:       fmt.Sprintf("lint --values-matrix testdata/values-matrix-persistence.yaml %s", testChart),
+		golden:    "output/lint-values-matrix-errors.txt",
+		wantError: true,
+	}, {
+		name:      "lint chart against an invalid values matrix",
+		cmd:       fmt.Sprintf("lint --values-matrix testdata/values-matrix-invalid.yaml %s", testChart),
+		golden:    "output/lint-values-matrix-invalid.txt",
+		wantError: true,
+	}, {
+		name:      "lint chart against a missing values matrix",
+		cmd:       fmt.Sprintf("lint --values-matrix testdata/missing.yaml %s", testChart),
+		golden:    "output/lint-values-matrix-missing.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestLintCmdWithQuietFlag(t *testing.T) {
 	testChart1 := "testdata/testcharts/alpine"
 	testChart2 := "testdata/testcharts/chart-bad-requirements"
@@ -87,6 +117,11 @@ func TestLintCmdWithKubeVersionFlag(t *testing.T) {
 		cmd:       fmt.Sprintf("lint --kube-version 1.21.0 --strict %s", testChart),
 		golden:    "output/lint-chart-with-deprecated-api-old-k8s.txt",
 		wantError: false,
+	}, {
+		name:      "lint chart with removed api version using kube version flag",
+		cmd:       fmt.Sprintf("lint --kube-version 1.29.0 %s", testChart),
+		golden:    "output/lint-chart-with-removed-api.txt",
+		wantError: true,
 	}}
 	runTestCmd(t, tests)
 }
@@ -30,6 +30,7 @@ import (
 	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cmd/require"
 	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
 var listHelp = `
@@ -50,6 +51,13 @@ Only items that match the filter will be returned.
     NAME                UPDATED                                  CHART
     maudlin-arachnid    2020-06-18 14:17:46.125134977 +0000 UTC  alpine-0.1.0
 
+Releases pinned with 'helm pin' are shown with a PINNED column, and '--pinned'
+lists only them.
+
+Releases whose stored records cannot be decoded are skipped, and reported in
+a warnings section on stderr. Use '--strict' to fail instead, and
+'helm release repair' to find and delete the unreadable records.
+
 If no results are found, 'helm list' will exit 0, but with no output (or in
 the case of no '-q' flag, only headers).
 
@@ -106,11 +114,14 @@ func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					for _, res := range results {
 						_, _ = fmt.Fprintln(out, res.Name)
 					}
-					return nil
+					return writeCorruptReleaseWarnings(cmd.ErrOrStderr(), client.Corrupt)
 				}
 			}
 
-			return outfmt.Write(out, newReleaseListWriter(results, client.TimeFormat, client.NoHeaders, settings.NoColor))
+			if err := outfmt.Write(out, newReleaseListWriter(results, client.TimeFormat, client.NoHeaders, settings.NoColor)); err != nil {
+				return err
+			}
+			return writeCorruptReleaseWarnings(cmd.ErrOrStderr(), client.Corrupt)
 		},
 	}
 
@@ -132,11 +143,32 @@ func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.IntVar(&client.Offset, "offset", 0, "next release index in the list, used to offset from start value")
 	f.StringVarP(&client.Filter, "filter", "f", "", "a regular expression (Perl compatible). Any releases that match the expression will be included in the results")
 	f.StringVarP(&client.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2). Works only for secret(default) and configmap storage backends.")
+	f.BoolVar(&client.Pinned, "pinned", false, "show only the pinned releases")
+	f.BoolVar(&client.Strict, "strict", false, "fail if the record of a release cannot be decoded, instead of skipping it with a warning")
 	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
 }
 
+// writeCorruptReleaseWarnings writes the records of releases skipped because
+// they could not be decoded, if any.
+func writeCorruptReleaseWarnings(out io.Writer, corrupt []*driver.CorruptReleaseError) error {
+	if len(corrupt) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "WARNING: skipped %d release records which could not be decoded:\n", len(corrupt))
+	table := uitable.New()
+	table.AddRow("KEY", "NAMESPACE", "ERROR")
+	for _, c := range corrupt {
+		table.AddRow(c.Key, c.Namespace, c.Err)
+	}
+	if err := output.EncodeTable(out, table); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Run 'helm release repair RELEASE_NAME' to inspect and delete them.")
+	return nil
+}
+
 type releaseElement struct {
 	Name       string `json:"name"`
 	Namespace  string `json:"namespace"`
@@ -145,17 +177,21 @@ type releaseElement struct {
 	Status     string `json:"status"`
 	Chart      string `json:"chart"`
 	AppVersion string `json:"app_version"`
+	Pinned     bool   `json:"pinned,omitempty"`
 }
 
 type releaseListWriter struct {
 	releases  []releaseElement
 	noHeaders bool
 	noColor   bool
+	// pinned adds the PINNED column, when a listed release is pinned.
+	pinned bool
 }
 
 func newReleaseListWriter(releases []*release.Release, timeFormat string, noHeaders bool, noColor bool) *releaseListWriter {
 	// Initialize the array so no results returns an empty array instead of null
 	elements := make([]releaseElement, 0, len(releases))
+	pinned := false
 	for _, r := range releases {
 		element := releaseElement{
 			Name:       r.Name,
@@ -164,7 +200,9 @@ func newReleaseListWriter(releases []*release.Release, timeFormat string, noHead
 			Status:     r.Info.Status.String(),
 			Chart:      formatChartName(r.Chart),
 			AppVersion: formatAppVersion(r.Chart),
+			Pinned:     r.Pin != nil,
 		}
+		pinned = pinned || element.Pinned
 
 		t := "-"
 		if tspb := r.Info.LastDeployed; !tspb.IsZero() {
@@ -178,13 +216,13 @@ func newReleaseListWriter(releases []*release.Release, timeFormat string, noHead
 
 		elements = append(elements, element)
 	}
-	return &releaseListWriter{elements, noHeaders, noColor}
+	return &releaseListWriter{elements, noHeaders, noColor, pinned}
 }
 
 func (w *releaseListWriter) WriteTable(out io.Writer) error {
 	table := uitable.New()
 	if !w.noHeaders {
-		table.AddRow(
+		headers := []interface{}{
 			output.ColorizeHeader("NAME", w.noColor),
 			output.ColorizeHeader("NAMESPACE", w.noColor),
 			output.ColorizeHeader("REVISION", w.noColor),
@@ -192,7 +230,11 @@ func (w *releaseListWriter) WriteTable(out io.Writer) error {
 			output.ColorizeHeader("STATUS", w.noColor),
 			output.ColorizeHeader("CHART", w.noColor),
 			output.ColorizeHeader("APP VERSION", w.noColor),
-		)
+		}
+		if w.pinned {
+			headers = append(headers, output.ColorizeHeader("PINNED", w.noColor))
+		}
+		table.AddRow(headers...)
 	}
 	for _, r := range w.releases {
 		// Parse the status string back to a release.Status to use color
@@ -219,7 +261,11 @@ func (w *releaseListWriter) WriteTable(out io.Writer) error {
 		default:
 			status = release.Status(r.Status)
 		}
-		table.AddRow(r.Name, output.ColorizeNamespace(r.Namespace, w.noColor), r.Revision, r.Updated, output.ColorizeStatus(status, w.noColor), r.Chart, r.AppVersion)
+		row := []interface{}{r.Name, output.ColorizeNamespace(r.Namespace, w.noColor), r.Revision, r.Updated, output.ColorizeStatus(status, w.noColor), r.Chart, r.AppVersion}
+		if w.pinned {
+			row = append(row, r.Pinned)
+		}
+		table.AddRow(row...)
 	}
 	return output.EncodeTable(out, table)
 }
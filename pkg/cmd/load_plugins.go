@@ -39,6 +39,11 @@ const (
 	pluginDynamicCompletionExecutable = "plugin.complete"
 )
 
+// pluginParentCommands are the commands a plugin may add a subcommand to with
+// its parentCommand. The others are left alone, so that a plugin cannot change
+// how releases are installed or changed.
+var pluginParentCommands = []string{"get", "show", "repo"}
+
 type PluginError struct {
 	error
 	Code int
@@ -69,6 +74,12 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 			md.Usage = fmt.Sprintf("the %q plugin", md.Name)
 		}
 
+		parentCmd, err := pluginParent(baseCmd, md)
+		if err != nil {
+			fmt.Fprintf(baseCmd.ErrOrStderr(), "WARNING: plugin %q not loaded: %s\n", md.Name, err)
+			continue
+		}
+
 		c := &cobra.Command{
 			Use:   md.Name,
 			Short: md.Usage,
@@ -83,6 +94,9 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 				// PrepareCommand uses os.ExpandEnv and expects the
 				// setupEnv vars.
 				plugin.SetupPluginEnv(settings, md.Name, plug.Dir)
+				if md.ParentCommand != "" {
+					setupParentCommandEnv(md.ParentCommand, u)
+				}
 				main, argv, prepCmdErr := plug.PrepareCommand(u)
 				if prepCmdErr != nil {
 					os.Stderr.WriteString(prepCmdErr.Error())
@@ -95,8 +109,7 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 			DisableFlagParsing: true,
 		}
 
-		// TODO: Make sure a command with this name does not already exist.
-		baseCmd.AddCommand(c)
+		parentCmd.AddCommand(c)
 
 		// For completion, we try to load more details about the plugins so as to allow for command and
 		// flag completion of the plugin itself.
@@ -111,6 +124,53 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 	}
 }
 
+// pluginParent returns the command the plugin is added to: its parentCommand,
+// or baseCmd. A plugin with a parentCommand may not take the name of one of
+// the commands of its parent.
+func pluginParent(baseCmd *cobra.Command, md *plugin.Metadata) (*cobra.Command, error) {
+	if md.ParentCommand == "" {
+		// TODO: Make sure a command with this name does not already exist.
+		return baseCmd, nil
+	}
+	if !slices.Contains(pluginParentCommands, md.ParentCommand) {
+		return nil, fmt.Errorf("parent command %q cannot be extended, it must be one of %s", md.ParentCommand, strings.Join(pluginParentCommands, ", "))
+	}
+	parent := subCommand(baseCmd, md.ParentCommand)
+	if parent == nil {
+		return nil, fmt.Errorf("parent command %q not found", md.ParentCommand)
+	}
+	if existing := subCommand(parent, md.Name); existing != nil {
+		return nil, fmt.Errorf("it conflicts with the %q command", existing.CommandPath())
+	}
+	return parent, nil
+}
+
+// subCommand returns the subcommand of cmd with the given name or alias.
+func subCommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, c := range cmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// setupParentCommandEnv adds the parent command of a plugin to os.Env, and
+// the release a plugin of 'helm get' is given, which is its first positional
+// argument. The namespace is already in HELM_NAMESPACE.
+func setupParentCommandEnv(parent string, args []string) {
+	os.Setenv("HELM_PLUGIN_PARENT_COMMAND", parent)
+	if parent != "get" {
+		return
+	}
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			os.Setenv("HELM_RELEASE", a)
+			return
+		}
+	}
+}
+
 func processParent(cmd *cobra.Command, args []string) ([]string, error) {
 	k, u := manuallyProcessArgs(args)
 	if err := cmd.Parent().ParseFlags(k); err != nil {
@@ -333,8 +393,12 @@ func pluginDynamicComp(plug *plugin.Plugin, cmd *cobra.Command, args []string, t
 
 	// We must include all sub-commands passed on the command-line.
 	// To do that, we pass-in the entire CommandPath, except the first two elements
-	// which are 'helm' and 'pluginName'.
-	argv := strings.Split(cmd.CommandPath(), " ")[2:]
+	// which are 'helm' and 'pluginName', and the parent command of the plugin.
+	skip := 2
+	if md.ParentCommand != "" {
+		skip++
+	}
+	argv := strings.Split(cmd.CommandPath(), " ")[skip:]
 	if !md.IgnoreFlags {
 		argv = append(argv, u...)
 		argv = append(argv, toComplete)
@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"helm.sh/helm/v4/pkg/action"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// notesChange describes how the notes of a release compare with the notes
+// stored for its previous revision.
+type notesChange struct {
+	previousRevision int
+	changed          bool
+	// diff is a unified diff of the notes, only set when requested
+	diff string
+}
+
+// compareReleaseNotes compares the notes of rel with those of the previous
+// revision. It returns nil when there is no previous revision to compare with.
+func compareReleaseNotes(cfg *action.Configuration, rel *release.Release, showDiff, strict bool) *notesChange {
+	if rel == nil || rel.Info == nil || rel.Version <= 1 {
+		return nil
+	}
+	get := action.NewGet(cfg)
+	get.Version = rel.Version - 1
+	previous, err := get.Run(rel.Name)
+	if err != nil {
+		slog.Debug("unable to load previous revision to compare notes", "release", rel.Name, "revision", get.Version, slog.Any("error", err))
+		return nil
+	}
+
+	var previousNotes string
+	if previous.Info != nil {
+		previousNotes = previous.Info.Notes
+	}
+	// Notes that were removed are not reported, as there are no new notes
+	// for the user to read.
+	change := &notesChange{
+		previousRevision: previous.Version,
+		changed:          strings.TrimSpace(rel.Info.Notes) != "" && notesDiffer(previousNotes, rel.Info.Notes, strict),
+	}
+	if change.changed && showDiff {
+		change.diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(previousNotes),
+			B:        difflib.SplitLines(rel.Info.Notes),
+			FromFile: fmt.Sprintf("NOTES (revision %d)", previous.Version),
+			ToFile:   fmt.Sprintf("NOTES (revision %d)", rel.Version),
+			Context:  3,
+		})
+		if err != nil {
+			slog.Debug("unable to compute notes diff", "release", rel.Name, slog.Any("error", err))
+		}
+	}
+	return change
+}
+
+// notesDiffer reports whether two sets of notes differ. Unless strict is set,
+// changes that only affect whitespace are ignored.
+func notesDiffer(a, b string, strict bool) bool {
+	if strict {
+		return a != b
+	}
+	return strings.Join(strings.Fields(a), " ") != strings.Join(strings.Fields(b), " ")
+}
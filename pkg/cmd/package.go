@@ -26,6 +26,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v4/pkg/action"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/downloader"
 	"helm.sh/helm/v4/pkg/getter"
@@ -45,6 +46,23 @@ provide '--keyring path/to/secret/keys' and '--key keyname'.
 
 If '--keyring' is not specified, Helm usually defaults to the public keyring
 unless your environment is otherwise configured.
+
+To make sure a chart is packaged with the dependencies recorded in its lock
+file, use the '--verify-lock' flag. It fails if the lock file is out of sync
+with Chart.yaml, or if the charts/ directory does not hold the locked versions.
+
+The files of the archive get the mode 0644 and its directories the mode 0755,
+whatever the platform and the umask the chart is packaged with, and no owner
+is recorded. Use '--file-mode' and '--dir-mode' to set other modes.
+
+To package a chart into the same bytes each time, use the '--reproducible'
+flag. It writes the entries of the archive sorted by path and with the Unix
+epoch as modification time, so that the digest of the archive only depends on
+the content of the chart. The provenance file of '--sign' records the time of
+the signature and so still differs, unless the SOURCE_DATE_EPOCH environment
+variable sets the time to record:
+
+  $ SOURCE_DATE_EPOCH=$(git log -1 --format=%ct) helm package --reproducible --sign ./mychart
 `
 
 func newPackageCmd(out io.Writer) *cobra.Command {
@@ -100,12 +118,18 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 						RegistryClient:   registryClient,
 						RepositoryConfig: settings.RepositoryConfig,
 						RepositoryCache:  settings.RepositoryCache,
+						DownloadRetries:  settings.DownloadRetries,
 					}
 
 					if err := downloadManager.Update(); err != nil {
 						return err
 					}
 				}
+				if client.VerifyLock {
+					if err := downloader.VerifyLock(path); err != nil {
+						return err
+					}
+				}
 				p, err := client.Run(path, vals)
 				if err != nil {
 					return err
@@ -125,6 +149,11 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 	f.StringVar(&client.AppVersion, "app-version", "", "set the appVersion on the chart to this version")
 	f.StringVarP(&client.Destination, "destination", "d", ".", "location to write the chart.")
 	f.BoolVarP(&client.DependencyUpdate, "dependency-update", "u", false, `update dependencies from "Chart.yaml" to dir "charts/" before packaging`)
+	f.BoolVar(&client.VerifyLock, "verify-lock", false, `check that the dependencies in "charts/" match the lock file before packaging`)
+	f.Var(newFileModeValue(chartutil.DefaultArchiveFileMode, &client.FileMode), "file-mode", "mode of the files of the chart archive, whatever their modes in the chart directory")
+	f.Var(newFileModeValue(chartutil.DefaultArchiveDirMode, &client.DirMode), "dir-mode", "mode of the directories of the chart archive, whatever their modes in the chart directory")
+	f.BoolVar(&client.Reproducible, "reproducible", false, "package the chart into the same bytes each time, writing the entries sorted by path and with a fixed modification time")
+	f.BoolVar(&client.SkipValidation, "skip-validation", false, "skip checking that the templates, Chart.yaml, values and CRDs of the chart parse before packaging")
 	f.StringVar(&client.Username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&client.Password, "password", "", "chart repository password where to locate the requested chart")
 	f.StringVar(&client.CertFile, "cert-file", "", "identify HTTPS client using this SSL certificate file")
@@ -97,6 +97,26 @@ func TestPackage(t *testing.T) {
 			hasfile: "chart-missing-deps-0.1.0.tgz",
 			err:     true,
 		},
+		{
+			name:   "package --verify-lock testdata/testcharts/reqtest",
+			args:   []string{"testdata/testcharts/reqtest"},
+			flags:  map[string]string{"verify-lock": "1"},
+			expect: "the lock file \\(requirements.lock\\) is out of sync",
+			err:    true,
+		},
+		{
+			name:    "package --file-mode --dir-mode testdata/testcharts/alpine",
+			args:    []string{"testdata/testcharts/alpine"},
+			flags:   map[string]string{"file-mode": "0640", "dir-mode": "0750"},
+			hasfile: "alpine-0.1.0.tgz",
+		},
+		{
+			name:   "package --file-mode with setuid",
+			args:   []string{"testdata/testcharts/alpine"},
+			flags:  map[string]string{"file-mode": "4755"},
+			expect: "invalid file mode \"4755\"",
+			err:    true,
+		},
 		{
 			name: "package testdata/testcharts/chart-bad-type",
 			args: []string{"testdata/testcharts/chart-bad-type"},
@@ -166,6 +186,23 @@ func TestPackage(t *testing.T) {
 	}
 }
 
+func TestPackageVerifyLock(t *testing.T) {
+	chartToPackage := copyIssue7233Chart(t, true)
+	dir := t.TempDir()
+	cmd := fmt.Sprintf("package '%s' --destination=%s --verify-lock", chartToPackage, dir)
+	_, output, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Logf("Output: %s", output)
+		t.Fatal(err)
+	}
+	chartPath := filepath.Join(dir, "issue-7233-0.1.0.tgz")
+	if fi, err := os.Stat(chartPath); err != nil {
+		t.Errorf("expected file %q, got err %q", chartPath, err)
+	} else if fi.Size() == 0 {
+		t.Errorf("file %q has zero bytes.", chartPath)
+	}
+}
+
 func TestSetAppVersion(t *testing.T) {
 	var ch *chart.Chart
 	expectedAppVersion := "app-version-foo"
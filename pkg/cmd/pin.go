@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const pinDesc = `
+This command pins a release, as a safety catch on critical releases.
+
+The upgrades, rollbacks and uninstalls of a pinned release fail, unless
+'--override-pin' is given. The pin is kept by the revisions created with
+'--override-pin', and is removed with 'helm unpin'.
+
+The pin is shown by 'helm get metadata', and 'helm list --pinned' lists the
+pinned releases.
+
+    $ helm pin prod-db --reason "holds the production database"
+`
+
+const unpinDesc = `
+This command removes the pin of a release, set with 'helm pin'.
+`
+
+func newPinCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewPin(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "pin RELEASE_NAME",
+		Short: "block the upgrades, rollbacks and uninstalls of a release",
+		Long:  pinDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if _, err := client.Run(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "release %q pinned\n", args[0])
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&client.Reason, "reason", "", "reason reported when the pin refuses an operation")
+	f.StringVar(&client.PinnedBy, "pinned-by", "", "who pins the release. Defaults to the local user")
+
+	return cmd
+}
+
+func newUnpinCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewUnpin(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "unpin RELEASE_NAME",
+		Short: "remove the pin of a release",
+		Long:  unpinDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if _, err := client.Run(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "release %q unpinned\n", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
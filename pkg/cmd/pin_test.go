@@ -0,0 +1,89 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/time"
+)
+
+func pinMock(name string) *release.Release {
+	rel := release.Mock(&release.MockReleaseOptions{Name: name})
+	rel.Pin = &release.Pin{
+		Reason:   "production database",
+		PinnedBy: "ops",
+		Since:    time.Unix(1452902400, 0).UTC(),
+	}
+	return rel
+}
+
+func TestPinCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "pin a release",
+		cmd:    "pin aeneas --reason 'production database' --pinned-by ops",
+		golden: "output/pin.txt",
+		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas"})},
+	}, {
+		name:      "pin a missing release",
+		cmd:       "pin aeneas",
+		golden:    "output/pin-missing.txt",
+		wantError: true,
+	}, {
+		name:   "unpin a release",
+		cmd:    "unpin aeneas",
+		golden: "output/unpin.txt",
+		rels:   []*release.Release{pinMock("aeneas")},
+	}, {
+		name:      "unpin a release which is not pinned",
+		cmd:       "unpin aeneas",
+		golden:    "output/unpin-not-pinned.txt",
+		rels:      []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas"})},
+		wantError: true,
+	}, {
+		name:      "uninstall a pinned release",
+		cmd:       "uninstall aeneas",
+		golden:    "output/uninstall-pinned.txt",
+		rels:      []*release.Release{pinMock("aeneas")},
+		wantError: true,
+	}, {
+		name:   "uninstall a pinned release with the override",
+		cmd:    "uninstall aeneas --override-pin",
+		golden: "output/uninstall.txt",
+		rels:   []*release.Release{pinMock("aeneas")},
+	}, {
+		name:   "list the pinned releases",
+		cmd:    "list --pinned",
+		golden: "output/list-pinned.txt",
+		rels:   []*release.Release{pinMock("aeneas"), release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+	}, {
+		name:   "list with a pinned release",
+		cmd:    "list",
+		golden: "output/list-with-pinned.txt",
+		rels:   []*release.Release{pinMock("aeneas"), release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestPinCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "pin", false)
+}
+
+func TestUnpinCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "unpin", false)
+}
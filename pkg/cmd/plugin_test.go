@@ -374,6 +374,88 @@ func TestLoadPlugins_HelmNoPlugins(t *testing.T) {
 	}
 }
 
+func TestLoadPluginsParentCommand(t *testing.T) {
+	settings.PluginsDirectory = "testdata/plugins-parent"
+	t.Setenv("HELM_PLUGIN_PARENT_COMMAND", "")
+	t.Setenv("HELM_RELEASE", "")
+
+	var out, stderr bytes.Buffer
+	cmd := &cobra.Command{Use: "helm"}
+	cmd.SetErr(&stderr)
+	get := &cobra.Command{Use: "get"}
+	get.AddCommand(&cobra.Command{Use: "values"})
+	cmd.AddCommand(get, &cobra.Command{Use: "install"})
+
+	loadPlugins(cmd, &out)
+
+	// Only the plugin with an allowed parent and no conflicting name is
+	// loaded, under its parent.
+	if len(cmd.Commands()) != 2 {
+		t.Errorf("Expected no top-level plugin, got %v", cmd.Commands())
+	}
+	cost := subCommand(get, "cost")
+	if cost == nil {
+		t.Fatalf("Expected the cost plugin under get, got %v", get.Commands())
+	}
+	if len(get.Commands()) != 2 {
+		t.Errorf("Expected the values command to be kept, got %v", get.Commands())
+	}
+
+	for _, warning := range []string{
+		`WARNING: plugin "deploy" not loaded: parent command "install" cannot be extended, it must be one of get, show, repo`,
+		`WARNING: plugin "values" not loaded: it conflicts with the "helm get values" command`,
+	} {
+		if !strings.Contains(stderr.String(), warning) {
+			t.Errorf("Expected warning %q, got:\n%s", warning, stderr.String())
+		}
+	}
+
+	// Currently, plugins assume a Linux subsystem. Skip the execution
+	// tests until this is fixed
+	if runtime.GOOS != "windows" {
+		if err := cost.RunE(cost, []string{"myrel", "--monthly"}); err != nil {
+			t.Fatal(err)
+		}
+		if expect := "get myrel " + settings.Namespace() + " myrel --monthly\n"; out.String() != expect {
+			t.Errorf("Expected output %q, got %q", expect, out.String())
+		}
+	}
+}
+
+func TestLoadPluginsParentCommandNotFound(t *testing.T) {
+	settings.PluginsDirectory = "testdata/plugins-parent"
+
+	var out, stderr bytes.Buffer
+	cmd := &cobra.Command{Use: "helm"}
+	cmd.SetErr(&stderr)
+	loadPlugins(cmd, &out)
+
+	if len(cmd.Commands()) != 0 {
+		t.Errorf("Expected no plugin, got %v", cmd.Commands())
+	}
+	if warning := `WARNING: plugin "cost" not loaded: parent command "get" not found`; !strings.Contains(stderr.String(), warning) {
+		t.Errorf("Expected warning %q, got:\n%s", warning, stderr.String())
+	}
+}
+
+func TestPluginParentCommandCompletion(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "completion for plugin of get",
+		cmd:    "__complete get co",
+		golden: "output/plugin_parent_comp.txt",
+		rels:   []*release.Release{},
+	}, {
+		name:   "dynamic completion for plugin of get",
+		cmd:    "__complete get cost myrel ''",
+		golden: "output/plugin_parent_dynamic_comp.txt",
+		rels:   []*release.Release{},
+	}}
+	for _, test := range tests {
+		settings.PluginsDirectory = "testdata/plugins-parent"
+		runTestCmd(t, []cmdTestCase{test})
+	}
+}
+
 func TestPluginCmdsCompletion(t *testing.T) {
 	tests := []cmdTestCase{{
 		name:   "completion for plugin update",
@@ -45,6 +45,7 @@ result in an error, and the chart will not be saved locally.
 
 func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewPull(action.WithConfig(cfg))
+	var credHelper string
 
 	cmd := &cobra.Command{
 		Use:     "pull [chart URL | repo/chartname] [...]",
@@ -60,13 +61,16 @@ func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
 			client.Settings = settings
+			defer pruneCacheBySettings()
+
 			if client.Version == "" && client.Devel {
 				slog.Debug("setting version to >0.0.0-0")
 				client.Version = ">0.0.0-0"
 			}
 
 			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
-				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
+				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password,
+				credentialHelperOpts(credHelper)...)
 			if err != nil {
 				return fmt.Errorf("missing registry client: %w", err)
 			}
@@ -90,6 +94,7 @@ func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.StringVar(&client.UntarDir, "untardir", ".", "if untar is specified, this flag specifies the name of the directory into which the chart is expanded")
 	f.StringVarP(&client.DestDir, "destination", "d", ".", "location to write the chart. If this and untardir are specified, untardir is appended to this")
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
+	addCredHelperFlag(f, &credHelper)
 
 	err := cmd.RegisterFlagCompletionFunc("version", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 1 {
@@ -17,11 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v4/pkg/repo/repotest"
@@ -61,6 +63,7 @@ func TestPullCmd(t *testing.T) {
 		expectDir    bool
 		expectVerify bool
 		expectSha    string
+		expectDigest string
 	}{
 		{
 			name:       "Basic chart fetch",
@@ -198,6 +201,28 @@ func TestPullCmd(t *testing.T) {
 			wantErrorMsg: "Error: chart reference and version mismatch: 0.2.0 is not 0.1.0",
 			wantError:    true,
 		},
+		{
+			name:         "Fetching OCI chart by digest",
+			args:         fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart@%s", ociSrv.RegistryURL, ociSrv.ManifestDigest),
+			expectFile:   "./oci-dependent-chart@" + strings.Replace(ociSrv.ManifestDigest, ":", "-", 1) + ".tgz",
+			expectDigest: ociSrv.ChartDigest,
+		},
+		{
+			name:         "Fetching OCI chart by digest ignores the tag",
+			args:         fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart:0.2.0@%s", ociSrv.RegistryURL, ociSrv.ManifestDigest),
+			expectFile:   "./oci-dependent-chart@" + strings.Replace(ociSrv.ManifestDigest, ":", "-", 1) + ".tgz",
+			expectDigest: ociSrv.ChartDigest,
+		},
+		{
+			name:      "Fail fetching OCI chart by digest with version",
+			args:      fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart@%s --version 0.1.0", ociSrv.RegistryURL, ociSrv.ManifestDigest),
+			wantError: true,
+		},
+		{
+			name:      "Fail fetching OCI chart by unknown digest",
+			args:      fmt.Sprintf("oci://%s/u/ocitestuser/oci-dependent-chart@sha256:%x", ociSrv.RegistryURL, sha256.Sum256([]byte("unknown"))),
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -252,6 +277,16 @@ func TestPullCmd(t *testing.T) {
 			if fi.IsDir() != tt.expectDir {
 				t.Errorf("%q: expected directory=%t, but it's not.", tt.name, tt.expectDir)
 			}
+
+			if tt.expectDigest != "" {
+				b, err := os.ReadFile(ef)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := fmt.Sprintf("sha256:%x", sha256.Sum256(b)); d != tt.expectDigest {
+					t.Errorf("%q: expected the pulled chart to have the digest %s, got %s", tt.name, tt.expectDigest, d)
+				}
+			}
 		})
 	}
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 
@@ -25,13 +26,21 @@ import (
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/cmd/require"
 	"helm.sh/helm/v4/pkg/pusher"
+	"helm.sh/helm/v4/pkg/registry"
 )
 
 const pushDesc = `
 Upload a chart to a registry.
 
 If the chart has an associated provenance file,
-it will also be uploaded.
+it will also be uploaded. Use '--with-prov' to fail the upload
+when the provenance file is missing, so that consumers can rely
+on 'helm pull --verify'.
+
+The chart is tagged with its version, unless another tag is given
+with '--tag'. The push fails if the tag already exists in the
+registry, which registries with immutable tags would refuse, unless
+'--force' is set.
 `
 
 type registryPushOptions struct {
@@ -40,8 +49,12 @@ type registryPushOptions struct {
 	caFile                string
 	insecureSkipTLSverify bool
 	plainHTTP             bool
+	withProv              bool
+	tag                   string
+	force                 bool
 	password              string
 	username              string
+	credHelper            string
 }
 
 func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -72,6 +85,7 @@ func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		RunE: func(_ *cobra.Command, args []string) error {
 			registryClient, err := newRegistryClient(
 				o.certFile, o.keyFile, o.caFile, o.insecureSkipTLSverify, o.plainHTTP, o.username, o.password,
+				credentialHelperOpts(o.credHelper)...,
 			)
 
 			if err != nil {
@@ -86,7 +100,13 @@ func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				action.WithPlainHTTP(o.plainHTTP),
 				action.WithPushOptWriter(out))
 			client.Settings = settings
+			client.WithProvenance = o.withProv
+			client.Tag = o.tag
+			client.Force = o.force
 			output, err := client.Run(chartRef, remote)
+			if errors.Is(err, registry.ErrTagAlreadyExists) {
+				return fmt.Errorf("%w. Use --force to overwrite it", err)
+			}
 			if err != nil {
 				return err
 			}
@@ -101,8 +121,12 @@ func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.StringVar(&o.caFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
 	f.BoolVar(&o.insecureSkipTLSverify, "insecure-skip-tls-verify", false, "skip tls certificate checks for the chart upload")
 	f.BoolVar(&o.plainHTTP, "plain-http", false, "use insecure HTTP connections for the chart upload")
+	f.BoolVar(&o.withProv, "with-prov", false, "require the chart's provenance file (.prov) to be uploaded alongside it")
+	f.StringVar(&o.tag, "tag", "", "tag the chart with this tag instead of its version")
+	f.BoolVar(&o.force, "force", false, "push the chart even if its tag already exists in the registry")
 	f.StringVar(&o.username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&o.password, "password", "", "chart repository password where to locate the requested chart")
+	addCredHelperFlag(f, &o.credHelper)
 
 	return cmd
 }
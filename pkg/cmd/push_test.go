@@ -17,11 +17,106 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"helm.sh/helm/v4/pkg/registry"
+	"helm.sh/helm/v4/pkg/repo/repotest"
 )
 
+func TestPushWithProvenance(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testcharts/*.tgz*"),
+	)
+	defer srv.Stop()
+
+	ociSrv, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSrv.Run(t)
+
+	registryConfig := filepath.Join(srv.Root(), "config.json")
+	remote := fmt.Sprintf("oci://%s/u/ocitestuser", ociSrv.RegistryURL)
+
+	_, _, err = executeActionCommand(fmt.Sprintf("push %s %s --with-prov --plain-http --registry-config %s",
+		filepath.Join(srv.Root(), "compressedchart-0.1.0.tgz"), remote, registryConfig))
+	if err == nil || !strings.Contains(err.Error(), "provenance file") {
+		t.Fatalf("expected pushing a chart without provenance to fail, got %v", err)
+	}
+
+	_, _, err = executeActionCommand(fmt.Sprintf("push %s %s --with-prov --plain-http --registry-config %s",
+		filepath.Join(srv.Root(), "signtest-0.1.0.tgz"), remote, registryConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	_, out, err := executeActionCommand(fmt.Sprintf("pull %s/signtest --version 0.1.0 --verify --keyring testdata/helm-test-key.pub -d %s --plain-http --registry-config %s",
+		remote, outdir, registryConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Chart Hash Verified: ") {
+		t.Errorf("expected the pulled chart to be verified, got %q", out)
+	}
+
+	for _, name := range []string{"signtest-0.1.0.tgz", "signtest-0.1.0.tgz.prov"} {
+		if _, err := os.Stat(filepath.Join(outdir, name)); err != nil {
+			t.Errorf("expected %s to be pulled: %s", name, err)
+		}
+	}
+}
+
 func TestPushFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "push", true)
 	checkFileCompletion(t, "push package.tgz", false)
 	checkFileCompletion(t, "push package.tgz oci://localhost:5000", false)
 }
+
+func TestPushTag(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testcharts/*.tgz*"),
+	)
+	defer srv.Stop()
+
+	ociSrv, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSrv.Run(t)
+
+	registryConfig := filepath.Join(srv.Root(), "config.json")
+	remote := fmt.Sprintf("oci://%s/u/ocitestuser", ociSrv.RegistryURL)
+	push := fmt.Sprintf("push %s %s --plain-http --registry-config %s",
+		filepath.Join(srv.Root(), "compressedchart-0.1.0.tgz"), remote, registryConfig)
+
+	if _, _, err := executeActionCommand(push + " --tag 0.1.0-rc.builds.45"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ociSrv.Client.Pull(fmt.Sprintf("%s/u/ocitestuser/compressedchart:0.1.0-rc.builds.45", ociSrv.RegistryURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Chart.Meta.Version != "0.1.0" {
+		t.Errorf("expected the chart version 0.1.0 to be recorded, got %q", result.Chart.Meta.Version)
+	}
+
+	_, _, err = executeActionCommand(push + " --tag 0.1.0-rc.builds.45")
+	if !errors.Is(err, registry.ErrTagAlreadyExists) {
+		t.Fatalf("expected pushing to an existing tag to fail with ErrTagAlreadyExists, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Use --force to overwrite it") {
+		t.Errorf("expected the error to suggest --force, got %q", err)
+	}
+
+	if _, _, err := executeActionCommand(push + " --tag 0.1.0-rc.builds.45 --force"); err != nil {
+		t.Fatalf("expected pushing to an existing tag with --force to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const recoverDesc = `
+This command recovers a release whose last install, upgrade or rollback was
+interrupted, for instance because the helm process was killed.
+
+Such a release is left in a pending state, which blocks its other operations.
+The pending revision is marked as failed, and the resources are left as they
+are: the release can then be upgraded, or rolled back with 'helm rollback'.
+
+An interrupted upgrade whose revision is intact can be resumed with
+'helm upgrade --resume' instead.
+`
+
+func newRecoverCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewRecover(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "recover RELEASE_NAME",
+		Short: "recover a release left pending by an interrupted operation",
+		Long:  recoverDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			rel, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "release %q recovered: revision %d marked as failed\n", args[0], rel.Version)
+			return nil
+		},
+	}
+
+	return cmd
+}
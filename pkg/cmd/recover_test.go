@@ -0,0 +1,43 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestRecoverCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "recover a pending release",
+		cmd:    "recover aeneas",
+		golden: "output/recover.txt",
+		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas", Status: release.StatusPendingUpgrade})},
+	}, {
+		name:      "recover a release which is not pending",
+		cmd:       "recover aeneas",
+		golden:    "output/recover-not-pending.txt",
+		rels:      []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas"})},
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestRecoverCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "recover", false)
+}
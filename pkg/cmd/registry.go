@@ -25,6 +25,21 @@ import (
 
 const registryHelp = `
 This command consists of multiple subcommands to interact with registries.
+
+OCI charts can be pulled from mirrors of their registries, as in air-gapped
+environments, by mapping the registries to their mirrors in the file set with
+$HELM_REGISTRY_MIRRORS_CONFIG (by default 'registry/mirrors.yaml' in the Helm
+configuration directory):
+
+    mirrors:
+      registry-1.docker.io: mirror.example.com:5000/docker
+      ghcr.io/org/charts: mirror.example.com:5000/org
+
+A registry may be given with a repository path prefix, the most specific
+mapping being used. Charts installed from a mirror record the reference they
+were requested by in their 'helm.sh/oci-mirrored-from' annotation. Pin the
+references by digest to verify that a mirror serves the same charts: a
+reference by tag is trusted to the mirror, and pulling it prints a warning.
 `
 
 func newRegistryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
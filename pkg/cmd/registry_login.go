@@ -38,6 +38,14 @@ Authenticate to a remote registry.
 For example for Github Container Registry:
 
     echo "$GITHUB_TOKEN" | helm registry login ghcr.io -u $GITHUB_USER --password-stdin
+
+Registries set up with a docker credential helper, in the credHelpers or
+credsStore of the docker config, need no login: the helper is run to get the
+credentials when the registry is accessed. To check the credentials of a
+helper, or use one which is not configured, give its name with '--cred-helper',
+e.g. for Amazon ECR with docker-credential-ecr-login:
+
+    helm registry login 123456789012.dkr.ecr.us-east-1.amazonaws.com --cred-helper ecr-login
 `
 
 type registryLoginOptions struct {
@@ -49,6 +57,7 @@ type registryLoginOptions struct {
 	caFile               string
 	insecure             bool
 	plainHTTP            bool
+	credHelper           string
 }
 
 func newRegistryLoginCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -63,9 +72,13 @@ func newRegistryLoginCmd(cfg *action.Configuration, out io.Writer) *cobra.Comman
 		RunE: func(_ *cobra.Command, args []string) error {
 			hostname := args[0]
 
-			username, password, err := getUsernamePassword(o.username, o.password, o.passwordFromStdinOpt)
-			if err != nil {
-				return err
+			var username, password string
+			if o.credHelper == "" {
+				var err error
+				username, password, err = getUsernamePassword(o.username, o.password, o.passwordFromStdinOpt)
+				if err != nil {
+					return err
+				}
 			}
 
 			return action.NewRegistryLogin(cfg).Run(out, hostname, username, password,
@@ -73,7 +86,8 @@ func newRegistryLoginCmd(cfg *action.Configuration, out io.Writer) *cobra.Comman
 				action.WithKeyFile(o.keyFile),
 				action.WithCAFile(o.caFile),
 				action.WithInsecure(o.insecure),
-				action.WithPlainHTTPLogin(o.plainHTTP))
+				action.WithPlainHTTPLogin(o.plainHTTP),
+				action.WithCredentialHelper(o.credHelper))
 		},
 	}
 
@@ -86,6 +100,7 @@ func newRegistryLoginCmd(cfg *action.Configuration, out io.Writer) *cobra.Comman
 	f.StringVar(&o.keyFile, "key-file", "", "identify registry client using this SSL key file")
 	f.StringVar(&o.caFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
 	f.BoolVar(&o.plainHTTP, "plain-http", false, "use insecure HTTP connections for the chart upload")
+	f.StringVar(&o.credHelper, "cred-helper", "", "log in with the credentials of the docker credential helper docker-credential-NAME, rather than a username and password. The credentials are not stored")
 
 	return cmd
 }
@@ -0,0 +1,136 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const releaseRepairDesc = `
+Report which revision records of a release cannot be decoded, for example
+because the Secret holding them was truncated.
+
+Such records are skipped by 'helm list', and break the commands reading the
+history of the release. With --delete-corrupt, they are deleted once confirmed
+interactively, or without confirmation with --yes. The records which can be
+decoded are never deleted.
+`
+
+func newReleaseRepairCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewReleaseRepair(cfg)
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "repair RELEASE_NAME",
+		Short: "find and delete the unreadable revision records of a release",
+		Long:  releaseRepairDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			// the records are reported before the deletion is confirmed
+			reported := false
+			client.ConfirmDelete = func(report *action.ReleaseRepairReport) error {
+				writeReleaseRepairReport(out, args[0], report)
+				reported = true
+				if yes {
+					return nil
+				}
+				return confirmCorruptDelete(out, len(report.Corrupt))
+			}
+
+			report, err := client.Run(args[0])
+			if report == nil {
+				return err
+			}
+			if !reported {
+				writeReleaseRepairReport(out, args[0], report)
+				if len(report.Corrupt) > 0 {
+					fmt.Fprintln(out, "Run with --delete-corrupt to delete them.")
+				}
+			}
+			for _, key := range report.Deleted {
+				fmt.Fprintf(out, "Deleted %s\n", key)
+			}
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.DeleteCorrupt, "delete-corrupt", false, "delete the revision records which cannot be decoded")
+	f.BoolVar(&yes, "yes", false, "delete the revision records which cannot be decoded without asking for confirmation")
+
+	return cmd
+}
+
+// confirmCorruptDelete asks the user to confirm the deletion of count release
+// records on an interactive terminal.
+func confirmCorruptDelete(out io.Writer, count int) error {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return errors.New("deleting release records must be confirmed interactively or with --yes")
+	}
+	fmt.Fprintf(out, "Delete %d unreadable release records? [y/N]: ", count)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return errors.New("repair aborted: the deletion was not confirmed")
+	}
+	return nil
+}
+
+// writeReleaseRepairReport writes the readable revisions of a release, and the
+// table of its records which cannot be decoded.
+func writeReleaseRepairReport(out io.Writer, name string, report *action.ReleaseRepairReport) {
+	readable := make([]string, 0, len(report.Readable))
+	for _, v := range report.Readable {
+		readable = append(readable, strconv.Itoa(v))
+	}
+	fmt.Fprintf(out, "RELEASE: %s\n", name)
+	fmt.Fprintf(out, "READABLE REVISIONS: %s\n", strings.Join(readable, ", "))
+	if len(report.Corrupt) == 0 {
+		fmt.Fprintln(out, "No unreadable revision records found.")
+		return
+	}
+
+	fmt.Fprintln(out, "UNREADABLE REVISION RECORDS:")
+	table := uitable.New()
+	table.AddRow("REVISION", "KEY", "NAMESPACE", "ERROR")
+	for _, c := range report.Corrupt {
+		table.AddRow(c.Version, c.Key, c.Namespace, c.Err)
+	}
+	_ = output.EncodeTable(out, table)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"helm.sh/helm/v4/internal/test"
+	release "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage"
+	"helm.sh/helm/v4/pkg/storage/driver"
+)
+
+// corruptStorageFixture stores the revisions 1 and 2 of angry-bird and the
+// revision 1 of smug-pigeon in Secrets, with the record of the revision 2 of
+// angry-bird truncated.
+func corruptStorageFixture(t *testing.T) *storage.Storage {
+	t.Helper()
+	secrets := fake.NewClientset().CoreV1().Secrets("default")
+	store := storage.Init(driver.NewSecrets(secrets))
+	for _, rel := range []*release.Release{
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 1, Status: release.StatusSuperseded, Namespace: "default"}),
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 2, Namespace: "default"}),
+		release.Mock(&release.MockReleaseOptions{Name: "smug-pigeon", Version: 1, Namespace: "default"}),
+	} {
+		require.NoError(t, store.Create(rel))
+	}
+
+	obj, err := secrets.Get(t.Context(), "sh.helm.release.v1.angry-bird.v2", metav1.GetOptions{})
+	require.NoError(t, err)
+	obj.Data["release"] = obj.Data["release"][:12]
+	_, err = secrets.Update(t.Context(), obj, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	return store
+}
+
+func TestReleaseRepairCmd(t *testing.T) {
+	defer resetEnv()()
+
+	_, out, err := executeActionCommandC(corruptStorageFixture(t), "release repair angry-bird")
+	require.NoError(t, err)
+	test.AssertGoldenString(t, out, "output/release-repair.txt")
+
+	_, out, err = executeActionCommandC(corruptStorageFixture(t), "release repair smug-pigeon")
+	require.NoError(t, err)
+	test.AssertGoldenString(t, out, "output/release-repair-healthy.txt")
+
+	_, _, err = executeActionCommandC(corruptStorageFixture(t), "release repair missing-bird")
+	assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+}
+
+func TestReleaseRepairCmd_DeleteCorrupt(t *testing.T) {
+	defer resetEnv()()
+	store := corruptStorageFixture(t)
+
+	_, out, err := executeActionCommandC(store, "release repair angry-bird --delete-corrupt")
+	require.Error(t, err, "the deletion must be confirmed")
+	assert.Contains(t, err.Error(), "confirmed interactively or with --yes")
+	assert.Contains(t, out, "sh.helm.release.v1.angry-bird.v2")
+
+	_, out, err = executeActionCommandC(store, "release repair angry-bird --delete-corrupt --yes")
+	require.NoError(t, err)
+	test.AssertGoldenString(t, out, "output/release-repair-delete.txt")
+
+	_, corrupt, err := store.ListWithCorrupt(func(*release.Release) bool { return true })
+	require.NoError(t, err)
+	assert.Empty(t, corrupt)
+	history, err := store.History("angry-bird")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestListCmd_Corrupt(t *testing.T) {
+	defer resetEnv()()
+
+	_, out, err := executeActionCommandC(corruptStorageFixture(t), "list --all")
+	require.NoError(t, err)
+	assert.Contains(t, out, "angry-bird")
+	assert.Contains(t, out, "smug-pigeon")
+	assert.Contains(t, out, "WARNING: skipped 1 release records which could not be decoded:")
+	assert.Contains(t, out, "sh.helm.release.v1.angry-bird.v2")
+
+	_, out, err = executeActionCommandC(corruptStorageFixture(t), "list --all --output json")
+	require.NoError(t, err)
+	assert.Contains(t, out, "WARNING: skipped 1 release records")
+
+	_, _, err = executeActionCommandC(corruptStorageFixture(t), "list --all --strict")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to decode 1 release records")
+}
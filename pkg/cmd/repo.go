@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/repo"
 )
 
 var repoHelm = `
@@ -52,3 +53,9 @@ func newRepoCmd(out io.Writer) *cobra.Command {
 func isNotExist(err error) bool {
 	return errors.Is(err, fs.ErrNotExist)
 }
+
+// newOCIIndexClient creates the registry client listing the charts of a
+// repository which is an OCI registry.
+func newOCIIndexClient(e *repo.Entry) (repo.OCIIndexClient, error) {
+	return newRegistryClient(e.CertFile, e.KeyFile, e.CAFile, e.InsecureSkipTLSverify, e.PlainHTTP, e.Username, e.Password)
+}
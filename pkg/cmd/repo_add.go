@@ -24,6 +24,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 
 	"helm.sh/helm/v4/pkg/cmd/require"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/registry"
 	"helm.sh/helm/v4/pkg/repo"
 )
 
@@ -59,6 +61,9 @@ type repoAddOptions struct {
 	caFile                string
 	insecureSkipTLSverify bool
 
+	ociCharts []string
+	plainHTTP bool
+
 	repoFile  string
 	repoCache string
 }
@@ -98,6 +103,8 @@ func newRepoAddCmd(out io.Writer) *cobra.Command {
 	f.BoolVar(&o.allowDeprecatedRepos, "allow-deprecated-repos", false, "by default, this command will not allow adding official repos that have been permanently deleted. This disables that behavior")
 	f.BoolVar(&o.passCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
 	f.DurationVar(&o.timeout, "timeout", getter.DefaultHTTPTimeout*time.Second, "time to wait for the index file download to complete")
+	f.StringSliceVar(&o.ociCharts, "oci-charts", nil, "charts indexed when the repository is an OCI registry. By default, every chart of the namespace listed by the registry catalog is indexed")
+	f.BoolVar(&o.plainHTTP, "plain-http", false, "use insecure HTTP connections to reach an OCI registry")
 
 	return cmd
 }
@@ -112,6 +119,15 @@ func (o *repoAddOptions) run(out io.Writer) error {
 		}
 	}
 
+	// Check if the repo name is legal
+	if err := repo.ValidateName(o.name); err != nil {
+		return err
+	}
+
+	if !registry.IsOCI(o.url) && (len(o.ociCharts) > 0 || o.plainHTTP) {
+		return fmt.Errorf("--oci-charts and --plain-http can only be used with an %s:// repository", registry.OCIScheme)
+	}
+
 	// Ensure the file directory exists as it is required for file locking
 	err := os.MkdirAll(filepath.Dir(o.repoFile), os.ModePerm)
 	if err != nil && !os.IsExist(err) {
@@ -178,22 +194,23 @@ func (o *repoAddOptions) run(out io.Writer) error {
 		KeyFile:               o.keyFile,
 		CAFile:                o.caFile,
 		InsecureSkipTLSverify: o.insecureSkipTLSverify,
+		PlainHTTP:             o.plainHTTP,
 	}
-
-	// Check if the repo name is legal
-	if strings.Contains(o.name, "/") {
-		return fmt.Errorf("repository name (%s) contains '/', please specify a different name without '/'", o.name)
+	if len(o.ociCharts) > 0 {
+		c.Charts = o.ociCharts
 	}
 
 	// If the repo exists do one of two things:
 	// 1. If the configuration for the name is the same continue without error
 	// 2. When the config is different require --force-update
-	if !o.forceUpdate && f.Has(o.name) {
+	// With --force-update the repo is always replaced and its index downloaded again.
+	exists := f.Has(o.name)
+	if !o.forceUpdate && exists {
 		existing := f.Get(o.name)
-		if c != *existing {
+		if !reflect.DeepEqual(c, *existing) {
 			// The input coming in for the name is different from what is already
 			// configured. Return an error.
-			return fmt.Errorf("repository name (%s) already exists, please specify a different name", o.name)
+			return fmt.Errorf("repository name (%s) already exists with a different configuration, please specify a different name or use --force-update to replace it", o.name)
 		}
 
 		// The add is idempotent so do nothing
@@ -201,6 +218,11 @@ func (o *repoAddOptions) run(out io.Writer) error {
 		return nil
 	}
 
+	// Adding the same repo under a second name is allowed, but most likely a mistake
+	if other := f.GetByURL(o.url); !exists && other != nil {
+		fmt.Fprintf(out, "WARNING: %q already points to %s, consider using it instead of adding %q\n", other.Name, other.URL, o.name)
+	}
+
 	r, err := repo.NewChartRepository(&c, getter.All(settings, getter.WithTimeout(o.timeout)))
 	if err != nil {
 		return err
@@ -209,6 +231,11 @@ func (o *repoAddOptions) run(out io.Writer) error {
 	if o.repoCache != "" {
 		r.CachePath = o.repoCache
 	}
+	if c.IsOCI() {
+		if r.RegistryClient, err = newOCIIndexClient(&c); err != nil {
+			return err
+		}
+	}
 	if _, err := r.DownloadIndexFile(); err != nil {
 		return fmt.Errorf("looks like %q is not a valid chart repository or cannot be reached: %w", o.url, err)
 	}
@@ -218,6 +245,10 @@ func (o *repoAddOptions) run(out io.Writer) error {
 	if err := f.WriteFile(o.repoFile, 0o600); err != nil {
 		return err
 	}
+	if exists {
+		fmt.Fprintf(out, "%q has been updated in your repositories\n", o.name)
+		return nil
+	}
 	fmt.Fprintf(out, "%q has been added to your repositories\n", o.name)
 	return nil
 }
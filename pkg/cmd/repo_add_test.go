@@ -74,7 +74,7 @@ func TestRepoAddCmd(t *testing.T) {
 		{
 			name:   "add repository second time",
 			cmd:    fmt.Sprintf("repo add test-name %s --repository-config %s --repository-cache %s --force-update", srv2.URL(), repoFile, tmpdir),
-			golden: "output/repo-add.txt",
+			golden: "output/repo-add-updated.txt",
 		},
 	}
 
@@ -134,6 +134,21 @@ func TestRepoAdd(t *testing.T) {
 	}
 }
 
+func TestRepoAddOCIFlagsRequireOCIRepository(t *testing.T) {
+	rootDir := t.TempDir()
+	o := &repoAddOptions{
+		name:      "test-name",
+		url:       "https://charts.example.com",
+		ociCharts: []string{"nginx"},
+		repoFile:  filepath.Join(rootDir, "repositories.yaml"),
+	}
+
+	err := o.run(io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "can only be used with an oci:// repository") {
+		t.Fatalf("expected --oci-charts to be rejected for an HTTP repository, got %v", err)
+	}
+}
+
 func TestRepoAddCheckLegalName(t *testing.T) {
 	ts := repotest.NewTempServer(
 		t,
@@ -166,6 +181,92 @@ func TestRepoAddCheckLegalName(t *testing.T) {
 	}
 }
 
+func TestRepoAddInvalidName(t *testing.T) {
+	for _, name := range []string{"", "test name", "test\tname", strings.Repeat("a", repo.MaxNameLength+1)} {
+		o := &repoAddOptions{
+			name:     name,
+			url:      "https://example.com/charts",
+			repoFile: filepath.Join(t.TempDir(), "repositories.yaml"),
+		}
+		if err := o.run(io.Discard); err == nil {
+			t.Errorf("expected repository name %q to be rejected", name)
+		}
+		if _, err := os.Stat(o.repoFile); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %s not to be written for repository name %q", o.repoFile, name)
+		}
+	}
+}
+
+func TestRepoAddExistingURL(t *testing.T) {
+	ts := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testserver/*.*"),
+	)
+	defer ts.Stop()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	t.Setenv(xdg.CacheHomeEnvVar, rootDir)
+
+	o := &repoAddOptions{name: "test-name", url: ts.URL(), repoFile: repoFile}
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	o = &repoAddOptions{name: "other-name", url: ts.URL() + "/", repoFile: repoFile}
+	if err := o.run(&out); err != nil {
+		t.Fatal(err)
+	}
+	expect := fmt.Sprintf("WARNING: \"test-name\" already points to %s, consider using it instead of adding \"other-name\"", ts.URL())
+	if !strings.Contains(out.String(), expect) {
+		t.Errorf("expected output to contain %q, got %q", expect, out.String())
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Has("test-name") || !f.Has("other-name") {
+		t.Errorf("expected both repositories to be configured, got %v", f.Repositories)
+	}
+}
+
+func TestRepoAddSortsRepositories(t *testing.T) {
+	ts := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testserver/*.*"),
+	)
+	defer ts.Stop()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	t.Setenv(xdg.CacheHomeEnvVar, rootDir)
+
+	// A file written by an older version, not sorted by name
+	legacy := "apiVersion: v1\nrepositories:\n- name: zeta\n  url: https://example.com/zeta\n- name: alpha\n  url: https://example.com/alpha\n"
+	if err := os.WriteFile(repoFile, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &repoAddOptions{name: "mid", url: ts.URL(), repoFile: repoFile}
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range f.Repositories {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "alpha,mid,zeta" {
+		t.Errorf("expected repositories to be sorted by name, got %v", names)
+	}
+}
+
 func TestRepoAddConcurrentGoRoutines(t *testing.T) {
 	const testName = "test-name"
 	repoFile := filepath.Join(t.TempDir(), "repositories.yaml")
@@ -24,6 +24,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/provenance"
 	"helm.sh/helm/v4/pkg/repo"
 )
 
@@ -162,6 +164,69 @@ func TestRepoIndexCmd(t *testing.T) {
 	}
 }
 
+func TestRepoIndexCmdMergeCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	comp := filepath.Join(dir, "compressedchart-0.1.0.tgz")
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", comp); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := provenance.DigestFile(comp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The index to merge has a chart which is not in the directory, the
+	// archive of the directory under another URL, and the same archive again
+	// under a rebuilt version.
+	mergeFrom := filepath.Join(t.TempDir(), "index.yaml")
+	old := repo.NewIndexFile()
+	for _, x := range []struct {
+		md       *chart.Metadata
+		filename string
+		digest   string
+	}{
+		{&chart.Metadata{APIVersion: "v2", Name: "elsewhere", Version: "1.0.0"}, "elsewhere-1.0.0.tgz", "0123456789abcdef"},
+		{&chart.Metadata{APIVersion: "v2", Name: "compressedchart", Version: "0.1.0"}, "old/compressedchart-0.1.0.tgz", digest},
+		{&chart.Metadata{APIVersion: "v2", Name: "compressedchart", Version: "0.1.0+rebuilt"}, "old/compressedchart-0.1.0+rebuilt.tgz", digest},
+	} {
+		if err := old.MustAdd(x.md, x.filename, "http://example.com", x.digest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := old.WriteFile(mergeFrom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--url", "http://example.com", "--merge", mergeFrom})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries) != 2 {
+		t.Errorf("expected 2 entries, got %d: %#v", len(index.Entries), index.Entries)
+	}
+
+	if vs := index.Entries["elsewhere"]; len(vs) != 1 || vs[0].Digest != "0123456789abcdef" {
+		t.Errorf("expected the entry of the merged index to be preserved, got %#v", vs)
+	}
+
+	vs := index.Entries["compressedchart"]
+	if len(vs) != 1 {
+		t.Fatalf("expected 1 version, got %d: %#v", len(vs), vs)
+	}
+	if vs[0].Digest != digest {
+		t.Errorf("expected digest %q, got %q", digest, vs[0].Digest)
+	}
+	if expected := "http://example.com/compressedchart-0.1.0.tgz"; vs[0].URLs[0] != expected {
+		t.Errorf("expected the URL of the indexed archive %q, got %q", expected, vs[0].URLs[0])
+	}
+}
+
 func linkOrCopy(source, target string) error {
 	if err := os.Link(source, target); err != nil {
 		return copyFile(source, target)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,11 +38,19 @@ Information is cached locally, where it is used by commands like 'helm search'.
 
 You can optionally specify a list of repositories you want to update.
 	$ helm repo update <repo_name> ...
+	$ helm repo update --repo <repo_name> --repo <repo_name>
 To update all the repositories, use 'helm repo update'.
+
+The index files are downloaded in parallel. A repository failing to update does
+not stop the others from being updated; the failed repositories are reported
+once all of them are done.
 `
 
 var errNoRepositories = errors.New("no repositories found. You must add one before updating")
 
+// maxConcurrentRepoUpdates is the number of index files downloaded at once.
+const maxConcurrentRepoUpdates = 8
+
 type repoUpdateOptions struct {
 	update    func([]*repo.ChartRepository, io.Writer) error
 	repoFile  string
@@ -65,12 +74,13 @@ func newRepoUpdateCmd(out io.Writer) *cobra.Command {
 		RunE: func(_ *cobra.Command, args []string) error {
 			o.repoFile = settings.RepositoryConfig
 			o.repoCache = settings.RepositoryCache
-			o.names = args
+			o.names = append(o.names, args...)
 			return o.run(out)
 		},
 	}
 
 	f := cmd.Flags()
+	f.StringArrayVar(&o.names, "repo", nil, "name of a repository to update. Can be specified multiple times")
 	f.DurationVar(&o.timeout, "timeout", getter.DefaultHTTPTimeout*time.Second, "time to wait for the index file download to complete")
 
 	return cmd
@@ -106,6 +116,11 @@ func (o *repoUpdateOptions) run(out io.Writer) error {
 			if o.repoCache != "" {
 				r.CachePath = o.repoCache
 			}
+			if cfg.IsOCI() {
+				if r.RegistryClient, err = newOCIIndexClient(cfg); err != nil {
+					return err
+				}
+			}
 			repos = append(repos, r)
 		}
 	}
@@ -115,40 +130,34 @@ func (o *repoUpdateOptions) run(out io.Writer) error {
 
 func updateCharts(repos []*repo.ChartRepository, out io.Writer) error {
 	fmt.Fprintln(out, "Hang tight while we grab the latest from your chart repositories...")
-	var wg sync.WaitGroup
-	failRepoURLChan := make(chan string, len(repos))
+	errs := make([]error, len(repos))
 
-	writeMutex := sync.Mutex{}
-	for _, re := range repos {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRepoUpdates)
+	for i, re := range repos {
 		wg.Add(1)
-		go func(re *repo.ChartRepository) {
+		go func() {
 			defer wg.Done()
-			if _, err := re.DownloadIndexFile(); err != nil {
-				writeMutex.Lock()
-				defer writeMutex.Unlock()
-				fmt.Fprintf(out, "...Unable to get an update from the %q chart repository (%s):\n\t%s\n", re.Config.Name, re.Config.URL, err)
-				failRepoURLChan <- re.Config.URL
-			} else {
-				writeMutex.Lock()
-				defer writeMutex.Unlock()
-				fmt.Fprintf(out, "...Successfully got an update from the %q chart repository\n", re.Config.Name)
-			}
-		}(re)
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			_, errs[i] = re.DownloadIndexFile()
+		}()
 	}
-
-	go func() {
-		wg.Wait()
-		close(failRepoURLChan)
-	}()
+	wg.Wait()
 
 	var repoFailList []string
-	for url := range failRepoURLChan {
-		repoFailList = append(repoFailList, url)
+	for i, re := range repos {
+		if errs[i] != nil {
+			fmt.Fprintf(out, "...Unable to get an update from the %q chart repository (%s):\n\t%s\n", re.Config.Name, re.Config.URL, errs[i])
+			repoFailList = append(repoFailList, fmt.Sprintf("%s (%s)", re.Config.Name, re.Config.URL))
+			continue
+		}
+		fmt.Fprintf(out, "...Successfully got an update from the %q chart repository\n", re.Config.Name)
 	}
 
 	if len(repoFailList) > 0 {
 		return fmt.Errorf("failed to update the following repositories: %s",
-			repoFailList)
+			strings.Join(repoFailList, ", "))
 	}
 
 	fmt.Fprintln(out, "Update Complete. ⎈Happy Helming!⎈")
@@ -156,6 +165,7 @@ func updateCharts(repos []*repo.ChartRepository, out io.Writer) error {
 }
 
 func checkRequestedRepos(requestedRepos []string, validRepos []*repo.Entry) error {
+	var missing []string
 	for _, requestedRepo := range requestedRepos {
 		found := false
 		for _, repo := range validRepos {
@@ -164,11 +174,20 @@ func checkRequestedRepos(requestedRepos []string, validRepos []*repo.Entry) erro
 				break
 			}
 		}
-		if !found {
-			return fmt.Errorf("no repositories found matching '%s'.  Nothing will be updated", requestedRepo)
+		if !found && !slices.Contains(missing, requestedRepo) {
+			missing = append(missing, requestedRepo)
 		}
 	}
-	return nil
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(validRepos))
+	for _, repo := range validRepos {
+		names = append(names, repo.Name)
+	}
+	return fmt.Errorf("no repositories found matching '%s'.  Nothing will be updated. Valid repositories are: %s",
+		strings.Join(missing, "', '"), strings.Join(names, ", "))
 }
 
 func isRepoRequested(repoName string, requestedRepos []string) bool {
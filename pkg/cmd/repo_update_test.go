@@ -19,13 +19,19 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"helm.sh/helm/v4/internal/test/ensure"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/helmpath"
 	"helm.sh/helm/v4/pkg/repo"
 	"helm.sh/helm/v4/pkg/repo/repotest"
 )
@@ -101,6 +107,74 @@ func TestUpdateCmdInvalid(t *testing.T) {
 	}
 }
 
+func TestUpdateCmdInvalidListsValidRepos(t *testing.T) {
+	o := &repoUpdateOptions{
+		update: func([]*repo.ChartRepository, io.Writer) error {
+			t.Fatal("no repository should be updated")
+			return nil
+		},
+		repoFile: "testdata/repositories.yaml",
+		names:    []string{"firstexample", "invalid", "other"},
+	}
+	err := o.run(io.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no repositories found matching 'invalid', 'other'")
+	assert.Contains(t, err.Error(), "Valid repositories are: charts, firstexample, secondexample")
+}
+
+func TestUpdateCmdParallelWithFailure(t *testing.T) {
+	defer resetEnv()()
+	ensure.HelmHome(t)
+
+	index, err := os.ReadFile("testdata/testserver/index.yaml")
+	require.NoError(t, err)
+	indexServer := func() *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write(index)
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	first, second := indexServer(), indexServer()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	repoCache := filepath.Join(rootDir, "cache")
+	f := repo.NewFile()
+	f.Add(
+		&repo.Entry{Name: "first", URL: first.URL},
+		&repo.Entry{Name: "broken", URL: broken.URL},
+		&repo.Entry{Name: "second", URL: second.URL},
+	)
+	require.NoError(t, f.WriteFile(repoFile, 0o644))
+	repoSetup := fmt.Sprintf("--repository-config %s --repository-cache %s", repoFile, repoCache)
+
+	// The failing repository does not stop the others from being updated.
+	_, out, err := executeActionCommand("repo update " + repoSetup)
+	require.Error(t, err)
+	assert.Equal(t, "failed to update the following repositories: broken ("+broken.URL+")", err.Error())
+	assert.Contains(t, out, `...Successfully got an update from the "first" chart repository`)
+	assert.Contains(t, out, `...Unable to get an update from the "broken" chart repository`)
+	assert.Contains(t, out, `...Successfully got an update from the "second" chart repository`)
+	assert.NotContains(t, out, "Update Complete.")
+	for _, name := range []string{"first", "second"} {
+		assert.FileExists(t, filepath.Join(repoCache, helmpath.CacheIndexFile(name)))
+	}
+
+	// Only the repositories given as arguments or with --repo are updated.
+	require.NoError(t, os.RemoveAll(repoCache))
+	_, out, err = executeActionCommand("repo update second --repo first " + repoSetup)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "broken")
+	assert.Contains(t, out, "Update Complete.")
+	assert.FileExists(t, filepath.Join(repoCache, helmpath.CacheIndexFile("first")))
+	assert.FileExists(t, filepath.Join(repoCache, helmpath.CacheIndexFile("second")))
+}
+
 func TestUpdateCustomCacheCmd(t *testing.T) {
 	rootDir := t.TempDir()
 	cachePath := filepath.Join(rootDir, "updcustomcache")
@@ -35,6 +35,10 @@ The first argument of the rollback command is the name of a release, and the
 second is a revision (version) number. If this argument is omitted or set to
 0, it will roll back to the previous release.
 
+With '--values-only', the currently deployed chart is kept and rendered again
+with the values of the given revision, instead of restoring that revision's
+chart and manifest.
+
 To see revision numbers, run 'helm history RELEASE'.
 `
 
@@ -79,10 +83,16 @@ func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&client.DryRun, "dry-run", false, "simulate a rollback")
 	f.BoolVar(&client.Force, "force", false, "force resource update through delete/recreate if needed")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during rollback")
+	addSkipHooksFlag(f, &client.SkipHookEvents)
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this rollback when rollback fails")
 	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
+	f.BoolVar(&client.Atomic, "atomic", false, "if set, roll forward to the previously deployed revision in case of a failed rollback. The --wait flag will be set automatically to \"watcher\" if --atomic is used")
+	f.BoolVar(&client.ValuesOnly, "values-only", false, "restore only the values of the given revision, keeping the currently deployed chart")
+	addPolicyOverrideFlag(f, &client.PolicyOverrides)
+	addOverridePinFlag(f, &client.OverridePin)
+	addRequireRecordFlag(f, cfg)
 	AddWaitFlag(cmd, &client.WaitStrategy)
 
 	return cmd
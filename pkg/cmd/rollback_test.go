@@ -164,3 +164,36 @@ func TestRollbackWithLabels(t *testing.T) {
 		t.Errorf("Expected {%v}, got {%v}", labels1, updatedRel.Labels)
 	}
 }
+
+func TestRollbackValuesOnly(t *testing.T) {
+	releaseName := "funny-bunny-values"
+	storage := storageFixture()
+	rel1 := release.Mock(&release.MockReleaseOptions{Name: releaseName, Version: 1, Status: release.StatusSuperseded})
+	rel1.Config = map[string]interface{}{"name": "old"}
+	rel2 := release.Mock(&release.MockReleaseOptions{Name: releaseName, Version: 2})
+	rel2.Chart.Metadata.Version = "0.2.0"
+	for _, rel := range []*release.Release{rel1, rel2} {
+		if err := storage.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, err := executeActionCommandC(storage, fmt.Sprintf("rollback %s 1 --values-only", releaseName))
+	if err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+	updatedRel, err := storage.Get(releaseName, 3)
+	if err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+
+	if updatedRel.Chart.Metadata.Version != "0.2.0" {
+		t.Errorf("Expected chart version 0.2.0, got %s", updatedRel.Chart.Metadata.Version)
+	}
+	if !reflect.DeepEqual(updatedRel.Config, rel1.Config) {
+		t.Errorf("Expected values {%v}, got {%v}", rel1.Config, updatedRel.Config)
+	}
+	if updatedRel.Info.Description != "Values rollback to revision 1" {
+		t.Errorf("unexpected description %q", updatedRel.Info.Description)
+	}
+}
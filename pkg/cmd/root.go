@@ -57,16 +57,26 @@ Environment variables:
 | Name                               | Description                                                                                                |
 |------------------------------------|------------------------------------------------------------------------------------------------------------|
 | $HELM_CACHE_HOME                   | set an alternative location for storing cached files.                                                      |
+| $HELM_CACHE_MAX_SIZE               | set the size the repository cache is pruned to after downloads, e.g. 1Gi (default unlimited)               |
 | $HELM_CONFIG_HOME                  | set an alternative location for storing Helm configuration.                                                |
 | $HELM_DATA_HOME                    | set an alternative location for storing Helm data.                                                         |
 | $HELM_DEBUG                        | indicate whether or not Helm is running in Debug mode                                                      |
+| $HELM_DOWNLOAD_RETRIES             | set the number of times a failed chart download is retried (default 0)                                     |
 | $HELM_DRIVER                       | set the backend storage driver. Values are: configmap, secret, memory, sql.                                |
 | $HELM_DRIVER_SQL_CONNECTION_STRING | set the connection string the SQL storage driver should use.                                               |
+| $HELM_DRIVER_SQL_MAX_OPEN_CONNS    | set the maximum number of connections the SQL storage driver opens (default unlimited)                     |
+| $HELM_DRIVER_SQL_MAX_IDLE_CONNS    | set the maximum number of idle connections the SQL storage driver keeps open (default 2)                   |
+| $HELM_DRIVER_SQL_CONN_MAX_LIFETIME | set how long the SQL storage driver keeps a connection open, e.g. 5m (default unlimited)                   |
+| $HELM_DRIVER_SQL_WRITE_RETRIES     | set the number of times a write failing on a serialization failure or deadlock is retried (default 3)      |
 | $HELM_MAX_HISTORY                  | set the maximum number of helm release history.                                                            |
 | $HELM_NAMESPACE                    | set the namespace used for the helm operations.                                                            |
 | $HELM_NO_PLUGINS                   | disable plugins. Set HELM_NO_PLUGINS=1 to disable plugins.                                                 |
 | $HELM_PLUGINS                      | set the path to the plugins directory                                                                      |
+| $HELM_RELEASE_WEBHOOK_URL          | set an endpoint every install, upgrade, rollback and uninstall is posted to as JSON.                       |
+| $HELM_RELEASE_WEBHOOK_SECRET       | set the key used to sign the payloads posted to $HELM_RELEASE_WEBHOOK_URL with HMAC-SHA256.                |
 | $HELM_REGISTRY_CONFIG              | set the path to the registry config file.                                                                  |
+| $HELM_REGISTRY_MIRRORS_CONFIG      | set the path to the file mapping registries to the mirrors OCI charts are pulled from.                     |
+| $HELM_REPOSITORY_BEARER_TOKEN      | set the bearer token sent to the host of the chart downloaded, see --bearer-token.                         |
 | $HELM_REPOSITORY_CACHE             | set the path to the repository cache directory                                                             |
 | $HELM_REPOSITORY_CONFIG            | set the path to the repositories file.                                                                     |
 | $KUBECONFIG                        | set an alternative Kubernetes configuration file (default "~/.kube/config")                                |
@@ -106,6 +116,12 @@ func NewRootCmd(out io.Writer, args []string, logSetup func(bool)) (*cobra.Comma
 	}
 	cobra.OnInitialize(func() {
 		helmDriver := os.Getenv("HELM_DRIVER")
+		actionConfig.FieldManager = settings.FieldManager
+		// 'helm storage migrate' lists or applies the migrations of the SQL
+		// storage itself.
+		if c, _, err := cmd.Find(args); err == nil && c.CommandPath() == "helm storage migrate" {
+			actionConfig.DeferStorageMigration = true
+		}
 		if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), helmDriver); err != nil {
 			log.Fatal(err)
 		}
@@ -113,6 +129,9 @@ func NewRootCmd(out io.Writer, args []string, logSetup func(bool)) (*cobra.Comma
 			loadReleasesInMemory(actionConfig)
 		}
 		actionConfig.SetHookOutputFunc(hookOutputWriter)
+		if settings.ReleaseWebhookURL != "" {
+			actionConfig.ReleaseRecorder = action.NewWebhookRecorder(settings.ReleaseWebhookURL, settings.ReleaseWebhookSecret)
+		}
 	})
 	return cmd, nil
 }
@@ -224,19 +243,27 @@ func newRootCmdWithConfig(actionConfig *action.Configuration, out io.Writer, arg
 		newLintCmd(out),
 		newPackageCmd(out),
 		newRepoCmd(out),
+		newCacheCmd(out),
 		newSearchCmd(out),
 		newVerifyCmd(out),
 
 		// release commands
+		newAdoptCmd(actionConfig, out),
+		newChangelogCmd(actionConfig, out),
 		newGetCmd(actionConfig, out),
 		newHistoryCmd(actionConfig, out),
 		newInstallCmd(actionConfig, out),
 		newListCmd(actionConfig, out),
+		newPinCmd(actionConfig, out),
+		newRecoverCmd(actionConfig, out),
+		newReleaseCmd(actionConfig, out),
 		newReleaseTestCmd(actionConfig, out),
 		newRollbackCmd(actionConfig, out),
 		newStatusCmd(actionConfig, out),
+		newStorageCmd(actionConfig, out),
 		newTemplateCmd(actionConfig, out),
 		newUninstallCmd(actionConfig, out),
+		newUnpinCmd(actionConfig, out),
 		newUpgradeCmd(actionConfig, out),
 
 		newCompletionCmd(out),
@@ -355,22 +382,23 @@ func checkForExpiredRepos(repofile string) {
 
 func newRegistryClient(
 	certFile, keyFile, caFile string, insecureSkipTLSverify, plainHTTP bool, username, password string,
+	extraOpts ...registry.ClientOption,
 ) (*registry.Client, error) {
 	if certFile != "" && keyFile != "" || caFile != "" || insecureSkipTLSverify {
-		registryClient, err := newRegistryClientWithTLS(certFile, keyFile, caFile, insecureSkipTLSverify, username, password)
+		registryClient, err := newRegistryClientWithTLS(certFile, keyFile, caFile, insecureSkipTLSverify, username, password, extraOpts...)
 		if err != nil {
 			return nil, err
 		}
 		return registryClient, nil
 	}
-	registryClient, err := newDefaultRegistryClient(plainHTTP, username, password)
+	registryClient, err := newDefaultRegistryClient(plainHTTP, username, password, extraOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return registryClient, nil
 }
 
-func newDefaultRegistryClient(plainHTTP bool, username, password string) (*registry.Client, error) {
+func newDefaultRegistryClient(plainHTTP bool, username, password string, extraOpts ...registry.ClientOption) (*registry.Client, error) {
 	opts := []registry.ClientOption{
 		registry.ClientOptDebug(settings.Debug),
 		registry.ClientOptEnableCache(true),
@@ -381,6 +409,12 @@ func newDefaultRegistryClient(plainHTTP bool, username, password string) (*regis
 	if plainHTTP {
 		opts = append(opts, registry.ClientOptPlainHTTP())
 	}
+	mirrors, err := registry.LoadMirrors(settings.RegistryMirrorsConfig)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, registry.ClientOptMirrors(mirrors))
+	opts = append(opts, extraOpts...)
 
 	// Create a new registry client
 	registryClient, err := registry.NewClient(opts...)
@@ -392,6 +426,7 @@ func newDefaultRegistryClient(plainHTTP bool, username, password string) (*regis
 
 func newRegistryClientWithTLS(
 	certFile, keyFile, caFile string, insecureSkipTLSverify bool, username, password string,
+	extraOpts ...registry.ClientOption,
 ) (*registry.Client, error) {
 	tlsConf, err := tlsutil.NewTLSConfig(
 		tlsutil.WithInsecureSkipVerify(insecureSkipTLSverify),
@@ -402,9 +437,13 @@ func newRegistryClientWithTLS(
 	if err != nil {
 		return nil, fmt.Errorf("can't create TLS config for client: %w", err)
 	}
+	mirrors, err := registry.LoadMirrors(settings.RegistryMirrorsConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a new registry client
-	registryClient, err := registry.NewClient(
+	opts := []registry.ClientOption{
 		registry.ClientOptDebug(settings.Debug),
 		registry.ClientOptEnableCache(true),
 		registry.ClientOptWriter(os.Stderr),
@@ -416,7 +455,9 @@ func newRegistryClientWithTLS(
 			},
 		}),
 		registry.ClientOptBasicAuth(username, password),
-	)
+		registry.ClientOptMirrors(mirrors),
+	}
+	registryClient, err := registry.NewClient(append(opts, extraOpts...)...)
 	if err != nil {
 		return nil, err
 	}
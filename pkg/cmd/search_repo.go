@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/gosuri/uitable"
@@ -57,6 +58,10 @@ Examples:
     # Search for the latest stable release for nginx-ingress with a major version of 1
     $ helm search repo nginx-ingress --version ^1.0.0
 
+The index of a repository which is an OCI registry is built by listing the
+tags of its charts. It is cached, and built again once older than
+--oci-index-ttl.
+
 Repositories are managed with 'helm repo' commands.
 `
 
@@ -73,6 +78,7 @@ type searchRepoOptions struct {
 	repoCacheDir   string
 	outputFormat   output.Format
 	failOnNoResult bool
+	ociIndexTTL    time.Duration
 }
 
 func newSearchRepoCmd(out io.Writer) *cobra.Command {
@@ -96,6 +102,7 @@ func newSearchRepoCmd(out io.Writer) *cobra.Command {
 	f.StringVar(&o.version, "version", "", "search using semantic versioning constraints on repositories you have added")
 	f.UintVar(&o.maxColWidth, "max-col-width", 50, "maximum column width for output table")
 	f.BoolVar(&o.failOnNoResult, "fail-on-no-result", false, "search fails if no results are found")
+	f.DurationVar(&o.ociIndexTTL, "oci-index-ttl", repo.DefaultOCIIndexTTL, "time the cached index of an OCI registry is used before listing the registry again")
 
 	bindOutputFlag(cmd, &o.outputFormat)
 
@@ -187,8 +194,7 @@ func (o *searchRepoOptions) buildIndex() (*search.Index, error) {
 	i := search.NewIndex()
 	for _, re := range rf.Repositories {
 		n := re.Name
-		f := filepath.Join(o.repoCacheDir, helmpath.CacheIndexFile(n))
-		ind, err := repo.LoadIndexFile(f)
+		ind, err := o.loadRepoIndex(re)
 		if err != nil {
 			slog.Warn("repo is corrupt or missing", "repo", n, slog.Any("error", err))
 			continue
@@ -199,6 +205,19 @@ func (o *searchRepoOptions) buildIndex() (*search.Index, error) {
 	return i, nil
 }
 
+// loadRepoIndex loads the cached index of a repository. The index of an OCI
+// registry is built again when the cached one is too old.
+func (o *searchRepoOptions) loadRepoIndex(re *repo.Entry) (*repo.IndexFile, error) {
+	if !re.IsOCI() {
+		return repo.LoadIndexFile(filepath.Join(o.repoCacheDir, helmpath.CacheIndexFile(re.Name)))
+	}
+	client, err := newOCIIndexClient(re)
+	if err != nil {
+		return nil, err
+	}
+	return repo.LoadOCIIndex(re, client, o.repoCacheDir, o.ociIndexTTL)
+}
+
 type repoChartElement struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
@@ -17,7 +17,14 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/repo"
+	"helm.sh/helm/v4/pkg/repo/repotest"
 )
 
 func TestSearchRepositoriesCmd(t *testing.T) {
@@ -98,6 +105,78 @@ func TestSearchRepositoriesCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestSearchRepoOCI(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testcharts/*.tgz*"),
+	)
+	defer srv.Stop()
+
+	dir := srv.Root()
+	ociSrv, err := repotest.NewOCIServer(t, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSrv.Run(t)
+
+	repoFile := filepath.Join(dir, "repositories.yaml")
+	repoCache := filepath.Join(dir, "repository")
+	flags := fmt.Sprintf("--repository-config %s --repository-cache %s", repoFile, repoCache)
+
+	_, out, err := executeActionCommand(fmt.Sprintf("repo add oci oci://%s/u/ocitestuser --username %s --password %s --plain-http %s",
+		ociSrv.RegistryURL, ociSrv.TestUsername, ociSrv.TestPassword, flags))
+	if err != nil {
+		t.Fatalf("unexpected error adding OCI repository: %s\n%s", err, out)
+	}
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := f.Get("oci"); e == nil || !e.IsOCI() || !e.PlainHTTP {
+		t.Fatalf("expected an OCI repository using plain HTTP, got %+v", e)
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(repoCache, helmpath.CacheIndexFile("oci")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.Has("oci-dependent-chart", "0.1.0") {
+		t.Fatalf("expected the index of the OCI repository to have oci-dependent-chart 0.1.0, got %v", index.Entries)
+	}
+
+	tests := []struct {
+		name   string
+		args   string
+		expect string
+	}{{
+		name:   "search by name",
+		args:   "oci-dependent",
+		expect: "oci/oci-dependent-chart\t0.1.0",
+	}, {
+		name:   "search with a matching version constraint",
+		args:   "oci-dependent --version '^0.1.0'",
+		expect: "oci/oci-dependent-chart\t0.1.0",
+	}, {
+		name:   "search with a version constraint matching nothing",
+		args:   "oci-dependent --version '>=1.0.0'",
+		expect: "No results found",
+	}, {
+		name:   "search building the index again",
+		args:   "oci-dependent --oci-index-ttl 0s",
+		expect: "oci/oci-dependent-chart\t0.1.0",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := executeActionCommand(fmt.Sprintf("search repo %s %s", tt.args, flags))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, tt.expect) {
+				t.Errorf("expected output to contain %q, got %q", tt.expect, out)
+			}
+		})
+	}
+}
+
 func TestSearchRepoOutputCompletion(t *testing.T) {
 	outputFlagCompletionTest(t, "search repo")
 }
@@ -17,15 +17,23 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"helm.sh/helm/v4/pkg/action"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/getter"
 )
 
 const showDesc = `
@@ -40,11 +48,28 @@ This command inspects a chart (directory, file, or URL) and displays all its con
 const showValuesDesc = `
 This command inspects a chart (directory, file, or URL) and displays the contents
 of the values.yaml file
+
+With '--all-subcharts', the values.yaml files of the bundled subcharts follow,
+recursively, in a single document: the values of each subchart are indented
+under the key its parent passes them down under, which is the alias of its
+dependency if it has one, and preceded by a comment giving the subchart and its
+version. The subcharts disabled by the conditions or tags of their dependencies
+are shown too, but marked as such. The values of a subchart which its parent
+already sets are commented out. The command fails if the keys of subcharts
+collide with each other, with 'global', or with a value of their parent which
+is not a map.
 `
 
 const showChartDesc = `
 This command inspects a chart (directory, file, or URL) and displays the contents
 of the Chart.yaml file
+
+With '--verify-links', the links of the chart are verified instead: the home,
+sources, icon and maintainers' URLs must be http or https URLs, the maintainers'
+email addresses must be valid, and the license in the 'artifacthub.io/license'
+annotation, if any, must be a valid SPDX expression. The URLs are only requested,
+to check that they are reachable, with '--probe'. The command fails if any link
+is invalid or unreachable.
 `
 
 const readmeChartDesc = `
@@ -54,7 +79,12 @@ of the README file
 
 const showCRDsDesc = `
 This command inspects a chart (directory, file, or URL) and displays the contents
-of the CustomResourceDefinition files
+of the CustomResourceDefinition files of the chart and of its subcharts, each
+preceded by a '# Source:' comment giving its file.
+
+When values are given with '--values' or '--set' and the like, the conditions
+and tags of the dependencies are evaluated with them, and the CRDs of the
+disabled subcharts are left out.
 `
 
 func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -105,6 +135,9 @@ func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: validArgsFunc,
 		RunE: func(_ *cobra.Command, args []string) error {
 			client.OutputFormat = action.ShowValues
+			if client.AllSubcharts && client.JSONPathTemplate != "" {
+				return errors.New("--all-subcharts cannot be used with --jsonpath")
+			}
 			err := addRegistryClient(client)
 			if err != nil {
 				return err
@@ -118,6 +151,8 @@ func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		},
 	}
 
+	var verifyLinks, probe bool
+	var outfmt output.Format
 	chartSubCmd := &cobra.Command{
 		Use:               "chart [CHART]",
 		Short:             "show the chart's definition",
@@ -126,10 +161,17 @@ func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: validArgsFunc,
 		RunE: func(_ *cobra.Command, args []string) error {
 			client.OutputFormat = action.ShowChart
+			if probe && !verifyLinks {
+				return errors.New("--probe can only be used with --verify-links")
+			}
 			err := addRegistryClient(client)
 			if err != nil {
 				return err
 			}
+			if verifyLinks {
+				opts := chartutil.VerifyLinksOptions{Probe: probe}
+				return runShowVerifyLinks(args, client, opts, out, outfmt)
+			}
 			output, err := runShow(args, client)
 			if err != nil {
 				return err
@@ -160,18 +202,24 @@ func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		},
 	}
 
+	valueOpts := &values.Options{}
 	crdsSubCmd := &cobra.Command{
 		Use:               "crds [CHART]",
 		Short:             "show the chart's CRDs",
 		Long:              showCRDsDesc,
 		Args:              require.ExactArgs(1),
 		ValidArgsFunction: validArgsFunc,
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			client.OutputFormat = action.ShowCRDs
 			err := addRegistryClient(client)
 			if err != nil {
 				return err
 			}
+			if valuesSupplied(cmd.Flags()) {
+				if client.Values, err = valueOpts.MergeValues(getter.All(settings)); err != nil {
+					return err
+				}
+			}
 			output, err := runShow(args, client)
 			if err != nil {
 				return err
@@ -181,6 +229,12 @@ func newShowCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		},
 	}
 
+	f := chartSubCmd.Flags()
+	f.BoolVar(&verifyLinks, "verify-links", false, "verify the links, maintainers' email addresses and license of the chart instead of showing its definition")
+	f.BoolVar(&probe, "probe", false, "with --verify-links, request the URLs of the chart to check that they are reachable")
+	bindOutputFlag(chartSubCmd, &outfmt)
+	addValueOptionsFlags(crdsSubCmd.Flags(), valueOpts)
+
 	cmds := []*cobra.Command{all, readmeSubCmd, valuesSubCmd, chartSubCmd, crdsSubCmd}
 	for _, subCmd := range cmds {
 		addShowFlags(subCmd, client)
@@ -196,6 +250,7 @@ func addShowFlags(subCmd *cobra.Command, client *action.Show) {
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	if subCmd.Name() == "values" {
 		f.StringVar(&client.JSONPathTemplate, "jsonpath", "", "supply a JSONPath expression to filter the output")
+		f.BoolVar(&client.AllSubcharts, "all-subcharts", false, "also show the values of the bundled subcharts, recursively, each one under its key")
 	}
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 
@@ -211,18 +266,79 @@ func addShowFlags(subCmd *cobra.Command, client *action.Show) {
 	}
 }
 
+// valuesSupplied reports whether values were given with any of the flags of
+// addValueOptionsFlags.
+func valuesSupplied(f *pflag.FlagSet) bool {
+	for _, name := range []string{"values", "set", "set-string", "set-file", "set-json", "set-literal", "values-from"} {
+		if f.Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func runShow(args []string, client *action.Show) (string, error) {
+	cp, err := locateShowChart(args, client)
+	if err != nil {
+		return "", err
+	}
+	return client.Run(cp)
+}
+
+func runShowVerifyLinks(args []string, client *action.Show, opts chartutil.VerifyLinksOptions, out io.Writer, outfmt output.Format) error {
+	cp, err := locateShowChart(args, client)
+	if err != nil {
+		return err
+	}
+	checks, err := client.VerifyLinks(context.Background(), cp, opts)
+	if err != nil {
+		return err
+	}
+	if err := outfmt.Write(out, &linkChecksWriter{checks}); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d links of the chart failed the verification", failed, len(checks))
+	}
+	return nil
+}
+
+func locateShowChart(args []string, client *action.Show) (string, error) {
 	slog.Debug("original chart version", "version", client.Version)
 	if client.Version == "" && client.Devel {
 		slog.Debug("setting version to >0.0.0-0")
 		client.Version = ">0.0.0-0"
 	}
 
-	cp, err := client.LocateChart(args[0], settings)
-	if err != nil {
-		return "", err
+	return client.LocateChart(args[0], settings)
+}
+
+type linkChecksWriter struct {
+	checks []chartutil.LinkCheck
+}
+
+func (w *linkChecksWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("FIELD", "VALUE", "STATUS", "MESSAGE")
+	for _, check := range w.checks {
+		table.AddRow(check.Field, check.Value, check.Status, check.Message)
 	}
-	return client.Run(cp)
+	return output.EncodeTable(out, table)
+}
+
+func (w *linkChecksWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.checks)
+}
+
+func (w *linkChecksWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.checks)
 }
 
 func addRegistryClient(client *action.Show) error {
@@ -153,3 +153,54 @@ func TestShowValuesFileCompletion(t *testing.T) {
 func TestShowCRDsFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "show crds", true)
 }
+
+func TestShowChartVerifyLinks(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "verify the links of a chart",
+		cmd:    "show chart --verify-links testdata/testcharts/alpine",
+		golden: "output/show-chart-verify-links.txt",
+	}, {
+		name:      "verify the invalid links of a chart",
+		cmd:       "show chart --verify-links testdata/testcharts/chart-with-bad-links",
+		golden:    "output/show-chart-verify-links-invalid.txt",
+		wantError: true,
+	}, {
+		name:      "verify the invalid links of a chart in JSON",
+		cmd:       "show chart --verify-links testdata/testcharts/chart-with-bad-links -o json",
+		golden:    "output/show-chart-verify-links-invalid.json",
+		wantError: true,
+	}, {
+		name:      "probe without verifying the links",
+		cmd:       "show chart --probe testdata/testcharts/alpine",
+		golden:    "output/show-chart-probe-without-verify-links.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestShowCRDs(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "show the CRDs of a chart and its subcharts",
+		cmd:    "show crds testdata/testcharts/chart-with-conditional-crds",
+		golden: "output/show-crds.txt",
+	}, {
+		name:   "show the CRDs of a chart without those of its disabled subcharts",
+		cmd:    "show crds testdata/testcharts/chart-with-conditional-crds --set monitoring.enabled=false",
+		golden: "output/show-crds-disabled-subchart.txt",
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestShowValuesAllSubcharts(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "show the values of a chart and of its subcharts",
+		cmd:    "show values testdata/testcharts/chart-with-subchart-values --all-subcharts",
+		golden: "output/show-values-all-subcharts.txt",
+	}, {
+		name:      "show the values of the subcharts with a jsonpath",
+		cmd:       "show values testdata/testcharts/chart-with-subchart-values --all-subcharts --jsonpath {.global}",
+		golden:    "output/show-values-all-subcharts-jsonpath.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
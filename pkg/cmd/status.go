@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 
 	"k8s.io/kubectl/pkg/cmd/get"
@@ -32,6 +34,7 @@ import (
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/kube"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -45,6 +48,7 @@ The status consists of:
 - revision of the release
 - description of the release (can be completion message or error message)
 - list of resources that this release consists of
+- live state of each resource (Ready, NotReady or Missing), with --show-resources
 - details on last test suite run, if applicable
 - additional notes provided by the chart
 `
@@ -92,6 +96,7 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f := cmd.Flags()
 
 	f.IntVar(&client.Version, "revision", 0, "if set, display the status of the named release with revision")
+	f.BoolVar(&client.ShowResources, "show-resources", false, "query the cluster for the live state of each resource of the release")
 
 	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
@@ -114,14 +119,30 @@ type statusPrinter struct {
 	showMetadata bool
 	hideNotes    bool
 	noColor      bool
+	// notesChange is set when the notes were compared with the previous revision
+	notesChange *notesChange
+}
+
+// releaseWithNotesChange adds the result of a notes comparison to the
+// structured output of a release.
+type releaseWithNotesChange struct {
+	*release.Release
+	NotesChanged bool `json:"notesChanged"`
+}
+
+func (s statusPrinter) object() interface{} {
+	if s.notesChange == nil || s.release == nil {
+		return s.release
+	}
+	return releaseWithNotesChange{Release: s.release, NotesChanged: s.notesChange.changed}
 }
 
 func (s statusPrinter) WriteJSON(out io.Writer) error {
-	return output.EncodeJSON(out, s.release)
+	return output.EncodeJSON(out, s.object())
 }
 
 func (s statusPrinter) WriteYAML(out io.Writer) error {
-	return output.EncodeYAML(out, s.release)
+	return output.EncodeYAML(out, s.object())
 }
 
 func (s statusPrinter) WriteTable(out io.Writer) error {
@@ -169,6 +190,41 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 		_, _ = fmt.Fprintf(out, "RESOURCES:\n%s\n", buf.String())
 	}
 
+	if len(s.release.Info.Pruned) > 0 {
+		_, _ = fmt.Fprintln(out, "PRUNED RESOURCES:")
+		for _, r := range s.release.Info.Pruned {
+			_, _ = fmt.Fprintf(out, "  %s\n", r)
+		}
+	}
+
+	if len(s.release.Info.ValuesMigrations) > 0 {
+		_, _ = fmt.Fprintln(out, "VALUES MIGRATIONS:")
+		for _, change := range s.release.Info.ValuesMigrations {
+			_, _ = fmt.Fprintf(out, "  %s\n", change)
+		}
+	}
+
+	if len(s.release.Info.ChangedTemplates) > 0 {
+		_, _ = fmt.Fprintln(out, "CHANGED TEMPLATES:")
+		for _, path := range s.release.Info.ChangedTemplates {
+			_, _ = fmt.Fprintf(out, "  %s\n", path)
+		}
+	}
+
+	if len(s.release.Info.PrunedFields) > 0 {
+		_, _ = fmt.Fprintln(out, "PRUNED FIELDS:")
+		for _, r := range s.release.Info.PrunedFields {
+			_, _ = fmt.Fprintf(out, "  %s/%s: %s\n", r.Kind, r.Name, strings.Join(r.Paths, ", "))
+		}
+	}
+
+	if len(s.release.Info.ResourceStatuses) > 0 {
+		_, _ = fmt.Fprintln(out, "RESOURCE STATUS:")
+		if err := writeResourceStatuses(out, s.release.Info.ResourceStatuses); err != nil {
+			return err
+		}
+	}
+
 	executions := executionsByHookEvent(s.release)
 	if tests, ok := executions[release.HookTest]; !ok || len(tests) == 0 {
 		_, _ = fmt.Fprintln(out, "TEST SUITE: None")
@@ -186,6 +242,12 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 			)
 		}
 	}
+	for _, h := range executions[release.HookTestFixture] {
+		if h.LastRun.StartedAt.IsZero() {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "TEST FIXTURE:   %s/%s\nPhase:          %s\n", h.Kind, h.Name, h.LastRun.Phase)
+	}
 
 	if s.debug {
 		_, _ = fmt.Fprintln(out, "USER-SUPPLIED VALUES:")
@@ -219,9 +281,57 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 	}
 
 	// Hide notes from output - option in install and upgrades
+	if !s.hideNotes && s.notesChange != nil && s.notesChange.changed {
+		_, _ = fmt.Fprintf(out, "NOTES CHANGED: the notes differ from revision %d\n", s.notesChange.previousRevision)
+		if s.notesChange.diff != "" {
+			_, _ = fmt.Fprintf(out, "NOTES DIFF:\n%s", s.notesChange.diff)
+		}
+	}
 	if !s.hideNotes && len(s.release.Info.Notes) > 0 {
 		_, _ = fmt.Fprintf(out, "NOTES:\n%s\n", strings.TrimSpace(s.release.Info.Notes))
 	}
+	// The notes of the chart a failed upgrade did not deploy, unless they
+	// were already shown as its notes
+	if !s.hideNotes && len(s.release.Info.PendingNotes) > 0 && s.release.Info.PendingNotes != s.release.Info.Notes {
+		_, _ = fmt.Fprintf(out, "PENDING NOTES (not deployed):\n%s\n", strings.TrimSpace(s.release.Info.PendingNotes))
+	}
+	return nil
+}
+
+// writeResourceStatuses writes the live state of resources grouped by kind,
+// followed by a summary line.
+func writeResourceStatuses(out io.Writer, statuses []release.ResourceStatus) error {
+	byKind := make(map[string][]release.ResourceStatus)
+	var kinds []string
+	counts := make(map[string]int)
+	for _, st := range statuses {
+		if _, ok := byKind[st.Kind]; !ok {
+			kinds = append(kinds, st.Kind)
+		}
+		byKind[st.Kind] = append(byKind[st.Kind], st)
+		counts[st.State]++
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		_, _ = fmt.Fprintf(out, "==> %s\n", kind)
+		tbl := uitable.New()
+		tbl.AddRow("NAME", "NAMESPACE", "STATE", "REPLICAS")
+		for _, st := range byKind[kind] {
+			replicas := ""
+			if st.ReadyReplicas != nil && st.DesiredReplicas != nil {
+				replicas = fmt.Sprintf("%d/%d", *st.ReadyReplicas, *st.DesiredReplicas)
+			}
+			tbl.AddRow(st.Name, st.Namespace, st.State, replicas)
+		}
+		if err := output.EncodeTable(out, tbl); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out)
+	}
+
+	_, _ = fmt.Fprintf(out, "%d resources: %d ready, %d not ready, %d missing\n\n",
+		len(statuses), counts[string(kube.ResourceReady)], counts[string(kube.ResourceNotReady)], counts[string(kube.ResourceMissing)])
 	return nil
 }
 
@@ -17,9 +17,14 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/internal/test"
+	"helm.sh/helm/v4/pkg/action"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	release "helm.sh/helm/v4/pkg/release/v1"
 	helmtime "helm.sh/helm/v4/pkg/time"
@@ -52,6 +57,34 @@ func TestStatusCmd(t *testing.T) {
 			Status:      release.StatusDeployed,
 			Description: "Mock description",
 		}),
+	}, {
+		name:   "get status of a deployed release with pruned resources",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-pruned.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+			Pruned: []string{"ConfigMap/second", "ConfigMap/third"},
+		}),
+	}, {
+		name:   "get status of a deployed release with pruned fields",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-pruned-fields.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+			PrunedFields: []release.PrunedFields{{
+				Kind:  "Deployment",
+				Name:  "web",
+				Paths: []string{"spec.template.spec.containers[0].resizePolicy", "spec.template.spec.hostUsers"},
+			}},
+		}),
+	}, {
+		name:   "get status of a deployed release with changed templates",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-changed-templates.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status:           release.StatusDeployed,
+			ChangedTemplates: []string{"web/templates/configmap.yaml", "web/templates/deployment.yaml"},
+		}),
 	}, {
 		name:   "get status of a deployed release with notes",
 		cmd:    "status flummoxed-chickadee",
@@ -60,6 +93,15 @@ func TestStatusCmd(t *testing.T) {
 			Status: release.StatusDeployed,
 			Notes:  "release notes",
 		}),
+	}, {
+		name:   "get status of a failed release with pending notes",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-pending-notes.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status:       release.StatusFailed,
+			Notes:        action.PreservedNotesMarker + "release notes",
+			PendingNotes: "new release notes",
+		}),
 	}, {
 		name:   "get status of a deployed release with notes in json",
 		cmd:    "status flummoxed-chickadee -o json",
@@ -86,6 +128,13 @@ func TestStatusCmd(t *testing.T) {
 				Status: release.StatusDeployed,
 			},
 		),
+	}, {
+		name:   "get status of a deployed release with the live state of its resources",
+		cmd:    "status flummoxed-chickadee --show-resources",
+		golden: "output/status.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+		}),
 	}, {
 		name:   "get status of a deployed release with test suite",
 		cmd:    "status flummoxed-chickadee",
@@ -116,6 +165,16 @@ func TestStatusCmd(t *testing.T) {
 					Phase:       release.HookPhaseFailed,
 				},
 			},
+			&release.Hook{
+				Name:   "test-credentials",
+				Kind:   "Secret",
+				Events: []release.HookEvent{release.HookTestFixture},
+				LastRun: release.HookExecution{
+					StartedAt:   mustParseTime("2006-01-02T15:04:00Z"),
+					CompletedAt: mustParseTime("2006-01-02T15:04:01Z"),
+					Phase:       release.HookPhaseSucceeded,
+				},
+			},
 			&release.Hook{
 				Name:   "passing-pre-install",
 				Events: []release.HookEvent{release.HookPreInstall},
@@ -130,6 +189,29 @@ func TestStatusCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestStatusPrinterResourceStatuses(t *testing.T) {
+	two, three := int32(2), int32(3)
+	rel := &release.Release{
+		Name:      "flummoxed-chickadee",
+		Namespace: "default",
+		Version:   1,
+		Info: &release.Info{
+			Status:       release.StatusDeployed,
+			LastDeployed: helmtime.Unix(1452902400, 0).UTC(),
+			ResourceStatuses: []release.ResourceStatus{
+				{Kind: "StatefulSet", Namespace: "default", Name: "db", State: "NotReady", ReadyReplicas: &two, DesiredReplicas: &three},
+				{Kind: "Deployment", Namespace: "default", Name: "web", State: "Ready", ReadyReplicas: &three, DesiredReplicas: &three},
+				{Kind: "Service", Namespace: "default", Name: "frontend", State: "Missing"},
+				{Kind: "Service", Namespace: "default", Name: "web", State: "Ready"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, statusPrinter{release: rel, noColor: true}.WriteTable(&buf))
+	test.AssertGoldenString(t, buf.String(), "output/status-with-resource-statuses.txt")
+}
+
 func mustParseTime(t string) helmtime.Time {
 	res, _ := helmtime.Parse(time.RFC3339, t)
 	return res
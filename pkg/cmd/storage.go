@@ -0,0 +1,91 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const storageHelp = `
+This command consists of multiple subcommands to maintain the storage of the
+releases.
+`
+
+const storageMigrateHelp = `
+This command applies the pending migrations of the schema of the SQL storage,
+selected with '$HELM_DRIVER=sql'. The migrations are applied in order, under a
+lock of the database, so that concurrent Helm invocations do not race.
+
+Every other command migrates the schema when it opens the SQL storage.
+Migrating it explicitly lets the migrations of a new version of Helm be
+reviewed with '--dry-run', which only lists them, and applied while the
+releases are not being changed.
+`
+
+func newStorageCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage migrate",
+		Short: "maintain the storage of the releases",
+		Long:  storageHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newStorageMigrateCmd(cfg, out))
+
+	return cmd
+}
+
+func newStorageMigrateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewStorageMigrate(cfg)
+
+	cmd := &cobra.Command{
+		Use:               "migrate",
+		Short:             "migrate the schema of the SQL storage",
+		Long:              storageMigrateHelp,
+		Args:              require.NoArgs,
+		ValidArgsFunction: noMoreArgsCompFunc,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			migrations, err := client.Run()
+			if err != nil {
+				return err
+			}
+			if len(migrations) == 0 {
+				fmt.Fprintln(out, "The schema of the SQL storage is up to date.")
+				return nil
+			}
+			if client.DryRun {
+				fmt.Fprintln(out, "Pending migrations of the schema of the SQL storage:")
+			} else {
+				fmt.Fprintln(out, "Applied migrations of the schema of the SQL storage:")
+			}
+			for _, id := range migrations {
+				fmt.Fprintf(out, "- %s\n", id)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&client.DryRun, "dry-run", false, "list the pending migrations without applying them")
+
+	return cmd
+}
@@ -36,6 +36,7 @@ import (
 
 	"helm.sh/helm/v4/pkg/action"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/cmd/require"
 	releaseutil "helm.sh/helm/v4/pkg/release/util"
@@ -47,17 +48,37 @@ Render chart templates locally and display the output.
 Any values that would normally be looked up or retrieved in-cluster will be
 faked locally. Additionally, none of the server-side testing of chart validity
 (e.g. whether an API is supported) is done.
+
+With --output-dir, the rendered templates are written to files instead. The
+"chart" --output-layout also writes the CRDs of the chart as they are, and the
+hooks under hooks/<event>/<weight>/. With --output-kustomize, a
+kustomization.yaml lists the files in the order Helm installs them, so that
+'kubectl apply -k' applies them in that order.
+
+With --emit-manifest-hash, the templates are rendered deterministically and a
+canonical hash of the manifests and hooks is printed after them, which does not
+depend on the order of the documents or of their keys, nor on their comments
+or formatting. 'helm upgrade --expect-manifest-hash' checks it, so that
+--is-upgrade must be given with it. The templates using random values need
+--random-seed, and the ones reading the clock or the cluster, or generating
+keys and certificates, fail. The templates using .Release.Revision cannot be
+checked: they are rendered with revision 1, and by the upgrade with the next
+revision of the release.
 `
 
 func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var validate bool
 	var includeCrds bool
 	var skipTests bool
+	var outputLayout string
 	client := action.NewInstall(cfg)
 	valueOpts := &values.Options{}
 	var kubeVersion string
 	var extraAPIs []string
 	var showFiles []string
+	var vc valuesCheck
+	var emitManifestHash bool
+	var randomSeed int64
 
 	cmd := &cobra.Command{
 		Use:   "template [NAME] [CHART]",
@@ -67,7 +88,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return compInstall(args, toComplete, client)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if kubeVersion != "" {
 				parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
 				if err != nil {
@@ -83,6 +104,13 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetRegistryClient(registryClient)
 
+			if err := validateValuesCheckFlags(vc); err != nil {
+				return err
+			}
+			if vc.only {
+				return runValuesCheck(args[len(args)-1], &client.ChartPathOptions, client.Devel, client.SkipSchemaValidation, vc, valueOpts, output.Table, out)
+			}
+
 			// This is for the case where "" is specifically passed in as a
 			// value. When there is no value passed in NoOptDefVal will be used
 			// and it is set to client. See addInstallFlags.
@@ -93,8 +121,40 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			client.ReleaseName = "release-name"
 			client.Replace = true // Skip the name check
 			client.ClientOnly = !validate
+			if len(valueOpts.ValuesFromResources) > 0 {
+				if client.ClientOnly {
+					return errors.New("--values-from reads values from the cluster and requires --validate")
+				}
+				valueOpts.ResourceReader = cfg.ResourceValuesReader(settings.Namespace())
+			}
+			switch outputLayout {
+			case string(action.OutputLayoutFlat):
+			case string(action.OutputLayoutChart):
+				client.OutputLayout = action.OutputLayoutChart
+			default:
+				return fmt.Errorf("invalid --output-layout %q: it must be %q or %q", outputLayout, action.OutputLayoutFlat, action.OutputLayoutChart)
+			}
+			chartLayout := client.OutputLayout == action.OutputLayoutChart || client.OutputKustomize
+			if chartLayout && client.OutputDir == "" {
+				return errors.New("--output-layout=chart and --output-kustomize require --output-dir")
+			}
+			if emitManifestHash {
+				// The hash is the one of what an upgrade renders.
+				if len(showFiles) > 0 || includeCrds || client.HideSecret || client.OutputDir != "" {
+					return errors.New("--emit-manifest-hash cannot be used with --show-only, --include-crds, --hide-secret or --output-dir")
+				}
+				if !client.IsUpgrade {
+					return errors.New("--emit-manifest-hash requires --is-upgrade, as 'helm upgrade --expect-manifest-hash' checks the manifests of an upgrade")
+				}
+				client.Deterministic = true
+			}
+			if cmd.Flags().Changed("random-seed") {
+				client.RandomSeed = &randomSeed
+			}
 			client.APIVersions = chartutil.VersionSet(extraAPIs)
 			client.IncludeCRDs = includeCrds
+			client.ShowOnly = showFiles
+			client.SkipTests = skipTests
 			rel, err := runInstall(args, client, valueOpts, out)
 
 			if err != nil && !settings.Debug {
@@ -108,8 +168,12 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			// we always want to print the YAML, even if it is not valid. The error is still returned afterwards.
 			if rel != nil {
 				var manifests bytes.Buffer
-				fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
-				if !client.DisableHooks {
+				// In the chart layout, the manifests and the hooks are written
+				// to the output directory by the install.
+				if !chartLayout {
+					fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
+				}
+				if !client.DisableHooks && (client.OutputDir == "" || !chartLayout) {
 					fileWritten := make(map[string]bool)
 					for _, m := range rel.Hooks {
 						if skipTests && isTestHook(m) {
@@ -186,6 +250,14 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				} else {
 					fmt.Fprintf(out, "%s", manifests.String())
 				}
+
+				if emitManifestHash && err == nil {
+					hash, err := releaseutil.HashRelease(rel)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(out, "---\n%s", hash)
+				}
 			}
 
 			return err
@@ -194,22 +266,28 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	addInstallFlags(cmd, f, client, valueOpts)
+	addNamespaceDefaultsFlags(f, cfg)
+	addValuesCheckFlags(f, &vc)
 	f.StringArrayVarP(&showFiles, "show-only", "s", []string{}, "only show manifests rendered from the given templates")
 	f.StringVar(&client.OutputDir, "output-dir", "", "writes the executed templates to files in output-dir instead of stdout")
 	f.BoolVar(&validate, "validate", false, "validate your manifests against the Kubernetes cluster you are currently pointing at. This is the same validation performed on an install")
 	f.BoolVar(&includeCrds, "include-crds", false, "include CRDs in the templated output")
 	f.BoolVar(&skipTests, "skip-tests", false, "skip tests from templated output")
 	f.BoolVar(&client.IsUpgrade, "is-upgrade", false, "set .Release.IsUpgrade instead of .Release.IsInstall")
-	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
+	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion. Capabilities.APIVersions are the API versions served by default by that version, if known to Helm")
 	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions (multiple can be specified)")
 	f.BoolVar(&client.UseReleaseName, "release-name", false, "use release name in the output-dir path.")
+	f.StringVar(&outputLayout, "output-layout", string(action.OutputLayoutFlat), "layout of the files written to output-dir: \"flat\" writes the templates, \"chart\" writes the CRDs and the hooks under hooks/<event>/<weight>/ as well")
+	f.BoolVar(&client.OutputKustomize, "output-kustomize", false, "write a kustomization.yaml listing the files of output-dir in install order, so that \"kubectl apply -k\" applies them in that order. Implies --output-layout=chart")
+	f.BoolVar(&emitManifestHash, "emit-manifest-hash", false, "render the templates deterministically and print a canonical hash of the manifests and hooks after them, for 'helm upgrade --expect-manifest-hash'")
+	f.Int64Var(&randomSeed, "random-seed", 0, "seed the random values of the templates when they are rendered deterministically, as with --emit-manifest-hash")
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 
 	return cmd
 }
 
 func isTestHook(h *release.Hook) bool {
-	return slices.Contains(h.Events, release.HookTest)
+	return slices.Contains(h.Events, release.HookTest) || slices.Contains(h.Events, release.HookTestFixture)
 }
 
 // The following functions (writeToFile, createOrOpenFile, and ensureDirectoryForFile)
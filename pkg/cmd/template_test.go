@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
 )
 
 var chartPath = "testdata/testcharts/subchart"
@@ -91,6 +93,12 @@ func TestTemplateCmd(t *testing.T) {
 			cmd:    fmt.Sprintf("template --api-versions helm.k8s.io/test --api-versions helm.k8s.io/test2 '%s'", chartPath),
 			golden: "output/template-with-api-version.txt",
 		},
+		{
+			name:      "check the values only",
+			cmd:       fmt.Sprintf("template '%s' --values-check-only --set subchart-with-schema.age=-25", "testdata/testcharts/chart-with-schema-and-subchart"),
+			golden:    "output/template-values-check.txt",
+			wantError: true,
+		},
 		{
 			name:   "template with CRDs",
 			cmd:    fmt.Sprintf("template '%s' --include-crds", chartPath),
@@ -133,11 +141,51 @@ func TestTemplateCmd(t *testing.T) {
 			wantError: true,
 			golden:    "output/template-with-invalid-yaml-debug.txt",
 		},
+		{
+			name:      "check values from resources without cluster access",
+			cmd:       fmt.Sprintf("template '%s' --values-from configmap/env#values.yaml", chartPath),
+			wantError: true,
+			golden:    "output/template-values-from-client-only.txt",
+		},
+		{
+			name:      "check invalid values from reference",
+			cmd:       fmt.Sprintf("template '%s' --values-from configmap/env", chartPath),
+			wantError: true,
+		},
+		{
+			name:      "check invalid output layout",
+			cmd:       fmt.Sprintf("template '%s' --output-dir out --output-layout nested", chartPath),
+			wantError: true,
+			golden:    "output/template-invalid-output-layout.txt",
+		},
+		{
+			name:      "check kustomization without output dir",
+			cmd:       fmt.Sprintf("template '%s' --output-kustomize", chartPath),
+			wantError: true,
+			golden:    "output/template-kustomize-without-output-dir.txt",
+		},
 		{
 			name:   "template skip-tests",
 			cmd:    fmt.Sprintf(`template '%s' --skip-tests`, chartPath),
 			golden: "output/template-skip-tests.txt",
 		},
+		{
+			name:   "template with manifest hash",
+			cmd:    fmt.Sprintf(`template '%s' --is-upgrade --emit-manifest-hash`, chartPath),
+			golden: "output/template-manifest-hash.txt",
+		},
+		{
+			name:      "check manifest hash without is-upgrade",
+			cmd:       fmt.Sprintf(`template '%s' --emit-manifest-hash`, chartPath),
+			wantError: true,
+			golden:    "output/template-manifest-hash-install.txt",
+		},
+		{
+			name:      "check manifest hash with show-only",
+			cmd:       fmt.Sprintf(`template '%s' --is-upgrade --emit-manifest-hash --show-only templates/service.yaml`, chartPath),
+			wantError: true,
+			golden:    "output/template-manifest-hash-show-only.txt",
+		},
 		{
 			// This test case is to ensure the case where specified dependencies
 			// in the Chart.yaml and those where the Chart.yaml don't have them
@@ -170,6 +218,21 @@ func TestTemplateCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestTemplateChartTooLarge(t *testing.T) {
+	previous := loader.MaxDecompressedChartSize
+	loader.MaxDecompressedChartSize = 64
+	defer func() { loader.MaxDecompressedChartSize = previous }()
+
+	_, _, err := executeActionCommand("template testdata/testcharts/compressedchart-0.1.0.tgz")
+	if err == nil {
+		t.Fatal("expected an error templating a chart larger than the maximum size")
+	}
+	expected := `decompressed chart is larger than the maximum size 64: exceeded while decompressing "compressedchart/Chart.yaml"`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
 func TestTemplateVersionCompletion(t *testing.T) {
 	repoFile := "testdata/helmhome/helm/repositories.yaml"
 	repoCache := "testdata/helmhome/helm/repository"
@@ -0,0 +1,2 @@
+#!/bin/sh
+echo "$HELM_PLUGIN_PARENT_COMMAND $HELM_RELEASE $HELM_NAMESPACE $*"
@@ -31,10 +31,16 @@ const uninstallDesc = `
 This command takes a release name and uninstalls the release.
 
 It removes all of the resources associated with the last release of the chart
-as well as the release history, freeing it up for future use.
+as well as the release history, freeing it up for future use. The CRDs
+installed from the crds/ directory of the chart are never removed, even when
+the release owns them exclusively.
 
 Use the '--dry-run' flag to see which releases will be uninstalled without actually
 uninstalling them.
+
+Besides the resources annotated with 'helm.sh/resource-policy: keep', the
+resources matching '--keep-selector' or of one of the '--keep-kinds' are kept:
+	$ helm uninstall my-release --keep-kinds PersistentVolumeClaim
 `
 
 func newUninstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -74,11 +80,17 @@ func newUninstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f := cmd.Flags()
 	f.BoolVar(&client.DryRun, "dry-run", false, "simulate a uninstall")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during uninstallation")
+	addSkipHooksFlag(f, &client.SkipHookEvents)
 	f.BoolVar(&client.IgnoreNotFound, "ignore-not-found", false, `Treat "release not found" as a successful uninstall`)
 	f.BoolVar(&client.KeepHistory, "keep-history", false, "remove all associated resources and mark the release as deleted, but retain the release history")
 	f.StringVar(&client.DeletionPropagation, "cascade", "background", "Must be \"background\", \"orphan\", or \"foreground\". Selects the deletion cascading strategy for the dependents. Defaults to background.")
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
+	f.StringVar(&client.KeepSelector, "keep-selector", "", "label selector of the resources of the release to keep rather than delete, e.g. app=db")
+	f.StringSliceVar(&client.KeepKinds, "keep-kinds", nil, "kinds of the resources of the release to keep rather than delete, e.g. PersistentVolumeClaim. Can be specified multiple times or comma separated")
+	addPolicyOverrideFlag(f, &client.PolicyOverrides)
+	addOverridePinFlag(f, &client.OverridePin)
+	addRequireRecordFlag(f, cfg)
 	AddWaitFlag(cmd, &client.WaitStrategy)
 
 	return cmd
@@ -17,16 +17,20 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/moby/term"
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v4/pkg/action"
@@ -36,6 +40,7 @@ import (
 	"helm.sh/helm/v4/pkg/cmd/require"
 	"helm.sh/helm/v4/pkg/downloader"
 	"helm.sh/helm/v4/pkg/getter"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/storage/driver"
 )
@@ -74,9 +79,52 @@ or '--set' flags. Priority is given to new values.
 
     $ helm upgrade --reuse-values --set foo=bar --set foo=newbar redis ./redis
 
+The '--edit-values' flag opens the merged values in $HELM_EDITOR, $KUBE_EDITOR
+or $EDITOR before they are used, as 'kubectl edit' does. The values which may be
+sensitive, such as passwords, are masked and keep their content unless they are
+changed; '--edit-values=full' shows them as they are:
+
+    $ helm upgrade --edit-values -f myvalues.yaml redis ./redis
+
 The --dry-run flag will output all generated chart manifests, including Secrets
 which can contain sensitive values. To hide Kubernetes Secrets use the
 --hide-secret flag. Please carefully consider how and when these flags are used.
+
+With --explain-changes, a dry run also renders the chart and values of the
+current release again, and lists the templates whose output the upgrade
+changes, such as the template of a checksum annotation which changed.
+
+With --show-only-changed, a dry run outputs only the manifests which differ
+from the ones of the current release, matched by kind, namespace and name,
+flagged with a '# CHANGED' or '# ADDED' comment, followed by the manifests the
+upgrade removes, flagged with '# REMOVED'. With --hide-secret, the Secrets are
+left out of both.
+
+The '--expect-manifest-hash' flag checks that the upgrade applies the manifests
+reviewed with 'helm template --emit-manifest-hash', rendered with the same
+release name, namespace, chart and values, and '--is-upgrade'. The upgrade is
+aborted before any change if they differ, listing the documents which do when
+the output of 'helm template' is given. 'helm template' renders the revision 1
+rather than the next revision of the release, so that charts whose templates
+use .Release.Revision cannot be checked:
+
+    $ helm template redis ./redis -f myvalues.yaml --is-upgrade --emit-manifest-hash > reviewed.yaml
+    $ helm upgrade redis ./redis -f myvalues.yaml --expect-manifest-hash reviewed.yaml
+
+An upgrade records its progress as it applies the resources of the release. If
+it is interrupted, for instance because the helm process was killed, the
+'--resume' flag continues it from where it stopped, with the chart and values
+it was started with. Only the release name is given:
+
+    $ helm upgrade --resume redis
+
+An upgrade that cannot be resumed is left pending; 'helm recover' marks it as
+failed so that the release can be upgraded or rolled back again.
+
+The '--force' flag replaces every resource of the release. To only replace the
+resources whose immutable fields change, such as the template of a Job, give
+them the 'helm.sh/upgrade-strategy: replace' annotation: when they change, they
+are deleted and created again rather than patched.
 `
 
 func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -84,12 +132,25 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	valueOpts := &values.Options{}
 	var outfmt output.Format
 	var createNamespace bool
+	var confirmImageChanges bool
+	var acceptImageChanges string
+	var showNotesDiff, notesDiffStrict bool
+	var resume bool
+	var vc valuesCheck
+	var policyFile string
+	var expectManifestHash string
+	var randomSeed int64
 
 	cmd := &cobra.Command{
 		Use:   "upgrade [RELEASE] [CHART]",
 		Short: "upgrade a release",
 		Long:  upgradeDesc,
-		Args:  require.ExactArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if resume {
+				return require.ExactArgs(1)(cmd, args)
+			}
+			return require.ExactArgs(2)(cmd, args)
+		},
 		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) == 0 {
 				return compListReleases(toComplete, args, cfg)
@@ -99,8 +160,26 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			return noMoreArgsComp()
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			client.Namespace = settings.Namespace()
+			if resume {
+				rel, err := runUpgradeResume(client, args[0], out)
+				if err != nil {
+					return fmt.Errorf("UPGRADE FAILED: %w", err)
+				}
+				if outfmt == output.Table {
+					_, _ = fmt.Fprintf(out, "Release %q has been upgraded. Happy Helming!\n", args[0])
+				}
+				return outfmt.Write(out, &statusPrinter{
+					release:      rel,
+					debug:        settings.Debug,
+					showMetadata: false,
+					hideNotes:    client.HideNotes,
+					noColor:      settings.NoColor,
+					notesChange:  compareReleaseNotes(cfg, rel, showNotesDiff, notesDiffStrict),
+				})
+			}
+			valueOpts.ResourceReader = cfg.ResourceValuesReader(client.Namespace)
 
 			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
 				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
@@ -109,6 +188,26 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetRegistryClient(registryClient)
 
+			if err := validateValuesCheckFlags(vc); err != nil {
+				return err
+			}
+			if vc.only {
+				return runValuesCheck(args[1], &client.ChartPathOptions, client.Devel, client.SkipSchemaValidation, vc, valueOpts, outfmt, out)
+			}
+			if policyFile != "" {
+				if client.Policy, err = action.LoadPolicyFile(policyFile); err != nil {
+					return err
+				}
+			}
+			if expectManifestHash != "" {
+				if client.ExpectedManifestHash, err = readExpectedManifestHash(expectManifestHash); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("random-seed") {
+				client.RandomSeed = &randomSeed
+			}
+
 			// This is for the case where "" is specifically passed in as a
 			// value. When there is no value passed in NoOptDefVal will be used
 			// and it is set to client. See addInstallFlags.
@@ -123,6 +222,9 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				histClient.Max = 1
 				versions, err := histClient.Run(args[0])
 				if err == driver.ErrReleaseNotFound || isReleaseUninstalled(versions) {
+					if client.ExpectedManifestHash != "" {
+						return errors.New("--expect-manifest-hash requires an existing release, it cannot be checked when installing one")
+					}
 					// Only print this to stdout for table output
 					if outfmt == output.Table {
 						_, _ = fmt.Fprintf(out, "Release %q does not exist. Installing it now.\n", args[0])
@@ -134,24 +236,34 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					instClient.DryRun = client.DryRun
 					instClient.DryRunOption = client.DryRunOption
 					instClient.DisableHooks = client.DisableHooks
+					instClient.SkipHookEvents = client.SkipHookEvents
 					instClient.SkipCRDs = client.SkipCRDs
+					instClient.ShareCRDs = client.ShareCRDs
 					instClient.Timeout = client.Timeout
 					instClient.WaitStrategy = client.WaitStrategy
 					instClient.WaitForJobs = client.WaitForJobs
 					instClient.Devel = client.Devel
 					instClient.Namespace = client.Namespace
 					instClient.Atomic = client.Atomic
+					instClient.AtomicCleanupTimeout = client.AtomicCleanupTimeout
 					instClient.PostRenderer = client.PostRenderer
 					instClient.DisableOpenAPIValidation = client.DisableOpenAPIValidation
+					instClient.PruneUnknownFields = client.PruneUnknownFields
 					instClient.SubNotes = client.SubNotes
 					instClient.HideNotes = client.HideNotes
 					instClient.SkipSchemaValidation = client.SkipSchemaValidation
 					instClient.Description = client.Description
+					instClient.DescriptionIsTemplate = client.DescriptionIsTemplate
 					instClient.DependencyUpdate = client.DependencyUpdate
 					instClient.Labels = client.Labels
+					instClient.Annotations = client.Annotations
+					instClient.Policy = client.Policy
 					instClient.EnableDNS = client.EnableDNS
+					instClient.StrictTemplate = client.StrictTemplate
 					instClient.HideSecret = client.HideSecret
 					instClient.TakeOwnership = client.TakeOwnership
+					instClient.ServerSideApply = client.ServerSideApply
+					instClient.ForceConflicts = client.ForceConflicts
 
 					if isReleaseUninstalled(versions) {
 						instClient.Replace = true
@@ -211,6 +323,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 							RepositoryConfig: settings.RepositoryConfig,
 							RepositoryCache:  settings.RepositoryCache,
 							Debug:            settings.Debug,
+							DownloadRetries:  client.DownloadRetries,
 						}
 						if err := man.Update(); err != nil {
 							return err
@@ -228,6 +341,27 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			if ch.Metadata.Deprecated {
 				slog.Warn("this chart is deprecated")
 			}
+			annotateChartSource(ch, &client.ChartPathOptions)
+
+			if err := valueOpts.CoerceSetValues(vals, ch); err != nil {
+				return err
+			}
+
+			if valueOpts.EditValues != "" {
+				// The values reused from the release are only merged by the
+				// upgrade, so the edited ones may not meet the schema alone.
+				validate := !client.SkipSchemaValidation && !client.ReuseValues && !client.ResetThenReuseValues
+				var edited bool
+				vals, edited, err = editValues(valueOpts.EditValues, vals, ch, validate)
+				if err != nil {
+					return err
+				}
+				client.ValuesEdited = edited
+			}
+
+			if confirmImageChanges || acceptImageChanges != "" || settings.Debug {
+				client.ConfirmImageChanges = newImageChangeConfirmer(cmd.ErrOrStderr(), confirmImageChanges || acceptImageChanges != "", acceptImageChanges)
+			}
 
 			// Create context and prepare the handle of SIGTERM
 			ctx := context.Background()
@@ -259,6 +393,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				showMetadata: false,
 				hideNotes:    client.HideNotes,
 				noColor:      settings.NoColor,
+				notesChange:  compareReleaseNotes(cfg, rel, showNotesDiff, notesDiffStrict),
 			})
 		},
 	}
@@ -269,29 +404,58 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.StringVar(&client.DryRunOption, "dry-run", "", "simulate an install. If --dry-run is set with no option being specified or as '--dry-run=client', it will not attempt cluster connections. Setting '--dry-run=server' allows attempting cluster connections.")
 	f.BoolVar(&client.HideSecret, "hide-secret", false, "hide Kubernetes Secrets when also using the --dry-run flag")
+	f.BoolVar(&client.ExplainChanges, "explain-changes", false, "list the templates whose output differs from the current release when also using the --dry-run flag")
+	f.BoolVar(&client.ShowOnlyChanged, "show-only-changed", false, "show only the manifests which differ from the current release, flagged as changed, added or removed, when also using the --dry-run flag")
 	f.Lookup("dry-run").NoOptDefVal = "client"
 	f.BoolVar(&client.Force, "force", false, "force resource updates through a replacement strategy")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "disable pre/post upgrade hooks")
+	addSkipHooksFlag(f, &client.SkipHookEvents)
 	f.BoolVar(&client.DisableOpenAPIValidation, "disable-openapi-validation", false, "if set, the upgrade process will not validate rendered templates against the Kubernetes OpenAPI Schema")
+	f.BoolVar(&client.PruneUnknownFields, "prune-unknown-fields", false, "if set, remove from the rendered templates the fields the Kubernetes OpenAPI Schema of the cluster does not know, and report them, instead of failing the validation")
 	f.BoolVar(&client.SkipCRDs, "skip-crds", false, "if set, no CRDs will be installed when an upgrade is performed with install flag enabled. By default, CRDs are installed if not already present, when an upgrade is performed with install flag enabled")
+	f.BoolVar(&client.ShareCRDs, "share-crds", false, "if set, CRDs installed by other releases are shared with them when an upgrade is performed with install flag enabled, instead of failing")
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&client.ResetValues, "reset-values", false, "when upgrading, reset the values to the ones built into the chart")
 	f.BoolVar(&client.ReuseValues, "reuse-values", false, "when upgrading, reuse the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' is specified, this is ignored")
+	f.BoolVar(&client.SkipValuesMigrations, "skip-values-migrations", false, "when reusing the values of the release, do not apply the migrations of the chart's values-migrations.yaml to them")
 	f.BoolVar(&client.ResetThenReuseValues, "reset-then-reuse-values", false, "when upgrading, reset the values to the ones built into the chart, apply the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' or '--reuse-values' is specified, this is ignored")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVar(&client.Atomic, "atomic", false, "if set, upgrade process rolls back changes made in case of failed upgrade. The --wait flag will be set automatically to \"watcher\" if --atomic is used")
+	f.DurationVar(&client.AtomicCleanupTimeout, "atomic-timeout", 0, "time given to the rollback after a failed --atomic upgrade, or to the uninstall after a failed --atomic installation. Defaults to --timeout")
 	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
 	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this upgrade when upgrade fails")
 	f.BoolVar(&client.SubNotes, "render-subchart-notes", false, "if set, render subchart notes along with the parent")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in upgrade output. Does not affect presence in chart metadata")
+	f.BoolVar(&client.PreserveNotesOnFailure, "reuse-notes", false, "if set and the upgrade fails after its resources were applied, keep the notes of the previous revision on the failed release, and record the notes of the new chart as pending")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
 	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be separated by comma. Original release labels will be merged with upgrade labels. You can unset label using null.")
+	addAnnotationFlag(f, &client.Annotations, "free-form metadata recorded with the release, as key=value. The value may contain any character. Can be given multiple times. Original release annotations will be merged with upgrade annotations. You can unset an annotation using null.")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
+	f.BoolVar(&client.DescriptionIsTemplate, "description-is-template", false, "render the description as a Go template, with .Release.Name, .Release.Namespace, .Chart.Name, .Chart.Version, .Chart.AppVersion and the env function")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
 	f.BoolVar(&client.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
+	f.BoolVar(&client.StrictTemplate, "strict-template", false, "fail rendering when a template uses a value which is not defined, instead of rendering it empty. Values given to default, required, empty, coalesce and hasKey may still be missing")
 	f.BoolVar(&client.TakeOwnership, "take-ownership", false, "if set, upgrade will ignore the check for helm annotations and take ownership of the existing resources")
+	f.BoolVar(&confirmImageChanges, "confirm-image-changes", false, "if set, ask for confirmation before upgrading when the container images of the release would change")
+	f.BoolVar(&showNotesDiff, "show-notes-diff", false, "if set, show a unified diff of the notes when they changed since the previous revision")
+	f.BoolVar(&notesDiffStrict, "notes-diff-strict", false, "if set, whitespace-only changes to the notes are also reported as changes")
+	f.StringVar(&acceptImageChanges, "accept-image-changes", "", "confirm image changes non-interactively: the upgrade only proceeds if every new or changed image is listed in this file (one per line). Implies --confirm-image-changes")
+	f.BoolVar(&client.PruneReport, "prune-report", false, "if set, list the resources deleted because they are no longer part of the release in the status output")
+	f.BoolVar(&resume, "resume", false, "resume the interrupted upgrade of the release from where it stopped. Only the release name is given")
+	f.IntVar(&client.MaxPruned, "max-pruned", -1, "abort the upgrade before any change if it would delete more than this many resources no longer part of the release. Use -1 for no limit")
+	addServerSideApplyFlags(f, &client.ServerSideApply, &client.ForceConflicts)
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 	addValueOptionsFlags(f, valueOpts)
+	addSchemaCoercionFlag(f, valueOpts)
+	addEditValuesFlag(f, valueOpts)
+	addValuesCheckFlags(f, &vc)
+	f.StringVar(&expectManifestHash, "expect-manifest-hash", "", "abort the upgrade before any change if the hash of the rendered manifests differs from this one. It is either a \"sha256:\" hash or a file holding the output of 'helm template --emit-manifest-hash', which lets the documents which differ be listed. The templates are rendered deterministically")
+	f.Int64Var(&randomSeed, "random-seed", 0, "seed the random values of the templates when they are rendered deterministically, as with --expect-manifest-hash")
+	f.StringVar(&policyFile, "policy-file", "", "replace the policy of the release with the policy of this YAML file. The policy of the release is kept if not set")
+	addPolicyOverrideFlag(f, &client.PolicyOverrides)
+	addOverridePinFlag(f, &client.OverridePin)
+	addRequireRecordFlag(f, cfg)
+	addNamespaceDefaultsFlags(f, cfg)
 	bindOutputFlag(cmd, &outfmt)
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 	AddWaitFlag(cmd, &client.WaitStrategy)
@@ -309,6 +473,118 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	return cmd
 }
 
+// runUpgradeResume resumes the interrupted upgrade of the named release,
+// until it completes or the command is interrupted.
+func runUpgradeResume(client *action.Upgrade, name string, out io.Writer) (*release.Release, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cSignal := make(chan os.Signal, 2)
+	signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(cSignal)
+	go func() {
+		if _, ok := <-cSignal; ok {
+			_, _ = fmt.Fprintf(out, "Release %s has been cancelled.\n", name)
+			cancel()
+		}
+	}()
+
+	return client.Resume(ctx, name)
+}
+
+// newImageChangeConfirmer returns a callback for Upgrade.ConfirmImageChanges
+// that prints a summary of the image changes to out. When confirm is set, the
+// changes must either all be listed in acceptFile or be confirmed by the user
+// on an interactive terminal.
+func newImageChangeConfirmer(out io.Writer, confirm bool, acceptFile string) func(releaseutil.ImageDiff) error {
+	return func(diff releaseutil.ImageDiff) error {
+		if diff.IsEmpty() {
+			return nil
+		}
+
+		fmt.Fprintln(out, "CONTAINER IMAGE CHANGES:")
+		for _, c := range diff.Changed {
+			fmt.Fprintf(out, "  ~ %s: %s -> %s\n", c.New.ID(), c.Old.Image, c.New.Image)
+		}
+		for _, c := range diff.Added {
+			fmt.Fprintf(out, "  + %s: %s\n", c.ID(), c.Image)
+		}
+		for _, c := range diff.Removed {
+			fmt.Fprintf(out, "  - %s: %s\n", c.ID(), c.Image)
+		}
+
+		if !confirm {
+			return nil
+		}
+
+		if acceptFile != "" {
+			accepted, err := readAcceptedImages(acceptFile)
+			if err != nil {
+				return err
+			}
+			var unexpected []string
+			for _, c := range diff.Changed {
+				if !accepted[c.New.Image] {
+					unexpected = append(unexpected, c.New.Image)
+				}
+			}
+			for _, c := range diff.Added {
+				if !accepted[c.Image] {
+					unexpected = append(unexpected, c.Image)
+				}
+			}
+			if len(unexpected) > 0 {
+				return fmt.Errorf("image changes not accepted by %s: %s", acceptFile, strings.Join(unexpected, ", "))
+			}
+			return nil
+		}
+
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			return errors.New("container images would change: confirm interactively or list the expected images with --accept-image-changes")
+		}
+		fmt.Fprint(out, "Proceed with these image changes? [y/N]: ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("upgrade aborted: container image changes were not confirmed")
+		}
+		return nil
+	}
+}
+
+// readAcceptedImages reads a file listing one image reference per line.
+// Blank lines and lines starting with '#' are ignored.
+func readAcceptedImages(filename string) (map[string]bool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read accepted images: %w", err)
+	}
+	accepted := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		accepted[line] = true
+	}
+	return accepted, nil
+}
+
+// readExpectedManifestHash returns the value of --expect-manifest-hash: the
+// hash itself, or the content of the file it names.
+func readExpectedManifestHash(value string) (string, error) {
+	if strings.HasPrefix(value, "sha256:") {
+		return value, nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to read expected manifest hash: %w", err)
+	}
+	return string(data), nil
+}
+
 func isReleaseUninstalled(versions []*release.Release) bool {
 	return len(versions) > 0 && versions[len(versions)-1].Info.Status == release.StatusUninstalled
 }
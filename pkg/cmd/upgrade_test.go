@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -27,6 +28,7 @@ import (
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -187,10 +189,33 @@ func TestUpgradeCmd(t *testing.T) {
 			golden: "output/upgrade-uninstalled-with-keep-history.txt",
 			rels:   []*release.Release{relWithStatusMock("funny-bunny", 2, ch, release.StatusUninstalled)},
 		},
+		{
+			name:      "resume a release without an interrupted upgrade",
+			cmd:       "upgrade --resume funny-bunny",
+			golden:    "output/upgrade-resume-not-pending.txt",
+			wantError: true,
+			rels:      []*release.Release{relMock("funny-bunny", 2, ch)},
+		},
+		{
+			name:      "resume an upgrade given a chart",
+			cmd:       fmt.Sprintf("upgrade --resume funny-bunny '%s'", chartPath),
+			golden:    "output/upgrade-resume-with-chart.txt",
+			wantError: true,
+		},
 	}
 	runTestCmd(t, tests)
 }
 
+func TestUpgradeValuesCheckOnly(t *testing.T) {
+	// The release does not exist: the values are checked without looking it up.
+	tests := []cmdTestCase{{
+		name:   "check the values of an upgrade",
+		cmd:    "upgrade schema testdata/testcharts/chart-with-schema --values-check-only --set age=30",
+		golden: "output/upgrade-values-check.txt",
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestUpgradeWithValue(t *testing.T) {
 	releaseName := "funny-bunny-v2"
 	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
@@ -218,6 +243,65 @@ func TestUpgradeWithValue(t *testing.T) {
 
 }
 
+func TestUpgradePolicy(t *testing.T) {
+	releaseName := "funny-bunny-policy"
+	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
+
+	defer resetEnv()()
+
+	store := storageFixture()
+
+	rel := relMock(releaseName, 3, ch)
+	rel.Policy = &release.Policy{RequireFlag: []string{"approved"}}
+	store.Create(rel)
+
+	cmd := fmt.Sprintf("upgrade %s '%s'", releaseName, chartPath)
+	_, _, err := executeActionCommandC(store, cmd)
+	if err == nil || !strings.Contains(err.Error(), "release policy rule requireFlag violated") {
+		t.Fatalf("expected the upgrade to be refused by the policy, got '%v'", err)
+	}
+
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyFile, []byte("minInterval: 1m\noverrideTokens: [hotfix]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = fmt.Sprintf("upgrade %s '%s' --override-policy approved --policy-file '%s'", releaseName, chartPath, policyFile)
+	if _, _, err := executeActionCommandC(store, cmd); err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+
+	updatedRel, err := store.Get(releaseName, 4)
+	if err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+	expected := &release.Policy{MinInterval: "1m", OverrideTokens: []string{release.HashPolicyToken("hotfix")}}
+	if !reflect.DeepEqual(updatedRel.Policy, expected) {
+		t.Errorf("expected the policy %+v, got %+v", expected, updatedRel.Policy)
+	}
+
+	cmd = fmt.Sprintf("upgrade %s '%s'", releaseName, chartPath)
+	_, _, err = executeActionCommandC(store, cmd)
+	if err == nil || !strings.Contains(err.Error(), "release policy rule minInterval violated") {
+		t.Fatalf("expected the upgrade to be refused by the policy, got '%v'", err)
+	}
+
+	cmd = fmt.Sprintf("upgrade %s '%s' --override-policy hotfix", releaseName, chartPath)
+	if _, _, err := executeActionCommandC(store, cmd); err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+}
+
+func TestUpgradeInvalidPolicyFile(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyFile, []byte("freezeWindows:\n- cron: \"0 18 * * 5\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := executeActionCommandC(storageFixture(), fmt.Sprintf("upgrade funny-bunny testdata/testcharts/alpine --policy-file '%s'", policyFile))
+	if err == nil || !strings.Contains(err.Error(), "freezeWindows[0]: duration") {
+		t.Errorf("expected the policy file to be rejected, got '%v'", err)
+	}
+}
+
 func TestUpgradeWithStringValue(t *testing.T) {
 	releaseName := "funny-bunny-v3"
 	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
@@ -277,6 +361,113 @@ func TestUpgradeInstallWithSubchartNotes(t *testing.T) {
 
 }
 
+func TestUpgradeNotesDiff(t *testing.T) {
+	releaseName := "notes-bunny"
+	relMock, _, _ := prepareMockRelease(t, releaseName)
+
+	chartWithNotes := func(t *testing.T, notes string) string {
+		t.Helper()
+		dir := t.TempDir()
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{
+				APIVersion: chart.APIVersionV1,
+				Name:       "notesChart",
+				Version:    "0.1.0",
+			},
+			Templates: []*chart.File{{Name: "templates/NOTES.txt", Data: []byte(notes)}},
+		}
+		if err := chartutil.SaveDir(c, dir); err != nil {
+			t.Fatal(err)
+		}
+		return filepath.Join(dir, c.Metadata.Name)
+	}
+
+	tests := []struct {
+		name     string
+		notes    string
+		flags    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "unchanged notes",
+			notes:    "Some mock release notes!",
+			excludes: []string{"NOTES CHANGED", "\"notesChanged\": true"},
+		},
+		{
+			name:     "whitespace-only change is ignored",
+			notes:    "Some  mock\nrelease notes!\n",
+			excludes: []string{"NOTES CHANGED"},
+		},
+		{
+			name:     "whitespace-only change in strict mode",
+			notes:    "Some  mock\nrelease notes!\n",
+			flags:    "--notes-diff-strict",
+			contains: []string{"NOTES CHANGED: the notes differ from revision 1"},
+		},
+		{
+			name:     "changed notes",
+			notes:    "Some new release notes!",
+			contains: []string{"NOTES CHANGED: the notes differ from revision 1", "NOTES:\nSome new release notes!"},
+			excludes: []string{"NOTES DIFF"},
+		},
+		{
+			name:  "changed notes with diff",
+			notes: "Some new release notes!",
+			flags: "--show-notes-diff",
+			contains: []string{
+				"NOTES DIFF:\n--- NOTES (revision 1)\n+++ NOTES (revision 2)\n",
+				"-Some mock release notes!",
+				"+Some new release notes!",
+			},
+		},
+		{
+			name:     "removed notes",
+			notes:    "",
+			excludes: []string{"NOTES CHANGED", "NOTES:"},
+		},
+		{
+			name:     "hidden notes",
+			notes:    "Some new release notes!",
+			flags:    "--show-notes-diff --hide-notes",
+			excludes: []string{"NOTES CHANGED", "NOTES DIFF", "NOTES:"},
+		},
+		{
+			name:     "json output",
+			notes:    "Some new release notes!",
+			flags:    "--output json",
+			contains: []string{"\"notesChanged\":true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer resetEnv()()
+
+			store := storageFixture()
+			if err := store.Create(relMock(releaseName, 1, nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := fmt.Sprintf("upgrade %s '%s' %s", releaseName, chartWithNotes(t, tt.notes), tt.flags)
+			_, out, err := executeActionCommandC(store, cmd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(out, s) {
+					t.Errorf("expected output to contain %q, got:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(out, s) {
+					t.Errorf("expected output not to contain %q, got:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
 func TestUpgradeWithValuesFile(t *testing.T) {
 
 	releaseName := "funny-bunny-v4"
@@ -573,3 +764,40 @@ func TestUpgradeWithDryRun(t *testing.T) {
 		t.Error("expected error when --hide-secret used without --dry-run")
 	}
 }
+
+func TestImageChangeConfirmer(t *testing.T) {
+	diff := releaseutil.ImageDiff{
+		Changed: []releaseutil.ImageChange{{
+			Old: releaseutil.ContainerImage{Kind: "Deployment", Name: "web", Container: "app", Image: "web:1.0"},
+			New: releaseutil.ContainerImage{Kind: "Deployment", Name: "web", Container: "app", Image: "web:2.0"},
+		}},
+		Added: []releaseutil.ContainerImage{{Kind: "Job", Name: "migrate", Container: "migrate", Image: "migrate:2.0"}},
+	}
+
+	acceptFile := filepath.Join(t.TempDir(), "accepted.txt")
+	if err := os.WriteFile(acceptFile, []byte("# expected images\nweb:2.0\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err := newImageChangeConfirmer(&out, true, acceptFile)(diff)
+	if err == nil || !strings.Contains(err.Error(), "migrate:2.0") {
+		t.Errorf("expected unlisted image to be rejected, got %v", err)
+	}
+	expected := "CONTAINER IMAGE CHANGES:\n  ~ Deployment/web/app: web:1.0 -> web:2.0\n  + Job/migrate/migrate: migrate:2.0\n"
+	if out.String() != expected {
+		t.Errorf("expected summary %q, got %q", expected, out.String())
+	}
+
+	if err := os.WriteFile(acceptFile, []byte("web:2.0\nmigrate:2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := newImageChangeConfirmer(io.Discard, true, acceptFile)(diff); err != nil {
+		t.Errorf("expected listed images to be accepted, got %v", err)
+	}
+
+	// Without confirmation the summary is informative only.
+	if err := newImageChangeConfirmer(io.Discard, false, "")(diff); err != nil {
+		t.Errorf("expected no error without confirmation, got %v", err)
+	}
+}
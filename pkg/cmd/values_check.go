@@ -0,0 +1,128 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cli/values"
+	"helm.sh/helm/v4/pkg/getter"
+)
+
+// valuesCheck holds the flags of the --values-check-only mode.
+type valuesCheck struct {
+	only        bool
+	unknownKeys bool
+}
+
+func addValuesCheckFlags(f *pflag.FlagSet, vc *valuesCheck) {
+	f.BoolVar(&vc.only, "values-check-only", false, "only check that the values parse, merge with the chart's defaults, satisfy the chart's schemas and set its required values, without rendering the chart or contacting the cluster")
+	f.BoolVar(&vc.unknownKeys, "values-check-unknown-keys", false, "with --values-check-only, also report the values the chart neither sets by default nor declares in its schema")
+}
+
+// valuesFindingsWriter prints the findings of a values check.
+type valuesFindingsWriter struct {
+	findings []action.ValuesFinding
+}
+
+// runValuesCheck checks the values given by valueOpts for the chart chartRef,
+// and fails if any of them is in error. The values read from cluster
+// resources cannot be checked.
+func runValuesCheck(chartRef string, chartPathOpts *action.ChartPathOptions, devel bool, skipSchemaValidation bool, vc valuesCheck, valueOpts *values.Options, outfmt output.Format, out io.Writer) error {
+	if chartPathOpts.Version == "" && devel {
+		chartPathOpts.Version = ">0.0.0-0"
+	}
+	cp, err := chartPathOpts.LocateChart(chartRef, settings)
+	if err != nil {
+		return err
+	}
+	ch, err := loader.Load(cp)
+	if err != nil {
+		return err
+	}
+
+	opts := *valueOpts
+	opts.ResourceReader = nil
+	findings, err := action.ValidateValues(ch, opts, action.ValuesCheckOptions{
+		Getters:              getter.All(settings),
+		SkipSchemaValidation: skipSchemaValidation,
+		UnknownKeys:          vc.unknownKeys,
+	})
+	if err != nil {
+		return err
+	}
+	if findings == nil {
+		findings = []action.ValuesFinding{}
+	}
+	if err := outfmt.Write(out, &valuesFindingsWriter{findings: findings}); err != nil {
+		return err
+	}
+
+	errs := 0
+	for _, f := range findings {
+		if f.Severity == action.ValuesError {
+			errs++
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("values check failed: %d error(s)", errs)
+	}
+	return nil
+}
+
+// validateValuesCheckFlags fails on the flags of the values check given
+// without --values-check-only.
+func validateValuesCheckFlags(vc valuesCheck) error {
+	if vc.unknownKeys && !vc.only {
+		return errors.New("--values-check-unknown-keys can only be used with --values-check-only")
+	}
+	return nil
+}
+
+func (w valuesFindingsWriter) WriteTable(out io.Writer) error {
+	warnings := 0
+	for _, f := range w.findings {
+		if f.Severity == action.ValuesWarning {
+			warnings++
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "[%s] ", strings.ToUpper(string(f.Severity)))
+		if f.Path != "" {
+			fmt.Fprintf(&b, "%s: ", f.Path)
+		}
+		fmt.Fprintf(&b, "%s (%s)", f.Message, f.Check)
+		_, _ = fmt.Fprintln(out, b.String())
+	}
+	_, _ = fmt.Fprintf(out, "Values checked: %d error(s), %d warning(s)\n", len(w.findings)-warnings, warnings)
+	return nil
+}
+
+func (w valuesFindingsWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.findings)
+}
+
+func (w valuesFindingsWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.findings)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cmd/require"
 )
 
@@ -34,9 +35,18 @@ tampered with, and was packaged by a trusted provider.
 This command can be used to verify a local chart. Several other commands provide
 '--verify' flags that run the same validation. To generate a signed package, use
 the 'helm package --sign' command.
+
+On success, the identities of the signer, the fingerprint of their key and the
+hash of the chart are printed. Use '--output json' or '--output yaml' to record
+them, e.g. in CI pipelines.
 `
 
+type verifyWriter struct {
+	result *action.VerifyResult
+}
+
 func newVerifyCmd(out io.Writer) *cobra.Command {
+	var outfmt output.Format
 	client := action.NewVerify()
 
 	cmd := &cobra.Command{
@@ -53,18 +63,34 @@ func newVerifyCmd(out io.Writer) *cobra.Command {
 			return noMoreArgsComp()
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			err := client.Run(args[0])
+			result, err := client.Run(args[0])
 			if err != nil {
 				return err
 			}
-
-			fmt.Fprint(out, client.Out)
-
-			return nil
+			return outfmt.Write(out, &verifyWriter{result})
 		},
 	}
 
 	cmd.Flags().StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
+	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
 }
+
+func (w verifyWriter) WriteTable(out io.Writer) error {
+	for _, name := range w.result.SignedBy {
+		_, _ = fmt.Fprintf(out, "Signed by: %s\n", name)
+	}
+	_, _ = fmt.Fprintf(out, "Using Key With Fingerprint: %s\n", w.result.Fingerprint)
+	_, _ = fmt.Fprintf(out, "Chart Hash Verified: %s\n", w.result.FileHash)
+	_, _ = fmt.Fprintf(out, "Provenance File: %s\n", w.result.ProvenanceFile)
+	return nil
+}
+
+func (w verifyWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.result)
+}
+
+func (w verifyWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.result)
+}
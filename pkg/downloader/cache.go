@@ -0,0 +1,135 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v4/internal/urlutil"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/provenance"
+	"helm.sh/helm/v4/pkg/repo"
+)
+
+// cachedChart returns the path of the archive of dep in the repository cache,
+// where charts are saved as <name>-<version>.tgz when they are downloaded. The
+// archive is only used if it holds the chart and version of dep, and matches
+// the digest recorded for that version in the cached index of the repository
+// of dep: without a digest to check it against, the chart is downloaded. A
+// provenance file is required in the cache when the charts are always
+// verified.
+func (m *Manager) cachedChart(dep *chart.Dependency, repos map[string]*repo.ChartRepository) (string, error) {
+	if m.RepositoryCache == "" {
+		return "", errors.New("no repository cache")
+	}
+	name := filepath.Join(m.RepositoryCache, fmt.Sprintf("%s-%s.tgz", dep.Name, dep.Version))
+	ch, err := loader.LoadFile(name)
+	if err != nil {
+		return "", err
+	}
+	if ch.Name() != dep.Name || !versionEquals(ch.Metadata.Version, dep.Version) {
+		return "", fmt.Errorf("%s holds version %s of chart %s", name, ch.Metadata.Version, ch.Name())
+	}
+
+	digest := indexedDigest(dep, repos)
+	if digest == "" {
+		return "", fmt.Errorf("no digest of %s %s in the cached repository index to check %s against", dep.Name, dep.Version, name)
+	}
+	sum, err := provenance.DigestFile(name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(sum, digest) {
+		return "", fmt.Errorf("%s does not match the digest %s of the repository index", name, digest)
+	}
+
+	switch m.Verify {
+	case VerifyAlways:
+		if _, err := VerifyChart(name, m.Keyring); err != nil {
+			return "", err
+		}
+	case VerifyIfPossible:
+		if _, err := os.Stat(name + ".prov"); err == nil {
+			if _, err := VerifyChart(name, m.Keyring); err != nil {
+				return "", err
+			}
+		}
+	}
+	return name, nil
+}
+
+// indexedDigest returns the digest recorded for the version of dep in the
+// cached index of its repository, if any.
+func indexedDigest(dep *chart.Dependency, repos map[string]*repo.ChartRepository) string {
+	for _, cr := range repos {
+		if cr.IndexFile == nil || !urlutil.Equal(dep.Repository, cr.Config.URL) {
+			continue
+		}
+		entry, err := findEntryByName(dep.Name, cr)
+		if err != nil {
+			return ""
+		}
+		ve, err := findVersionedEntry(dep.Version, entry)
+		if err != nil {
+			return ""
+		}
+		return ve.Digest
+	}
+	return ""
+}
+
+// remoteDependency reports whether dep is fetched from a chart repository or
+// a registry, rather than from the charts/ directory or a local path.
+func remoteDependency(dep *chart.Dependency) bool {
+	return dep.Repository != "" && !strings.HasPrefix(dep.Repository, "file://")
+}
+
+// allCached reports whether every dependency of deps fetched from a chart
+// repository or a registry is in the repository cache.
+func (m *Manager) allCached(deps []*chart.Dependency) bool {
+	repos, err := m.loadChartRepositories()
+	if err != nil {
+		return false
+	}
+	for _, dep := range deps {
+		if !remoteDependency(dep) {
+			continue
+		}
+		if _, err := m.cachedChart(dep, repos); err != nil {
+			if m.Debug {
+				fmt.Fprintf(m.Out, "Dependency %s %s is not usable from the cache: %s\n", dep.Name, dep.Version, err)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// copyCachedChart copies the archive of a chart from the repository cache to
+// dest.
+func copyCachedChart(name, dest string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dest, filepath.Base(name)), data, 0644)
+}
@@ -110,6 +110,11 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 	}
 
 	destfile := filepath.Join(dest, name)
+	unlock, err := c.lockCacheFile(dest, destfile)
+	if err != nil {
+		return destfile, nil, err
+	}
+	defer unlock()
 	if err := fileutil.AtomicWriteFile(destfile, data, 0644); err != nil {
 		return destfile, nil, err
 	}
@@ -126,6 +131,11 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 			return destfile, ver, nil
 		}
 		provfile := destfile + ".prov"
+		unlockProv, err := c.lockCacheFile(dest, provfile)
+		if err != nil {
+			return destfile, nil, err
+		}
+		defer unlockProv()
 		if err := fileutil.AtomicWriteFile(provfile, body, 0644); err != nil {
 			return destfile, nil, err
 		}
@@ -142,6 +152,15 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 	return destfile, ver, nil
 }
 
+// lockCacheFile locks a file downloaded to dest when dest is the repository
+// cache, which keeps the cache from being pruned while it is written.
+func (c *ChartDownloader) lockCacheFile(dest, path string) (func(), error) {
+	if c.RepositoryCache == "" || filepath.Clean(dest) != filepath.Clean(c.RepositoryCache) {
+		return func() {}, nil
+	}
+	return repo.LockCacheFile(path)
+}
+
 // ResolveChartVersion resolves a chart reference to a URL.
 //
 // It returns the URL and sets the ChartDownloader's Options that can fetch
@@ -205,6 +224,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 				c.Options,
 				getter.WithBasicAuth(rc.Username, rc.Password),
 				getter.WithPassCredentialsAll(rc.PassCredentialsAll),
+				getter.WithCredentialDomains(rc.CredentialDomains),
 			)
 		}
 		return u, nil
@@ -240,6 +260,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 			c.Options = append(c.Options,
 				getter.WithBasicAuth(r.Config.Username, r.Config.Password),
 				getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
+				getter.WithCredentialDomains(r.Config.CredentialDomains),
 			)
 		}
 	}
@@ -18,6 +18,7 @@ package downloader
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"helm.sh/helm/v4/internal/test/ensure"
@@ -58,6 +59,10 @@ func TestResolveChartRef(t *testing.T) {
 		{name: "no repository", ref: "oci://", fail: true},
 		{name: "oci ref", ref: "oci://example.com/helm-charts/nginx", version: "15.4.2", expect: "oci://example.com/helm-charts/nginx:15.4.2"},
 		{name: "oci ref with sha256 and version mismatch", ref: "oci://example.com/install/by/sha:0.1.1@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6", version: "0.1.2", fail: true},
+		{name: "oci ref with sha256", ref: "oci://example.com/install/by/sha@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6", expect: "oci://example.com/install/by/sha@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6"},
+		{name: "oci ref with tag and sha256", ref: "oci://example.com/install/by/sha:0.1.1@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6", expect: "oci://example.com/install/by/sha@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6"},
+		{name: "oci ref with sha256 and version", ref: "oci://example.com/install/by/sha@sha256:d234555386402a5867ef0169fefe5486858b6d8d209eaf32fd26d29b16807fd6", version: "0.1.1", fail: true},
+		{name: "oci ref with invalid sha256", ref: "oci://example.com/install/by/sha@sha256:d234", fail: true},
 	}
 
 	c := ChartDownloader{
@@ -140,7 +145,7 @@ func TestResolveChartOpts(t *testing.T) {
 			continue
 		}
 
-		if *(got.(*getter.HTTPGetter)) != *(expect.(*getter.HTTPGetter)) {
+		if !reflect.DeepEqual(got, expect) {
 			t.Errorf("%s: expected %s, got %s", tt.name, expect, got)
 		}
 	}
@@ -75,8 +75,22 @@ type Manager struct {
 	RegistryClient   *registry.Client
 	RepositoryConfig string
 	RepositoryCache  string
+	// DownloadRetries is the number of times a failed download is retried.
+	DownloadRetries int
+	// Concurrency is the number of dependencies downloaded at once. It
+	// defaults to DefaultConcurrency, 1 downloads them one at a time.
+	Concurrency int
+	// PreferCache takes the dependencies from the charts already in the
+	// repository cache when it holds their locked versions, matching the
+	// digests of the cached repository indexes, and only downloads the others. The repositories are not refreshed when every
+	// dependency is in the cache.
+	PreferCache bool
 }
 
+// DefaultConcurrency is the number of dependencies a Manager downloads at once
+// unless its Concurrency is set.
+const DefaultConcurrency = 4
+
 // Build rebuilds a local charts directory from a lockfile.
 //
 // If the lockfile is not present, this will run a Manager.Update()
@@ -133,7 +147,7 @@ func (m *Manager) Build() error {
 		return err
 	}
 
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && (!m.PreferCache || !m.allCached(lock.Dependencies)) {
 		// For each repo in the file, update the cached copy of that repo
 		if err := m.UpdateRepositories(); err != nil {
 			return err
@@ -181,9 +195,19 @@ func (m *Manager) Update() error {
 		return err
 	}
 
+	// With PreferCache, the dependencies are first resolved against the
+	// cached indexes, which are only refreshed if a resolved version is not
+	// in the cache.
+	var lock *chart.Lock
+	if !m.SkipUpdate && m.PreferCache {
+		if l, err := m.resolve(req, repoNames); err == nil && m.allCached(l.Dependencies) {
+			lock = l
+		}
+	}
+
 	// For each of the repositories Helm is configured to know about, update
 	// the index information locally.
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && lock == nil {
 		if err := m.UpdateRepositories(); err != nil {
 			return err
 		}
@@ -191,9 +215,10 @@ func (m *Manager) Update() error {
 
 	// Now we need to find out which version of a chart best satisfies the
 	// dependencies in the Chart.yaml
-	lock, err := m.resolve(req, repoNames)
-	if err != nil {
-		return err
+	if lock == nil {
+		if lock, err = m.resolve(req, repoNames); err != nil {
+			return err
+		}
 	}
 
 	// Now we need to fetch every package here into charts/
@@ -268,111 +293,193 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 	defer os.RemoveAll(tmpPath)
 
 	fmt.Fprintf(m.Out, "Saving %d charts\n", len(deps))
-	var saveError error
-	churls := make(map[string]struct{})
-	for _, dep := range deps {
-		// No repository means the chart is in charts directory
-		if dep.Repository == "" {
-			fmt.Fprintf(m.Out, "Dependency %s did not declare a repository. Assuming it exists in the charts directory\n", dep.Name)
-			// NOTE: we are only validating the local dependency conforms to the constraints. No copying to tmpPath is necessary.
-			chartPath := filepath.Join(destPath, dep.Name)
-			ch, err := loader.LoadDir(chartPath)
-			if err != nil {
-				return fmt.Errorf("unable to load chart '%s': %v", chartPath, err)
-			}
 
-			constraint, err := semver.NewConstraint(dep.Version)
-			if err != nil {
-				return fmt.Errorf("dependency %s has an invalid version/constraint format: %s", dep.Name, err)
-			}
+	// The dependencies are downloaded concurrently, so the output of the
+	// workers is serialized. repos is only read from here on.
+	out := &syncWriter{w: m.Out}
 
-			v, err := semver.NewVersion(ch.Metadata.Version)
-			if err != nil {
-				return fmt.Errorf("invalid version %s for dependency %s: %s", dep.Version, dep.Name, err)
-			}
+	concurrency := m.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
 
-			if !constraint.Check(v) {
-				saveError = fmt.Errorf("dependency %s at version %s does not satisfy the constraint %s", dep.Name, ch.Metadata.Version, dep.Version)
-				break
-			}
-			continue
+	var (
+		mu       sync.Mutex
+		churls   = make(map[string]struct{})
+		errs     = make([]error, len(deps))
+		failed   = make(chan struct{})
+		failOnce sync.Once
+		wg       sync.WaitGroup
+	)
+	// claim reports whether churl is yet to be downloaded, in which case the
+	// caller is now in charge of downloading it.
+	claim := func(churl string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := churls[churl]; ok {
+			return false
 		}
-		if strings.HasPrefix(dep.Repository, "file://") {
-			if m.Debug {
-				fmt.Fprintf(m.Out, "Archiving %s from repo %s\n", dep.Name, dep.Repository)
-			}
-			ver, err := tarFromLocalDir(m.ChartPath, dep.Name, dep.Repository, dep.Version, tmpPath)
-			if err != nil {
-				saveError = err
-				break
+		churls[churl] = struct{}{}
+		return true
+	}
+
+	next := make(chan int)
+	for range min(concurrency, len(deps)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				select {
+				case <-failed:
+					continue
+				default:
+				}
+				if err := m.downloadDependency(out, deps[i], repos, destPath, tmpPath, claim); err != nil {
+					errs[i] = fmt.Errorf("dependency %s: %w", deps[i].Name, err)
+					// An error cancels the downloads yet to be started.
+					failOnce.Do(func() { close(failed) })
+				}
 			}
-			dep.Version = ver
-			continue
+		}()
+	}
+feed:
+	for i := range deps {
+		select {
+		case next <- i:
+		case <-failed:
+			break feed
 		}
+	}
+	close(next)
+	wg.Wait()
+
+	// The errors are reported in the order of the dependencies.
+	if saveError := errors.Join(errs...); saveError != nil {
+		fmt.Fprintln(m.Out, "Save error occurred: ", saveError)
+		return saveError
+	}
 
-		// Any failure to resolve/download a chart should fail:
-		// https://github.com/helm/helm/issues/1439
-		churl, username, password, insecureskiptlsverify, passcredentialsall, caFile, certFile, keyFile, err := m.findChartURL(dep.Name, dep.Version, dep.Repository, repos)
+	// now we can move all downloaded charts to destPath and delete outdated dependencies
+	return m.safeMoveDeps(deps, tmpPath, destPath)
+}
+
+// downloadDependency downloads dep into tmpPath, or checks that it is already
+// in destPath when it has no repository, writing its progress to out. claim reports whether the chart at a
+// URL is yet to be downloaded, so that charts shared by several dependencies
+// are only downloaded once.
+func (m *Manager) downloadDependency(out io.Writer, dep *chart.Dependency, repos map[string]*repo.ChartRepository, destPath, tmpPath string, claim func(churl string) bool) error {
+	// No repository means the chart is in charts directory
+	if dep.Repository == "" {
+		fmt.Fprintf(out, "Dependency %s did not declare a repository. Assuming it exists in the charts directory\n", dep.Name)
+		// NOTE: we are only validating the local dependency conforms to the constraints. No copying to tmpPath is necessary.
+		chartPath := filepath.Join(destPath, dep.Name)
+		ch, err := loader.LoadDir(chartPath)
 		if err != nil {
-			saveError = fmt.Errorf("could not find %s: %w", churl, err)
-			break
+			return fmt.Errorf("unable to load chart '%s': %v", chartPath, err)
 		}
 
-		if _, ok := churls[churl]; ok {
-			fmt.Fprintf(m.Out, "Already downloaded %s from repo %s\n", dep.Name, dep.Repository)
-			continue
+		constraint, err := semver.NewConstraint(dep.Version)
+		if err != nil {
+			return fmt.Errorf("dependency %s has an invalid version/constraint format: %s", dep.Name, err)
 		}
 
-		fmt.Fprintf(m.Out, "Downloading %s from repo %s\n", dep.Name, dep.Repository)
+		v, err := semver.NewVersion(ch.Metadata.Version)
+		if err != nil {
+			return fmt.Errorf("invalid version %s for dependency %s: %s", dep.Version, dep.Name, err)
+		}
 
-		dl := ChartDownloader{
-			Out:              m.Out,
-			Verify:           m.Verify,
-			Keyring:          m.Keyring,
-			RepositoryConfig: m.RepositoryConfig,
-			RepositoryCache:  m.RepositoryCache,
-			RegistryClient:   m.RegistryClient,
-			Getters:          m.Getters,
-			Options: []getter.Option{
-				getter.WithBasicAuth(username, password),
-				getter.WithPassCredentialsAll(passcredentialsall),
-				getter.WithInsecureSkipVerifyTLS(insecureskiptlsverify),
-				getter.WithTLSClientConfig(certFile, keyFile, caFile),
-			},
+		if !constraint.Check(v) {
+			return fmt.Errorf("dependency %s at version %s does not satisfy the constraint %s", dep.Name, ch.Metadata.Version, dep.Version)
+		}
+		return nil
+	}
+	if strings.HasPrefix(dep.Repository, "file://") {
+		if m.Debug {
+			fmt.Fprintf(out, "Archiving %s from repo %s\n", dep.Name, dep.Repository)
 		}
+		ver, err := tarFromLocalDir(m.ChartPath, dep.Name, dep.Repository, dep.Version, tmpPath)
+		if err != nil {
+			return err
+		}
+		dep.Version = ver
+		return nil
+	}
 
-		version := ""
-		if registry.IsOCI(churl) {
-			churl, version, err = parseOCIRef(churl)
-			if err != nil {
-				return fmt.Errorf("could not parse OCI reference: %w", err)
+	if m.PreferCache {
+		cached, err := m.cachedChart(dep, repos)
+		if err == nil {
+			if !claim(cached) {
+				fmt.Fprintf(out, "Already copied %s from the cache\n", dep.Name)
+				return nil
 			}
-			dl.Options = append(dl.Options,
-				getter.WithRegistryClient(m.RegistryClient),
-				getter.WithTagName(version))
+			fmt.Fprintf(out, "Copying %s %s from the cache\n", dep.Name, dep.Version)
+			return copyCachedChart(cached, tmpPath)
 		}
-
-		if _, _, err = dl.DownloadTo(churl, version, tmpPath); err != nil {
-			saveError = fmt.Errorf("could not download %s: %w", churl, err)
-			break
+		if m.Debug {
+			fmt.Fprintf(out, "Dependency %s %s is not usable from the cache: %s\n", dep.Name, dep.Version, err)
 		}
+	}
 
-		churls[churl] = struct{}{}
+	// Any failure to resolve/download a chart should fail:
+	// https://github.com/helm/helm/issues/1439
+	churl, username, password, insecureskiptlsverify, passcredentialsall, caFile, certFile, keyFile, err := m.findChartURL(dep.Name, dep.Version, dep.Repository, repos)
+	if err != nil {
+		return fmt.Errorf("could not find %s: %w", churl, err)
 	}
 
-	// TODO: this should probably be refactored to be a []error, so we can capture and provide more information rather than "last error wins".
-	if saveError == nil {
-		// now we can move all downloaded charts to destPath and delete outdated dependencies
-		if err := m.safeMoveDeps(deps, tmpPath, destPath); err != nil {
-			return err
+	if !claim(churl) {
+		fmt.Fprintf(out, "Already downloaded %s from repo %s\n", dep.Name, dep.Repository)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Downloading %s from repo %s\n", dep.Name, dep.Repository)
+
+	dl := ChartDownloader{
+		Out:              out,
+		Verify:           m.Verify,
+		Keyring:          m.Keyring,
+		RepositoryConfig: m.RepositoryConfig,
+		RepositoryCache:  m.RepositoryCache,
+		RegistryClient:   m.RegistryClient,
+		Getters:          m.Getters,
+		Options: []getter.Option{
+			getter.WithBasicAuth(username, password),
+			getter.WithPassCredentialsAll(passcredentialsall),
+			getter.WithInsecureSkipVerifyTLS(insecureskiptlsverify),
+			getter.WithTLSClientConfig(certFile, keyFile, caFile),
+			getter.WithRetries(m.DownloadRetries, getter.DefaultRetryBackoff),
+		},
+	}
+
+	version := ""
+	if registry.IsOCI(churl) {
+		churl, version, err = parseOCIRef(churl)
+		if err != nil {
+			return fmt.Errorf("could not parse OCI reference: %w", err)
 		}
-	} else {
-		fmt.Fprintln(m.Out, "Save error occurred: ", saveError)
-		return saveError
+		dl.Options = append(dl.Options,
+			getter.WithRegistryClient(m.RegistryClient),
+			getter.WithTagName(version))
+	}
+
+	if _, _, err = dl.DownloadTo(churl, version, tmpPath); err != nil {
+		return fmt.Errorf("could not download %s: %w", churl, err)
 	}
 	return nil
 }
 
+// syncWriter serializes the writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
 func parseOCIRef(chartRef string) (string, string, error) {
 	refTagRegexp := regexp.MustCompile(`^(oci://[^:]+(:[0-9]{1,5})?[^:]+):(.*)$`)
 	caps := refTagRegexp.FindStringSubmatch(chartRef)
@@ -537,6 +644,13 @@ func (m *Manager) ensureMissingRepos(repoNames map[string]string, deps []*chart.
 			Name: rn,
 			URL:  dd.Repository,
 		}
+		// With PreferCache, the cached index of the repository is used as
+		// it is.
+		if m.PreferCache {
+			if _, err := os.Stat(filepath.Join(m.RepositoryCache, helmpath.CacheIndexFile(rn))); err == nil {
+				continue
+			}
+		}
 		ru = append(ru, ri)
 	}
 
@@ -0,0 +1,186 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/repo"
+)
+
+// latentRepo is a chart repository answering the requests for charts after a
+// delay, keeping track of how many were served at once.
+type latentRepo struct {
+	*httptest.Server
+	// missing holds the charts answered with a 404, after the delay.
+	missing     map[string]bool
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	downloads   int
+}
+
+func (r *latentRepo) enter() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight++
+	r.downloads++
+	r.maxInFlight = max(r.maxInFlight, r.inFlight)
+}
+
+func (r *latentRepo) leave() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+}
+
+// newLatentRepoManager serves charts dep-0 to dep-<count-1> from a latentRepo
+// and returns a Manager configured to use it, along with the dependencies on
+// those charts.
+func newLatentRepoManager(t *testing.T, count int, latency time.Duration) (*Manager, *latentRepo, []*chart.Dependency) {
+	t.Helper()
+	root := t.TempDir()
+	docroot := filepath.Join(root, "repo")
+	require.NoError(t, os.MkdirAll(docroot, 0755))
+
+	for i := range count {
+		c := &chart.Chart{Metadata: &chart.Metadata{Name: fmt.Sprintf("dep-%d", i), Version: "0.1.0", APIVersion: chart.APIVersionV2}}
+		_, err := chartutil.Save(c, docroot)
+		require.NoError(t, err)
+	}
+
+	r := &latentRepo{missing: make(map[string]bool)}
+	files := http.FileServer(http.Dir(docroot))
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, ".tgz") {
+			r.enter()
+			defer r.leave()
+			time.Sleep(latency)
+			if r.missing[filepath.Base(req.URL.Path)] {
+				http.NotFound(w, req)
+				return
+			}
+		}
+		files.ServeHTTP(w, req)
+	}))
+	t.Cleanup(r.Close)
+
+	index, err := repo.IndexDirectory(docroot, r.URL)
+	require.NoError(t, err)
+	cache := filepath.Join(root, "cache")
+	require.NoError(t, os.MkdirAll(cache, 0755))
+	require.NoError(t, index.WriteFile(filepath.Join(cache, helmpath.CacheIndexFile("latent")), 0644))
+
+	rf := repo.NewFile()
+	rf.Add(&repo.Entry{Name: "latent", URL: r.URL})
+	repoConfig := filepath.Join(root, "repositories.yaml")
+	require.NoError(t, rf.WriteFile(repoConfig, 0644))
+
+	var deps []*chart.Dependency
+	for i := range count {
+		deps = append(deps, &chart.Dependency{Name: fmt.Sprintf("dep-%d", i), Version: "0.1.0", Repository: r.URL})
+	}
+
+	m := &Manager{
+		Out:              new(bytes.Buffer),
+		ChartPath:        filepath.Join(root, "chart"),
+		RepositoryConfig: repoConfig,
+		RepositoryCache:  cache,
+		Getters: getter.Providers{getter.Provider{
+			Schemes: []string{"http", "https"},
+			New:     getter.NewHTTPGetter,
+		}},
+	}
+	require.NoError(t, os.MkdirAll(m.ChartPath, 0755))
+	return m, r, deps
+}
+
+func chartsDir(t *testing.T, m *Manager) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(m.ChartPath, "charts"))
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestDownloadAll_Concurrency(t *testing.T) {
+	expect := []string{"dep-0-0.1.0.tgz", "dep-1-0.1.0.tgz", "dep-2-0.1.0.tgz", "dep-3-0.1.0.tgz", "dep-4-0.1.0.tgz", "dep-5-0.1.0.tgz"}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		expect      int
+	}{
+		{name: "default", concurrency: 0, expect: DefaultConcurrency},
+		{name: "limited", concurrency: 2, expect: 2},
+		{name: "sequential", concurrency: 1, expect: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, r, deps := newLatentRepoManager(t, len(expect), 100*time.Millisecond)
+			m.Concurrency = tt.concurrency
+
+			require.NoError(t, m.downloadAll(deps))
+			assert.Equal(t, tt.expect, r.maxInFlight)
+			assert.Equal(t, len(expect), r.downloads)
+			assert.Equal(t, expect, chartsDir(t, m))
+		})
+	}
+}
+
+func TestDownloadAll_ConcurrencyErrors(t *testing.T) {
+	// The four downloads are in flight when the first fails.
+	m, r, deps := newLatentRepoManager(t, 4, 200*time.Millisecond)
+	r.missing["dep-1-0.1.0.tgz"] = true
+	r.missing["dep-2-0.1.0.tgz"] = true
+
+	err := m.downloadAll(deps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency dep-1: could not download")
+	assert.Contains(t, err.Error(), "dependency dep-2: could not download")
+	assert.Less(t, strings.Index(err.Error(), "dep-1"), strings.Index(err.Error(), "dep-2"), "errors are reported in the order of the dependencies")
+	assert.Empty(t, chartsDir(t, m), "nothing is saved when a download fails")
+
+	// Downloading one at a time, a failure cancels the downloads yet to start.
+	m, r, deps = newLatentRepoManager(t, 4, 10*time.Millisecond)
+	m.Concurrency = 1
+	deps[0].Version = "9.9.9"
+
+	err = m.downloadAll(deps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency dep-0: could not find")
+	assert.Zero(t, r.downloads)
+}
@@ -18,10 +18,13 @@ package downloader
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -505,6 +508,11 @@ func checkBuildWithOptionalFields(t *testing.T, chartName string, dep chart.Depe
 	if err := m.Build(); err != nil {
 		t.Fatal(err)
 	}
+
+	// The built charts/ directory matches the lock file.
+	if err := VerifyLock(dir(chartName)); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestBuild_WithoutOptionalFields(t *testing.T) {
@@ -548,6 +556,161 @@ func TestBuild_WithRepositoryAlias(t *testing.T) {
 	})
 }
 
+// offlineGetter fails every request, to prove nothing was fetched.
+type offlineGetter struct{}
+
+func (offlineGetter) Get(url string, _ ...getter.Option) (*bytes.Buffer, error) {
+	return nil, fmt.Errorf("offline: unexpected request to %s", url)
+}
+
+func TestBuild_PreferCache(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/*.tgz*"),
+	)
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+	cache := t.TempDir()
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "prefer-cache",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{
+				{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL()},
+			},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A first build refreshes the index of the repository in the cache and
+	// writes the lock file.
+	m := &Manager{
+		ChartPath: dir("prefer-cache"),
+		Out:       new(bytes.Buffer),
+		Getters: getter.Providers{getter.Provider{
+			Schemes: []string{"http", "https"},
+			New:     getter.NewHTTPGetter,
+		}},
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  cache,
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	offline := getter.Providers{getter.Provider{
+		Schemes: []string{"http", "https"},
+		New: func(...getter.Option) (getter.Getter, error) {
+			return offlineGetter{}, nil
+		},
+	}}
+	build := func(t *testing.T, preferCache bool) (string, error) {
+		t.Helper()
+		if err := os.RemoveAll(dir("prefer-cache", "charts")); err != nil {
+			t.Fatal(err)
+		}
+		b := new(bytes.Buffer)
+		m := &Manager{
+			ChartPath:        dir("prefer-cache"),
+			Out:              b,
+			Getters:          offline,
+			RepositoryConfig: dir("repositories.yaml"),
+			RepositoryCache:  cache,
+			PreferCache:      preferCache,
+		}
+		err := m.Build()
+		return b.String(), err
+	}
+
+	t.Run("missing from the cache", func(t *testing.T) {
+		if _, err := build(t, true); err == nil {
+			t.Fatal("expected the download of the dependency to fail")
+		}
+	})
+
+	data, err := os.ReadFile(filepath.Join("testdata", "local-subchart-0.1.0.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cache, "local-subchart-0.1.0.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("without prefer cache", func(t *testing.T) {
+		if _, err := build(t, false); err == nil {
+			t.Fatal("expected the download of the dependency to fail")
+		}
+	})
+
+	t.Run("in the cache", func(t *testing.T) {
+		out, err := build(t, true)
+		if err != nil {
+			t.Fatalf("%s\n%s", err, out)
+		}
+		if !strings.Contains(out, "Copying local-subchart 0.1.0 from the cache") {
+			t.Errorf("expected the dependency to be copied from the cache, got:\n%s", out)
+		}
+		if strings.Contains(out, "Hang tight") {
+			t.Errorf("expected the repositories not to be refreshed, got:\n%s", out)
+		}
+		if err := VerifyLock(dir("prefer-cache")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("without digest", func(t *testing.T) {
+		indexes, err := filepath.Glob(filepath.Join(cache, "*-index.yaml"))
+		if err != nil || len(indexes) == 0 {
+			t.Fatalf("expected cached indexes, got %v: %v", indexes, err)
+		}
+		for _, index := range indexes {
+			data, err := os.ReadFile(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = os.WriteFile(index, data, 0644) })
+			if err := os.WriteFile(index, regexp.MustCompile(`(?m)^\s*digest:.*\n`).ReplaceAll(data, nil), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out, err := build(t, true)
+		if err == nil {
+			t.Fatal("expected the download of the dependency to fail")
+		}
+		if strings.Contains(out, "from the cache") {
+			t.Errorf("expected a chart without digest not to be copied from the cache, got:\n%s", out)
+		}
+	})
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		// The same chart, archived again, does not match the digest of the
+		// index.
+		local, err := loader.LoadDir(filepath.Join("testdata", "local-subchart"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := chartutil.SaveWithOptions(local, cache, chartutil.SaveOptions{ModTime: time.Unix(1, 0)}); err != nil {
+			t.Fatal(err)
+		}
+		out, err := build(t, true)
+		if err == nil {
+			t.Fatal("expected the download of the dependency to fail")
+		}
+		if strings.Contains(out, "from the cache") {
+			t.Errorf("expected the dependency not to be copied from the cache, got:\n%s", out)
+		}
+	})
+}
+
 func TestErrRepoNotFound_Error(t *testing.T) {
 	type fields struct {
 		Repos []string
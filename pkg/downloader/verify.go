@@ -0,0 +1,159 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v4/internal/resolver"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+)
+
+// VerifyLock checks, without any network access, that the unpacked chart at
+// chartPath is ready to be packaged from its lock file: the lock file must be
+// in sync with the dependencies of the chart, and every locked dependency
+// must be present in the charts/ directory at its locked version.
+//
+// Every discrepancy found is reported in the returned error. A chart without
+// dependencies needs no lock file.
+func VerifyLock(chartPath string) error {
+	if fi, err := os.Stat(chartPath); err != nil {
+		return fmt.Errorf("could not find %s: %w", chartPath, err)
+	} else if !fi.IsDir() {
+		return errors.New("only unpacked charts can be verified")
+	}
+	c, err := loader.LoadDir(chartPath)
+	if err != nil {
+		return err
+	}
+	if c.Lock == nil {
+		if len(c.Metadata.Dependencies) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s has dependencies but no lock file. Please update the dependencies", c.Name())
+	}
+
+	errs := []error{VerifyLockDigest(c)}
+	errs = append(errs, VerifyLockedCharts(filepath.Join(chartPath, "charts"), c.Lock.Dependencies)...)
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("the dependencies of %s do not match its lock file:\n%w", c.Name(), err)
+	}
+	return nil
+}
+
+// VerifyLockDigest checks that the digest recorded in the lock file of a chart
+// matches the dependencies of the chart.
+//
+// The digest is computed once repository aliases are replaced with the URLs
+// they stand for. Aliases are resolved from the repositories recorded in the
+// lock file, so that no repository configuration is needed.
+func VerifyLockDigest(c *chart.Chart) error {
+	lockName, reqName := "Chart.lock", "Chart.yaml"
+	if c.Metadata.APIVersion == chart.APIVersionV1 {
+		lockName, reqName = "requirements.lock", "requirements.yaml"
+	}
+	outOfSync := fmt.Errorf("the lock file (%s) is out of sync with the dependencies file (%s). Please update the dependencies", lockName, reqName)
+
+	if c.Lock == nil {
+		return fmt.Errorf("no lock file (%s) found", lockName)
+	}
+	req := c.Metadata.Dependencies
+	lock := c.Lock.Dependencies
+
+	// Charts built with Helm 2 hash the dependencies before aliases are
+	// resolved. See https://github.com/helm/helm/issues/7233
+	if c.Metadata.APIVersion == chart.APIVersionV1 {
+		if sum, err := resolver.HashV2Req(req); err == nil && sum == c.Lock.Digest {
+			return nil
+		}
+	}
+
+	resolved := make([]*chart.Dependency, len(req))
+	for i, d := range req {
+		dep := *d
+		if strings.HasPrefix(dep.Repository, "@") || strings.HasPrefix(dep.Repository, "alias:") {
+			// The resolver locks the dependencies in the order they are
+			// declared in, and records the URL of the repository.
+			if i >= len(lock) || lock[i].Name != dep.Name {
+				return outOfSync
+			}
+			dep.Repository = lock[i].Repository
+		}
+		resolved[i] = &dep
+	}
+	if sum, err := resolver.HashReq(resolved, lock); err != nil || sum != c.Lock.Digest {
+		return outOfSync
+	}
+	return nil
+}
+
+// VerifyLockedCharts checks that every locked dependency is present in the
+// charts directory chartsDir, as an archive or an unpacked directory, at its
+// locked version. One error is returned for each discrepancy.
+func VerifyLockedCharts(chartsDir string, deps []*chart.Dependency) []error {
+	files, err := os.ReadDir(chartsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return []error{fmt.Errorf("unable to read %s: %w", chartsDir, err)}
+	}
+
+	var errs []error
+	found := make(map[string][]*chart.Chart)
+	paths := make(map[*chart.Chart]string)
+	for _, f := range files {
+		if !f.IsDir() && filepath.Ext(f.Name()) != ".tgz" {
+			continue
+		}
+		p := filepath.Join("charts", f.Name())
+		ch, err := loader.Load(filepath.Join(chartsDir, f.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s is not a valid chart: %w", p, err))
+			continue
+		}
+		found[ch.Name()] = append(found[ch.Name()], ch)
+		paths[ch] = p
+	}
+
+	for _, dep := range deps {
+		charts := found[dep.Name]
+		if len(charts) == 0 {
+			errs = append(errs, fmt.Errorf("dependency %q is locked at version %s but is missing from charts/", dep.Name, dep.Version))
+			continue
+		}
+		var locked bool
+		var others []*chart.Chart
+		for _, ch := range charts {
+			if versionEquals(ch.Metadata.Version, dep.Version) {
+				locked = true
+			} else {
+				others = append(others, ch)
+			}
+		}
+		for _, ch := range others {
+			if locked {
+				errs = append(errs, fmt.Errorf("%s holds version %s of dependency %q, in addition to the locked version %s", paths[ch], ch.Metadata.Version, dep.Name, dep.Version))
+			} else {
+				errs = append(errs, fmt.Errorf("%s holds version %s of dependency %q, but version %s is locked", paths[ch], ch.Metadata.Version, dep.Name, dep.Version))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,186 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v4/internal/resolver"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// saveLockedChart saves a chart depending on local-subchart 0.1.0, with a
+// lock file in sync with its dependencies, and returns its path. The charts/
+// directory is left empty.
+func saveLockedChart(t *testing.T, repository string) string {
+	t.Helper()
+	dep := &chart.Dependency{Name: "local-subchart", Version: "^0.1.0", Repository: repository}
+	locked := &chart.Dependency{Name: "local-subchart", Version: "0.1.0", Repository: "https://charts.example.com"}
+
+	hashed := *dep
+	hashed.Repository = locked.Repository
+	digest, err := resolver.HashReq([]*chart.Dependency{&hashed}, []*chart.Dependency{locked})
+	require.NoError(t, err)
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:         "locked",
+			Version:      "0.1.0",
+			APIVersion:   chart.APIVersionV2,
+			Dependencies: []*chart.Dependency{dep},
+		},
+	}
+	lock, err := yaml.Marshal(&chart.Lock{
+		Generated:    time.Now(),
+		Digest:       digest,
+		Dependencies: []*chart.Dependency{locked},
+	})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, chartutil.SaveDir(c, dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "locked", "Chart.lock"), lock, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "locked", "charts"), 0755))
+	return filepath.Join(dir, "locked")
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dst, data, 0644))
+}
+
+func TestVerifyLock(t *testing.T) {
+	chartPath := saveLockedChart(t, "https://charts.example.com")
+	copyFile(t, "testdata/local-subchart-0.1.0.tgz", filepath.Join(chartPath, "charts", "local-subchart-0.1.0.tgz"))
+
+	assert.NoError(t, VerifyLock(chartPath))
+}
+
+func TestVerifyLock_RepositoryAlias(t *testing.T) {
+	for _, repository := range []string{"@example", "alias:example"} {
+		t.Run(repository, func(t *testing.T) {
+			chartPath := saveLockedChart(t, repository)
+			copyFile(t, "testdata/local-subchart-0.1.0.tgz", filepath.Join(chartPath, "charts", "local-subchart-0.1.0.tgz"))
+
+			assert.NoError(t, VerifyLock(chartPath))
+		})
+	}
+}
+
+func TestVerifyLock_UnpackedDependency(t *testing.T) {
+	chartPath := saveLockedChart(t, "https://charts.example.com")
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "local-subchart", Version: "0.1.0", APIVersion: chart.APIVersionV2},
+	}
+	require.NoError(t, chartutil.SaveDir(sub, filepath.Join(chartPath, "charts")))
+
+	assert.NoError(t, VerifyLock(chartPath))
+}
+
+func TestVerifyLock_Discrepancies(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, chartPath string)
+		expect []string
+	}{
+		{
+			name:   "missing dependency",
+			setup:  func(*testing.T, string) {},
+			expect: []string{`dependency "local-subchart" is locked at version 0.1.0 but is missing from charts/`},
+		},
+		{
+			name: "wrong version",
+			setup: func(t *testing.T, chartPath string) {
+				sub := &chart.Chart{
+					Metadata: &chart.Metadata{Name: "local-subchart", Version: "0.2.0", APIVersion: chart.APIVersionV2},
+				}
+				require.NoError(t, chartutil.SaveDir(sub, filepath.Join(chartPath, "charts")))
+			},
+			expect: []string{`charts/local-subchart holds version 0.2.0 of dependency "local-subchart", but version 0.1.0 is locked`},
+		},
+		{
+			name: "additional version",
+			setup: func(t *testing.T, chartPath string) {
+				copyFile(t, "testdata/local-subchart-0.1.0.tgz", filepath.Join(chartPath, "charts", "local-subchart-0.1.0.tgz"))
+				sub := &chart.Chart{
+					Metadata: &chart.Metadata{Name: "local-subchart", Version: "0.2.0", APIVersion: chart.APIVersionV2},
+				}
+				require.NoError(t, chartutil.SaveDir(sub, filepath.Join(chartPath, "charts")))
+			},
+			expect: []string{`charts/local-subchart holds version 0.2.0 of dependency "local-subchart", in addition to the locked version 0.1.0`},
+		},
+		{
+			name: "out of sync lock file",
+			setup: func(t *testing.T, chartPath string) {
+				copyFile(t, "testdata/local-subchart-0.1.0.tgz", filepath.Join(chartPath, "charts", "local-subchart-0.1.0.tgz"))
+				md, err := chartutil.LoadChartfile(filepath.Join(chartPath, "Chart.yaml"))
+				require.NoError(t, err)
+				md.Dependencies[0].Version = "^0.2.0"
+				require.NoError(t, chartutil.SaveChartfile(filepath.Join(chartPath, "Chart.yaml"), md))
+			},
+			expect: []string{"the lock file (Chart.lock) is out of sync with the dependencies file (Chart.yaml)"},
+		},
+		{
+			name: "every discrepancy",
+			setup: func(t *testing.T, chartPath string) {
+				md, err := chartutil.LoadChartfile(filepath.Join(chartPath, "Chart.yaml"))
+				require.NoError(t, err)
+				md.Dependencies[0].Repository = "https://other.example.com"
+				require.NoError(t, chartutil.SaveChartfile(filepath.Join(chartPath, "Chart.yaml"), md))
+			},
+			expect: []string{
+				"the lock file (Chart.lock) is out of sync with the dependencies file (Chart.yaml)",
+				`dependency "local-subchart" is locked at version 0.1.0 but is missing from charts/`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartPath := saveLockedChart(t, "https://charts.example.com")
+			tt.setup(t, chartPath)
+
+			err := VerifyLock(chartPath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "the dependencies of locked do not match its lock file")
+			for _, e := range tt.expect {
+				assert.Contains(t, err.Error(), e)
+			}
+		})
+	}
+}
+
+func TestVerifyLock_NoLockFile(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "unlocked", Version: "0.1.0", APIVersion: chart.APIVersionV2},
+	}
+	dir := t.TempDir()
+	require.NoError(t, chartutil.SaveDir(c, dir))
+	assert.NoError(t, VerifyLock(filepath.Join(dir, "unlocked")), "a chart without dependencies needs no lock file")
+
+	c.Metadata.Dependencies = []*chart.Dependency{{Name: "local-subchart", Version: "0.1.0", Repository: "https://charts.example.com"}}
+	require.NoError(t, chartutil.SaveChartfile(filepath.Join(dir, "unlocked", "Chart.yaml"), c.Metadata))
+	assert.ErrorContains(t, VerifyLock(filepath.Join(dir, "unlocked")), "unlocked has dependencies but no lock file")
+}
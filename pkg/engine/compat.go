@@ -0,0 +1,268 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// CompatKind is the kind of template identifier a CompatRule applies to.
+type CompatKind string
+
+const (
+	// CompatFunction is a template function, e.g. "env".
+	CompatFunction CompatKind = "function"
+	// CompatField is a field of the render context, given by its path from
+	// the root, e.g. ".Capabilities.TillerVersion".
+	CompatField CompatKind = "field"
+)
+
+// CompatRule describes the lifecycle of a template identifier.
+type CompatRule struct {
+	// Identifier is the name of the function, or the path of the field.
+	Identifier string
+	Kind       CompatKind
+	// DeprecatedIn is the Helm version that deprecated the identifier, if any.
+	DeprecatedIn string
+	// RemovedIn is the Helm version that removed the identifier. It is empty
+	// while the identifier is still available.
+	RemovedIn string
+	// Replacement is the identifier to use instead, if any.
+	Replacement string
+	// Note tells what to do instead when there is no replacement.
+	Note string
+}
+
+// DefaultCompatRules lists the template identifiers which were deprecated or
+// removed between Helm versions.
+var DefaultCompatRules = []CompatRule{
+	{Identifier: "env", Kind: CompatFunction, RemovedIn: "v2.0.0", Note: "templates cannot read the environment, pass the value in with the chart values"},
+	{Identifier: "expandenv", Kind: CompatFunction, RemovedIn: "v2.0.0", Note: "templates cannot read the environment, pass the value in with the chart values"},
+	{Identifier: "trimall", Kind: CompatFunction, DeprecatedIn: "v3.0.0", Replacement: "trimAll"},
+	{Identifier: ".Capabilities.TillerVersion", Kind: CompatField, RemovedIn: "v3.0.0", Replacement: ".Capabilities.HelmVersion"},
+	{Identifier: ".Capabilities.KubeVersion.GitVersion", Kind: CompatField, DeprecatedIn: "v3.0.0", Replacement: ".Capabilities.KubeVersion.Version"},
+	{Identifier: ".Chart.ApiVersion", Kind: CompatField, RemovedIn: "v3.0.0", Replacement: ".Chart.APIVersion"},
+	{Identifier: ".Release.Time", Kind: CompatField, RemovedIn: "v3.0.0", Note: "use the now function for the time of rendering"},
+}
+
+// Removed reports whether the identifier is no longer available.
+func (r CompatRule) Removed() bool { return r.RemovedIn != "" }
+
+func (r CompatRule) String() string {
+	name := r.Identifier
+	if r.Kind == CompatFunction {
+		name = fmt.Sprintf("the %q function", r.Identifier)
+	}
+
+	var b strings.Builder
+	switch {
+	case r.Removed():
+		fmt.Fprintf(&b, "%s was removed in Helm %s", name, r.RemovedIn)
+	case r.DeprecatedIn != "":
+		fmt.Fprintf(&b, "%s is deprecated since Helm %s", name, r.DeprecatedIn)
+	default:
+		fmt.Fprintf(&b, "%s is deprecated", name)
+	}
+	if r.Replacement != "" {
+		fmt.Fprintf(&b, "; use %s instead", r.Replacement)
+	} else if r.Note != "" {
+		fmt.Fprintf(&b, "; %s", r.Note)
+	}
+	return b.String()
+}
+
+// matchesField reports whether the field path, e.g. ".Release.Time.Unix",
+// starts with the field of the rule.
+func (r CompatRule) matchesField(path string) bool {
+	return r.Kind == CompatField && (path == r.Identifier || strings.HasPrefix(path, r.Identifier+"."))
+}
+
+// CompatWarning is a use of a template identifier listed in a CompatRule.
+type CompatWarning struct {
+	// Template is the name of the template using the identifier.
+	Template string
+	// Location is where the identifier is used, as "template:line:column".
+	Location string
+	Rule     CompatRule
+
+	pos parse.Pos
+}
+
+func (w CompatWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Location, w.Rule)
+}
+
+// CompatWarnings parses templates, keyed by name, without rendering them and
+// returns the uses of the identifiers listed in DefaultCompatRules, in order
+// of template and position. The templates using removed functions are still
+// scanned; the errors of the templates which cannot be parsed for other
+// reasons are joined.
+func (e Engine) CompatWarnings(templates map[string]string) ([]CompatWarning, error) {
+	t := template.New("gotpl")
+	if err := e.initFunMap(t); err != nil {
+		return nil, err
+	}
+	custom := e.hostFuncs()
+
+	// Stand in for the removed functions, so the templates using them parse.
+	stubs := template.FuncMap{}
+	for _, r := range DefaultCompatRules {
+		if r.Kind == CompatFunction && r.Removed() {
+			if _, ok := custom[r.Identifier]; !ok {
+				stubs[r.Identifier] = func(...interface{}) string { return "" }
+			}
+		}
+	}
+	t.Funcs(stubs)
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if _, err := t.New(name).Parse(templates[name]); err != nil {
+			errs = append(errs, cleanupParseError(name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var warnings []CompatWarning
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil || tpl.Tree.Root == nil {
+			continue
+		}
+		s := compatScanner{tree: tpl.Tree, custom: custom}
+		s.walk(tpl.Tree.Root)
+		warnings = append(warnings, s.warnings...)
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Template != warnings[j].Template {
+			return warnings[i].Template < warnings[j].Template
+		}
+		return warnings[i].pos < warnings[j].pos
+	})
+	return warnings, nil
+}
+
+// compatScanner collects the uses of the identifiers listed in
+// DefaultCompatRules in a parsed template.
+type compatScanner struct {
+	tree     *parse.Tree
+	custom   template.FuncMap
+	warnings []CompatWarning
+}
+
+func (s *compatScanner) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			s.walk(child)
+		}
+	case *parse.ActionNode:
+		s.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			s.walk(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			s.walk(arg)
+		}
+	case *parse.IfNode:
+		s.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		s.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		s.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		s.walk(n.Pipe)
+	case *parse.ChainNode:
+		s.walk(n.Node)
+	case *parse.IdentifierNode:
+		if _, ok := s.custom[n.Ident]; ok {
+			return
+		}
+		for _, r := range DefaultCompatRules {
+			if r.Kind == CompatFunction && r.Identifier == n.Ident {
+				s.report(n, r)
+			}
+		}
+	case *parse.FieldNode:
+		s.checkField(n, "."+strings.Join(n.Ident, "."))
+	case *parse.VariableNode:
+		// Only $ is known to hold the root of the render context.
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			s.checkField(n, "."+strings.Join(n.Ident[1:], "."))
+		}
+	}
+}
+
+func (s *compatScanner) walkBranch(n *parse.BranchNode) {
+	s.walk(n.Pipe)
+	s.walk(n.List)
+	s.walk(n.ElseList)
+}
+
+func (s *compatScanner) checkField(n parse.Node, path string) {
+	for _, r := range DefaultCompatRules {
+		if r.matchesField(path) {
+			s.report(n, r)
+		}
+	}
+}
+
+func (s *compatScanner) report(n parse.Node, r CompatRule) {
+	location, _ := s.tree.ErrorContext(n)
+	s.warnings = append(s.warnings, CompatWarning{
+		Template: s.tree.ParseName,
+		Location: location,
+		Rule:     r,
+		pos:      n.Position(),
+	})
+}
+
+// compatHints explains the failures caused by the use of a removed function
+// or field, given the name of the undefined function or the path of the field
+// evaluated.
+func compatHints(function, field string) []string {
+	var hints []string
+	for _, r := range DefaultCompatRules {
+		if !r.Removed() {
+			continue
+		}
+		if (function != "" && r.Kind == CompatFunction && r.Identifier == function) || (field != "" && r.matchesField(field)) {
+			hints = append(hints, r.String())
+		}
+	}
+	return hints
+}
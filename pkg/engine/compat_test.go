@@ -0,0 +1,104 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestRenderCompatHints(t *testing.T) {
+	cases := []struct {
+		name     string
+		tpl      string
+		expected string
+	}{
+		{
+			name: "removed function",
+			tpl:  `{{ env "HOME" }}`,
+			expected: `parse error at (compat:1): function "env" not defined
+hint: the "env" function was removed in Helm v2.0.0; templates cannot read the environment, pass the value in with the chart values`,
+		},
+		{
+			name: "removed field",
+			tpl:  `{{ .Capabilities.TillerVersion.SemVer }}`,
+			expected: `compat:1:16
+  executing "compat" at <.Capabilities.TillerVersion.SemVer>:
+    can't evaluate field TillerVersion in type interface {}
+hint: .Capabilities.TillerVersion was removed in Helm v3.0.0; use .Capabilities.HelmVersion instead`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tpls := map[string]renderable{
+				"compat": {tpl: tt.tpl, vals: chartutil.Values{
+					"Values":       map[string]interface{}{},
+					"Capabilities": chartutil.DefaultCapabilities,
+				}},
+			}
+			_, err := new(Engine).render(tpls)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestCompatWarnings(t *testing.T) {
+	templates := map[string]string{
+		"chart/templates/_helpers.tpl": `{{ define "chart.version" }}{{ $.Capabilities.KubeVersion.GitVersion }}{{ end }}`,
+		"chart/templates/configmap.yaml": `home: {{ env "HOME" }}
+{{- if .Capabilities.TillerVersion }}
+name: {{ trimall "-" .Release.Name }}
+{{- end }}`,
+		"chart/templates/service.yaml": `version: {{ .Capabilities.KubeVersion.Version }}`,
+	}
+
+	warnings, err := new(Engine).CompatWarnings(templates)
+	require.NoError(t, err)
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	assert.Equal(t, []string{
+		`chart/templates/_helpers.tpl:1:32: .Capabilities.KubeVersion.GitVersion is deprecated since Helm v3.0.0; use .Capabilities.KubeVersion.Version instead`,
+		`chart/templates/configmap.yaml:1:9: the "env" function was removed in Helm v2.0.0; templates cannot read the environment, pass the value in with the chart values`,
+		`chart/templates/configmap.yaml:2:20: .Capabilities.TillerVersion was removed in Helm v3.0.0; use .Capabilities.HelmVersion instead`,
+		`chart/templates/configmap.yaml:3:9: the "trimall" function is deprecated since Helm v3.0.0; use trimAll instead`,
+	}, got)
+	assert.True(t, warnings[1].Rule.Removed())
+	assert.False(t, warnings[3].Rule.Removed())
+}
+
+func TestCompatWarningsCustomFunction(t *testing.T) {
+	e := Engine{CustomTemplateFuncs: template.FuncMap{
+		"env": func(string) string { return "" },
+	}}
+	warnings, err := e.CompatWarnings(map[string]string{"t": `{{ env "HOME" }}`})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestCompatWarningsParseError(t *testing.T) {
+	_, err := new(Engine).CompatWarnings(map[string]string{"t": `{{ if }}`})
+	assert.ErrorContains(t, err, "parse error at (t:1)")
+}
@@ -0,0 +1,117 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"slices"
+	"text/template"
+)
+
+// seededFuncs are the functions returning random values which are derived
+// from the RandomSeed of a deterministic render.
+var seededFuncs = []string{
+	"randAlphaNum", "randAlpha", "randAscii", "randNumeric", "randBytes", "randInt", "uuidv4", "shuffle",
+}
+
+// nondeterministicFuncs are the functions whose results cannot be derived
+// from a seed, as they read the clock or the cluster, or use cryptographic
+// randomness.
+var nondeterministicFuncs = []string{
+	"now", "ago", "lookup",
+	"genPrivateKey", "genCA", "genCAWithKey", "genSelfSignedCert", "genSelfSignedCertWithKey",
+	"genSignedCert", "genSignedCertWithKey", "encryptAES", "htpasswd", "bcrypt",
+}
+
+const (
+	alphaChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	numericChars = "0123456789"
+)
+
+// asciiChars are the printable ASCII characters, as randAscii returns.
+var asciiChars = func() string {
+	b := make([]byte, 0, '~'-' '+1)
+	for c := byte(' '); c <= '~'; c++ {
+		b = append(b, c)
+	}
+	return string(b)
+}()
+
+// deterministicFuncs returns the functions replacing the ones of the templates
+// which return random or time dependent values in a deterministic render.
+// With a seed, the random values are drawn from a source seeded with it, in
+// the order the templates call the functions. Without one, the functions
+// fail, as do the ones which cannot be derived from a seed, and getHostByName
+// when it resolves names.
+func deterministicFuncs(seed *int64, enableDNS bool) template.FuncMap {
+	f := make(template.FuncMap, len(seededFuncs)+len(nondeterministicFuncs)+1)
+	failing := nondeterministicFuncs
+	if enableDNS {
+		failing = append(slices.Clone(failing), "getHostByName")
+	}
+	for _, name := range failing {
+		f[name] = func(...interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("function %q is not deterministic and cannot be used in a deterministic render", name)
+		}
+	}
+	if seed == nil {
+		for _, name := range seededFuncs {
+			f[name] = func(...interface{}) (interface{}, error) {
+				return nil, fmt.Errorf("function %q returns random values, a random seed is required to use it in a deterministic render", name)
+			}
+		}
+		return f
+	}
+
+	r := rand.New(rand.NewSource(*seed))
+	randString := func(chars string) func(int) string {
+		return func(count int) string {
+			b := make([]byte, count)
+			for i := range b {
+				b[i] = chars[r.Intn(len(chars))]
+			}
+			return string(b)
+		}
+	}
+	f["randAlphaNum"] = randString(alphaChars + numericChars)
+	f["randAlpha"] = randString(alphaChars)
+	f["randNumeric"] = randString(numericChars)
+	f["randAscii"] = randString(asciiChars)
+	f["randBytes"] = func(count int) (string, error) {
+		b := make([]byte, count)
+		r.Read(b)
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	f["randInt"] = func(low, high int) int {
+		return r.Intn(high-low) + low
+	}
+	f["uuidv4"] = func() string {
+		var u [16]byte
+		r.Read(u[:])
+		u[6] = (u[6] & 0x0f) | 0x40 // Version 4
+		u[8] = (u[8] & 0x3f) | 0x80 // Variant RFC 4122
+		return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+	}
+	f["shuffle"] = func(s string) string {
+		runes := []rune(s)
+		r.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+		return string(runes)
+	}
+	return f
+}
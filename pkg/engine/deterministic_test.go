@@ -0,0 +1,80 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const randomTemplate = `{{ randAlphaNum 8 }} {{ randAlpha 4 }} {{ randNumeric 4 }} {{ randAscii 4 | b64enc }} {{ randInt 10 20 }} {{ randBytes 6 }} {{ uuidv4 }} {{ shuffle "abcdef" }}`
+
+func TestRenderDeterministicSeeded(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{
+		"templates/cm.yaml":  randomTemplate,
+		"templates/tpl.yaml": `{{ tpl "{{ randAlphaNum 8 }}" . }}`,
+	})
+
+	render := func(seed int64) map[string]string {
+		e := Engine{Deterministic: true, RandomSeed: &seed}
+		out, err := e.Render(c, v)
+		require.NoError(t, err)
+		return out
+	}
+
+	first := render(42)
+	assert.Equal(t, first, render(42), "the same seed renders the same values")
+	assert.NotEqual(t, first, render(43), "another seed renders other values")
+	assert.Regexp(t, `^[a-zA-Z0-9]{8} [a-zA-Z]{4} [0-9]{4} \S+ 1[0-9] \S+ [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12} [a-f]{6}$`, first["host/templates/cm.yaml"])
+}
+
+func TestRenderDeterministicWithoutSeed(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{"templates/cm.yaml": `{{ randAlphaNum 8 }}`})
+
+	e := Engine{Deterministic: true}
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "randAlphaNum" returns random values, a random seed is required`)
+
+	// Outside of a deterministic render, the function is the one of sprig.
+	e.Deterministic = false
+	out, err := e.Render(c, v)
+	require.NoError(t, err)
+	assert.Len(t, out["host/templates/cm.yaml"], 8)
+}
+
+func TestRenderDeterministicNondeterministicFuncs(t *testing.T) {
+	seed := int64(1)
+	for _, tpl := range []string{`{{ now }}`, `{{ genCA "ca" 365 }}`, `{{ bcrypt "password" }}`, `{{ lookup "v1" "Pod" "" "" }}`} {
+		c, v := funcMapOptionsChart(map[string]string{"templates/cm.yaml": tpl})
+		e := Engine{Deterministic: true, RandomSeed: &seed}
+		_, err := e.Render(c, v)
+		require.Error(t, err, tpl)
+		assert.Contains(t, err.Error(), "is not deterministic and cannot be used in a deterministic render", tpl)
+	}
+
+	// getHostByName is deterministic unless it resolves names.
+	c, v := funcMapOptionsChart(map[string]string{"templates/cm.yaml": `{{ getHostByName "example.com" }}`})
+	e := Engine{Deterministic: true}
+	_, err := e.Render(c, v)
+	require.NoError(t, err)
+	e.EnableDNS = true
+	_, err = e.Render(c, v)
+	assert.ErrorContains(t, err, `function "getHostByName" is not deterministic`)
+}
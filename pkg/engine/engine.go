@@ -49,7 +49,8 @@ var execErrNoTemplateAssociated = regexp.MustCompile(`^template: no template (?P
 // Engine is an implementation of the Helm rendering implementation for templates.
 type Engine struct {
 	// If strict is enabled, template rendering will fail if a template references
-	// a value that was not passed in.
+	// a value that was not passed in. The values given to default, required,
+	// empty, coalesce and hasKey may still be missing.
 	Strict bool
 	// In LintMode, some 'required' template values may be missing, so don't fail
 	LintMode bool
@@ -59,6 +60,21 @@ type Engine struct {
 	EnableDNS bool
 	// CustomTemplateFuncs is defined by users to provide custom template funcs
 	CustomTemplateFuncs template.FuncMap
+	// FuncMapOptions adds functions to the templates and disables some of
+	// them, for the programs embedding the engine.
+	FuncMapOptions FuncMapOptions
+	// Deterministic makes the output of the templates depend on their inputs
+	// only. The functions returning random values draw them from RandomSeed,
+	// and fail without one, and the ones reading the clock or using
+	// cryptographic randomness fail.
+	Deterministic bool
+	// RandomSeed seeds the random values of a deterministic render.
+	RandomSeed *int64
+	// Targets, if set, selects the templates the caller is interested in.
+	// Templates it returns false for are not executed and left out of the
+	// results, unless skipping them could change the output of the selected
+	// templates, in which case everything is rendered as usual.
+	Targets func(templateName string) bool
 }
 
 // New creates a new instance of Engine using the passed in rest config.
@@ -119,6 +135,82 @@ func RenderWithClientProvider(chrt *chart.Chart, values chartutil.Values, client
 	}.Render(chrt, values)
 }
 
+// RenderStrings evaluates each of the given template strings the way the
+// 'tpl' function would when called from a template at the root of the chart.
+//
+// The chart's templates are parsed first so that named templates can be used
+// with 'include', and each string is executed against the root chart's render
+// context (.Values, .Release, .Chart, ...). Results are keyed like the input.
+// Strings that fail to render are left out of the results and reported in the
+// returned error map instead. The returned error is only set when the chart's
+// own templates cannot be parsed.
+func (e Engine) RenderStrings(chrt *chart.Chart, values chartutil.Values, tpls map[string]string) (rendered map[string]string, failed map[string]error, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("rendering template failed: %v", r)
+		}
+	}()
+
+	tmap := make(map[string]renderable)
+	top := recAllTpls(chrt, tmap, values)
+
+	t := template.New("gotpl")
+	if e.Strict {
+		t.Option("missingkey=error")
+	} else {
+		t.Option("missingkey=zero")
+	}
+	if err := e.initFunMap(t); err != nil {
+		return nil, nil, err
+	}
+
+	for _, filename := range sortTemplates(tmap) {
+		if _, err := t.New(filename).Parse(tmap[filename].tpl); err != nil {
+			return nil, nil, withErrorHints(cleanupParseError(filename, err), err.Error(), nil, nil)
+		}
+	}
+	if e.Strict {
+		allowMissingValues(t)
+	}
+
+	tpl := tplFun(t, make(map[string]int), e.Strict, e.FuncMapOptions.disabled())
+	rendered = make(map[string]string, len(tpls))
+	failed = make(map[string]error)
+	for key, s := range tpls {
+		out, err := tpl(s, top)
+		if err != nil {
+			failed[key] = err
+			continue
+		}
+		rendered[key] = out
+	}
+	return rendered, failed, nil
+}
+
+// Parse parses templates, keyed by file name, without rendering them, so no
+// values are needed. Unlike rendering it does not stop at the first template
+// which cannot be parsed: the errors of every template are joined.
+func (e Engine) Parse(templates map[string]string) error {
+	t := template.New("gotpl")
+	if err := e.initFunMap(t); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if _, err := t.New(name).Parse(templates[name]); err != nil {
+			errs = append(errs, withErrorHints(cleanupParseError(name, err), err.Error(), nil, nil))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // renderable is an object that can be rendered.
 type renderable struct {
 	// tpl is the current template.
@@ -162,7 +254,7 @@ func includeFun(t *template.Template, includedNames map[string]int) func(string,
 
 // As does 'tpl', so that nested calls to 'tpl' see the templates
 // defined by their enclosing contexts.
-func tplFun(parent *template.Template, includedNames map[string]int, strict bool) func(string, interface{}) (string, error) {
+func tplFun(parent *template.Template, includedNames map[string]int, strict bool, disabled template.FuncMap) func(string, interface{}) (string, error) {
 	return func(tpl string, vals interface{}) (string, error) {
 		t, err := parent.Clone()
 		if err != nil {
@@ -182,8 +274,10 @@ func tplFun(parent *template.Template, includedNames map[string]int, strict bool
 		// this lets any 'define's inside tpl be 'include'd.
 		t.Funcs(template.FuncMap{
 			"include": includeFun(t, includedNames),
-			"tpl":     tplFun(t, includedNames, strict),
+			"tpl":     tplFun(t, includedNames, strict, disabled),
 		})
+		// Keep the functions disabled by the host disabled in the clone.
+		t.Funcs(disabled)
 
 		// We need a .New template, as template text which is just blanks
 		// or comments after parsing out defines just adds new named
@@ -195,6 +289,9 @@ func tplFun(parent *template.Template, includedNames map[string]int, strict bool
 		if err != nil {
 			return "", fmt.Errorf("cannot parse template %q: %w", tpl, err)
 		}
+		if strict {
+			allowMissingValues(t)
+		}
 
 		var buf strings.Builder
 		if err := t.Execute(&buf, vals); err != nil {
@@ -207,13 +304,19 @@ func tplFun(parent *template.Template, includedNames map[string]int, strict bool
 }
 
 // initFunMap creates the Engine's FuncMap and adds context-specific functions.
-func (e Engine) initFunMap(t *template.Template) {
+// It returns an error if the FuncMapOptions are invalid.
+func (e Engine) initFunMap(t *template.Template) error {
+	if err := e.FuncMapOptions.validate(); err != nil {
+		return err
+	}
+	disabled := e.FuncMapOptions.disabled()
+
 	funcMap := funcMap()
 	includedNames := make(map[string]int)
 
 	// Add the template-rendering functions here so we can close over t.
 	funcMap["include"] = includeFun(t, includedNames)
-	funcMap["tpl"] = tplFun(t, includedNames, e.Strict)
+	funcMap["tpl"] = tplFun(t, includedNames, e.Strict, disabled)
 
 	// Add the `required` function here so we can use lintMode
 	funcMap["required"] = func(warn string, val interface{}) (interface{}, error) {
@@ -247,6 +350,12 @@ func (e Engine) initFunMap(t *template.Template) {
 		return "", errors.New(warnWrap(msg))
 	}
 
+	// In strict mode, the values given to the functions testing or replacing
+	// them are looked up with lenientValue, see allowMissingValues.
+	if e.Strict {
+		funcMap[lenientValueFunc] = lenientValue
+	}
+
 	// If we are not linting and have a cluster connection, provide a Kubernetes-backed
 	// implementation.
 	if !e.LintMode && e.clientProvider != nil {
@@ -263,8 +372,14 @@ func (e Engine) initFunMap(t *template.Template) {
 
 	// Set custom template funcs
 	maps.Copy(funcMap, e.CustomTemplateFuncs)
+	maps.Copy(funcMap, e.FuncMapOptions.ExtraFuncs)
+	if e.Deterministic {
+		maps.Copy(funcMap, deterministicFuncs(e.RandomSeed, e.EnableDNS))
+	}
+	maps.Copy(funcMap, disabled)
 
 	t.Funcs(funcMap)
+	return nil
 }
 
 // render takes a map of templates/values and renders them.
@@ -290,7 +405,9 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 		t.Option("missingkey=zero")
 	}
 
-	e.initFunMap(t)
+	if err := e.initFunMap(t); err != nil {
+		return map[string]string{}, err
+	}
 
 	// We want to parse the templates in a predictable order. The order favors
 	// higher-level (in file system) templates over deeply nested templates.
@@ -299,9 +416,14 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 	for _, filename := range keys {
 		r := tpls[filename]
 		if _, err := t.New(filename).Parse(r.tpl); err != nil {
-			return map[string]string{}, cleanupParseError(filename, err)
+			return map[string]string{}, withErrorHints(cleanupParseError(filename, err), err.Error(), nil, nil)
 		}
 	}
+	if e.Strict {
+		allowMissingValues(t)
+	}
+
+	scope := e.renderScope(t, keys)
 
 	rendered = make(map[string]string, len(keys))
 	for _, filename := range keys {
@@ -310,12 +432,16 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 		if strings.HasPrefix(path.Base(filename), "_") {
 			continue
 		}
+		if scope != nil && !scope[filename] {
+			continue
+		}
 		// At render time, add information about the template that is being rendered.
 		vals := tpls[filename].vals
 		vals["Template"] = chartutil.Values{"Name": filename, "BasePath": tpls[filename].basePath}
 		var buf strings.Builder
 		if err := t.ExecuteTemplate(&buf, filename, vals); err != nil {
-			return map[string]string{}, reformatExecErrorMsg(filename, err)
+			values, _ := asMap(vals["Values"])
+			return map[string]string{}, withErrorHints(reformatExecErrorMsg(filename, err), err.Error(), values, templateNames(t))
 		}
 
 		// Work around the issue where Go will emit "<no value>" even if Options(missing=zero)
@@ -327,6 +453,16 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 	return rendered, nil
 }
 
+// templateNames returns the names of the templates defined in t, including
+// the ones defined with define.
+func templateNames(t *template.Template) []string {
+	var names []string
+	for _, tpl := range t.Templates() {
+		names = append(names, tpl.Name())
+	}
+	return names
+}
+
 func cleanupParseError(filename string, err error) error {
 	tokens := strings.Split(err.Error(), ": ")
 	if len(tokens) == 1 {
@@ -397,7 +533,7 @@ func reformatExecErrorMsg(filename string, err error) error {
 			errMsg := matches[execErrFmt.SubexpIndex("errMsg")]
 			traceable = TraceableError{
 				location:         templateName,
-				message:          errMsg,
+				message:          undefinedValueMessage(strings.Trim(locationName, "<>"), errMsg),
 				executedFunction: "executing " + functionName + " at " + locationName + ":",
 			}
 		} else if matches := execErrFmtWithoutTemplate.FindStringSubmatch(current.Error()); matches != nil {
@@ -477,6 +613,7 @@ func recAllTpls(c *chart.Chart, templates map[string]renderable, vals chartutil.
 		"Files":        newFiles(c.Files),
 		"Release":      vals["Release"],
 		"Capabilities": vals["Capabilities"],
+		"HookResults":  vals["HookResults"],
 		"Values":       make(chartutil.Values),
 		"Subcharts":    subCharts,
 	}
@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
@@ -138,6 +139,26 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"templates/_helpers.tpl": `{{ define "name" }}{{ .Chart.Name }}{{ end }}`,
+		"templates/pod.yaml":     `name: {{ include "name" . }}, image: {{ .Values.image | required "image is required" }}`,
+		"templates/NOTES.txt":    `{{ tpl .Values.notes . }}{{ lookup "v1" "Pod" "" "" }}`,
+	}
+	assert.NoError(t, Engine{}.Parse(valid), "templates are parsed without values")
+
+	invalid := map[string]string{
+		"templates/pod.yaml":     `{{ .Values.image`,
+		"templates/service.yaml": `{{ nope }}`,
+		"templates/ok.yaml":      `{{ .Values.ok }}`,
+	}
+	err := Engine{}.Parse(invalid)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "parse error at (templates/pod.yaml:1): unclosed action")
+	assert.Contains(t, err.Error(), `parse error at (templates/service.yaml:1): function "nope" not defined`)
+	assert.NotContains(t, err.Error(), "templates/ok.yaml")
+}
+
 func TestRenderRefsOrdering(t *testing.T) {
 	parentChart := &chart.Chart{
 		Metadata: &chart.Metadata{
@@ -248,6 +269,56 @@ func TestRenderWithDNS(t *testing.T) {
 	}
 }
 
+func TestRenderHookResults(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/done", Data: []byte(`{{ (index .HookResults "migrate").Name }}`)},
+			{Name: "templates/missing", Data: []byte(`{{ (index .HookResults "nope").Name }}`)},
+		},
+	}
+	vals := map[string]interface{}{
+		"Values": map[string]interface{}{},
+		"HookResults": chartutil.HookResults{
+			"migrate": chartutil.NewHookResult("Job", "default", "migrate", "1234", nil),
+		},
+	}
+
+	out, err := Render(c, vals)
+	if err != nil {
+		t.Fatalf("Failed to render templates: %s", err)
+	}
+	assert.Equal(t, "migrate", out["moby/templates/done"])
+	assert.Equal(t, "", out["moby/templates/missing"])
+
+	vals["HookResults"] = chartutil.HookResults{
+		"migrate": chartutil.PendingHookResult(fmt.Errorf("hook %q has not run", "migrate")),
+	}
+	_, err = Render(c, vals)
+	assert.ErrorContains(t, err, `hook "migrate" has not run`)
+}
+
+func TestRenderYAMLArrayFuncsInAllTemplates(t *testing.T) {
+	tpl := []byte(`{{ range mustFromYamlArray .Values.list }}{{ . }}{{ end }}`)
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/NOTES.txt", Data: tpl},
+			{Name: "templates/hook.yaml", Data: []byte("# helm.sh/hook: pre-install\n" + string(tpl))},
+		},
+	}
+	vals := map[string]interface{}{
+		"Values": map[string]interface{}{"list": "- a\n- b\n"},
+	}
+
+	out, err := Render(c, vals)
+	if err != nil {
+		t.Fatalf("Failed to render templates: %s", err)
+	}
+	assert.Equal(t, "ab", out["moby/templates/NOTES.txt"])
+	assert.Equal(t, "# helm.sh/hook: pre-install\nab", out["moby/templates/hook.yaml"])
+}
+
 type kindProps struct {
 	shouldErr  error
 	gvr        schema.GroupVersionResource
@@ -258,6 +329,8 @@ type testClientProvider struct {
 	t       *testing.T
 	scheme  map[string]kindProps
 	objects []runtime.Object
+	// actions records the requests made by the clients provided.
+	actions []k8stesting.Action
 }
 
 func (p *testClientProvider) GetClientFor(apiVersion, kind string) (dynamic.NamespaceableResourceInterface, bool, error) {
@@ -265,7 +338,12 @@ func (p *testClientProvider) GetClientFor(apiVersion, kind string) (dynamic.Name
 	if props.shouldErr != nil {
 		return nil, false, props.shouldErr
 	}
-	return fake.NewSimpleDynamicClient(runtime.NewScheme(), p.objects...).Resource(props.gvr), props.namespaced, nil
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), p.objects...)
+	client.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		p.actions = append(p.actions, action)
+		return false, nil, nil
+	})
+	return client.Resource(props.gvr), props.namespaced, nil
 }
 
 var _ ClientProvider = &testClientProvider{}
@@ -420,6 +498,116 @@ func TestRenderWithClientProvider_error(t *testing.T) {
 	}
 }
 
+func TestRenderWithClientProvider_selectors(t *testing.T) {
+	labeled := func(name, app string) *unstructured.Unstructured {
+		obj := makeUnstructured("v1", "Secret", name, "default")
+		obj.SetLabels(map[string]string{"app": app})
+		return obj
+	}
+	provider := &testClientProvider{
+		t: t,
+		scheme: map[string]kindProps{
+			"v1/Secret": {
+				gvr:        schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+				namespaced: true,
+			},
+		},
+		objects: []runtime.Object{
+			labeled("foo-1", "foo"),
+			labeled("foo-2", "foo"),
+			labeled("bar-1", "bar"),
+		},
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/labels", Data: []byte(`{{ (lookup "v1" "Secret" "default" "" (dict "labelSelector" "app=foo")).items | len }}`)},
+			{Name: "templates/fields", Data: []byte(`{{ (lookup "v1" "Secret" "default" "" (dict "fieldSelector" "type=Opaque")).items | len }}`)},
+		},
+	}
+	vals := map[string]interface{}{"Values": map[string]interface{}{}}
+
+	out, err := RenderWithClientProvider(c, vals, provider)
+	if err != nil {
+		t.Fatalf("Failed to render templates: %s", err)
+	}
+	assert.Equal(t, "2", out["moby/templates/labels"])
+	// The fake client does not filter on fields, so check the request instead.
+	assert.Equal(t, "3", out["moby/templates/fields"])
+
+	var selectors []string
+	for _, action := range provider.actions {
+		if list, ok := action.(k8stesting.ListAction); ok {
+			restrictions := list.GetListRestrictions()
+			selectors = append(selectors, restrictions.Labels.String()+"|"+restrictions.Fields.String())
+		}
+	}
+	assert.ElementsMatch(t, []string{"app=foo|", "|type=Opaque"}, selectors)
+}
+
+func TestRenderWithClientProvider_invalidSelectors(t *testing.T) {
+	provider := &testClientProvider{
+		t: t,
+		scheme: map[string]kindProps{
+			"v1/Secret": {
+				gvr:        schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+				namespaced: true,
+			},
+		},
+	}
+	cases := map[string]struct {
+		template string
+		err      string
+	}{
+		"label": {
+			template: `{{ lookup "v1" "Secret" "default" "" (dict "labelSelector" "app in foo") }}`,
+			err:      `invalid label selector "app in foo"`,
+		},
+		"field": {
+			template: `{{ lookup "v1" "Secret" "default" "" (dict "fieldSelector" "type") }}`,
+			err:      `invalid field selector "type"`,
+		},
+		"unknown": {
+			template: `{{ lookup "v1" "Secret" "default" "" (dict "limit" "10") }}`,
+			err:      `unknown lookup option "limit"`,
+		},
+		"get": {
+			template: `{{ lookup "v1" "Secret" "default" "foo" (dict "labelSelector" "app=foo") }}`,
+			err:      `lookup options can only be used when listing objects, not when getting "foo"`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &chart.Chart{
+				Metadata:  &chart.Metadata{Name: "moby", Version: "1.2.3"},
+				Templates: []*chart.File{{Name: "templates/" + name, Data: []byte(tc.template)}},
+			}
+			vals := map[string]interface{}{"Values": map[string]interface{}{}}
+
+			_, err := RenderWithClientProvider(c, vals, provider)
+			assert.ErrorContains(t, err, tc.err)
+			assert.ErrorContains(t, err, "moby/templates/"+name)
+		})
+	}
+}
+
+func TestRenderLookupSelectorsWithoutCluster(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/lookup", Data: []byte(`{{ lookup "v1" "Secret" "default" "" (dict "labelSelector" "app=foo") }}`)},
+		},
+	}
+	vals := map[string]interface{}{"Values": map[string]interface{}{}}
+
+	out, err := Render(c, vals)
+	if err != nil {
+		t.Fatalf("Failed to render templates: %s", err)
+	}
+	assert.Equal(t, "map[]", out["moby/templates/lookup"])
+}
+
 func TestParallelRenderInternals(t *testing.T) {
 	// Make sure that we can use one Engine to run parallel template renders.
 	e := new(Engine)
@@ -1322,7 +1510,8 @@ NestedHelperFunctions/templates/_helpers_1.tpl:1:39
     error calling include:
 NestedHelperFunctions/charts/common/templates/_helpers_2.tpl:1:49
   executing "common.names.get_name" at <.Values.nonexistant.key>:
-    nil pointer evaluating interface {}.key`
+    nil pointer evaluating interface {}.key
+hint: .Values.nonexistant is not set; provide it with --set nonexistant.key=... or guard with ` + "`if`"
 
 	v := chartutil.Values{}
 
@@ -1355,7 +1544,8 @@ func TestMultilineNoTemplateAssociatedError(t *testing.T) {
 	expectedErrorMessage := `multiline/templates/svc.yaml:1:9
   executing "multiline/templates/svc.yaml" at <include "nested_helper.name" .>:
     error calling include:
-template: no template "nested_helper.name" associated with template "gotpl"`
+template: no template "nested_helper.name" associated with template "gotpl"
+hint: no template is defined with the name "nested_helper.name"; check the name given to include or template`
 
 	v := chartutil.Values{}
 
@@ -1428,3 +1618,41 @@ func TestRenderCustomTemplateFuncs(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, rendered)
 	}
 }
+
+func TestRenderStrings(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", AppVersion: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "moby.name" }}moby-{{ .Release.Name }}{{ end }}`)},
+		},
+	}
+	v := chartutil.Values{
+		"Values": chartutil.Values{
+			"tag": "{{ .Chart.AppVersion }}",
+		},
+		"Chart": c.Metadata,
+		"Release": chartutil.Values{
+			"Name": "whale",
+		},
+	}
+
+	out, failed, err := new(Engine).RenderStrings(c, v, map[string]string{
+		"tag":     "{{ .Chart.AppVersion }}",
+		"name":    `{{ include "moby.name" . }}`,
+		"nested":  `{{ tpl .Values.tag . }}`,
+		"missing": `{{ include "nope" . }}`,
+		"broken":  `{{ .Values.tag`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]string{
+		"tag":    "1.2.3",
+		"name":   "moby-whale",
+		"nested": "1.2.3",
+	}, out)
+	assert.Len(t, failed, 2)
+	assert.Contains(t, failed, "missing")
+	assert.Contains(t, failed, "broken")
+}
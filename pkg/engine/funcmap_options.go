@@ -0,0 +1,107 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"text/template"
+)
+
+// FuncMapOptions lets the programs embedding the engine change the functions
+// available to the templates. The Helm CLI does not set them.
+type FuncMapOptions struct {
+	// ExtraFuncs are added to the functions of the templates. Their names
+	// must not be the ones of the functions of Helm, Sprig or text/template,
+	// unless AllowOverride is set.
+	ExtraFuncs template.FuncMap
+	// AllowOverride lets ExtraFuncs replace the functions of Helm, Sprig or
+	// text/template.
+	AllowOverride bool
+	// DisabledFuncs are the names of the functions the templates cannot call.
+	// The templates using them still parse, but rendering a call fails with
+	// an error naming the function.
+	DisabledFuncs []string
+}
+
+// goTemplateBuiltins are the functions text/template predefines.
+var goTemplateBuiltins = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or",
+	"print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// isBuiltinFunc reports whether name is a function of Helm, Sprig or
+// text/template.
+func isBuiltinFunc(name string, helmFuncs template.FuncMap) bool {
+	_, ok := helmFuncs[name]
+	return ok || slices.Contains(goTemplateBuiltins, name)
+}
+
+// validate returns an error for every extra function shadowing a builtin one
+// without AllowOverride, and for every disabled function which does not
+// exist, so that a misspelled name does not leave the function enabled.
+func (o FuncMapOptions) validate() error {
+	helmFuncs := funcMap()
+	var errs []error
+	names := make([]string, 0, len(o.ExtraFuncs))
+	for name := range o.ExtraFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !o.AllowOverride && isBuiltinFunc(name, helmFuncs) {
+			errs = append(errs, fmt.Errorf("extra template function %q shadows a builtin function, set AllowOverride to replace it", name))
+		}
+	}
+	for _, name := range o.DisabledFuncs {
+		if _, ok := o.ExtraFuncs[name]; ok {
+			continue
+		}
+		if !isBuiltinFunc(name, helmFuncs) {
+			errs = append(errs, fmt.Errorf("cannot disable unknown template function %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// disabled returns the functions standing in for the DisabledFuncs, which
+// fail with an error naming them whatever their arguments.
+func (o FuncMapOptions) disabled() template.FuncMap {
+	if len(o.DisabledFuncs) == 0 {
+		return nil
+	}
+	f := make(template.FuncMap, len(o.DisabledFuncs))
+	for _, name := range o.DisabledFuncs {
+		f[name] = func(...interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("function %q disabled by host", name)
+		}
+	}
+	return f
+}
+
+// hostFuncs returns the functions the embedding program adds to the
+// templates, with CustomTemplateFuncs and the ExtraFuncs of FuncMapOptions.
+func (e Engine) hostFuncs() template.FuncMap {
+	f := make(template.FuncMap, len(e.CustomTemplateFuncs)+len(e.FuncMapOptions.ExtraFuncs))
+	maps.Copy(f, e.CustomTemplateFuncs)
+	maps.Copy(f, e.FuncMapOptions.ExtraFuncs)
+	return f
+}
@@ -0,0 +1,128 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func funcMapOptionsChart(templates map[string]string) (*chart.Chart, chartutil.Values) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "host"}}
+	for name, data := range templates {
+		c.Templates = append(c.Templates, &chart.File{Name: name, Data: []byte(data)})
+	}
+	v := chartutil.Values{
+		"Values":  chartutil.Values{"path": "secret/db"},
+		"Chart":   c.Metadata,
+		"Release": chartutil.Values{"Name": "TestRelease"},
+	}
+	return c, v
+}
+
+func TestRenderExtraFuncs(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{
+		"templates/secret.yaml": `password: {{ vaultLookup .Values.path | quote }}`,
+		"templates/tpl.yaml":    `{{ tpl "{{ vaultLookup .Values.path }}" . }}`,
+	})
+
+	e := new(Engine)
+	e.FuncMapOptions.ExtraFuncs = template.FuncMap{
+		"vaultLookup": func(path string) string { return "value-of-" + path },
+	}
+	out, err := e.Render(c, v)
+	require.NoError(t, err)
+	assert.Equal(t, `password: "value-of-secret/db"`, out["host/templates/secret.yaml"])
+	assert.Equal(t, "value-of-secret/db", out["host/templates/tpl.yaml"])
+}
+
+func TestRenderExtraFuncsShadowingBuiltins(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{
+		"templates/cm.yaml": `{{ upper "a" }}{{ len "abc" }}`,
+	})
+	extra := template.FuncMap{
+		"upper": func(s string) string { return "custom:" + s },
+		"len":   func(interface{}) int { return 0 },
+	}
+
+	e := new(Engine)
+	e.FuncMapOptions.ExtraFuncs = extra
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `extra template function "len" shadows a builtin function`)
+	assert.Contains(t, err.Error(), `extra template function "upper" shadows a builtin function`)
+
+	e.FuncMapOptions.AllowOverride = true
+	out, err := e.Render(c, v)
+	require.NoError(t, err)
+	assert.Equal(t, "custom:a0", out["host/templates/cm.yaml"])
+}
+
+func TestRenderDisabledFuncs(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{
+		"templates/dns.yaml": "host: {{ .Release.Name }}\nip: {{ getHostByName \"example.com\" }}",
+	})
+
+	e := new(Engine)
+	e.FuncMapOptions.DisabledFuncs = []string{"getHostByName"}
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "host/templates/dns.yaml:2:")
+	assert.Contains(t, err.Error(), `function "getHostByName" disabled by host`)
+
+	// A template not calling the function renders.
+	c, v = funcMapOptionsChart(map[string]string{
+		"templates/cm.yaml": `{{ if false }}{{ getHostByName "example.com" }}{{ end }}ok`,
+	})
+	out, err := e.Render(c, v)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out["host/templates/cm.yaml"])
+}
+
+func TestRenderDisabledFuncsInTpl(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{
+		"templates/_helpers.tpl": `{{ define "name" }}{{ .Release.Name }}{{ end }}`,
+		"templates/cm.yaml":      `{{ tpl "{{ include \"name\" . }}" . }}`,
+	})
+
+	e := new(Engine)
+	e.FuncMapOptions.DisabledFuncs = []string{"include"}
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "include" disabled by host`)
+}
+
+func TestRenderDisabledFuncsUnknown(t *testing.T) {
+	c, v := funcMapOptionsChart(map[string]string{"templates/cm.yaml": `ok`})
+
+	e := new(Engine)
+	e.FuncMapOptions.DisabledFuncs = []string{"getHostByNam"}
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `cannot disable unknown template function "getHostByNam"`)
+
+	// The extra functions can be disabled as well.
+	e.FuncMapOptions.ExtraFuncs = template.FuncMap{"getHostByNam": func() string { return "" }}
+	_, err = e.Render(c, v)
+	require.NoError(t, err)
+}
@@ -49,17 +49,18 @@ func funcMap() template.FuncMap {
 
 	// Add some extra functionality
 	extra := template.FuncMap{
-		"toToml":        toTOML,
-		"fromToml":      fromTOML,
-		"toYaml":        toYAML,
-		"mustToYaml":    mustToYAML,
-		"toYamlPretty":  toYAMLPretty,
-		"fromYaml":      fromYAML,
-		"fromYamlArray": fromYAMLArray,
-		"toJson":        toJSON,
-		"mustToJson":    mustToJSON,
-		"fromJson":      fromJSON,
-		"fromJsonArray": fromJSONArray,
+		"toToml":            toTOML,
+		"fromToml":          fromTOML,
+		"toYaml":            toYAML,
+		"mustToYaml":        mustToYAML,
+		"toYamlPretty":      toYAMLPretty,
+		"fromYaml":          fromYAML,
+		"fromYamlArray":     fromYAMLArray,
+		"mustFromYamlArray": mustFromYAMLArray,
+		"toJson":            toJSON,
+		"mustToJson":        mustToJSON,
+		"fromJson":          fromJSON,
+		"fromJsonArray":     fromJSONArray,
 
 		// This is a placeholder for the "include" function, which is
 		// late-bound to a template. By declaring it here, we preserve the
@@ -69,7 +70,7 @@ func funcMap() template.FuncMap {
 		"required": func(string, interface{}) (interface{}, error) { return "not implemented", nil },
 		// Provide a placeholder for the "lookup" function, which requires a kubernetes
 		// connection.
-		"lookup": func(string, string, string, string) (map[string]interface{}, error) {
+		"lookup": func(string, string, string, string, ...map[string]interface{}) (map[string]interface{}, error) {
 			return map[string]interface{}{}, nil
 		},
 	}
@@ -105,6 +106,12 @@ func mustToYAML(v interface{}) string {
 	return strings.TrimSuffix(string(data), "\n")
 }
 
+// toYAMLPretty takes an interface, marshals it to yaml with an indentation of
+// two spaces, and returns a string. Map keys are sorted and flow style is
+// never used, so the output is stable. It will always return a string, even
+// on marshal error (empty string).
+//
+// This is designed to be called from a template.
 func toYAMLPretty(v interface{}) string {
 	var data bytes.Buffer
 	encoder := goYaml.NewEncoder(&data)
@@ -138,7 +145,8 @@ func fromYAML(str string) map[string]interface{} {
 // This is not a general-purpose YAML parser, and will not parse all valid
 // YAML documents. Additionally, because its intended use is within templates
 // it tolerates errors. It will insert the returned error message string as
-// the first and only item in the returned array.
+// the first and only item in the returned array. An empty string is an empty
+// array.
 func fromYAMLArray(str string) []interface{} {
 	a := []interface{}{}
 
@@ -148,6 +156,20 @@ func fromYAMLArray(str string) []interface{} {
 	return a
 }
 
+// mustFromYAMLArray converts a YAML array into a []interface{}, like
+// fromYAMLArray, but returns an error instead of tolerating it.
+//
+// This is designed to be called from a template when the rendering must fail
+// on malformed input.
+func mustFromYAMLArray(str string) ([]interface{}, error) {
+	a := []interface{}{}
+
+	if err := yaml.Unmarshal([]byte(str), &a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 // toTOML takes an interface, marshals it to toml, and returns a string. It will
 // always return a string, even on marshal error (empty string).
 //
@@ -37,6 +37,10 @@ func TestFuncs(t *testing.T) {
 		tpl:    `{{ toYamlPretty . }}`,
 		expect: "baz:\n  - 1\n  - 2\n  - 3",
 		vars:   map[string]interface{}{"baz": []int{1, 2, 3}},
+	}, {
+		tpl:    `{{ toYamlPretty . }}`,
+		expect: "a:\n  b:\n    - c: 1\n      d:\n        - e\nz: true",
+		vars:   map[string]interface{}{"z": true, "a": map[string]interface{}{"b": []interface{}{map[string]interface{}{"d": []string{"e"}, "c": 1}}}},
 	}, {
 		tpl:    `{{ toToml . }}`,
 		expect: "foo = \"bar\"\n",
@@ -81,6 +85,22 @@ keyInElement1 = "valueInElement1"`,
 		tpl:    `{{ fromYamlArray . }}`,
 		expect: "[one 2 map[name:helm]]",
 		vars:   `["one", 2, { "name": "helm" }]`,
+	}, {
+		tpl:    `{{ fromYamlArray . }}`,
+		expect: "[map[a:[b map[c:d]]] e]",
+		vars:   "- a:\n    - b\n    - c: d\n- e\n",
+	}, {
+		tpl:    `{{ fromYamlArray . }}`,
+		expect: "[]",
+		vars:   "",
+	}, {
+		tpl:    `{{ mustFromYamlArray . }}`,
+		expect: "[one 2 map[name:helm]]",
+		vars:   "- one\n- 2\n- name: helm\n",
+	}, {
+		tpl:    `{{ mustFromYamlArray . }}`,
+		expect: "[]",
+		vars:   "",
 	}, {
 		// Regression for https://github.com/helm/helm/issues/2271
 		tpl:    `{{ toToml . }}`,
@@ -122,6 +142,10 @@ keyInElement1 = "valueInElement1"`,
 		tpl:    `{{ fromYamlArray . }}`,
 		expect: `[error unmarshaling JSON: while decoding JSON: json: cannot unmarshal object into Go value of type []interface {}]`,
 		vars:   `hello: world`,
+	}, {
+		tpl:    `{{ fromYamlArray . }}`,
+		expect: `[error converting YAML to JSON: yaml: line 1: did not find expected ',' or ']']`,
+		vars:   `[one, two`,
 	}, {
 		// This should never result in a network lookup. Regression for #7955
 		tpl:    `{{ lookup "v1" "Namespace" "" "unlikelynamespace99999999" }}`,
@@ -151,6 +175,12 @@ keyInElement1 = "valueInElement1"`,
 	}, {
 		tpl:  `{{ mustToJson . }}`,
 		vars: loopMap,
+	}, {
+		tpl:  `{{ mustFromYamlArray . }}`,
+		vars: `hello: world`,
+	}, {
+		tpl:  `{{ mustFromYamlArray . }}`,
+		vars: `[one, two`,
 	}, {
 		tpl:    `{{ toYaml . }}`,
 		expect: "", // should return empty string and swallow error
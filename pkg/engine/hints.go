@@ -0,0 +1,230 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+var (
+	// nilPointerErr matches a field evaluated on a missing value, capturing
+	// the path of the field under .Values.
+	nilPointerErr = regexp.MustCompile(`at <\$?\.Values((?:\.[\w-]+)+)>: nil pointer evaluating interface \{\}\.[\w-]+`)
+	// missingValueKeyErr matches a field of the values whose key is missing in
+	// a strict render, capturing the path under .Values and the key.
+	missingValueKeyErr = regexp.MustCompile(`at <\$?\.Values((?:\.[\w-]+)+)>: map has no entry for key "([^"]*)"`)
+	// wrongTypeErr matches a value passed to a function expecting another type.
+	wrongTypeErr = regexp.MustCompile(`wrong type for value; expected ([^;]+); got (\S+(?: \{\})?)`)
+	// undefinedTemplateErr matches an include or template of a missing template.
+	undefinedTemplateErr = regexp.MustCompile(`no template "([^"]*)" associated with template`)
+	// yamlParseErr matches the error returned for rendered output which is not YAML.
+	yamlParseErr = regexp.MustCompile(`^YAML parse error on ([^:]+):`)
+	// undefinedFunctionErr matches a call to a function which is not defined.
+	undefinedFunctionErr = regexp.MustCompile(`function "([^"]*)" not defined`)
+	// fieldErr matches a failure evaluating a field of the render context,
+	// capturing its path.
+	fieldErr = regexp.MustCompile(`at <\$?((?:\.[\w-]+)+)>: `)
+)
+
+// maxHintKeys is the maximum number of existing keys listed in a hint.
+const maxHintKeys = 3
+
+// hintedError is an error followed by suggestions on how to fix it.
+type hintedError struct {
+	err   error
+	hints []string
+}
+
+func (e hintedError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.err.Error())
+	for _, h := range e.hints {
+		fmt.Fprintf(&b, "\nhint: %s", h)
+	}
+	return b.String()
+}
+
+func (e hintedError) Unwrap() error { return e.err }
+
+// WithErrorHints returns err followed by suggestions on how to fix the
+// failures commonly hit when writing templates. The message of err is left
+// untouched, hints are only added after it. Values are the values the failing
+// template was rendered with, used to suggest existing keys; they may be nil.
+//
+// Errors which match no known failure are returned as is.
+func WithErrorHints(err error, values map[string]interface{}) error {
+	return withErrorHints(err, err.Error(), values, nil)
+}
+
+// withErrorHints is WithErrorHints, matching the failures against msg rather
+// than the message of err, and suggesting template names among templates.
+func withErrorHints(err error, msg string, values map[string]interface{}, templates []string) error {
+	var hints []string
+	if m := nilPointerErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, missingValueHints(m[1], values)...)
+	}
+	if m := missingValueKeyErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, undefinedValueHints(m[1], m[2], values)...)
+	}
+	if m := wrongTypeErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, fmt.Sprintf("a value is a %s where a %s is expected; check the type of the values used here, or convert them, e.g. with toString or toYaml", m[2], m[1]))
+	}
+	if m := undefinedTemplateErr.FindStringSubmatch(msg); m != nil {
+		hint := fmt.Sprintf("no template is defined with the name %q; check the name given to include or template", m[1])
+		if near := closest(m[1], templates, true); len(near) > 0 {
+			hint += fmt.Sprintf(", did you mean %q?", near[0])
+		}
+		hints = append(hints, hint)
+	}
+	if m := yamlParseErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, fmt.Sprintf("the output of %s is not valid YAML; check its indentation, e.g. by using nindent after toYaml", m[1]))
+	}
+	if m := undefinedFunctionErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, compatHints(m[1], "")...)
+	}
+	if m := fieldErr.FindStringSubmatch(msg); m != nil {
+		hints = append(hints, compatHints("", m[1])...)
+	}
+	if len(hints) == 0 {
+		return err
+	}
+	return hintedError{err: err, hints: hints}
+}
+
+// missingValueHints explains which part of the path of a field under .Values
+// is not set, listing the closest existing keys.
+func missingValueHints(path string, values map[string]interface{}) []string {
+	keys := strings.Split(strings.TrimPrefix(path, "."), ".")
+	// The last key is the field evaluated on a missing value.
+	setPath := strings.Join(keys, ".")
+
+	current := values
+	parent := ".Values"
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := asMap(current[key])
+		if !ok {
+			missing := parent + "." + key
+			hints := []string{fmt.Sprintf("%s is not set; provide it with --set %s=... or guard with `if`", missing, setPath)}
+			if _, exists := current[key]; !exists && current != nil {
+				if near := closest(key, mapKeys(current), false); len(near) > 0 {
+					hints = append(hints, fmt.Sprintf("existing keys under %s: %s", parent, strings.Join(near, ", ")))
+				}
+			}
+			return hints
+		}
+		current = next
+		parent += "." + key
+	}
+	return []string{fmt.Sprintf("%s is not set; provide it with --set %s=... or guard with `if`", parent, setPath)}
+}
+
+// undefinedValueHints explains how to fix a strict render failing on the
+// missing key of a path under .Values, listing the closest existing keys.
+func undefinedValueHints(path, key string, values map[string]interface{}) []string {
+	current := values
+	parent := ".Values"
+	for _, k := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if k == key {
+			break
+		}
+		next, ok := asMap(current[k])
+		if !ok {
+			break
+		}
+		current = next
+		parent += "." + k
+	}
+	hints := []string{fmt.Sprintf("%s.%s is not set; fix the path if it is a typo, set it, or guard it with default or hasKey", parent, key)}
+	if near := closest(key, mapKeys(current), true); len(near) > 0 {
+		hints = append(hints, fmt.Sprintf("existing keys under %s: %s", parent, strings.Join(near, ", ")))
+	}
+	return hints
+}
+
+// asMap returns v as a map, if it is a non-nil one.
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, m != nil
+	case chartutil.Values:
+		return m, m != nil
+	}
+	return nil, false
+}
+
+func mapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// closest returns up to maxHintKeys candidates, closest to name first. With
+// onlyClose, candidates too far from name to be a typo are left out.
+func closest(name string, candidates []string, onlyClose bool) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	var matches []scored
+	lower := strings.ToLower(name)
+	for _, c := range candidates {
+		d := levenshtein(lower, strings.ToLower(c))
+		if !onlyClose || d <= max(2, len(name)/3) {
+			matches = append(matches, scored{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+	var names []string
+	for i := 0; i < len(matches) && i < maxHintKeys; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,104 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestRenderErrorHints(t *testing.T) {
+	values := map[string]interface{}{
+		"image":        map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"imagePolicy":  "Always",
+		"replicaCount": 1,
+		"service":      map[string]interface{}{"port": 80},
+	}
+	cases := []struct {
+		name     string
+		tpl      string
+		expected string
+	}{
+		{
+			name: "missing value with similar keys",
+			tpl:  `{{ .Values.imag.tag }}`,
+			expected: `missing:1:10
+  executing "missing" at <.Values.imag.tag>:
+    nil pointer evaluating interface {}.tag
+hint: .Values.imag is not set; provide it with --set imag.tag=... or guard with ` + "`if`" + `
+hint: existing keys under .Values: image, imagePolicy, service`,
+		},
+		{
+			name: "missing nested value",
+			tpl:  `{{ .Values.service.tls.cert }}`,
+			expected: `missing:1:10
+  executing "missing" at <.Values.service.tls.cert>:
+    nil pointer evaluating interface {}.cert
+hint: .Values.service.tls is not set; provide it with --set service.tls.cert=... or guard with ` + "`if`" + `
+hint: existing keys under .Values.service: port`,
+		},
+		{
+			name: "wrong type",
+			tpl:  `{{ upper .Values.image }}`,
+			expected: `missing:1:16
+  executing "missing" at <.Values.image>:
+    wrong type for value; expected string; got map[string]interface {}
+hint: a value is a map[string]interface {} where a string is expected; check the type of the values used here, or convert them, e.g. with toString or toYaml`,
+		},
+		{
+			name: "undefined template",
+			tpl:  `{{ define "chart.fullname" }}x{{ end }}{{ include "chart.fulname" . }}`,
+			expected: `missing:1:42
+  executing "missing" at <include "chart.fulname" .>:
+    error calling include:
+template: no template "chart.fulname" associated with template "gotpl"
+hint: no template is defined with the name "chart.fulname"; check the name given to include or template, did you mean "chart.fullname"?`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tpls := map[string]renderable{
+				"missing": {tpl: tt.tpl, vals: chartutil.Values{"Values": values}},
+			}
+			_, err := new(Engine).render(tpls)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestRenderErrorWithoutHints(t *testing.T) {
+	tpls := map[string]renderable{
+		"failtpl": {tpl: `{{ fail "This is an error" }}`, vals: chartutil.Values{"Values": map[string]interface{}{}}},
+	}
+	_, err := new(Engine).render(tpls)
+	assert.EqualError(t, err, `execution error at (failtpl:1:3): This is an error`)
+}
+
+func TestWithErrorHints(t *testing.T) {
+	yamlErr := errors.New("YAML parse error on chart/templates/deployment.yaml: error converting YAML to JSON: yaml: line 3: mapping values are not allowed in this context")
+	err := WithErrorHints(yamlErr, nil)
+	assert.EqualError(t, err, yamlErr.Error()+"\nhint: the output of chart/templates/deployment.yaml is not valid YAML; check its indentation, e.g. by using nindent after toYaml")
+	assert.ErrorIs(t, err, yamlErr)
+
+	other := errors.New("something else")
+	assert.Equal(t, other, WithErrorHints(other, nil))
+}
@@ -24,17 +24,22 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 )
 
-type lookupFunc = func(apiversion string, resource string, namespace string, name string) (map[string]interface{}, error)
+type lookupFunc = func(apiversion string, resource string, namespace string, name string, options ...map[string]interface{}) (map[string]interface{}, error)
 
 // NewLookupFunction returns a function for looking up objects in the cluster.
 //
 // If the resource does not exist, no error is raised.
+//
+// When listing objects, an optional map of options narrows down the objects
+// returned, e.g. (dict "labelSelector" "app=foo" "fieldSelector" "type=Opaque").
 func NewLookupFunction(config *rest.Config) lookupFunc {
 	return newLookupFunction(clientProviderFromConfig{config: config})
 }
@@ -55,7 +60,11 @@ func (c clientProviderFromConfig) GetClientFor(apiVersion, kind string) (dynamic
 }
 
 func newLookupFunction(clientProvider ClientProvider) lookupFunc {
-	return func(apiversion string, kind string, namespace string, name string) (map[string]interface{}, error) {
+	return func(apiversion string, kind string, namespace string, name string, options ...map[string]interface{}) (map[string]interface{}, error) {
+		listOptions, err := lookupListOptions(name, options)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
 		var client dynamic.ResourceInterface
 		c, namespaced, err := clientProvider.GetClientFor(apiversion, kind)
 		if err != nil {
@@ -80,7 +89,7 @@ func newLookupFunction(clientProvider ClientProvider) lookupFunc {
 			return obj.UnstructuredContent(), nil
 		}
 		// this will return a list
-		obj, err := client.List(context.Background(), metav1.ListOptions{})
+		obj, err := client.List(context.Background(), listOptions)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				// Just return an empty interface when the object was not found.
@@ -93,6 +102,42 @@ func newLookupFunction(clientProvider ClientProvider) lookupFunc {
 	}
 }
 
+// lookupListOptions converts the options given to lookup into list options,
+// validating the selectors.
+func lookupListOptions(name string, options []map[string]interface{}) (metav1.ListOptions, error) {
+	var listOptions metav1.ListOptions
+	if len(options) == 0 {
+		return listOptions, nil
+	}
+	if len(options) > 1 {
+		return listOptions, fmt.Errorf("lookup takes a single map of options, got %d", len(options))
+	}
+	if name != "" && len(options[0]) > 0 {
+		return listOptions, fmt.Errorf("lookup options can only be used when listing objects, not when getting %q", name)
+	}
+	for key, value := range options[0] {
+		selector, ok := value.(string)
+		if !ok {
+			return listOptions, fmt.Errorf("lookup option %q must be a string, got %T", key, value)
+		}
+		switch key {
+		case "labelSelector":
+			if _, err := labels.Parse(selector); err != nil {
+				return listOptions, fmt.Errorf("invalid label selector %q: %w", selector, err)
+			}
+			listOptions.LabelSelector = selector
+		case "fieldSelector":
+			if _, err := fields.ParseSelector(selector); err != nil {
+				return listOptions, fmt.Errorf("invalid field selector %q: %w", selector, err)
+			}
+			listOptions.FieldSelector = selector
+		default:
+			return listOptions, fmt.Errorf("unknown lookup option %q, expected labelSelector or fieldSelector", key)
+		}
+	}
+	return listOptions, nil
+}
+
 // getDynamicClientOnKind returns a dynamic client on an Unstructured type. This client can be further namespaced.
 func getDynamicClientOnKind(apiversion string, kind string, config *rest.Config) (dynamic.NamespaceableResourceInterface, bool, error) {
 	gvk := schema.FromAPIVersionAndKind(apiversion, kind)
@@ -0,0 +1,171 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"log/slog"
+	"path"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// mutatingFuncs are template functions that can alter the values shared by
+// all templates of a chart. A template calling them cannot be skipped without
+// possibly changing the output of the templates rendered after it.
+var mutatingFuncs = map[string]bool{
+	"set":                true,
+	"unset":              true,
+	"merge":              true,
+	"mergeOverwrite":     true,
+	"mustMerge":          true,
+	"mustMergeOverwrite": true,
+}
+
+// templateRefs describes what a parsed template depends on.
+type templateRefs struct {
+	// names of the templates referenced through 'template' or 'include'
+	names []string
+	// dynamic is set when a template name is not a string literal, or when
+	// the template renders strings through 'tpl', which may reference any
+	// named template
+	dynamic bool
+	// mutates is set when the template calls one of mutatingFuncs
+	mutates bool
+}
+
+// renderScope works out which of the top-level templates need to be executed
+// to produce the templates selected by e.Targets.
+//
+// It returns nil when every template must be executed: either no targets
+// were given, or skipping the other templates could change the output of the
+// targets because a template references named templates dynamically or
+// mutates shared values.
+func (e Engine) renderScope(t *template.Template, keys []string) map[string]bool {
+	if e.Targets == nil {
+		return nil
+	}
+
+	cache := make(map[string]*templateRefs)
+	scope := make(map[string]bool)
+	for _, filename := range keys {
+		if strings.HasPrefix(path.Base(filename), "_") {
+			continue
+		}
+		target := e.Targets(filename)
+		dynamic, mutates := closure(t, filename, cache, make(map[string]bool))
+		if dynamic {
+			slog.Debug("rendering all templates: template references named templates dynamically", "template", filename)
+			return nil
+		}
+		if mutates && !target {
+			slog.Debug("rendering all templates: template may alter shared values", "template", filename)
+			return nil
+		}
+		if target {
+			scope[filename] = true
+		}
+	}
+	return scope
+}
+
+// closure walks the named templates reachable from name and reports whether
+// any of them references templates dynamically or mutates shared values.
+func closure(t *template.Template, name string, cache map[string]*templateRefs, seen map[string]bool) (dynamic, mutates bool) {
+	if seen[name] {
+		return false, false
+	}
+	seen[name] = true
+
+	refs, ok := cache[name]
+	if !ok {
+		refs = &templateRefs{}
+		if tpl := t.Lookup(name); tpl != nil && tpl.Tree != nil {
+			walkRefs(tpl.Tree.Root, refs)
+		}
+		cache[name] = refs
+	}
+
+	dynamic, mutates = refs.dynamic, refs.mutates
+	for _, n := range refs.names {
+		d, m := closure(t, n, cache, seen)
+		dynamic = dynamic || d
+		mutates = mutates || m
+	}
+	return dynamic, mutates
+}
+
+// walkRefs collects the template references found in the tree rooted at node.
+func walkRefs(node parse.Node, refs *templateRefs) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkRefs(c, refs)
+		}
+	case *parse.ActionNode:
+		walkRefs(n.Pipe, refs)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.TemplateNode:
+		refs.names = append(refs.names, n.Name)
+		walkRefs(n.Pipe, refs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walkRefs(c, refs)
+		}
+	case *parse.ChainNode:
+		walkRefs(n.Node, refs)
+	case *parse.CommandNode:
+		if len(n.Args) > 0 {
+			if ident, ok := n.Args[0].(*parse.IdentifierNode); ok {
+				switch {
+				case ident.Ident == "include":
+					if len(n.Args) > 1 {
+						if s, ok := n.Args[1].(*parse.StringNode); ok {
+							refs.names = append(refs.names, s.Text)
+						} else {
+							refs.dynamic = true
+						}
+					}
+				case ident.Ident == "tpl":
+					refs.dynamic = true
+				case mutatingFuncs[ident.Ident]:
+					refs.mutates = true
+				}
+			}
+		}
+		for _, a := range n.Args {
+			walkRefs(a, refs)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, refs *templateRefs) {
+	walkRefs(n.Pipe, refs)
+	walkRefs(n.List, refs)
+	walkRefs(n.ElseList, refs)
+}
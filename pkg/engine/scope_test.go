@@ -0,0 +1,131 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func renderValues(t *testing.T, c *chart.Chart) chartutil.Values {
+	t.Helper()
+	vals, err := chartutil.ToRenderValuesWithSchemaValidation(c, nil, chartutil.ReleaseOptions{Name: "release-name", Namespace: "default", Revision: 1, IsInstall: true}, chartutil.DefaultCapabilities, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vals
+}
+
+// TestRenderTargetsMatchFullRender renders every template of a chart with a
+// subchart on its own and checks the output is identical to a full render.
+func TestRenderTargetsMatchFullRender(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "0.1.0"},
+		Templates: []*chart.File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "moby.name" }}{{ .Chart.Name }}{{ end }}
+{{ define "moby.labels" }}app: {{ template "moby.name" . }}{{ end }}`)},
+			{Name: "templates/deployment.yaml", Data: []byte(`metadata:
+  name: {{ include "moby.name" . }}
+  labels:
+    {{- include "moby.labels" . | nindent 4 }}`)},
+			{Name: "templates/configmap.yaml", Data: []byte(`data:
+{{- range $k, $v := .Values.data }}
+  {{ $k }}: {{ $v | quote }}
+{{- end }}`)},
+			{Name: "templates/service.yaml", Data: []byte(`{{ with .Values.port }}port: {{ . }}{{ end }}`)},
+		},
+		Values: map[string]interface{}{
+			"data": map[string]interface{}{"a": "1", "b": "2"},
+			"port": 80,
+		},
+	}
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "whale", Version: "0.1.0"},
+		Templates: []*chart.File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "whale.name" }}{{ .Chart.Name }}-{{ .Values.size }}{{ end }}`)},
+			{Name: "templates/pod.yaml", Data: []byte(`name: {{ include "whale.name" . }}`)},
+		},
+		Values: map[string]interface{}{"size": "big"},
+	}
+	c.AddDependency(sub)
+
+	full, err := Render(c, renderValues(t, c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range full {
+		e := Engine{Targets: func(n string) bool { return n == name }}
+		partial, err := e.Render(c, renderValues(t, c))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		assert.Equal(t, full[name], partial[name], name)
+	}
+}
+
+func TestRenderTargets(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby"},
+		Templates: []*chart.File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "moby.name" }}moby{{ end }}`)},
+			{Name: "templates/wanted.yaml", Data: []byte(`name: {{ include "moby.name" . }}`)},
+			{Name: "templates/unwanted.yaml", Data: []byte(`{{ fail "must not be executed" }}`)},
+		},
+	}
+	e := Engine{Targets: func(n string) bool { return n == "moby/templates/wanted.yaml" }}
+
+	out, err := e.Render(c, renderValues(t, c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"moby/templates/wanted.yaml": "name: moby"}, out)
+}
+
+func TestRenderTargetsFallback(t *testing.T) {
+	for name, unwanted := range map[string]string{
+		"dynamic include": `{{ include (printf "%s.name" "moby") . }}`,
+		"tpl":             `{{ tpl "{{ include \"moby.name\" . }}" . }}`,
+		"mutating":        `{{ $_ := set .Values "name" "changed" }}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := &chart.Chart{
+				Metadata: &chart.Metadata{Name: "moby"},
+				Templates: []*chart.File{
+					{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "moby.name" }}moby{{ end }}`)},
+					{Name: "templates/a.yaml", Data: []byte(unwanted)},
+					{Name: "templates/b.yaml", Data: []byte(`name: {{ .Values.name }}`)},
+				},
+				Values: map[string]interface{}{"name": "original"},
+			}
+			e := Engine{Targets: func(n string) bool { return n == "moby/templates/b.yaml" }}
+
+			out, err := e.Render(c, renderValues(t, c))
+			if err != nil {
+				t.Fatal(err)
+			}
+			full, err := Render(c, renderValues(t, c))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, full, out)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+)
+
+// lenientFuncs are the functions which are given values that may not be set
+// on purpose, to test them or to replace them. In strict mode, the values
+// passed to them are looked up without failing on missing keys.
+var lenientFuncs = map[string]bool{
+	"default":  true,
+	"required": true,
+	"empty":    true,
+	"coalesce": true,
+	"hasKey":   true,
+}
+
+// lenientValueFunc is the name of the function looking up the values passed to
+// the lenientFuncs in strict mode.
+const lenientValueFunc = "_lenientValue"
+
+var (
+	// missingKeyErr matches the error of a strict render evaluating a missing
+	// key of the values, capturing the key.
+	missingKeyErr = regexp.MustCompile(`^map has no entry for key "([^"]*)"$`)
+	// fieldPath matches the path of a field, as .Values.image.tag or $v.tag.
+	fieldPath = regexp.MustCompile(`^\$?[\w]*(?:\.[\w-]+)+$`)
+)
+
+// lenientValue returns the value at the path of fields from v, or nil if a
+// key of the path is missing. As in a template, the fields of a struct and its
+// methods without arguments are evaluated as well.
+func lenientValue(v interface{}, fields ...string) (interface{}, error) {
+	for _, field := range fields {
+		if v == nil {
+			return nil, nil
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Map {
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("can't evaluate field %s in type %T", field, v)
+			}
+			e := rv.MapIndex(reflect.ValueOf(field).Convert(rv.Type().Key()))
+			if !e.IsValid() {
+				return nil, nil
+			}
+			v = e.Interface()
+			continue
+		}
+		var err error
+		if v, err = lenientField(rv, field); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// lenientField returns the value of the method without arguments or of the
+// field named field of rv, which is not a map. A nil pointer has no value.
+func lenientField(rv reflect.Value, field string) (interface{}, error) {
+	if m := rv.MethodByName(field); m.IsValid() && m.Type().NumIn() == 0 {
+		switch out := m.Call(nil); {
+		case len(out) == 2 && !out[1].IsNil():
+			return nil, out[1].Interface().(error)
+		case len(out) == 1 || len(out) == 2:
+			return out[0].Interface(), nil
+		}
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		if f, ok := rv.Type().FieldByName(field); ok && f.IsExported() {
+			return rv.FieldByIndex(f.Index).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("can't evaluate field %s in type %s", field, rv.Type())
+}
+
+// allowMissingValues rewrites the templates of t so that the paths of fields
+// given to the lenientFuncs, as arguments or through a pipeline, are looked up
+// with lenientValue. The paths may start from the dot, as .Values.image.tag or
+// .pullPolicy within a with, from a variable, as $v.port, or from a
+// parenthesized expression. With missingkey=error, the templates can then
+// still test or replace values which are not set, as in
+// {{ .Values.image.tag | default .Chart.AppVersion }}.
+func allowMissingValues(t *template.Template) {
+	for _, tpl := range t.Templates() {
+		if tpl.Tree != nil && tpl.Root != nil {
+			lenientNode(tpl.Root)
+		}
+	}
+}
+
+func lenientNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, c := range n.Nodes {
+			lenientNode(c)
+		}
+	case *parse.ActionNode:
+		lenientPipe(n.Pipe)
+	case *parse.IfNode:
+		lenientBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		lenientBranch(&n.BranchNode)
+	case *parse.WithNode:
+		lenientBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		lenientPipe(n.Pipe)
+	case *parse.PipeNode:
+		lenientPipe(n)
+	case *parse.ChainNode:
+		lenientNode(n.Node)
+	}
+}
+
+func lenientBranch(b *parse.BranchNode) {
+	lenientPipe(b.Pipe)
+	if b.List != nil {
+		lenientNode(b.List)
+	}
+	if b.ElseList != nil {
+		lenientNode(b.ElseList)
+	}
+}
+
+func lenientPipe(p *parse.PipeNode) {
+	if p == nil {
+		return
+	}
+	for i, cmd := range p.Cmds {
+		for _, arg := range cmd.Args {
+			lenientNode(arg)
+		}
+		id, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if !ok || !lenientFuncs[id.Ident] {
+			continue
+		}
+		for j, arg := range cmd.Args[1:] {
+			if lookup := lenientLookup(arg); lookup != nil {
+				cmd.Args[j+1] = lookup
+			}
+		}
+		// The value piped into the function.
+		if i > 0 && len(p.Cmds[0].Args) == 1 {
+			if lookup := lenientLookup(p.Cmds[0].Args[0]); lookup != nil {
+				p.Cmds[0].Args[0] = lookup
+			}
+		}
+	}
+}
+
+// lenientLookup returns the call to lenientValue replacing node, if it is a
+// path of fields, or nil.
+func lenientLookup(node parse.Node) parse.Node {
+	var base parse.Node
+	var fields []string
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		base = &parse.DotNode{NodeType: parse.NodeDot, Pos: n.Pos}
+		fields = n.Ident
+	case *parse.VariableNode:
+		if len(n.Ident) < 2 {
+			return nil
+		}
+		base = &parse.VariableNode{NodeType: parse.NodeVariable, Pos: n.Pos, Ident: n.Ident[:1]}
+		fields = n.Ident[1:]
+	case *parse.ChainNode:
+		base = n.Node
+		if lookup := lenientLookup(n.Node); lookup != nil {
+			base = lookup
+		}
+		fields = n.Field
+	case *parse.PipeNode:
+		// A parenthesized path, as (.Values.missing).
+		if len(n.Decl) > 0 || len(n.Cmds) != 1 || len(n.Cmds[0].Args) != 1 {
+			return nil
+		}
+		return lenientLookup(n.Cmds[0].Args[0])
+	default:
+		return nil
+	}
+
+	args := []parse.Node{parse.NewIdentifier(lenientValueFunc).SetPos(node.Position()), base}
+	for _, f := range fields {
+		args = append(args, &parse.StringNode{NodeType: parse.NodeString, Pos: node.Position(), Quoted: strconv.Quote(f), Text: f})
+	}
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      node.Position(),
+		Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: node.Position(), Args: args}},
+	}
+}
+
+// undefinedValueMessage returns the message of a strict render failing on a
+// missing key of the path it evaluated, naming the path.
+func undefinedValueMessage(path, errMsg string) string {
+	if m := missingKeyErr.FindStringSubmatch(errMsg); m != nil && fieldPath.MatchString(path) {
+		return fmt.Sprintf("%s is not defined: the key %q is missing", path, m[1])
+	}
+	return errMsg
+}
@@ -0,0 +1,135 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func strictChart(templates map[string]string) (*chart.Chart, chartutil.Values) {
+	c, v := funcMapOptionsChart(templates)
+	v["Values"] = chartutil.Values{
+		"image": map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+		"empty": map[string]interface{}{},
+		"ports": map[string]interface{}{"http": map[string]interface{}{"name": "http"}},
+	}
+	return c, v
+}
+
+func TestRenderStrictTypo(t *testing.T) {
+	c, v := strictChart(map[string]string{
+		"templates/deployment.yaml": "kind: Deployment\nimage: {{ .Values.image.repository }}:{{ .Values.image.tga }}\n",
+	})
+
+	e := Engine{Strict: true}
+	_, err := e.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "host/templates/deployment.yaml:2:")
+	assert.Contains(t, err.Error(), `.Values.image.tga is not defined: the key "tga" is missing`)
+	assert.Contains(t, err.Error(), "hint: .Values.image.tga is not set")
+	assert.Contains(t, err.Error(), "hint: existing keys under .Values.image: tag")
+
+	// Outside of strict mode, the typo renders nothing.
+	out, err := Engine{}.Render(c, v)
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\nimage: nginx:\n", out["host/templates/deployment.yaml"])
+}
+
+func TestRenderStrictMissingInIncludeAndTpl(t *testing.T) {
+	for name, tpl := range map[string]string{
+		"include": `{{ include "host.name" . }}`,
+		"tpl":     `{{ tpl "{{ .Values.nmae }}" . }}`,
+	} {
+		c, v := strictChart(map[string]string{
+			"templates/cm.yaml":  tpl,
+			"templates/_helpers": `{{ define "host.name" }}{{ .Values.nmae }}{{ end }}`,
+		})
+		_, err := Engine{Strict: true}.Render(c, v)
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), `.Values.nmae`, name)
+		assert.Contains(t, err.Error(), `"nmae"`, name)
+	}
+}
+
+func TestRenderStrictLenientFuncs(t *testing.T) {
+	c, v := strictChart(map[string]string{
+		"templates/cm.yaml": `a: {{ .Values.missing | default "a" }}
+b: {{ default "b" .Values.missing.deeper }}
+c: {{ $.Values.image.missing | default "c" | quote }}
+d: {{ hasKey .Values "missing" }} {{ hasKey .Values.missing "key" }}
+e: {{ empty .Values.missing }}
+f: {{ coalesce .Values.missing .Values.image.tag }}
+g: {{ (.Values.missing | default .Values.image).tag }}
+h: {{ include "host.tag" . }}
+i: {{ tpl "{{ .Values.missing | default \"i\" }}" . }}
+{{- if hasKey .Values "missing" }}
+j: {{ .Values.missing }}
+{{- end }}
+{{- range $k, $v := .Values.ports }}
+k: {{ $v.port | default 80 }} {{ .protocol | default "TCP" }}
+{{- end }}
+{{- with .Values.image }}
+l: {{ .pullPolicy | default "IfNotPresent" }}
+{{- end }}
+{{- $img := .Values.image }}
+m: {{ $img.pullPolicy | default "Always" }}
+n: {{ default "n" (.Values.missing) }} {{ default "n" (.Values.image).missing }}
+o: {{ .Chart.Name | default "o" }} {{ .Release.Missing | default "o" }}`,
+		"templates/_helpers": `{{ define "host.tag" }}{{ .Values.image.digest | default .Values.image.tag }}{{ end }}`,
+	})
+
+	out, err := Engine{Strict: true}.Render(c, v)
+	require.NoError(t, err)
+	assert.Equal(t, `a: a
+b: b
+c: "c"
+d: false false
+e: true
+f: 1.27
+g: 1.27
+h: 1.27
+i: i
+k: 80 TCP
+l: IfNotPresent
+m: Always
+n: n n
+o: host o`, out["host/templates/cm.yaml"])
+}
+
+func TestRenderStrictRequired(t *testing.T) {
+	c, v := strictChart(map[string]string{
+		"templates/cm.yaml": `{{ required "image.digest is required" .Values.image.digest }}`,
+	})
+	_, err := Engine{Strict: true}.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "image.digest is required")
+}
+
+func TestRenderStrictPathThroughValue(t *testing.T) {
+	c, v := strictChart(map[string]string{
+		"templates/cm.yaml": `{{ .Values.image.tag.minor | default "x" }}`,
+	})
+	_, err := Engine{Strict: true}.Render(c, v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't evaluate field minor in type string")
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ShouldForwardCredentials reports whether the credentials of a repository
+// located at from may be sent along with a request to to, either directly or
+// when following a redirect.
+//
+// Credentials are forwarded when both URLs share the same scheme, host and
+// port, the default port of the scheme being equivalent to no port at all.
+// They are also forwarded to any host when passCredentialsAll is set, and to
+// the hosts listed in credentialDomains, where an entry of the form
+// "*.example.com" matches every subdomain of example.com. Credentials are
+// never sent over plain HTTP once the repository is reached over HTTPS.
+func ShouldForwardCredentials(from, to *url.URL, passCredentialsAll bool, credentialDomains []string) bool {
+	if isDowngrade(from, to) {
+		return false
+	}
+	if passCredentialsAll || URLEqual(from, to) {
+		return true
+	}
+
+	host := strings.ToLower(to.Hostname())
+	if host == "" {
+		return false
+	}
+	for _, domain := range credentialDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// URLEqual reports whether both URLs point to the same service, that is share
+// the same scheme, host and port.
+func URLEqual(u1, u2 *url.URL) bool {
+	return u1.Scheme == u2.Scheme && strings.EqualFold(u1.Hostname(), u2.Hostname()) && portOrDefault(u1) == portOrDefault(u2)
+}
+
+// isDowngrade reports whether going from one URL to the other drops TLS.
+func isDowngrade(from, to *url.URL) bool {
+	return from.Scheme == "https" && to.Scheme == "http"
+}
+
+func portOrDefault(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+
+	switch u.Scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestURLEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		url1     string
+		url2     string
+		expected bool
+	}{
+		{
+			name:     "identical URLs",
+			url1:     "https://example.com:443",
+			url2:     "https://example.com:443",
+			expected: true,
+		},
+		{
+			name:     "same host, scheme, default HTTPS port vs explicit",
+			url1:     "https://example.com",
+			url2:     "https://example.com:443",
+			expected: true,
+		},
+		{
+			name:     "same host, scheme, default HTTP port vs explicit",
+			url1:     "http://example.com",
+			url2:     "http://example.com:80",
+			expected: true,
+		},
+		{
+			name:     "host case differs",
+			url1:     "https://Example.com",
+			url2:     "https://example.com",
+			expected: true,
+		},
+		{
+			name:     "different schemes",
+			url1:     "http://example.com",
+			url2:     "https://example.com",
+			expected: false,
+		},
+		{
+			name:     "different hosts",
+			url1:     "https://example.com",
+			url2:     "https://www.example.com",
+			expected: false,
+		},
+		{
+			name:     "different ports",
+			url1:     "https://example.com:8080",
+			url2:     "https://example.com:9090",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := URLEqual(mustParseURL(t, tc.url1), mustParseURL(t, tc.url2)); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestShouldForwardCredentials(t *testing.T) {
+	tests := []struct {
+		name               string
+		from               string
+		to                 string
+		passCredentialsAll bool
+		credentialDomains  []string
+		expected           bool
+	}{
+		{
+			name:     "same location",
+			from:     "https://charts.example.com/stable",
+			to:       "https://charts.example.com/stable/nginx-1.0.0.tgz",
+			expected: true,
+		},
+		{
+			name:     "default port equivalence",
+			from:     "https://charts.example.com",
+			to:       "https://charts.example.com:443/nginx-1.0.0.tgz",
+			expected: true,
+		},
+		{
+			name:     "cross-host redirect",
+			from:     "https://charts.example.com",
+			to:       "https://cdn.example.net/nginx-1.0.0.tgz",
+			expected: false,
+		},
+		{
+			name:     "different port",
+			from:     "https://charts.example.com",
+			to:       "https://charts.example.com:8443/nginx-1.0.0.tgz",
+			expected: false,
+		},
+		{
+			name:              "allowlisted host",
+			from:              "https://charts.example.com",
+			to:                "https://cdn.example.net/nginx-1.0.0.tgz",
+			credentialDomains: []string{"cdn.example.net"},
+			expected:          true,
+		},
+		{
+			name:              "allowlisted subdomains",
+			from:              "https://charts.example.com",
+			to:                "https://eu.cdn.example.net/nginx-1.0.0.tgz",
+			credentialDomains: []string{"*.cdn.example.net"},
+			expected:          true,
+		},
+		{
+			name:              "wildcard does not match the bare domain",
+			from:              "https://charts.example.com",
+			to:                "https://cdn.example.net/nginx-1.0.0.tgz",
+			credentialDomains: []string{"*.cdn.example.net"},
+			expected:          false,
+		},
+		{
+			name:              "host not allowlisted",
+			from:              "https://charts.example.com",
+			to:                "https://evil.example.org/nginx-1.0.0.tgz",
+			credentialDomains: []string{"cdn.example.net"},
+			expected:          false,
+		},
+		{
+			name:               "pass credentials to all hosts",
+			from:               "https://charts.example.com",
+			to:                 "https://cdn.example.net/nginx-1.0.0.tgz",
+			passCredentialsAll: true,
+			expected:           true,
+		},
+		{
+			name:     "scheme downgrade on the same host",
+			from:     "https://charts.example.com",
+			to:       "http://charts.example.com/nginx-1.0.0.tgz",
+			expected: false,
+		},
+		{
+			name:               "scheme downgrade with pass credentials to all hosts",
+			from:               "https://charts.example.com",
+			to:                 "http://cdn.example.net/nginx-1.0.0.tgz",
+			passCredentialsAll: true,
+			expected:           false,
+		},
+		{
+			name:              "scheme downgrade to an allowlisted host",
+			from:              "https://charts.example.com",
+			to:                "http://cdn.example.net/nginx-1.0.0.tgz",
+			credentialDomains: []string{"cdn.example.net"},
+			expected:          false,
+		},
+		{
+			name:              "scheme upgrade to an allowlisted host",
+			from:              "http://charts.example.com",
+			to:                "https://cdn.example.net/nginx-1.0.0.tgz",
+			credentialDomains: []string{"cdn.example.net"},
+			expected:          true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ShouldForwardCredentials(mustParseURL(t, tc.from), mustParseURL(t, tc.to), tc.passCredentialsAll, tc.credentialDomains)
+			if got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("failed to parse URL %s: %v", s, err)
+	}
+	return u
+}
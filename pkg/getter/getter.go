@@ -18,6 +18,7 @@ package getter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"slices"
@@ -41,12 +42,17 @@ type options struct {
 	acceptHeader          string
 	username              string
 	password              string
+	bearerToken           string
 	passCredentialsAll    bool
+	credentialDomains     []string
 	userAgent             string
 	version               string
 	registryClient        *registry.Client
 	timeout               time.Duration
 	transport             *http.Transport
+	retries               int
+	retryBackoff          time.Duration
+	ctx                   context.Context
 }
 
 // Option allows specifying various settings configurable by the user for overriding the defaults
@@ -76,12 +82,30 @@ func WithBasicAuth(username, password string) Option {
 	}
 }
 
+// WithBearerToken sets the request's Authorization header to use the provided
+// bearer token, in place of basic auth credentials. Like them, it is only sent
+// to the URL given with WithURL, see ShouldForwardCredentials.
+func WithBearerToken(token string) Option {
+	return func(opts *options) {
+		opts.bearerToken = token
+	}
+}
+
 func WithPassCredentialsAll(pass bool) Option {
 	return func(opts *options) {
 		opts.passCredentialsAll = pass
 	}
 }
 
+// WithCredentialDomains sets the hosts, besides the one of the URL given with
+// WithURL, to which the basic auth credentials may be sent, such as the CDN a
+// repository redirects chart downloads to. See ShouldForwardCredentials.
+func WithCredentialDomains(domains []string) Option {
+	return func(opts *options) {
+		opts.credentialDomains = domains
+	}
+}
+
 // WithUserAgent sets the request's User-Agent header to use the provided agent name.
 func WithUserAgent(userAgent string) Option {
 	return func(opts *options) {
@@ -143,6 +167,33 @@ func WithTransport(transport *http.Transport) Option {
 	}
 }
 
+// DefaultRetryBackoff is the delay before the first retry of a failed
+// download. It doubles with every following attempt.
+const DefaultRetryBackoff = time.Second
+
+// MaxRetryBackoff is the longest delay between two attempts of a failed
+// download, whether it comes from the backoff or from a Retry-After header.
+const MaxRetryBackoff = time.Minute
+
+// WithRetries sets the number of times a failed request is retried. The delay
+// between attempts starts at backoff and doubles after each attempt, unless
+// the server asks for a specific delay with a Retry-After header. Either delay
+// is at most MaxRetryBackoff.
+func WithRetries(count int, backoff time.Duration) Option {
+	return func(opts *options) {
+		opts.retries = count
+		opts.retryBackoff = backoff
+	}
+}
+
+// WithContext sets the context of the requests. Cancelling it also stops the
+// wait between two attempts of a failed download.
+func WithContext(ctx context.Context) Option {
+	return func(opts *options) {
+		opts.ctx = ctx
+	}
+}
+
 // Getter is an interface to support GET to the specified URL.
 type Getter interface {
 	// Get file content by url string
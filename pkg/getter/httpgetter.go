@@ -17,12 +17,17 @@ package getter
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
+	"time"
 
 	"helm.sh/helm/v4/internal/tlsutil"
 	"helm.sh/helm/v4/internal/version"
@@ -44,11 +49,58 @@ func (g *HTTPGetter) Get(href string, options ...Option) (*bytes.Buffer, error)
 }
 
 func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
+	client, err := g.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := g.opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := 0; ; attempt++ {
+		buf, retryAfter, err := g.getOnce(ctx, client, href)
+		if err == nil || retryAfter < 0 || attempt >= g.opts.retries {
+			return buf, err
+		}
+		delay := retryDelay(retryAfter, g.opts.retryBackoff, attempt)
+		slog.Debug("retrying failed download", "url", href, "attempt", attempt+1, "delay", delay, slog.Any("error", err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to fetch %s: %w", href, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns how long to wait before the attempt following attempt:
+// the delay asked for by the server with retryAfter or, if there is none,
+// backoff doubled after each attempt. It is at most MaxRetryBackoff.
+func retryDelay(retryAfter, backoff time.Duration, attempt int) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, MaxRetryBackoff)
+	}
+	delay := backoff
+	for range attempt {
+		if delay >= MaxRetryBackoff/2 {
+			return MaxRetryBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, MaxRetryBackoff)
+}
+
+// getOnce performs a single request for href. When the request failed, it
+// also returns how long to wait before retrying it: a negative delay means the
+// error is permanent, and a zero delay leaves the choice to the caller.
+func (g *HTTPGetter) getOnce(ctx context.Context, client *http.Client, href string) (*bytes.Buffer, time.Duration, error) {
 	// Set a helm specific user agent so that a repo server and metrics can
 	// separate helm calls from other tools interacting with repos.
-	req, err := http.NewRequest(http.MethodGet, href, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
 	if err != nil {
-		return nil, err
+		return nil, -1, err
 	}
 
 	if g.opts.acceptHeader != "" {
@@ -60,43 +112,96 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		req.Header.Set("User-Agent", g.opts.userAgent)
 	}
 
-	// Before setting the basic auth credentials, make sure the URL associated
-	// with the basic auth is the one being fetched.
+	// Before setting the credentials, make sure the URL associated with them
+	// is the one being fetched.
 	u1, err := url.Parse(g.opts.url)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse getter URL: %w", err)
+		return nil, -1, fmt.Errorf("unable to parse getter URL: %w", err)
 	}
 	u2, err := url.Parse(href)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse URL getting from: %w", err)
+		return nil, -1, fmt.Errorf("unable to parse URL getting from: %w", err)
 	}
 
-	// Host on URL (returned from url.Parse) contains the port if present.
-	// This check ensures credentials are not passed between different
-	// services on different ports.
-	if g.opts.passCredentialsAll || (u1.Scheme == u2.Scheme && u1.Host == u2.Host) {
-		if g.opts.username != "" && g.opts.password != "" {
-			req.SetBasicAuth(g.opts.username, g.opts.password)
-		}
-	}
-
-	client, err := g.httpClient()
-	if err != nil {
-		return nil, err
+	if ShouldForwardCredentials(u1, u2, g.opts.passCredentialsAll, g.opts.credentialDomains) {
+		g.setAuthorization(req)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch %s : %s", href, resp.Status)
+		err := fmt.Errorf("failed to fetch %s : %s", href, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return nil, parseRetryAfter(resp.Header.Get("Retry-After")), err
+		}
+		return nil, -1, err
 	}
 
 	buf := bytes.NewBuffer(nil)
-	_, err = io.Copy(buf, resp.Body)
-	return buf, err
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		// Discard the partial download.
+		return nil, 0, err
+	}
+	return buf, 0, nil
+}
+
+// checkRedirect decides which credentials are sent when following a redirect.
+// Rather than failing, a redirect to a location the credentials must not be
+// forwarded to is followed anonymously.
+func (g *HTTPGetter) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	req.Header.Del("Authorization")
+	if g.opts.bearerToken == "" && (g.opts.username == "" || g.opts.password == "") {
+		return nil
+	}
+	from, err := url.Parse(g.opts.url)
+	if err != nil {
+		return nil
+	}
+	// A hop dropping TLS would expose the credentials even when the final
+	// location is trusted.
+	if isDowngrade(via[len(via)-1].URL, req.URL) {
+		return nil
+	}
+	if ShouldForwardCredentials(from, req.URL, g.opts.passCredentialsAll, g.opts.credentialDomains) {
+		g.setAuthorization(req)
+	}
+	return nil
+}
+
+// setAuthorization sets the Authorization header of req from the basic auth
+// credentials or, if there are none, the bearer token, so that the token never
+// overrides the credentials configured for a repository.
+func (g *HTTPGetter) setAuthorization(req *http.Request) {
+	switch {
+	case g.opts.username != "" && g.opts.password != "":
+		req.SetBasicAuth(g.opts.username, g.opts.password)
+	case g.opts.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+g.opts.bearerToken)
+	}
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, given
+// either in seconds or as an HTTP date. It returns zero if there is none.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
 
 // NewHTTPGetter constructs a valid http/https client as a Getter
@@ -113,8 +218,9 @@ func NewHTTPGetter(options ...Option) (Getter, error) {
 func (g *HTTPGetter) httpClient() (*http.Client, error) {
 	if g.opts.transport != nil {
 		return &http.Client{
-			Transport: g.opts.transport,
-			Timeout:   g.opts.timeout,
+			Transport:     g.opts.transport,
+			Timeout:       g.opts.timeout,
+			CheckRedirect: g.checkRedirect,
 		}, nil
 	}
 
@@ -149,8 +255,9 @@ func (g *HTTPGetter) httpClient() (*http.Client, error) {
 	}
 
 	client := &http.Client{
-		Transport: g.transport,
-		Timeout:   g.opts.timeout,
+		Transport:     g.transport,
+		Timeout:       g.opts.timeout,
+		CheckRedirect: g.checkRedirect,
 	}
 
 	return client, nil
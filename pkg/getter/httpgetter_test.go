@@ -16,6 +16,8 @@ limitations under the License.
 package getter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -678,3 +680,328 @@ func TestHTTPTransportOption(t *testing.T) {
 		t.Fatal("transport.TLSClientConfig should not be set")
 	}
 }
+
+func TestHTTPGetterRetries(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch {
+		case r.URL.Path == "/missing.tgz":
+			w.WriteHeader(http.StatusNotFound)
+		case attempts == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case attempts == 2:
+			// Announce more data than is sent, so the client sees a truncated body.
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "partial")
+		default:
+			fmt.Fprint(w, "chart")
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		attempts = 0
+		g, err := NewHTTPGetter(WithURL(srv.URL), WithRetries(2, time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := g.Get(srv.URL + "/chart.tgz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != "chart" {
+			t.Errorf("expected the partial download to be discarded, got %q", got.String())
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("gives up after the last retry", func(t *testing.T) {
+		attempts = 0
+		g, err := NewHTTPGetter(WithURL(srv.URL), WithRetries(1, time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Get(srv.URL + "/chart.tgz"); err == nil {
+			t.Fatal("expected the download to fail")
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		attempts = 0
+		g, err := NewHTTPGetter(WithURL(srv.URL), WithRetries(3, time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Get(srv.URL + "/missing.tgz"); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Fatalf("expected a 404 error, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestHTTPGetterRetryAfterCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	g, err := NewHTTPGetter(WithURL(srv.URL), WithRetries(3, time.Millisecond), WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := g.Get(srv.URL + "/chart.tgz"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to be interrupted by the context, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the download to stop with the context, it took %s", elapsed)
+	}
+}
+
+func TestHTTPGetterCredentialsOnRedirect(t *testing.T) {
+	var cdnAuth bool
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, cdnAuth = r.BasicAuth()
+		fmt.Fprint(w, "chart")
+	}))
+	defer cdn.Close()
+
+	// The repository and the CDN listen on the loopback address, but are
+	// told apart by their host names.
+	cdnURL, _ := url.ParseRequestURI(cdn.URL)
+	cdnURL.Host = "localhost:" + cdnURL.Port()
+
+	var repoAuth bool
+	repoSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, repoAuth = r.BasicAuth()
+		switch r.URL.Path {
+		case "/moved.tgz":
+			http.Redirect(w, r, "/chart.tgz", http.StatusFound)
+		case "/chart.tgz":
+			fmt.Fprint(w, "chart")
+		default:
+			http.Redirect(w, r, cdnURL.String()+"/chart.tgz", http.StatusFound)
+		}
+	}))
+	defer repoSrv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		opts     []Option
+		repoAuth bool
+		cdnAuth  bool
+	}{
+		{
+			name:     "same host redirect",
+			path:     "/moved.tgz",
+			repoAuth: true,
+		},
+		{
+			name:     "cross-host redirect is followed anonymously",
+			path:     "/cdn.tgz",
+			repoAuth: true,
+			cdnAuth:  false,
+		},
+		{
+			name:     "cross-host redirect to an allowlisted host",
+			path:     "/cdn.tgz",
+			opts:     []Option{WithCredentialDomains([]string{"localhost"})},
+			repoAuth: true,
+			cdnAuth:  true,
+		},
+		{
+			name:     "cross-host redirect with pass credentials to all hosts",
+			path:     "/cdn.tgz",
+			opts:     []Option{WithPassCredentialsAll(true)},
+			repoAuth: true,
+			cdnAuth:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repoAuth, cdnAuth = false, false
+			opts := append([]Option{WithURL(repoSrv.URL), WithBasicAuth("username", "password")}, tc.opts...)
+			g, err := NewHTTPGetter(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := g.Get(repoSrv.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != "chart" {
+				t.Errorf("expected %q, got %q", "chart", got.String())
+			}
+			if repoAuth != tc.repoAuth {
+				t.Errorf("expected credentials sent to the repository to be %t, got %t", tc.repoAuth, repoAuth)
+			}
+			if cdnAuth != tc.cdnAuth {
+				t.Errorf("expected credentials sent to the CDN to be %t, got %t", tc.cdnAuth, cdnAuth)
+			}
+		})
+	}
+}
+
+func TestHTTPGetterBearerToken(t *testing.T) {
+	var otherAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, "chart")
+	}))
+	defer other.Close()
+
+	// Both servers listen on the loopback address, but are told apart by
+	// their host names.
+	otherURL, _ := url.ParseRequestURI(other.URL)
+	otherURL.Host = "localhost:" + otherURL.Port()
+
+	var repoAuth string
+	repoSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repoAuth = r.Header.Get("Authorization")
+		if r.URL.Path == "/other.tgz" {
+			http.Redirect(w, r, otherURL.String()+"/chart.tgz", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "chart")
+	}))
+	defer repoSrv.Close()
+
+	tests := []struct {
+		name      string
+		href      string
+		opts      []Option
+		repoAuth  string
+		otherAuth string
+	}{
+		{
+			name:     "token sent to the configured host",
+			href:     repoSrv.URL + "/chart.tgz",
+			repoAuth: "Bearer token",
+		},
+		{
+			name:     "basic auth preferred to token",
+			href:     repoSrv.URL + "/chart.tgz",
+			opts:     []Option{WithBasicAuth("username", "password")},
+			repoAuth: "Basic dXNlcm5hbWU6cGFzc3dvcmQ=",
+		},
+		{
+			name: "token not sent to another host",
+			href: otherURL.String() + "/chart.tgz",
+		},
+		{
+			name:     "token not forwarded on a cross-host redirect",
+			href:     repoSrv.URL + "/other.tgz",
+			repoAuth: "Bearer token",
+		},
+		{
+			name:      "token forwarded with pass credentials to all hosts",
+			href:      repoSrv.URL + "/other.tgz",
+			opts:      []Option{WithPassCredentialsAll(true)},
+			repoAuth:  "Bearer token",
+			otherAuth: "Bearer token",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repoAuth, otherAuth = "", ""
+			opts := append([]Option{WithURL(repoSrv.URL), WithBearerToken("token")}, tc.opts...)
+			g, err := NewHTTPGetter(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := g.Get(tc.href); err != nil {
+				t.Fatal(err)
+			}
+			if repoAuth != tc.repoAuth {
+				t.Errorf("expected Authorization %q sent to the repository, got %q", tc.repoAuth, repoAuth)
+			}
+			if otherAuth != tc.otherAuth {
+				t.Errorf("expected Authorization %q sent to the other host, got %q", tc.otherAuth, otherAuth)
+			}
+		})
+	}
+}
+
+func TestHTTPGetterCredentialsOnSchemeDowngrade(t *testing.T) {
+	var plainAuth bool
+	plainSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, plainAuth = r.BasicAuth()
+		fmt.Fprint(w, "chart")
+	}))
+	defer plainSrv.Close()
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plainSrv.URL+"/chart.tgz", http.StatusFound)
+	}))
+	defer tlsSrv.Close()
+
+	plainURL, _ := url.ParseRequestURI(plainSrv.URL)
+	g, err := NewHTTPGetter(
+		WithURL(tlsSrv.URL),
+		WithBasicAuth("username", "password"),
+		WithPassCredentialsAll(true),
+		WithCredentialDomains([]string{plainURL.Hostname()}),
+		WithTransport(tlsSrv.Client().Transport.(*http.Transport)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(tlsSrv.URL + "/chart.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	if plainAuth {
+		t.Error("expected credentials not to be forwarded over plain HTTP")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter(""); d != 0 {
+		t.Errorf("expected no delay without a header, got %s", d)
+	}
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("expected a delay of 3s, got %s", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("expected no delay for an invalid header, got %s", d)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(date); d <= 0 || d > time.Minute {
+		t.Errorf("expected a delay of up to a minute, got %s", d)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		attempt    int
+		expected   time.Duration
+	}{
+		{"first backoff", 0, 0, time.Second},
+		{"doubled backoff", 0, 3, 8 * time.Second},
+		{"capped backoff", 0, 10, MaxRetryBackoff},
+		{"backoff of many attempts", 0, 100, MaxRetryBackoff},
+		{"retry after", 5 * time.Second, 3, 5 * time.Second},
+		{"huge retry after", parseRetryAfter("86400"), 0, MaxRetryBackoff},
+	}
+	for _, tt := range tests {
+		if d := retryDelay(tt.retryAfter, time.Second, tt.attempt); d != tt.expected {
+			t.Errorf("%s: expected a delay of %s, got %s", tt.name, tt.expected, d)
+		}
+	}
+}
@@ -19,7 +19,6 @@ package kube // import "helm.sh/helm/v4/pkg/kube"
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -30,7 +29,6 @@ import (
 	"strings"
 	"sync"
 
-	jsonpatch "github.com/evanphx/json-patch/v5"
 	v1 "k8s.io/api/core/v1"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -44,9 +42,6 @@ import (
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
-	"k8s.io/apimachinery/pkg/util/mergepatch"
-	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes"
@@ -78,6 +73,10 @@ type Client struct {
 	Factory Factory
 	// Namespace allows to bypass the kubeconfig file for the choice of the namespace
 	Namespace string
+	// FieldManager is the name of the manager of the fields set on the
+	// resources created, updated or patched by the client. It defaults to
+	// ManagedFieldsManager, or to the name of the running binary.
+	FieldManager string
 
 	Waiter
 	kubeClient kubernetes.Interface
@@ -88,7 +87,11 @@ type WaitStrategy string
 const (
 	StatusWatcherStrategy WaitStrategy = "watcher"
 	LegacyStrategy        WaitStrategy = "legacy"
-	HookOnlyStrategy      WaitStrategy = "hookOnly"
+	// HookOnlyStrategy does not wait for the resources of a release, but
+	// watches its hooks until they are ready, like StatusWatcherStrategy. The
+	// actions replace it by StatusWatcherStrategy when they are atomic, so that
+	// a failed hook or resource rolls the release back.
+	HookOnlyStrategy WaitStrategy = "hookOnly"
 )
 
 func init() {
@@ -197,7 +200,9 @@ func (c *Client) IsReachable() error {
 // Create creates Kubernetes resources specified in the resource list.
 func (c *Client) Create(resources ResourceList) (*Result, error) {
 	slog.Debug("creating resource(s)", "resources", len(resources))
-	if err := perform(resources, createResource); err != nil {
+	if err := perform(resources, func(info *resource.Info) error {
+		return createResource(info, c.fieldManager())
+	}); err != nil {
 		return nil, err
 	}
 	return &Result{Created: resources}, nil
@@ -397,18 +402,24 @@ func (c *Client) BuildTable(reader io.Reader, validate bool) (ResourceList, erro
 	return result, scrubValidationError(err)
 }
 
-func (c *Client) update(original, target ResourceList, force, threeWayMerge bool) (*Result, error) {
+// update updates target from original. The resources are applied server-side
+// if apply is set, and patched client-side otherwise. The resources of target
+// in replace, as well as those with the replace upgrade strategy, are
+// replaced, and waited for deletion until ctx is done.
+func (c *Client) update(ctx context.Context, original, target, replace ResourceList, force, threeWayMerge bool, apply *ServerSideApplyOptions) (*Result, error) {
 	updateErrors := []error{}
 	res := &Result{}
 
 	slog.Debug("checking resources for changes", "resources", len(target))
+	live := fetchLiveObjects(target)
 	err := target.Visit(func(info *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
 
-		helper := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(getManagedFieldsManager())
-		if _, err := helper.Get(info.Namespace, info.Name); err != nil {
+		helper := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(c.fieldManager())
+		current, err := live.get(info, helper)
+		if err != nil {
 			if !apierrors.IsNotFound(err) {
 				return fmt.Errorf("could not get information about the resource: %w", err)
 			}
@@ -417,7 +428,11 @@ func (c *Client) update(original, target ResourceList, force, threeWayMerge bool
 			res.Created = append(res.Created, info)
 
 			// Since the resource does not exist, create it.
-			if err := createResource(info); err != nil {
+			if apply != nil {
+				if err := applyResource(info, c.fieldManager(), *apply); err != nil {
+					return fmt.Errorf("failed to create resource: %w", err)
+				}
+			} else if err := createResource(info, c.fieldManager()); err != nil {
 				return fmt.Errorf("failed to create resource: %w", err)
 			}
 
@@ -432,7 +447,19 @@ func (c *Client) update(original, target ResourceList, force, threeWayMerge bool
 			return fmt.Errorf("no %s with the name %q found", kind, info.Name)
 		}
 
-		if err := updateResource(c, info, originalInfo.Object, force, threeWayMerge); err != nil {
+		switch {
+		case (isReplaced(info.Object) || replace.Contains(info)) && (apply == nil || !apply.DryRun):
+			var replaced bool
+			replaced, err = c.replaceResource(ctx, info, originalInfo.Object, current, threeWayMerge, apply)
+			if replaced {
+				res.Replaced = append(res.Replaced, info)
+			}
+		case apply != nil:
+			err = applyResource(info, c.fieldManager(), *apply)
+		default:
+			err = updateResource(c, info, originalInfo.Object, live.listedObject(info), force, threeWayMerge)
+		}
+		if err != nil {
 			slog.Debug("error updating the resource", "namespace", info.Namespace, "name", info.Name, "kind", info.Mapping.GroupVersionKind.Kind, slog.Any("error", err))
 			updateErrors = append(updateErrors, err)
 		}
@@ -449,6 +476,11 @@ func (c *Client) update(original, target ResourceList, force, threeWayMerge bool
 		return res, joinErrors(updateErrors, " && ")
 	}
 
+	// A dry run leaves the resources no longer in the release alone.
+	if apply != nil && apply.DryRun {
+		return res, nil
+	}
+
 	for _, info := range original.Difference(target) {
 		slog.Debug("deleting resource", "namespace", info.Namespace, "name", info.Name, "kind", info.Mapping.GroupVersionKind.Kind)
 
@@ -484,7 +516,7 @@ func (c *Client) update(original, target ResourceList, force, threeWayMerge bool
 // The difference to Update is that UpdateThreeWayMerge does a three-way-merge
 // for unstructured objects.
 func (c *Client) UpdateThreeWayMerge(original, target ResourceList, force bool) (*Result, error) {
-	return c.update(original, target, force, true)
+	return c.update(context.Background(), original, target, nil, force, true, nil)
 }
 
 // Update takes the current list of objects and target list of objects and
@@ -495,7 +527,14 @@ func (c *Client) UpdateThreeWayMerge(original, target ResourceList, force bool)
 // resource updates, creations, and deletions that were attempted. These can be
 // used for cleanup or other logging purposes.
 func (c *Client) Update(original, target ResourceList, force bool) (*Result, error) {
-	return c.update(original, target, force, false)
+	return c.update(context.Background(), original, target, nil, force, false, nil)
+}
+
+// UpdateWithContext is Update, also replacing the resources of target in
+// replace, and giving up on waiting for the deletion of the resources it
+// replaces when ctx is done.
+func (c *Client) UpdateWithContext(ctx context.Context, original, target, replace ResourceList, force bool) (*Result, error) {
+	return c.update(ctx, original, target, replace, force, false, nil)
 }
 
 // Delete deletes Kubernetes resources specified in the resources list with
@@ -548,6 +587,14 @@ func rdelete(_ *Client, resources ResourceList, propagation metav1.DeletionPropa
 	return res, nil
 }
 
+// fieldManager returns the name of the manager of the fields the client sets.
+func (c *Client) fieldManager() string {
+	if c.FieldManager != "" {
+		return c.FieldManager
+	}
+	return getManagedFieldsManager()
+}
+
 // getManagedFieldsManager returns the manager string. If one was set it will be returned.
 // Otherwise, one is calculated based on the name of the binary.
 func getManagedFieldsManager() string {
@@ -587,13 +634,13 @@ func batchPerform(infos ResourceList, fn func(*resource.Info) error, errs chan<-
 
 var createMutex sync.Mutex
 
-func createResource(info *resource.Info) error {
+func createResource(info *resource.Info, fieldManager string) error {
 	return retry.RetryOnConflict(
 		retry.DefaultRetry,
 		func() error {
 			createMutex.Lock()
 			defer createMutex.Unlock()
-			obj, err := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(getManagedFieldsManager()).Create(info.Namespace, true, info.Object)
+			obj, err := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(fieldManager).Create(info.Namespace, true, info.Object)
 			if err != nil {
 				return err
 			}
@@ -611,73 +658,34 @@ func deleteResource(info *resource.Info, policy metav1.DeletionPropagation) erro
 		})
 }
 
-func createPatch(target *resource.Info, current runtime.Object, threeWayMergeForUnstructured bool) ([]byte, types.PatchType, error) {
-	oldData, err := json.Marshal(current)
-	if err != nil {
-		return nil, types.StrategicMergePatchType, fmt.Errorf("serializing current configuration: %w", err)
-	}
-	newData, err := json.Marshal(target.Object)
-	if err != nil {
-		return nil, types.StrategicMergePatchType, fmt.Errorf("serializing target configuration: %w", err)
-	}
-
+// createPatch computes the patch moving target from its original
+// configuration to its target one, against the object live in the cluster.
+func createPatch(target *resource.Info, original runtime.Object, threeWayMergeForUnstructured bool, fieldManager string) ([]byte, types.PatchType, error) {
 	// Fetch the current object for the three way merge
-	helper := resource.NewHelper(target.Client, target.Mapping).WithFieldManager(getManagedFieldsManager())
+	helper := resource.NewHelper(target.Client, target.Mapping).WithFieldManager(fieldManager)
 	currentObj, err := helper.Get(target.Namespace, target.Name)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return nil, types.StrategicMergePatchType, fmt.Errorf("unable to get data for current object %s/%s: %w", target.Namespace, target.Name, err)
 	}
+	return createPatchAgainst(target, original, currentObj, threeWayMergeForUnstructured)
+}
 
-	// Even if currentObj is nil (because it was not found), it will marshal just fine
-	currentData, err := json.Marshal(currentObj)
-	if err != nil {
-		return nil, types.StrategicMergePatchType, fmt.Errorf("serializing live configuration: %w", err)
-	}
-
-	// Get a versioned object
-	versionedObject := AsVersioned(target)
-
-	// Unstructured objects, such as CRDs, may not have a not registered error
-	// returned from ConvertToVersion. Anything that's unstructured should
-	// use generic JSON merge patch. Strategic Merge Patch is not supported
-	// on objects like CRDs.
-	_, isUnstructured := versionedObject.(runtime.Unstructured)
-
-	// On newer K8s versions, CRDs aren't unstructured but has this dedicated type
-	_, isCRD := versionedObject.(*apiextv1beta1.CustomResourceDefinition)
-
-	if isUnstructured || isCRD {
-		if threeWayMergeForUnstructured {
-			// from https://github.com/kubernetes/kubectl/blob/b83b2ec7d15f286720bccf7872b5c72372cb8e80/pkg/cmd/apply/patcher.go#L129
-			preconditions := []mergepatch.PreconditionFunc{
-				mergepatch.RequireKeyUnchanged("apiVersion"),
-				mergepatch.RequireKeyUnchanged("kind"),
-				mergepatch.RequireMetadataKeyUnchanged("name"),
-			}
-			patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(oldData, newData, currentData, preconditions...)
-			if err != nil && mergepatch.IsPreconditionFailed(err) {
-				err = fmt.Errorf("%w: at least one field was changed: apiVersion, kind or name", err)
-			}
-			return patch, types.MergePatchType, err
-		}
-		// fall back to generic JSON merge patch
-		patch, err := jsonpatch.CreateMergePatch(oldData, newData)
-		return patch, types.MergePatchType, err
-	}
-
-	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
-	if err != nil {
-		return nil, types.StrategicMergePatchType, fmt.Errorf("unable to create patch metadata from object: %w", err)
-	}
-
-	patch, err := strategicpatch.CreateThreeWayMergePatch(oldData, newData, currentData, patchMeta, true)
-	return patch, types.StrategicMergePatchType, err
+// createPatchAgainst computes the patch moving target from its original
+// configuration to its target one, against the live object live.
+func createPatchAgainst(target *resource.Info, original, live runtime.Object, threeWayMergeForUnstructured bool) ([]byte, types.PatchType, error) {
+	patchType, patch, err := ComputePatch(&resource.Info{Object: original}, target, live, PatchOptions{
+		ThreeWayMergeForUnstructured: threeWayMergeForUnstructured,
+	})
+	return patch, patchType, err
 }
 
-func updateResource(_ *Client, target *resource.Info, currentObj runtime.Object, force, threeWayMergeForUnstructured bool) error {
+// updateResource updates target from its original configuration currentObj.
+// live is the object live in the cluster if it was listed before, or nil if it
+// is to be fetched.
+func updateResource(c *Client, target *resource.Info, currentObj, live runtime.Object, force, threeWayMergeForUnstructured bool) error {
 	var (
 		obj    runtime.Object
-		helper = resource.NewHelper(target.Client, target.Mapping).WithFieldManager(getManagedFieldsManager())
+		helper = resource.NewHelper(target.Client, target.Mapping).WithFieldManager(c.fieldManager())
 		kind   = target.Mapping.GroupVersionKind.Kind
 	)
 
@@ -690,7 +698,16 @@ func updateResource(_ *Client, target *resource.Info, currentObj runtime.Object,
 		}
 		slog.Debug("replace succeeded", "name", target.Name, "initialKind", currentObj.GetObjectKind().GroupVersionKind().Kind, "kind", kind)
 	} else {
-		patch, patchType, err := createPatch(target, currentObj, threeWayMergeForUnstructured)
+		var (
+			patch     []byte
+			patchType types.PatchType
+			err       error
+		)
+		if live != nil {
+			patch, patchType, err = createPatchAgainst(target, currentObj, live, threeWayMergeForUnstructured)
+		} else {
+			patch, patchType, err = createPatch(target, currentObj, threeWayMergeForUnstructured, c.fieldManager())
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create patch: %w", err)
 		}
@@ -699,7 +716,9 @@ func updateResource(_ *Client, target *resource.Info, currentObj runtime.Object,
 			slog.Debug("no changes detected", "kind", kind, "name", target.Name)
 			// This needs to happen to make sure that Helm has the latest info from the API
 			// Otherwise there will be no labels and other functions that use labels will panic
-			if err := target.Get(); err != nil {
+			if live != nil {
+				target.Refresh(live, true)
+			} else if err := target.Get(); err != nil {
 				return fmt.Errorf("failed to refresh resource information: %w", err)
 			}
 			return nil
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -341,6 +342,35 @@ func testUpdate(t *testing.T, threeWayMerge bool) {
 	}
 }
 
+func TestCreateFieldManager(t *testing.T) {
+	list := newPodList("starfish")
+
+	var managers []string
+	c := newTestClient(t)
+	c.FieldManager = "argocd-controller"
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			managers = append(managers, req.URL.Query().Get("fieldManager"))
+			return newResponse(http.StatusOK, &list.Items[0])
+		}),
+	}
+
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+	_, err = c.Create(resources)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"argocd-controller"}, managers)
+}
+
+func TestClientFieldManager(t *testing.T) {
+	c := &Client{}
+	assert.Equal(t, getManagedFieldsManager(), c.fieldManager(), "the manager defaults to the name of the binary")
+
+	c.FieldManager = "argocd-controller"
+	assert.Equal(t, "argocd-controller", c.fieldManager())
+}
+
 func TestUpdate(t *testing.T) {
 	testUpdate(t, false)
 }
@@ -987,7 +1017,7 @@ func (c createPatchTestCase) run(t *testing.T) {
 		},
 	}
 
-	patch, patchType, err := createPatch(targetInfo, c.current, c.threeWayMergeForUnstructured)
+	patch, patchType, err := createPatch(targetInfo, c.current, c.threeWayMergeForUnstructured, "helm")
 	if err != nil {
 		t.Fatalf("Failed to create patch: %v", err)
 	}
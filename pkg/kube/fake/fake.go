@@ -18,6 +18,7 @@ limitations under the License.
 package fake
 
 import (
+	"fmt"
 	"io"
 	"time"
 
@@ -47,6 +48,12 @@ type FailingKubeClient struct {
 	WaitForDeleteError         error
 	WatchUntilReadyError       error
 	WaitDuration               time.Duration
+	ResourceStatusesError      error
+	DummyResourceStatuses      []kube.ResourceStatus
+	PruneUnknownFieldsError    error
+	DummyPrunedFields          []kube.PrunedFields
+	// ServerSideApplySupported is reported by IsServerSideApplySupported.
+	ServerSideApplySupported bool
 }
 
 // FailingKubeWaiter implements kube.Waiter for testing purposes.
@@ -57,6 +64,9 @@ type FailingKubeWaiter struct {
 	waitForDeleteError   error
 	watchUntilReadyError error
 	waitDuration         time.Duration
+	// hookOnly makes the waiter only watch hooks, as the waiter of
+	// kube.HookOnlyStrategy does.
+	hookOnly bool
 }
 
 // Create returns the configured error if set or prints
@@ -76,7 +86,15 @@ func (f *FailingKubeClient) Get(resources kube.ResourceList, related bool) (map[
 }
 
 // Waits the amount of time defined on f.WaitDuration, then returns the configured error if set or prints.
+// When f.WaitDuration exceeds a non-zero timeout, it waits for the timeout and times out instead.
 func (f *FailingKubeWaiter) Wait(resources kube.ResourceList, d time.Duration) error {
+	if f.hookOnly {
+		return nil
+	}
+	if d > 0 && f.waitDuration > d {
+		time.Sleep(d)
+		return fmt.Errorf("timed out waiting for the condition after %s", d)
+	}
 	time.Sleep(f.waitDuration)
 	if f.waitError != nil {
 		return f.waitError
@@ -86,6 +104,9 @@ func (f *FailingKubeWaiter) Wait(resources kube.ResourceList, d time.Duration) e
 
 // WaitWithJobs returns the configured error if set or prints
 func (f *FailingKubeWaiter) WaitWithJobs(resources kube.ResourceList, d time.Duration) error {
+	if f.hookOnly {
+		return nil
+	}
 	if f.waitError != nil {
 		return f.waitError
 	}
@@ -94,6 +115,9 @@ func (f *FailingKubeWaiter) WaitWithJobs(resources kube.ResourceList, d time.Dur
 
 // WaitForDelete returns the configured error if set or prints
 func (f *FailingKubeWaiter) WaitForDelete(resources kube.ResourceList, d time.Duration) error {
+	if f.hookOnly {
+		return nil
+	}
 	if f.waitForDeleteError != nil {
 		return f.waitForDeleteError
 	}
@@ -162,6 +186,50 @@ func (f *FailingKubeClient) DeleteWithPropagationPolicy(resources kube.ResourceL
 	return f.PrintingKubeClient.DeleteWithPropagationPolicy(resources, policy)
 }
 
+// ResourceStatuses returns the configured error or statuses if set or prints
+func (f *FailingKubeClient) ResourceStatuses(resources kube.ResourceList) ([]kube.ResourceStatus, error) {
+	if f.ResourceStatusesError != nil {
+		return nil, f.ResourceStatusesError
+	}
+	if f.DummyResourceStatuses != nil {
+		return f.DummyResourceStatuses, nil
+	}
+	return f.PrintingKubeClient.ResourceStatuses(resources)
+}
+
+// PruneUnknownFields returns the configured error or pruned fields if set or
+// prunes nothing
+func (f *FailingKubeClient) PruneUnknownFields(resources kube.ResourceList) ([]kube.PrunedFields, error) {
+	if f.PruneUnknownFieldsError != nil {
+		return nil, f.PruneUnknownFieldsError
+	}
+	if f.DummyPrunedFields != nil {
+		return f.DummyPrunedFields, nil
+	}
+	return f.PrintingKubeClient.PruneUnknownFields(resources)
+}
+
+// IsServerSideApplySupported returns ServerSideApplySupported
+func (f *FailingKubeClient) IsServerSideApplySupported() (bool, error) {
+	return f.ServerSideApplySupported, nil
+}
+
+// CreateServerSideApply returns the configured create error if set or prints
+func (f *FailingKubeClient) CreateServerSideApply(resources kube.ResourceList, opts kube.ServerSideApplyOptions) (*kube.Result, error) {
+	if f.CreateError != nil {
+		return nil, f.CreateError
+	}
+	return f.PrintingKubeClient.CreateServerSideApply(resources, opts)
+}
+
+// UpdateServerSideApply returns the configured update error if set or prints
+func (f *FailingKubeClient) UpdateServerSideApply(r, modified kube.ResourceList, opts kube.ServerSideApplyOptions) (*kube.Result, error) {
+	if f.UpdateError != nil {
+		return &kube.Result{}, f.UpdateError
+	}
+	return f.PrintingKubeClient.UpdateServerSideApply(r, modified, opts)
+}
+
 func (f *FailingKubeClient) GetWaiter(ws kube.WaitStrategy) (kube.Waiter, error) {
 	waiter, _ := f.PrintingKubeClient.GetWaiter(ws)
 	printingKubeWaiter, _ := waiter.(*PrintingKubeWaiter)
@@ -171,6 +239,7 @@ func (f *FailingKubeClient) GetWaiter(ws kube.WaitStrategy) (kube.Waiter, error)
 		waitForDeleteError:   f.WaitForDeleteError,
 		watchUntilReadyError: f.WatchUntilReadyError,
 		waitDuration:         f.WaitDuration,
+		hookOnly:             ws == kube.HookOnlyStrategy,
 	}, nil
 }
 
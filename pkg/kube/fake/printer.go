@@ -150,6 +150,41 @@ func (p *PrintingKubeClient) GetWaiter(_ kube.WaitStrategy) (kube.Waiter, error)
 	return &PrintingKubeWaiter{Out: p.Out, LogOutput: p.LogOutput}, nil
 }
 
+// ResourceStatuses reports every resource as ready.
+func (p *PrintingKubeClient) ResourceStatuses(resources kube.ResourceList) ([]kube.ResourceStatus, error) {
+	statuses := make([]kube.ResourceStatus, 0, len(resources))
+	for _, r := range resources {
+		statuses = append(statuses, kube.ResourceStatus{
+			Namespace: r.Namespace,
+			Name:      r.Name,
+			State:     kube.ResourceReady,
+		})
+	}
+	return statuses, nil
+}
+
+// PruneUnknownFields implements KubeClient PruneUnknownFields, and prunes
+// nothing.
+func (p *PrintingKubeClient) PruneUnknownFields(_ kube.ResourceList) ([]kube.PrunedFields, error) {
+	return nil, nil
+}
+
+// IsServerSideApplySupported implements KubeClient IsServerSideApplySupported,
+// and reports no support.
+func (p *PrintingKubeClient) IsServerSideApplySupported() (bool, error) {
+	return false, nil
+}
+
+// CreateServerSideApply implements KubeClient CreateServerSideApply.
+func (p *PrintingKubeClient) CreateServerSideApply(resources kube.ResourceList, _ kube.ServerSideApplyOptions) (*kube.Result, error) {
+	return p.Create(resources)
+}
+
+// UpdateServerSideApply implements KubeClient UpdateServerSideApply.
+func (p *PrintingKubeClient) UpdateServerSideApply(original, modified kube.ResourceList, _ kube.ServerSideApplyOptions) (*kube.Result, error) {
+	return p.Update(original, modified, false)
+}
+
 func bufferize(resources kube.ResourceList) io.Reader {
 	var builder strings.Builder
 	for _, info := range resources {
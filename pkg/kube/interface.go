@@ -17,6 +17,7 @@ limitations under the License.
 package kube
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -124,8 +125,53 @@ type InterfaceResources interface {
 	BuildTable(reader io.Reader, validate bool) (ResourceList, error)
 }
 
+// InterfacePruneUnknownFields is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfacePruneUnknownFields and integrate its method(s) into the Interface.
+type InterfacePruneUnknownFields interface {
+	// PruneUnknownFields removes from the objects of resources the fields the
+	// cluster does not know, and returns the fields removed from each resource.
+	PruneUnknownFields(resources ResourceList) ([]PrunedFields, error)
+}
+
+// InterfaceServerSideApply is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceServerSideApply and integrate its method(s) into the Interface.
+type InterfaceServerSideApply interface {
+	// IsServerSideApplySupported reports whether the API server supports
+	// server-side apply.
+	IsServerSideApplySupported() (bool, error)
+
+	// CreateServerSideApply creates one or more resources with server-side
+	// apply.
+	CreateServerSideApply(resources ResourceList, opts ServerSideApplyOptions) (*Result, error)
+
+	// UpdateServerSideApply updates one or more resources with server-side
+	// apply, or creates the resource if it doesn't exist.
+	UpdateServerSideApply(original, target ResourceList, opts ServerSideApplyOptions) (*Result, error)
+}
+
+// InterfaceUpdateContext is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceUpdateContext and integrate its method(s) into the Interface.
+type InterfaceUpdateContext interface {
+	// UpdateWithContext is Update, also replacing the resources of target in
+	// replace as if they had the replace upgrade strategy, and giving up on
+	// waiting for the deletion of the resources it replaces when ctx is done.
+	UpdateWithContext(ctx context.Context, original, target, replace ResourceList, force bool) (*Result, error)
+
+	// UpdateServerSideApplyWithContext is UpdateServerSideApply, also
+	// replacing the resources of target in replace as if they had the replace
+	// upgrade strategy, and giving up on waiting for the deletion of the
+	// resources it replaces when ctx is done.
+	UpdateServerSideApplyWithContext(ctx context.Context, original, target, replace ResourceList, opts ServerSideApplyOptions) (*Result, error)
+}
+
 var _ Interface = (*Client)(nil)
 var _ InterfaceThreeWayMerge = (*Client)(nil)
 var _ InterfaceLogs = (*Client)(nil)
 var _ InterfaceDeletionPropagation = (*Client)(nil)
 var _ InterfaceResources = (*Client)(nil)
+var _ InterfacePruneUnknownFields = (*Client)(nil)
+var _ InterfaceServerSideApply = (*Client)(nil)
+var _ InterfaceUpdateContext = (*Client)(nil)
@@ -0,0 +1,194 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"log/slog"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// bulkGetMinResources is the number of resources of a kind in a namespace
+// from which their live objects are listed at once, rather than fetched one
+// by one. Listing returns all the objects of the kind in the namespace, not
+// only the ones of the release, so it only pays off for large releases.
+const bulkGetMinResources = 50
+
+// bulkGetPageSize is the number of objects listed per request.
+var bulkGetPageSize int64 = 500
+
+// liveGroup identifies the resources of a kind in a namespace.
+type liveGroup struct {
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+type liveKey struct {
+	liveGroup
+	name string
+}
+
+func liveKeyOf(info *resource.Info) liveKey {
+	return liveKey{liveGroup{info.Mapping.Resource, info.Namespace}, info.Name}
+}
+
+// listable reports whether the objects of the kind of group may be listed.
+// Secrets are never listed, so that the secrets of the namespace which do
+// not belong to the release are not read.
+func (g liveGroup) listable() bool {
+	return g.resource.GroupResource() != schema.GroupResource{Resource: "secrets"}
+}
+
+// liveObjects holds the live objects of resources, fetched in bulk.
+type liveObjects struct {
+	// wanted are the resources of the kinds and namespaces to list, which
+	// are listed when the first of them is looked up, so that the objects
+	// reflect the changes made to the other kinds before.
+	wanted map[liveGroup]map[string]*resource.Info
+	// listed are the kinds and namespaces whose objects were all listed.
+	listed  map[liveGroup]bool
+	objects map[liveKey]runtime.Object
+}
+
+// fetchLiveObjects prepares the listing of the live objects of the resources,
+// for every kind and namespace holding at least bulkGetMinResources of them.
+// A field selector only matches a single name, so the objects of the kind in
+// the namespace are listed in pages of bulkGetPageSize, until all the
+// resources are found, and only the ones of the resources are kept. The kinds
+// which cannot be listed are left to be fetched one by one.
+func fetchLiveObjects(resources ResourceList) *liveObjects {
+	live := &liveObjects{
+		wanted:  make(map[liveGroup]map[string]*resource.Info),
+		listed:  make(map[liveGroup]bool),
+		objects: make(map[liveKey]runtime.Object),
+	}
+	for _, info := range resources {
+		if info.Mapping == nil || info.Name == "" {
+			continue
+		}
+		key := liveKeyOf(info)
+		if !key.listable() {
+			continue
+		}
+		if live.wanted[key.liveGroup] == nil {
+			live.wanted[key.liveGroup] = make(map[string]*resource.Info)
+		}
+		live.wanted[key.liveGroup][info.Name] = info
+	}
+	for group, infos := range live.wanted {
+		if len(infos) < bulkGetMinResources {
+			delete(live.wanted, group)
+		}
+	}
+	return live
+}
+
+// ensureListed lists the objects of group the first time one of them is
+// looked up.
+func (l *liveObjects) ensureListed(group liveGroup) {
+	infos, ok := l.wanted[group]
+	if !ok {
+		return
+	}
+	delete(l.wanted, group)
+	if err := l.list(group, infos); err != nil {
+		slog.Debug("unable to list the resources, getting them one by one", "resource", group.resource.String(), "namespace", group.namespace, slog.Any("error", err))
+		return
+	}
+	l.listed[group] = true
+}
+
+// list lists the objects of a kind in a namespace, and keeps the ones of
+// infos.
+func (l *liveObjects) list(group liveGroup, infos map[string]*resource.Info) error {
+	var first *resource.Info
+	for _, info := range infos {
+		first = info
+		break
+	}
+	helper := resource.NewHelper(first.Client, first.Mapping)
+	gvk := first.Mapping.GroupVersionKind
+
+	found := make(map[liveKey]runtime.Object)
+	opts := &metav1.ListOptions{Limit: bulkGetPageSize}
+	for {
+		list, err := helper.List(group.namespace, gvk.GroupVersion().String(), opts)
+		if err != nil {
+			return err
+		}
+		err = meta.EachListItem(list, func(obj runtime.Object) error {
+			name, err := metadataAccessor.Name(obj)
+			if err != nil {
+				return err
+			}
+			if _, ok := infos[name]; ok {
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+				found[liveKey{group, name}] = obj
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) == len(infos) {
+			break
+		}
+		next, err := metadataAccessor.Continue(list)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			break
+		}
+		opts.Continue = next
+	}
+
+	for key, obj := range found {
+		l.objects[key] = obj
+	}
+	return nil
+}
+
+// get returns the live object of info, with a not found error if it does not
+// exist. The objects of a kind and namespace which was not listed are fetched
+// with helper.
+func (l *liveObjects) get(info *resource.Info, helper *resource.Helper) (runtime.Object, error) {
+	key := liveKeyOf(info)
+	l.ensureListed(key.liveGroup)
+	if !l.listed[key.liveGroup] {
+		return helper.Get(info.Namespace, info.Name)
+	}
+	if obj, ok := l.objects[key]; ok {
+		return obj, nil
+	}
+	return nil, apierrors.NewNotFound(info.Mapping.Resource.GroupResource(), info.Name)
+}
+
+// listedObject returns the live object of info if it was listed, and nil if
+// it is to be fetched on its own.
+func (l *liveObjects) listedObject(info *resource.Info) runtime.Object {
+	key := liveKeyOf(info)
+	if !l.listed[key.liveGroup] {
+		return nil
+	}
+	return l.objects[key]
+}
@@ -0,0 +1,185 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+// newRoundTripTestClient returns a client serving the pods of live, listed
+// in pages of the requested limit unless listable is false, and counting the
+// requests it receives by method and path.
+func newRoundTripTestClient(t *testing.T, live v1.PodList, listable bool, requests map[string]int) *Client {
+	t.Helper()
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			p, m := req.URL.Path, req.Method
+			requests[m+" "+p]++
+			switch {
+			case p == "/namespaces/default/pods" && m == http.MethodGet:
+				if !listable {
+					return newResponse(http.StatusMethodNotAllowed, &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusMethodNotAllowed, Reason: metav1.StatusReasonMethodNotAllowed})
+				}
+				start, _ := strconv.Atoi(req.URL.Query().Get("continue"))
+				end := len(live.Items)
+				if limit, _ := strconv.Atoi(req.URL.Query().Get("limit")); limit > 0 && start+limit < end {
+					end = start + limit
+				}
+				page := v1.PodList{Items: live.Items[start:end]}
+				if end < len(live.Items) {
+					page.Continue = strconv.Itoa(end)
+				}
+				return newResponse(http.StatusOK, &page)
+			case p == "/namespaces/default/pods" && m == http.MethodPost:
+				return newResponse(http.StatusCreated, &live.Items[0])
+			case strings.HasPrefix(p, "/namespaces/default/pods/"):
+				name := strings.TrimPrefix(p, "/namespaces/default/pods/")
+				for i := range live.Items {
+					if live.Items[i].Name == name {
+						return newResponse(http.StatusOK, &live.Items[i])
+					}
+				}
+				return newResponse(http.StatusNotFound, notFoundBody())
+			default:
+				t.Fatalf("unexpected request: %s %s", m, p)
+				return nil, nil
+			}
+		}),
+	}
+	return c
+}
+
+// TestUpdateRoundTrips counts the requests of the upgrade of a release of 50
+// pods, one of which is changed and one added.
+func TestUpdateRoundTrips(t *testing.T) {
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = fmt.Sprintf("pod-%02d", i)
+	}
+	live := newPodList(names...)
+	original := newPodList(names...)
+	target := newPodList(append(names, "pod-new")...)
+	target.Items[0].Spec.Containers[0].Image = "abc/app:v5"
+
+	tests := []struct {
+		name     string
+		listable bool
+		pageSize int64
+		expected map[string]int
+	}{{
+		name:     "listed at once",
+		listable: true,
+		pageSize: 500,
+		expected: map[string]int{
+			"GET /namespaces/default/pods":          1,
+			"PATCH /namespaces/default/pods/pod-00": 1,
+			"POST /namespaces/default/pods":         1,
+		},
+	}, {
+		name:     "listed in pages",
+		listable: true,
+		pageSize: 20,
+		expected: map[string]int{
+			"GET /namespaces/default/pods":          3,
+			"PATCH /namespaces/default/pods/pod-00": 1,
+			"POST /namespaces/default/pods":         1,
+		},
+	}, {
+		name:     "got one by one when the pods cannot be listed",
+		pageSize: 500,
+		expected: func() map[string]int {
+			// Each pod is got to find it exists and to compute its patch, and
+			// the unchanged ones once more to refresh them.
+			expected := map[string]int{
+				"GET /namespaces/default/pods":          1,
+				"GET /namespaces/default/pods/pod-new":  1,
+				"PATCH /namespaces/default/pods/pod-00": 1,
+				"POST /namespaces/default/pods":         1,
+			}
+			for _, name := range names {
+				expected["GET /namespaces/default/pods/"+name] = 3
+			}
+			expected["GET /namespaces/default/pods/pod-00"] = 2
+			return expected
+		}(),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := bulkGetPageSize
+			bulkGetPageSize = tt.pageSize
+			t.Cleanup(func() { bulkGetPageSize = previous })
+
+			requests := map[string]int{}
+			c := newRoundTripTestClient(t, live, tt.listable, requests)
+			first, err := c.Build(objBody(&original), false)
+			require.NoError(t, err)
+			second, err := c.Build(objBody(&target), false)
+			require.NoError(t, err)
+
+			result, err := c.Update(first, second, false)
+			require.NoError(t, err)
+			assert.Len(t, result.Created, 1)
+			assert.Len(t, result.Updated, 50)
+			assert.Empty(t, result.Deleted)
+			assert.Equal(t, tt.expected, requests)
+			for _, info := range result.Updated {
+				assert.NotEmpty(t, info.Object, "the updated resources should hold their live object")
+			}
+		})
+	}
+}
+
+func TestFetchLiveObjectsGroups(t *testing.T) {
+	c := newTestClient(t)
+	build := func(list v1.PodList) ResourceList {
+		t.Helper()
+		resources, err := c.Build(objBody(&list), false)
+		require.NoError(t, err)
+		return resources
+	}
+	names := make([]string, bulkGetMinResources)
+	for i := range names {
+		names[i] = fmt.Sprintf("pod-%02d", i)
+	}
+
+	live := fetchLiveObjects(build(newPodList(names...)))
+	assert.Len(t, live.wanted, 1, "the pods should be listed")
+
+	live = fetchLiveObjects(build(newPodList(names[1:]...)))
+	assert.Empty(t, live.wanted, "too few pods to list them")
+
+	secrets := make(ResourceList, 0, bulkGetMinResources)
+	for _, info := range build(newPodList(names...)) {
+		info.Mapping.Resource = v1.SchemeGroupVersion.WithResource("secrets")
+		secrets = append(secrets, info)
+	}
+	live = fetchLiveObjects(secrets)
+	assert.Empty(t, live.wanted, "secrets should never be listed")
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/mergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// PatchOptions configures the patch computed by ComputePatch.
+type PatchOptions struct {
+	// ThreeWayMergeForUnstructured computes a three-way JSON merge patch for
+	// the objects which do not support strategic merge, such as custom
+	// resources, rather than a two-way patch which ignores the live object.
+	ThreeWayMergeForUnstructured bool
+}
+
+// ComputePatch computes the patch Helm sends to move a resource from its
+// original configuration, as recorded in the previous release, to its target
+// configuration, while preserving the changes made to the live object current
+// wherever possible. It returns the type of the patch along with it.
+//
+// Kubernetes built-in kinds get a three-way strategic merge patch. Custom
+// resources and CRDs, which do not support strategic merge, get a JSON merge
+// patch, which is three-way when opts.ThreeWayMergeForUnstructured is set.
+// An empty patch, "{}", means there is nothing to change.
+//
+// current is the object as served by the API server, with its defaulted
+// fields: as they appear in neither the original nor the target
+// configuration, the patch leaves them alone. A nil current is an object
+// which does not exist, and a nil original a resource Helm did not configure
+// before.
+//
+// ComputePatch makes no request to the API server, so that admission and
+// policy tools can evaluate the patch Helm sends without deriving it again.
+func ComputePatch(original, target *resource.Info, current runtime.Object, opts PatchOptions) (types.PatchType, []byte, error) {
+	if target == nil || target.Object == nil {
+		return types.StrategicMergePatchType, nil, errors.New("no target configuration to patch to")
+	}
+
+	oldData := []byte("{}")
+	if original != nil && original.Object != nil {
+		var err error
+		oldData, err = json.Marshal(original.Object)
+		if err != nil {
+			return types.StrategicMergePatchType, nil, fmt.Errorf("serializing original configuration: %w", err)
+		}
+	}
+	newData, err := json.Marshal(target.Object)
+	if err != nil {
+		return types.StrategicMergePatchType, nil, fmt.Errorf("serializing target configuration: %w", err)
+	}
+	// Even if current is nil (because it was not found), it will marshal just fine
+	currentData, err := json.Marshal(current)
+	if err != nil {
+		return types.StrategicMergePatchType, nil, fmt.Errorf("serializing live configuration: %w", err)
+	}
+
+	// Get a versioned object
+	versionedObject := AsVersioned(target)
+
+	// Unstructured objects, such as CRDs, may not have a not registered error
+	// returned from ConvertToVersion. Anything that's unstructured should
+	// use generic JSON merge patch. Strategic Merge Patch is not supported
+	// on objects like CRDs.
+	_, isUnstructured := versionedObject.(runtime.Unstructured)
+
+	// On newer K8s versions, CRDs aren't unstructured but has this dedicated type
+	_, isCRD := versionedObject.(*apiextv1beta1.CustomResourceDefinition)
+
+	if isUnstructured || isCRD {
+		if opts.ThreeWayMergeForUnstructured {
+			// from https://github.com/kubernetes/kubectl/blob/b83b2ec7d15f286720bccf7872b5c72372cb8e80/pkg/cmd/apply/patcher.go#L129
+			preconditions := []mergepatch.PreconditionFunc{
+				mergepatch.RequireKeyUnchanged("apiVersion"),
+				mergepatch.RequireKeyUnchanged("kind"),
+				mergepatch.RequireMetadataKeyUnchanged("name"),
+			}
+			patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(oldData, newData, currentData, preconditions...)
+			if err != nil && mergepatch.IsPreconditionFailed(err) {
+				err = fmt.Errorf("%w: at least one field was changed: apiVersion, kind or name", err)
+			}
+			return types.MergePatchType, patch, err
+		}
+		// fall back to generic JSON merge patch
+		patch, err := jsonpatch.CreateMergePatch(oldData, newData)
+		return types.MergePatchType, patch, err
+	}
+
+	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
+	if err != nil {
+		return types.StrategicMergePatchType, nil, fmt.Errorf("unable to create patch metadata from object: %w", err)
+	}
+
+	patch, err := strategicpatch.CreateThreeWayMergePatch(oldData, newData, currentData, patchMeta, true)
+	return types.StrategicMergePatchType, patch, err
+}
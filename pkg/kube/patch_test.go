@@ -0,0 +1,342 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// patchInfo builds the resource info of a manifest, mapped to the resource
+// of its kind.
+func patchInfo(t *testing.T, manifest string) *resource.Info {
+	t.Helper()
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &obj.Object))
+	gvk := obj.GroupVersionKind()
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	return &resource.Info{
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Object:    obj,
+		Mapping: &meta.RESTMapping{
+			Resource:         plural,
+			GroupVersionKind: gvk,
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestComputePatch(t *testing.T) {
+	const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+      - name: sidecar
+        image: envoy:1.29
+`
+	const deploymentScaled = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.27
+      - name: sidecar
+        image: envoy:1.29
+`
+	// The live deployment has fields defaulted by the API server, and an
+	// annotation added by another controller.
+	const deploymentLive = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+  resourceVersion: "42"
+spec:
+  replicas: 1
+  revisionHistoryLimit: 10
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+      - name: sidecar
+        image: envoy:1.29
+        imagePullPolicy: IfNotPresent
+      restartPolicy: Always
+status:
+  replicas: 1
+`
+	const configMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  color: blue
+  size: large
+`
+	const configMapChanged = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  color: red
+`
+	const customResource = `
+apiVersion: crd.com/v1
+kind: Data
+metadata:
+  name: test-obj
+  namespace: default
+spec:
+  color: blue
+  list:
+  - a
+  - b
+`
+	const customResourceChanged = `
+apiVersion: crd.com/v1
+kind: Data
+metadata:
+  name: test-obj
+  namespace: default
+spec:
+  color: red
+  list:
+  - a
+`
+	const customResourceLive = `
+apiVersion: crd.com/v1
+kind: Data
+metadata:
+  name: test-obj
+  namespace: default
+  labels:
+    added-by: controller
+spec:
+  color: blue
+  list:
+  - a
+  - b
+  defaulted: true
+`
+	const customResourceV2 = `
+apiVersion: crd.com/v2
+kind: Data
+metadata:
+  name: test-obj
+  namespace: default
+spec:
+  color: blue
+  list:
+  - a
+  - b
+`
+	const crd = `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: datas.crd.com
+spec:
+  group: crd.com
+  version: v1
+`
+	const crdChanged = `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: datas.crd.com
+spec:
+  group: crd.com
+  version: v2
+`
+	const deploymentBeta = `
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+`
+	const deploymentV1 = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+`
+
+	tests := []struct {
+		name     string
+		original string
+		target   string
+		current  string
+		opts     PatchOptions
+
+		expectedType  types.PatchType
+		expectedPatch string
+		expectedError string
+	}{{
+		name:          "built-in kind with a change merges by key and keeps defaulted fields",
+		original:      deployment,
+		target:        deploymentScaled,
+		current:       deploymentLive,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{"spec":{"replicas":3,"template":{"spec":{"$setElementOrder/containers":[{"name":"web"},{"name":"sidecar"}],"containers":[{"image":"nginx:1.27","name":"web"}]}}}}`,
+	}, {
+		name:          "built-in kind without change",
+		original:      deployment,
+		target:        deployment,
+		current:       deploymentLive,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{}`,
+	}, {
+		name:          "built-in kind drops the fields removed from the chart",
+		original:      configMap,
+		target:        configMapChanged,
+		current:       configMap,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{"data":{"color":"red","size":null}}`,
+	}, {
+		name:          "built-in kind without original configuration",
+		target:        configMapChanged,
+		current:       configMap,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{"data":{"color":"red"}}`,
+	}, {
+		name:          "built-in kind missing from the cluster",
+		original:      configMap,
+		target:        configMapChanged,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default"},"data":{"color":"red","size":null}}`,
+	}, {
+		name:          "custom resource gets a two-way merge patch",
+		original:      customResource,
+		target:        customResourceChanged,
+		current:       customResourceLive,
+		expectedType:  types.MergePatchType,
+		expectedPatch: `{"spec":{"color":"red","list":["a"]}}`,
+	}, {
+		name:          "custom resource gets a three-way merge patch",
+		original:      customResource,
+		target:        customResourceChanged,
+		current:       customResourceLive,
+		opts:          PatchOptions{ThreeWayMergeForUnstructured: true},
+		expectedType:  types.MergePatchType,
+		expectedPatch: `{"spec":{"color":"red","list":["a"]}}`,
+	}, {
+		name:          "custom resource three-way merge patch reverts live changes to configured fields",
+		original:      customResource,
+		target:        customResource,
+		current:       customResourceChanged,
+		opts:          PatchOptions{ThreeWayMergeForUnstructured: true},
+		expectedType:  types.MergePatchType,
+		expectedPatch: `{"spec":{"color":"blue","list":["a","b"]}}`,
+	}, {
+		name:          "custom resource definition gets a merge patch",
+		original:      crd,
+		target:        crdChanged,
+		current:       crd,
+		expectedType:  types.MergePatchType,
+		expectedPatch: `{"spec":{"version":"v2"}}`,
+	}, {
+		name:          "custom resource apiVersion change with a two-way merge patch",
+		original:      customResource,
+		target:        customResourceV2,
+		current:       customResource,
+		expectedType:  types.MergePatchType,
+		expectedPatch: `{"apiVersion":"crd.com/v2"}`,
+	}, {
+		name:          "custom resource apiVersion change with a three-way merge patch",
+		original:      customResource,
+		target:        customResourceV2,
+		current:       customResource,
+		opts:          PatchOptions{ThreeWayMergeForUnstructured: true},
+		expectedType:  types.MergePatchType,
+		expectedError: "precondition failed for: map[apiVersion:crd.com/v2]",
+	}, {
+		name:          "built-in kind apiVersion change",
+		original:      deploymentBeta,
+		target:        deploymentV1,
+		current:       deploymentV1,
+		expectedType:  types.StrategicMergePatchType,
+		expectedPatch: `{}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original *resource.Info
+			if tt.original != "" {
+				original = patchInfo(t, tt.original)
+			}
+			target := patchInfo(t, tt.target)
+			var current runtime.Object
+			if tt.current != "" {
+				current = patchInfo(t, tt.current).Object
+			}
+
+			patchType, patch, err := ComputePatch(original, target, current, tt.opts)
+			assert.Equal(t, tt.expectedType, patchType)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expectedPatch, string(patch))
+		})
+	}
+}
+
+func TestComputePatchWithoutTarget(t *testing.T) {
+	_, _, err := ComputePatch(nil, nil, nil, PatchOptions{})
+	assert.ErrorContains(t, err, "no target configuration")
+}
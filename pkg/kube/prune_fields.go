@@ -0,0 +1,259 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/openapi"
+)
+
+// PrunedFields lists the fields removed from a resource because the cluster
+// does not know them.
+type PrunedFields struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Paths are the paths of the fields removed, such as
+	// "spec.template.spec.containers[0].resizePolicy".
+	Paths []string
+}
+
+// PruneUnknownFields removes from the objects of resources the fields the
+// cluster does not know, according to its OpenAPI v3 schemas, and returns the
+// fields removed from each resource.
+func (c *Client) PruneUnknownFields(resources ResourceList) ([]PrunedFields, error) {
+	config, err := c.Factory.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return PruneUnknownFields(client.OpenAPIV3(), resources)
+}
+
+// PruneUnknownFields removes from the objects of resources the fields the
+// OpenAPI v3 schemas served by client do not know, and returns the fields
+// removed from each resource.
+//
+// The resources whose kind has no schema, such as custom resources whose
+// definition has none, and the parts of an object whose schema preserves
+// unknown fields, are left untouched.
+func PruneUnknownFields(client openapi.Client, resources ResourceList) ([]PrunedFields, error) {
+	paths, err := client.Paths()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the OpenAPI schemas of the cluster: %w", err)
+	}
+
+	docs := make(map[string]*openAPIDocument)
+	var report []PrunedFields
+	for _, info := range resources {
+		u, ok := info.Object.(runtime.Unstructured)
+		if !ok {
+			continue
+		}
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		path := openAPIPath(gvk.GroupVersion())
+		doc, ok := docs[path]
+		if !ok {
+			gv, served := paths[path]
+			if served {
+				if doc, err = loadOpenAPIDocument(gv); err != nil {
+					return nil, fmt.Errorf("unable to load the OpenAPI schema of %s: %w", gvk.GroupVersion(), err)
+				}
+			}
+			docs[path] = doc
+		}
+		if doc == nil {
+			continue
+		}
+		s := doc.schemaFor(gvk)
+		if s == nil {
+			continue
+		}
+
+		content := u.UnstructuredContent()
+		var pruned []string
+		doc.prune(content, s, "", &pruned)
+		if len(pruned) == 0 {
+			continue
+		}
+		u.SetUnstructuredContent(content)
+		report = append(report, PrunedFields{
+			Kind:      gvk.Kind,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+			Paths:     pruned,
+		})
+	}
+	return report, nil
+}
+
+// openAPIPath returns the path under which the OpenAPI v3 schema of gv is
+// served.
+func openAPIPath(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return "api/" + gv.Version
+	}
+	return "apis/" + gv.Group + "/" + gv.Version
+}
+
+// openAPIDocument is the part of an OpenAPI v3 document needed to prune the
+// unknown fields of objects.
+type openAPIDocument struct {
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPISchema struct {
+	Ref                   string                    `json:"$ref,omitempty"`
+	AllOf                 []*openAPISchema          `json:"allOf,omitempty"`
+	Properties            map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties  json.RawMessage           `json:"additionalProperties,omitempty"`
+	Items                 *openAPISchema            `json:"items,omitempty"`
+	PreserveUnknownFields bool                      `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	GroupVersionKinds     []schema.GroupVersionKind `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+func loadOpenAPIDocument(gv openapi.GroupVersion) (*openAPIDocument, error) {
+	data, err := gv.Schema(runtime.ContentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
+	doc := &openAPIDocument{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// schemaFor returns the schema of the objects of gvk, or nil if the document
+// has none.
+func (d *openAPIDocument) schemaFor(gvk schema.GroupVersionKind) *openAPISchema {
+	for _, s := range d.Components.Schemas {
+		if slices.Contains(s.GroupVersionKinds, gvk) {
+			return s
+		}
+	}
+	return nil
+}
+
+// resolve follows the references of s, and merges the schemas it is made of
+// with allOf.
+func (d *openAPIDocument) resolve(s *openAPISchema) *openAPISchema {
+	for depth := 0; s != nil && s.Ref != "" && depth < 32; depth++ {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+	merged := *s
+	merged.AllOf = nil
+	for _, part := range s.AllOf {
+		part = d.resolve(part)
+		if part == nil {
+			continue
+		}
+		if len(part.Properties) > 0 {
+			if merged.Properties == nil {
+				merged.Properties = make(map[string]*openAPISchema)
+			}
+			for name, property := range part.Properties {
+				merged.Properties[name] = property
+			}
+		}
+		if merged.AdditionalProperties == nil {
+			merged.AdditionalProperties = part.AdditionalProperties
+		}
+		if merged.Items == nil {
+			merged.Items = part.Items
+		}
+		merged.PreserveUnknownFields = merged.PreserveUnknownFields || part.PreserveUnknownFields
+	}
+	return &merged
+}
+
+// additionalProperties returns the schema of the properties of s not listed in
+// its properties, and whether there can be any.
+func additionalProperties(s *openAPISchema) (*openAPISchema, bool) {
+	if len(s.AdditionalProperties) == 0 {
+		return nil, false
+	}
+	var allowed bool
+	if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+		return nil, allowed
+	}
+	additional := &openAPISchema{}
+	if err := json.Unmarshal(s.AdditionalProperties, additional); err != nil {
+		// A schema Helm does not understand: keep the fields.
+		return nil, true
+	}
+	return additional, true
+}
+
+// prune removes from value the fields s does not know, and appends their
+// paths to pruned.
+func (d *openAPIDocument) prune(value interface{}, s *openAPISchema, path string, pruned *[]string) {
+	s = d.resolve(s)
+	if s == nil || s.PreserveUnknownFields {
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		additional, allowed := additionalProperties(s)
+		if len(s.Properties) == 0 && !allowed {
+			// The schema does not describe the fields of the object.
+			return
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if property, ok := s.Properties[name]; ok {
+				d.prune(v[name], property, fieldPath, pruned)
+				continue
+			}
+			if !allowed {
+				delete(v, name)
+				*pruned = append(*pruned, fieldPath)
+				continue
+			}
+			if additional != nil {
+				d.prune(v[name], additional, fieldPath, pruned)
+			}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range v {
+			d.prune(item, s.Items, fmt.Sprintf("%s[%d]", path, i), pruned)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/openapi"
+	"k8s.io/client-go/openapi/openapitest"
+)
+
+// newOpenAPIClient serves the OpenAPI v3 schemas of an older cluster, from
+// testdata/openapi.
+func newOpenAPIClient(t *testing.T) *openapitest.FakeClient {
+	t.Helper()
+	client := openapitest.NewFakeClient()
+	for path, file := range map[string]string{
+		"apis/apps/v1":    "testdata/openapi/apis__apps__v1.json",
+		"apis/crd.com/v1": "testdata/openapi/apis__crd.com__v1.json",
+	} {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		client.PathsMap[path] = openapitest.FakeGroupVersion{GVSpec: data}
+	}
+	return client
+}
+
+// A Deployment written for a newer cluster, whose containers have a
+// resizePolicy and whose pods have hostUsers.
+const newerDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      hostUsers: false
+      nodeSelector:
+        disk: ssd
+      containers:
+      - name: web
+        image: nginx:1.27
+        resources:
+          limits:
+            cpu: 500m
+        resizePolicy:
+        - resourceName: cpu
+          restartPolicy: NotRequired
+      - name: sidecar
+        image: envoy:1.29
+        resizePolicy:
+        - resourceName: memory
+          restartPolicy: RestartContainer
+`
+
+func TestPruneUnknownFields(t *testing.T) {
+	deployment := patchInfo(t, newerDeployment)
+	resources := ResourceList{deployment}
+
+	report, err := PruneUnknownFields(newOpenAPIClient(t), resources)
+	require.NoError(t, err)
+
+	assert.Equal(t, []PrunedFields{{
+		Kind:      "Deployment",
+		Namespace: "default",
+		Name:      "web",
+		Paths: []string{
+			"spec.template.spec.containers[0].resizePolicy",
+			"spec.template.spec.containers[1].resizePolicy",
+			"spec.template.spec.hostUsers",
+		},
+	}}, report)
+
+	obj := deployment.Object.(*unstructured.Unstructured)
+	podSpec, _, err := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	require.NoError(t, err)
+	assert.NotContains(t, podSpec, "hostUsers")
+	assert.Equal(t, map[string]interface{}{"disk": "ssd"}, podSpec["nodeSelector"])
+	containers := podSpec["containers"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"name":      "web",
+		"image":     "nginx:1.27",
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}},
+	}, containers[0])
+	labels, _, err := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "web"}, labels)
+}
+
+func TestPruneUnknownFieldsCustomResources(t *testing.T) {
+	data := patchInfo(t, `
+apiVersion: crd.com/v1
+kind: Data
+metadata:
+  name: data
+  namespace: default
+spec:
+  color: blue
+  size: large
+  extra:
+    anything:
+      goes: here
+`)
+	blob := patchInfo(t, `
+apiVersion: crd.com/v1
+kind: Blob
+metadata:
+  name: blob
+  namespace: default
+spec:
+  anything: goes
+`)
+	// No schema is served for this kind, nor for its group.
+	widget := patchInfo(t, `
+apiVersion: widgets.io/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: default
+spec:
+  anything: goes
+`)
+
+	report, err := PruneUnknownFields(newOpenAPIClient(t), ResourceList{data, blob, widget})
+	require.NoError(t, err)
+	assert.Equal(t, []PrunedFields{{Kind: "Data", Namespace: "default", Name: "data", Paths: []string{"spec.size"}}}, report)
+
+	spec, _, err := unstructured.NestedMap(data.Object.(*unstructured.Unstructured).Object, "spec")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"color": "blue",
+		"extra": map[string]interface{}{"anything": map[string]interface{}{"goes": "here"}},
+	}, spec)
+	assert.Equal(t, "goes", blob.Object.(*unstructured.Unstructured).Object["spec"].(map[string]interface{})["anything"])
+	assert.Equal(t, "goes", widget.Object.(*unstructured.Unstructured).Object["spec"].(map[string]interface{})["anything"])
+}
+
+func TestPruneUnknownFieldsNothingToPrune(t *testing.T) {
+	deployment := patchInfo(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+`)
+	report, err := PruneUnknownFields(newOpenAPIClient(t), ResourceList{deployment})
+	require.NoError(t, err)
+	assert.Empty(t, report)
+}
+
+func TestPruneUnknownFieldsErrors(t *testing.T) {
+	client := openapitest.NewFakeClient()
+	client.ForcedErr = errors.New("openapi unavailable")
+	_, err := PruneUnknownFields(client, ResourceList{patchInfo(t, newerDeployment)})
+	assert.ErrorContains(t, err, "unable to list the OpenAPI schemas of the cluster: openapi unavailable")
+
+	client = &openapitest.FakeClient{PathsMap: map[string]openapi.GroupVersion{
+		"apis/apps/v1": openapitest.FakeGroupVersion{ForcedErr: errors.New("schema unavailable")},
+	}}
+	_, err = PruneUnknownFields(client, ResourceList{patchInfo(t, newerDeployment)})
+	assert.ErrorContains(t, err, "unable to load the OpenAPI schema of apps/v1: schema unavailable")
+}
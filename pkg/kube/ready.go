@@ -33,9 +33,14 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 
+	helmStatusReaders "helm.sh/helm/v4/internal/statusreaders"
 	deploymentutil "helm.sh/helm/v4/internal/third_party/k8s.io/kubernetes/deployment/util"
 )
 
+// WaitForLoadBalancerAnno is the annotation that, when set to "false" on a
+// LoadBalancer Service, skips waiting for its load balancer to be provisioned.
+const WaitForLoadBalancerAnno = helmStatusReaders.WaitForLoadBalancerAnnotation
+
 // ReadyCheckerOption is a function that configures a ReadyChecker.
 type ReadyCheckerOption func(*ReadyChecker)
 
@@ -264,8 +269,13 @@ func (c *ReadyChecker) serviceReady(s *corev1.Service) bool {
 			return true
 		}
 
-		if s.Status.LoadBalancer.Ingress == nil {
-			slog.Debug("Service does not have load balancer ingress IP address", "namespace", s.GetNamespace(), "name", s.GetName())
+		if s.GetAnnotations()[WaitForLoadBalancerAnno] == "false" {
+			slog.Debug("Service load balancer is not waited for due to annotation", "namespace", s.GetNamespace(), "name", s.GetName(), "annotation", WaitForLoadBalancerAnno)
+			return true
+		}
+
+		if !helmStatusReaders.LoadBalancerIngressReady(s) {
+			slog.Debug("Service does not have load balancer ingress for every IP family", "namespace", s.GetNamespace(), "name", s.GetName(), "ipFamilies", s.Spec.IPFamilies)
 			return false
 		}
 	}
@@ -1139,6 +1139,8 @@ func Test_ReadyChecker_volumeReady(t *testing.T) {
 }
 
 func Test_ReadyChecker_serviceReady(t *testing.T) {
+	requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
 	type args struct {
 		service *corev1.Service
 	}
@@ -1172,6 +1174,57 @@ func Test_ReadyChecker_serviceReady(t *testing.T) {
 			args: args{service: newService("foo", corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "bar"})},
 			want: true,
 		},
+		{
+			name: "single-stack load balancer has an ingress ip",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(nil, corev1.IPv4Protocol)),
+				corev1.LoadBalancerIngress{IP: "203.0.113.10"})},
+			want: true,
+		},
+		{
+			name: "load balancer ingress has neither ip nor hostname",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(nil, corev1.IPv4Protocol)),
+				corev1.LoadBalancerIngress{})},
+			want: false,
+		},
+		{
+			name: "load balancer ingress is hostname only",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(nil, corev1.IPv4Protocol)),
+				corev1.LoadBalancerIngress{Hostname: "lb.example.com"})},
+			want: true,
+		},
+		{
+			name: "dual-stack required load balancer is missing an ipv6 ingress",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(&requireDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol)),
+				corev1.LoadBalancerIngress{IP: "203.0.113.10"})},
+			want: false,
+		},
+		{
+			name: "dual-stack required load balancer has an ingress per ip family",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(&requireDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol)),
+				corev1.LoadBalancerIngress{IP: "203.0.113.10"}, corev1.LoadBalancerIngress{IP: "2001:db8::10"})},
+			want: true,
+		},
+		{
+			name: "dual-stack required load balancer has a hostname ingress",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(&requireDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol)),
+				corev1.LoadBalancerIngress{Hostname: "lb.example.com"})},
+			want: true,
+		},
+		{
+			name: "dual-stack preferred load balancer has a single ingress ip",
+			args: args{service: withLoadBalancerIngress(newService("foo", loadBalancerSpec(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol)),
+				corev1.LoadBalancerIngress{IP: "2001:db8::10"})},
+			want: true,
+		},
+		{
+			name: "load balancer wait is disabled by annotation",
+			args: args{service: func() *corev1.Service {
+				s := newService("foo", loadBalancerSpec(nil, corev1.IPv4Protocol))
+				s.Annotations = map[string]string{WaitForLoadBalancerAnno: "false"}
+				return s
+			}()},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1573,6 +1626,20 @@ func newService(name string, serviceSpec corev1.ServiceSpec) *corev1.Service {
 	}
 }
 
+func loadBalancerSpec(policy *corev1.IPFamilyPolicy, families ...corev1.IPFamily) corev1.ServiceSpec {
+	return corev1.ServiceSpec{
+		Type:           corev1.ServiceTypeLoadBalancer,
+		ClusterIP:      "10.0.0.1",
+		IPFamilyPolicy: policy,
+		IPFamilies:     families,
+	}
+}
+
+func withLoadBalancerIngress(s *corev1.Service, ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+	s.Status.LoadBalancer.Ingress = ingress
+	return s
+}
+
 func newcrdBetaReady(name string, crdBetaStatus apiextv1beta1.CustomResourceDefinitionStatus) apiextv1beta1.CustomResourceDefinition {
 	return apiextv1beta1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
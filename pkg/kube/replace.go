@@ -0,0 +1,159 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// UpgradeStrategyAnno is the annotation name for the way a resource is
+// updated.
+const UpgradeStrategyAnno = "helm.sh/upgrade-strategy"
+
+// ReplaceStrategy is the upgrade strategy deleting and recreating a resource
+// which changed, rather than patching it. It allows changes to its immutable
+// fields, such as the template of a Job.
+const ReplaceStrategy = "replace"
+
+const (
+	releaseNameAnno      = "meta.helm.sh/release-name"
+	releaseNamespaceAnno = "meta.helm.sh/release-namespace"
+)
+
+var (
+	// replaceDeleteTimeout is how long the deletion of a replaced resource is
+	// waited for before recreating it, unless the context of the update has a
+	// deadline.
+	replaceDeleteTimeout = 5 * time.Minute
+	// replacePollInterval is how often a replaced resource is checked for
+	// deletion.
+	replacePollInterval = time.Second
+)
+
+// isReplaced reports whether obj has the replace upgrade strategy.
+func isReplaced(obj runtime.Object) bool {
+	annotations, err := metadataAccessor.Annotations(obj)
+	if err != nil {
+		return false
+	}
+	return strings.ToLower(strings.TrimSpace(annotations[UpgradeStrategyAnno])) == ReplaceStrategy
+}
+
+// checkReplaceOwnership checks that the live object belongs to the release
+// target belongs to, so that replacing it does not delete the resource of
+// another release.
+func checkReplaceOwnership(target, live runtime.Object) error {
+	want, err := metadataAccessor.Annotations(target)
+	if err != nil {
+		return err
+	}
+	if want[releaseNameAnno] == "" {
+		return nil
+	}
+	got, err := metadataAccessor.Annotations(live)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{releaseNameAnno, releaseNamespaceAnno} {
+		if got[key] != want[key] {
+			return fmt.Errorf("annotation validation error: key %q must equal %q: current value is %q", key, want[key], got[key])
+		}
+	}
+	return nil
+}
+
+// isRetryableReplaceError reports whether err, got checking for the deletion
+// of a replaced resource, is transient. Status errors are retried on the same
+// status codes as the legacy waiter retries, and other errors, such as
+// connection errors, are always retried.
+func isRetryableReplaceError(err error) bool {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		code := status.Status().Code
+		return code == 0 || code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+	}
+	return true
+}
+
+// replaceResource deletes the live object of target and recreates it from
+// target, if target changed from its original configuration currentObj. It
+// reports whether the resource was replaced. The deletion is waited for until
+// ctx is done, or for replaceDeleteTimeout if ctx has no deadline.
+func (c *Client) replaceResource(ctx context.Context, target *resource.Info, currentObj, live runtime.Object, threeWayMergeForUnstructured bool, apply *ServerSideApplyOptions) (bool, error) {
+	kind := target.Mapping.GroupVersionKind.Kind
+	patch, _, err := createPatchAgainst(target, currentObj, live, threeWayMergeForUnstructured)
+	if err != nil {
+		return false, fmt.Errorf("failed to create patch: %w", err)
+	}
+	if patch == nil || string(patch) == "{}" {
+		slog.Debug("no changes detected", "kind", kind, "name", target.Name)
+		target.Refresh(live, true)
+		return false, nil
+	}
+
+	if err := checkReplaceOwnership(target.Object, live); err != nil {
+		return false, fmt.Errorf("cannot replace %s %q: %w", kind, target.Name, err)
+	}
+
+	slog.Debug("replacing resource", "kind", kind, "name", target.Name, "namespace", target.Namespace)
+	if err := deleteResource(target, metav1.DeletePropagationBackground); err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete %s %q to replace it: %w", kind, target.Name, err)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, replaceDeleteTimeout)
+		defer cancel()
+	}
+	helper := resource.NewHelper(target.Client, target.Mapping)
+	err = wait.PollUntilContextCancel(ctx, replacePollInterval, true, func(_ context.Context) (bool, error) {
+		_, err := helper.Get(target.Namespace, target.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			return true, nil
+		case err != nil && !isRetryableReplaceError(err):
+			return false, err
+		case err != nil:
+			slog.Debug("retrying to check for the deletion of the replaced resource", "kind", kind, "name", target.Name, slog.Any("error", err))
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to wait for the deletion of %s %q to replace it: %w", kind, target.Name, err)
+	}
+
+	if apply != nil {
+		err = applyResource(target, c.fieldManager(), *apply)
+	} else {
+		err = createResource(target, c.fieldManager())
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to recreate %s %q: %w", kind, target.Name, err)
+	}
+	return true, nil
+}
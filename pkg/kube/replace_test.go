@@ -0,0 +1,288 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func newMigrateJob(image string, annotations map[string]string) *batchv1.Job {
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "migrate",
+			Namespace:   v1.NamespaceDefault,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers:    []v1.Container{{Name: "migrate", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+// immutableJobClient serves a Job whose template cannot be patched, as the
+// template of a Job is immutable, and records the requests it gets.
+func immutableJobClient(t *testing.T, live *batchv1.Job) (*Client, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var actions []string
+	deleted := false
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			p, m := req.URL.Path, req.Method
+			actions = append(actions, p+":"+m)
+			switch {
+			case p == "/namespaces/default/jobs/migrate" && m == http.MethodGet:
+				if deleted {
+					return newResponse(http.StatusNotFound, notFoundBody())
+				}
+				return newResponse(http.StatusOK, live)
+			case p == "/namespaces/default/jobs/migrate" && m == http.MethodPatch:
+				return newResponse(http.StatusUnprocessableEntity, &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Code:    http.StatusUnprocessableEntity,
+					Reason:  metav1.StatusReasonInvalid,
+					Message: `Job.batch "migrate" is invalid: spec.template: Invalid value: field is immutable`,
+				})
+			case p == "/namespaces/default/jobs/migrate" && m == http.MethodDelete:
+				deleted = true
+				return newResponse(http.StatusOK, live)
+			case p == "/namespaces/default/jobs" && m == http.MethodPost:
+				return newResponse(http.StatusCreated, live)
+			default:
+				t.Fatalf("unexpected request: %s %s", m, p)
+				return nil, nil
+			}
+		}),
+	}
+	return c, &actions
+}
+
+func TestUpdateReplaceStrategy(t *testing.T) {
+	defer func(interval time.Duration) { replacePollInterval = interval }(replacePollInterval)
+	replacePollInterval = time.Millisecond
+
+	owner := map[string]string{
+		releaseNameAnno:      "db",
+		releaseNamespaceAnno: v1.NamespaceDefault,
+	}
+	withReplace := map[string]string{UpgradeStrategyAnno: ReplaceStrategy}
+	for k, v := range owner {
+		withReplace[k] = v
+	}
+
+	tests := []struct {
+		name     string
+		live     map[string]string
+		target   map[string]string
+		replace  bool
+		image    string
+		err      string
+		actions  []string
+		replaced int
+	}{
+		{
+			name:   "immutable change fails without the annotation",
+			live:   owner,
+			target: owner,
+			image:  "migrate:v2",
+			err:    "field is immutable",
+			actions: []string{
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs/migrate:PATCH",
+			},
+		},
+		{
+			name:   "immutable change succeeds with the annotation",
+			live:   owner,
+			target: withReplace,
+			image:  "migrate:v2",
+			actions: []string{
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs/migrate:DELETE",
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs:POST",
+			},
+			replaced: 1,
+		},
+		{
+			name:    "immutable change succeeds for a resource to replace",
+			live:    owner,
+			target:  owner,
+			replace: true,
+			image:   "migrate:v2",
+			actions: []string{
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs/migrate:DELETE",
+				"/namespaces/default/jobs/migrate:GET",
+				"/namespaces/default/jobs:POST",
+			},
+			replaced: 1,
+		},
+		{
+			name:   "unchanged resource is not replaced",
+			live:   withReplace,
+			target: withReplace,
+			image:  "migrate:v1",
+			actions: []string{
+				"/namespaces/default/jobs/migrate:GET",
+			},
+		},
+		{
+			name:   "resource of another release is not replaced",
+			live:   map[string]string{releaseNameAnno: "other", releaseNamespaceAnno: v1.NamespaceDefault},
+			target: withReplace,
+			image:  "migrate:v2",
+			err:    `key "meta.helm.sh/release-name" must equal "db": current value is "other"`,
+			actions: []string{
+				"/namespaces/default/jobs/migrate:GET",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, actions := immutableJobClient(t, newMigrateJob("migrate:v1", tc.live))
+			original, err := c.Build(objBody(newMigrateJob("migrate:v1", owner)), false)
+			require.NoError(t, err)
+			target, err := c.Build(objBody(newMigrateJob(tc.image, tc.target)), false)
+			require.NoError(t, err)
+
+			var replace ResourceList
+			if tc.replace {
+				replace = target
+			}
+			res, err := c.UpdateWithContext(t.Context(), original, target, replace, false)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.actions, *actions)
+			assert.Len(t, res.Replaced, tc.replaced)
+		})
+	}
+}
+
+func TestUpdateReplaceStrategyWaitForDeletion(t *testing.T) {
+	defer func(interval time.Duration) { replacePollInterval = interval }(replacePollInterval)
+	replacePollInterval = time.Millisecond
+
+	withReplace := map[string]string{UpgradeStrategyAnno: ReplaceStrategy}
+	tests := []struct {
+		name    string
+		gets    []int
+		timeout time.Duration
+		err     string
+	}{
+		{
+			name: "transient errors are retried",
+			gets: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK, http.StatusNotFound},
+		},
+		{
+			name: "non-retryable error stops the wait",
+			gets: []int{http.StatusForbidden},
+			err:  "failed to wait for the deletion",
+		},
+		{
+			name:    "wait stops when the context is done",
+			gets:    []int{http.StatusOK},
+			timeout: 20 * time.Millisecond,
+			err:     "context deadline exceeded",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			live := newMigrateJob("migrate:v1", nil)
+			var mu sync.Mutex
+			deleted := false
+			gets := tc.gets
+
+			c := newTestClient(t)
+			c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+				NegotiatedSerializer: unstructuredSerializer,
+				Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					p, m := req.URL.Path, req.Method
+					switch {
+					case p == "/namespaces/default/jobs/migrate" && m == http.MethodGet && deleted:
+						code := gets[0]
+						if len(gets) > 1 {
+							gets = gets[1:]
+						}
+						if code == http.StatusOK {
+							return newResponse(code, live)
+						}
+						return newResponse(code, &metav1.Status{Status: metav1.StatusFailure, Code: int32(code)})
+					case p == "/namespaces/default/jobs/migrate" && m == http.MethodGet:
+						return newResponse(http.StatusOK, live)
+					case p == "/namespaces/default/jobs/migrate" && m == http.MethodDelete:
+						deleted = true
+						return newResponse(http.StatusOK, live)
+					case p == "/namespaces/default/jobs" && m == http.MethodPost:
+						return newResponse(http.StatusCreated, live)
+					default:
+						t.Fatalf("unexpected request: %s %s", m, p)
+						return nil, nil
+					}
+				}),
+			}
+			original, err := c.Build(objBody(newMigrateJob("migrate:v1", nil)), false)
+			require.NoError(t, err)
+			target, err := c.Build(objBody(newMigrateJob("migrate:v2", withReplace)), false)
+			require.NoError(t, err)
+
+			ctx := t.Context()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			_, err = c.UpdateWithContext(ctx, original, target, nil, false)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// ResourceState is the live state of a resource.
+type ResourceState string
+
+// States reported by ReadyChecker.Status.
+const (
+	// ResourceReady is the state of a resource passing the readiness checks.
+	ResourceReady ResourceState = "Ready"
+	// ResourceNotReady is the state of a resource failing the readiness checks.
+	ResourceNotReady ResourceState = "NotReady"
+	// ResourceMissing is the state of a resource not found in the cluster.
+	ResourceMissing ResourceState = "Missing"
+)
+
+// ResourceStatus is the live state of a resource of a release.
+type ResourceStatus struct {
+	Kind      string
+	Namespace string
+	Name      string
+	State     ResourceState
+	// ReadyReplicas and DesiredReplicas are only set for the kinds managing
+	// replicas, Deployments and StatefulSets.
+	ReadyReplicas   *int32
+	DesiredReplicas *int32
+}
+
+// InterfaceResourceStatus is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceResourceStatus and integrate its method(s) into the Interface.
+type InterfaceResourceStatus interface {
+	// ResourceStatuses queries the cluster for the live state of resources.
+	// Resources which are not found are reported as ResourceMissing.
+	ResourceStatuses(resources ResourceList) ([]ResourceStatus, error)
+}
+
+var _ InterfaceResourceStatus = (*Client)(nil)
+
+// ResourceStatuses queries the cluster for the live state of resources, using
+// the readiness checks of the wait strategies.
+func (c *Client) ResourceStatuses(resources ResourceList) ([]ResourceStatus, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	checker := NewReadyChecker(cs, CheckJobs(true))
+	ctx := context.Background()
+
+	statuses := make([]ResourceStatus, 0, len(resources))
+	for _, info := range resources {
+		status, err := checker.Status(ctx, info)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Status fetches the latest state of v and reports whether it is ready, as
+// IsReady does. A resource which is not found is reported as missing rather
+// than as an error. The replica counts of Deployments and StatefulSets are
+// reported along with their state.
+func (c *ReadyChecker) Status(ctx context.Context, v *resource.Info) (ResourceStatus, error) {
+	status := ResourceStatus{
+		Kind:      resourceKind(v),
+		Namespace: v.Namespace,
+		Name:      v.Name,
+		State:     ResourceNotReady,
+	}
+
+	// IsReady only fetches the kinds it checks, so the existence of the
+	// others is checked first.
+	if v.Client != nil {
+		if err := v.Get(); err != nil {
+			if apierrors.IsNotFound(err) {
+				status.State = ResourceMissing
+				return status, nil
+			}
+			return status, fmt.Errorf("unable to get %s %q: %w", status.Kind, v.Name, err)
+		}
+	}
+
+	ready, err := c.IsReady(ctx, v)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			status.State = ResourceMissing
+			return status, nil
+		}
+		return status, fmt.Errorf("unable to check the readiness of %s %q: %w", status.Kind, v.Name, err)
+	}
+	if ready {
+		status.State = ResourceReady
+	}
+
+	switch AsVersioned(v).(type) {
+	case *appsv1.Deployment:
+		dep, err := c.client.AppsV1().Deployments(v.Namespace).Get(ctx, v.Name, metav1.GetOptions{})
+		if err != nil {
+			return status, fmt.Errorf("unable to get %s %q: %w", status.Kind, v.Name, err)
+		}
+		status.ReadyReplicas, status.DesiredReplicas = replicaCounts(dep.Status.ReadyReplicas, dep.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		sts, err := c.client.AppsV1().StatefulSets(v.Namespace).Get(ctx, v.Name, metav1.GetOptions{})
+		if err != nil {
+			return status, fmt.Errorf("unable to get %s %q: %w", status.Kind, v.Name, err)
+		}
+		status.ReadyReplicas, status.DesiredReplicas = replicaCounts(sts.Status.ReadyReplicas, sts.Spec.Replicas)
+	}
+	return status, nil
+}
+
+// replicaCounts returns the ready and desired replicas of a workload, the
+// desired replicas defaulting to 1 when unset.
+func replicaCounts(ready int32, desired *int32) (*int32, *int32) {
+	want := int32(1)
+	if desired != nil {
+		want = *desired
+	}
+	return &ready, &want
+}
+
+// resourceKind returns the kind of v, taken from its REST mapping when it has
+// one.
+func resourceKind(v *resource.Info) string {
+	if v.Mapping != nil {
+		return v.Mapping.GroupVersionKind.Kind
+	}
+	if v.Object != nil {
+		return v.Object.GetObjectKind().GroupVersionKind().Kind
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func statusInfo(kind string, obj runtime.Object, name string) *resource.Info {
+	obj.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Kind: kind})
+	return &resource.Info{Object: obj, Name: name, Namespace: defaultNamespace}
+}
+
+func TestReadyChecker_Status(t *testing.T) {
+	dep := newDeployment("web", 2, 1, 0, true)
+	dep.Status.ReadyReplicas = 2
+	rs := newReplicaSet("web", 2, 2, true)
+	sts := newStatefulSet("db", 3, 0, 1, 3, true)
+	client := fake.NewClientset(dep, rs, sts)
+	checker := NewReadyChecker(client)
+
+	tests := []struct {
+		name   string
+		info   *resource.Info
+		expect ResourceStatus
+	}{
+		{
+			name: "ready deployment",
+			info: statusInfo("Deployment", &appsv1.Deployment{}, "web"),
+			expect: ResourceStatus{
+				Kind: "Deployment", Namespace: defaultNamespace, Name: "web", State: ResourceReady,
+				ReadyReplicas: intToInt32(2), DesiredReplicas: intToInt32(2),
+			},
+		},
+		{
+			name: "unready statefulset",
+			info: statusInfo("StatefulSet", &appsv1.StatefulSet{}, "db"),
+			expect: ResourceStatus{
+				Kind: "StatefulSet", Namespace: defaultNamespace, Name: "db", State: ResourceNotReady,
+				ReadyReplicas: intToInt32(1), DesiredReplicas: intToInt32(3),
+			},
+		},
+		{
+			name: "missing service",
+			info: statusInfo("Service", &corev1.Service{}, "frontend"),
+			expect: ResourceStatus{
+				Kind: "Service", Namespace: defaultNamespace, Name: "frontend", State: ResourceMissing,
+			},
+		},
+		{
+			name: "missing deployment",
+			info: statusInfo("Deployment", &appsv1.Deployment{}, "api"),
+			expect: ResourceStatus{
+				Kind: "Deployment", Namespace: defaultNamespace, Name: "api", State: ResourceMissing,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := checker.Status(t.Context(), tt.info)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, status)
+		})
+	}
+}
+
+func TestReadyChecker_StatusError(t *testing.T) {
+	client := fake.NewClientset()
+	client.PrependReactor("get", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "worker", nil)
+	})
+	checker := NewReadyChecker(client)
+
+	_, err := checker.Status(t.Context(), statusInfo("Pod", &corev1.Pod{}, "worker"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unable to check the readiness of Pod "worker"`)
+	assert.True(t, apierrors.IsForbidden(err), "only resources which are not found are reported as missing")
+}
+
+func TestReplicaCounts(t *testing.T) {
+	ready, desired := replicaCounts(0, nil)
+	assert.Equal(t, int32(0), *ready)
+	assert.Equal(t, int32(1), *desired, "the desired replicas default to 1")
+
+	ready, desired = replicaCounts(2, intToInt32(4))
+	assert.Equal(t, int32(2), *ready)
+	assert.Equal(t, int32(4), *desired)
+}
+
+func TestResourceKind(t *testing.T) {
+	info := statusInfo("ConfigMap", &corev1.ConfigMap{}, "settings")
+	assert.Equal(t, "ConfigMap", resourceKind(info))
+
+	info.Mapping = &meta.RESTMapping{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}}
+	assert.Equal(t, "Secret", resourceKind(info), "the REST mapping takes precedence")
+}
@@ -23,6 +23,9 @@ type Result struct {
 	Created ResourceList
 	Updated ResourceList
 	Deleted ResourceList
+	// Replaced are the updated resources which were deleted and recreated,
+	// as they have the ReplaceStrategy upgrade strategy.
+	Replaced ResourceList
 }
 
 // If needed, we can add methods to the Result type for things like diffing
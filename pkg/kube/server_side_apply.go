@@ -0,0 +1,125 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// serverSideApplyVersion is the first version of Kubernetes in which
+// server-side apply is generally available.
+var serverSideApplyVersion = version.MajorMinor(1, 22)
+
+// ServerSideApplyOptions controls how resources are applied server-side.
+type ServerSideApplyOptions struct {
+	// ForceConflicts takes over the fields of the resources managed by other
+	// field managers, instead of failing with a conflict.
+	ForceConflicts bool
+	// DryRun has the API server process the apply without persisting it.
+	DryRun bool
+}
+
+// IsServerSideApplySupported reports whether the API server supports
+// server-side apply.
+func (c *Client) IsServerSideApplySupported() (bool, error) {
+	client, err := c.getKubeClient()
+	if err != nil {
+		return false, err
+	}
+	info, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("unable to get the version of the Kubernetes API server: %w", err)
+	}
+	v, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the version of the Kubernetes API server: %w", err)
+	}
+	return v.AtLeast(serverSideApplyVersion), nil
+}
+
+// CreateServerSideApply creates the resources with server-side apply.
+func (c *Client) CreateServerSideApply(resources ResourceList, opts ServerSideApplyOptions) (*Result, error) {
+	slog.Debug("applying resource(s)", "resources", len(resources), "dryRun", opts.DryRun)
+	if err := perform(resources, func(info *resource.Info) error {
+		return applyResource(info, c.fieldManager(), opts)
+	}); err != nil {
+		return nil, err
+	}
+	return &Result{Created: resources}, nil
+}
+
+// UpdateServerSideApply is Update, applying the resources of target with
+// server-side apply. A dry run does not delete the resources of original
+// missing from target.
+func (c *Client) UpdateServerSideApply(original, target ResourceList, opts ServerSideApplyOptions) (*Result, error) {
+	return c.update(context.Background(), original, target, nil, false, false, &opts)
+}
+
+// UpdateServerSideApplyWithContext is UpdateServerSideApply, also replacing
+// the resources of target in replace, and giving up on waiting for the
+// deletion of the resources it replaces when ctx is done.
+func (c *Client) UpdateServerSideApplyWithContext(ctx context.Context, original, target, replace ResourceList, opts ServerSideApplyOptions) (*Result, error) {
+	return c.update(ctx, original, target, replace, false, false, &opts)
+}
+
+// applyResource applies the configuration of info with server-side apply,
+// creating the resource if it does not exist.
+func applyResource(info *resource.Info, fieldManager string, opts ServerSideApplyOptions) error {
+	data, err := applyConfiguration(info)
+	if err != nil {
+		return fmt.Errorf("unable to serialize %q: %w", info.Name, err)
+	}
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(fieldManager).
+		DryRun(opts.DryRun)
+	obj, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &opts.ForceConflicts})
+	if err != nil {
+		return fmt.Errorf("cannot apply %q with kind %s: %w", info.Name, info.Mapping.GroupVersionKind.Kind, err)
+	}
+	if opts.DryRun {
+		return nil
+	}
+	return info.Refresh(obj, true)
+}
+
+// applyConfiguration returns the configuration of info to apply server-side,
+// without the fields the API server refuses in an apply.
+func applyConfiguration(info *resource.Info) ([]byte, error) {
+	var obj *unstructured.Unstructured
+	if u, ok := info.Object.(*unstructured.Unstructured); ok {
+		obj = u.DeepCopy()
+	} else {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		obj = &unstructured.Unstructured{Object: content}
+		obj.SetGroupVersionKind(info.Mapping.GroupVersionKind)
+	}
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	return runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+// applyRequest is a request of a server-side apply test.
+type applyRequest struct {
+	action      string
+	contentType string
+	query       map[string]string
+	body        map[string]interface{}
+}
+
+// newApplyTestClient returns a client serving pods, of which existing are
+// found, and recording the requests it receives.
+func newApplyTestClient(t *testing.T, existing map[string]v1.Pod, requests *[]applyRequest) *Client {
+	t.Helper()
+	c := newTestClient(t)
+	c.FieldManager = "helm"
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			r := applyRequest{
+				action:      req.URL.Path + ":" + req.Method,
+				contentType: req.Header.Get("Content-Type"),
+				query:       map[string]string{},
+			}
+			for key := range req.URL.Query() {
+				r.query[key] = req.URL.Query().Get(key)
+			}
+			if req.Body != nil {
+				data, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				if len(data) > 0 {
+					require.NoError(t, json.Unmarshal(data, &r.body))
+				}
+			}
+			*requests = append(*requests, r)
+
+			name := req.URL.Path[len("/namespaces/default/pods/"):]
+			pod, ok := existing[name]
+			switch req.Method {
+			case http.MethodGet:
+				if !ok {
+					return newResponse(http.StatusNotFound, notFoundBody())
+				}
+				return newResponse(http.StatusOK, &pod)
+			case http.MethodPatch, http.MethodDelete:
+				if !ok {
+					pod = newPod(name)
+				}
+				return newResponse(http.StatusOK, &pod)
+			default:
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+				return nil, nil
+			}
+		}),
+	}
+	return c
+}
+
+func TestCreateServerSideApply(t *testing.T) {
+	var requests []applyRequest
+	c := newApplyTestClient(t, nil, &requests)
+
+	list := newPodList("starfish")
+	list.Items[0].ResourceVersion = "42"
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+
+	result, err := c.CreateServerSideApply(resources, ServerSideApplyOptions{ForceConflicts: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+
+	require.Len(t, requests, 1)
+	r := requests[0]
+	assert.Equal(t, "/namespaces/default/pods/starfish:PATCH", r.action)
+	assert.Equal(t, string(types.ApplyPatchType), r.contentType)
+	assert.Equal(t, map[string]string{"fieldManager": "helm", "force": "true"}, r.query)
+	assert.Equal(t, "Pod", r.body["kind"])
+	assert.Equal(t, "v1", r.body["apiVersion"])
+	metadata := r.body["metadata"].(map[string]interface{})
+	assert.Equal(t, "starfish", metadata["name"])
+	assert.NotContains(t, metadata, "resourceVersion", "the resource version is not part of the applied configuration")
+}
+
+func TestUpdateServerSideApply(t *testing.T) {
+	listA := newPodList("starfish", "otter", "squid")
+	listB := newPodList("starfish", "otter", "dolphin")
+	listB.Items[0].Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "https", ContainerPort: 443}}
+
+	existing := map[string]v1.Pod{}
+	for _, pod := range listA.Items {
+		existing[pod.Name] = pod
+	}
+
+	var requests []applyRequest
+	c := newApplyTestClient(t, existing, &requests)
+	original, err := c.Build(objBody(&listA), false)
+	require.NoError(t, err)
+	target, err := c.Build(objBody(&listB), false)
+	require.NoError(t, err)
+
+	result, err := c.UpdateServerSideApply(original, target, ServerSideApplyOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+	assert.Len(t, result.Updated, 2)
+	assert.Len(t, result.Deleted, 1)
+
+	var actions []string
+	for _, r := range requests {
+		actions = append(actions, r.action)
+		if strings.HasSuffix(r.action, ":PATCH") {
+			assert.Equal(t, string(types.ApplyPatchType), r.contentType)
+			assert.Equal(t, map[string]string{"fieldManager": "helm", "force": "false"}, r.query)
+		}
+	}
+	assert.Equal(t, []string{
+		"/namespaces/default/pods/starfish:GET",
+		"/namespaces/default/pods/starfish:PATCH",
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods/otter:PATCH",
+		"/namespaces/default/pods/dolphin:GET",
+		"/namespaces/default/pods/dolphin:PATCH", // apply creates dolphin
+		"/namespaces/default/pods/squid:GET",
+		"/namespaces/default/pods/squid:DELETE",
+	}, actions)
+
+	// The whole configuration is applied, not a patch of its changes.
+	ports := requests[1].body["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["ports"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "https", "containerPort": float64(443)}}, ports)
+}
+
+func TestUpdateServerSideApplyDryRun(t *testing.T) {
+	listA := newPodList("starfish", "squid")
+	listB := newPodList("starfish")
+	existing := map[string]v1.Pod{}
+	for _, pod := range listA.Items {
+		existing[pod.Name] = pod
+	}
+
+	var requests []applyRequest
+	c := newApplyTestClient(t, existing, &requests)
+	original, err := c.Build(objBody(&listA), false)
+	require.NoError(t, err)
+	target, err := c.Build(objBody(&listB), false)
+	require.NoError(t, err)
+
+	result, err := c.UpdateServerSideApply(original, target, ServerSideApplyOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Deleted, "a dry run deletes nothing")
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/namespaces/default/pods/starfish:PATCH", requests[1].action)
+	assert.Equal(t, string(types.ApplyPatchType), requests[1].contentType)
+	assert.Equal(t, metav1.DryRunAll, requests[1].query["dryRun"])
+}
+
+func TestIsServerSideApplySupported(t *testing.T) {
+	for gitVersion, expected := range map[string]bool{
+		"v1.21.14":        false,
+		"v1.22.0":         true,
+		"v1.31.2+k3s1":    true,
+		"v1.33.1-eks-abc": true,
+	} {
+		kubeClient := k8sfake.NewSimpleClientset()
+		kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: gitVersion}
+		c := &Client{kubeClient: kubeClient}
+
+		supported, err := c.IsServerSideApplySupported()
+		require.NoError(t, err)
+		assert.Equal(t, expected, supported, gitVersion)
+	}
+}
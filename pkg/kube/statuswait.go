@@ -40,6 +40,18 @@ import (
 	helmStatusReaders "helm.sh/helm/v4/internal/statusreaders"
 )
 
+// WaitConditionAnno is the annotation that, set to "ConditionType=Status" on a
+// custom resource, makes the status watcher wait for that condition instead of
+// the Ready one.
+const WaitConditionAnno = helmStatusReaders.WaitConditionAnnotation
+
+// ValidateWaitCondition checks that value is a valid WaitConditionAnno, given
+// as "ConditionType=Status" or as "ConditionType", the status being True.
+func ValidateWaitCondition(value string) error {
+	_, _, err := helmStatusReaders.ParseWaitCondition(value)
+	return err
+}
+
 type statusWaiter struct {
 	client     dynamic.Interface
 	restMapper meta.RESTMapper
@@ -78,6 +90,9 @@ func (w *statusWaiter) Wait(resourceList ResourceList, timeout time.Duration) er
 	defer cancel()
 	slog.Debug("waiting for resources", "count", len(resourceList), "timeout", timeout)
 	sw := watcher.NewDefaultStatusWatcher(w.client, w.restMapper)
+	customResourceSR := helmStatusReaders.NewCustomResourceStatusReader(w.restMapper)
+	serviceSR := helmStatusReaders.NewCustomServiceStatusReader(w.restMapper)
+	sw.StatusReader = statusreaders.NewStatusReader(w.restMapper, customResourceSR, serviceSR)
 	return w.wait(ctx, resourceList, sw)
 }
 
@@ -87,7 +102,9 @@ func (w *statusWaiter) WaitWithJobs(resourceList ResourceList, timeout time.Dura
 	slog.Debug("waiting for resources", "count", len(resourceList), "timeout", timeout)
 	sw := watcher.NewDefaultStatusWatcher(w.client, w.restMapper)
 	newCustomJobStatusReader := helmStatusReaders.NewCustomJobStatusReader(w.restMapper)
-	customSR := statusreaders.NewStatusReader(w.restMapper, newCustomJobStatusReader)
+	customResourceSR := helmStatusReaders.NewCustomResourceStatusReader(w.restMapper)
+	serviceSR := helmStatusReaders.NewCustomServiceStatusReader(w.restMapper)
+	customSR := statusreaders.NewStatusReader(w.restMapper, newCustomJobStatusReader, customResourceSR, serviceSR)
 	sw.StatusReader = customSR
 	return w.wait(ctx, resourceList, sw)
 }
@@ -214,6 +231,8 @@ func statusObserver(cancel context.CancelFunc, desired status.Status) collector.
 	}
 }
 
+// hookOnlyWaiter only watches hooks until they are ready, and returns at once
+// when asked to wait for the other resources.
 type hookOnlyWaiter struct {
 	sw *statusWaiter
 }
@@ -448,3 +448,108 @@ func TestWatchForReady(t *testing.T) {
 		})
 	}
 }
+
+func TestHookOnlyWaiter(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t)
+	fakeClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	fakeMapper := testutil.NewFakeRESTMapper(
+		appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		batchv1.SchemeGroupVersion.WithKind("Job"),
+	)
+	waiter := hookOnlyWaiter{sw: &statusWaiter{
+		client:     fakeClient,
+		restMapper: fakeMapper,
+	}}
+	objs := getRuntimeObjFromManifests(t, []string{notReadyDeploymentManifest, jobReadyManifest})
+	for _, obj := range objs {
+		u := obj.(*unstructured.Unstructured)
+		gvr := getGVR(t, fakeMapper, u)
+		require.NoError(t, fakeClient.Tracker().Create(gvr, u, u.GetNamespace()))
+	}
+	resourceList := getResourceListFromRuntimeObjs(t, c, objs)
+
+	// The resources of the release are not waited for.
+	assert.NoError(t, waiter.Wait(resourceList, time.Second))
+	assert.NoError(t, waiter.WaitWithJobs(resourceList, time.Second))
+	assert.NoError(t, waiter.WaitForDelete(resourceList, time.Second))
+
+	// The hooks are, until the job completes.
+	err := waiter.WatchUntilReady(resourceList, time.Second)
+	assert.ErrorContains(t, err, "resource not ready, name: ready-not-complete, kind: Job, status: InProgress")
+}
+
+func TestStatusWaitCustomResources(t *testing.T) {
+	t.Parallel()
+	widgetManifest := func(annotation, conditions string) string {
+		return `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: default
+  generation: 1
+  annotations:
+    ` + WaitConditionAnno + `: "` + annotation + `"
+status:
+  observedGeneration: 1
+  conditions:
+` + conditions
+	}
+	tests := []struct {
+		name        string
+		manifest    string
+		waitForJobs bool
+		expectErrs  []error
+	}{
+		{
+			name:     "Ready condition True",
+			manifest: widgetManifest("Ready=True", "  - type: Ready\n    status: \"True\"\n"),
+		},
+		{
+			name:       "Ready condition False",
+			manifest:   widgetManifest("Ready=True", "  - type: Ready\n    status: \"False\"\n"),
+			expectErrs: []error{errors.New("resource not ready, name: widget, kind: Widget, status: InProgress"), errors.New("context deadline exceeded")},
+		},
+		{
+			name:     "custom condition",
+			manifest: widgetManifest("Synced=True", "  - type: Ready\n    status: \"False\"\n  - type: Synced\n    status: \"True\"\n"),
+		},
+		{
+			name:        "custom condition not met when waiting for jobs",
+			manifest:    widgetManifest("Synced=True", "  - type: Ready\n    status: \"True\"\n  - type: Synced\n    status: \"False\"\n"),
+			waitForJobs: true,
+			expectErrs:  []error{errors.New("resource not ready, name: widget, kind: Widget, status: InProgress"), errors.New("context deadline exceeded")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			widgetGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+			fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				{Group: "example.com", Version: "v1", Resource: "widgets"}: "WidgetList",
+			})
+			fakeMapper := testutil.NewFakeRESTMapper(widgetGVK)
+			statusWaiter := statusWaiter{
+				client:     fakeClient,
+				restMapper: fakeMapper,
+			}
+			u := getRuntimeObjFromManifests(t, []string{tt.manifest})[0].(*unstructured.Unstructured)
+			require.NoError(t, fakeClient.Tracker().Create(getGVR(t, fakeMapper, u), u, u.GetNamespace()))
+			resourceList := ResourceList{{Name: u.GetName(), Namespace: u.GetNamespace(), Object: u}}
+
+			var err error
+			if tt.waitForJobs {
+				err = statusWaiter.WaitWithJobs(resourceList, time.Second)
+			} else {
+				err = statusWaiter.Wait(resourceList, time.Second)
+			}
+			if tt.expectErrs != nil {
+				assert.EqualError(t, err, errors.Join(tt.expectErrs...).Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -27,6 +27,9 @@ import (
 type linterOptions struct {
 	KubeVersion          *chartutil.KubeVersion
 	SkipSchemaValidation bool
+	DeprecationRules     []rules.DeprecationRule
+	CompatWarnings       bool
+	Subcharts            bool
 }
 
 type LinterOption func(lo *linterOptions)
@@ -43,6 +46,29 @@ func WithSkipSchemaValidation(skipSchemaValidation bool) LinterOption {
 	}
 }
 
+// WithDeprecationRules adds rules for deprecated Kubernetes APIs to the built-in ones.
+func WithDeprecationRules(deprecationRules []rules.DeprecationRule) LinterOption {
+	return func(lo *linterOptions) {
+		lo.DeprecationRules = deprecationRules
+	}
+}
+
+// WithCompatWarnings checks the templates for the uses of deprecated or removed
+// template functions and fields.
+func WithCompatWarnings(compatWarnings bool) LinterOption {
+	return func(lo *linterOptions) {
+		lo.CompatWarnings = compatWarnings
+	}
+}
+
+// WithSubcharts lints the templates of the enabled subcharts, rendered with
+// the values the chart passes down to them.
+func WithSubcharts(subcharts bool) LinterOption {
+	return func(lo *linterOptions) {
+		lo.Subcharts = subcharts
+	}
+}
+
 func RunAll(baseDir string, values map[string]interface{}, namespace string, options ...LinterOption) support.Linter {
 
 	chartDir, _ := filepath.Abs(baseDir)
@@ -58,7 +84,15 @@ func RunAll(baseDir string, values map[string]interface{}, namespace string, opt
 
 	rules.Chartfile(&result)
 	rules.ValuesWithOverrides(&result, values)
-	rules.TemplatesWithSkipSchemaValidation(&result, values, namespace, lo.KubeVersion, lo.SkipSchemaValidation)
+	rules.ValuesMigrations(&result)
+	if lo.Subcharts {
+		rules.TemplatesWithSubcharts(&result, values, namespace, lo.KubeVersion, lo.SkipSchemaValidation, lo.DeprecationRules)
+	} else {
+		rules.TemplatesWithDeprecationRules(&result, values, namespace, lo.KubeVersion, lo.SkipSchemaValidation, lo.DeprecationRules)
+	}
+	if lo.CompatWarnings {
+		rules.TemplateCompat(&result)
+	}
 	rules.Dependencies(&result)
 	rules.Crds(&result)
 
@@ -21,12 +21,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
-	"github.com/Masterminds/semver/v3"
-	"github.com/asaskevich/govalidator"
 	"sigs.k8s.io/yaml"
 
-	chart "helm.sh/helm/v4/pkg/chart/v2"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
@@ -53,20 +51,20 @@ func Chartfile(linter *support.Linter) {
 	// errors would already be caught in the above load function
 	chartFileForTypeCheck, _ := loadChartFileForTypeCheck(chartPath)
 
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartName(chartFile))
-
-	// Chart metadata
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartAPIVersion(chartFile))
-
+	// Chart metadata. The findings come in the order of the fields, and the
+	// type checks of version and appVersion run next to their fields.
+	findings := chartutil.ValidateMetadata(chartFile)
+	runFindings := func(fields ...string) {
+		for len(findings) > 0 && (len(fields) == 0 || slices.Contains(fields, findings[0].Path)) {
+			linter.RunLinterRule(findingSeverity(findings[0].Severity), chartFileName, errors.New(findings[0].Message))
+			findings = findings[1:]
+		}
+	}
+	runFindings("name", "apiVersion")
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartVersionType(chartFileForTypeCheck))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartVersion(chartFile))
+	runFindings("version")
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartAppVersionType(chartFileForTypeCheck))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartMaintainer(chartFile))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartSources(chartFile))
-	linter.RunLinterRule(support.InfoSev, chartFileName, validateChartIconPresence(chartFile))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartIconURL(chartFile))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartType(chartFile))
-	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartDependencies(chartFile))
+	runFindings()
 }
 
 func validateChartVersionType(data map[string]interface{}) error {
@@ -112,100 +110,18 @@ func validateChartYamlStrictFormat(chartFileError error) error {
 	return nil
 }
 
-func validateChartName(cf *chart.Metadata) error {
-	if cf.Name == "" {
-		return errors.New("name is required")
-	}
-	name := filepath.Base(cf.Name)
-	if name != cf.Name {
-		return fmt.Errorf("chart name %q is invalid", cf.Name)
-	}
-	return nil
-}
-
-func validateChartAPIVersion(cf *chart.Metadata) error {
-	if cf.APIVersion == "" {
-		return errors.New("apiVersion is required. The value must be either \"v1\" or \"v2\"")
-	}
-
-	if cf.APIVersion != chart.APIVersionV1 && cf.APIVersion != chart.APIVersionV2 {
-		return fmt.Errorf("apiVersion '%s' is not valid. The value must be either \"v1\" or \"v2\"", cf.APIVersion)
-	}
-
-	return nil
-}
-
-func validateChartVersion(cf *chart.Metadata) error {
-	if cf.Version == "" {
-		return errors.New("version is required")
-	}
-
-	version, err := semver.NewVersion(cf.Version)
-	if err != nil {
-		return fmt.Errorf("version '%s' is not a valid SemVer", cf.Version)
-	}
-
-	c, err := semver.NewConstraint(">0.0.0-0")
-	if err != nil {
-		return err
-	}
-	valid, msg := c.Validate(version)
-
-	if !valid && len(msg) > 0 {
-		return fmt.Errorf("version %v", msg[0])
-	}
-
-	return nil
-}
-
-func validateChartMaintainer(cf *chart.Metadata) error {
-	for _, maintainer := range cf.Maintainers {
-		if maintainer.Name == "" {
-			return errors.New("each maintainer requires a name")
-		} else if maintainer.Email != "" && !govalidator.IsEmail(maintainer.Email) {
-			return fmt.Errorf("invalid email '%s' for maintainer '%s'", maintainer.Email, maintainer.Name)
-		} else if maintainer.URL != "" && !govalidator.IsURL(maintainer.URL) {
-			return fmt.Errorf("invalid url '%s' for maintainer '%s'", maintainer.URL, maintainer.Name)
-		}
-	}
-	return nil
-}
-
-func validateChartSources(cf *chart.Metadata) error {
-	for _, source := range cf.Sources {
-		if source == "" || !govalidator.IsRequestURL(source) {
-			return fmt.Errorf("invalid source URL '%s'", source)
-		}
-	}
-	return nil
-}
-
-func validateChartIconPresence(cf *chart.Metadata) error {
-	if cf.Icon == "" {
-		return errors.New("icon is recommended")
+// findingSeverity returns the lint severity of a finding of
+// chartutil.ValidateMetadata.
+func findingSeverity(sev chartutil.Severity) int {
+	switch sev {
+	case chartutil.SeverityInfo:
+		return support.InfoSev
+	case chartutil.SeverityWarning:
+		return support.WarningSev
+	case chartutil.SeverityError:
+		return support.ErrorSev
 	}
-	return nil
-}
-
-func validateChartIconURL(cf *chart.Metadata) error {
-	if cf.Icon != "" && !govalidator.IsRequestURL(cf.Icon) {
-		return fmt.Errorf("invalid icon URL '%s'", cf.Icon)
-	}
-	return nil
-}
-
-func validateChartDependencies(cf *chart.Metadata) error {
-	if len(cf.Dependencies) > 0 && cf.APIVersion != chart.APIVersionV2 {
-		return fmt.Errorf("dependencies are not valid in the Chart file with apiVersion '%s'. They are valid in apiVersion '%s'", cf.APIVersion, chart.APIVersionV2)
-	}
-	return nil
-}
-
-func validateChartType(cf *chart.Metadata) error {
-	if len(cf.Type) > 0 && cf.APIVersion != chart.APIVersionV2 {
-		return fmt.Errorf("chart type is not valid in apiVersion '%s'. It is valid in apiVersion '%s'", cf.APIVersion, chart.APIVersionV2)
-	}
-	return nil
+	return support.UnknownSev
 }
 
 // loadChartFileForTypeCheck loads the Chart.yaml
@@ -23,8 +23,6 @@ import (
 	"strings"
 	"testing"
 
-	chart "helm.sh/helm/v4/pkg/chart/v2"
-	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
 
@@ -35,14 +33,9 @@ const (
 )
 
 var (
-	badChartNamePath         = filepath.Join(badChartNameDir, "Chart.yaml")
-	badChartFilePath         = filepath.Join(badChartDir, "Chart.yaml")
 	nonExistingChartFilePath = filepath.Join(os.TempDir(), "Chart.yaml")
 )
 
-var badChart, _ = chartutil.LoadChartfile(badChartFilePath)
-var badChartName, _ = chartutil.LoadChartfile(badChartNamePath)
-
 // Validation functions Test
 func TestValidateChartYamlNotDirectory(t *testing.T) {
 	_ = os.Mkdir(nonExistingChartFilePath, os.ModePerm)
@@ -66,151 +59,6 @@ func TestValidateChartYamlFormat(t *testing.T) {
 	}
 }
 
-func TestValidateChartName(t *testing.T) {
-	err := validateChartName(badChart)
-	if err == nil {
-		t.Errorf("validateChartName to return a linter error, got no error")
-	}
-
-	err = validateChartName(badChartName)
-	if err == nil {
-		t.Error("expected validateChartName to return a linter error for an invalid name, got no error")
-	}
-}
-
-func TestValidateChartVersion(t *testing.T) {
-	var failTest = []struct {
-		Version  string
-		ErrorMsg string
-	}{
-		{"", "version is required"},
-		{"1.2.3.4", "version '1.2.3.4' is not a valid SemVer"},
-		{"waps", "'waps' is not a valid SemVer"},
-		{"-3", "'-3' is not a valid SemVer"},
-	}
-
-	var successTest = []string{"0.0.1", "0.0.1+build", "0.0.1-beta"}
-
-	for _, test := range failTest {
-		badChart.Version = test.Version
-		err := validateChartVersion(badChart)
-		if err == nil || !strings.Contains(err.Error(), test.ErrorMsg) {
-			t.Errorf("validateChartVersion(%s) to return \"%s\", got no error", test.Version, test.ErrorMsg)
-		}
-	}
-
-	for _, version := range successTest {
-		badChart.Version = version
-		err := validateChartVersion(badChart)
-		if err != nil {
-			t.Errorf("validateChartVersion(%s) to return no error, got a linter error", version)
-		}
-	}
-}
-
-func TestValidateChartMaintainer(t *testing.T) {
-	var failTest = []struct {
-		Name     string
-		Email    string
-		ErrorMsg string
-	}{
-		{"", "", "each maintainer requires a name"},
-		{"", "test@test.com", "each maintainer requires a name"},
-		{"John Snow", "wrongFormatEmail.com", "invalid email"},
-	}
-
-	var successTest = []struct {
-		Name  string
-		Email string
-	}{
-		{"John Snow", ""},
-		{"John Snow", "john@winterfell.com"},
-	}
-
-	for _, test := range failTest {
-		badChart.Maintainers = []*chart.Maintainer{{Name: test.Name, Email: test.Email}}
-		err := validateChartMaintainer(badChart)
-		if err == nil || !strings.Contains(err.Error(), test.ErrorMsg) {
-			t.Errorf("validateChartMaintainer(%s, %s) to return \"%s\", got no error", test.Name, test.Email, test.ErrorMsg)
-		}
-	}
-
-	for _, test := range successTest {
-		badChart.Maintainers = []*chart.Maintainer{{Name: test.Name, Email: test.Email}}
-		err := validateChartMaintainer(badChart)
-		if err != nil {
-			t.Errorf("validateChartMaintainer(%s, %s) to return no error, got %s", test.Name, test.Email, err.Error())
-		}
-	}
-}
-
-func TestValidateChartSources(t *testing.T) {
-	var failTest = []string{"", "RiverRun", "john@winterfell", "riverrun.io"}
-	var successTest = []string{"http://riverrun.io", "https://riverrun.io", "https://riverrun.io/blackfish"}
-	for _, test := range failTest {
-		badChart.Sources = []string{test}
-		err := validateChartSources(badChart)
-		if err == nil || !strings.Contains(err.Error(), "invalid source URL") {
-			t.Errorf("validateChartSources(%s) to return \"invalid source URL\", got no error", test)
-		}
-	}
-
-	for _, test := range successTest {
-		badChart.Sources = []string{test}
-		err := validateChartSources(badChart)
-		if err != nil {
-			t.Errorf("validateChartSources(%s) to return no error, got %s", test, err.Error())
-		}
-	}
-}
-
-func TestValidateChartIconPresence(t *testing.T) {
-	t.Run("Icon absent", func(t *testing.T) {
-		testChart := &chart.Metadata{
-			Icon: "",
-		}
-
-		err := validateChartIconPresence(testChart)
-
-		if err == nil {
-			t.Errorf("validateChartIconPresence to return a linter error, got no error")
-		} else if !strings.Contains(err.Error(), "icon is recommended") {
-			t.Errorf("expected %q, got %q", "icon is recommended", err.Error())
-		}
-	})
-	t.Run("Icon present", func(t *testing.T) {
-		testChart := &chart.Metadata{
-			Icon: "http://example.org/icon.png",
-		}
-
-		err := validateChartIconPresence(testChart)
-
-		if err != nil {
-			t.Errorf("Unexpected error: %q", err.Error())
-		}
-	})
-}
-
-func TestValidateChartIconURL(t *testing.T) {
-	var failTest = []string{"RiverRun", "john@winterfell", "riverrun.io"}
-	var successTest = []string{"http://riverrun.io", "https://riverrun.io", "https://riverrun.io/blackfish.png"}
-	for _, test := range failTest {
-		badChart.Icon = test
-		err := validateChartIconURL(badChart)
-		if err == nil || !strings.Contains(err.Error(), "invalid icon URL") {
-			t.Errorf("validateChartIconURL(%s) to return \"invalid icon URL\", got no error", test)
-		}
-	}
-
-	for _, test := range successTest {
-		badChart.Icon = test
-		err := validateChartSources(badChart)
-		if err != nil {
-			t.Errorf("validateChartIconURL(%s) to return no error, got %s", test, err.Error())
-		}
-	}
-}
-
 func TestChartfile(t *testing.T) {
 	t.Run("Chart.yaml basic validity issues", func(t *testing.T) {
 		linter := support.Linter{ChartDir: badChartDir}
@@ -248,6 +96,25 @@ func TestChartfile(t *testing.T) {
 		}
 	})
 
+	t.Run("Chart.yaml with an invalid name", func(t *testing.T) {
+		linter := support.Linter{ChartDir: badChartNameDir}
+		Chartfile(&linter)
+		msgs := linter.Messages
+
+		if len(msgs) != 2 {
+			t.Errorf("Expected 2 messages, got %d", len(msgs))
+			return
+		}
+
+		if !strings.Contains(msgs[0].Err.Error(), `chart name "../badchartname" is invalid`) {
+			t.Errorf("Unexpected message 0: %s", msgs[0].Err)
+		}
+
+		if !strings.Contains(msgs[1].Err.Error(), "icon is recommended") {
+			t.Errorf("Unexpected message 1: %s", msgs[1].Err)
+		}
+	})
+
 	t.Run("Chart.yaml validity issues due to type mismatch", func(t *testing.T) {
 		linter := support.Linter{ChartDir: anotherBadChartDir}
 		Chartfile(&linter)
@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules // import "helm.sh/helm/v4/pkg/lint/rules"
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/engine"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+// TemplateCompat lints the templates of a chart, and of its dependencies, for
+// the uses of template functions and fields which were deprecated or removed
+// in Helm, listed in engine.DefaultCompatRules.
+//
+// The uses of removed identifiers are errors, the uses of deprecated ones,
+// which still work, are warnings.
+func TemplateCompat(linter *support.Linter) {
+	c, err := loader.Load(linter.ChartDir)
+	if err != nil {
+		// Reported by the templates rule.
+		return
+	}
+
+	templates := make(map[string]string)
+	collectTemplates(c, templates)
+
+	var e engine.Engine
+	warnings, err := e.CompatWarnings(templates)
+	if err != nil {
+		// Reported by the templates rule.
+		return
+	}
+
+	for _, w := range warnings {
+		sev := support.WarningSev
+		if w.Rule.Removed() {
+			sev = support.ErrorSev
+		}
+		linter.RunLinterRule(sev, strings.TrimPrefix(w.Template, c.Name()+"/"), errors.New(w.String()))
+	}
+}
+
+// collectTemplates adds the templates of c and of its dependencies to
+// templates, keyed by their path in the rendered chart.
+func collectTemplates(c *chart.Chart, templates map[string]string) {
+	for _, dep := range c.Dependencies() {
+		collectTemplates(dep, templates)
+	}
+	for _, t := range c.Templates {
+		templates[path.Join(c.ChartFullPath(), t.Name)] = string(t.Data)
+	}
+}
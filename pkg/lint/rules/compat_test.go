@@ -0,0 +1,80 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+func TestTemplateCompat(t *testing.T) {
+	mychart := chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: "v2",
+			Name:       "compat",
+			Version:    "0.1.0",
+		},
+		Templates: []*chart.File{
+			{
+				Name: "templates/configmap.yaml",
+				Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ trimall \"-\" .Release.Name }}\ndata:\n  tiller: {{ .Capabilities.TillerVersion | quote }}"),
+			},
+			{
+				Name: "templates/secret.yaml",
+				Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: {{ .Release.Name }}"),
+			},
+		},
+	}
+	tmpdir := t.TempDir()
+	if err := chartutil.SaveDir(&mychart, tmpdir); err != nil {
+		t.Fatal(err)
+	}
+
+	linter := support.Linter{ChartDir: filepath.Join(tmpdir, mychart.Name())}
+	TemplateCompat(&linter)
+	if l := len(linter.Messages); l != 2 {
+		for i, msg := range linter.Messages {
+			t.Logf("Message %d: %s", i, msg)
+		}
+		t.Fatalf("Expected 2 lint messages, got %d", l)
+	}
+
+	expected := []struct {
+		severity int
+		message  string
+	}{
+		{support.WarningSev, `the "trimall" function is deprecated since Helm v3.0.0; use trimAll instead`},
+		{support.ErrorSev, `.Capabilities.TillerVersion was removed in Helm v3.0.0; use .Capabilities.HelmVersion instead`},
+	}
+	for i, exp := range expected {
+		msg := linter.Messages[i]
+		if msg.Path != "templates/configmap.yaml" {
+			t.Errorf("Message %d: expected path templates/configmap.yaml, got %s", i, msg.Path)
+		}
+		if msg.Severity != exp.severity {
+			t.Errorf("Message %d: expected severity %d, got %d", i, exp.severity, msg.Severity)
+		}
+		if !strings.HasSuffix(msg.Err.Error(), exp.message) {
+			t.Errorf("Message %d: expected %q, got %q", i, exp.message, msg.Err)
+		}
+	}
+}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package rules // import "helm.sh/helm/v4/pkg/lint/rules"
 
-import "testing"
+import (
+	"testing"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
 
 func TestValidateNoDeprecations(t *testing.T) {
 	deprecated := &k8sYamlStruct{
@@ -39,3 +44,95 @@ func TestValidateNoDeprecations(t *testing.T) {
 		t.Errorf("Expected a v1 Pod to not be deprecated")
 	}
 }
+
+func TestValidateDeprecationRules(t *testing.T) {
+	pdb := &k8sYamlStruct{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget"}
+
+	tests := []struct {
+		name        string
+		resource    *k8sYamlStruct
+		kubeVersion string
+		rules       []DeprecationRule
+		severity    int
+		message     string
+	}{
+		{
+			name:        "before deprecation",
+			resource:    pdb,
+			kubeVersion: "1.20.0",
+			rules:       DefaultDeprecationRules,
+			severity:    support.WarningSev,
+		},
+		{
+			name:        "deprecated",
+			resource:    pdb,
+			kubeVersion: "1.21.0",
+			rules:       DefaultDeprecationRules,
+			severity:    support.WarningSev,
+			message:     "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+; use policy/v1 PodDisruptionBudget",
+		},
+		{
+			name:        "removed",
+			resource:    pdb,
+			kubeVersion: "1.25.0",
+			rules:       DefaultDeprecationRules,
+			severity:    support.ErrorSev,
+			message:     "policy/v1beta1 PodDisruptionBudget is unavailable in v1.25+; use policy/v1 PodDisruptionBudget",
+		},
+		{
+			name:        "first matching rule wins",
+			resource:    pdb,
+			kubeVersion: "1.25.0",
+			rules:       append([]DeprecationRule{{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", DeprecatedIn: "1.21"}}, DefaultDeprecationRules...),
+			severity:    support.WarningSev,
+			message:     "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+",
+		},
+		{
+			name:        "falls back to API metadata",
+			resource:    &k8sYamlStruct{APIVersion: "extensions/v1beta1", Kind: "Deployment"},
+			kubeVersion: "1.22.0",
+			severity:    support.WarningSev,
+			message:     "extensions/v1beta1 Deployment is deprecated in v1.8+, unavailable in v1.16+; use apps/v1 Deployment",
+		},
+		{
+			name:        "current API",
+			resource:    &k8sYamlStruct{APIVersion: "policy/v1", Kind: "PodDisruptionBudget"},
+			kubeVersion: "1.29.0",
+			rules:       DefaultDeprecationRules,
+			severity:    support.WarningSev,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeVersion, err := chartutil.ParseKubeVersion(tt.kubeVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			severity, err := validateDeprecationRules(tt.resource, kubeVersion, tt.rules)
+			if severity != tt.severity {
+				t.Errorf("expected severity %d, got %d", tt.severity, severity)
+			}
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.message {
+				t.Errorf("expected error %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestValidateDeprecationRulesInvalidVersion(t *testing.T) {
+	_, err := validateDeprecationRules(
+		&k8sYamlStruct{APIVersion: "example.com/v1", Kind: "Widget"},
+		nil,
+		[]DeprecationRule{{APIVersion: "example.com/v1", Kind: "Widget", DeprecatedIn: "soon"}},
+	)
+	if err == nil {
+		t.Fatal("expected an invalid rule version to be reported")
+	}
+}
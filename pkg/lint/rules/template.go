@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
+	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/engine"
@@ -51,6 +52,27 @@ func TemplatesWithKubeVersion(linter *support.Linter, values map[string]interfac
 
 // TemplatesWithSkipSchemaValidation lints the templates in the Linter, allowing to specify the kubernetes version and if schema validation is enabled or not.
 func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool) {
+	TemplatesWithDeprecationRules(linter, values, namespace, kubeVersion, skipSchemaValidation, nil)
+}
+
+// TemplatesWithDeprecationRules lints the templates in the Linter, checking the rendered resources against
+// the given deprecation rules in addition to DefaultDeprecationRules.
+func TemplatesWithDeprecationRules(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool, deprecationRules []DeprecationRule) {
+	lintTemplates(linter, values, namespace, kubeVersion, skipSchemaValidation, deprecationRules, false)
+}
+
+// TemplatesWithSubcharts lints the templates in the Linter like
+// TemplatesWithDeprecationRules, and the templates of the enabled subcharts,
+// rendered with the values the chart passes down to them, including the
+// globals. Their messages are reported at charts/<name>/templates/..., where
+// name is the alias of the subchart, if any.
+func TemplatesWithSubcharts(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool, deprecationRules []DeprecationRule) {
+	lintTemplates(linter, values, namespace, kubeVersion, skipSchemaValidation, deprecationRules, true)
+}
+
+func lintTemplates(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool, deprecationRules []DeprecationRule, withSubcharts bool) {
+	deprecationRules = append(slices.Clip(deprecationRules), DefaultDeprecationRules...)
+
 	fpath := "templates/"
 	templatesPath := filepath.Join(linter.ChartDir, fpath)
 
@@ -110,6 +132,27 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		return
 	}
 
+	if lintRenderedTemplates(linter, chart, chart, renderedContentMap, kubeVersion, deprecationRules) && withSubcharts {
+		lintSubchartTemplates(linter, chart, chart, renderedContentMap, kubeVersion, deprecationRules)
+	}
+}
+
+// lintSubchartTemplates lints the rendered templates of the subcharts of c,
+// recursively. ProcessDependencies removed the subcharts disabled by their
+// conditions or tags, and named the others after their aliases.
+func lintSubchartTemplates(linter *support.Linter, root, c *chart.Chart, renderedContentMap map[string]string, kubeVersion *chartutil.KubeVersion, deprecationRules []DeprecationRule) bool {
+	for _, sub := range c.Dependencies() {
+		if !lintRenderedTemplates(linter, root, sub, renderedContentMap, kubeVersion, deprecationRules) || !lintSubchartTemplates(linter, root, sub, renderedContentMap, kubeVersion, deprecationRules) {
+			return false
+		}
+	}
+	return true
+}
+
+// lintRenderedTemplates lints the rendered templates of c, a subchart of root
+// or root itself. It returns false if a template is not valid YAML, which
+// stops the linting of the templates.
+func lintRenderedTemplates(linter *support.Linter, root, c *chart.Chart, renderedContentMap map[string]string, kubeVersion *chartutil.KubeVersion, deprecationRules []DeprecationRule) bool {
 	/* Iterate over all the templates to check:
 	- It is a .yaml file
 	- All the values in the template file is defined
@@ -117,9 +160,10 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 	- Generated content is a valid Yaml file
 	- Metadata.Namespace is not set
 	*/
-	for _, template := range chart.Templates {
+	for _, template := range c.Templates {
 		fileName := template.Name
-		fpath = fileName
+		// The path of the template, relative to the root chart.
+		fpath := strings.TrimPrefix(path.Join(c.ChartFullPath(), fileName), root.ChartFullPath()+"/")
 
 		linter.RunLinterRule(support.ErrorSev, fpath, validateAllowedExtension(fileName))
 
@@ -135,7 +179,7 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		// NOTE: disabled for now, Refs https://github.com/helm/helm/issues/1037
 		// linter.RunLinterRule(support.WarningSev, fpath, validateQuotes(string(preExecutedTemplate)))
 
-		renderedContent := renderedContentMap[path.Join(chart.Name(), fileName)]
+		renderedContent := renderedContentMap[path.Join(c.ChartFullPath(), fileName)]
 		if strings.TrimSpace(renderedContent) != "" {
 			linter.RunLinterRule(support.WarningSev, fpath, validateTopIndentLevel(renderedContent))
 
@@ -155,13 +199,14 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 				//  If YAML linting fails here, it will always fail in the next block as well, so we should return here.
 				// fix https://github.com/helm/helm/issues/11391
 				if !linter.RunLinterRule(support.ErrorSev, fpath, validateYamlContent(err)) {
-					return
+					return false
 				}
 				if yamlStruct != nil {
 					// NOTE: set to warnings to allow users to support out-of-date kubernetes
 					// Refs https://github.com/helm/helm/issues/8596
 					linter.RunLinterRule(support.WarningSev, fpath, validateMetadataName(yamlStruct))
-					linter.RunLinterRule(support.WarningSev, fpath, validateNoDeprecations(yamlStruct, kubeVersion))
+					deprecationSev, err := validateDeprecationRules(yamlStruct, kubeVersion, deprecationRules)
+					linter.RunLinterRule(deprecationSev, fpath, err)
 
 					linter.RunLinterRule(support.ErrorSev, fpath, validateMatchSelector(yamlStruct, renderedContent))
 					linter.RunLinterRule(support.ErrorSev, fpath, validateListAnnotations(yamlStruct, renderedContent))
@@ -169,6 +214,7 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 			}
 		}
 	}
+	return true
 }
 
 // validateTopIndentLevel checks that the content does not start with an indent level > 0.
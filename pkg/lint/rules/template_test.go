@@ -221,6 +221,48 @@ func TestDeprecatedAPIFails(t *testing.T) {
 	}
 }
 
+func TestTemplatesWithSubcharts(t *testing.T) {
+	const chartDir = "./testdata/subchart-values"
+
+	// Without the subcharts, only the templates of the chart are linted.
+	linter := support.Linter{ChartDir: chartDir}
+	TemplatesWithDeprecationRules(&linter, map[string]interface{}{}, namespace, nil, false, nil)
+	if len(linter.Messages) != 0 {
+		t.Fatalf("Expected no message, got %v", linter.Messages)
+	}
+
+	// The subchart aliased api gets the suffix of the chart, and the
+	// global domain. The disabled subchart is skipped.
+	linter = support.Linter{ChartDir: chartDir}
+	TemplatesWithSubcharts(&linter, map[string]interface{}{}, namespace, nil, false, nil)
+	if len(linter.Messages) != 1 {
+		t.Fatalf("Expected one message, got %v", linter.Messages)
+	}
+	msg := linter.Messages[0]
+	if msg.Path != "charts/api/templates/configmap.yaml" {
+		t.Errorf("Expected the message to be attributed to the template of the subchart, got %s", msg.Path)
+	}
+	if !strings.Contains(msg.Err.Error(), `"example.com-API"`) {
+		t.Errorf("Unexpected error: %s", msg.Err)
+	}
+
+	// The values given override the ones of the chart.
+	linter = support.Linter{ChartDir: chartDir}
+	vals := map[string]interface{}{"api": map[string]interface{}{"suffix": "api"}}
+	TemplatesWithSubcharts(&linter, vals, namespace, nil, false, nil)
+	if len(linter.Messages) != 0 {
+		t.Fatalf("Expected no message, got %v", linter.Messages)
+	}
+
+	// Enabling the disabled subchart lints it.
+	linter = support.Linter{ChartDir: chartDir}
+	vals = map[string]interface{}{"api": map[string]interface{}{"suffix": "api"}, "disabled": map[string]interface{}{"enabled": true}}
+	TemplatesWithSubcharts(&linter, vals, namespace, nil, false, nil)
+	if len(linter.Messages) != 1 || linter.Messages[0].Path != "charts/disabled/templates/configmap.yaml" {
+		t.Fatalf("Expected one message for the disabled subchart, got %v", linter.Messages)
+	}
+}
+
 const manifest = `apiVersion: v1
 kind: ConfigMap
 metadata:
@@ -17,10 +17,15 @@ limitations under the License.
 package rules
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
@@ -32,6 +37,9 @@ import (
 //
 // If additional values are supplied, they are coalesced into the values in values.yaml.
 func ValuesWithOverrides(linter *support.Linter, valueOverrides map[string]interface{}) {
+	linter.RunLinterRule(support.ErrorSev, loader.SchemaYAMLFile, validateSchemaYAMLFile(filepath.Join(linter.ChartDir, loader.SchemaYAMLFile)))
+	linter.RunLinterRule(support.ErrorSev, loader.SchemaYAMLFile, validateSchemaFilesMatch(linter.ChartDir))
+
 	file := "values.yaml"
 	vf := filepath.Join(linter.ChartDir, file)
 	fileExists := linter.RunLinterRule(support.InfoSev, file, validateValuesFileExistence(vf))
@@ -68,6 +76,11 @@ func validateValuesFile(valuesPath string, overrides map[string]interface{}) err
 	ext := filepath.Ext(valuesPath)
 	schemaPath := valuesPath[:len(valuesPath)-len(ext)] + ".schema.json"
 	schema, err := os.ReadFile(schemaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if schema, err = readSchemaYAML(valuesPath[:len(valuesPath)-len(ext)] + ".schema.yaml"); err != nil {
+			return err
+		}
+	}
 	if len(schema) == 0 {
 		return nil
 	}
@@ -76,3 +89,69 @@ func validateValuesFile(valuesPath string, overrides map[string]interface{}) err
 	}
 	return chartutil.ValidateAgainstSingleSchema(coalescedValues, schema)
 }
+
+// readSchemaYAML reads the values schema written in YAML at schemaPath and
+// converts it to JSON. It returns no schema when there is no such file, or
+// when it does not parse, which is reported by validateSchemaYAMLFile.
+func readSchemaYAML(schemaPath string) ([]byte, error) {
+	data, err := os.ReadFile(schemaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	schema, err := loader.SchemaJSON(data)
+	if err != nil {
+		return nil, nil
+	}
+	return schema, nil
+}
+
+// validateSchemaFilesMatch checks that the values.schema.json and
+// values.schema.yaml of a chart, when both exist, define the same schema.
+func validateSchemaFilesMatch(chartDir string) error {
+	fromJSON, err := os.ReadFile(filepath.Join(chartDir, "values.schema.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fromYAML, err := readSchemaYAML(filepath.Join(chartDir, loader.SchemaYAMLFile))
+	if fromYAML == nil || err != nil {
+		return err
+	}
+
+	var a, b interface{}
+	if err := json.Unmarshal(fromJSON, &a); err != nil {
+		return fmt.Errorf("unable to parse values.schema.json: %w", err)
+	}
+	if err := json.Unmarshal(fromYAML, &b); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", loader.SchemaYAMLFile, err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		return fmt.Errorf("%s and values.schema.json define different schemas: keep only one of them", loader.SchemaYAMLFile)
+	}
+	return nil
+}
+
+// validateSchemaYAMLFile checks that the values schema written in YAML, if
+// any, parses and is a valid JSON Schema.
+func validateSchemaYAMLFile(schemaPath string) error {
+	data, err := os.ReadFile(schemaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	schema, err := loader.SchemaJSON(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse YAML: %w", err)
+	}
+	if err := chartutil.CompileSchema(schema); err != nil {
+		return fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+// ValuesMigrations lints the values-migrations.yaml file, if the chart has one:
+// it must only hold known fields, valid version constraints and complete
+// operations.
+func ValuesMigrations(linter *support.Linter) {
+	file := chartutil.ValuesMigrationsFileName
+	data, err := os.ReadFile(filepath.Join(linter.ChartDir, file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if !linter.RunLinterRule(support.ErrorSev, file, err) {
+		return
+	}
+
+	_, err = chartutil.ParseValuesMigrations(data)
+	linter.RunLinterRule(support.ErrorSev, file, err)
+}
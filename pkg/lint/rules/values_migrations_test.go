@@ -0,0 +1,46 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v4/internal/test/ensure"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+func TestValuesMigrations(t *testing.T) {
+	linter := support.Linter{ChartDir: t.TempDir()}
+	ValuesMigrations(&linter)
+	assert.Empty(t, linter.Messages, "a chart without migrations is valid")
+
+	valid := "migrations:\n- fromVersion: \"<2.0.0\"\n  operations:\n  - op: rename\n    from: a\n    to: b\n"
+	linter = support.Linter{ChartDir: ensure.TempFile(t, "values-migrations.yaml", []byte(valid))}
+	ValuesMigrations(&linter)
+	assert.Empty(t, linter.Messages)
+
+	invalid := "migrations:\n- fromVersion: nope\n  operations:\n  - op: copy\n"
+	linter = support.Linter{ChartDir: ensure.TempFile(t, "values-migrations.yaml", []byte(invalid))}
+	ValuesMigrations(&linter)
+	if assert.Len(t, linter.Messages, 1) {
+		assert.Equal(t, support.ErrorSev, linter.Messages[0].Severity)
+		assert.ErrorContains(t, linter.Messages[0].Err, `migrations[0].fromVersion: "nope" is not a valid SemVer constraint`)
+		assert.ErrorContains(t, linter.Messages[0].Err, `migrations[0].operations[0]: unknown op "copy"`)
+	}
+}
@@ -159,6 +159,97 @@ func TestValidateValuesFile(t *testing.T) {
 	}
 }
 
+func TestValidateValuesFileSchemaYAML(t *testing.T) {
+	tmpdir := ensure.TempFile(t, "values.yaml", []byte("username: 1234\npassword: swordfish"))
+	schema := "type: object\nproperties:\n  username:\n    type: string\n"
+	if err := os.WriteFile(filepath.Join(tmpdir, "values.schema.yaml"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := validateValuesFile(filepath.Join(tmpdir, "values.yaml"), map[string]interface{}{})
+	assert.ErrorContains(t, err, "- at '/username': got number, want string")
+}
+
+func TestValidateValuesFileSchemaYAMLReadError(t *testing.T) {
+	tmpdir := ensure.TempFile(t, "values.yaml", []byte("username: admin\npassword: swordfish"))
+	if err := os.Mkdir(filepath.Join(tmpdir, "values.schema.yaml"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := validateValuesFile(filepath.Join(tmpdir, "values.yaml"), map[string]interface{}{})
+	assert.ErrorContains(t, err, "is a directory")
+}
+
+func TestValidateSchemaYAMLFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		schema       string
+		errorMessage string
+	}{
+		{
+			name:   "valid schema",
+			schema: "$schema: http://json-schema.org/draft-07/schema#\ntype: object\nrequired: [username]\n",
+		},
+		{
+			name:         "invalid YAML",
+			schema:       "type: [object",
+			errorMessage: "unable to parse YAML",
+		},
+		{
+			name:         "invalid JSON Schema",
+			schema:       "$schema: http://json-schema.org/draft-07/schema#\ntype: objects\n",
+			errorMessage: "invalid JSON Schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir := ensure.TempFile(t, "values.schema.yaml", []byte(tt.schema))
+			err := validateSchemaYAMLFile(filepath.Join(tmpdir, "values.schema.yaml"))
+			if tt.errorMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorMessage)
+			}
+		})
+	}
+
+	assert.NoError(t, validateSchemaYAMLFile(filepath.Join(t.TempDir(), "values.schema.yaml")))
+}
+
+func TestValidateSchemaFilesMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		schemaYAML   string
+		errorMessage string
+	}{
+		{
+			name:       "same schema",
+			schemaYAML: "$schema: http://json-schema.org/draft-07/schema#\ntitle: helm values test schema\ntype: object\nadditionalProperties: false\nrequired: [username, password]\nproperties:\n  username:\n    description: Your username\n    type: string\n  password:\n    description: Your password\n    type: string\n",
+		},
+		{
+			name:         "different schema",
+			schemaYAML:   "type: object\n",
+			errorMessage: "values.schema.yaml and values.schema.json define different schemas",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir := ensure.TempFile(t, "values.schema.yaml", []byte(tt.schemaYAML))
+			createTestingSchema(t, tmpdir)
+			err := validateSchemaFilesMatch(tmpdir)
+			if tt.errorMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorMessage)
+			}
+		})
+	}
+
+	assert.NoError(t, validateSchemaFilesMatch(ensure.TempFile(t, "values.schema.yaml", []byte("type: object\n"))))
+}
+
 func createTestingSchema(t *testing.T, dir string) string {
 	t.Helper()
 	schemafile := filepath.Join(dir, "values.schema.json")
@@ -95,6 +95,11 @@ type Metadata struct {
 	// DEPRECATED: Use PlatformCommand instead. Remove in Helm 4.
 	Command string `json:"command"`
 
+	// ParentCommand, if set, mounts the plugin as a subcommand of this Helm
+	// command instead of as a top-level command, e.g. "get" for a plugin
+	// invoked as `helm get cost RELEASE`. Only some commands may be extended.
+	ParentCommand string `json:"parentCommand"`
+
 	// IgnoreFlags ignores any flags passed in from Helm
 	//
 	// For example, if the plugin is invoked as `helm --debug myplugin`, if this
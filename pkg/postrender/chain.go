@@ -0,0 +1,54 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type chain []PostRenderer
+
+// Chain returns a PostRenderer that passes the rendered manifests through each
+// of the renderers in order, each one receiving the output of the previous
+// one. It stops at the first renderer returning an error. Nil renderers are
+// skipped.
+func Chain(renderers ...PostRenderer) PostRenderer {
+	c := make(chain, 0, len(renderers))
+	for _, r := range renderers {
+		if r != nil {
+			c = append(c, r)
+		}
+	}
+	return c
+}
+
+// Run the renderers of the chain in order
+func (c chain) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	manifests := renderedManifests
+	for i, r := range c {
+		modified, err := r.Run(manifests)
+		if err != nil {
+			return nil, fmt.Errorf("post-renderer %d (%T) of the chain failed: %w", i, r, err)
+		}
+		if modified == nil {
+			return nil, fmt.Errorf("post-renderer %d (%T) of the chain produced no output", i, r)
+		}
+		manifests = modified
+	}
+	return manifests, nil
+}
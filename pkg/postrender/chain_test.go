@@ -0,0 +1,70 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replacer struct {
+	old, new string
+	calls    *int
+}
+
+func (r replacer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	*r.calls++
+	return bytes.NewBufferString(strings.ReplaceAll(renderedManifests.String(), r.old, r.new)), nil
+}
+
+type failing struct{}
+
+func (failing) Run(*bytes.Buffer) (*bytes.Buffer, error) {
+	return nil, errors.New("boom")
+}
+
+func TestChain(t *testing.T) {
+	t.Run("renderers run in order", func(t *testing.T) {
+		var calls int
+		c := Chain(replacer{"FOO", "BAR", &calls}, nil, replacer{"BAR", "BAZ", &calls})
+
+		out, err := c.Run(bytes.NewBufferString("name: FOO"))
+		require.NoError(t, err)
+		assert.Equal(t, "name: BAZ", out.String())
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("an empty chain returns the manifests", func(t *testing.T) {
+		out, err := Chain().Run(bytes.NewBufferString("name: FOO"))
+		require.NoError(t, err)
+		assert.Equal(t, "name: FOO", out.String())
+	})
+
+	t.Run("the chain stops at the first error", func(t *testing.T) {
+		var calls int
+		c := Chain(replacer{"FOO", "BAR", &calls}, failing{}, replacer{"BAR", "BAZ", &calls})
+
+		_, err := c.Run(bytes.NewBufferString("name: FOO"))
+		assert.EqualError(t, err, "post-renderer 1 (postrender.failing) of the chain failed: boom")
+		assert.Equal(t, 1, calls)
+	})
+}
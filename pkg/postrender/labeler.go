@@ -0,0 +1,63 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+type labeler struct {
+	labels map[string]string
+}
+
+// NewLabeler returns a PostRenderer that sets the labels in the metadata of
+// every manifest, overriding the values of labels already set. The manifests
+// keep their order and comments, and metadata.labels is added to the manifests
+// without labels.
+func NewLabeler(labels map[string]string) PostRenderer {
+	return &labeler{labels: maps.Clone(labels)}
+}
+
+// Run sets the labels on the rendered manifests
+func (l *labeler) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	manifests, err := kio.ParseAll(renderedManifests.String())
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the rendered manifests: %w", err)
+	}
+
+	// Sorting the keys keeps the labels added in a predictable order.
+	keys := slices.Sorted(maps.Keys(l.labels))
+	for i, manifest := range manifests {
+		for _, k := range keys {
+			if err := manifest.PipeE(kyaml.SetLabel(k, l.labels[k])); err != nil {
+				return nil, fmt.Errorf("error setting label %q on manifest %d: %w", k, i, err)
+			}
+		}
+	}
+
+	labeled, err := kio.StringAll(manifests)
+	if err != nil {
+		return nil, fmt.Errorf("error writing the labeled manifests: %w", err)
+	}
+	return bytes.NewBufferString(labeled), nil
+}
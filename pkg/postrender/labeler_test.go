@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const labelerManifests = `# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  labels:
+    app: web # the name of the app
+    team: old
+data:
+  key: value
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+`
+
+func TestLabeler(t *testing.T) {
+	l := NewLabeler(map[string]string{"team": "platform", "env": "prod"})
+
+	out, err := l.Run(bytes.NewBufferString(labelerManifests))
+	require.NoError(t, err)
+	// The labels added are quoted, so that values such as "true" stay strings.
+	assert.Equal(t, `# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  labels:
+    app: web # the name of the app
+    team: platform
+    env: 'prod'
+data:
+  key: value
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+  labels:
+    env: 'prod'
+    team: 'platform'
+spec:
+  group: example.com
+`, out.String())
+}
+
+func TestLabelerInvalidManifests(t *testing.T) {
+	_, err := NewLabeler(map[string]string{"team": "platform"}).Run(bytes.NewBufferString("kind: [ConfigMap"))
+	assert.ErrorContains(t, err, "error parsing the rendered manifests")
+}
+
+func TestChainWithLabelers(t *testing.T) {
+	c := Chain(NewLabeler(map[string]string{"team": "platform"}), NewLabeler(map[string]string{"team": "web"}))
+
+	out, err := c.Run(bytes.NewBufferString("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n  labels:\n    team: 'web'\n", out.String())
+}
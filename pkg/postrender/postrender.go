@@ -15,8 +15,8 @@ limitations under the License.
 */
 
 // Package postrender contains an interface that can be implemented for custom
-// post-renderers and an exec implementation that can be used for arbitrary
-// binaries and scripts
+// post-renderers, an exec implementation that can be used for arbitrary
+// binaries and scripts, and in-process implementations which can be chained
 package postrender
 
 import "bytes"
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/openpgp"           //nolint
 	"golang.org/x/crypto/openpgp/clearsign" //nolint
@@ -78,6 +79,10 @@ type Signatory struct {
 	Entity *openpgp.Entity
 	// The keyring for this instance of Helm. This is used for verification.
 	KeyRing openpgp.EntityList
+	// SignatureTime, if set, is the creation time recorded in the signatures,
+	// rather than the time they are made. With an RSA key, signing the same
+	// archive at the same time produces the same signature.
+	SignatureTime time.Time
 }
 
 // NewFromFiles constructs a new Signatory from the PGP key in the given filename.
@@ -224,7 +229,11 @@ func (s *Signatory) ClearSign(chartpath string) (string, error) {
 	}
 
 	// Sign the buffer
-	w, err := clearsign.Encode(out, s.Entity.PrivateKey, &defaultPGPConfig)
+	config := defaultPGPConfig
+	if !s.SignatureTime.IsZero() {
+		config.Time = func() time.Time { return s.SignatureTime }
+	}
+	w, err := clearsign.Encode(out, s.Entity.PrivateKey, &config)
 	if err != nil {
 		return "", err
 	}
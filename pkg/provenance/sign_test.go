@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	pgperrors "golang.org/x/crypto/openpgp/errors" //nolint
 )
@@ -232,6 +233,44 @@ func TestClearSign(t *testing.T) {
 	}
 }
 
+func TestClearSignWithSignatureTime(t *testing.T) {
+	signer, err := NewFromFiles(testKeyfile, testPubfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.SignatureTime = time.Unix(1700000000, 0)
+
+	first, err := signer.ClearSign(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := signer.ClearSign(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected the signatures made with the same signature time to be identical:\n%s\n%s", first, second)
+	}
+
+	ver, err := signer.Verify(testChartfile, writeSignature(t, first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver.FileHash == "" {
+		t.Error("expected the signature to verify the chart")
+	}
+}
+
+func writeSignature(t *testing.T, sig string) string {
+	t.Helper()
+	dir := t.TempDir()
+	name := filepath.Join(dir, "hashtest-1.2.3.tgz.prov")
+	if err := os.WriteFile(name, []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
 // failSigner always fails to sign and returns an error
 type failSigner struct{}
 
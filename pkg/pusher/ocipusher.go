@@ -83,12 +83,31 @@ func (pusher *OCIPusher) push(chartRef, href string) error {
 		if err != nil {
 			return err
 		}
-		pushOpts = append(pushOpts, registry.PushOptProvData(provBytes))
+		pushOpts = append(pushOpts, registry.PushOptWithProvenance(provBytes))
+	} else if pusher.opts.withProvenance {
+		return fmt.Errorf("provenance file %s not found: %w", provRef, err)
 	}
 
+	// The chart is tagged with its version unless another tag is given, in
+	// which case its version is only recorded in the config of the artifact.
+	tag := meta.Metadata.Version
+	if pusher.opts.tag != "" {
+		tag = pusher.opts.tag
+		pushOpts = append(pushOpts, registry.PushOptStrictMode(false))
+	}
 	ref := fmt.Sprintf("%s:%s",
 		path.Join(strings.TrimPrefix(href, fmt.Sprintf("%s://", registry.OCIScheme)), meta.Metadata.Name),
-		meta.Metadata.Version)
+		tag)
+
+	if !pusher.opts.force {
+		exists, err := client.TagExists(ref)
+		if err != nil {
+			return fmt.Errorf("unable to check whether %s exists: %w", ref, err)
+		}
+		if exists {
+			return fmt.Errorf("%s: %w", ref, registry.ErrTagAlreadyExists)
+		}
+	}
 
 	// The time the chart was "created" is semantically the time the chart archive file was last written(modified)
 	chartArchiveFileCreatedTime := ctime.Modified(stat)
@@ -105,6 +105,7 @@ func TestOCIPusher_Push_ErrorHandling(t *testing.T) {
 		name          string
 		chartRef      string
 		expectedError string
+		options       []Option
 		setupFunc     func() string
 	}{
 		{
@@ -120,6 +121,12 @@ func TestOCIPusher_Push_ErrorHandling(t *testing.T) {
 				return tempDir
 			},
 		},
+		{
+			name:          "missing provenance file",
+			chartRef:      "../../pkg/cmd/testdata/testcharts/compressedchart-0.1.0.tgz",
+			expectedError: "provenance file ../../pkg/cmd/testdata/testcharts/compressedchart-0.1.0.tgz.prov not found",
+			options:       []Option{WithProvenance(true)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -134,7 +141,7 @@ func TestOCIPusher_Push_ErrorHandling(t *testing.T) {
 				chartRef = tt.setupFunc()
 			}
 
-			err = pusher.Push(chartRef, "oci://localhost:5000/test")
+			err = pusher.Push(chartRef, "oci://localhost:5000/test", tt.options...)
 			if err == nil {
 				t.Fatal("Expected error but got none")
 			}
@@ -34,6 +34,9 @@ type options struct {
 	caFile                string
 	insecureSkipTLSverify bool
 	plainHTTP             bool
+	withProvenance        bool
+	tag                   string
+	force                 bool
 }
 
 // Option allows specifying various settings configurable by the user for overriding the defaults
@@ -69,6 +72,27 @@ func WithPlainHTTP(plainHTTP bool) Option {
 	}
 }
 
+// WithProvenance requires the chart's provenance file to be pushed alongside it.
+func WithProvenance(withProvenance bool) Option {
+	return func(opts *options) {
+		opts.withProvenance = withProvenance
+	}
+}
+
+// WithTag sets the tag the chart is pushed to, instead of its version.
+func WithTag(tag string) Option {
+	return func(opts *options) {
+		opts.tag = tag
+	}
+}
+
+// WithForce allows pushing the chart to a tag which already exists.
+func WithForce(force bool) Option {
+	return func(opts *options) {
+		opts.force = force
+	}
+}
+
 // Pusher is an interface to support upload to the specified URL.
 type Pusher interface {
 	// Push file content by url string
@@ -37,6 +37,7 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -77,7 +78,15 @@ type (
 		credentialsStore   credentials.Store
 		httpClient         *http.Client
 		plainHTTP          bool
-		err                error // pass any errors from the ClientOption functions
+		// name of the docker credential helper forced with
+		// ClientOptCredentialHelper
+		credentialHelper string
+		credentialsMu    sync.Mutex
+		credentials      map[string]auth.Credential
+		// mirrors maps the locations of references pulled to the locations
+		// they are pulled from instead, see ClientOptMirrors
+		mirrors map[string]string
+		err     error // pass any errors from the ClientOption functions
 	}
 
 	// ClientOption allows specifying various settings configurable by the user for overriding the defaults
@@ -128,7 +137,7 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		}
 		authorizer.SetUserAgent(version.GetUserAgent())
 
-		authorizer.Credential = credentials.Credential(client.credentialsStore)
+		authorizer.Credential = client.credential
 
 		if client.enableCache {
 			authorizer.Cache = auth.NewCache()
@@ -162,6 +171,28 @@ func ClientOptBasicAuth(username, password string) ClientOption {
 	}
 }
 
+// authClient returns the authorizer of c for an operation on the registry at
+// host. The credentials set with ClientOptBasicAuth are only sent to that
+// registry, not to the other hosts it may redirect to.
+func (c *Client) authClient(host string) *auth.Client {
+	if c.username == "" || c.password == "" {
+		return c.authorizer
+	}
+	scoped := *c.authorizer
+	basic := auth.StaticCredential(host, auth.Credential{Username: c.username, Password: c.password})
+	fallback := c.authorizer.Credential
+	scoped.Credential = func(ctx context.Context, hostport string) (auth.Credential, error) {
+		if cred, err := basic(ctx, hostport); err == nil && cred != auth.EmptyCredential {
+			return cred, nil
+		}
+		if fallback == nil {
+			return auth.EmptyCredential, nil
+		}
+		return fallback(ctx, hostport)
+	}
+	return &scoped
+}
+
 // ClientOptWriter returns a function that sets the writer setting on client options set
 func ClientOptWriter(out io.Writer) ClientOption {
 	return func(client *Client) {
@@ -209,20 +240,43 @@ func ClientOptPlainHTTP() ClientOption {
 	}
 }
 
+// ClientOptMirrors returns a function that sets the mirrors charts are pulled
+// from. Each key is a registry host, optionally followed by a repository path
+// prefix, as "registry-1.docker.io/bitnamicharts", and its value the host and
+// path prefix replacing it in the references pulled, located or resolved.
+// Pushes are not affected.
+//
+// Only a reference pinned by digest is verified to be served the same chart by
+// the mirror. A reference by tag is trusted to the mirror, and pulling it warns
+// about it.
+func ClientOptMirrors(mirrors map[string]string) ClientOption {
+	return func(c *Client) {
+		c.mirrors = make(map[string]string, len(mirrors))
+		for from, to := range mirrors {
+			c.mirrors[trimMirrorLocation(from)] = trimMirrorLocation(to)
+		}
+	}
+}
+
 type (
 	// LoginOption allows specifying various settings on login
 	LoginOption func(*loginOperation)
 
 	loginOperation struct {
-		host   string
-		client *Client
+		host             string
+		client           *Client
+		credentialHelper string
 	}
 )
 
 // Login logs into a registry
 func (c *Client) Login(host string, options ...LoginOption) error {
+	operation := &loginOperation{host: host, client: c}
 	for _, option := range options {
-		option(&loginOperation{host, c})
+		option(operation)
+	}
+	if operation.credentialHelper != "" {
+		return c.loginWithCredentialHelper(host, operation.credentialHelper)
 	}
 
 	reg, err := remote.NewRegistry(host)
@@ -252,6 +306,48 @@ func (c *Client) Login(host string, options ...LoginOption) error {
 	return nil
 }
 
+// loginWithCredentialHelper checks that the credentials given by the docker
+// credential helper docker-credential-<name> give access to the registry at
+// host. They are not stored, as helpers usually return short-lived tokens:
+// the helper is run again when the registry is accessed.
+func (c *Client) loginWithCredentialHelper(host, name string) error {
+	ctx := context.Background()
+	cred, err := helperCredential(ctx, name, host)
+	if err != nil {
+		return fmt.Errorf("getting the credentials of %q from docker-credential-%s: %w", host, name, err)
+	}
+
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return err
+	}
+	reg.PlainHTTP = c.plainHTTP
+	// The credentials are only used to check the access to host, with a copy
+	// of the authorizer, so that the other registries are not sent them.
+	authorizer := *c.authorizer
+	authorizer.Credential = auth.StaticCredential(reg.Reference.Registry, cred)
+	authorizer.ForceAttemptOAuth2 = true
+	reg.Client = &authorizer
+	if err := reg.Ping(ctx); err != nil {
+		authorizer.ForceAttemptOAuth2 = false
+		if err := reg.Ping(ctx); err != nil {
+			return fmt.Errorf("authenticating to %q: %w", host, err)
+		}
+	}
+
+	fmt.Fprintf(c.out, "Login Succeeded, with the credentials of docker-credential-%s\n", name)
+	return nil
+}
+
+// LoginOptCredentialHelper returns a function that logs in with the credentials
+// of the docker credential helper docker-credential-<name> rather than a
+// username and password.
+func LoginOptCredentialHelper(name string) LoginOption {
+	return func(o *loginOperation) {
+		o.credentialHelper = name
+	}
+}
+
 // LoginOptBasicAuth returns a function that sets the username/password settings on login
 func LoginOptBasicAuth(username string, password string) LoginOption {
 	return func(o *loginOperation) {
@@ -398,11 +494,20 @@ type (
 )
 
 // Pull downloads a chart from a registry
+//
+// A reference matching a mirror set with ClientOptMirrors is pulled from the
+// mirror. When it is pinned by digest, the manifest the mirror serves must have
+// that digest. Otherwise the chart the mirror serves cannot be verified, which
+// is warned about.
 func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
+	ref, mirrored := c.Mirror(ref)
 	parsedRef, err := newReference(ref)
 	if err != nil {
 		return nil, err
 	}
+	if mirrored && parsedRef.Digest == "" {
+		fmt.Fprintf(c.out, "Warning: %s is pulled from a mirror without being pinned by digest, the chart it serves cannot be verified\n", parsedRef.String())
+	}
 
 	operation := &pullOperation{
 		withChart: true, // By default, always download the chart layer
@@ -438,7 +543,7 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 		return nil, err
 	}
 	repository.PlainHTTP = c.plainHTTP
-	repository.Client = c.authorizer
+	repository.Client = c.authClient(repository.Reference.Registry)
 
 	ctx := context.Background()
 
@@ -463,6 +568,9 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	if parsedRef.Digest != "" && manifest.Digest.String() != parsedRef.Digest {
+		return nil, fmt.Errorf("manifest digest %s of %s does not match the digest it is pinned by", manifest.Digest, parsedRef.String())
+	}
 
 	descriptors = append(descriptors, layers...)
 
@@ -683,7 +791,7 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 		return nil, err
 	}
 	repository.PlainHTTP = c.plainHTTP
-	repository.Client = c.authorizer
+	repository.Client = c.authClient(repository.Reference.Registry)
 
 	manifestDescriptor, err = oras.ExtendedCopy(ctx, memoryStore, parsedRef.String(), repository, parsedRef.String(), oras.DefaultExtendedCopyOptions)
 	if err != nil {
@@ -724,13 +832,22 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 	return result, err
 }
 
-// PushOptProvData returns a function that sets the prov bytes setting on push
-func PushOptProvData(provData []byte) PushOption {
+// PushOptWithProvenance returns a function that sets the provenance file to
+// push alongside the chart. It is stored as an additional layer with the
+// ProvLayerMediaType media type.
+func PushOptWithProvenance(provData []byte) PushOption {
 	return func(operation *pushOperation) {
 		operation.provData = provData
 	}
 }
 
+// PushOptProvData returns a function that sets the prov bytes setting on push
+//
+// Deprecated: use PushOptWithProvenance.
+func PushOptProvData(provData []byte) PushOption {
+	return PushOptWithProvenance(provData)
+}
+
 // PushOptStrictMode returns a function that sets the strictMode setting on push
 func PushOptStrictMode(strictMode bool) PushOption {
 	return func(operation *pushOperation) {
@@ -747,6 +864,7 @@ func PushOptCreationTime(creationTime string) PushOption {
 
 // Tags provides a sorted list all semver compliant tags for a given repository
 func (c *Client) Tags(ref string) ([]string, error) {
+	ref, _ = c.Mirror(ref)
 	parsedReference, err := registry.ParseReference(ref)
 	if err != nil {
 		return nil, err
@@ -758,7 +876,7 @@ func (c *Client) Tags(ref string) ([]string, error) {
 		return nil, err
 	}
 	repository.PlainHTTP = c.plainHTTP
-	repository.Client = c.authorizer
+	repository.Client = c.authClient(repository.Reference.Registry)
 
 	var tagVersions []*semver.Version
 	err = repository.Tags(ctx, "", func(tags []string) error {
@@ -790,8 +908,74 @@ func (c *Client) Tags(ref string) ([]string, error) {
 
 }
 
+// Repositories provides a sorted list of the repositories stored directly
+// under a namespace of a registry, given as HOST/NAMESPACE, using the catalog
+// API of the registry.
+func (c *Client) Repositories(namespace string) ([]string, error) {
+	host, prefix, _ := strings.Cut(namespace, "/")
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return nil, err
+	}
+	reg.PlainHTTP = c.plainHTTP
+	reg.Client = c.authClient(reg.Reference.Registry)
+
+	var repositories []string
+	err = reg.Repositories(context.Background(), "", func(repos []string) error {
+		for _, repo := range repos {
+			name, ok := strings.CutPrefix(repo, prefix)
+			if ok && name != "" && !strings.Contains(name, "/") {
+				repositories = append(repositories, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(repositories)
+	return repositories, nil
+}
+
+// ErrTagAlreadyExists is returned when pushing a chart to a tag which already
+// exists in the repository, which registries with immutable tags refuse.
+var ErrTagAlreadyExists = errors.New("tag already exists")
+
+// TagExists reports whether the tag of ref exists in its repository.
+func (c *Client) TagExists(ref string) (bool, error) {
+	parsedRef, err := newReference(ref)
+	if err != nil {
+		return false, err
+	}
+	if parsedRef.Tag == "" {
+		return false, fmt.Errorf("reference %s has no tag", ref)
+	}
+
+	repository, err := remote.NewRepository(parsedRef.String())
+	if err != nil {
+		return false, err
+	}
+	repository.PlainHTTP = c.plainHTTP
+	repository.Client = c.authClient(repository.Reference.Registry)
+
+	_, err = repository.Resolve(context.Background(), parsedRef.Tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // Resolve a reference to a descriptor.
 func (c *Client) Resolve(ref string) (desc ocispec.Descriptor, err error) {
+	ref, _ = c.Mirror(ref)
 	remoteRepository, err := remote.NewRepository(ref)
 	if err != nil {
 		return desc, err
@@ -809,6 +993,10 @@ func (c *Client) Resolve(ref string) (desc ocispec.Descriptor, err error) {
 }
 
 // ValidateReference for path and version
+//
+// A reference pinned by digest, as "oci://registry/repo/chart@sha256:...", is
+// pulled by its digest: the tag it may have is not resolved, and no version
+// may be given with it.
 func (c *Client) ValidateReference(ref, version string, u *url.URL) (*url.URL, error) {
 	var tag string
 
@@ -817,6 +1005,19 @@ func (c *Client) ValidateReference(ref, version string, u *url.URL) (*url.URL, e
 		return nil, err
 	}
 
+	if registryReference.Digest != "" {
+		if version != "" {
+			return nil, fmt.Errorf("chart reference %s is pinned by digest, a version cannot be given with it", ref)
+		}
+		pinned := registryReference.orasReference
+		pinned.Reference = registryReference.Digest
+		if err := pinned.ValidateReferenceAsDigest(); err != nil {
+			return nil, fmt.Errorf("invalid chart reference digest: %w", err)
+		}
+		u.Path = fmt.Sprintf("%s@%s", registryReference.Repository, registryReference.Digest)
+		return u, nil
+	}
+
 	if version == "" {
 		// Use OCI URI tag as default
 		version = registryReference.Tag
@@ -826,26 +1027,6 @@ func (c *Client) ValidateReference(ref, version string, u *url.URL) (*url.URL, e
 		}
 	}
 
-	if registryReference.Digest != "" {
-		if version == "" {
-			// Install by digest only
-			return u, nil
-		}
-		u.Path = fmt.Sprintf("%s@%s", registryReference.Repository, registryReference.Digest)
-
-		// Validate the tag if it was specified
-		path := registryReference.Registry + "/" + registryReference.Repository + ":" + version
-		desc, err := c.Resolve(path)
-		if err != nil {
-			// The resource does not have to be tagged when digest is specified
-			return u, nil
-		}
-		if desc.Digest.String() != registryReference.Digest {
-			return nil, fmt.Errorf("chart reference digest mismatch: %s is not %s", desc.Digest.String(), registryReference.Digest)
-		}
-		return u, nil
-	}
-
 	// Evaluate whether an explicit version has been provided. Otherwise, determine version to use
 	_, errSemVer := semver.NewVersion(version)
 	if errSemVer == nil {
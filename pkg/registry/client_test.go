@@ -18,6 +18,7 @@ package registry
 
 import (
 	"io"
+	"path/filepath"
 	"testing"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -51,3 +52,29 @@ func TestTagManifestTransformsReferences(t *testing.T) {
 	_, err = memStore.Resolve(ctx, refWithPlus)
 	require.Error(t, err, "Should NOT find the reference with the original +")
 }
+
+func TestClientBasicAuthCredential(t *testing.T) {
+	credentialsFile := filepath.Join(t.TempDir(), "config.json")
+
+	client, err := NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptBasicAuth("user", "pass"))
+	require.NoError(t, err)
+	cred, err := client.authClient("registry.example.com").Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user", cred.Username)
+	require.Equal(t, "pass", cred.Password)
+
+	// The credentials are not sent to the other hosts, as the ones blobs are
+	// redirected to.
+	cred, err = client.authClient("registry.example.com").Credential(t.Context(), "blobs.example.com")
+	require.NoError(t, err)
+	require.Empty(t, cred.Username)
+	cred, err = client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Empty(t, cred.Username)
+
+	client, err = NewClient(ClientOptCredentialsFile(credentialsFile))
+	require.NoError(t, err)
+	cred, err = client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Empty(t, cred.Username)
+}
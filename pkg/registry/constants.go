@@ -34,4 +34,12 @@ const (
 
 	// LegacyChartLayerMediaType is the legacy reserved media type for Helm chart package content.
 	LegacyChartLayerMediaType = "application/tar+gzip"
+
+	// ChartDigestAnnotation is the chart annotation recording the manifest
+	// digest a chart was pulled by, when its reference was pinned by digest.
+	ChartDigestAnnotation = "helm.sh/oci-manifest-digest"
+
+	// ChartMirroredFromAnnotation is the chart annotation recording the OCI
+	// reference a chart was requested by, when it was pulled from a mirror.
+	ChartMirroredFromAnnotation = "helm.sh/oci-mirrored-from"
 )
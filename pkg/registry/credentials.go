@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v4/pkg/registry"
+
+import (
+	"context"
+	"log/slog"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// ClientOptCredentialHelper returns a function that sets the docker credential
+// helper, docker-credential-<name>, to get the credentials of every registry
+// from, ahead of the stored credentials.
+func ClientOptCredentialHelper(name string) ClientOption {
+	return func(client *Client) {
+		client.credentialHelper = name
+	}
+}
+
+// credential returns the credentials to access the registry at hostport.
+//
+// They come from the credential helper of the client if one is set, then from
+// the Helm registry config and the docker config, which may in turn run the
+// helpers set with credHelpers or credsStore. The credentials found for a host
+// are kept for the lifetime of the client, as helpers may have to fetch a
+// token to return them. Hosts without credentials, or whose lookup failed, are
+// looked up again the next time they are accessed.
+//
+// Failures getting credentials are logged, and the registry is accessed
+// anonymously.
+func (c *Client) credential(ctx context.Context, hostport string) (auth.Credential, error) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+
+	if cred, ok := c.credentials[hostport]; ok {
+		return cred, nil
+	}
+	cred := c.lookupCredential(ctx, hostport)
+	if cred == auth.EmptyCredential {
+		return cred, nil
+	}
+	if c.credentials == nil {
+		c.credentials = make(map[string]auth.Credential)
+	}
+	c.credentials[hostport] = cred
+	return cred, nil
+}
+
+// lookupCredential returns the credentials of hostport, or
+// auth.EmptyCredential if there are none or they could not be got.
+func (c *Client) lookupCredential(ctx context.Context, hostport string) auth.Credential {
+	if c.credentialHelper != "" {
+		cred, err := helperCredential(ctx, c.credentialHelper, hostport)
+		if err != nil {
+			slog.Warn("failed to get credentials from the credential helper",
+				"helper", "docker-credential-"+c.credentialHelper, "host", hostport, slog.Any("error", err))
+		} else if cred != auth.EmptyCredential {
+			return cred
+		}
+	}
+
+	cred, err := credentials.Credential(c.credentialsStore)(ctx, hostport)
+	if err != nil {
+		slog.Warn("failed to get stored credentials, continuing without them", "host", hostport, slog.Any("error", err))
+		return auth.EmptyCredential
+	}
+	return cred
+}
+
+// helperCredential runs the docker credential helper docker-credential-<name>
+// to get the credentials of the registry at hostport.
+func helperCredential(ctx context.Context, name, hostport string) (auth.Credential, error) {
+	return credentials.Credential(credentials.NewNativeStore(name))(ctx, hostport)
+}
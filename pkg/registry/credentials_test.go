@@ -0,0 +1,187 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+const fakeHelperScript = `#!/bin/sh
+read host
+echo "$host" >> "%s"
+echo '{"ServerURL":"'$host'","Username":"helper-user","Secret":"helper-secret"}'
+`
+
+const failingHelperScript = `#!/bin/sh
+read host
+echo "$host" >> "%s"
+echo "token expired" >&2
+exit 1
+`
+
+// installHelper puts the docker credential helper docker-credential-<name>
+// running script in a temporary directory at the front of $PATH, and points
+// $DOCKER_CONFIG to an empty directory. It returns the file the fake helper
+// appends the hosts it is run for to.
+func installHelper(t *testing.T, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake credential helpers are shell scripts")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	helper := filepath.Join(dir, "docker-credential-"+name)
+	if err := os.WriteFile(helper, []byte(strings.ReplaceAll(script, "%s", calls)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	return calls
+}
+
+func helperCalls(t *testing.T, calls string) []string {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return strings.Fields(string(data))
+}
+
+func TestClientCredentialHelper(t *testing.T) {
+	calls := installHelper(t, "fake", fakeHelperScript)
+
+	client, err := NewClient(
+		ClientOptCredentialsFile(filepath.Join(t.TempDir(), "config.json")),
+		ClientOptCredentialHelper("fake"),
+	)
+	require.NoError(t, err)
+
+	for range 2 {
+		cred, err := client.authorizer.Credential(t.Context(), "registry.example.com")
+		require.NoError(t, err)
+		require.Equal(t, auth.Credential{Username: "helper-user", Password: "helper-secret"}, cred)
+	}
+	require.Equal(t, []string{"registry.example.com"}, helperCalls(t, calls), "the credentials should be cached per host")
+
+	_, err = client.authorizer.Credential(t.Context(), "other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"registry.example.com", "other.example.com"}, helperCalls(t, calls))
+}
+
+func TestClientCredentialHelperFromDockerConfig(t *testing.T) {
+	calls := installHelper(t, "fake", fakeHelperScript)
+	config := `{"credHelpers": {"registry.example.com": "fake"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"), []byte(config), 0600))
+
+	client, err := NewClient(ClientOptCredentialsFile(filepath.Join(t.TempDir(), "config.json")))
+	require.NoError(t, err)
+
+	cred, err := client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "helper-user", cred.Username)
+
+	cred, err = client.authorizer.Credential(t.Context(), "other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.EmptyCredential, cred)
+	require.Equal(t, []string{"registry.example.com"}, helperCalls(t, calls))
+
+	_, err = client.authorizer.Credential(t.Context(), "other.example.com")
+	require.NoError(t, err)
+	_, err = client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"registry.example.com"}, helperCalls(t, calls), "the credentials found should be cached")
+}
+
+func TestClientCredentialHelperFailure(t *testing.T) {
+	calls := installHelper(t, "broken", failingHelperScript)
+	credentialsFile := filepath.Join(t.TempDir(), "config.json")
+	stored := `{"auths": {"registry.example.com": {"auth": "c3RvcmVkOnBhc3M="}}}`
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(stored), 0600))
+
+	client, err := NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptCredentialHelper("broken"))
+	require.NoError(t, err)
+
+	// The stored credentials are used instead.
+	cred, err := client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.Credential{Username: "stored", Password: "pass"}, cred)
+
+	// Helpers configured in the docker config which fail leave the registry
+	// accessed anonymously.
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"), []byte(`{"credsStore": "broken"}`), 0600))
+	client, err = NewClient(ClientOptCredentialsFile(filepath.Join(t.TempDir(), "config.json")))
+	require.NoError(t, err)
+	cred, err = client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.EmptyCredential, cred)
+
+	// The failures are not cached, the helper is run again.
+	cred, err = client.authorizer.Credential(t.Context(), "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.EmptyCredential, cred)
+	require.Equal(t, []string{"registry.example.com", "registry.example.com", "registry.example.com"}, helperCalls(t, calls))
+}
+
+func TestLoginCredentialHelper(t *testing.T) {
+	installHelper(t, "fake", fakeHelperScript)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "helper-user" || pass != "helper-secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	credentialsFile := filepath.Join(t.TempDir(), "config.json")
+	var out bytes.Buffer
+	client, err := NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptWriter(&out))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Login(u.Host, LoginOptPlainText(true), LoginOptCredentialHelper("fake")))
+	require.Contains(t, out.String(), "Login Succeeded")
+	_, err = os.Stat(credentialsFile)
+	require.True(t, os.IsNotExist(err), "the credentials of the helper should not be stored")
+	cred, err := client.authorizer.Credential(t.Context(), "other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.EmptyCredential, cred, "the credentials of the helper should not be sent to other registries")
+
+	installHelper(t, "broken", failingHelperScript)
+	out.Reset()
+	client, err = NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptWriter(&out))
+	require.NoError(t, err)
+	err = client.Login(u.Host, LoginOptPlainText(true), LoginOptCredentialHelper("broken"))
+	require.ErrorContains(t, err, "from docker-credential-broken")
+	require.NotContains(t, out.String(), "Login Succeeded")
+}
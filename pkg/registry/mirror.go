@@ -0,0 +1,98 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v4/pkg/registry"
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// MirrorsFile is the file mapping registry locations to their mirrors, read
+// by the CLI. It has the form:
+//
+//	mirrors:
+//	  registry-1.docker.io: mirror.example.com:5000/docker
+//	  ghcr.io/org/charts: mirror.example.com:5000/org
+type MirrorsFile struct {
+	Mirrors map[string]string `json:"mirrors"`
+}
+
+// LoadMirrors reads the mirrors of a mirrors file. A missing file has no
+// mirrors.
+func LoadMirrors(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f MirrorsFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse registry mirrors file %s: %w", path, err)
+	}
+	for from, to := range f.Mirrors {
+		if trimMirrorLocation(from) == "" || trimMirrorLocation(to) == "" {
+			return nil, fmt.Errorf("invalid registry mirror %q of %q in %s: both must be set", to, from, path)
+		}
+	}
+	return f.Mirrors, nil
+}
+
+// Mirror returns ref with its location replaced by the one of its mirror, and
+// whether it has one. The most specific mirror matching ref is used; a mirror
+// of a registry host only matches references of that exact host and port.
+func (c *Client) Mirror(ref string) (string, bool) {
+	rest, hasScheme := strings.CutPrefix(ref, OCIScheme+"://")
+	host, _, _ := strings.Cut(rest, "/")
+
+	var match string
+	for from := range c.mirrors {
+		if len(from) <= len(match) {
+			continue
+		}
+		if !strings.Contains(from, "/") {
+			if host == from {
+				match = from
+			}
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(rest, from); ok && (suffix == "" || strings.ContainsAny(suffix[:1], "/:@")) {
+			match = from
+		}
+	}
+	if match == "" {
+		return ref, false
+	}
+
+	mirrored := c.mirrors[match] + strings.TrimPrefix(rest, match)
+	if hasScheme {
+		mirrored = OCIScheme + "://" + mirrored
+	}
+	return mirrored, true
+}
+
+// trimMirrorLocation trims the scheme and the trailing slashes of a mirror
+// location.
+func trimMirrorLocation(location string) string {
+	return strings.TrimRight(strings.TrimPrefix(strings.TrimSpace(location), OCIScheme+"://"), "/")
+}
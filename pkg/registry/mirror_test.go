@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientMirror(t *testing.T) {
+	c, err := NewClient(ClientOptMirrors(map[string]string{
+		"registry-1.docker.io":                 "mirror.example.com/docker/",
+		"oci://registry-1.docker.io/bitnami/":  "mirror.example.com:5000/bitnami",
+		"ghcr.io/org/charts/nginx":             "mirror.example.com/nginx",
+		"localhost:5000":                       "mirror.example.com/local",
+		"registry-1.docker.io/bitnamicharts/x": "unused.example.com",
+	}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		ref      string
+		expected string
+		mirrored bool
+	}{
+		{"oci://registry-1.docker.io/library/nginx:1.0.0", "oci://mirror.example.com/docker/library/nginx:1.0.0", true},
+		{"registry-1.docker.io/library/nginx", "mirror.example.com/docker/library/nginx", true},
+		{"oci://registry-1.docker.io/bitnami/redis@sha256:abc", "oci://mirror.example.com:5000/bitnami/redis@sha256:abc", true},
+		{"oci://registry-1.docker.io/bitnamiextra/redis:1.0.0", "oci://mirror.example.com/docker/bitnamiextra/redis:1.0.0", true},
+		{"ghcr.io/org/charts/nginx:1.0.0", "mirror.example.com/nginx:1.0.0", true},
+		{"ghcr.io/org/charts/nginx-ingress:1.0.0", "ghcr.io/org/charts/nginx-ingress:1.0.0", false},
+		{"localhost:5000/chart:1.0.0", "mirror.example.com/local/chart:1.0.0", true},
+		{"localhost:5001/chart:1.0.0", "localhost:5001/chart:1.0.0", false},
+		{"localhost/chart:1.0.0", "localhost/chart:1.0.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			mirrored, ok := c.Mirror(tt.ref)
+			assert.Equal(t, tt.expected, mirrored)
+			assert.Equal(t, tt.mirrored, ok)
+		})
+	}
+}
+
+func TestPullMirrorUnpinnedWarning(t *testing.T) {
+	var out bytes.Buffer
+	c, err := NewClient(
+		ClientOptWriter(&out),
+		ClientOptPlainHTTP(),
+		ClientOptMirrors(map[string]string{"registry.example.com": "127.0.0.1:1/mirror"}),
+	)
+	require.NoError(t, err)
+
+	// The mirror is unreachable: only the warning before pulling matters.
+	_, err = c.Pull("oci://registry.example.com/charts/nginx:1.0.0")
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "Warning: 127.0.0.1:1/mirror/charts/nginx:1.0.0 is pulled from a mirror without being pinned by digest")
+
+	out.Reset()
+	_, err = c.Pull("oci://registry.example.com/charts/nginx@sha256:" + strings.Repeat("a", 64))
+	require.Error(t, err)
+	assert.NotContains(t, out.String(), "Warning")
+
+	out.Reset()
+	_, err = c.Pull("oci://127.0.0.1:1/charts/nginx:1.0.0")
+	require.Error(t, err)
+	assert.NotContains(t, out.String(), "Warning")
+}
+
+func TestLoadMirrors(t *testing.T) {
+	dir := t.TempDir()
+
+	mirrors, err := LoadMirrors(filepath.Join(dir, "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, mirrors)
+
+	path := filepath.Join(dir, "mirrors.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("mirrors:\n  registry-1.docker.io: mirror.example.com/docker\n"), 0o644))
+	mirrors, err = LoadMirrors(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"registry-1.docker.io": "mirror.example.com/docker"}, mirrors)
+
+	require.NoError(t, os.WriteFile(path, []byte("mirrors:\n  registry-1.docker.io: \"\"\n"), 0o644))
+	_, err = LoadMirrors(path)
+	assert.ErrorContains(t, err, "invalid registry mirror")
+
+	require.NoError(t, os.WriteFile(path, []byte("mirror:\n  registry-1.docker.io: mirror.example.com\n"), 0o644))
+	_, err = LoadMirrors(path)
+	assert.ErrorContains(t, err, "failed to parse registry mirrors file")
+}
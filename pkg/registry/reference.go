@@ -70,6 +70,16 @@ func newReference(raw string) (result reference, err error) {
 	return result, nil
 }
 
+// ReferenceDigest returns the digest a chart reference is pinned to, as in
+// "oci://registry/repo/chart@sha256:...", or an empty string.
+func ReferenceDigest(ref string) string {
+	r, err := newReference(ref)
+	if err != nil {
+		return ""
+	}
+	return r.Digest
+}
+
 func (r *reference) String() string {
 	if r.Tag == "" {
 		return r.orasReference.String() + "@" + r.Digest
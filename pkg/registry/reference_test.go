@@ -98,3 +98,18 @@ func TestNewReference(t *testing.T) {
 	}
 	verify(t, actual, "registry.example.com", "the/repository", "", "sha256:c6841b3a895f1444a6738b5d04564a57e860ce42f8519c3be807fb6d9bee7888")
 }
+
+func TestReferenceDigest(t *testing.T) {
+	digest := "sha256:c6841b3a895f1444a6738b5d04564a57e860ce42f8519c3be807fb6d9bee7888"
+	tests := map[string]string{
+		"oci://registry.example.com/the/repository@" + digest:       digest,
+		"oci://registry.example.com/the/repository:1.0.0@" + digest: digest,
+		"oci://registry.example.com/the/repository:1.0.0":           "",
+		"thing:1.0": "",
+	}
+	for ref, expected := range tests {
+		if actual := ReferenceDigest(ref); actual != expected {
+			t.Errorf("expected the digest of %s to be %q, got %q", ref, expected, actual)
+		}
+	}
+}
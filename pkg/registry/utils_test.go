@@ -145,6 +145,7 @@ func setup(suite *TestSuite, tlsEnabled, insecure bool) *registry.Registry {
 	config.HTTP.Addr = fmt.Sprintf("127.0.0.1:%d", port)
 	config.HTTP.DrainTimeout = time.Duration(10) * time.Second
 	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.Catalog.MaxEntries = 1000
 
 	config.Auth = configuration.Auth{
 		"htpasswd": configuration.Parameters{
@@ -277,7 +278,7 @@ func testPush(suite *TestSuite) {
 
 	// push with prov
 	ref = fmt.Sprintf("%s/testrepo/%s:%s", suite.DockerRegistryHost, meta.Name, meta.Version)
-	result, err := suite.RegistryClient.Push(chartData, ref, PushOptProvData(provData), PushOptCreationTime(testingChartCreationTime))
+	result, err := suite.RegistryClient.Push(chartData, ref, PushOptWithProvenance(provData), PushOptCreationTime(testingChartCreationTime))
 	suite.Nil(err, "no error pushing good ref with prov")
 
 	_, err = suite.RegistryClient.Pull(ref, PullOptWithProv(true))
@@ -398,4 +399,16 @@ func testTags(suite *TestSuite) {
 	tags, err := suite.RegistryClient.Tags(ref)
 	suite.Nil(err, "no error retrieving tags")
 	suite.Equal(1, len(tags))
+
+	exists, err := suite.RegistryClient.TagExists(fmt.Sprintf("%s:%s", ref, meta.Version))
+	suite.Nil(err, "no error checking for an existing tag")
+	suite.True(exists, "the tag pushed exists")
+	exists, err = suite.RegistryClient.TagExists(fmt.Sprintf("%s:9.9.9", ref))
+	suite.Nil(err, "no error checking for a missing tag")
+	suite.False(exists, "the tag not pushed does not exist")
+
+	// The chart is listed in its namespace
+	repositories, err := suite.RegistryClient.Repositories(fmt.Sprintf("%s/testrepo", suite.DockerRegistryHost))
+	suite.Nil(err, "no error listing repositories")
+	suite.Contains(repositories, meta.Name)
 }
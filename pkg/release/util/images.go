@@ -0,0 +1,163 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ContainerImage is a container image reference found in a rendered manifest.
+type ContainerImage struct {
+	// Kind is the kind of the object holding the pod template, e.g. Deployment.
+	Kind string
+	// Namespace is the namespace of the object holding the pod template, as
+	// written in the manifest.
+	Namespace string
+	// Name is the name of the object holding the pod template.
+	Name string
+	// Container is the name of the container using the image.
+	Container string
+	// Image is the image reference, as written in the manifest.
+	Image string
+}
+
+// ID identifies the container independently of the image it runs. The
+// namespace is left out when the manifest does not set one.
+func (c ContainerImage) ID() string {
+	if c.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s/%s", c.Namespace, c.Kind, c.Name, c.Container)
+	}
+	return fmt.Sprintf("%s/%s/%s", c.Kind, c.Name, c.Container)
+}
+
+// ImageChange describes a container whose image differs between two manifests.
+type ImageChange struct {
+	Old ContainerImage
+	New ContainerImage
+}
+
+// ImageDiff summarizes the container image differences between two manifests.
+type ImageDiff struct {
+	Added   []ContainerImage
+	Removed []ContainerImage
+	Changed []ImageChange
+}
+
+// IsEmpty returns true if no image was added, removed or changed.
+func (d ImageDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// podSpecPaths maps the kinds known to embed a pod spec to the path of that
+// spec in the object.
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ExtractImages returns the container images referenced by the pod templates
+// of the workloads in a rendered manifest, including init and ephemeral
+// containers. The result is sorted by ContainerImage.ID.
+func ExtractImages(manifest string) ([]ContainerImage, error) {
+	var images []ContainerImage
+	for _, doc := range SplitManifests(manifest) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("unable to parse manifest: %w", err)
+		}
+		kind, _ := obj["kind"].(string)
+		path, ok := podSpecPaths[kind]
+		if !ok {
+			continue
+		}
+		var name, namespace string
+		if md, ok := obj["metadata"].(map[string]interface{}); ok {
+			name, _ = md["name"].(string)
+			namespace, _ = md["namespace"].(string)
+		}
+
+		spec, ok := nestedMap(obj, path...)
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, _ := spec[field].([]interface{})
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				image, _ := container["image"].(string)
+				if image == "" {
+					continue
+				}
+				cname, _ := container["name"].(string)
+				images = append(images, ContainerImage{Kind: kind, Namespace: namespace, Name: name, Container: cname, Image: image})
+			}
+		}
+	}
+	sort.SliceStable(images, func(i, j int) bool { return images[i].ID() < images[j].ID() })
+	return images, nil
+}
+
+// DiffImages compares two sets of container images, matching containers by
+// their ID.
+func DiffImages(current, target []ContainerImage) ImageDiff {
+	var diff ImageDiff
+	old := make(map[string]ContainerImage, len(current))
+	for _, c := range current {
+		old[c.ID()] = c
+	}
+	seen := make(map[string]bool, len(target))
+	for _, c := range target {
+		seen[c.ID()] = true
+		prev, ok := old[c.ID()]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, c)
+		case prev.Image != c.Image:
+			diff.Changed = append(diff.Changed, ImageChange{Old: prev, New: c})
+		}
+	}
+	for _, c := range current {
+		if !seen[c.ID()] {
+			diff.Removed = append(diff.Removed, c)
+		}
+	}
+	return diff
+}
+
+func nestedMap(obj map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	cur := obj
+	for _, f := range fields {
+		next, ok := cur[f].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	return cur, true
+}
@@ -0,0 +1,118 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util // import "helm.sh/helm/v4/pkg/release/util"
+
+import (
+	"reflect"
+	"testing"
+)
+
+const imagesManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: blue
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: example.com/migrate:1.0
+      containers:
+      - name: app
+        image: example.com/web:1.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: busybox:1.36
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: not-an-image
+`
+
+func TestExtractImages(t *testing.T) {
+	images, err := ExtractImages(imagesManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ContainerImage{
+		{Kind: "CronJob", Name: "cleanup", Container: "cleanup", Image: "busybox:1.36"},
+		{Kind: "Deployment", Namespace: "blue", Name: "web", Container: "app", Image: "example.com/web:1.0"},
+		{Kind: "Deployment", Namespace: "blue", Name: "web", Container: "migrate", Image: "example.com/migrate:1.0"},
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("Expected %v, got %v", expected, images)
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	current := []ContainerImage{
+		{Kind: "Deployment", Name: "web", Container: "app", Image: "example.com/web:1.0"},
+		{Kind: "Deployment", Name: "web", Container: "sidecar", Image: "envoy:1.0"},
+		{Kind: "Job", Name: "once", Container: "job", Image: "busybox"},
+	}
+	target := []ContainerImage{
+		{Kind: "Deployment", Name: "web", Container: "app", Image: "example.com/web:2.0"},
+		{Kind: "Deployment", Name: "web", Container: "sidecar", Image: "envoy:1.0"},
+		{Kind: "StatefulSet", Name: "db", Container: "db", Image: "postgres:16"},
+	}
+
+	diff := DiffImages(current, target)
+	expected := ImageDiff{
+		Added:   []ContainerImage{target[2]},
+		Removed: []ContainerImage{current[2]},
+		Changed: []ImageChange{{Old: current[0], New: target[0]}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %v, got %v", expected, diff)
+	}
+	if DiffImages(current, current).IsEmpty() != true {
+		t.Error("Expected no difference between identical image sets")
+	}
+}
+
+func TestDiffImagesNamespaces(t *testing.T) {
+	current := []ContainerImage{
+		{Kind: "Deployment", Namespace: "blue", Name: "web", Container: "app", Image: "example.com/web:1.0"},
+		{Kind: "Deployment", Namespace: "green", Name: "web", Container: "app", Image: "example.com/web:2.0"},
+	}
+	target := []ContainerImage{
+		{Kind: "Deployment", Namespace: "blue", Name: "web", Container: "app", Image: "example.com/web:1.0"},
+		{Kind: "Deployment", Namespace: "green", Name: "web", Container: "app", Image: "example.com/web:2.0"},
+	}
+
+	if diff := DiffImages(current, target); !diff.IsEmpty() {
+		t.Errorf("Expected no difference between workloads of the same name in different namespaces, got %v", diff)
+	}
+	if id := current[0].ID(); id != "blue/Deployment/web/app" {
+		t.Errorf("Expected the ID to include the namespace, got %q", id)
+	}
+}
@@ -137,6 +137,12 @@ func sortHooksByKind(hooks []*release.Hook, ordering KindSortOrder) []*release.H
 	return h
 }
 
+// Less reports whether resources of kindA come before resources of kindB in
+// the ordering. Kinds missing from the ordering come last, alphabetically.
+func (o KindSortOrder) Less(kindA, kindB string) bool {
+	return lessByKind(nil, nil, kindA, kindB, o)
+}
+
 func lessByKind(_ interface{}, _ interface{}, kindA string, kindB string, o KindSortOrder) bool {
 	ordering := make(map[string]int, len(o))
 	for v, k := range o {
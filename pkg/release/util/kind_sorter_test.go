@@ -345,3 +345,22 @@ func TestKindSorterForHooks(t *testing.T) {
 		})
 	}
 }
+
+func TestKindSortOrderLess(t *testing.T) {
+	for _, test := range []struct {
+		kindA, kindB string
+		expected     bool
+	}{
+		{"Namespace", "ConfigMap", true},
+		{"ConfigMap", "Namespace", false},
+		{"Job", "Job", false},
+		{"ConfigMap", "Widget", true},
+		{"Widget", "ConfigMap", false},
+		{"Gadget", "Widget", true},
+		{"Widget", "Gadget", false},
+	} {
+		if got := InstallOrder.Less(test.kindA, test.kindB); got != test.expected {
+			t.Errorf("expected %s before %s to be %t, got %t", test.kindA, test.kindB, test.expected, got)
+		}
+	}
+}
@@ -28,8 +28,9 @@ type SimpleHead struct {
 	Version  string `json:"apiVersion"`
 	Kind     string `json:"kind,omitempty"`
 	Metadata *struct {
-		Name        string            `json:"name"`
-		Annotations map[string]string `json:"annotations"`
+		Name         string            `json:"name"`
+		GenerateName string            `json:"generateName,omitempty"`
+		Annotations  map[string]string `json:"annotations"`
 	} `json:"metadata,omitempty"`
 }
 
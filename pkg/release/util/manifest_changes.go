@@ -0,0 +1,128 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util // import "helm.sh/helm/v4/pkg/release/util"
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// The comments flagging the documents of the manifest returned by
+// ChangedManifests.
+const (
+	ChangedComment = "# CHANGED"
+	AddedComment   = "# ADDED"
+	RemovedComment = "# REMOVED"
+)
+
+// manifestDoc is a document of a manifest with its key and normalized form.
+type manifestDoc struct {
+	key        string
+	content    string
+	normalized string
+}
+
+// ChangedManifests returns the documents of current which differ from the
+// corresponding ones of previous, matched by kind, namespace and name, and
+// compared in their normalized form, ignoring the comments, the formatting
+// and the order of the keys. The changed documents are flagged with
+// ChangedComment and the added ones with AddedComment, followed by the
+// documents of previous which are no longer in current flagged with
+// RemovedComment.
+func ChangedManifests(previous, current string) (string, error) {
+	before, err := manifestDocs(previous)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the previous manifest: %w", err)
+	}
+	after, err := manifestDocs(current)
+	if err != nil {
+		return "", err
+	}
+
+	byKey := make(map[string][]*manifestDoc)
+	for i := range before {
+		byKey[before[i].key] = append(byKey[before[i].key], &before[i])
+	}
+	matched := make(map[*manifestDoc]bool)
+
+	var b strings.Builder
+	write := func(comment, content string) {
+		fmt.Fprintf(&b, "---\n%s\n%s\n", comment, content)
+	}
+	for _, doc := range after {
+		candidates := byKey[doc.key]
+		if len(candidates) == 0 {
+			write(AddedComment, doc.content)
+			continue
+		}
+		prev := candidates[0]
+		byKey[doc.key] = candidates[1:]
+		matched[prev] = true
+		if prev.normalized != doc.normalized {
+			write(ChangedComment, doc.content)
+		}
+	}
+	for i := range before {
+		if !matched[&before[i]] {
+			write(RemovedComment, before[i].content)
+		}
+	}
+	return b.String(), nil
+}
+
+// manifestDocs splits a manifest into its documents, in order, leaving the
+// empty ones out.
+func manifestDocs(manifest string) ([]manifestDoc, error) {
+	split := SplitManifests(manifest)
+	keys := make([]string, 0, len(split))
+	for k := range split {
+		keys = append(keys, k)
+	}
+	sort.Sort(BySplitManifestsOrder(keys))
+
+	var docs []manifestDoc
+	for _, k := range keys {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(split[k]), &obj); err != nil {
+			return nil, fmt.Errorf("unable to parse manifest: %w", err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		normalized, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, manifestDoc{key: documentKey(obj), content: split[k], normalized: string(normalized)})
+	}
+	return docs, nil
+}
+
+// documentKey returns the kind, namespace and name of a parsed document.
+func documentKey(obj map[string]interface{}) string {
+	kind, _ := obj["kind"].(string)
+	var name, namespace string
+	if md, ok := obj["metadata"].(map[string]interface{}); ok {
+		name, _ = md["name"].(string)
+		namespace, _ = md["namespace"].(string)
+	}
+	return kind + "/" + namespace + "/" + name
+}
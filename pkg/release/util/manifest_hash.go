@@ -0,0 +1,222 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	rspb "helm.sh/helm/v4/pkg/release/v1"
+)
+
+const (
+	manifestHashPrefix = "sha256:"
+	// manifestHashLine and documentHashLine start the lines of the text form
+	// of a ManifestHash. They are YAML comments, so that the text form can
+	// follow the manifests it hashes.
+	manifestHashLine = "# manifest-hash: "
+	documentHashLine = "# document-hash: "
+)
+
+// DocumentHash is the hash of a document of a rendered manifest.
+type DocumentHash struct {
+	// ID identifies the object of the document, as
+	// "<apiVersion> <kind> <namespace>/<name>", without the namespace if it
+	// is not set.
+	ID string
+	// Hash is the hash of the canonical form of the document.
+	Hash string
+}
+
+// ManifestHash is the canonical hash of a rendered manifest. It does not
+// depend on the order of the documents, of their keys, on their comments nor
+// on their formatting.
+type ManifestHash struct {
+	// Sum is the hash of the whole manifest, as "sha256:<hex>".
+	Sum string
+	// Documents are the hashes of the documents, sorted by ID and hash. They
+	// are unknown when the hash is parsed from a bare Sum.
+	Documents []DocumentHash
+}
+
+// ManifestHashDiff lists the documents which differ between two manifest
+// hashes, by ID.
+type ManifestHashDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// IsEmpty returns true if no document was added, removed or changed.
+func (d ManifestHashDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// HashManifest returns the canonical hash of a rendered manifest. Each
+// document is parsed and hashed in its JSON form, which sorts the keys and
+// drops the comments and the formatting, and the sum is the hash of the
+// sorted IDs and hashes of the documents. The empty documents are left out.
+func HashManifest(manifest string) (ManifestHash, error) {
+	var docs []DocumentHash
+	for _, doc := range SplitManifests(manifest) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return ManifestHash{}, fmt.Errorf("unable to parse manifest: %w", err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		canonical, err := json.Marshal(obj)
+		if err != nil {
+			return ManifestHash{}, err
+		}
+		docs = append(docs, DocumentHash{ID: documentID(obj), Hash: hashOf(canonical)})
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].ID != docs[j].ID {
+			return docs[i].ID < docs[j].ID
+		}
+		return docs[i].Hash < docs[j].Hash
+	})
+
+	var all strings.Builder
+	for _, d := range docs {
+		fmt.Fprintf(&all, "%s\t%s\n", d.ID, d.Hash)
+	}
+	return ManifestHash{Sum: hashOf([]byte(all.String())), Documents: docs}, nil
+}
+
+// HashRelease returns the canonical hash of the manifest of rel and of the
+// manifests of its hooks, which together are what an install or an upgrade
+// renders.
+func HashRelease(rel *rspb.Release) (ManifestHash, error) {
+	var manifest strings.Builder
+	manifest.WriteString(rel.Manifest)
+	for _, h := range rel.Hooks {
+		fmt.Fprintf(&manifest, "\n---\n%s\n", h.Manifest)
+	}
+	return HashManifest(manifest.String())
+}
+
+// ParseManifestHash parses the text form of a manifest hash, as returned by
+// ManifestHash.String. The lines which are not part of it are ignored, so the
+// manifests it follows can be given too. A bare sum is accepted as well, in
+// which case the hashes of the documents are unknown.
+func ParseManifestHash(s string) (ManifestHash, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, manifestHashPrefix) && !strings.ContainsAny(s, " \n") {
+		return ManifestHash{Sum: s}, nil
+	}
+
+	var h ManifestHash
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if sum, ok := strings.CutPrefix(line, manifestHashLine); ok {
+			h.Sum = strings.TrimSpace(sum)
+			continue
+		}
+		if doc, ok := strings.CutPrefix(line, documentHashLine); ok {
+			hash, id, found := strings.Cut(doc, " ")
+			if !found || !strings.HasPrefix(hash, manifestHashPrefix) {
+				return ManifestHash{}, fmt.Errorf("invalid document hash %q", line)
+			}
+			h.Documents = append(h.Documents, DocumentHash{ID: id, Hash: hash})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ManifestHash{}, err
+	}
+	if !strings.HasPrefix(h.Sum, manifestHashPrefix) {
+		return ManifestHash{}, fmt.Errorf("no manifest hash found, expected %q followed by the hash, or a bare %q hash", strings.TrimSpace(manifestHashLine), manifestHashPrefix)
+	}
+	return h, nil
+}
+
+// String returns the text form of h: a line holding the sum, followed by a
+// line for each document. The lines are YAML comments.
+func (h ManifestHash) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s\n", manifestHashLine, h.Sum)
+	for _, d := range h.Documents {
+		fmt.Fprintf(&b, "%s%s %s\n", documentHashLine, d.Hash, d.ID)
+	}
+	return b.String()
+}
+
+// Diff returns the documents of h which differ from the ones of expected.
+// A document is matched by its ID, so that one rendered several times only
+// differs if the set of its hashes does.
+func (h ManifestHash) Diff(expected ManifestHash) ManifestHashDiff {
+	group := func(docs []DocumentHash) (map[string][]string, []string) {
+		byID := make(map[string][]string)
+		var ids []string
+		for _, d := range docs {
+			if _, ok := byID[d.ID]; !ok {
+				ids = append(ids, d.ID)
+			}
+			byID[d.ID] = append(byID[d.ID], d.Hash)
+		}
+		return byID, ids
+	}
+	old, oldIDs := group(expected.Documents)
+	cur, curIDs := group(h.Documents)
+
+	var diff ManifestHashDiff
+	for _, id := range curIDs {
+		hashes, ok := old[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, id)
+		case strings.Join(hashes, ",") != strings.Join(cur[id], ","):
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for _, id := range oldIDs {
+		if _, ok := cur[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	return diff
+}
+
+// documentID identifies the object of a parsed document.
+func documentID(obj map[string]interface{}) string {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	var name, namespace string
+	if md, ok := obj["metadata"].(map[string]interface{}); ok {
+		name, _ = md["name"].(string)
+		namespace, _ = md["namespace"].(string)
+	}
+	if namespace != "" {
+		name = namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", apiVersion, kind, name)
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return manifestHashPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util // import "helm.sh/helm/v4/pkg/release/util"
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	rspb "helm.sh/helm/v4/pkg/release/v1"
+)
+
+const hashManifest = `---
+# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: default
+data:
+  a: "1"
+  b: "2"
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`
+
+// reorderedHashManifest is hashManifest with its documents and keys in
+// another order, other comments and another formatting.
+const reorderedHashManifest = `
+# Source: web/templates/all.yaml
+kind: Deployment
+apiVersion: apps/v1
+spec: {replicas: 2}
+metadata: {name: web}
+
+
+---
+# a comment
+metadata:
+    namespace: default
+    name: web
+data:
+    b: "2"
+    a: '1'
+apiVersion: v1
+kind: ConfigMap
+---
+`
+
+func TestHashManifestIgnoresOrderAndFormatting(t *testing.T) {
+	h, err := HashManifest(hashManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reordered, err := HashManifest(reorderedHashManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Sum != reordered.Sum {
+		t.Errorf("expected the same sum, got %s and %s", h.Sum, reordered.Sum)
+	}
+	if !reflect.DeepEqual(h.Documents, reordered.Documents) {
+		t.Errorf("expected the same documents, got %v and %v", h.Documents, reordered.Documents)
+	}
+
+	ids := []string{h.Documents[0].ID, h.Documents[1].ID}
+	expected := []string{"apps/v1 Deployment web", "v1 ConfigMap default/web"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected documents %v, got %v", expected, ids)
+	}
+}
+
+func TestHashManifestChanges(t *testing.T) {
+	h, err := HashManifest(hashManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, manifest := range []string{
+		strings.Replace(hashManifest, `a: "1"`, `a: "3"`, 1),
+		// The type of a value is part of the document.
+		strings.Replace(hashManifest, `replicas: 2`, `replicas: "2"`, 1),
+		strings.Replace(hashManifest, `namespace: default`, `namespace: other`, 1),
+	} {
+		changed, err := HashManifest(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed.Sum == h.Sum {
+			t.Errorf("expected the sum to change for\n%s", manifest)
+		}
+	}
+
+	if _, err := HashManifest("kind: [unterminated"); err == nil {
+		t.Error("expected an error for an invalid manifest")
+	}
+}
+
+func TestHashRelease(t *testing.T) {
+	rel := &rspb.Release{
+		Manifest: hashManifest,
+		Hooks: []*rspb.Hook{
+			{Manifest: "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n"},
+		},
+	}
+	h, err := HashRelease(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Documents) != 3 || h.Documents[0].ID != "apps/v1 Deployment web" || h.Documents[1].ID != "batch/v1 Job migrate" {
+		t.Errorf("expected the hook to be hashed, got %v", h.Documents)
+	}
+}
+
+func TestParseManifestHash(t *testing.T) {
+	h, err := HashManifest(hashManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The text form follows the manifests it hashes.
+	parsed, err := ParseManifestHash(hashManifest + "---\n" + h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, h) {
+		t.Errorf("expected %v, got %v", h, parsed)
+	}
+
+	parsed, err = ParseManifestHash(" " + h.Sum + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Sum != h.Sum || parsed.Documents != nil {
+		t.Errorf("expected the bare sum %s, got %v", h.Sum, parsed)
+	}
+
+	for _, s := range []string{"", "md5:1234", hashManifest, "# manifest-hash: sha256:1\n# document-hash: v1 ConfigMap web\n"} {
+		if _, err := ParseManifestHash(s); err == nil {
+			t.Errorf("expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestManifestHashDiff(t *testing.T) {
+	expected, err := HashManifest(hashManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := strings.Replace(hashManifest, `a: "1"`, `a: "3"`, 1)
+	manifest = strings.Replace(manifest, "kind: Deployment", "kind: StatefulSet", 1)
+	actual, err := HashManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := actual.Diff(expected)
+	want := ManifestHashDiff{
+		Added:   []string{"apps/v1 StatefulSet web"},
+		Removed: []string{"apps/v1 Deployment web"},
+		Changed: []string{"v1 ConfigMap default/web"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("expected %v, got %v", want, diff)
+	}
+	if !expected.Diff(expected).IsEmpty() {
+		t.Error("expected no difference between a hash and itself")
+	}
+}
@@ -61,6 +61,7 @@ var events = map[string]release.HookEvent{
 	release.HookPreRollback.String():  release.HookPreRollback,
 	release.HookPostRollback.String(): release.HookPostRollback,
 	release.HookTest.String():         release.HookTest,
+	release.HookTestFixture.String():  release.HookTestFixture,
 	// Support test-success for backward compatibility with Helm 2 tests
 	"test-success": release.HookTest,
 }
@@ -136,6 +137,14 @@ func SortManifests(files map[string]string, _ chartutil.VersionSet, ordering Kin
 //	 metadata:
 //			annotations:
 //				helm.sh/hook-output-log-policy: hook-succeeded,hook-failed
+//
+// To determine whether the hook is rendered right before it runs, it looks for a YAML structure like this:
+//
+//	 kind: SomeKind
+//	 apiVersion: v1
+//	 metadata:
+//			annotations:
+//				helm.sh/hook-render: late
 func (file *manifestFile) sort(result *result) error {
 	// Go through manifests in order found in file (function `SplitManifests` creates integer-sortable keys)
 	var sortedEntryKeys []string
@@ -182,6 +191,7 @@ func (file *manifestFile) sort(result *result) error {
 			Weight:            hw,
 			DeletePolicies:    []release.HookDeletePolicy{},
 			OutputLogPolicies: []release.HookOutputLogPolicy{},
+			RenderLate:        strings.TrimSpace(entry.Metadata.Annotations[release.HookRenderAnnotation]) == release.HookRenderLate,
 		}
 
 		isUnknownHook := false
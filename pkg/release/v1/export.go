@@ -0,0 +1,277 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/time"
+)
+
+// ExportAPIVersionV1 identifies the v1 release export format.
+const ExportAPIVersionV1 = "helm.sh/release-export/v1"
+
+// ExportV1 is a stable, versioned representation of a release meant to be
+// consumed by other tools.
+//
+// Unlike Release, whose serialization follows its Go fields, the shape of
+// ExportV1 only ever changes together with its API version. Fields added by
+// later versions of the format are kept in Unknown, so that a document can
+// be read and written back without losing them.
+type ExportV1 struct {
+	// APIVersion is always ExportAPIVersionV1.
+	APIVersion string `json:"apiVersion"`
+	// Release is the exported release.
+	Release ExportedReleaseV1 `json:"release"`
+	// Unknown holds the fields of the document not known to this version.
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+// ExportedReleaseV1 is a release, as represented by the v1 export format.
+type ExportedReleaseV1 struct {
+	Name          string                 `json:"name"`
+	Namespace     string                 `json:"namespace"`
+	Revision      int                    `json:"revision"`
+	Status        string                 `json:"status"`
+	Description   string                 `json:"description"`
+	FirstDeployed time.Time              `json:"firstDeployed"`
+	LastDeployed  time.Time              `json:"lastDeployed"`
+	Deleted       time.Time              `json:"deleted"`
+	Chart         ExportedChartV1        `json:"chart"`
+	Values        map[string]interface{} `json:"values"`
+	Manifest      string                 `json:"manifest"`
+	Notes         string                 `json:"notes"`
+	Hooks         []ExportedHookV1       `json:"hooks"`
+	Labels        map[string]string      `json:"labels"`
+	// Unknown holds the fields of the release not known to this version.
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+// ExportedChartV1 identifies the chart of an exported release.
+type ExportedChartV1 struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion"`
+}
+
+// ExportedHookV1 is a release hook, as represented by the v1 export format.
+type ExportedHookV1 struct {
+	Name           string   `json:"name"`
+	Kind           string   `json:"kind"`
+	Path           string   `json:"path"`
+	Manifest       string   `json:"manifest"`
+	Events         []string `json:"events"`
+	Weight         int      `json:"weight"`
+	DeletePolicies []string `json:"deletePolicies"`
+}
+
+// NewExportV1 builds the v1 export of a release.
+func NewExportV1(rel *Release) *ExportV1 {
+	exp := &ExportV1{
+		APIVersion: ExportAPIVersionV1,
+		Release: ExportedReleaseV1{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Revision:  rel.Version,
+			Values:    rel.Config,
+			Manifest:  rel.Manifest,
+			Labels:    rel.Labels,
+			Hooks:     []ExportedHookV1{},
+		},
+	}
+	if info := rel.Info; info != nil {
+		exp.Release.Status = info.Status.String()
+		exp.Release.Description = info.Description
+		exp.Release.FirstDeployed = info.FirstDeployed
+		exp.Release.LastDeployed = info.LastDeployed
+		exp.Release.Deleted = info.Deleted
+		exp.Release.Notes = info.Notes
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		exp.Release.Chart = ExportedChartV1{
+			Name:       rel.Chart.Metadata.Name,
+			Version:    rel.Chart.Metadata.Version,
+			AppVersion: rel.Chart.Metadata.AppVersion,
+		}
+	}
+	for _, h := range rel.Hooks {
+		hook := ExportedHookV1{
+			Name:           h.Name,
+			Kind:           h.Kind,
+			Path:           h.Path,
+			Manifest:       h.Manifest,
+			Weight:         h.Weight,
+			Events:         []string{},
+			DeletePolicies: []string{},
+		}
+		for _, e := range h.Events {
+			hook.Events = append(hook.Events, e.String())
+		}
+		for _, p := range h.DeletePolicies {
+			hook.DeletePolicies = append(hook.DeletePolicies, string(p))
+		}
+		exp.Release.Hooks = append(exp.Release.Hooks, hook)
+	}
+	return exp
+}
+
+// ParseExport reads a release export, failing on export formats other than v1.
+func ParseExport(data []byte) (*ExportV1, error) {
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("unable to parse release export: %w", err)
+	}
+	if header.APIVersion != ExportAPIVersionV1 {
+		return nil, fmt.Errorf("unsupported release export apiVersion %q, expected %q", header.APIVersion, ExportAPIVersionV1)
+	}
+	exp := &ExportV1{}
+	if err := json.Unmarshal(data, exp); err != nil {
+		return nil, fmt.Errorf("unable to parse release export: %w", err)
+	}
+	return exp, nil
+}
+
+// ToRelease converts the export back into a release. Only the chart's
+// metadata is exported, so the returned release's chart has no templates.
+func (e *ExportV1) ToRelease() *Release {
+	r := e.Release
+	rel := &Release{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		Version:   r.Revision,
+		Config:    r.Values,
+		Manifest:  r.Manifest,
+		Labels:    r.Labels,
+		Info: &Info{
+			Status:        Status(r.Status),
+			Description:   r.Description,
+			FirstDeployed: r.FirstDeployed,
+			LastDeployed:  r.LastDeployed,
+			Deleted:       r.Deleted,
+			Notes:         r.Notes,
+		},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{
+				APIVersion: chart.APIVersionV2,
+				Name:       r.Chart.Name,
+				Version:    r.Chart.Version,
+				AppVersion: r.Chart.AppVersion,
+			},
+		},
+	}
+	for _, h := range r.Hooks {
+		hook := &Hook{
+			Name:     h.Name,
+			Kind:     h.Kind,
+			Path:     h.Path,
+			Manifest: h.Manifest,
+			Weight:   h.Weight,
+		}
+		for _, e := range h.Events {
+			hook.Events = append(hook.Events, HookEvent(e))
+		}
+		for _, p := range h.DeletePolicies {
+			hook.DeletePolicies = append(hook.DeletePolicies, HookDeletePolicy(p))
+		}
+		rel.Hooks = append(rel.Hooks, hook)
+	}
+	return rel
+}
+
+func (e ExportV1) MarshalJSON() ([]byte, error) {
+	type plain ExportV1
+	return marshalWithUnknown(plain(e), e.Unknown)
+}
+
+func (e *ExportV1) UnmarshalJSON(data []byte) error {
+	type plain ExportV1
+	var p plain
+	unknown, err := unmarshalWithUnknown(data, &p)
+	if err != nil {
+		return err
+	}
+	*e = ExportV1(p)
+	e.Unknown = unknown
+	return nil
+}
+
+func (r ExportedReleaseV1) MarshalJSON() ([]byte, error) {
+	type plain ExportedReleaseV1
+	return marshalWithUnknown(plain(r), r.Unknown)
+}
+
+func (r *ExportedReleaseV1) UnmarshalJSON(data []byte) error {
+	type plain ExportedReleaseV1
+	var p plain
+	unknown, err := unmarshalWithUnknown(data, &p)
+	if err != nil {
+		return err
+	}
+	*r = ExportedReleaseV1(p)
+	r.Unknown = unknown
+	return nil
+}
+
+// marshalWithUnknown encodes v, adding the unknown fields that v does not
+// define itself.
+func marshalWithUnknown(v interface{}, unknown map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, raw := range unknown {
+		if _, known := fields[k]; !known {
+			fields[k] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// unmarshalWithUnknown decodes data into v and returns the fields of data that
+// v does not define.
+func unmarshalWithUnknown(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	knownFields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(known, &knownFields); err != nil {
+		return nil, err
+	}
+	for k := range knownFields {
+		delete(fields, k)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
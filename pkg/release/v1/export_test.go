@@ -0,0 +1,168 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// TestExportV1Shape guards the v1 export format. If it fails, the JSON
+// representation changed: rather than updating the expected fields, introduce
+// a new export API version.
+func TestExportV1Shape(t *testing.T) {
+	rel := Mock(&MockReleaseOptions{Name: "shape", Labels: map[string]string{"team": "platform"}})
+	data, err := json.Marshal(NewExportV1(rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var fields []string
+	collectFieldPaths("", doc, &fields)
+	slices.Sort(fields)
+	expect := []string{
+		"apiVersion",
+		"release",
+		"release.chart",
+		"release.chart.appVersion",
+		"release.chart.name",
+		"release.chart.version",
+		"release.deleted",
+		"release.description",
+		"release.firstDeployed",
+		"release.hooks",
+		"release.hooks[].deletePolicies",
+		"release.hooks[].events",
+		"release.hooks[].kind",
+		"release.hooks[].manifest",
+		"release.hooks[].name",
+		"release.hooks[].path",
+		"release.hooks[].weight",
+		"release.labels",
+		"release.lastDeployed",
+		"release.manifest",
+		"release.name",
+		"release.namespace",
+		"release.notes",
+		"release.revision",
+		"release.status",
+		"release.values",
+	}
+	if !reflect.DeepEqual(expect, fields) {
+		t.Errorf("the %s format changed, which requires a new export API version\nexpected: %v\ngot:      %v", ExportAPIVersionV1, expect, fields)
+	}
+}
+
+// collectFieldPaths lists the paths of the fields of an export document. The
+// contents of free-form maps are left out.
+func collectFieldPaths(prefix string, v interface{}, fields *[]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if prefix == "release.values" || prefix == "release.labels" {
+			return
+		}
+		for k, val := range v {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			*fields = append(*fields, path)
+			collectFieldPaths(path, val, fields)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			collectFieldPaths(prefix+"[]", v[0], fields)
+		}
+	}
+}
+
+func TestExportV1RoundTrip(t *testing.T) {
+	rel := Mock(&MockReleaseOptions{Name: "roundtrip", Version: 2})
+	data, err := json.Marshal(NewExportV1(rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := ParseExport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := exp.ToRelease()
+	if got.Name != rel.Name || got.Namespace != rel.Namespace || got.Version != rel.Version {
+		t.Errorf("expected release %s/%s revision %d, got %s/%s revision %d", rel.Namespace, rel.Name, rel.Version, got.Namespace, got.Name, got.Version)
+	}
+	if got.Info.Status != rel.Info.Status || got.Info.Notes != rel.Info.Notes || !got.Info.LastDeployed.Equal(rel.Info.LastDeployed) {
+		t.Errorf("expected info %+v, got %+v", rel.Info, got.Info)
+	}
+	if got.Chart.Metadata.Name != rel.Chart.Metadata.Name || got.Chart.Metadata.Version != rel.Chart.Metadata.Version {
+		t.Errorf("expected chart %s-%s, got %s-%s", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version, got.Chart.Metadata.Name, got.Chart.Metadata.Version)
+	}
+	if !reflect.DeepEqual(rel.Config, got.Config) || got.Manifest != rel.Manifest {
+		t.Error("expected values and manifest to be preserved")
+	}
+	if len(got.Hooks) != len(rel.Hooks) || !reflect.DeepEqual(rel.Hooks[0].Events, got.Hooks[0].Events) {
+		t.Errorf("expected hooks %v, got %v", rel.Hooks, got.Hooks)
+	}
+}
+
+func TestExportV1PreservesUnknownFields(t *testing.T) {
+	data, err := os.ReadFile("testdata/export-v1-future.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := ParseExport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Release.Name != "future" || exp.Release.Revision != 3 {
+		t.Errorf("expected known fields to be read, got %+v", exp.Release)
+	}
+
+	out, err := json.Marshal(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got interface{}
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected the document to survive a round trip\nexpected: %v\ngot:      %v", want, got)
+	}
+}
+
+func TestParseExportRejectsOtherVersions(t *testing.T) {
+	for _, doc := range []string{
+		`{"apiVersion": "helm.sh/release-export/v2", "release": {}}`,
+		`{"release": {}}`,
+		`not json`,
+	} {
+		if _, err := ParseExport([]byte(doc)); err == nil {
+			t.Errorf("expected %s to be rejected", doc)
+		}
+	}
+}
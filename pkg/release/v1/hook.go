@@ -34,8 +34,26 @@ const (
 	HookPreRollback  HookEvent = "pre-rollback"
 	HookPostRollback HookEvent = "post-rollback"
 	HookTest         HookEvent = "test"
+	// HookTestFixture resources are created before the test hooks run, and
+	// deleted once they all completed.
+	HookTestFixture HookEvent = "test-fixture"
 )
 
+// HookEvents are all the hook events, in the order of the lifecycle of a
+// release.
+var HookEvents = []HookEvent{
+	HookPreInstall,
+	HookPostInstall,
+	HookPreUpgrade,
+	HookPostUpgrade,
+	HookPreRollback,
+	HookPostRollback,
+	HookPreDelete,
+	HookPostDelete,
+	HookTest,
+	HookTestFixture,
+}
+
 func (x HookEvent) String() string { return string(x) }
 
 // HookDeletePolicy specifies the hook delete policy
@@ -73,6 +91,13 @@ const HookDeleteAnnotation = "helm.sh/hook-delete-policy"
 // HookOutputLogAnnotation is the label name for the output log policy for a hook
 const HookOutputLogAnnotation = "helm.sh/hook-output-log-policy"
 
+// HookRenderAnnotation is the label name for the render policy for a hook
+const HookRenderAnnotation = "helm.sh/hook-render"
+
+// HookRenderLate defers rendering a hook until right before it runs, giving
+// its template access to the objects created by the hooks that ran before it.
+const HookRenderLate = "late"
+
 // Hook defines a hook object.
 type Hook struct {
 	Name string `json:"name,omitempty"`
@@ -86,12 +111,16 @@ type Hook struct {
 	Events []HookEvent `json:"events,omitempty"`
 	// LastRun indicates the date/time this was last run.
 	LastRun HookExecution `json:"last_run,omitempty"`
-	// Weight indicates the sort order for execution among similar Hook type
+	// Weight indicates the sort order for execution among similar Hook type.
+	// Hooks of equal weight are executed by kind, in the order resources are
+	// installed, then by name.
 	Weight int `json:"weight,omitempty"`
 	// DeletePolicies are the policies that indicate when to delete the hook
 	DeletePolicies []HookDeletePolicy `json:"delete_policies,omitempty"`
 	// OutputLogPolicies defines whether we should copy hook logs back to main process
 	OutputLogPolicies []HookOutputLogPolicy `json:"output_log_policies,omitempty"`
+	// RenderLate indicates that the hook is rendered again right before it runs.
+	RenderLate bool `json:"render_late,omitempty"`
 }
 
 // A HookExecution records the result for the last execution of a hook for a given release.
@@ -35,6 +35,86 @@ type Info struct {
 	Status Status `json:"status,omitempty"`
 	// Contains the rendered templates/NOTES.txt if available
 	Notes string `json:"notes,omitempty"`
+	// PendingNotes contains the notes of the chart a failed upgrade attempted
+	// to deploy. Notes may have been kept from the revision it started from.
+	PendingNotes string `json:"pending_notes,omitempty"`
 	// Contains the deployed resources information
 	Resources map[string][]runtime.Object `json:"resources,omitempty"`
+	// Pruned lists, as "Kind/name", the resources deleted by the upgrade
+	// which produced this revision, when it was asked for a prune report.
+	Pruned []string `json:"pruned,omitempty"`
+	// FieldManager is the name of the manager of the fields Helm set on the
+	// resources of the release, as recorded in their managedFields.
+	FieldManager string `json:"field_manager,omitempty"`
+	// AppliedBy is the local user who ran the Helm operation which produced
+	// this revision.
+	AppliedBy string `json:"applied_by,omitempty"`
+	// ResourceStatuses is the live state of the resources of the release, when
+	// it was queried by the status action.
+	ResourceStatuses []ResourceStatus `json:"resource_statuses,omitempty"`
+	// PrunedFields lists the fields removed from the resources of the release
+	// because the cluster did not know them, when it was asked to prune them.
+	PrunedFields []PrunedFields `json:"pruned_fields,omitempty"`
+	// Checkpoint records the progress of a pending upgrade, so that an
+	// interrupted upgrade can be resumed. It is cleared once the upgrade ends.
+	Checkpoint *UpgradeCheckpoint `json:"checkpoint,omitempty"`
+	// ValuesEdited is set when the values of the release were edited by hand
+	// before it was installed or upgraded.
+	ValuesEdited bool `json:"values_edited,omitempty"`
+	// NamespaceDefaults identifies the default values of the namespace which
+	// were layered beneath the values of the release, if any.
+	NamespaceDefaults *NamespaceDefaults `json:"namespace_defaults,omitempty"`
+	// ValuesMigrations lists the changes made to the values of the previous
+	// revision by the values migrations of the chart, when the upgrade
+	// which produced this revision reused them.
+	ValuesMigrations []string `json:"values_migrations,omitempty"`
+	// ChangedTemplates lists the templates whose output differs from their
+	// output in the previous revision, when a dry-run upgrade was asked to
+	// explain its changes.
+	ChangedTemplates []string `json:"changed_templates,omitempty"`
+}
+
+// NamespaceDefaults identifies the default values of a namespace injected in a
+// release.
+type NamespaceDefaults struct {
+	// Source is the ConfigMap the values were read from, as
+	// "namespace/name".
+	Source string `json:"source"`
+	// Digest is the SHA-256 digest of the values, as "sha256:<hex>".
+	Digest string `json:"digest"`
+}
+
+// PrunedFields lists the fields removed from a resource of a release because
+// the cluster did not know them.
+type PrunedFields struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Paths     []string `json:"paths"`
+}
+
+// UpgradeCheckpoint is the progress of an upgrade, as recorded on its pending
+// release.
+type UpgradeCheckpoint struct {
+	// Digest identifies the chart, values and manifest being upgraded to.
+	Digest string `json:"digest"`
+	// PreHooksComplete is set once the pre-upgrade hooks have run.
+	PreHooksComplete bool `json:"pre_hooks_complete,omitempty"`
+	// Applied lists, in order, the resources applied so far.
+	Applied []string `json:"applied,omitempty"`
+	// ApplyComplete is set once all the resources have been applied.
+	ApplyComplete bool `json:"apply_complete,omitempty"`
+}
+
+// ResourceStatus is the live state of a resource of a release.
+type ResourceStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// State is one of Ready, NotReady or Missing.
+	State string `json:"state"`
+	// ReadyReplicas and DesiredReplicas are only set for Deployments and
+	// StatefulSets.
+	ReadyReplicas   *int32 `json:"ready_replicas,omitempty"`
+	DesiredReplicas *int32 `json:"desired_replicas,omitempty"`
 }
@@ -0,0 +1,276 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxRecurringWindow is the longest duration of a recurring freeze window.
+const maxRecurringWindow = 31 * 24 * time.Hour
+
+// PolicyOperation is an operation on a release which its policy controls.
+type PolicyOperation string
+
+const (
+	PolicyUpgrade   PolicyOperation = "upgrade"
+	PolicyRollback  PolicyOperation = "rollback"
+	PolicyUninstall PolicyOperation = "uninstall"
+)
+
+// Policy constrains the operations on a release. It is set when the release
+// is installed or upgraded, and checked before the release is upgraded,
+// rolled back or uninstalled.
+type Policy struct {
+	// FreezeWindows are the periods during which the release cannot be
+	// changed.
+	FreezeWindows []FreezeWindow `json:"freezeWindows,omitempty"`
+	// MinInterval is the minimum time between two deployments of the
+	// release, as a duration such as "1h".
+	MinInterval string `json:"minInterval,omitempty"`
+	// RequireFlag lists the tokens one of which must be given to change the
+	// release, as an approval.
+	RequireFlag []string `json:"requireFlag,omitempty"`
+	// OverrideTokens lists the tokens which, when given, bypass all the rules
+	// of the policy.
+	OverrideTokens []string `json:"overrideTokens,omitempty"`
+}
+
+// policyTokenHashPrefix prefixes the tokens of a policy stored hashed.
+const policyTokenHashPrefix = "sha256:"
+
+// HashPolicyToken returns the hash under which token is stored in the policy
+// of a release, so that the release does not disclose it.
+func HashPolicyToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return policyTokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// WithHashedTokens returns a copy of the policy whose approval and override
+// tokens are hashed with HashPolicyToken, as they are stored in releases.
+// Tokens already hashed are kept.
+func (p *Policy) WithHashedTokens() *Policy {
+	if p == nil {
+		return nil
+	}
+	hashed := *p
+	hashed.FreezeWindows = slices.Clone(p.FreezeWindows)
+	hashed.RequireFlag = hashPolicyTokens(p.RequireFlag)
+	hashed.OverrideTokens = hashPolicyTokens(p.OverrideTokens)
+	return &hashed
+}
+
+func hashPolicyTokens(tokens []string) []string {
+	if tokens == nil {
+		return nil
+	}
+	hashed := make([]string, len(tokens))
+	for i, token := range tokens {
+		hashed[i] = storedPolicyToken(token)
+	}
+	return hashed
+}
+
+// storedPolicyToken returns the hash of a token of a policy, which may
+// already be hashed.
+func storedPolicyToken(token string) string {
+	if strings.HasPrefix(token, policyTokenHashPrefix) {
+		return token
+	}
+	return HashPolicyToken(token)
+}
+
+// FreezeWindow is a period during which a release cannot be changed: either
+// a single interval from Start to End, or a window recurring at the times
+// matching Cron and lasting Duration.
+type FreezeWindow struct {
+	// Start and End are RFC 3339 times.
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	// Cron is a five-field cron expression, "minute hour day-of-month month
+	// day-of-week", such as "0 18 * * 5" for every Friday at 18:00.
+	Cron string `json:"cron,omitempty"`
+	// Duration is the duration of the recurring window, such as "62h".
+	Duration string `json:"duration,omitempty"`
+	// TimeZone is the IANA time zone of Cron, UTC if empty.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Reason is reported when the window refuses an operation.
+	Reason string `json:"reason,omitempty"`
+}
+
+// PolicyViolation is the error returned when an operation breaks a rule of
+// the policy of a release.
+type PolicyViolation struct {
+	// Rule is the rule broken, such as "minInterval" or "freezeWindows[0]".
+	Rule    string
+	Message string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("release policy rule %s violated: %s", v.Rule, v.Message)
+}
+
+// Validate checks that the rules of the policy are well-formed.
+func (p *Policy) Validate() error {
+	var errs []error
+	if p.MinInterval != "" {
+		if d, err := time.ParseDuration(p.MinInterval); err != nil {
+			errs = append(errs, fmt.Errorf("minInterval: %w", err))
+		} else if d < 0 {
+			errs = append(errs, errors.New("minInterval: must not be negative"))
+		}
+	}
+	for i, w := range p.FreezeWindows {
+		if _, err := w.parse(); err != nil {
+			errs = append(errs, fmt.Errorf("freezeWindows[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check returns a *PolicyViolation if the operation op, done at now on a
+// release last deployed at lastDeployed, breaks a rule of the policy. tokens
+// are the tokens given with the operation, to approve it or to override the
+// policy. A nil policy allows every operation.
+func (p *Policy) Check(op PolicyOperation, now, lastDeployed time.Time, tokens []string) error {
+	if p == nil || containsAny(p.OverrideTokens, tokens) {
+		return nil
+	}
+
+	for i, w := range p.FreezeWindows {
+		parsed, err := w.parse()
+		if err != nil {
+			return &PolicyViolation{Rule: fmt.Sprintf("freezeWindows[%d]", i), Message: err.Error()}
+		}
+		if start, end, ok := parsed.contains(now); ok {
+			msg := fmt.Sprintf("%s refused during the freeze window from %s to %s", op, start.Format(time.RFC3339), end.Format(time.RFC3339))
+			if w.Reason != "" {
+				msg += ": " + w.Reason
+			}
+			return &PolicyViolation{Rule: fmt.Sprintf("freezeWindows[%d]", i), Message: msg}
+		}
+	}
+
+	if p.MinInterval != "" && op != PolicyUninstall && !lastDeployed.IsZero() {
+		interval, err := time.ParseDuration(p.MinInterval)
+		if err != nil {
+			return &PolicyViolation{Rule: "minInterval", Message: err.Error()}
+		}
+		if elapsed := now.Sub(lastDeployed); elapsed < interval {
+			return &PolicyViolation{
+				Rule:    "minInterval",
+				Message: fmt.Sprintf("the release was deployed %s ago, %s must pass between deployments", elapsed.Round(time.Second), interval),
+			}
+		}
+	}
+
+	if len(p.RequireFlag) > 0 && !containsAny(p.RequireFlag, tokens) {
+		return &PolicyViolation{
+			Rule:    "requireFlag",
+			Message: fmt.Sprintf("%s requires one of the approval tokens of the policy", op),
+		}
+	}
+	return nil
+}
+
+// freezeWindow is a parsed FreezeWindow.
+type freezeWindow struct {
+	start, end time.Time
+	schedule   *cronSchedule
+	duration   time.Duration
+	location   *time.Location
+}
+
+func (w FreezeWindow) parse() (*freezeWindow, error) {
+	switch {
+	case w.Cron != "" && (w.Start != "" || w.End != ""):
+		return nil, errors.New("either start and end or cron must be set, not both")
+	case w.Cron != "":
+		schedule, err := parseCron(w.Cron)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("duration: %w", err)
+		}
+		if duration <= 0 || duration > maxRecurringWindow {
+			return nil, fmt.Errorf("duration: must be positive and at most %s", maxRecurringWindow)
+		}
+		location := time.UTC
+		if w.TimeZone != "" {
+			if location, err = time.LoadLocation(w.TimeZone); err != nil {
+				return nil, fmt.Errorf("timeZone: %w", err)
+			}
+		}
+		return &freezeWindow{schedule: schedule, duration: duration, location: location}, nil
+	case w.Start != "" && w.End != "":
+		start, err := time.Parse(time.RFC3339, w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("start: %w", err)
+		}
+		end, err := time.Parse(time.RFC3339, w.End)
+		if err != nil {
+			return nil, fmt.Errorf("end: %w", err)
+		}
+		if !end.After(start) {
+			return nil, errors.New("end must be after start")
+		}
+		return &freezeWindow{start: start, end: end}, nil
+	default:
+		return nil, errors.New("either start and end or cron must be set")
+	}
+}
+
+// contains returns the bounds of the occurrence of the window containing t.
+func (w *freezeWindow) contains(t time.Time) (time.Time, time.Time, bool) {
+	if w.schedule == nil {
+		return w.start, w.end, !t.Before(w.start) && t.Before(w.end)
+	}
+	// Look for the latest start of the window which is less than its
+	// duration before t.
+	minute := t.In(w.location).Truncate(time.Minute)
+	for start := minute; t.Sub(start) < w.duration; start = start.Add(-time.Minute) {
+		if w.schedule.matches(start) {
+			return start, start.Add(w.duration), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// containsAny reports whether one of the tokens given, values, is one of the
+// tokens of the policy, list, which may be stored hashed.
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		hash := HashPolicyToken(v)
+		for _, token := range list {
+			if token != "" && storedPolicyToken(token) == hash {
+				return true
+			}
+		}
+	}
+	return false
+}
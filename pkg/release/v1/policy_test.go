@@ -0,0 +1,210 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestPolicyCheck(t *testing.T) {
+	// 2025-06-06 and 2026-01-02 are Fridays.
+	friday := "0 18 * * 5"
+	policy := &Policy{
+		FreezeWindows: []FreezeWindow{
+			{Start: "2025-12-20T00:00:00Z", End: "2026-01-05T00:00:00Z", Reason: "holidays"},
+			{Cron: friday, Duration: "62h"},
+		},
+		MinInterval:    "1h",
+		OverrideTokens: []string{"incident-42"},
+	}
+
+	tests := []struct {
+		name         string
+		policy       *Policy
+		op           PolicyOperation
+		now          string
+		lastDeployed string
+		tokens       []string
+		rule         string
+	}{
+		{name: "nil policy", op: PolicyUpgrade, now: "2025-12-24T12:00:00Z"},
+		{name: "outside the windows", policy: policy, op: PolicyUpgrade, now: "2025-06-04T12:00:00Z"},
+		{name: "interval freeze", policy: policy, op: PolicyUpgrade, now: "2025-12-24T12:00:00Z", rule: "freezeWindows[0]"},
+		{name: "window ends are excluded", policy: policy, op: PolicyUpgrade, now: "2026-01-05T08:00:00Z"},
+		{name: "recurring freeze start", policy: policy, op: PolicyRollback, now: "2025-06-06T18:00:00Z", rule: "freezeWindows[1]"},
+		{name: "recurring freeze weekend", policy: policy, op: PolicyUninstall, now: "2025-06-08T07:59:00Z", rule: "freezeWindows[1]"},
+		{name: "recurring freeze over", policy: policy, op: PolicyUpgrade, now: "2025-06-09T08:00:00Z"},
+		{name: "before recurring freeze", policy: policy, op: PolicyUpgrade, now: "2025-06-06T17:59:59Z"},
+		{name: "override token", policy: policy, op: PolicyUpgrade, now: "2025-12-24T12:00:00Z", tokens: []string{"other", "incident-42"}},
+		{name: "min interval", policy: policy, op: PolicyUpgrade, now: "2025-06-04T12:00:00Z", lastDeployed: "2025-06-04T11:30:00Z", rule: "minInterval"},
+		{name: "min interval elapsed", policy: policy, op: PolicyRollback, now: "2025-06-04T12:00:00Z", lastDeployed: "2025-06-04T11:00:00Z"},
+		{name: "min interval ignored by uninstall", policy: policy, op: PolicyUninstall, now: "2025-06-04T12:00:00Z", lastDeployed: "2025-06-04T11:30:00Z"},
+		{name: "approval missing", policy: &Policy{RequireFlag: []string{"approved-by-sre"}}, op: PolicyUpgrade, now: "2025-06-04T12:00:00Z", rule: "requireFlag"},
+		{name: "approval given", policy: &Policy{RequireFlag: []string{"approved-by-sre"}}, op: PolicyUpgrade, now: "2025-06-04T12:00:00Z", tokens: []string{"approved-by-sre"}},
+		{name: "empty token", policy: &Policy{RequireFlag: []string{""}}, op: PolicyUpgrade, now: "2025-06-04T12:00:00Z", tokens: []string{""}, rule: "requireFlag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lastDeployed time.Time
+			if tt.lastDeployed != "" {
+				lastDeployed = mustParseTime(t, tt.lastDeployed)
+			}
+			err := tt.policy.Check(tt.op, mustParseTime(t, tt.now), lastDeployed, tt.tokens)
+			if tt.rule == "" {
+				if err != nil {
+					t.Fatalf("expected no violation, got %v", err)
+				}
+				return
+			}
+			var violation *PolicyViolation
+			if !errors.As(err, &violation) {
+				t.Fatalf("expected a policy violation, got %v", err)
+			}
+			if violation.Rule != tt.rule {
+				t.Errorf("expected the rule %s to be violated, got %s", tt.rule, violation.Rule)
+			}
+		})
+	}
+}
+
+func TestPolicyHashedTokens(t *testing.T) {
+	policy := &Policy{RequireFlag: []string{"approved-by-sre"}, OverrideTokens: []string{"incident-42", HashPolicyToken("hotfix")}}
+	hashed := policy.WithHashedTokens()
+	if policy.RequireFlag[0] != "approved-by-sre" {
+		t.Error("the policy should not be modified")
+	}
+	for _, token := range append(hashed.RequireFlag, hashed.OverrideTokens...) {
+		if !strings.HasPrefix(token, "sha256:") {
+			t.Errorf("expected a hashed token, got %q", token)
+		}
+	}
+	if hashed.OverrideTokens[1] != HashPolicyToken("hotfix") {
+		t.Error("a token already hashed should be kept")
+	}
+
+	now := mustParseTime(t, "2025-06-04T12:00:00Z")
+	for _, tokens := range [][]string{{"approved-by-sre"}, {"incident-42"}, {"hotfix"}} {
+		if err := hashed.Check(PolicyUpgrade, now, time.Time{}, tokens); err != nil {
+			t.Errorf("expected %v to be accepted, got %v", tokens, err)
+		}
+	}
+	if err := hashed.Check(PolicyUpgrade, now, time.Time{}, []string{HashPolicyToken("approved-by-sre")}); err == nil {
+		t.Error("the hash of a token should not be accepted as the token")
+	}
+	if (*Policy)(nil).WithHashedTokens() != nil {
+		t.Error("expected a nil policy")
+	}
+}
+
+func TestPolicyCheckMessage(t *testing.T) {
+	policy := &Policy{FreezeWindows: []FreezeWindow{{Cron: "30 22 * * *", Duration: "8h", TimeZone: "Europe/Paris", Reason: "nightly batch"}}}
+	err := policy.Check(PolicyUpgrade, mustParseTime(t, "2025-06-05T01:00:00Z"), time.Time{}, nil)
+	expected := "release policy rule freezeWindows[0] violated: upgrade refused during the freeze window from 2025-06-04T22:30:00+02:00 to 2025-06-05T06:30:00+02:00: nightly batch"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		err    string
+	}{
+		{name: "empty", policy: Policy{}},
+		{name: "valid", policy: Policy{MinInterval: "30m", FreezeWindows: []FreezeWindow{{Start: "2025-12-20T00:00:00Z", End: "2026-01-05T00:00:00+01:00"}, {Cron: "0 0 1,15 * *", Duration: "24h"}}}},
+		{name: "bad interval", policy: Policy{MinInterval: "soon"}, err: "minInterval"},
+		{name: "negative interval", policy: Policy{MinInterval: "-1h"}, err: "must not be negative"},
+		{name: "empty window", policy: Policy{FreezeWindows: []FreezeWindow{{}}}, err: "freezeWindows[0]: either start and end or cron must be set"},
+		{name: "both kinds", policy: Policy{FreezeWindows: []FreezeWindow{{Start: "2025-12-20T00:00:00Z", Cron: "* * * * *"}}}, err: "not both"},
+		{name: "bad start", policy: Policy{FreezeWindows: []FreezeWindow{{Start: "2025-12-20", End: "2026-01-05T00:00:00Z"}}}, err: "start"},
+		{name: "end before start", policy: Policy{FreezeWindows: []FreezeWindow{{Start: "2026-01-05T00:00:00Z", End: "2025-12-20T00:00:00Z"}}}, err: "end must be after start"},
+		{name: "missing duration", policy: Policy{FreezeWindows: []FreezeWindow{{Cron: "0 18 * * 5"}}}, err: "duration"},
+		{name: "too long", policy: Policy{FreezeWindows: []FreezeWindow{{Cron: "0 18 * * 5", Duration: "800h"}}}, err: "at most"},
+		{name: "bad time zone", policy: Policy{FreezeWindows: []FreezeWindow{{Cron: "0 18 * * 5", Duration: "1h", TimeZone: "Mars/Olympus"}}}, err: "timeZone"},
+		{name: "bad cron", policy: Policy{FreezeWindows: []FreezeWindow{{Cron: "0 18 * *", Duration: "1h"}}}, err: "5 fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		matches []string
+		misses  []string
+		err     string
+	}{
+		{expr: "*/15 9-17 * * 1-5", matches: []string{"2025-06-02T09:00:00Z", "2025-06-06T17:45:00Z"}, misses: []string{"2025-06-02T09:10:00Z", "2025-06-07T10:00:00Z", "2025-06-02T18:00:00Z"}},
+		{expr: "0 0 * * 7", matches: []string{"2025-06-08T00:00:00Z"}, misses: []string{"2025-06-07T00:00:00Z"}},
+		{expr: "0 12 1 * 1", matches: []string{"2025-06-01T12:00:00Z", "2025-06-02T12:00:00Z"}, misses: []string{"2025-06-03T12:00:00Z"}},
+		{expr: "5/20 * * 12 *", matches: []string{"2025-12-01T00:05:00Z", "2025-12-01T00:45:00Z"}, misses: []string{"2025-11-01T00:05:00Z", "2025-12-01T00:00:00Z"}},
+		{expr: "60 * * * *", err: "out of the range"},
+		{expr: "* * 0 * *", err: "out of the range"},
+		{expr: "*/0 * * * *", err: "invalid step"},
+		{expr: "5-1 * * * *", err: "invalid range"},
+		{expr: "a * * * *", err: "invalid value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseCron(tt.expr)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range tt.matches {
+				if !s.matches(mustParseTime(t, m)) {
+					t.Errorf("expected %s to match", m)
+				}
+			}
+			for _, m := range tt.misses {
+				if s.matches(mustParseTime(t, m)) {
+					t.Errorf("expected %s not to match", m)
+				}
+			}
+		})
+	}
+}
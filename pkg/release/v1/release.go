@@ -17,6 +17,7 @@ package v1
 
 import (
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/time"
 )
 
 // Release describes a deployment of a chart, together with the chart
@@ -42,6 +43,22 @@ type Release struct {
 	// Labels of the release.
 	// Disabled encoding into Json cause labels are stored in storage driver metadata field.
 	Labels map[string]string `json:"-"`
+	// Annotations are free-form metadata of the release. Unlike labels, they
+	// are stored with the release and cannot be used to query the releases.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Policy constrains the upgrades, rollbacks and uninstalls of the release.
+	Policy *Policy `json:"policy,omitempty"`
+	// Pin blocks the upgrades, rollbacks and uninstalls of the release, unless
+	// it is overridden. It is carried over to the revisions created with the
+	// override.
+	Pin *Pin `json:"pin,omitempty"`
+}
+
+// Pin records why, by whom and since when a release is pinned.
+type Pin struct {
+	Reason   string    `json:"reason,omitempty"`
+	PinnedBy string    `json:"pinned_by,omitempty"`
+	Since    time.Time `json:"since"`
 }
 
 // SetStatus is a helper for setting the status on a release.
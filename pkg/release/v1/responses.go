@@ -21,4 +21,17 @@ type UninstallReleaseResponse struct {
 	Release *Release `json:"release,omitempty"`
 	// Info is an uninstall message
 	Info string `json:"info,omitempty"`
+	// KeptResources are the resources of the release which were not deleted,
+	// because of their resource policy or the keep filters of the uninstall.
+	KeptResources []KeptResource `json:"keptResources,omitempty"`
+}
+
+// KeptResource is a resource an uninstall did not delete.
+type KeptResource struct {
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the manifest of the resource, or the
+	// namespace of the release if the manifest sets none. It is empty for
+	// cluster-scoped resources.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
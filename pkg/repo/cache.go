@@ -0,0 +1,260 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"helm.sh/helm/v4/pkg/helmpath"
+)
+
+// CacheCategory is a kind of file kept in the repository cache.
+type CacheCategory string
+
+const (
+	// CacheCategoryIndexes are the repository indexes and chart lists
+	// downloaded by 'helm repo add' and 'helm repo update'.
+	CacheCategoryIndexes CacheCategory = "indexes"
+	// CacheCategoryCharts are the chart archives and provenance files
+	// downloaded when installing or upgrading a chart from a repository.
+	CacheCategoryCharts CacheCategory = "charts"
+	// CacheCategoryOCI are the indexes and chart lists built from the tags of
+	// OCI registries added with 'helm repo add'.
+	CacheCategoryOCI CacheCategory = "oci"
+)
+
+// CacheCategories are all the categories of the repository cache.
+var CacheCategories = []CacheCategory{CacheCategoryIndexes, CacheCategoryCharts, CacheCategoryOCI}
+
+// CacheEntry is a file of the repository cache.
+type CacheEntry struct {
+	Category CacheCategory `json:"category"`
+	Path     string        `json:"path"`
+	Size     int64         `json:"size"`
+	// LastUsed is when the file was last written, which Helm does every time
+	// it downloads it.
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// CacheUsage sums up the entries of a cache category.
+type CacheUsage struct {
+	Category CacheCategory `json:"category"`
+	Entries  int           `json:"entries"`
+	Size     int64         `json:"size"`
+}
+
+// CachePruneOptions selects the entries evicted by PruneCache.
+type CachePruneOptions struct {
+	// MaxSize is the total size the cache is brought under by evicting the
+	// least recently used entries first. Zero means no limit.
+	MaxSize int64
+	// OlderThan evicts the entries which were not used for this long. Zero
+	// means no limit.
+	OlderThan time.Duration
+	// All evicts every entry, regardless of the other limits.
+	All bool
+	// Categories restricts eviction to the entries of these categories. Empty
+	// means every category. The size of the other entries still counts
+	// towards MaxSize.
+	Categories []CacheCategory
+	// DryRun reports the entries which would be evicted without removing them.
+	DryRun bool
+}
+
+// cacheCategory returns the category of a file of the repository cache, or
+// false for files which are not cached content, such as lock files. oci holds
+// the names of the cached files of OCI registries.
+func cacheCategory(name string, oci map[string]bool) (CacheCategory, bool) {
+	switch {
+	case oci[name]:
+		return CacheCategoryOCI, true
+	case strings.HasSuffix(name, "index.yaml"), strings.HasSuffix(name, "charts.txt"):
+		return CacheCategoryIndexes, true
+	case strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".tgz.prov"):
+		return CacheCategoryCharts, true
+	}
+	return "", false
+}
+
+// ociCacheFiles returns the names of the files cached for the OCI registries
+// of the repositories file.
+func ociCacheFiles(repos *File) map[string]bool {
+	names := map[string]bool{}
+	if repos == nil {
+		return names
+	}
+	for _, e := range repos.Repositories {
+		if e.IsOCI() {
+			names[helmpath.CacheIndexFile(e.Name)] = true
+			names[helmpath.CacheChartsFile(e.Name)] = true
+		}
+	}
+	return names
+}
+
+// ScanCache lists the entries of the repository cache in dir, least recently
+// used first. The repositories file, which may be nil, tells the indexes of
+// OCI registries apart. A missing cache is empty.
+func ScanCache(dir string, repos *File) ([]CacheEntry, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read repository cache: %w", err)
+	}
+
+	oci := ociCacheFiles(repos)
+	var entries []CacheEntry
+	for _, f := range files {
+		if !f.Type().IsRegular() {
+			continue
+		}
+		category, ok := cacheCategory(f.Name(), oci)
+		if !ok {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			// The file was removed since the directory was read.
+			continue
+		}
+		entries = append(entries, CacheEntry{
+			Category: category,
+			Path:     filepath.Join(dir, f.Name()),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastUsed.Equal(entries[j].LastUsed) {
+			return entries[i].LastUsed.Before(entries[j].LastUsed)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// SummarizeCache sums up the entries of each category, always reporting every
+// category.
+func SummarizeCache(entries []CacheEntry) []CacheUsage {
+	usage := make([]CacheUsage, len(CacheCategories))
+	for i, c := range CacheCategories {
+		usage[i].Category = c
+	}
+	for _, e := range entries {
+		for i := range usage {
+			if usage[i].Category == e.Category {
+				usage[i].Entries++
+				usage[i].Size += e.Size
+			}
+		}
+	}
+	return usage
+}
+
+// PruneCache evicts entries of the repository cache in dir according to the
+// options, and returns the evicted entries. Files locked by another process,
+// see LockCacheFile, are left in place.
+func PruneCache(dir string, repos *File, opts CachePruneOptions) ([]CacheEntry, error) {
+	entries, err := ScanCache(dir, repos)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	var evicted []CacheEntry
+	var errs []error
+	now := time.Now()
+	for _, e := range entries {
+		tooOld := opts.OlderThan > 0 && now.Sub(e.LastUsed) > opts.OlderThan
+		tooBig := opts.MaxSize > 0 && total > opts.MaxSize
+		if !opts.All && !tooOld && !tooBig {
+			continue
+		}
+		if len(opts.Categories) > 0 && !slices.Contains(opts.Categories, e.Category) {
+			continue
+		}
+		if !opts.DryRun {
+			removed, err := removeUnlocked(e.Path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !removed {
+				continue
+			}
+		}
+		total -= e.Size
+		evicted = append(evicted, e)
+	}
+	return evicted, errors.Join(errs...)
+}
+
+// cacheLockPath returns the path of the lock file guarding a file of the
+// repository cache.
+func cacheLockPath(path string) string {
+	return path + ".lock"
+}
+
+// LockCacheFile waits for the lock guarding a file of the repository cache,
+// which keeps PruneCache from removing it while it is written. The returned
+// function releases the lock.
+func LockCacheFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	fileLock := flock.New(cacheLockPath(path))
+	if err := fileLock.Lock(); err != nil {
+		return nil, fmt.Errorf("unable to lock %s: %w", path, err)
+	}
+	return func() { fileLock.Unlock() }, nil
+}
+
+// removeUnlocked removes a file of the repository cache, unless another
+// process holds its lock. The lock is held while the file is removed, and the
+// lock file itself is kept, as removing it would let another process lock a
+// new lock file in the meantime.
+func removeUnlocked(path string) (bool, error) {
+	fileLock := flock.New(cacheLockPath(path))
+	locked, err := fileLock.TryLock()
+	if err != nil {
+		return false, fmt.Errorf("unable to lock %s: %w", path, err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer fileLock.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("unable to remove %s: %w", path, err)
+	}
+	return true, nil
+}
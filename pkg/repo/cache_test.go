@@ -0,0 +1,199 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCacheFile writes a file of the given size to the cache, last used age
+// before now.
+func writeCacheFile(t *testing.T, dir, name string, size int, now time.Time, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+	used := now.Add(-age)
+	require.NoError(t, os.Chtimes(path, used, used))
+}
+
+func evictedNames(entries []CacheEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, filepath.Base(e.Path))
+	}
+	return names
+}
+
+func testCache(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	writeCacheFile(t, dir, "stable-index.yaml", 100, now, time.Hour)
+	writeCacheFile(t, dir, "stable-charts.txt", 10, now, time.Hour)
+	writeCacheFile(t, dir, "nginx-1.0.0.tgz", 50, now, 48*time.Hour)
+	writeCacheFile(t, dir, "nginx-1.0.0.tgz.prov", 5, now, 48*time.Hour)
+	writeCacheFile(t, dir, "redis-2.0.0.tgz", 70, now, 2*time.Hour)
+	writeCacheFile(t, dir, "registry-index.yaml", 40, now, 96*time.Hour)
+	writeCacheFile(t, dir, "repositories.lock", 0, now, 72*time.Hour)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0755))
+	return dir
+}
+
+// testRepositories has the OCI registry whose index is in testCache.
+var testRepositories = &File{Repositories: []*Entry{
+	{Name: "stable", URL: "https://charts.example.com"},
+	{Name: "registry", URL: "oci://registry.example.com/charts"},
+}}
+
+func TestScanCache(t *testing.T) {
+	dir := testCache(t)
+
+	entries, err := ScanCache(dir, testRepositories)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"registry-index.yaml", "nginx-1.0.0.tgz", "nginx-1.0.0.tgz.prov", "redis-2.0.0.tgz", "stable-charts.txt", "stable-index.yaml",
+	}, evictedNames(entries))
+
+	assert.Equal(t, []CacheUsage{
+		{Category: CacheCategoryIndexes, Entries: 2, Size: 110},
+		{Category: CacheCategoryCharts, Entries: 3, Size: 125},
+		{Category: CacheCategoryOCI, Entries: 1, Size: 40},
+	}, SummarizeCache(entries))
+
+	// Without the repositories file, the index of the registry cannot be told
+	// apart from the index of a chart repository.
+	entries, err = ScanCache(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, CacheUsage{Category: CacheCategoryIndexes, Entries: 3, Size: 150}, SummarizeCache(entries)[0])
+}
+
+func TestScanCacheMissing(t *testing.T) {
+	entries, err := ScanCache(filepath.Join(t.TempDir(), "missing"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, []CacheUsage{
+		{Category: CacheCategoryIndexes},
+		{Category: CacheCategoryCharts},
+		{Category: CacheCategoryOCI},
+	}, SummarizeCache(entries))
+}
+
+func TestPruneCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    CachePruneOptions
+		evicted []string
+	}{
+		{
+			name:    "max size evicts least recently used first",
+			opts:    CachePruneOptions{MaxSize: 150},
+			evicted: []string{"registry-index.yaml", "nginx-1.0.0.tgz", "nginx-1.0.0.tgz.prov", "redis-2.0.0.tgz"},
+		},
+		{
+			name:    "older than",
+			opts:    CachePruneOptions{OlderThan: 24 * time.Hour},
+			evicted: []string{"registry-index.yaml", "nginx-1.0.0.tgz", "nginx-1.0.0.tgz.prov"},
+		},
+		{
+			name:    "max size of a category",
+			opts:    CachePruneOptions{MaxSize: 150, Categories: []CacheCategory{CacheCategoryCharts}},
+			evicted: []string{"nginx-1.0.0.tgz", "nginx-1.0.0.tgz.prov", "redis-2.0.0.tgz"},
+		},
+		{
+			name:    "all of a category",
+			opts:    CachePruneOptions{All: true, Categories: []CacheCategory{CacheCategoryOCI}},
+			evicted: []string{"registry-index.yaml"},
+		},
+		{
+			name: "no limits",
+			opts: CachePruneOptions{},
+		},
+		{
+			name:    "all",
+			opts:    CachePruneOptions{All: true},
+			evicted: []string{"registry-index.yaml", "nginx-1.0.0.tgz", "nginx-1.0.0.tgz.prov", "redis-2.0.0.tgz", "stable-charts.txt", "stable-index.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testCache(t)
+
+			evicted, err := PruneCache(dir, testRepositories, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.evicted, evictedNames(evicted))
+			for _, name := range tt.evicted {
+				assert.NoFileExists(t, filepath.Join(dir, name))
+			}
+			assert.FileExists(t, filepath.Join(dir, "repositories.lock"))
+		})
+	}
+}
+
+func TestPruneCacheDryRun(t *testing.T) {
+	dir := testCache(t)
+
+	evicted, err := PruneCache(dir, testRepositories, CachePruneOptions{All: true, DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, evicted, 6)
+	for _, e := range evicted {
+		assert.FileExists(t, e.Path)
+	}
+}
+
+func TestPruneCacheSkipsLockedFiles(t *testing.T) {
+	dir := testCache(t)
+	locked := filepath.Join(dir, "nginx-1.0.0.tgz")
+
+	unlock, err := LockCacheFile(locked)
+	require.NoError(t, err)
+
+	evicted, err := PruneCache(dir, testRepositories, CachePruneOptions{OlderThan: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry-index.yaml", "nginx-1.0.0.tgz.prov"}, evictedNames(evicted))
+	assert.FileExists(t, locked)
+
+	// Once released, the file is evicted and its lock file is kept.
+	unlock()
+	evicted, err = PruneCache(dir, testRepositories, CachePruneOptions{OlderThan: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nginx-1.0.0.tgz"}, evictedNames(evicted))
+	assert.NoFileExists(t, locked)
+	assert.FileExists(t, locked+".lock")
+}
+
+func TestLockCacheFileBlocksPruning(t *testing.T) {
+	dir := testCache(t)
+	locked := filepath.Join(dir, "stable-index.yaml")
+
+	// The lock is held by another process, which flock tells apart from this
+	// one by the file descriptor.
+	fileLock := flock.New(locked + ".lock")
+	require.NoError(t, fileLock.Lock())
+	defer fileLock.Unlock()
+
+	evicted, err := PruneCache(dir, testRepositories, CachePruneOptions{All: true})
+	require.NoError(t, err)
+	assert.NotContains(t, evictedNames(evicted), "stable-index.yaml")
+	assert.FileExists(t, locked)
+}
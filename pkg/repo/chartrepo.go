@@ -43,6 +43,14 @@ type Entry struct {
 	CAFile                string `json:"caFile"`
 	InsecureSkipTLSverify bool   `json:"insecure_skip_tls_verify"`
 	PassCredentialsAll    bool   `json:"pass_credentials_all"`
+	// CredentialDomains are the hosts, besides the one of the repository, to
+	// which its credentials are sent, such as the CDN serving its charts.
+	CredentialDomains []string `json:"credentialDomains,omitempty"`
+	// Charts are the charts indexed when the entry points to an OCI
+	// registry. When empty, every chart of the namespace is indexed.
+	Charts []string `json:"charts,omitempty"`
+	// PlainHTTP uses HTTP rather than HTTPS to reach an OCI registry.
+	PlainHTTP bool `json:"plain_http,omitempty"`
 }
 
 // ChartRepository represents a chart repository
@@ -51,6 +59,13 @@ type ChartRepository struct {
 	IndexFile *IndexFile
 	Client    getter.Getter
 	CachePath string
+	// RegistryClient lists the charts of the repository when it is an OCI
+	// registry, which has no index file.
+	RegistryClient OCIIndexClient
+
+	// bearerToken is sent to the repository when downloading its index, for
+	// the repositories which are not configured, see WithBearerToken.
+	bearerToken string
 }
 
 // NewChartRepository constructs ChartRepository
@@ -75,6 +90,10 @@ func NewChartRepository(cfg *Entry, getters getter.Providers) (*ChartRepository,
 
 // DownloadIndexFile fetches the index from a repository.
 func (r *ChartRepository) DownloadIndexFile() (string, error) {
+	if r.Config.IsOCI() {
+		return r.downloadOCIIndexFile()
+	}
+
 	indexURL, err := ResolveReferenceURL(r.Config.URL, "index.yaml")
 	if err != nil {
 		return "", err
@@ -85,7 +104,9 @@ func (r *ChartRepository) DownloadIndexFile() (string, error) {
 		getter.WithInsecureSkipVerifyTLS(r.Config.InsecureSkipTLSverify),
 		getter.WithTLSClientConfig(r.Config.CertFile, r.Config.KeyFile, r.Config.CAFile),
 		getter.WithBasicAuth(r.Config.Username, r.Config.Password),
+		getter.WithBearerToken(r.bearerToken),
 		getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
+		getter.WithCredentialDomains(r.Config.CredentialDomains),
 	)
 	if err != nil {
 		return "", err
@@ -107,18 +128,29 @@ func (r *ChartRepository) DownloadIndexFile() (string, error) {
 		fmt.Fprintln(&charts, name)
 	}
 	chartsFile := filepath.Join(r.CachePath, helmpath.CacheChartsFile(r.Config.Name))
-	os.MkdirAll(filepath.Dir(chartsFile), 0755)
-	os.WriteFile(chartsFile, []byte(charts.String()), 0644)
+	if err := writeLockedCacheFile(chartsFile, []byte(charts.String())); err != nil {
+		return "", err
+	}
 
 	// Create the index file in the cache directory
 	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
-	os.MkdirAll(filepath.Dir(fname), 0755)
-	return fname, os.WriteFile(fname, index, 0644)
+	return fname, writeLockedCacheFile(fname, index)
+}
+
+// writeLockedCacheFile writes a file of the repository cache while holding its lock.
+func writeLockedCacheFile(path string, data []byte) error {
+	unlock, err := LockCacheFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return os.WriteFile(path, data, 0644)
 }
 
 type findChartInRepoURLOptions struct {
 	Username              string
 	Password              string
+	BearerToken           string
 	PassCredentialsAll    bool
 	InsecureSkipTLSverify bool
 	CertFile              string
@@ -144,6 +176,13 @@ func WithUsernamePassword(username, password string) FindChartInRepoURLOption {
 	}
 }
 
+// WithBearerToken specifies the bearer token for the repository
+func WithBearerToken(token string) FindChartInRepoURLOption {
+	return func(options *findChartInRepoURLOptions) {
+		options.BearerToken = token
+	}
+}
+
 // WithPassCredentialsAll flags whether credentials should be passed on to other domains
 func WithPassCredentialsAll(passCredentialsAll bool) FindChartInRepoURLOption {
 	return func(options *findChartInRepoURLOptions) {
@@ -196,6 +235,7 @@ func FindChartInRepoURL(repoURL string, chartName string, getters getter.Provide
 	if err != nil {
 		return "", err
 	}
+	r.bearerToken = opts.BearerToken
 	idx, err := r.DownloadIndexFile()
 	if err != nil {
 		return "", fmt.Errorf("looks like %q is not a valid chart repository or cannot be reached: %w", repoURL, err)
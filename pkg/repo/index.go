@@ -18,13 +18,17 @@ package repo
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
@@ -36,7 +40,6 @@ import (
 	"helm.sh/helm/v4/internal/urlutil"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
-	"helm.sh/helm/v4/pkg/provenance"
 )
 
 // APIVersionV1 is the v1 API version for index and repository files.
@@ -225,11 +228,68 @@ func (i IndexFile) Get(name, version string) (*ChartVersion, error) {
 //
 // The mode on the file is set to 'mode'.
 func (i IndexFile) WriteFile(dest string, mode os.FileMode) error {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(i.writeYAML(w))
+	}()
+	err := fileutil.AtomicWriteFile(dest, r, mode)
+	r.Close()
+	return err
+}
+
+// writeYAML writes the index as YAML one chart at a time, so that the YAML of
+// the whole index is never held in memory. It writes what yaml.Marshal does.
+func (i IndexFile) writeYAML(w io.Writer) error {
+	entries := i.Entries
+	i.Entries = nil
 	b, err := yaml.Marshal(i)
 	if err != nil {
 		return err
 	}
-	return fileutil.AtomicWriteFile(dest, bytes.NewReader(b), mode)
+	// The fields are in the order of their keys, entries being one of them.
+	head, tail, ok := bytes.Cut(b, []byte("\nentries: null\n"))
+	if !ok {
+		return errors.New("unable to find the entries of the index")
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", head); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if _, err := io.WriteString(w, "entries: {}\n"); err != nil {
+			return err
+		}
+		_, err := w.Write(tail)
+		return err
+	}
+
+	if _, err := io.WriteString(w, "entries:\n"); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b, err := yaml.Marshal(map[string]ChartVersions{name: entries[name]})
+		if err != nil {
+			return err
+		}
+		// The charts are nested under entries, the empty lines of block
+		// scalars excepted.
+		for line := range bytes.Lines(b) {
+			if len(bytes.TrimRight(line, "\n")) > 0 {
+				if _, err := io.WriteString(w, "  "); err != nil {
+					return err
+				}
+			}
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = w.Write(tail)
+	return err
 }
 
 // WriteJSONFile writes an index file in JSON format to the given destination
@@ -246,7 +306,8 @@ func (i IndexFile) WriteJSONFile(dest string, mode os.FileMode) error {
 
 // Merge merges the given index file into this index.
 //
-// This merges by name and version.
+// This merges by name and version, and by digest: an entry of the given index
+// whose archive is already in this index is a duplicate.
 //
 // If one of the entries in the given index does _not_ already exist, it is added.
 // In all other cases, the existing record is preserved.
@@ -255,7 +316,7 @@ func (i IndexFile) WriteJSONFile(dest string, mode os.FileMode) error {
 func (i *IndexFile) Merge(f *IndexFile) {
 	for _, cvs := range f.Entries {
 		for _, cv := range cvs {
-			if !i.Has(cv.Name, cv.Version) {
+			if !i.Has(cv.Name, cv.Version) && !i.hasDigest(cv.Name, cv.Digest) {
 				e := i.Entries[cv.Name]
 				i.Entries[cv.Name] = append(e, cv)
 			}
@@ -263,6 +324,19 @@ func (i *IndexFile) Merge(f *IndexFile) {
 	}
 }
 
+// hasDigest reports whether a version of the chart name has the given digest.
+func (i IndexFile) hasDigest(name, digest string) bool {
+	if digest == "" {
+		return false
+	}
+	for _, cv := range i.Entries[name] {
+		if cv.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
+
 // ChartVersion represents a chart entry in the IndexFile
 type ChartVersion struct {
 	*chart.Metadata
@@ -289,9 +363,14 @@ type ChartVersion struct {
 	URLDeprecated string `json:"url,omitempty"`
 }
 
+// indexWorkers is the number of chart archives IndexDirectory reads at once.
+var indexWorkers = runtime.NumCPU()
+
 // IndexDirectory reads a (flat) directory and generates an index.
 //
-// It indexes only charts that have been packaged (*.tgz).
+// It indexes only charts that have been packaged (*.tgz). The archives are
+// streamed by a bounded pool of workers: only the metadata of each chart is
+// kept in memory, and its digest is computed while the archive is read.
 //
 // The index returned will be in an unsorted state
 func IndexDirectory(dir, baseURL string) (*IndexFile, error) {
@@ -305,8 +384,46 @@ func IndexDirectory(dir, baseURL string) (*IndexFile, error) {
 	}
 	archives = append(archives, moreArchives...)
 
+	type result struct {
+		metadata *chart.Metadata
+		digest   string
+		err      error
+	}
+	// Every archive has its own channel, for the results to be added in
+	// order while the following archives are read.
+	results := make([]chan result, len(archives))
+	for j := range results {
+		results[j] = make(chan result, 1)
+	}
+	jobs := make(chan int)
+	done := make(chan struct{})
+	defer close(done)
+	for range max(1, min(indexWorkers, len(archives))) {
+		go func() {
+			for j := range jobs {
+				var r result
+				r.metadata, r.digest, r.err = readArchive(archives[j])
+				results[j] <- r
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for j := range archives {
+			select {
+			case jobs <- j:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// The archives are added in order, for the index to be the same whatever
+	// the order in which they were read.
 	index := NewIndexFile()
-	for _, arch := range archives {
+	for j, arch := range archives {
+		r := <-results[j]
+
 		fname, err := filepath.Rel(dir, arch)
 		if err != nil {
 			return index, err
@@ -321,22 +438,71 @@ func IndexDirectory(dir, baseURL string) (*IndexFile, error) {
 			parentURL = path.Join(baseURL, parentDir)
 		}
 
-		c, err := loader.Load(arch)
-		if err != nil {
+		if r.err != nil {
+			return index, r.err
+		}
+		if r.metadata == nil {
 			// Assume this is not a chart.
 			continue
 		}
-		hash, err := provenance.DigestFile(arch)
-		if err != nil {
-			return index, err
-		}
-		if err := index.MustAdd(c.Metadata, fname, parentURL, hash); err != nil {
+		if err := index.MustAdd(r.metadata, fname, parentURL, r.digest); err != nil {
 			return index, fmt.Errorf("failed adding to %s to index: %w", fname, err)
 		}
 	}
 	return index, nil
 }
 
+// readArchive streams a chart archive once, reading the metadata of the chart
+// and computing the SHA256 digest of the archive. The metadata is nil if the
+// archive is not a valid chart, and an error is only returned when the archive
+// cannot be read.
+func readArchive(name string) (*chart.Metadata, string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	md, digest, err := readChartArchive(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read %s: %w", name, err)
+	}
+	return md, digest, nil
+}
+
+// readChartArchive reads the metadata and computes the digest of the chart
+// archive in r, like readArchive.
+func readChartArchive(r io.Reader) (*chart.Metadata, string, error) {
+	in := &readErrorRecorder{r: r}
+	hash := sha256.New()
+	md, err := loader.LoadArchiveMetadata(io.TeeReader(in, hash))
+	if in.err != nil {
+		return nil, "", in.err
+	}
+	if err != nil {
+		return nil, "", nil
+	}
+	// Hash what remains after the end of the tar stream.
+	if _, err := io.Copy(hash, in); err != nil {
+		return nil, "", err
+	}
+	return md, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readErrorRecorder records the errors of the reads of r, which tell the
+// archives which cannot be read apart from the ones which are not valid.
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
 // loadIndex loads an index file and does minimal validity checking.
 //
 // The source parameter is only used for logging.
@@ -20,18 +20,26 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
+	"testing/iotest"
+
+	"sigs.k8s.io/yaml"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/provenance"
 )
 
 const (
@@ -251,6 +259,15 @@ func TestMerge(t *testing.T) {
 		t.Errorf("Expected %q version to be 0.2.0, got %s", v.Name, v.Version)
 	}
 
+	// An archive already indexed is not added again, whatever its version.
+	ind3 := NewIndexFile()
+	if err := ind3.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "dreadnought", Version: "0.1.0+rebuilt"}, "dreadnought-0.1.0+rebuilt.tgz", "http://example.com", "aaaa"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ind1.Merge(ind3)
+	if vs := ind1.Entries["dreadnought"]; len(vs) != 2 {
+		t.Errorf("Expected the duplicate digest to be skipped, got %d versions", len(vs))
+	}
 }
 
 func TestDownloadIndexFile(t *testing.T) {
@@ -506,6 +523,127 @@ func TestIndexDirectory(t *testing.T) {
 			t.Errorf("Expected %q, got %q", cname, frob.Name)
 		}
 	}
+
+	// The digests are computed while streaming the archives, and must be
+	// those of the whole files.
+	digest, err := provenance.DigestFile(filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := index.Entries["frobnitz"][0].Digest; d != digest {
+		t.Errorf("Expected digest %q, got %q", digest, d)
+	}
+}
+
+func TestIndexDirectorySkipsInvalidCharts(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("testdata/repository/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "frobnitz-1.2.3.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "invalid-1.0.0.tgz"), []byte("not a chart"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := IndexDirectory(dir, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries) != 1 || len(index.Entries["frobnitz"]) != 1 {
+		t.Errorf("Expected only frobnitz to be indexed, got %v", index.Entries)
+	}
+}
+
+func TestReadChartArchiveError(t *testing.T) {
+	data, err := os.ReadFile("testdata/repository/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errRead := errors.New("input/output error")
+	_, _, err = readChartArchive(io.MultiReader(bytes.NewReader(data[:len(data)/2]), iotest.ErrReader(errRead)))
+	if !errors.Is(err, errRead) {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+}
+
+func TestIndexDirectoryWorkers(t *testing.T) {
+	dir := "testdata/repository"
+	expected, err := IndexDirectory(dir, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(n int) { indexWorkers = n }(indexWorkers)
+	indexWorkers = 1
+	index, err := IndexDirectory(dir, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, cvs := range expected.Entries {
+		if len(index.Entries[name]) != len(cvs) {
+			t.Fatalf("Expected %d versions of %s, got %d", len(cvs), name, len(index.Entries[name]))
+		}
+		for i, cv := range cvs {
+			if got := index.Entries[name][i]; got.Version != cv.Version || got.Digest != cv.Digest {
+				t.Errorf("Expected %s %s (%s), got %s (%s)", name, cv.Version, cv.Digest, got.Version, got.Digest)
+			}
+		}
+	}
+}
+
+// BenchmarkIndexDirectory compares the memory used to index a directory of
+// 1000 charts by streaming their metadata with the one used by loading each
+// chart in full, as IndexDirectory used to do. Compare the B/op of the two.
+func BenchmarkIndexDirectory(b *testing.B) {
+	dir := b.TempDir()
+	template := []byte(strings.Repeat("# padding to make the chart archive sizeable\n", 2000))
+	for i := range 1000 {
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: fmt.Sprintf("chart%d", i), Version: "1.0.0"},
+			Templates: []*chart.File{
+				{Name: "templates/configmap.yaml", Data: template},
+			},
+		}
+		if _, err := chartutil.Save(c, dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := IndexDirectory(dir, "http://example.com"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("full load", func(b *testing.B) {
+		b.ReportAllocs()
+		archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			index := NewIndexFile()
+			for _, arch := range archives {
+				c, err := loader.Load(arch)
+				if err != nil {
+					b.Fatal(err)
+				}
+				digest, err := provenance.DigestFile(arch)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := index.MustAdd(c.Metadata, filepath.Base(arch), "http://example.com", digest); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
 }
 
 func TestIndexAdd(t *testing.T) {
@@ -561,6 +699,32 @@ func TestIndexWrite(t *testing.T) {
 	}
 }
 
+func TestIndexWriteStreamsYAML(t *testing.T) {
+	for _, f := range []string{testfile, annotationstestfile, chartmuseumtestfile} {
+		i, err := LoadIndexFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i.Entries["multiline"] = ChartVersions{{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: "multiline", Version: "1.0.0", Description: "first\n\n  indented\nlast\n"},
+			URLs:     []string{"multiline-1.0.0.tgz"},
+		}}
+		for _, index := range []*IndexFile{i, NewIndexFile()} {
+			expected, err := yaml.Marshal(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := index.writeYAML(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(expected) {
+				t.Errorf("%s: expected the index to be written as\n%s\ngot\n%s", f, expected, got.String())
+			}
+		}
+	}
+}
+
 func TestIndexJSONWrite(t *testing.T) {
 	i := NewIndexFile()
 	if err := i.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "clipper", Version: "0.1.0"}, "clipper-0.1.0.tgz", "http://example.com/charts", "sha256:1234567890"); err != nil {
@@ -0,0 +1,160 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/registry"
+)
+
+// DefaultOCIIndexTTL is how long the index built for an OCI registry is used
+// before the registry is listed again.
+const DefaultOCIIndexTTL = time.Hour
+
+// OCIIndexClient lists the charts stored in an OCI registry. It is
+// implemented by *registry.Client.
+type OCIIndexClient interface {
+	// Tags lists the versions of the chart stored at a HOST/NAMESPACE/NAME
+	// reference.
+	Tags(ref string) ([]string, error)
+	// Repositories lists the charts stored under a HOST/NAMESPACE.
+	Repositories(namespace string) ([]string, error)
+}
+
+// IsOCI reports whether the entry points to a namespace of an OCI registry
+// rather than to an HTTP repository serving an index.yaml.
+func (e *Entry) IsOCI() bool {
+	return registry.IsOCI(e.URL)
+}
+
+// BuildOCIIndex builds an index of the charts stored under the namespace of
+// an OCI registry the entry points to, with one version per tag.
+//
+// The charts indexed are the ones named by the entry, or every repository of
+// the namespace listed by the catalog of the registry when it names none. As
+// the charts are not pulled, the versions only carry the name, the version and
+// the reference of the chart.
+func BuildOCIIndex(entry *Entry, client OCIIndexClient) (*IndexFile, error) {
+	if !entry.IsOCI() {
+		return nil, fmt.Errorf("%s is not an OCI registry", entry.URL)
+	}
+	namespace := strings.TrimSuffix(strings.TrimPrefix(entry.URL, registry.OCIScheme+"://"), "/")
+
+	names := entry.Charts
+	if len(names) == 0 {
+		var err error
+		names, err = client.Repositories(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the charts of %s: %w", entry.URL, err)
+		}
+	}
+
+	index := NewIndexFile()
+	for _, name := range names {
+		ref := fmt.Sprintf("%s/%s", namespace, name)
+		tags, err := client.Tags(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the versions of chart %s: %w", ref, err)
+		}
+		for _, tag := range tags {
+			index.Entries[name] = append(index.Entries[name], &ChartVersion{
+				Metadata: &chart.Metadata{
+					APIVersion: chart.APIVersionV2,
+					Name:       name,
+					Version:    tag,
+				},
+				URLs: []string{fmt.Sprintf("%s://%s:%s", registry.OCIScheme, ref, tag)},
+			})
+		}
+	}
+	index.SortEntries()
+	return index, nil
+}
+
+// LoadOCIIndex returns the index of the OCI registry the entry points to.
+//
+// The index cached in cacheDir is used while it is younger than ttl. Otherwise
+// the index is built again and cached. When the registry cannot be listed, a
+// stale cached index is used rather than failing.
+func LoadOCIIndex(entry *Entry, client OCIIndexClient, cacheDir string, ttl time.Duration) (*IndexFile, error) {
+	fname := filepath.Join(cacheDir, helmpath.CacheIndexFile(entry.Name))
+	if info, err := os.Stat(fname); err == nil && time.Since(info.ModTime()) < ttl {
+		if index, err := LoadIndexFile(fname); err == nil {
+			return index, nil
+		}
+	}
+
+	index, err := BuildOCIIndex(entry, client)
+	if err != nil {
+		cached, cacheErr := LoadIndexFile(fname)
+		if cacheErr != nil {
+			return nil, err
+		}
+		slog.Warn("using stale index of repository", "name", entry.Name, slog.Any("error", err))
+		return cached, nil
+	}
+	if _, err := writeIndexCache(index, cacheDir, entry.Name); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// writeIndexCache writes an index and the list of its charts in cacheDir, and
+// returns the path of the index.
+func writeIndexCache(index *IndexFile, cacheDir, name string) (string, error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+
+	var charts strings.Builder
+	for chartName := range index.Entries {
+		fmt.Fprintln(&charts, chartName)
+	}
+	chartsFile := filepath.Join(cacheDir, helmpath.CacheChartsFile(name))
+	if err := writeLockedCacheFile(chartsFile, []byte(charts.String())); err != nil {
+		return "", err
+	}
+
+	fname := filepath.Join(cacheDir, helmpath.CacheIndexFile(name))
+	unlock, err := LockCacheFile(fname)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	return fname, index.WriteFile(fname, 0644)
+}
+
+// downloadOCIIndexFile builds the index of an OCI registry and caches it.
+func (r *ChartRepository) downloadOCIIndexFile() (string, error) {
+	if r.RegistryClient == nil {
+		return "", errors.New("a registry client is required to index an OCI registry")
+	}
+	index, err := BuildOCIIndex(r.Config, r.RegistryClient)
+	if err != nil {
+		return "", err
+	}
+	return writeIndexCache(index, r.CachePath, r.Config.Name)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/helmpath"
+)
+
+// fakeOCIIndexClient serves the tags of the charts of a registry namespace.
+type fakeOCIIndexClient struct {
+	namespace string
+	tags      map[string][]string
+	err       error
+	calls     int
+}
+
+func (c *fakeOCIIndexClient) Tags(ref string) ([]string, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	name, ok := strings.CutPrefix(ref, c.namespace+"/")
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	tags, ok := c.tags[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return tags, nil
+}
+
+func (c *fakeOCIIndexClient) Repositories(namespace string) ([]string, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if namespace != c.namespace {
+		return nil, nil
+	}
+	var names []string
+	for name := range c.tags {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func newFakeOCIIndexClient() *fakeOCIIndexClient {
+	return &fakeOCIIndexClient{
+		namespace: "registry.example.com/charts",
+		tags: map[string][]string{
+			"alpine": {"0.2.0", "0.1.0", "0.3.0-rc.1"},
+			"nginx":  {"1.0.0+build.1"},
+		},
+	}
+}
+
+func TestEntryIsOCI(t *testing.T) {
+	assert.True(t, (&Entry{URL: "oci://registry.example.com/charts"}).IsOCI())
+	assert.False(t, (&Entry{URL: "https://charts.example.com"}).IsOCI())
+}
+
+func TestBuildOCIIndex(t *testing.T) {
+	client := newFakeOCIIndexClient()
+
+	index, err := BuildOCIIndex(&Entry{Name: "oci", URL: "oci://registry.example.com/charts/"}, client)
+	require.NoError(t, err)
+
+	require.Len(t, index.Entries, 2)
+	alpine := index.Entries["alpine"]
+	require.Len(t, alpine, 3)
+	assert.Equal(t, "0.3.0-rc.1", alpine[0].Version)
+	assert.Equal(t, "0.2.0", alpine[1].Version)
+	assert.Equal(t, "0.1.0", alpine[2].Version)
+	assert.Equal(t, "alpine", alpine[0].Name)
+	assert.Equal(t, "v2", alpine[0].APIVersion)
+	assert.Equal(t, []string{"oci://registry.example.com/charts/alpine:0.2.0"}, alpine[1].URLs)
+
+	cv, err := index.Get("nginx", "1.0.0+build.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"oci://registry.example.com/charts/nginx:1.0.0+build.1"}, cv.URLs)
+}
+
+func TestBuildOCIIndexCharts(t *testing.T) {
+	client := newFakeOCIIndexClient()
+
+	index, err := BuildOCIIndex(&Entry{Name: "oci", URL: "oci://registry.example.com/charts", Charts: []string{"nginx"}}, client)
+	require.NoError(t, err)
+	assert.Len(t, index.Entries, 1)
+	assert.True(t, index.Has("nginx", "1.0.0+build.1"))
+
+	_, err = BuildOCIIndex(&Entry{Name: "oci", URL: "oci://registry.example.com/charts", Charts: []string{"missing"}}, client)
+	assert.ErrorContains(t, err, "unable to list the versions of chart registry.example.com/charts/missing")
+}
+
+func TestBuildOCIIndexErrors(t *testing.T) {
+	_, err := BuildOCIIndex(&Entry{Name: "http", URL: "https://charts.example.com"}, newFakeOCIIndexClient())
+	assert.ErrorContains(t, err, "is not an OCI registry")
+
+	client := newFakeOCIIndexClient()
+	client.err = errors.New("catalog unsupported")
+	_, err = BuildOCIIndex(&Entry{Name: "oci", URL: "oci://registry.example.com/charts"}, client)
+	assert.ErrorIs(t, err, client.err)
+	assert.ErrorContains(t, err, "unable to list the charts of oci://registry.example.com/charts")
+}
+
+func TestLoadOCIIndex(t *testing.T) {
+	dir := t.TempDir()
+	entry := &Entry{Name: "oci", URL: "oci://registry.example.com/charts"}
+	client := newFakeOCIIndexClient()
+
+	index, err := LoadOCIIndex(entry, client, dir, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, index.Has("alpine", "0.2.0"))
+	calls := client.calls
+	assert.FileExists(t, filepath.Join(dir, helmpath.CacheIndexFile(entry.Name)))
+	charts, err := os.ReadFile(filepath.Join(dir, helmpath.CacheChartsFile(entry.Name)))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alpine", "nginx"}, strings.Fields(string(charts)))
+
+	// The cached index is used while it is fresh.
+	client.tags["redis"] = []string{"1.0.0"}
+	index, err = LoadOCIIndex(entry, client, dir, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, calls, client.calls)
+	assert.False(t, index.Has("redis", "1.0.0"))
+
+	// The index is built again once the cached one has expired.
+	stale := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, helmpath.CacheIndexFile(entry.Name)), stale, stale))
+	index, err = LoadOCIIndex(entry, client, dir, time.Hour)
+	require.NoError(t, err)
+	assert.Greater(t, client.calls, calls)
+	assert.True(t, index.Has("redis", "1.0.0"))
+}
+
+func TestLoadOCIIndexStale(t *testing.T) {
+	dir := t.TempDir()
+	entry := &Entry{Name: "oci", URL: "oci://registry.example.com/charts"}
+	client := newFakeOCIIndexClient()
+
+	_, err := LoadOCIIndex(entry, client, dir, 0)
+	require.NoError(t, err)
+
+	// A stale index is used when the registry cannot be listed.
+	client.err = errors.New("registry unreachable")
+	index, err := LoadOCIIndex(entry, client, dir, 0)
+	require.NoError(t, err)
+	assert.True(t, index.Has("alpine", "0.2.0"))
+
+	// Without a cached index, the error is returned.
+	_, err = LoadOCIIndex(entry, client, t.TempDir(), 0)
+	assert.ErrorIs(t, err, client.err)
+}
+
+func TestDownloadOCIIndexFile(t *testing.T) {
+	dir := t.TempDir()
+	r := &ChartRepository{
+		Config:         &Entry{Name: "oci", URL: "oci://registry.example.com/charts"},
+		IndexFile:      NewIndexFile(),
+		CachePath:      dir,
+		RegistryClient: newFakeOCIIndexClient(),
+	}
+
+	fname, err := r.DownloadIndexFile()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, helmpath.CacheIndexFile("oci")), fname)
+	index, err := LoadIndexFile(fname)
+	require.NoError(t, err)
+	assert.True(t, index.Has("nginx", "1.0.0+build.1"))
+	assert.FileExists(t, filepath.Join(dir, helmpath.CacheChartsFile("oci")))
+
+	r.RegistryClient = nil
+	_, err = r.DownloadIndexFile()
+	assert.ErrorContains(t, err, "a registry client is required")
+}
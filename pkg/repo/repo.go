@@ -17,14 +17,42 @@ limitations under the License.
 package repo // import "helm.sh/helm/v4/pkg/repo"
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 
 	"sigs.k8s.io/yaml"
 )
 
+// MaxNameLength is the maximum length of a repository name.
+//
+// Repository names are part of the names of the files caching their index, so
+// they are kept well within the limits of file systems.
+const MaxNameLength = 100
+
+// ValidateName checks that name can be used as the name of a repository in
+// chart references of the form NAME/CHART.
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("repository name cannot be empty")
+	}
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("repository name (%s) contains '/', please specify a different name without '/'", name)
+	}
+	if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("repository name (%q) contains whitespace, please specify a different name without whitespace", name)
+	}
+	if len(name) > MaxNameLength {
+		return fmt.Errorf("repository name (%s) is longer than %d characters", name, MaxNameLength)
+	}
+	return nil
+}
+
 // File represents the repositories.yaml file
 type File struct {
 	APIVersion   string    `json:"apiVersion"`
@@ -94,6 +122,18 @@ func (r *File) Get(name string) *Entry {
 	return nil
 }
 
+// GetByURL returns the first entry with the given URL if it exists, otherwise
+// returns nil. Trailing slashes are ignored when comparing URLs.
+func (r *File) GetByURL(url string) *Entry {
+	url = strings.TrimSuffix(url, "/")
+	for _, entry := range r.Repositories {
+		if entry != nil && strings.TrimSuffix(entry.URL, "/") == url {
+			return entry
+		}
+	}
+	return nil
+}
+
 // Remove removes the entry from the list of repositories.
 func (r *File) Remove(name string) bool {
 	cp := []*Entry{}
@@ -113,8 +153,16 @@ func (r *File) Remove(name string) bool {
 }
 
 // WriteFile writes a repositories file to the given path.
+//
+// The repositories are written sorted by name, so that the file does not
+// change with the order entries were added in. Files written in another order
+// are normalized the first time they are written.
 func (r *File) WriteFile(path string, perm os.FileMode) error {
-	data, err := yaml.Marshal(r)
+	sorted := *r
+	sorted.Repositories = slices.Clone(r.Repositories)
+	slices.SortStableFunc(sorted.Repositories, compareEntries)
+
+	data, err := yaml.Marshal(&sorted)
 	if err != nil {
 		return err
 	}
@@ -123,3 +171,16 @@ func (r *File) WriteFile(path string, perm os.FileMode) error {
 	}
 	return os.WriteFile(path, data, perm)
 }
+
+// compareEntries orders entries by name, with invalid entries last.
+func compareEntries(a, b *Entry) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return strings.Compare(a.Name, b.Name)
+}
@@ -18,6 +18,7 @@ package repo
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -217,6 +218,110 @@ func TestWriteFile(t *testing.T) {
 	}
 }
 
+func TestWriteFileSortsRepositories(t *testing.T) {
+	// The repositories of the test file are not sorted by name
+	repos, err := LoadFile(testRepositoriesFile)
+	if err != nil {
+		t.Fatalf("failed to load file (%v)", err)
+	}
+	repos.Add(&Entry{Name: "alpha", URL: "https://example.com/alpha"})
+
+	path := filepath.Join(t.TempDir(), "repositories.yaml")
+	if err := repos.WriteFile(path, 0600); err != nil {
+		t.Fatalf("failed to write file (%v)", err)
+	}
+	if repos.Repositories[0].Name != "stable" {
+		t.Errorf("expected the repositories to be left in place, got %q first", repos.Repositories[0].Name)
+	}
+
+	written, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("failed to load file (%v)", err)
+	}
+	var names []string
+	for _, r := range written.Repositories {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "alpha,incubator,stable" {
+		t.Errorf("expected repositories sorted by name, got %v", names)
+	}
+
+	// Writing the file again leaves it unchanged
+	first, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := written.WriteFile(path, 0600); err != nil {
+		t.Fatalf("failed to write file (%v)", err)
+	}
+	second, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("expected the file to be stable, got:\n%s\nthen:\n%s", first, second)
+	}
+}
+
+func TestRepoFile_GetByURL(t *testing.T) {
+	repo := NewFile()
+	repo.Add(
+		&Entry{
+			Name: "first",
+			URL:  "https://example.com/first",
+		},
+		nil,
+		&Entry{
+			Name: "second",
+			URL:  "https://example.com/second/",
+		},
+	)
+
+	for url, name := range map[string]string{
+		"https://example.com/first":   "first",
+		"https://example.com/first/":  "first",
+		"https://example.com/second":  "second",
+		"https://example.com/second/": "second",
+	} {
+		entry := repo.GetByURL(url)
+		if entry == nil || entry.Name != name {
+			t.Errorf("expected %s to be the URL of %q, got %+v", url, name, entry)
+		}
+	}
+
+	if entry := repo.GetByURL("https://example.com/third"); entry != nil {
+		t.Errorf("Got unexpected entry %+v", entry)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "stable"},
+		{name: "my-repo_1.0"},
+		{name: strings.Repeat("a", MaxNameLength)},
+		{name: "", wantErr: "repository name cannot be empty"},
+		{name: "test-hub/test-name", wantErr: "contains '/'"},
+		{name: "test name", wantErr: "contains whitespace"},
+		{name: "test\nname", wantErr: "contains whitespace"},
+		{name: strings.Repeat("a", MaxNameLength+1), wantErr: "is longer than 100 characters"},
+	}
+	for _, tt := range tests {
+		err := ValidateName(tt.name)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("expected %q to be valid, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("expected %q to be rejected with %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
 func TestRepoNotExists(t *testing.T) {
 	if _, err := LoadFile("/this/path/does/not/exist.yaml"); err == nil {
 		t.Errorf("expected err to be non-nil when path does not exist")
@@ -18,10 +18,12 @@ package repotest
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -145,6 +147,12 @@ type OCIServer struct {
 	TestUsername string
 	TestPassword string
 	Client       *ociRegistry.Client
+	// ManifestDigest is the digest of the manifest of the oci-dependent-chart
+	// pushed by Run.
+	ManifestDigest string
+	// ChartDigest is the digest of the content of the oci-dependent-chart
+	// pushed by Run.
+	ChartDigest string
 }
 
 type OCIServerRunConfig struct {
@@ -184,6 +192,7 @@ func NewOCIServer(t *testing.T, dir string) (*OCIServer, error) {
 	config.HTTP.Addr = fmt.Sprintf("127.0.0.1:%d", port)
 	config.HTTP.DrainTimeout = time.Duration(10) * time.Second
 	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.Catalog.MaxEntries = 1000
 	config.Auth = configuration.Auth{
 		"htpasswd": configuration.Parameters{
 			"realm": "localhost",
@@ -215,6 +224,7 @@ func (srv *OCIServer) Run(t *testing.T, opts ...OCIServerOpt) {
 	}
 
 	go srv.ListenAndServe()
+	srv.waitForListener(t)
 
 	credentialsFile := filepath.Join(srv.Dir, "config.json")
 
@@ -279,6 +289,8 @@ func (srv *OCIServer) Run(t *testing.T, opts ...OCIServerOpt) {
 		result.Config.Digest, result.Config.Size,
 		result.Chart.Digest, result.Chart.Size)
 
+	srv.ManifestDigest = result.Manifest.Digest
+	srv.ChartDigest = result.Chart.Digest
 	srv.Client = registryClient
 	c := cfg.DependingChart
 	if c == nil {
@@ -308,6 +320,25 @@ func (srv *OCIServer) Run(t *testing.T, opts ...OCIServerOpt) {
 		result.Chart.Digest, result.Chart.Size)
 }
 
+// waitForListener waits for the registry started in the background to accept
+// connections, so that the client does not race it.
+func (srv *OCIServer) waitForListener(t *testing.T) {
+	t.Helper()
+	addr := strings.Replace(srv.RegistryURL, "localhost", "127.0.0.1", 1)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("test registry not listening on %s: %v", srv.RegistryURL, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // Root gets the docroot for the server.
 func (s *Server) Root() string {
 	return s.docroot
@@ -75,7 +75,7 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 	r, err := decodeRelease(obj.Data["release"])
 	if err != nil {
 		slog.Debug("failed to decode data", "key", key, slog.Any("error", err))
-		return nil, err
+		return nil, newCorruptReleaseError(key, obj.Namespace, obj.Labels, err)
 	}
 	r.Labels = filterSystemLabels(obj.Labels)
 	// return the release object
@@ -84,25 +84,38 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 
 // List fetches all releases and returns the list releases such
 // that filter(release) == true. An error is returned if the
-// configmap fails to retrieve the releases.
+// configmap fails to retrieve the releases. The releases which cannot be
+// decoded are skipped.
 func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	results, corrupt, err := cfgmaps.ListWithCorrupt(filter)
+	for _, c := range corrupt {
+		slog.Debug("failed to decode release", "key", c.Key, slog.Any("error", c.Err))
+	}
+	return results, err
+}
+
+// ListWithCorrupt fetches all releases and returns the list releases such
+// that filter(release) == true, along with the ConfigMaps holding releases
+// which cannot be decoded.
+func (cfgmaps *ConfigMaps) ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*CorruptReleaseError, error) {
 	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
 	opts := metav1.ListOptions{LabelSelector: lsel.String()}
 
 	list, err := cfgmaps.impl.List(context.Background(), opts)
 	if err != nil {
 		slog.Debug("failed to list releases", slog.Any("error", err))
-		return nil, err
+		return nil, nil, err
 	}
 
 	var results []*rspb.Release
+	var corrupt []*CorruptReleaseError
 
 	// iterate over the configmaps object list
 	// and decode each release
 	for _, item := range list.Items {
 		rls, err := decodeRelease(item.Data["release"])
 		if err != nil {
-			slog.Debug("failed to decode release", "item", item, slog.Any("error", err))
+			corrupt = append(corrupt, newCorruptReleaseError(item.Name, item.Namespace, item.Labels, err))
 			continue
 		}
 
@@ -112,7 +125,19 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 			results = append(results, rls)
 		}
 	}
-	return results, nil
+	return results, corrupt, nil
+}
+
+// DeleteCorrupt deletes the ConfigMap named by key without decoding the
+// release it holds.
+func (cfgmaps *ConfigMaps) DeleteCorrupt(key string) error {
+	if err := cfgmaps.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrReleaseNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 // Query fetches all releases that match the provided map of labels.
@@ -248,3 +248,39 @@ func TestConfigMapDelete(t *testing.T) {
 		t.Errorf("Expected {%v}, got {%v}", ErrReleaseNotFound, err)
 	}
 }
+
+func TestConfigMapListWithCorrupt(t *testing.T) {
+	cfgmaps := newTestFixtureCfgMaps(t,
+		releaseStub("angry-bird", 1, "default", rspb.StatusSuperseded),
+		releaseStub("angry-bird", 2, "default", rspb.StatusDeployed),
+	)
+	key := testKey("angry-bird", 2)
+	cfgmaps.impl.(*MockConfigMapsInterface).objects[key].Data["release"] = "not base64"
+
+	list, corrupt, err := cfgmaps.ListWithCorrupt(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(list) != 1 || list[0].Version != 1 {
+		t.Errorf("Expected only revision 1 to be listed, got %d releases", len(list))
+	}
+	if len(corrupt) != 1 || corrupt[0].Key != key || corrupt[0].Version != 2 {
+		t.Fatalf("Expected the record of revision 2 to be corrupt, got %v", corrupt)
+	}
+
+	_, err = cfgmaps.Get(key)
+	var corruptErr *CorruptReleaseError
+	if !errors.As(err, &corruptErr) {
+		t.Errorf("Expected a CorruptReleaseError getting %q, got %v", key, err)
+	}
+
+	if err := cfgmaps.DeleteCorrupt(key); err != nil {
+		t.Fatalf("Failed to delete corrupt release with key %q: %s", key, err)
+	}
+	if _, corrupt, _ := cfgmaps.ListWithCorrupt(func(*rspb.Release) bool { return true }); len(corrupt) != 0 {
+		t.Errorf("Expected the corrupt record to be deleted")
+	}
+	if err := cfgmaps.DeleteCorrupt(key); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected ErrReleaseNotFound deleting a missing record, got %v", err)
+	}
+}
@@ -19,6 +19,8 @@ package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
@@ -34,6 +36,9 @@ var (
 	ErrNoDeployedReleases = errors.New("has no deployed releases")
 )
 
+// releaseKeyPrefix is the prefix of the keys releases are stored under.
+const releaseKeyPrefix = "sh.helm.release.v1."
+
 // StorageDriverError records an error and the release name that caused it
 type StorageDriverError struct {
 	ReleaseName string
@@ -53,6 +58,46 @@ func NewErrNoDeployedReleases(releaseName string) error {
 	}
 }
 
+// CorruptReleaseError records a record of a release which exists in storage
+// but cannot be decoded, as opposed to ErrReleaseNotFound.
+type CorruptReleaseError struct {
+	// Key is the key the record is stored under.
+	Key string
+	// Namespace is the namespace the record is stored in.
+	Namespace string
+	// Name and Version identify the release held by the record, as far as
+	// its labels tell. They are left empty when the labels are missing.
+	Name    string
+	Version int
+	Err     error
+}
+
+func (e *CorruptReleaseError) Error() string {
+	return fmt.Sprintf("release record %q is corrupt: %s", e.Key, e.Err.Error())
+}
+
+func (e *CorruptReleaseError) Unwrap() error { return e.Err }
+
+// newCorruptReleaseError records that the record stored under key, with the
+// given labels, cannot be decoded. Without labels, the release is identified
+// by the key, of the form sh.helm.release.v1.NAME.vVERSION.
+func newCorruptReleaseError(key, namespace string, lbs map[string]string, err error) *CorruptReleaseError {
+	name, version := lbs["name"], lbs["version"]
+	if name == "" {
+		if i := strings.LastIndex(key, ".v"); i > 0 && strings.HasPrefix(key, releaseKeyPrefix) {
+			name, version = key[len(releaseKeyPrefix):i], key[i+2:]
+		}
+	}
+	v, _ := strconv.Atoi(version)
+	return &CorruptReleaseError{
+		Key:       key,
+		Namespace: namespace,
+		Name:      name,
+		Version:   v,
+		Err:       err,
+	}
+}
+
 // Creator is the interface that wraps the Create method.
 //
 // Create stores the release or returns ErrReleaseExists
@@ -102,3 +147,45 @@ type Driver interface {
 	Queryor
 	Name() string
 }
+
+// CorruptionHandler is implemented by the drivers which report the records
+// they cannot decode, which List and Query skip. It is introduced to avoid
+// breaking backwards compatibility for Driver implementers.
+//
+// TODO Helm 4: Remove CorruptionHandler and integrate its methods into Driver.
+//
+// ListWithCorrupt returns the set of all releases that satisfy the filter
+// predicate, along with the records which could not be decoded.
+//
+// DeleteCorrupt deletes the record named by key without decoding it, or
+// returns ErrReleaseNotFound if it does not exist.
+type CorruptionHandler interface {
+	ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*CorruptReleaseError, error)
+	DeleteCorrupt(key string) error
+}
+
+// ListOptions selects the releases listed by a Pager, in the order of their
+// name, namespace and version.
+type ListOptions struct {
+	// Latest only lists the latest revision of every release, whatever its
+	// status.
+	Latest bool
+	// Statuses only lists the releases with one of these statuses, once the
+	// latest revisions are selected. Empty lists every status.
+	Statuses []rspb.Status
+	// Offset skips this many releases. Limit lists at most this many
+	// releases, zero meaning no limit.
+	Offset int
+	Limit  int
+}
+
+// Pager is implemented by the drivers which select and page the releases in
+// storage, rather than reading all of them. It is introduced to avoid breaking
+// backwards compatibility for Driver implementers.
+//
+// ListPage returns the releases selected by the options, along with the
+// records which could not be decoded. The records which cannot be decoded
+// still count towards the offset and the limit.
+type Pager interface {
+	ListPage(opts ListOptions) ([]*rspb.Release, []*CorruptReleaseError, error)
+}
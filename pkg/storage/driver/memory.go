@@ -17,6 +17,7 @@ limitations under the License.
 package driver
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,11 +43,64 @@ type Memory struct {
 	namespace string
 	// A map of namespaces to releases
 	cache map[string]memReleases
+
+	maxHistory int
+	// A map of operations to the errors injected for them
+	injected map[string][]injectedError
+}
+
+// The operations of the memory driver for which errors can be injected.
+const (
+	MemoryOpCreate = "Create"
+	MemoryOpUpdate = "Update"
+	MemoryOpGet    = "Get"
+	MemoryOpDelete = "Delete"
+)
+
+// injectedError is an error returned by an operation of the memory driver for
+// the given keys, or for every key if there are none.
+type injectedError struct {
+	err  error
+	keys []string
+}
+
+// MemoryOption is a function that configures a Memory driver.
+type MemoryOption func(*Memory)
+
+// WithMaxHistory returns a MemoryOption that configures a Memory driver to
+// keep at most n revisions of each release. As with the history limit of the
+// storage, the oldest revisions are pruned when a revision is created, except
+// for the latest deployed one. Zero means no limit.
+func WithMaxHistory(n int) MemoryOption {
+	return func(mem *Memory) {
+		mem.maxHistory = n
+	}
 }
 
-// NewMemory initializes a new memory driver.
-func NewMemory() *Memory {
-	return &Memory{cache: map[string]memReleases{}, namespace: "default"}
+// WithErrorInjection returns a MemoryOption that configures a Memory driver to
+// fail the operation op, one of MemoryOpCreate, MemoryOpUpdate, MemoryOpGet or
+// MemoryOpDelete, with err. If keys are given, only the operations on these
+// release keys fail. It is meant for tests of the handling of storage errors.
+// An error is returned if op is not one of these operations.
+func WithErrorInjection(op string, err error, keys ...string) (MemoryOption, error) {
+	switch op {
+	case MemoryOpCreate, MemoryOpUpdate, MemoryOpGet, MemoryOpDelete:
+	default:
+		return nil, fmt.Errorf("unknown memory driver operation %q", op)
+	}
+	return func(mem *Memory) {
+		mem.injected[op] = append(mem.injected[op], injectedError{err: err, keys: keys})
+	}, nil
+}
+
+// NewMemory initializes a new memory driver. Passed MemoryOptions can be used
+// to override defaults.
+func NewMemory(opts ...MemoryOption) *Memory {
+	mem := &Memory{cache: map[string]memReleases{}, namespace: "default", injected: map[string][]injectedError{}}
+	for _, opt := range opts {
+		opt(mem)
+	}
+	return mem
 }
 
 // SetNamespace sets a specific namespace in which releases will be accessed.
@@ -64,6 +118,10 @@ func (mem *Memory) Name() string {
 func (mem *Memory) Get(key string) (*rspb.Release, error) {
 	defer unlock(mem.rlock())
 
+	if err := mem.injectedError(MemoryOpGet, key); err != nil {
+		return nil, err
+	}
+
 	keyWithoutPrefix := strings.TrimPrefix(key, "sh.helm.release.v1.")
 	switch elems := strings.Split(keyWithoutPrefix, ".v"); len(elems) {
 	case 2:
@@ -153,6 +211,10 @@ func (mem *Memory) Query(keyvals map[string]string) ([]*rspb.Release, error) {
 func (mem *Memory) Create(key string, rls *rspb.Release) error {
 	defer unlock(mem.wlock())
 
+	if err := mem.injectedError(MemoryOpCreate, key); err != nil {
+		return err
+	}
+
 	// For backwards compatibility, we protect against an unset namespace
 	namespace := rls.Namespace
 	if namespace == "" {
@@ -168,7 +230,7 @@ func (mem *Memory) Create(key string, rls *rspb.Release) error {
 		if err := recs.Add(newRecord(key, rls)); err != nil {
 			return err
 		}
-		mem.cache[namespace][rls.Name] = recs
+		mem.cache[namespace][rls.Name] = mem.prune(recs)
 		return nil
 	}
 	mem.cache[namespace][rls.Name] = records{newRecord(key, rls)}
@@ -179,6 +241,10 @@ func (mem *Memory) Create(key string, rls *rspb.Release) error {
 func (mem *Memory) Update(key string, rls *rspb.Release) error {
 	defer unlock(mem.wlock())
 
+	if err := mem.injectedError(MemoryOpUpdate, key); err != nil {
+		return err
+	}
+
 	// For backwards compatibility, we protect against an unset namespace
 	namespace := rls.Namespace
 	if namespace == "" {
@@ -199,6 +265,10 @@ func (mem *Memory) Update(key string, rls *rspb.Release) error {
 func (mem *Memory) Delete(key string) (*rspb.Release, error) {
 	defer unlock(mem.wlock())
 
+	if err := mem.injectedError(MemoryOpDelete, key); err != nil {
+		return nil, err
+	}
+
 	keyWithoutPrefix := strings.TrimPrefix(key, "sh.helm.release.v1.")
 	elems := strings.Split(keyWithoutPrefix, ".v")
 
@@ -222,6 +292,49 @@ func (mem *Memory) Delete(key string) (*rspb.Release, error) {
 	return nil, ErrReleaseNotFound
 }
 
+// injectedError returns the error injected for the operation op on key, if any.
+func (mem *Memory) injectedError(op, key string) error {
+	key = strings.TrimPrefix(key, "sh.helm.release.v1.")
+	for _, inj := range mem.injected[op] {
+		if len(inj.keys) == 0 {
+			return inj.err
+		}
+		for _, k := range inj.keys {
+			if strings.TrimPrefix(k, "sh.helm.release.v1.") == key {
+				return inj.err
+			}
+		}
+	}
+	return nil
+}
+
+// prune removes the oldest records of a release beyond the maximum history,
+// keeping the latest deployed revision.
+func (mem *Memory) prune(recs records) records {
+	if mem.maxHistory <= 0 || len(recs) <= mem.maxHistory {
+		return recs
+	}
+
+	// The records are sorted from the oldest revision to the newest.
+	lastDeployed := -1
+	for i, rec := range recs {
+		if rec.rls.Info != nil && rec.rls.Info.Status == rspb.StatusDeployed {
+			lastDeployed = i
+		}
+	}
+
+	kept := make(records, 0, mem.maxHistory)
+	toDelete := len(recs) - mem.maxHistory
+	for i, rec := range recs {
+		if toDelete > 0 && i != lastDeployed {
+			toDelete--
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	return kept
+}
+
 // wlock locks mem for writing
 func (mem *Memory) wlock() func() {
 	mem.Lock()
@@ -17,6 +17,7 @@ limitations under the License.
 package driver
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -287,3 +288,143 @@ func TestMemoryDelete(t *testing.T) {
 	}
 
 }
+
+func TestMemoryMaxHistory(t *testing.T) {
+	// The fixture creates the revisions of rls-a in the order 4, 1, 3, 2:
+	// revision 4, deployed, is kept while the others are pruned.
+	ts := tsFixtureMemory(t, WithMaxHistory(2))
+	ts.SetNamespace("default")
+
+	versions := func() []int {
+		t.Helper()
+		ls, err := ts.Query(map[string]string{"name": "rls-a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vs []int
+		for _, rls := range ls {
+			vs = append(vs, rls.Version)
+		}
+		return vs
+	}
+	if vs := versions(); !reflect.DeepEqual(vs, []int{3, 4}) {
+		t.Fatalf("expected revisions [3 4], got %v", vs)
+	}
+
+	if err := ts.Create(testKey("rls-a", 5), releaseStub("rls-a", 5, "default", rspb.StatusFailed)); err != nil {
+		t.Fatal(err)
+	}
+	if vs := versions(); !reflect.DeepEqual(vs, []int{4, 5}) {
+		t.Fatalf("expected revisions [4 5], got %v", vs)
+	}
+	if err := ts.Create(testKey("rls-a", 6), releaseStub("rls-a", 6, "default", rspb.StatusFailed)); err != nil {
+		t.Fatal(err)
+	}
+	if vs := versions(); !reflect.DeepEqual(vs, []int{4, 6}) {
+		t.Fatalf("expected the deployed revision to be kept, got %v", vs)
+	}
+}
+
+func TestMemoryErrorInjection(t *testing.T) {
+	errInjected := errors.New("storage unavailable")
+
+	var tests = []struct {
+		desc string
+		opt  MemoryOption
+		op   func(mem *Memory, key string) error
+		key  string
+		err  bool
+	}{
+		{
+			"create fails for every key",
+			injectError(t, MemoryOpCreate, errInjected),
+			func(mem *Memory, key string) error {
+				return mem.Create(key, releaseStub("rls-a", 5, "default", rspb.StatusDeployed))
+			},
+			"rls-a.v5",
+			true,
+		},
+		{
+			"update fails for the key",
+			injectError(t, MemoryOpUpdate, errInjected, "rls-a.v4"),
+			func(mem *Memory, key string) error {
+				return mem.Update(key, releaseStub("rls-a", 4, "default", rspb.StatusSuperseded))
+			},
+			"rls-a.v4",
+			true,
+		},
+		{
+			"update succeeds for another key",
+			injectError(t, MemoryOpUpdate, errInjected, "rls-a.v4"),
+			func(mem *Memory, key string) error {
+				return mem.Update(key, releaseStub("rls-a", 3, "default", rspb.StatusSuperseded))
+			},
+			"rls-a.v3",
+			false,
+		},
+		{
+			"get fails for the prefixed key",
+			injectError(t, MemoryOpGet, errInjected, "sh.helm.release.v1.rls-a.v1"),
+			func(mem *Memory, key string) error {
+				_, err := mem.Get(key)
+				return err
+			},
+			"rls-a.v1",
+			true,
+		},
+		{
+			"delete fails for the key",
+			injectError(t, MemoryOpDelete, errInjected, "rls-b.v1"),
+			func(mem *Memory, key string) error {
+				_, err := mem.Delete(key)
+				return err
+			},
+			"rls-b.v1",
+			true,
+		},
+		{
+			"other operations succeed",
+			injectError(t, MemoryOpDelete, errInjected),
+			func(mem *Memory, key string) error {
+				_, err := mem.Get(key)
+				return err
+			},
+			"rls-b.v1",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		ts := tsFixtureMemory(t)
+		tt.opt(ts)
+		ts.SetNamespace("default")
+		err := tt.op(ts, tt.key)
+		if tt.err && !errors.Is(err, errInjected) {
+			t.Fatalf("%q: expected the injected error, got %v", tt.desc, err)
+		}
+		if !tt.err && err != nil {
+			t.Fatalf("%q: unexpected error: %s", tt.desc, err)
+		}
+	}
+}
+
+func TestMemoryErrorInjectionUnknownOperation(t *testing.T) {
+	opt, err := WithErrorInjection("List", errors.New("fail"))
+	if err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+	if opt != nil {
+		t.Error("expected no option for an unknown operation")
+	}
+}
+
+// injectError returns the MemoryOption of WithErrorInjection, failing the
+// test on an unknown operation.
+func injectError(t *testing.T, op string, err error, keys ...string) MemoryOption {
+	t.Helper()
+	opt, ierr := WithErrorInjection(op, err, keys...)
+	if ierr != nil {
+		t.Fatal(ierr)
+	}
+	return opt
+}
@@ -44,6 +44,10 @@ func releaseStub(name string, vers int, namespace string, status rspb.Status) *r
 			"key1": "val1",
 			"key2": "val2",
 		},
+		Annotations: map[string]string{
+			"example.com/commit": "3b383ba",
+			"example.com/build":  `{"url": "https://ci.example.com/1", "status": "passed"}`,
+		},
 	}
 }
 
@@ -51,7 +55,7 @@ func testKey(name string, vers int) string {
 	return fmt.Sprintf("%s.v%d", name, vers)
 }
 
-func tsFixtureMemory(t *testing.T) *Memory {
+func tsFixtureMemory(t *testing.T, opts ...MemoryOption) *Memory {
 	t.Helper()
 	hs := []*rspb.Release{
 		// rls-a
@@ -71,7 +75,7 @@ func tsFixtureMemory(t *testing.T) *Memory {
 		releaseStub("rls-c", 2, "mynamespace", rspb.StatusSuperseded),
 	}
 
-	mem := NewMemory()
+	mem := NewMemory(opts...)
 	for _, tt := range hs {
 		err := mem.Create(testKey(tt.Name, tt.Version), tt)
 		if err != nil {
@@ -266,9 +270,12 @@ func newTestFixtureSQL(t *testing.T, _ ...*rspb.Release) (*SQL, sqlmock.Sqlmock)
 	}
 
 	sqlxDB := sqlx.NewDb(sqlDB, "sqlmock")
-	return &SQL{
+	d := &SQL{
 		db:               sqlxDB,
 		namespace:        "default",
 		statementBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
-	}, mock
+	}
+	// The schema of the mocked database is taken as migrated.
+	d.migrateOnce.Do(func() {})
+	return d, mock
 }
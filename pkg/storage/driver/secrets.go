@@ -18,6 +18,7 @@ package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -39,6 +40,22 @@ var _ Driver = (*Secrets)(nil)
 // SecretsDriverName is the string name of the driver.
 const SecretsDriverName = "Secret"
 
+const (
+	// maxSecretDataSize is the largest encoded release stored in a single
+	// Secret, which is the limit Kubernetes puts on the data of a Secret.
+	// Larger releases are split in chunks of this size, each stored in a
+	// Secret of its own.
+	maxSecretDataSize = 1024 * 1024
+
+	// releaseChunksAnnotation is set on the Secret of a release split in
+	// chunks, holding the number of chunks.
+	releaseChunksAnnotation = "helm.sh/release-chunks"
+
+	// releaseChunkType is the type of the Secrets holding the chunks of a
+	// release.
+	releaseChunkType = "helm.sh/release.v1.chunk"
+)
+
 // Secrets is a wrapper around an implementation of a kubernetes
 // SecretsInterface.
 type Secrets struct {
@@ -61,43 +78,68 @@ func (secrets *Secrets) Name() string {
 // Get fetches the release named by key. The corresponding release is returned
 // or error if not found.
 func (secrets *Secrets) Get(key string) (*rspb.Release, error) {
+	r, _, err := secrets.get(key)
+	return r, err
+}
+
+// get fetches the release named by key along with the Secret holding it.
+func (secrets *Secrets) get(key string) (*rspb.Release, *v1.Secret, error) {
 	// fetch the secret holding the release named by key
 	obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return nil, ErrReleaseNotFound
+			return nil, nil, ErrReleaseNotFound
 		}
-		return nil, fmt.Errorf("get: failed to get %q: %w", key, err)
+		return nil, nil, fmt.Errorf("get: failed to get %q: %w", key, err)
 	}
 	// found the secret, decode the base64 data string
-	r, err := decodeRelease(string(obj.Data["release"]))
+	r, err := secrets.decodeSecret(obj)
+	if isAPIError(err) {
+		return nil, nil, fmt.Errorf("get: %w", err)
+	}
 	if err != nil {
-		return r, fmt.Errorf("get: failed to decode data %q: %w", key, err)
+		return nil, nil, fmt.Errorf("get: %w", newCorruptReleaseError(key, obj.Namespace, obj.Labels, err))
 	}
 	r.Labels = filterSystemLabels(obj.Labels)
-	return r, nil
+	return r, obj, nil
 }
 
 // List fetches all releases and returns the list releases such
 // that filter(release) == true. An error is returned if the
-// secret fails to retrieve the releases.
+// secret fails to retrieve the releases. The releases which cannot be
+// decoded are skipped.
 func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	results, corrupt, err := secrets.ListWithCorrupt(filter)
+	for _, c := range corrupt {
+		slog.Debug("list failed to decode release", "key", c.Key, slog.Any("error", c.Err))
+	}
+	return results, err
+}
+
+// ListWithCorrupt fetches all releases and returns the list releases such
+// that filter(release) == true, along with the Secrets holding releases which
+// cannot be decoded.
+func (secrets *Secrets) ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*CorruptReleaseError, error) {
 	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
 	opts := metav1.ListOptions{LabelSelector: lsel.String()}
 
 	list, err := secrets.impl.List(context.Background(), opts)
 	if err != nil {
-		return nil, fmt.Errorf("list: failed to list: %w", err)
+		return nil, nil, fmt.Errorf("list: failed to list: %w", err)
 	}
 
 	var results []*rspb.Release
+	var corrupt []*CorruptReleaseError
 
 	// iterate over the secrets object list
 	// and decode each release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+		rls, err := secrets.decodeSecret(&item)
+		if isAPIError(err) {
+			return nil, nil, fmt.Errorf("list: %w", err)
+		}
 		if err != nil {
-			slog.Debug("list failed to decode release", "key", item.Name, slog.Any("error", err))
+			corrupt = append(corrupt, newCorruptReleaseError(item.Name, item.Namespace, item.Labels, err))
 			continue
 		}
 
@@ -107,7 +149,41 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 			results = append(results, rls)
 		}
 	}
-	return results, nil
+	return results, corrupt, nil
+}
+
+// DeleteCorrupt deletes the Secret named by key, along with the Secrets
+// holding its chunks, without decoding the release it holds.
+func (secrets *Secrets) DeleteCorrupt(key string) error {
+	obj, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrReleaseNotFound
+		}
+		return fmt.Errorf("delete: failed to get %q: %w", key, err)
+	}
+	// the chunks are looked for up to the number recorded, if readable
+	chunks, _ := chunkCount(obj)
+	if err := secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("delete: failed to delete %q: %w", key, err)
+	}
+	return secrets.deleteChunks(key, 0, chunks)
+}
+
+// isAPIError tells whether err was returned by the Kubernetes API, reading the
+// chunks of a release, rather than by decoding it.
+func isAPIError(err error) bool {
+	var status apierrors.APIStatus
+	return errors.As(err, &status)
+}
+
+// decodeSecret decodes the release held by obj.
+func (secrets *Secrets) decodeSecret(obj *v1.Secret) (*rspb.Release, error) {
+	data, err := secrets.releaseData(obj)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRelease(data)
 }
 
 // Query fetches all releases that match the provided map of labels.
@@ -134,7 +210,7 @@ func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error)
 
 	var results []*rspb.Release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+		rls, err := secrets.decodeSecret(&item)
 		if err != nil {
 			slog.Debug("failed to decode release", "key", item.Name, slog.Any("error", err))
 			continue
@@ -160,11 +236,25 @@ func (secrets *Secrets) Create(key string, rls *rspb.Release) error {
 	if err != nil {
 		return fmt.Errorf("create: failed to encode release %q: %w", rls.Name, err)
 	}
+	chunks := splitSecretsObject(obj)
+	if len(chunks) > 0 {
+		// the chunks are written first, so that the release is never seen
+		// without them. Make sure they do not belong to an existing release.
+		if _, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+			return ErrReleaseExists
+		}
+		if err := secrets.writeChunks(chunks); err != nil {
+			return fmt.Errorf("create: failed to create: %w", err)
+		}
+	}
 	// push the secret object out into the kubiverse
 	if _, err := secrets.impl.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
 		if apierrors.IsAlreadyExists(err) {
 			return ErrReleaseExists
 		}
+		if cleanupErr := secrets.deleteChunks(key, 0, len(chunks)); cleanupErr != nil {
+			slog.Debug("failed to delete chunks of release", "key", key, slog.Any("error", cleanupErr))
+		}
 
 		return fmt.Errorf("create: failed to create: %w", err)
 	}
@@ -186,28 +276,102 @@ func (secrets *Secrets) Update(key string, rls *rspb.Release) error {
 	if err != nil {
 		return fmt.Errorf("update: failed to encode release %q: %w", rls.Name, err)
 	}
+	chunks := splitSecretsObject(obj)
+
+	// the chunks of the previous version of the release left over are
+	// deleted once it is updated
+	previousChunks := 0
+	if previous, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+		previousChunks, _ = chunkCount(previous)
+	}
+
+	if err := secrets.writeChunks(chunks); err != nil {
+		return fmt.Errorf("update: failed to update: %w", err)
+	}
 	// push the secret object out into the kubiverse
 	_, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("update: failed to update: %w", err)
 	}
+	if err := secrets.deleteChunks(key, len(chunks), previousChunks); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
 	return nil
 }
 
-// Delete deletes the Secret holding the release named by key.
+// Delete deletes the Secret holding the release named by key, along with the
+// Secrets holding its chunks.
 func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 	// fetch the release to check existence
-	if rls, err = secrets.Get(key); err != nil {
+	rls, obj, err := secrets.get(key)
+	if err != nil {
 		return nil, err
 	}
+	chunks, _ := chunkCount(obj)
 	// delete the release
 	err = secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{})
 	if err != nil {
 		return nil, err
 	}
+	if err := secrets.deleteChunks(key, 0, chunks); err != nil {
+		return rls, err
+	}
 	return rls, nil
 }
 
+// releaseData returns the encoded release stored in obj, reassembling it from
+// its chunks if it was split.
+func (secrets *Secrets) releaseData(obj *v1.Secret) (string, error) {
+	count, err := chunkCount(obj)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return string(obj.Data["release"]), nil
+	}
+
+	var data strings.Builder
+	for i := 0; i < count; i++ {
+		name := chunkName(obj.Name, i)
+		chunk, err := secrets.impl.Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("release %q is corrupted: chunk %d of %d (%s) is missing", obj.Name, i+1, count, name)
+			}
+			return "", fmt.Errorf("failed to get chunk %q: %w", name, err)
+		}
+		data.Write(chunk.Data["release"])
+	}
+	return data.String(), nil
+}
+
+// writeChunks creates the Secrets holding the chunks of a release, replacing
+// the ones which already exist.
+func (secrets *Secrets) writeChunks(chunks []*v1.Secret) error {
+	for _, chunk := range chunks {
+		_, err := secrets.impl.Create(context.Background(), chunk, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			_, err = secrets.impl.Update(context.Background(), chunk, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write chunk %q: %w", chunk.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteChunks deletes the chunks from to to, excluded, of the release named by key.
+func (secrets *Secrets) deleteChunks(key string, from, to int) error {
+	var errs []error
+	for i := from; i < to; i++ {
+		name := chunkName(key, i)
+		if err := secrets.impl.Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete chunk %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // newSecretsObject constructs a kubernetes Secret object
 // to store a release. Each secret data entry is the base64
 // encoded gzipped string of a release.
@@ -262,3 +426,58 @@ func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, er
 		Data: map[string][]byte{"release": []byte(s)},
 	}, nil
 }
+
+// splitSecretsObject splits the release stored in obj in chunks if it is too
+// large for a single Secret. The chunks are moved out of obj, which records
+// their number in its annotations, and are returned in order. Nothing is
+// returned if the release fits in obj.
+//
+// The chunks of the release stored in the Secret KEY are stored in the Secrets
+// KEY.0, KEY.1 and so on. They carry no labels, so that they are never listed
+// as releases.
+func splitSecretsObject(obj *v1.Secret) []*v1.Secret {
+	data := obj.Data["release"]
+	if len(data) <= maxSecretDataSize {
+		return nil
+	}
+
+	var chunks []*v1.Secret
+	for i := 0; len(data) > 0; i++ {
+		n := min(len(data), maxSecretDataSize)
+		chunks = append(chunks, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: chunkName(obj.Name, i),
+			},
+			Type: releaseChunkType,
+			Data: map[string][]byte{"release": data[:n]},
+		})
+		data = data[n:]
+	}
+
+	obj.Data = map[string][]byte{}
+	if obj.Annotations == nil {
+		obj.Annotations = map[string]string{}
+	}
+	obj.Annotations[releaseChunksAnnotation] = strconv.Itoa(len(chunks))
+	return chunks
+}
+
+// chunkCount returns the number of chunks the release stored in obj was split
+// in, or 0 if it was not.
+func chunkCount(obj *v1.Secret) (int, error) {
+	value, ok := obj.Annotations[releaseChunksAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("release %q is corrupted: invalid number of chunks %q", obj.Name, value)
+	}
+	return count, nil
+}
+
+// chunkName returns the name of the Secret holding the chunk i of the release
+// named by key.
+func chunkName(key string, i int) string {
+	return fmt.Sprintf("%s.%d", key, i)
+}
@@ -14,13 +14,17 @@ limitations under the License.
 package driver
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
@@ -248,3 +252,267 @@ func TestSecretDelete(t *testing.T) {
 		t.Errorf("Expected {%v}, got {%v}", ErrReleaseNotFound, err)
 	}
 }
+
+// largeReleaseStub returns a release too large to be stored in a single
+// Secret once encoded.
+func largeReleaseStub(name string, vers int, namespace string, status rspb.Status) *rspb.Release {
+	rls := releaseStub(name, vers, namespace, status)
+	// random data does not compress
+	manifest := make([]byte, maxSecretDataSize)
+	rand.New(rand.NewSource(int64(vers))).Read(manifest)
+	rls.Manifest = base64.StdEncoding.EncodeToString(manifest)
+	return rls
+}
+
+func secretsObjects(secrets *Secrets) map[string]*v1.Secret {
+	return secrets.impl.(*MockSecretsInterface).objects
+}
+
+func TestSplitSecretsObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks []int
+	}{
+		{name: "small", size: 1},
+		{name: "limit", size: maxSecretDataSize},
+		{name: "above limit", size: maxSecretDataSize + 1, chunks: []int{maxSecretDataSize, 1}},
+		{name: "twice the limit", size: 2 * maxSecretDataSize, chunks: []int{maxSecretDataSize, maxSecretDataSize}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("a"), tt.size)
+			obj := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.smug-pigeon.v1"},
+				Data:       map[string][]byte{"release": data},
+			}
+			chunks := splitSecretsObject(obj)
+			if len(chunks) != len(tt.chunks) {
+				t.Fatalf("Expected %d chunks, got %d", len(tt.chunks), len(chunks))
+			}
+			if len(chunks) == 0 {
+				if len(obj.Data["release"]) != tt.size || obj.Annotations[releaseChunksAnnotation] != "" {
+					t.Errorf("Expected the release to be left in the Secret")
+				}
+				return
+			}
+
+			if len(obj.Data) != 0 {
+				t.Errorf("Expected no data in the Secret of the release, got %d entries", len(obj.Data))
+			}
+			if count, err := chunkCount(obj); err != nil || count != len(tt.chunks) {
+				t.Errorf("Expected %d chunks to be recorded, got %d (%v)", len(tt.chunks), count, err)
+			}
+			var joined []byte
+			for i, chunk := range chunks {
+				if chunk.Name != chunkName(obj.Name, i) {
+					t.Errorf("Expected chunk %d to be named %q, got %q", i, chunkName(obj.Name, i), chunk.Name)
+				}
+				if len(chunk.Labels) != 0 {
+					t.Errorf("Expected no labels on chunk %d, got %v", i, chunk.Labels)
+				}
+				if len(chunk.Data["release"]) != tt.chunks[i] {
+					t.Errorf("Expected chunk %d to hold %d bytes, got %d", i, tt.chunks[i], len(chunk.Data["release"]))
+				}
+				joined = append(joined, chunk.Data["release"]...)
+			}
+			if !bytes.Equal(joined, data) {
+				t.Errorf("Expected the chunks to hold the release")
+			}
+		})
+	}
+}
+
+func TestSecretCreateLargeRelease(t *testing.T) {
+	secrets := newTestFixtureSecrets(t)
+
+	rel := largeReleaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+
+	objects := secretsObjects(secrets)
+	if len(objects) != 3 {
+		t.Fatalf("Expected the release to be stored in 3 Secrets, got %d", len(objects))
+	}
+	for _, obj := range objects {
+		if len(obj.Data["release"]) > maxSecretDataSize {
+			t.Errorf("Secret %q holds %d bytes, more than %d", obj.Name, len(obj.Data["release"]), maxSecretDataSize)
+		}
+	}
+	if objects[key].Annotations[releaseChunksAnnotation] != "2" {
+		t.Errorf("Expected 2 chunks, got annotations %v", objects[key].Annotations)
+	}
+
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release with key %q: %s", key, err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected the large release to be reassembled")
+	}
+
+	list, err := secrets.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(list) != 1 || list[0].Manifest != rel.Manifest {
+		t.Errorf("Expected the large release to be listed once, got %d releases", len(list))
+	}
+
+	results, err := secrets.Query(map[string]string{"name": rel.Name, "owner": "helm"})
+	if err != nil {
+		t.Fatalf("Failed to query releases: %s", err)
+	}
+	if len(results) != 1 || results[0].Manifest != rel.Manifest {
+		t.Errorf("Expected the large release to be queried once, got %d releases", len(results))
+	}
+
+	if err := secrets.Create(key, rel); !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("Expected {%v}, got {%v}", ErrReleaseExists, err)
+	}
+}
+
+func TestSecretUpdateLargeRelease(t *testing.T) {
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+	secrets := newTestFixtureSecrets(t, rel)
+
+	// a release stored in a single Secret grows over the limit
+	large := largeReleaseStub(rel.Name, rel.Version, rel.Namespace, rspb.StatusSuperseded)
+	if err := secrets.Update(key, large); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+	if len(secretsObjects(secrets)) != 3 {
+		t.Errorf("Expected the release to be stored in 3 Secrets, got %d", len(secretsObjects(secrets)))
+	}
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release with key %q: %s", key, err)
+	}
+	if got.Manifest != large.Manifest || got.Info.Status != rspb.StatusSuperseded {
+		t.Errorf("Expected the large release to be stored")
+	}
+
+	// and shrinks back under it
+	rel.Info.Status = rspb.StatusSuperseded
+	if err := secrets.Update(key, rel); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+	objects := secretsObjects(secrets)
+	if len(objects) != 1 {
+		t.Errorf("Expected the chunks to be deleted, got %d Secrets", len(objects))
+	}
+	if _, ok := objects[key].Annotations[releaseChunksAnnotation]; ok {
+		t.Errorf("Expected no chunks to be recorded, got annotations %v", objects[key].Annotations)
+	}
+	got, err = secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release with key %q: %s", key, err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+}
+
+func TestSecretDeleteLargeRelease(t *testing.T) {
+	secrets := newTestFixtureSecrets(t)
+
+	rel := largeReleaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+
+	rls, err := secrets.Delete(key)
+	if err != nil {
+		t.Fatalf("Failed to delete release with key %q: %s", key, err)
+	}
+	if !reflect.DeepEqual(rel, rls) {
+		t.Errorf("Expected the large release to be returned")
+	}
+	if len(secretsObjects(secrets)) != 0 {
+		t.Errorf("Expected all the Secrets of the release to be deleted, got %d", len(secretsObjects(secrets)))
+	}
+}
+
+func TestSecretGetMissingChunk(t *testing.T) {
+	secrets := newTestFixtureSecrets(t, releaseStub("angry-bird", 1, "default", rspb.StatusDeployed))
+
+	rel := largeReleaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+	delete(secretsObjects(secrets), chunkName(key, 1))
+
+	_, err := secrets.Get(key)
+	expect := `release "smug-pigeon.v1" is corrupted: chunk 2 of 2 (smug-pigeon.v1.1) is missing`
+	if err == nil || !strings.Contains(err.Error(), expect) {
+		t.Errorf("Expected error containing %q, got %v", expect, err)
+	}
+
+	// the corrupted release is left out of lists
+	list, err := secrets.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(list) != 1 || list[0].Name != "angry-bird" {
+		t.Errorf("Expected only the intact release to be listed, got %d releases", len(list))
+	}
+}
+
+func TestSecretListWithCorrupt(t *testing.T) {
+	secrets := newTestFixtureSecrets(t,
+		releaseStub("angry-bird", 1, "default", rspb.StatusSuperseded),
+		releaseStub("angry-bird", 2, "default", rspb.StatusDeployed),
+	)
+	key := testKey("angry-bird", 2)
+	secretsObjects(secrets)[key].Data["release"] = []byte("H4sIAAAAAAAC")
+
+	list, corrupt, err := secrets.ListWithCorrupt(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(list) != 1 || list[0].Version != 1 {
+		t.Errorf("Expected only revision 1 to be listed, got %d releases", len(list))
+	}
+	if len(corrupt) != 1 {
+		t.Fatalf("Expected 1 corrupt record, got %d", len(corrupt))
+	}
+	if corrupt[0].Key != key || corrupt[0].Name != "angry-bird" || corrupt[0].Version != 2 {
+		t.Errorf("Expected the record of revision 2 to be corrupt, got %+v", corrupt[0])
+	}
+
+	_, err = secrets.Get(key)
+	var corruptErr *CorruptReleaseError
+	if !errors.As(err, &corruptErr) || corruptErr.Key != key {
+		t.Errorf("Expected a CorruptReleaseError getting %q, got %v", key, err)
+	}
+	if errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected a corrupt release to be told apart from a missing one")
+	}
+}
+
+func TestSecretDeleteCorrupt(t *testing.T) {
+	secrets := newTestFixtureSecrets(t, releaseStub("angry-bird", 1, "default", rspb.StatusDeployed))
+
+	rel := largeReleaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+	delete(secretsObjects(secrets), chunkName(key, 1))
+
+	if err := secrets.DeleteCorrupt(key); err != nil {
+		t.Fatalf("Failed to delete corrupt release with key %q: %s", key, err)
+	}
+	if len(secretsObjects(secrets)) != 1 {
+		t.Errorf("Expected the release and its remaining chunk to be deleted, got %d Secrets", len(secretsObjects(secrets)))
+	}
+	if err := secrets.DeleteCorrupt(key); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected ErrReleaseNotFound deleting a missing record, got %v", err)
+	}
+}
@@ -17,11 +17,15 @@ limitations under the License.
 package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -29,13 +33,14 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 
-	// Import pq for postgres dialect
-	_ "github.com/lib/pq"
+	// Import pq for postgres dialect and its errors
+	"github.com/lib/pq"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
 
 var _ Driver = (*SQL)(nil)
+var _ Pager = (*SQL)(nil)
 
 var labelMap = map[string]struct{}{
 	"modifiedAt": {},
@@ -65,6 +70,7 @@ const (
 	sqlReleaseTableOwnerColumn      = "owner"
 	sqlReleaseTableCreatedAtColumn  = "createdAt"
 	sqlReleaseTableModifiedAtColumn = "modifiedAt"
+	sqlReleaseTableLabelsColumn     = "labels"
 
 	sqlCustomLabelsTableReleaseKeyColumn       = "releaseKey"
 	sqlCustomLabelsTableReleaseNamespaceColumn = "releaseNamespace"
@@ -83,55 +89,95 @@ const (
 	sqlReleaseDefaultType  = "helm.sh/release.v1"
 )
 
+// sqlMigrationLockID is the key of the PostgreSQL advisory lock held while the
+// schema is migrated, so that concurrent Helm invocations do not race.
+const sqlMigrationLockID int64 = 0x68656c6d
+
+// sqlDefaultWriteRetries is the number of times a write is retried by default
+// after a serialization failure or a deadlock.
+const sqlDefaultWriteRetries = 3
+
+// sqlRetryDelay is the delay before the first retry of a write, doubled before
+// every following one.
+var sqlRetryDelay = 50 * time.Millisecond
+
+// sqlListPageSize is the number of records read at once when listing or
+// querying releases.
+var sqlListPageSize uint64 = 500
+
 // SQL is the sql storage driver implementation.
 type SQL struct {
 	db               *sqlx.DB
 	namespace        string
 	statementBuilder sq.StatementBuilderType
+
+	// writeRetries is the number of times a write failing on a serialization
+	// failure or a deadlock is retried.
+	writeRetries int
+
+	// The schema is migrated when the driver is created, or before its first
+	// operation with WithDeferredMigration.
+	deferMigration bool
+	migrateOnce    sync.Once
+	migrateErr     error
 }
 
-// Name returns the name of the driver.
-func (s *SQL) Name() string {
-	return SQLDriverName
+// SQLOption is a function that configures an SQL driver.
+type SQLOption func(*SQL)
+
+// WithMaxOpenConns returns an SQLOption that limits the number of connections
+// the SQL driver opens to the database. Zero means no limit.
+func WithMaxOpenConns(n int) SQLOption {
+	return func(s *SQL) {
+		s.db.SetMaxOpenConns(n)
+	}
 }
 
-// Check if all migrations al
-func (s *SQL) checkAlreadyApplied(migrations []*migrate.Migration) bool {
-	// make map (set) of ids for fast search
-	migrationsIDs := make(map[string]struct{})
-	for _, migration := range migrations {
-		migrationsIDs[migration.Id] = struct{}{}
+// WithMaxIdleConns returns an SQLOption that limits the number of idle
+// connections the SQL driver keeps open.
+func WithMaxIdleConns(n int) SQLOption {
+	return func(s *SQL) {
+		s.db.SetMaxIdleConns(n)
 	}
+}
 
-	// get list of applied migrations
-	migrate.SetDisableCreateTable(true)
-	records, err := migrate.GetMigrationRecords(s.db.DB, postgreSQLDialect)
-	migrate.SetDisableCreateTable(false)
-	if err != nil {
-		slog.Debug("failed to get migration records", slog.Any("error", err))
-		return false
+// WithConnMaxLifetime returns an SQLOption that closes the connections of the
+// SQL driver once they have been open for d. Zero means no limit.
+func WithConnMaxLifetime(d time.Duration) SQLOption {
+	return func(s *SQL) {
+		s.db.SetConnMaxLifetime(d)
 	}
+}
 
-	for _, record := range records {
-		if _, ok := migrationsIDs[record.Id]; ok {
-			slog.Debug("found previous migration", "id", record.Id, "appliedAt", record.AppliedAt)
-			delete(migrationsIDs, record.Id)
-		}
+// WithWriteRetries returns an SQLOption that configures the number of times
+// the SQL driver retries a write which fails on a serialization failure or a
+// deadlock, with an exponential backoff.
+func WithWriteRetries(n int) SQLOption {
+	return func(s *SQL) {
+		s.writeRetries = n
 	}
+}
 
-	// check if all migrations applied
-	if len(migrationsIDs) != 0 {
-		for id := range migrationsIDs {
-			slog.Debug("find unapplied migration", "id", id)
-		}
-		return false
+// WithDeferredMigration returns an SQLOption that leaves the schema as it is
+// when the SQL driver is created, so that its pending migrations can be listed
+// with PendingMigrations. The schema is then migrated by Migrate, or before
+// the first operation of the driver.
+func WithDeferredMigration() SQLOption {
+	return func(s *SQL) {
+		s.deferMigration = true
 	}
-	return true
 }
 
-func (s *SQL) ensureDBSetup() error {
+// Name returns the name of the driver.
+func (s *SQL) Name() string {
+	return SQLDriverName
+}
 
-	migrations := &migrate.MemoryMigrationSource{
+// sqlMigrations returns the migrations of the schema of the SQL storage. They
+// are applied in the order of their IDs, so the ID of a new migration must sort
+// after the ones of the existing migrations.
+func sqlMigrations() *migrate.MemoryMigrationSource {
+	return &migrate.MemoryMigrationSource{
 		Migrations: []*migrate.Migration{
 			{
 				Id: "init",
@@ -232,17 +278,233 @@ func (s *SQL) ensureDBSetup() error {
 					`, sqlCustomLabelsTableName),
 				},
 			},
+			{
+				// The custom labels of each release are kept in an indexed
+				// JSONB column, backfilled from the custom labels table.
+				Id: "labels",
+				Up: []string{
+					fmt.Sprintf(`
+						ALTER TABLE %s ADD COLUMN %s JSONB NOT NULL DEFAULT '{}';
+
+						UPDATE %s SET %s = custom.labels FROM (
+							SELECT %s, %s, jsonb_object_agg(%s, %s) AS labels
+							FROM %s
+							GROUP BY %s, %s
+						) AS custom
+						WHERE %s.%s = custom.%s AND %s.%s = custom.%s;
+
+						CREATE INDEX ON %s USING GIN (%s);
+					`,
+						sqlReleaseTableName,
+						sqlReleaseTableLabelsColumn,
+						sqlReleaseTableName,
+						sqlReleaseTableLabelsColumn,
+						sqlCustomLabelsTableReleaseKeyColumn,
+						sqlCustomLabelsTableReleaseNamespaceColumn,
+						sqlCustomLabelsTableKeyColumn,
+						sqlCustomLabelsTableValueColumn,
+						sqlCustomLabelsTableName,
+						sqlCustomLabelsTableReleaseKeyColumn,
+						sqlCustomLabelsTableReleaseNamespaceColumn,
+						sqlReleaseTableName,
+						sqlReleaseTableKeyColumn,
+						sqlCustomLabelsTableReleaseKeyColumn,
+						sqlReleaseTableName,
+						sqlReleaseTableNamespaceColumn,
+						sqlCustomLabelsTableReleaseNamespaceColumn,
+						sqlReleaseTableName,
+						sqlReleaseTableLabelsColumn,
+					),
+				},
+				Down: []string{
+					fmt.Sprintf(`
+						ALTER TABLE %s DROP COLUMN %s;
+					`, sqlReleaseTableName, sqlReleaseTableLabelsColumn),
+				},
+			},
+			{
+				// The releases are listed in pages, in the order of their
+				// namespace and key.
+				Id: "list_order",
+				Up: []string{
+					fmt.Sprintf(`
+						CREATE INDEX %s_list_order ON %s (%s, %s);
+					`,
+						sqlReleaseTableName,
+						sqlReleaseTableName,
+						sqlReleaseTableNamespaceColumn,
+						sqlReleaseTableKeyColumn,
+					),
+				},
+				Down: []string{
+					fmt.Sprintf(`
+						DROP INDEX %s_list_order;
+					`, sqlReleaseTableName),
+				},
+			},
 		},
 	}
+}
 
-	// Check that init migration already applied
+// Check if all migrations already applied
+func (s *SQL) checkAlreadyApplied(migrations []*migrate.Migration) bool {
+	pending, _, err := s.migrationStatus(migrations)
+	if err != nil {
+		slog.Debug("failed to get migration records", slog.Any("error", err))
+		return false
+	}
+	for _, migration := range pending {
+		slog.Debug("find unapplied migration", "id", migration.Id)
+	}
+	return len(pending) == 0
+}
+
+// migrationStatus returns the migrations which are not applied yet, in the
+// order they are applied, and the IDs of the applied migrations which are not
+// among migrations, as a newer Helm applied them.
+func (s *SQL) migrationStatus(migrations []*migrate.Migration) ([]*migrate.Migration, []string, error) {
+	// get list of applied migrations, without creating their table
+	migrate.SetDisableCreateTable(true)
+	records, err := migrate.GetMigrationRecords(s.db.DB, postgreSQLDialect)
+	migrate.SetDisableCreateTable(false)
+	if err != nil {
+		// The table of the migrations is missing from a new database.
+		if pingErr := s.db.Ping(); pingErr != nil {
+			return nil, nil, err
+		}
+		slog.Debug("no migration records", slog.Any("error", err))
+		records = nil
+	}
+
+	applied := make(map[string]bool)
+	for _, record := range records {
+		applied[record.Id] = true
+	}
+	known := make(map[string]bool)
+	var pending []*migrate.Migration
+	for _, migration := range migrations {
+		known[migration.Id] = true
+		if applied[migration.Id] {
+			slog.Debug("found previous migration", "id", migration.Id)
+			continue
+		}
+		pending = append(pending, migration)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Less(pending[j]) })
+
+	var unknown []string
+	for _, record := range records {
+		if !known[record.Id] {
+			unknown = append(unknown, record.Id)
+		}
+	}
+	return pending, unknown, nil
+}
+
+func (s *SQL) ensureDBSetup() error {
+	_, err := s.Migrate()
+	return err
+}
+
+// ensureSchema migrates the schema of the SQL storage, once, when the driver is
+// created or, with WithDeferredMigration, before its first operation.
+func (s *SQL) ensureSchema() error {
+	s.migrateOnce.Do(func() {
+		s.migrateErr = s.ensureDBSetup()
+	})
+	return s.migrateErr
+}
+
+// PendingMigrations returns the IDs of the migrations of the schema of the SQL
+// storage which are not applied yet, in the order they would be applied. It
+// fails if the schema cannot be migrated by this version of Helm.
+func (s *SQL) PendingMigrations() ([]string, error) {
+	pending, unknown, err := s.migrationStatus(sqlMigrations().Migrations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the migrations of the SQL storage: %w", err)
+	}
+	if err := checkUnknownMigrations(pending, unknown); err != nil {
+		return nil, err
+	}
+	return migrationIDs(pending), nil
+}
+
+// Migrate applies the pending migrations of the schema of the SQL storage, and
+// returns their IDs. The migrations are applied under an advisory lock, so
+// that concurrent Helm invocations do not race.
+func (s *SQL) Migrate() ([]string, error) {
+	migrations := sqlMigrations()
+
+	// Check that the migrations are already applied
 	if s.checkAlreadyApplied(migrations.Migrations) {
+		return nil, nil
+	}
+
+	// Populate the database with the relations we need if they don't exist
+	// yet. Migrations already applied by a concurrent invocation while we
+	// waited for the lock are skipped.
+	var applied []string
+	err := s.withMigrationLock(func() error {
+		pending, unknown, err := s.migrationStatus(migrations.Migrations)
+		if err != nil {
+			return err
+		}
+		if err := checkUnknownMigrations(pending, unknown); err != nil {
+			return err
+		}
+		if _, err := migrate.Exec(s.db.DB, postgreSQLDialect, migrations, migrate.Up); err != nil {
+			return err
+		}
+		applied = migrationIDs(pending)
 		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to migrate the schema of the SQL storage: %w", err)
 	}
+	return applied, nil
+}
 
-	// Populate the database with the relations we need if they don't exist yet
-	_, err := migrate.Exec(s.db.DB, postgreSQLDialect, migrations, migrate.Up)
-	return err
+// checkUnknownMigrations returns an error if migrations are pending while the
+// schema holds migrations unknown to this version of Helm, which cannot be
+// applied in order.
+func checkUnknownMigrations(pending []*migrate.Migration, unknown []string) error {
+	if len(pending) == 0 || len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the schema of the SQL storage was migrated by a newer version of Helm, with the migrations %v unknown to this one, and cannot be migrated with %v", unknown, migrationIDs(pending))
+}
+
+func migrationIDs(migrations []*migrate.Migration) []string {
+	ids := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		ids = append(ids, migration.Id)
+	}
+	return ids
+}
+
+// withMigrationLock runs fn while holding the advisory lock guarding the
+// schema migrations.
+func (s *SQL) withMigrationLock(fn func() error) error {
+	ctx := context.Background()
+
+	// Advisory locks belong to a session, so the lock is taken and released
+	// on the same connection.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", sqlMigrationLockID); err != nil {
+		return fmt.Errorf("unable to lock the database for migrations: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", sqlMigrationLockID); err != nil {
+			slog.Debug("failed to release the migrations lock", slog.Any("error", err))
+		}
+	}()
+
+	return fn()
 }
 
 // SQLReleaseWrapper describes how Helm releases are stored in an SQL database
@@ -266,6 +528,9 @@ type SQLReleaseWrapper struct {
 	Owner      string `db:"owner"`
 	CreatedAt  int    `db:"createdAt"`
 	ModifiedAt int    `db:"modifiedAt"`
+
+	// The custom labels of the release, as a JSON object
+	Labels string `db:"labels"`
 }
 
 type SQLReleaseCustomLabelWrapper struct {
@@ -275,8 +540,10 @@ type SQLReleaseCustomLabelWrapper struct {
 	Value            string `db:"value"`
 }
 
-// NewSQL initializes a new sql driver.
-func NewSQL(connectionString string, namespace string) (*SQL, error) {
+// NewSQL initializes a new sql driver. Passed SQLOptions can be used to
+// override defaults. The schema of the database is migrated, under an advisory
+// lock, unless WithDeferredMigration is passed.
+func NewSQL(connectionString string, namespace string, opts ...SQLOption) (*SQL, error) {
 	db, err := sqlx.Connect(postgreSQLDialect, connectionString)
 	if err != nil {
 		return nil, err
@@ -285,19 +552,87 @@ func NewSQL(connectionString string, namespace string) (*SQL, error) {
 	driver := &SQL{
 		db:               db,
 		statementBuilder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		writeRetries:     sqlDefaultWriteRetries,
 	}
-
-	if err := driver.ensureDBSetup(); err != nil {
-		return nil, err
+	for _, opt := range opts {
+		opt(driver)
 	}
 
 	driver.namespace = namespace
 
+	if !driver.deferMigration {
+		if err := driver.ensureSchema(); err != nil {
+			return nil, err
+		}
+	}
+
 	return driver, nil
 }
 
+// isRetryableSQLError reports whether err is a serialization failure or a
+// deadlock, after which the transaction can be retried.
+func isRetryableSQLError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// retryWrite runs the write fn, retrying it with an exponential backoff when
+// it fails on a serialization failure or a deadlock.
+func (s *SQL) retryWrite(fn func() error) error {
+	delay := sqlRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= s.writeRetries || !isRetryableSQLError(err) {
+			return err
+		}
+		slog.Debug("retrying the write after a serialization failure", "attempt", attempt+1, slog.Any("error", err))
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// selectPages runs the query of sb in pages of sqlListPageSize records, in the
+// order of their namespace and key, and calls fn with the records of every
+// page, so that the records of all the releases are not read at once.
+func (s *SQL) selectPages(sb sq.SelectBuilder, fn func([]SQLReleaseWrapper) error) error {
+	sb = sb.OrderBy(sqlReleaseTableNamespaceColumn, sqlReleaseTableKeyColumn).Limit(sqlListPageSize)
+	page := sb
+	for {
+		query, args, err := page.ToSql()
+		if err != nil {
+			slog.Debug("failed to build query", slog.Any("error", err))
+			return err
+		}
+
+		var records = []SQLReleaseWrapper{}
+		if err := s.db.Select(&records, query, args...); err != nil {
+			return err
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+		if uint64(len(records)) < sqlListPageSize {
+			return nil
+		}
+
+		// The next page starts after the last record of this one.
+		last := records[len(records)-1]
+		page = sb.Where(sq.Expr(
+			fmt.Sprintf("(%s, %s) > (?, ?)", sqlReleaseTableNamespaceColumn, sqlReleaseTableKeyColumn),
+			last.Namespace, last.Key,
+		))
+	}
+}
+
 // Get returns the release named by key.
 func (s *SQL) Get(key string) (*rspb.Release, error) {
+	if err := s.ensureSchema(); err != nil {
+		return nil, err
+	}
+
 	var record SQLReleaseWrapper
 
 	qb := s.statementBuilder.
@@ -321,7 +656,7 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 	release, err := decodeRelease(record.Body)
 	if err != nil {
 		slog.Debug("failed to decode data", "key", key, slog.Any("error", err))
-		return nil, err
+		return nil, newCorruptReleaseError(key, s.namespace, nil, err)
 	}
 
 	if release.Labels, err = s.getReleaseCustomLabels(key, s.namespace); err != nil {
@@ -332,10 +667,26 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 	return release, nil
 }
 
-// List returns the list of all releases such that filter(release) == true
+// List returns the list of all releases such that filter(release) == true.
+// The releases which cannot be decoded are skipped.
 func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	releases, corrupt, err := s.ListWithCorrupt(filter)
+	for _, c := range corrupt {
+		slog.Debug("failed to decode release", "namespace", c.Namespace, "key", c.Key, slog.Any("error", c.Err))
+	}
+	return releases, err
+}
+
+// ListWithCorrupt returns the list of all releases such that
+// filter(release) == true, along with the records of releases which cannot be
+// decoded.
+func (s *SQL) ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*CorruptReleaseError, error) {
+	if err := s.ensureSchema(); err != nil {
+		return nil, nil, err
+	}
+
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn).
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn, sqlReleaseTableLabelsColumn).
 		From(sqlReleaseTableName).
 		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
 
@@ -344,44 +695,191 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
 	}
 
-	query, args, err := sb.ToSql()
+	var releases []*rspb.Release
+	var corrupt []*CorruptReleaseError
+	err := s.selectPages(sb, func(records []SQLReleaseWrapper) error {
+		for _, record := range records {
+			release, err := decodeRelease(record.Body)
+			if err != nil {
+				corrupt = append(corrupt, newCorruptReleaseError(record.Key, record.Namespace, nil, err))
+				continue
+			}
+
+			if release.Labels, err = decodeSQLLabels(record.Labels); err != nil {
+				slog.Debug("failed to decode release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
+				return err
+			}
+			maps.Copy(release.Labels, getReleaseSystemLabels(release))
+
+			if filter(release) {
+				releases = append(releases, release)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		slog.Debug("failed to build query", slog.Any("error", err))
-		return nil, err
+		slog.Debug("failed to list", slog.Any("error", err))
+		return nil, nil, err
 	}
 
-	var records = []SQLReleaseWrapper{}
-	if err := s.db.Select(&records, query, args...); err != nil {
-		slog.Debug("failed to list", slog.Any("error", err))
-		return nil, err
+	return releases, corrupt, nil
+}
+
+// ListPage returns the releases selected by opts, in the order of their name,
+// namespace and version, along with the records of releases which cannot be
+// decoded. The releases are selected by the database and read in pages of at
+// most sqlListPageSize records, every page starting after the name, namespace
+// and version of the last record of the previous one, so that the records are
+// read in a stable order whatever the offset.
+func (s *SQL) ListPage(opts ListOptions) ([]*rspb.Release, []*CorruptReleaseError, error) {
+	if err := s.ensureSchema(); err != nil {
+		return nil, nil, err
 	}
 
+	sb := s.statementBuilder.
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableNameColumn, sqlReleaseTableVersionColumn, sqlReleaseTableBodyColumn, sqlReleaseTableLabelsColumn).
+		From(sqlReleaseTableName + " AS r").
+		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
+
+	// If a namespace was specified, we only list releases from that namespace
+	if s.namespace != "" {
+		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+	}
+	if opts.Latest {
+		sb = sb.Where(fmt.Sprintf("r.%[1]s = (SELECT MAX(l.%[1]s) FROM %[2]s AS l WHERE l.%[3]s = r.%[3]s AND l.%[4]s = r.%[4]s AND l.%[5]s = r.%[5]s)",
+			sqlReleaseTableVersionColumn, sqlReleaseTableName, sqlReleaseTableNameColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableOwnerColumn))
+	}
+	if len(opts.Statuses) > 0 {
+		statuses := make([]string, 0, len(opts.Statuses))
+		for _, status := range opts.Statuses {
+			statuses = append(statuses, status.String())
+		}
+		sb = sb.Where(sq.Eq{sqlReleaseTableStatusColumn: statuses})
+	}
+	sb = sb.OrderBy(sqlReleaseTableNameColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableVersionColumn)
+
 	var releases []*rspb.Release
-	for _, record := range records {
-		release, err := decodeRelease(record.Body)
+	var corrupt []*CorruptReleaseError
+	skip, taken := opts.Offset, 0
+	page := sb
+	for {
+		size := sqlListPageSize
+		if opts.Limit > 0 {
+			if left := uint64(skip + opts.Limit - taken); left < size {
+				size = left
+			}
+		}
+		query, args, err := page.Limit(size).ToSql()
 		if err != nil {
-			slog.Debug("failed to decode release", "record", record, slog.Any("error", err))
-			continue
+			slog.Debug("failed to build query", slog.Any("error", err))
+			return nil, nil, err
 		}
-
-		if release.Labels, err = s.getReleaseCustomLabels(record.Key, record.Namespace); err != nil {
-			slog.Debug("failed to get release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
-			return nil, err
+		var records = []SQLReleaseWrapper{}
+		if err := s.db.Select(&records, query, args...); err != nil {
+			slog.Debug("failed to list", slog.Any("error", err))
+			return nil, nil, err
 		}
-		maps.Copy(release.Labels, getReleaseSystemLabels(release))
 
-		if filter(release) {
+		for _, record := range records {
+			// The records skipped are not decoded, so the records which
+			// cannot be decoded count towards the offset.
+			if skip > 0 {
+				skip--
+				continue
+			}
+			taken++
+			release, err := decodeRelease(record.Body)
+			if err != nil {
+				corrupt = append(corrupt, newCorruptReleaseError(record.Key, record.Namespace, nil, err))
+				continue
+			}
+			if release.Labels, err = decodeSQLLabels(record.Labels); err != nil {
+				slog.Debug("failed to decode release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
+				return nil, nil, err
+			}
+			maps.Copy(release.Labels, getReleaseSystemLabels(release))
 			releases = append(releases, release)
 		}
+		if uint64(len(records)) < size || (opts.Limit > 0 && taken == opts.Limit) {
+			return releases, corrupt, nil
+		}
+
+		// The next page starts after the last record of this one.
+		last := records[len(records)-1]
+		page = sb.Where(sq.Expr(
+			fmt.Sprintf("(r.%s, r.%s, r.%s) > (?, ?, ?)", sqlReleaseTableNameColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableVersionColumn),
+			last.Name, last.Namespace, last.Version,
+		))
 	}
+}
 
-	return releases, nil
+// DeleteCorrupt deletes the record of the release named by key, along with
+// its custom labels, without decoding it.
+func (s *SQL) DeleteCorrupt(key string) error {
+	if err := s.ensureSchema(); err != nil {
+		return err
+	}
+	return s.retryWrite(func() error {
+		return s.deleteCorrupt(key)
+	})
+}
+
+func (s *SQL) deleteCorrupt(key string) error {
+	transaction, err := s.db.Beginx()
+	if err != nil {
+		slog.Debug("failed to start SQL transaction", slog.Any("error", err))
+		return fmt.Errorf("error beginning transaction: %v", err)
+	}
+
+	deleteQuery, args, err := s.statementBuilder.
+		Delete(sqlReleaseTableName).
+		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
+		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace}).
+		ToSql()
+	if err != nil {
+		slog.Debug("failed to build delete query", slog.Any("error", err))
+		transaction.Rollback()
+		return err
+	}
+
+	result, err := transaction.Exec(deleteQuery, args...)
+	if err != nil {
+		slog.Debug("failed perform delete query", slog.Any("error", err))
+		transaction.Rollback()
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		transaction.Rollback()
+		return ErrReleaseNotFound
+	}
+
+	deleteCustomLabelsQuery, args, err := s.statementBuilder.
+		Delete(sqlCustomLabelsTableName).
+		Where(sq.Eq{sqlCustomLabelsTableReleaseKeyColumn: key}).
+		Where(sq.Eq{sqlCustomLabelsTableReleaseNamespaceColumn: s.namespace}).
+		ToSql()
+	if err != nil {
+		slog.Debug("failed to build delete Labels query", slog.Any("error", err))
+		transaction.Rollback()
+		return err
+	}
+	if _, err := transaction.Exec(deleteCustomLabelsQuery, args...); err != nil {
+		transaction.Rollback()
+		return err
+	}
+	return transaction.Commit()
 }
 
 // Query returns the set of releases that match the provided set of labels.
+// Labels other than the system ones are matched against the custom labels of
+// the releases.
 func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
+	if err := s.ensureSchema(); err != nil {
+		return nil, err
+	}
+
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn).
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn, sqlReleaseTableLabelsColumn).
 		From(sqlReleaseTableName)
 
 	keys := make([]string, 0, len(labels))
@@ -389,13 +887,20 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
+	custom := make(map[string]string)
 	for _, key := range keys {
 		if _, ok := labelMap[key]; ok {
 			sb = sb.Where(sq.Eq{key: labels[key]})
 		} else {
-			slog.Debug("unknown label", "key", key)
-			return nil, fmt.Errorf("unknown label %s", key)
+			custom[key] = labels[key]
+		}
+	}
+	if len(custom) > 0 {
+		customLabels, err := json.Marshal(custom)
+		if err != nil {
+			return nil, err
 		}
+		sb = sb.Where(sq.Expr(sqlReleaseTableLabelsColumn+" @> ?", string(customLabels)))
 	}
 
 	// If a namespace was specified, we only list releases from that namespace
@@ -403,37 +908,27 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
 	}
 
-	// Build our query
-	query, args, err := sb.ToSql()
-	if err != nil {
-		slog.Debug("failed to build query", slog.Any("error", err))
-		return nil, err
-	}
-
-	var records = []SQLReleaseWrapper{}
-	if err := s.db.Select(&records, query, args...); err != nil {
-		slog.Debug("failed to query with labels", slog.Any("error", err))
-		return nil, err
-	}
-
-	if len(records) == 0 {
-		return nil, ErrReleaseNotFound
-	}
-
 	var releases []*rspb.Release
-	for _, record := range records {
-		release, err := decodeRelease(record.Body)
-		if err != nil {
-			slog.Debug("failed to decode release", "record", record, slog.Any("error", err))
-			continue
-		}
+	err := s.selectPages(sb, func(records []SQLReleaseWrapper) error {
+		for _, record := range records {
+			release, err := decodeRelease(record.Body)
+			if err != nil {
+				slog.Debug("failed to decode release", "record", record, slog.Any("error", err))
+				continue
+			}
+
+			if release.Labels, err = decodeSQLLabels(record.Labels); err != nil {
+				slog.Debug("failed to decode release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
+				return err
+			}
 
-		if release.Labels, err = s.getReleaseCustomLabels(record.Key, record.Namespace); err != nil {
-			slog.Debug("failed to get release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
-			return nil, err
+			releases = append(releases, release)
 		}
-
-		releases = append(releases, release)
+		return nil
+	})
+	if err != nil {
+		slog.Debug("failed to query with labels", slog.Any("error", err))
+		return nil, err
 	}
 
 	if len(releases) == 0 {
@@ -443,8 +938,18 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 	return releases, nil
 }
 
-// Create creates a new release.
+// Create creates a new release. A write failing on a serialization failure or
+// a deadlock is retried.
 func (s *SQL) Create(key string, rls *rspb.Release) error {
+	if err := s.ensureSchema(); err != nil {
+		return err
+	}
+	return s.retryWrite(func() error {
+		return s.create(key, rls)
+	})
+}
+
+func (s *SQL) create(key string, rls *rspb.Release) error {
 	namespace := rls.Namespace
 	if namespace == "" {
 		namespace = defaultNamespace
@@ -457,6 +962,12 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 		return err
 	}
 
+	labels, err := encodeSQLLabels(rls.Labels)
+	if err != nil {
+		slog.Debug("failed to encode release custom labels", slog.Any("error", err))
+		return err
+	}
+
 	transaction, err := s.db.Beginx()
 	if err != nil {
 		slog.Debug("failed to start SQL transaction", slog.Any("error", err))
@@ -475,6 +986,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 			sqlReleaseTableStatusColumn,
 			sqlReleaseTableOwnerColumn,
 			sqlReleaseTableCreatedAtColumn,
+			sqlReleaseTableLabelsColumn,
 		).
 		Values(
 			key,
@@ -486,6 +998,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 			rls.Info.Status.String(),
 			sqlReleaseDefaultOwner,
 			int(time.Now().Unix()),
+			labels,
 		).ToSql()
 	if err != nil {
 		slog.Debug("failed to build insert query", slog.Any("error", err))
@@ -516,7 +1029,8 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 		return err
 	}
 
-	// Filtering labels before insert cause in SQL storage driver system releases are stored in separate columns of release table
+	// Filtering labels before insert cause in SQL storage driver system releases are stored in separate columns of release table.
+	// The custom labels table is still written for the Helm versions predating the labels column.
 	for k, v := range filterSystemLabels(rls.Labels) {
 		insertLabelsQuery, args, err := s.statementBuilder.
 			Insert(sqlCustomLabelsTableName).
@@ -545,13 +1059,21 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 			return err
 		}
 	}
-	defer transaction.Commit()
-
-	return nil
+	return transaction.Commit()
 }
 
-// Update updates a release.
+// Update updates a release. A write failing on a serialization failure or a
+// deadlock is retried.
 func (s *SQL) Update(key string, rls *rspb.Release) error {
+	if err := s.ensureSchema(); err != nil {
+		return err
+	}
+	return s.retryWrite(func() error {
+		return s.update(key, rls)
+	})
+}
+
+func (s *SQL) update(key string, rls *rspb.Release) error {
 	namespace := rls.Namespace
 	if namespace == "" {
 		namespace = defaultNamespace
@@ -564,6 +1086,12 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 		return err
 	}
 
+	labels, err := encodeSQLLabels(rls.Labels)
+	if err != nil {
+		slog.Debug("failed to encode release custom labels", slog.Any("error", err))
+		return err
+	}
+
 	query, args, err := s.statementBuilder.
 		Update(sqlReleaseTableName).
 		Set(sqlReleaseTableBodyColumn, body).
@@ -572,6 +1100,7 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 		Set(sqlReleaseTableStatusColumn, rls.Info.Status.String()).
 		Set(sqlReleaseTableOwnerColumn, sqlReleaseDefaultOwner).
 		Set(sqlReleaseTableModifiedAtColumn, int(time.Now().Unix())).
+		Set(sqlReleaseTableLabelsColumn, labels).
 		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
 		Where(sq.Eq{sqlReleaseTableNamespaceColumn: namespace}).
 		ToSql()
@@ -589,8 +1118,22 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 	return nil
 }
 
-// Delete deletes a release or returns ErrReleaseNotFound.
+// Delete deletes a release or returns ErrReleaseNotFound. A write failing on a
+// serialization failure or a deadlock is retried.
 func (s *SQL) Delete(key string) (*rspb.Release, error) {
+	if err := s.ensureSchema(); err != nil {
+		return nil, err
+	}
+	var release *rspb.Release
+	err := s.retryWrite(func() error {
+		var err error
+		release, err = s.delete(key)
+		return err
+	})
+	return release, err
+}
+
+func (s *SQL) delete(key string) (*rspb.Release, error) {
 	transaction, err := s.db.Beginx()
 	if err != nil {
 		slog.Debug("failed to start SQL transaction", slog.Any("error", err))
@@ -692,3 +1235,24 @@ func getReleaseSystemLabels(rls *rspb.Release) map[string]string {
 		"version": strconv.Itoa(rls.Version),
 	}
 }
+
+// Encode the custom labels of a release for the labels column
+func encodeSQLLabels(labels map[string]string) (string, error) {
+	data, err := json.Marshal(filterSystemLabels(labels))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Decode the custom labels of a release from the labels column
+func decodeSQLLabels(data string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if data == "" {
+		return labels, nil
+	}
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return nil, err
+	}
+	return filterSystemLabels(labels), nil
+}
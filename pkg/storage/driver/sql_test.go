@@ -21,6 +21,7 @@ import (
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	migrate "github.com/rubenv/sql-migrate"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
@@ -92,10 +93,11 @@ func TestSQLList(t *testing.T) {
 
 	for i := 0; i < 3; i++ {
 		query := fmt.Sprintf(
-			"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
+			"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
 			sqlReleaseTableKeyColumn,
 			sqlReleaseTableNamespaceColumn,
 			sqlReleaseTableBodyColumn,
+			sqlReleaseTableLabelsColumn,
 			sqlReleaseTableName,
 			sqlReleaseTableOwnerColumn,
 			sqlReleaseTableNamespaceColumn,
@@ -103,19 +105,17 @@ func TestSQLList(t *testing.T) {
 
 		rows := mock.NewRows([]string{
 			sqlReleaseTableBodyColumn,
+			sqlReleaseTableLabelsColumn,
 		})
 		for _, r := range releases {
 			body, _ := encodeRelease(r)
-			rows.AddRow(body)
+			labels, _ := encodeSQLLabels(r.Labels)
+			rows.AddRow(body, labels)
 		}
 		mock.
 			ExpectQuery(regexp.QuoteMeta(query)).
 			WithArgs(sqlReleaseDefaultOwner, sqlDriver.namespace).
 			WillReturnRows(rows).RowsWillBeClosed()
-
-		for _, r := range releases {
-			mockGetReleaseCustomLabels(mock, "", r.Namespace, r.Labels)
-		}
 	}
 
 	// list all deleted releases
@@ -179,9 +179,10 @@ func TestSqlCreate(t *testing.T) {
 
 	sqlDriver, mock := newTestFixtureSQL(t)
 	body, _ := encodeRelease(rel)
+	labels, _ := encodeSQLLabels(rel.Labels)
 
 	query := fmt.Sprintf(
-		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)",
+		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)",
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableTypeColumn,
@@ -192,12 +193,13 @@ func TestSqlCreate(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableLabelsColumn,
 	)
 
 	mock.ExpectBegin()
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix())).
+		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), labels).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	labelsQuery := fmt.Sprintf(
@@ -236,9 +238,10 @@ func TestSqlCreateAlreadyExists(t *testing.T) {
 
 	sqlDriver, mock := newTestFixtureSQL(t)
 	body, _ := encodeRelease(rel)
+	labels, _ := encodeSQLLabels(rel.Labels)
 
 	insertQuery := fmt.Sprintf(
-		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)",
+		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)",
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableTypeColumn,
@@ -249,13 +252,14 @@ func TestSqlCreateAlreadyExists(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableLabelsColumn,
 	)
 
 	// Insert fails (primary key already exists)
 	mock.ExpectBegin()
 	mock.
 		ExpectExec(regexp.QuoteMeta(insertQuery)).
-		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix())).
+		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), labels).
 		WillReturnError(fmt.Errorf("dialect dependent SQL error"))
 
 	selectQuery := fmt.Sprintf(
@@ -297,9 +301,10 @@ func TestSqlUpdate(t *testing.T) {
 
 	sqlDriver, mock := newTestFixtureSQL(t)
 	body, _ := encodeRelease(rel)
+	labels, _ := encodeSQLLabels(rel.Labels)
 
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s = $1, %s = $2, %s = $3, %s = $4, %s = $5, %s = $6 WHERE %s = $7 AND %s = $8",
+		"UPDATE %s SET %s = $1, %s = $2, %s = $3, %s = $4, %s = $5, %s = $6, %s = $7 WHERE %s = $8 AND %s = $9",
 		sqlReleaseTableName,
 		sqlReleaseTableBodyColumn,
 		sqlReleaseTableNameColumn,
@@ -307,13 +312,14 @@ func TestSqlUpdate(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableModifiedAtColumn,
+		sqlReleaseTableLabelsColumn,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 	)
 
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(body, rel.Name, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), key, namespace).
+		WithArgs(body, rel.Name, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), labels, key, namespace).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	if err := sqlDriver.Update(key, rel); err != nil {
@@ -344,17 +350,20 @@ func TestSqlQuery(t *testing.T) {
 
 	supersededRelease := releaseStub("smug-pigeon", 1, "default", rspb.StatusSuperseded)
 	supersededReleaseBody, _ := encodeRelease(supersededRelease)
+	supersededReleaseLabels, _ := encodeSQLLabels(supersededRelease.Labels)
 	deployedRelease := releaseStub("smug-pigeon", 2, "default", rspb.StatusDeployed)
 	deployedReleaseBody, _ := encodeRelease(deployedRelease)
+	deployedReleaseLabels, _ := encodeSQLLabels(deployedRelease.Labels)
 
 	// Let's actually start our test
 	sqlDriver, mock := newTestFixtureSQL(t)
 
 	query := fmt.Sprintf(
-		"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3 AND %s = $4",
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3 AND %s = $4",
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableNameColumn,
 		sqlReleaseTableOwnerColumn,
@@ -377,18 +386,19 @@ func TestSqlQuery(t *testing.T) {
 		WillReturnRows(
 			mock.NewRows([]string{
 				sqlReleaseTableBodyColumn,
+				sqlReleaseTableLabelsColumn,
 			}).AddRow(
 				deployedReleaseBody,
+				deployedReleaseLabels,
 			),
 		).RowsWillBeClosed()
 
-	mockGetReleaseCustomLabels(mock, "", deployedRelease.Namespace, deployedRelease.Labels)
-
 	query = fmt.Sprintf(
-		"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3",
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3",
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableNameColumn,
 		sqlReleaseTableOwnerColumn,
@@ -401,16 +411,16 @@ func TestSqlQuery(t *testing.T) {
 		WillReturnRows(
 			mock.NewRows([]string{
 				sqlReleaseTableBodyColumn,
+				sqlReleaseTableLabelsColumn,
 			}).AddRow(
 				supersededReleaseBody,
+				supersededReleaseLabels,
 			).AddRow(
 				deployedReleaseBody,
+				deployedReleaseLabels,
 			),
 		).RowsWillBeClosed()
 
-	mockGetReleaseCustomLabels(mock, "", supersededRelease.Namespace, supersededRelease.Labels)
-	mockGetReleaseCustomLabels(mock, "", deployedRelease.Namespace, deployedRelease.Labels)
-
 	_, err := sqlDriver.Query(labelSetUnknown)
 	if err == nil {
 		t.Errorf("Expected error {%v}, got nil", ErrReleaseNotFound)
@@ -449,6 +459,55 @@ func TestSqlQuery(t *testing.T) {
 	}
 }
 
+func TestSqlQueryCustomLabels(t *testing.T) {
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	body, _ := encodeRelease(rel)
+	labels, _ := encodeSQLLabels(rel.Labels)
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s @> $2 AND %s = $3",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("smug-pigeon", `{"key1":"val1","key2":"val2"}`, "default").
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableBodyColumn,
+				sqlReleaseTableLabelsColumn,
+			}).AddRow(
+				body,
+				labels,
+			),
+		).RowsWillBeClosed()
+
+	results, err := sqlDriver.Query(map[string]string{
+		"name": "smug-pigeon",
+		"key1": "val1",
+		"key2": "val2",
+	})
+	if err != nil {
+		t.Fatalf("failed to query for smug-pigeon release by custom labels: %v", err)
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0], rel) {
+		t.Errorf("Expected release {%v}, got {%v}", rel, results)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
 func TestSqlDelete(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
@@ -590,3 +649,520 @@ func TestSqlCheckAppliedMigrations(t *testing.T) {
 		}
 	}
 }
+
+func TestSqlWithMigrationLock(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(sqlMigrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec(regexp.QuoteMeta("CREATE TABLE migrated")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(sqlMigrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := sqlDriver.withMigrationLock(func() error {
+		_, err := sqlDriver.db.Exec("CREATE TABLE migrated")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to run with the migrations lock: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSqlWithMigrationLockError(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(sqlMigrationLockID).
+		WillReturnError(fmt.Errorf("connection reset"))
+
+	err := sqlDriver.withMigrationLock(func() error {
+		t.Fatal("migrations must not run without the lock")
+		return nil
+	})
+	if err == nil || err.Error() != "unable to lock the database for migrations: connection reset" {
+		t.Errorf("Expected lock error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSqlLabelsEncoding(t *testing.T) {
+	labels, err := encodeSQLLabels(map[string]string{
+		"name": "smug-pigeon",
+		"key1": "val1",
+	})
+	if err != nil {
+		t.Fatalf("failed to encode labels: %v", err)
+	}
+	if labels != `{"key1":"val1"}` {
+		t.Errorf("Expected system labels to be left out, got %s", labels)
+	}
+
+	decoded, err := decodeSQLLabels(labels)
+	if err != nil {
+		t.Fatalf("failed to decode labels: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, map[string]string{"key1": "val1"}) {
+		t.Errorf("Expected labels {key1: val1}, got %v", decoded)
+	}
+
+	decoded, err = decodeSQLLabels("")
+	if err != nil || len(decoded) != 0 {
+		t.Errorf("Expected no labels for an empty column, got %v, %v", decoded, err)
+	}
+}
+
+func TestSqlListWithCorrupt(t *testing.T) {
+	rel := releaseStub("angry-bird", 1, "default", rspb.StatusSuperseded)
+	body, _ := encodeRelease(rel)
+	labels, _ := encodeSQLLabels(rel.Labels)
+	corruptKey := "sh.helm.release.v1.angry-bird.v2"
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	rows := mock.NewRows([]string{
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+	}).
+		AddRow("sh.helm.release.v1.angry-bird.v1", "default", body, labels).
+		AddRow(corruptKey, "default", "H4sIAAAAAAAC", labels)
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(sqlReleaseDefaultOwner, sqlDriver.namespace).
+		WillReturnRows(rows).RowsWillBeClosed()
+
+	list, corrupt, err := sqlDriver.ListWithCorrupt(func(*rspb.Release) bool { return true })
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Failed to list releases: %v", err)
+	}
+	if len(list) != 1 || list[0].Version != 1 {
+		t.Errorf("Expected only revision 1 to be listed, got %d releases", len(list))
+	}
+	expect := []*CorruptReleaseError{{Key: corruptKey, Namespace: "default", Name: "angry-bird", Version: 2}}
+	if len(corrupt) != 1 {
+		t.Fatalf("Expected 1 corrupt record, got %d", len(corrupt))
+	}
+	corrupt[0].Err = nil
+	if !reflect.DeepEqual(expect, corrupt) {
+		t.Errorf("Expected %+v, got %+v", expect[0], corrupt[0])
+	}
+}
+
+func TestSqlDeleteCorrupt(t *testing.T) {
+	key := "sh.helm.release.v1.angry-bird.v2"
+	namespace := "default"
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	deleteQuery := fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = $1 AND %s = $2",
+		sqlReleaseTableName,
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	deleteLabelsQuery := fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = $1 AND %s = $2",
+		sqlCustomLabelsTableName,
+		sqlCustomLabelsTableReleaseKeyColumn,
+		sqlCustomLabelsTableReleaseNamespaceColumn,
+	)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(regexp.QuoteMeta(deleteQuery)).
+		WithArgs(key, namespace).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.
+		ExpectExec(regexp.QuoteMeta(deleteLabelsQuery)).
+		WithArgs(key, namespace).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := sqlDriver.DeleteCorrupt(key); err != nil {
+		t.Fatalf("failed to delete corrupt release with key %q: %v", key, err)
+	}
+
+	// a record which does not exist is not found
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(regexp.QuoteMeta(deleteQuery)).
+		WithArgs(key, namespace).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err := sqlDriver.DeleteCorrupt(key); err != ErrReleaseNotFound {
+		t.Errorf("Expected ErrReleaseNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSqlListPages(t *testing.T) {
+	defer func(size uint64) { sqlListPageSize = size }(sqlListPageSize)
+	sqlListPageSize = 2
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	order := fmt.Sprintf(" ORDER BY %s, %s LIMIT 2", sqlReleaseTableNamespaceColumn, sqlReleaseTableKeyColumn)
+	after := fmt.Sprintf(" AND (%s, %s) > ($3, $4)", sqlReleaseTableNamespaceColumn, sqlReleaseTableKeyColumn)
+
+	page := func(names ...string) *sqlmock.Rows {
+		rows := mock.NewRows([]string{
+			sqlReleaseTableKeyColumn,
+			sqlReleaseTableNamespaceColumn,
+			sqlReleaseTableBodyColumn,
+			sqlReleaseTableLabelsColumn,
+		})
+		for _, name := range names {
+			body, _ := encodeRelease(releaseStub(name, 1, "default", rspb.StatusDeployed))
+			rows.AddRow(testKey(name, 1), "default", body, "{}")
+		}
+		return rows
+	}
+
+	// The last page is empty, as the previous one is full.
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query+order)).
+		WithArgs(sqlReleaseDefaultOwner, "default").
+		WillReturnRows(page("a", "b")).RowsWillBeClosed()
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query+after+order)).
+		WithArgs(sqlReleaseDefaultOwner, "default", "default", testKey("b", 1)).
+		WillReturnRows(page("c", "d")).RowsWillBeClosed()
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query+after+order)).
+		WithArgs(sqlReleaseDefaultOwner, "default", "default", testKey("d", 1)).
+		WillReturnRows(page()).RowsWillBeClosed()
+
+	releases, err := sqlDriver.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	var names []string
+	for _, rel := range releases {
+		names = append(names, rel.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected the releases of every page, got %v", names)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+// listPageRows returns the rows of the records ListPage reads.
+func listPageRows(mock sqlmock.Sqlmock) *sqlmock.Rows {
+	return mock.NewRows([]string{
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+	})
+}
+
+func TestSqlListPage(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+	query := fmt.Sprintf(
+		"SELECT %[1]s, %[2]s, %[8]s, %[7]s, %[3]s, %[4]s FROM %[5]s AS r WHERE %[6]s = $1 AND %[2]s = $2"+
+			" AND r.%[7]s = (SELECT MAX(l.%[7]s) FROM %[5]s AS l WHERE l.%[8]s = r.%[8]s AND l.%[2]s = r.%[2]s AND l.%[6]s = r.%[6]s)"+
+			" AND %[9]s IN ($3,$4) ORDER BY %[8]s, %[2]s, %[7]s LIMIT 3",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableStatusColumn,
+	)
+
+	rows := listPageRows(mock)
+	rows.AddRow(testKey("a", 1), "default", "a", 1, "not decoded as it is skipped", "{}")
+	body, _ := encodeRelease(releaseStub("b", 2, "default", rspb.StatusDeployed))
+	rows.AddRow(testKey("b", 2), "default", "b", 2, body, `{"team":"web"}`)
+	rows.AddRow(testKey("c", 1), "default", "c", 1, "not a release", "{}")
+	mock.
+		ExpectQuery("^"+regexp.QuoteMeta(query)+"$").
+		WithArgs(sqlReleaseDefaultOwner, "default", "deployed", "failed").
+		WillReturnRows(rows)
+
+	releases, corrupt, err := sqlDriver.ListPage(ListOptions{
+		Latest:   true,
+		Statuses: []rspb.Status{rspb.StatusDeployed, rspb.StatusFailed},
+		Offset:   1,
+		Limit:    2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to list a page: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Name != "b" || releases[0].Labels["team"] != "web" || releases[0].Labels["name"] != "b" {
+		t.Errorf("Expected release b with its custom and system labels, got %v", releases)
+	}
+	if len(corrupt) != 1 || corrupt[0].Key != testKey("c", 1) {
+		t.Errorf("Expected the record of c to be reported corrupt, got %v", corrupt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSqlListPageVersions(t *testing.T) {
+	defer func(size uint64) { sqlListPageSize = size }(sqlListPageSize)
+	sqlListPageSize = 2
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+	query := fmt.Sprintf(
+		"SELECT %[1]s, %[2]s, %[4]s, %[5]s, %[6]s, %[7]s FROM %[3]s AS r WHERE %[8]s = $1 AND %[2]s = $2 AND %[9]s IN ($3)",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableLabelsColumn,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableStatusColumn,
+	)
+	order := fmt.Sprintf(" ORDER BY %s, %s, %s LIMIT 2", sqlReleaseTableNameColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableVersionColumn)
+	after := fmt.Sprintf(" AND (r.%s, r.%s, r.%s) > ($4, $5, $6)", sqlReleaseTableNameColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableVersionColumn)
+
+	page := func(revisions ...*rspb.Release) *sqlmock.Rows {
+		rows := listPageRows(mock)
+		for _, rel := range revisions {
+			body, _ := encodeRelease(rel)
+			rows.AddRow(testKey(rel.Name, rel.Version), rel.Namespace, rel.Name, rel.Version, body, "{}")
+		}
+		return rows
+	}
+	superseded := func(name string, version int) *rspb.Release {
+		return releaseStub(name, version, "default", rspb.StatusSuperseded)
+	}
+
+	// The revisions of a are read across both pages, the second one starting
+	// after the second revision rather than after the release.
+	mock.
+		ExpectQuery("^"+regexp.QuoteMeta(query+order)+"$").
+		WithArgs(sqlReleaseDefaultOwner, "default", "superseded").
+		WillReturnRows(page(superseded("a", 1), superseded("a", 2)))
+	mock.
+		ExpectQuery("^"+regexp.QuoteMeta(query+after+order)+"$").
+		WithArgs(sqlReleaseDefaultOwner, "default", "superseded", "a", "default", 2).
+		WillReturnRows(page(superseded("a", 3), superseded("b", 1)))
+
+	releases, corrupt, err := sqlDriver.ListPage(ListOptions{
+		Statuses: []rspb.Status{rspb.StatusSuperseded},
+		Offset:   1,
+		Limit:    3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to list a page: %v", err)
+	}
+	if len(corrupt) != 0 {
+		t.Errorf("Expected no corrupt records, got %v", corrupt)
+	}
+	var keys []string
+	for _, rel := range releases {
+		keys = append(keys, testKey(rel.Name, rel.Version))
+	}
+	if expected := []string{testKey("a", 2), testKey("a", 3), testKey("b", 1)}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected the revisions %v, got %v", expected, keys)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSqlUpdateRetry(t *testing.T) {
+	defer func(delay time.Duration) { sqlRetryDelay = delay }(sqlRetryDelay)
+	sqlRetryDelay = 0
+
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	key := testKey(rel.Name, rel.Version)
+	update := regexp.QuoteMeta(fmt.Sprintf("UPDATE %s SET", sqlReleaseTableName))
+
+	t.Run("serialization failure", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		sqlDriver.writeRetries = 2
+		mock.ExpectExec(update).WillReturnError(&pq.Error{Code: "40001"})
+		mock.ExpectExec(update).WillReturnError(&pq.Error{Code: "40P01"})
+		mock.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := sqlDriver.Update(key, rel); err != nil {
+			t.Fatalf("Expected the update to be retried, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("sql expectations weren't met: %v", err)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		sqlDriver.writeRetries = 1
+		mock.ExpectExec(update).WillReturnError(&pq.Error{Code: "40001"})
+		mock.ExpectExec(update).WillReturnError(&pq.Error{Code: "40001"})
+
+		if err := sqlDriver.Update(key, rel); !isRetryableSQLError(err) {
+			t.Fatalf("Expected the serialization failure, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("sql expectations weren't met: %v", err)
+		}
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		sqlDriver.writeRetries = 2
+		mock.ExpectExec(update).WillReturnError(&pq.Error{Code: "23505"})
+
+		if err := sqlDriver.Update(key, rel); err == nil {
+			t.Fatal("Expected the update to fail")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("sql expectations weren't met: %v", err)
+		}
+	})
+}
+
+func TestSqlPendingMigrations(t *testing.T) {
+	records := func(ids ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "applied_at"})
+		for _, id := range ids {
+			rows.AddRow(id, time.Time{})
+		}
+		return rows
+	}
+
+	t.Run("new database", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		mock.ExpectQuery("gorp_migrations").WillReturnError(fmt.Errorf(`relation "gorp_migrations" does not exist`))
+
+		pending, err := sqlDriver.PendingMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []string{"custom_labels", "init", "labels", "list_order"}
+		if !reflect.DeepEqual(pending, expect) {
+			t.Errorf("Expected the pending migrations %v, got %v", expect, pending)
+		}
+	})
+
+	t.Run("previous schema", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		mock.ExpectQuery("gorp_migrations").WillReturnRows(records("custom_labels", "init", "labels"))
+
+		pending, err := sqlDriver.PendingMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(pending, []string{"list_order"}) {
+			t.Errorf("Expected the pending migration list_order, got %v", pending)
+		}
+	})
+
+	t.Run("newer schema", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		mock.ExpectQuery("gorp_migrations").WillReturnRows(records("custom_labels", "init", "labels", "list_order", "newer"))
+
+		pending, err := sqlDriver.PendingMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("Expected no pending migrations, got %v", pending)
+		}
+	})
+
+	t.Run("newer schema with a pending migration", func(t *testing.T) {
+		sqlDriver, mock := newTestFixtureSQL(t)
+		mock.ExpectQuery("gorp_migrations").WillReturnRows(records("custom_labels", "init", "newer"))
+
+		_, err := sqlDriver.PendingMigrations()
+		expect := "the schema of the SQL storage was migrated by a newer version of Helm, with the migrations [newer] unknown to this one, and cannot be migrated with [labels list_order]"
+		if err == nil || err.Error() != expect {
+			t.Errorf("Expected error %q, got %v", expect, err)
+		}
+	})
+}
+
+func TestSqlMigrateFromPreviousSchema(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+	applied := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "applied_at"}).
+			AddRow("custom_labels", time.Time{}).
+			AddRow("init", time.Time{}).
+			AddRow("labels", time.Time{})
+	}
+
+	mock.ExpectQuery("gorp_migrations").WillReturnRows(applied())
+	mock.
+		ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(sqlMigrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("gorp_migrations").WillReturnRows(applied())
+	mock.ExpectExec("create table if not exists").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("gorp_migrations").WillReturnRows(applied())
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(regexp.QuoteMeta(fmt.Sprintf("CREATE INDEX %s_list_order ON %s (%s, %s)", sqlReleaseTableName, sqlReleaseTableName, sqlReleaseTableNamespaceColumn, sqlReleaseTableKeyColumn))).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("insert into \"gorp_migrations\"").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.
+		ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(sqlMigrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrated, err := sqlDriver.Migrate()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if !reflect.DeepEqual(migrated, []string{"list_order"}) {
+		t.Errorf("Expected the migration list_order to be applied, got %v", migrated)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
@@ -16,6 +16,8 @@ package driver
 import (
 	"reflect"
 	"testing"
+
+	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
 
 func TestGetSystemLabel(t *testing.T) {
@@ -106,3 +108,28 @@ func TestContainsSystemLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeReleaseAnnotations(t *testing.T) {
+	rls := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	data, err := encodeRelease(rls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeRelease(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rls.Annotations, got.Annotations) {
+		t.Errorf("expected annotations %v, got %v", rls.Annotations, got.Annotations)
+	}
+
+	// Releases stored before the annotations decode without them.
+	legacy := b64.EncodeToString([]byte(`{"name":"smug-pigeon","version":1,"namespace":"default"}`))
+	got, err = decodeRelease(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "smug-pigeon" || got.Annotations != nil {
+		t.Errorf("expected a release without annotations, got %+v", got)
+	}
+}
@@ -90,6 +90,30 @@ func (s *Storage) ListReleases() ([]*rspb.Release, error) {
 	return s.List(func(_ *rspb.Release) bool { return true })
 }
 
+// ListWithCorrupt returns the releases satisfying filter, along with the
+// records of releases which cannot be decoded, if the storage driver reports
+// them. An error is returned if the storage backend fails to retrieve the
+// releases.
+func (s *Storage) ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*driver.CorruptReleaseError, error) {
+	if d, ok := s.Driver.(driver.CorruptionHandler); ok {
+		return d.ListWithCorrupt(filter)
+	}
+	rls, err := s.List(filter)
+	return rls, nil, err
+}
+
+// DeleteCorrupt deletes the record stored under key without decoding it. An
+// error is returned if the storage driver cannot delete records this way, or
+// if the record does not exist.
+func (s *Storage) DeleteCorrupt(key string) error {
+	slog.Debug("deleting corrupt release record", "key", key)
+	d, ok := s.Driver.(driver.CorruptionHandler)
+	if !ok {
+		return fmt.Errorf("the %s storage driver cannot delete corrupt release records", s.Name())
+	}
+	return d.DeleteCorrupt(key)
+}
+
 // ListUninstalled returns all releases with Status == UNINSTALLED. An error is returned
 // if the storage backend fails to retrieve the releases.
 func (s *Storage) ListUninstalled() ([]*rspb.Release, error) {
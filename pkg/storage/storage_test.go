@@ -531,6 +531,56 @@ func TestUpgradeInitiallyFailedReleaseWithHistoryLimit(t *testing.T) {
 	}
 }
 
+// corruptMockDriver is a Memory driver also reporting a corrupt record.
+type corruptMockDriver struct {
+	*driver.Memory
+	corrupt []*driver.CorruptReleaseError
+	deleted []string
+}
+
+func (d *corruptMockDriver) ListWithCorrupt(filter func(*rspb.Release) bool) ([]*rspb.Release, []*driver.CorruptReleaseError, error) {
+	rls, err := d.List(filter)
+	return rls, d.corrupt, err
+}
+
+func (d *corruptMockDriver) DeleteCorrupt(key string) error {
+	d.deleted = append(d.deleted, key)
+	return nil
+}
+
+func TestStorageListWithCorrupt(t *testing.T) {
+	storage := Init(driver.NewMemory())
+	rls := ReleaseTestData{Name: "angry-beaver", Version: 1, Status: rspb.StatusDeployed}.ToRelease()
+	assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+
+	// drivers which do not report corrupt records only list the releases
+	list, corrupt, err := storage.ListWithCorrupt(func(*rspb.Release) bool { return true })
+	assertErrNil(t.Fatal, err, "ListWithCorrupt")
+	if len(list) != 1 || len(corrupt) != 0 {
+		t.Errorf("Expected 1 release and no corrupt record, got %d and %d", len(list), len(corrupt))
+	}
+	if err := storage.DeleteCorrupt("sh.helm.release.v1.angry-beaver.v2"); err == nil {
+		t.Errorf("Expected an error deleting a corrupt record with the memory driver")
+	}
+
+	d := &corruptMockDriver{
+		Memory:  driver.NewMemory(),
+		corrupt: []*driver.CorruptReleaseError{{Key: "sh.helm.release.v1.angry-beaver.v2", Name: "angry-beaver", Version: 2}},
+	}
+	storage = Init(d)
+	assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+
+	list, corrupt, err = storage.ListWithCorrupt(func(*rspb.Release) bool { return true })
+	assertErrNil(t.Fatal, err, "ListWithCorrupt")
+	if len(list) != 1 || !reflect.DeepEqual(d.corrupt, corrupt) {
+		t.Errorf("Expected 1 release and the corrupt record, got %d and %v", len(list), corrupt)
+	}
+	assertErrNil(t.Fatal, storage.DeleteCorrupt(corrupt[0].Key), "DeleteCorrupt")
+	if !reflect.DeepEqual([]string{corrupt[0].Key}, d.deleted) {
+		t.Errorf("Expected the corrupt record to be deleted, got %v", d.deleted)
+	}
+}
+
 type ReleaseTestData struct {
 	Name      string
 	Version   int
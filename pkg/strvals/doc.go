@@ -29,5 +29,27 @@ The above is equivalent to the YAML document
 
 This package provides a parser and utilities for converting the strvals format
 to other formats.
+
+The values parsed into a destination map are merged with it: a key overwrites
+the value it has in the destination, and the keys it does not set are kept.
+Lists are merged by index, and an index past the end of a list pads it with
+nulls, unless the line is parsed with ParseStrict, which refuses it.
+
+A null value has a different meaning depending on where it is set:
+
+  - name=null keeps the key with a null value, which removes it from the
+    values of the chart when they are coalesced with them.
+  - list[1]=null removes the element from the list and shifts the elements
+    after it, as the lists replace the ones of the chart as a whole and a
+    null element would be kept.
+  - list[1].name=null deletes the key from the element of the list, for the
+    same reason.
+
+The lists are not merged with the ones of the chart, so the elements can only
+be deleted from the lists of the destination map, such as the ones of the
+values files. The elements are deleted in the order of the line, so
+list[1]=null,list[1]=null removes the second and the third elements.
+ParseString sets the string "null" instead, and ParseJSON keeps the null values
+in the lists.
 */
 package strvals
@@ -271,6 +271,26 @@ func TestParseLiteral(t *testing.T) {
 			str:    `name=em%GT)tqUDqz,i-\h+Mbqs-!:.m\\rE=mkbM#rR}@{-k@`,
 			expect: map[string]interface{}{"name": `em%GT)tqUDqz,i-\h+Mbqs-!:.m\\rE=mkbM#rR}@{-k@`},
 		},
+		{
+			str:    "db.dsn=host=db,port=5432,sslmode=require",
+			expect: map[string]interface{}{"db": map[string]interface{}{"dsn": "host=db,port=5432,sslmode=require"}},
+		},
+		{
+			str:    `config={"a": [1, 2], "b": {"c": "d"}}`,
+			expect: map[string]interface{}{"config": `{"a": [1, 2], "b": {"c": "d"}}`},
+		},
+		{
+			str:    "motd=line one\nline two\n",
+			expect: map[string]interface{}{"motd": "line one\nline two\n"},
+		},
+		{
+			str:    "greeting=héllo, 世界 🚀",
+			expect: map[string]interface{}{"greeting": "héllo, 世界 🚀"},
+		},
+		{
+			str:    "hosts[1]=a.example.com,b.example.com",
+			expect: map[string]interface{}{"hosts": []interface{}{nil, "a.example.com,b.example.com"}},
+		},
 	}
 
 	for _, tt := range cases {
@@ -60,6 +60,18 @@ func Parse(s string) (map[string]interface{}, error) {
 	return vals, err
 }
 
+// ParseStrict parses a set line like Parse, but returns an error for a list
+// index past the end of the list, or deleting an element which does not
+// exist, instead of padding the list with nulls.
+func ParseStrict(s string) (map[string]interface{}, error) {
+	vals := map[string]interface{}{}
+	scanner := bytes.NewBufferString(s)
+	t := newParser(scanner, vals, false)
+	t.strict = true
+	err := t.parse()
+	return vals, err
+}
+
 // ParseString parses a set line and forces a string value.
 //
 // A set line is of the form name1=value1,name2=value2
@@ -138,6 +150,10 @@ type parser struct {
 	data      map[string]interface{}
 	reader    RunesValueReader
 	isjsonval bool
+	// strict refuses the list indices past the end of the lists.
+	strict bool
+	// inList is the number of list elements the key being parsed is in.
+	inList int
 }
 
 func newParser(sc *bytes.Buffer, data map[string]interface{}, stringBool bool) *parser {
@@ -201,12 +217,18 @@ func (t *parser) key(data map[string]interface{}, nestedNameLevel int) (reterr e
 			kk := string(k)
 			// Find or create target list
 			list := []interface{}{}
-			if _, ok := data[kk]; ok {
+			_, existed := data[kk]
+			if existed {
 				list = data[kk].([]interface{})
 			}
 
 			// Now we need to get the value after the ].
 			list, err = t.listItem(list, i, nestedNameLevel)
+			// Deleting an element of a list which does not exist does not
+			// create it, as an empty list would replace the one of the chart.
+			if err == nil && !existed && len(list) == 0 {
+				return nil
+			}
 			set(data, kk, list)
 			return err
 		case last == '=':
@@ -253,7 +275,7 @@ func (t *parser) key(data map[string]interface{}, nestedNameLevel int) (reterr e
 					return e
 				}
 				v, e := t.reader(rs)
-				set(data, string(k), v)
+				t.setValue(data, string(k), v)
 				return e
 			default:
 				return e
@@ -271,16 +293,19 @@ func (t *parser) key(data map[string]interface{}, nestedNameLevel int) (reterr e
 
 			// First, create or find the target map.
 			inner := map[string]interface{}{}
-			if _, ok := data[string(k)]; ok {
+			_, existed := data[string(k)]
+			if existed {
 				inner = data[string(k)].(map[string]interface{})
 			}
 
 			// Recurse
 			e := t.key(inner, nestedNameLevel)
-			if e == nil && len(inner) == 0 {
+			// Within a list element, deleting the last key of a map leaves
+			// it empty, and deleting a key which does not exist does nothing.
+			if e == nil && len(inner) == 0 && t.inList == 0 {
 				return fmt.Errorf("key map %q has no value", string(k))
 			}
-			if len(inner) != 0 {
+			if len(inner) != 0 || existed {
 				set(data, string(k), inner)
 			}
 			return e
@@ -296,6 +321,41 @@ func set(data map[string]interface{}, key string, val interface{}) {
 	data[key] = val
 }
 
+// setValue sets key to val in data. A null value is kept in the maps, so that
+// it removes the key from the values of the chart when they are coalesced.
+// The lists replace the ones of the chart as a whole instead, so within a list
+// element a null value deletes the key right away.
+func (t *parser) setValue(data map[string]interface{}, key string, val interface{}) {
+	if val == nil && t.inList > 0 {
+		delete(data, key)
+		return
+	}
+	set(data, key, val)
+}
+
+// setIndex sets the element at index in list, refusing in strict mode an
+// index past the end of the list.
+func (t *parser) setIndex(list []interface{}, index int, val interface{}) ([]interface{}, error) {
+	if t.strict && index > len(list) {
+		return list, fmt.Errorf("index %d is out of range of a list of %d elements", index, len(list))
+	}
+	return setIndex(list, index, val)
+}
+
+// deleteIndex removes the element at index from list, shifting the elements
+// after it. Deleting an element past the end of the list does nothing, or is
+// an error in strict mode.
+func (t *parser) deleteIndex(list []interface{}, index int) ([]interface{}, error) {
+	if index < len(list) {
+		// Copy the list, which may be shared with the destination values.
+		return append(list[:index:index], list[index+1:]...), nil
+	}
+	if t.strict {
+		return list, fmt.Errorf("cannot delete index %d of a list of %d elements", index, len(list))
+	}
+	return list, nil
+}
+
 func setIndex(list []interface{}, index int, val interface{}) (l2 []interface{}, err error) {
 	// There are possible index values that are out of range on a target system
 	// causing a panic. This will catch the panic and return an error instead.
@@ -350,7 +410,7 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 				return list, err
 			}
 			if empval {
-				return setIndex(list, i, nil)
+				return t.setIndex(list, i, nil)
 			}
 			// parse jsonvals by using Go’s JSON standard library
 			// Decode is preferred to Unmarshal in order to parse just the json parts of the list key1=jsonval1,key2=jsonval2,...
@@ -362,7 +422,7 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 			if err = dec.Decode(&jsonval); err != nil {
 				return list, err
 			}
-			if list, err = setIndex(list, i, jsonval); err != nil {
+			if list, err = t.setIndex(list, i, jsonval); err != nil {
 				return list, err
 			}
 			if _, err = io.CopyN(io.Discard, t.sc, dec.InputOffset()); err != nil {
@@ -375,9 +435,9 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 		vl, e := t.valList()
 		switch e {
 		case nil:
-			return setIndex(list, i, vl)
+			return t.setIndex(list, i, vl)
 		case io.EOF:
-			return setIndex(list, i, "")
+			return t.setIndex(list, i, "")
 		case ErrNotList:
 			rs, e := t.val()
 			if e != nil && e != io.EOF {
@@ -387,7 +447,11 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 			if e != nil {
 				return list, e
 			}
-			return setIndex(list, i, v)
+			// A null element would be kept as such, so it is deleted.
+			if v == nil {
+				return t.deleteIndex(list, i)
+			}
+			return t.setIndex(list, i, v)
 		default:
 			return list, e
 		}
@@ -410,7 +474,7 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 		if err != nil {
 			return list, err
 		}
-		return setIndex(list, i, list2)
+		return t.setIndex(list, i, list2)
 	case last == '.':
 		// We have a nested object. Send to t.key
 		inner := map[string]interface{}{}
@@ -425,11 +489,13 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 		}
 
 		// Recurse
+		t.inList++
 		e := t.key(inner, nestedNameLevel)
+		t.inList--
 		if e != nil {
 			return list, e
 		}
-		return setIndex(list, i, inner)
+		return t.setIndex(list, i, inner)
 	default:
 		return nil, fmt.Errorf("parse error: unexpected token %v", last)
 	}
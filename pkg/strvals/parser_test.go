@@ -816,3 +816,242 @@ func TestParseSetNestedLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestParseIntoNull(t *testing.T) {
+	dest := func() map[string]interface{} {
+		return map[string]interface{}{
+			"list": []interface{}{"a", "b", "c"},
+			"objs": []interface{}{
+				map[string]interface{}{"name": "x", "port": 80, "tls": map[string]interface{}{"enabled": true}},
+				map[string]interface{}{"name": "y", "port": 81},
+			},
+			"nested": []interface{}{[]interface{}{1, 2, 3}},
+			"map":    map[string]interface{}{"key": "value", "other": "value"},
+		}
+	}
+	tests := []struct {
+		name   string
+		input  string
+		expect map[string]interface{}
+	}{
+		{
+			name:   "null element is deleted and the rest shifted",
+			input:  "list[1]=null",
+			expect: map[string]interface{}{"list": []interface{}{"a", "c"}},
+		},
+		{
+			name:   "first element",
+			input:  "list[0]=NULL",
+			expect: map[string]interface{}{"list": []interface{}{"b", "c"}},
+		},
+		{
+			name:   "last element",
+			input:  "list[2]=null",
+			expect: map[string]interface{}{"list": []interface{}{"a", "b"}},
+		},
+		{
+			name:   "deletions apply in order",
+			input:  "list[1]=null,list[1]=null",
+			expect: map[string]interface{}{"list": []interface{}{"a"}},
+		},
+		{
+			name:   "all elements",
+			input:  "list[0]=null,list[0]=null,list[0]=null",
+			expect: map[string]interface{}{"list": []interface{}{}},
+		},
+		{
+			name:   "element past the end is left alone",
+			input:  "list[5]=null",
+			expect: map[string]interface{}{"list": []interface{}{"a", "b", "c"}},
+		},
+		{
+			name:   "set after a deletion",
+			input:  "list[0]=null,list[1]=z",
+			expect: map[string]interface{}{"list": []interface{}{"b", "z"}},
+		},
+		{
+			name:  "key of an element",
+			input: "objs[0].port=null",
+			expect: map[string]interface{}{"objs": []interface{}{
+				map[string]interface{}{"name": "x", "tls": map[string]interface{}{"enabled": true}},
+				map[string]interface{}{"name": "y", "port": 81},
+			}},
+		},
+		{
+			name:  "nested key of an element",
+			input: "objs[0].tls.enabled=null",
+			expect: map[string]interface{}{"objs": []interface{}{
+				map[string]interface{}{"name": "x", "port": 80, "tls": map[string]interface{}{}},
+				map[string]interface{}{"name": "y", "port": 81},
+			}},
+		},
+		{
+			name:  "missing key of an element",
+			input: "objs[1].tls.enabled=null,objs[1].missing=null",
+			expect: map[string]interface{}{"objs": []interface{}{
+				map[string]interface{}{"name": "x", "port": 80, "tls": map[string]interface{}{"enabled": true}},
+				map[string]interface{}{"name": "y", "port": 81},
+			}},
+		},
+		{
+			name:  "element of an object list",
+			input: "objs[0]=null",
+			expect: map[string]interface{}{"objs": []interface{}{
+				map[string]interface{}{"name": "y", "port": 81},
+			}},
+		},
+		{
+			name:   "element of a nested list",
+			input:  "nested[0][1]=null",
+			expect: map[string]interface{}{"nested": []interface{}{[]interface{}{1, 3}}},
+		},
+		{
+			name:   "key of a map is kept as null",
+			input:  "map.key=null",
+			expect: map[string]interface{}{"map": map[string]interface{}{"key": nil, "other": "value"}},
+		},
+		{
+			name:   "new list",
+			input:  "fresh[0]=a,fresh[1]=null",
+			expect: map[string]interface{}{"fresh": []interface{}{"a"}},
+		},
+		{
+			name:   "missing list is not created",
+			input:  "missing[0]=null",
+			expect: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dest()
+			if err := ParseInto(tt.input, got); err != nil {
+				t.Fatalf("%s: %s", tt.input, err)
+			}
+			want := dest()
+			for k, v := range tt.expect {
+				want[k] = v
+			}
+
+			y1, err := yaml.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			y2, err := yaml.Marshal(got)
+			if err != nil {
+				t.Fatalf("Error serializing parsed value: %s", err)
+			}
+			if string(y1) != string(y2) {
+				t.Errorf("%s: Expected:\n%s\nGot:\n%s", tt.input, y1, y2)
+			}
+		})
+	}
+}
+
+func TestParseIntoNullLeavesDestinationList(t *testing.T) {
+	list := []interface{}{"a", "b", "c"}
+	got := map[string]interface{}{"list": list}
+	if err := ParseInto("list[0]=null", got); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(list) != "[a b c]" {
+		t.Errorf("Expected the original list to be left alone, got %v", list)
+	}
+	if fmt.Sprint(got["list"]) != "[b c]" {
+		t.Errorf("Expected [b c], got %v", got["list"])
+	}
+}
+
+func TestParseNullKeepsStringsAndJSON(t *testing.T) {
+	got := map[string]interface{}{"list": []interface{}{"a", "b"}}
+	if err := ParseIntoString("list[1]=null", got); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got["list"]) != "[a null]" {
+		t.Errorf("Expected the string null to be set, got %v", got["list"])
+	}
+
+	got = map[string]interface{}{"list": []interface{}{"a", "b"}}
+	if err := ParseJSON("list[1]=null", got); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got["list"]) != "[a <nil>]" {
+		t.Errorf("Expected a null element to be set, got %v", got["list"])
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		str    string
+		expect map[string]interface{}
+		errStr string
+	}{
+		{
+			str:    "list[0]=a,list[1]=b",
+			expect: map[string]interface{}{"list": []interface{}{"a", "b"}},
+		},
+		{
+			str:    "list[0]=a,list[0]=b",
+			expect: map[string]interface{}{"list": []interface{}{"b"}},
+		},
+		{
+			str:    "list[0]=a,list[1]=b,list[0]=null",
+			expect: map[string]interface{}{"list": []interface{}{"b"}},
+		},
+		{
+			str:    "list[0].name=a,list[1].name=b",
+			expect: map[string]interface{}{"list": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}}},
+		},
+		{
+			str:    "list[0]=a,list[3]=b",
+			errStr: "index 3 is out of range of a list of 1 elements",
+		},
+		{
+			str:    "list[1]=a",
+			errStr: "index 1 is out of range of a list of 0 elements",
+		},
+		{
+			str:    "list[2].name=a",
+			errStr: "index 2 is out of range of a list of 0 elements",
+		},
+		{
+			str:    "nested[0][1]=a",
+			errStr: "index 1 is out of range of a list of 0 elements",
+		},
+		{
+			str:    "list[0]=a,list[1]=null",
+			errStr: "cannot delete index 1 of a list of 1 elements",
+		},
+		{
+			str:    "list[-1]=a",
+			errStr: "negative -1 index not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStrict(tt.str)
+		if tt.errStr != "" {
+			if err == nil {
+				t.Errorf("%s: Expected error %q. Got nil", tt.str, tt.errStr)
+			} else if err.Error() != tt.errStr {
+				t.Errorf("%s: Expected error %q. Got %q", tt.str, tt.errStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", tt.str, err)
+		}
+
+		y1, err := yaml.Marshal(tt.expect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y2, err := yaml.Marshal(got)
+		if err != nil {
+			t.Fatalf("Error serializing parsed value: %s", err)
+		}
+		if string(y1) != string(y2) {
+			t.Errorf("%s: Expected:\n%s\nGot:\n%s", tt.str, y1, y2)
+		}
+	}
+}